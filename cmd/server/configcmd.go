@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// newConfigCommand builds "server config validate" and "server config
+// print". Both load the configuration exactly like the server, from the
+// config file and the environment
+func newConfigCommand(opts *rootOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Check or show the configuration without starting anything",
+	}
+
+	validateCmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Check that the configuration loads and is valid",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := opts.loadConfig(); err != nil {
+				return fmt.Errorf("configuration is invalid: %w", err)
+			}
+			fmt.Println("Configuration is valid")
+			return nil
+		},
+	}
+
+	var format string
+	printCmd := &cobra.Command{
+		Use:   "print",
+		Short: "Print the effective configuration with secrets masked",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := opts.loadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load configuration: %w", err)
+			}
+			if err := printConfig(cfg, format); err != nil {
+				return fmt.Errorf("failed to print configuration: %w", err)
+			}
+			return nil
+		},
+	}
+	printCmd.Flags().StringVar(&format, "format", "yaml", "output format (yaml|json)")
+
+	cmd.AddCommand(validateCmd, printCmd)
+	return cmd
+}
+
+// printConfig writes the effective configuration to stdout with secrets
+// masked
+func printConfig(cfg *config.Config, format string) error {
+	redacted := config.Redacted(cfg)
+
+	switch format {
+	case "yaml":
+		encoder := yaml.NewEncoder(os.Stdout)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(redacted); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(redacted)
+	}
+	return fmt.Errorf("unknown format %q", format)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/encryption"
+	"github.com/spf13/cobra"
+)
+
+// encryptionCommandTimeout bounds re-encrypting all secret columns
+const encryptionCommandTimeout = 10 * time.Minute
+
+// newEncryptionCommand builds "server encryption generate-key|rotate", which
+// create column encryption keys and re-encrypt the stored secrets after a
+// new key was made active or encryption was enabled
+func newEncryptionCommand(opts *rootOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "encryption",
+		Short: "Manage the encryption of sensitive database columns",
+	}
+
+	var version string
+	generate := &cobra.Command{
+		Use:   "generate-key",
+		Short: "Print a new random key as a version:key pair for database.encryption.key_secrets",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := encryption.GenerateKey()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s:%s\n", version, key)
+			return nil
+		},
+	}
+	generate.Flags().StringVar(&version, "version", "", "version the key is stored under")
+	generate.MarkFlagRequired("version")
+
+	rotate := &cobra.Command{
+		Use:   "rotate",
+		Short: "Re-encrypt the secrets not encrypted with the active key",
+		Long: "Re-encrypt the secrets stored in plaintext or with another key than\n" +
+			"database.encryption.active_key. Older keys can be removed from key_secrets\n" +
+			"once it has run against the servers using the new active key.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := opts.loadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load configuration: %w", err)
+			}
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), encryptionCommandTimeout)
+			defer cancel()
+
+			store, _, err := openDatabase(ctx, cfg, "Rotating encryption keys")
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			rotated, err := store.RotateEncryption(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to rotate encryption keys after %d secrets: %w", rotated, err)
+			}
+			fmt.Printf("Re-encrypted %d secrets with key %s\n", rotated, cfg.Database.Encryption.ActiveKey)
+			return nil
+		},
+	}
+
+	cmd.AddCommand(generate, rotate)
+	return cmd
+}
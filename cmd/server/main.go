@@ -1,13 +1,12 @@
 package main
 
 import (
-	"flag"
 	"fmt"
-	"log"
 	"os"
 	"runtime"
 
 	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/spf13/cobra"
 )
 
 var (
@@ -17,39 +16,82 @@ var (
 )
 
 func main() {
-	// Parse command line flags
-	var (
-		configPath = flag.String("config", "", "path to configuration file")
-		envPath    = flag.String("env", "development", "Environment (development|production)")
-		showVer    = flag.Bool("version", false, "show version information")
-	)
+	if err := newRootCommand().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
 
-	flag.Parse()
+// rootOptions holds the persistent flags shared by every subcommand
+type rootOptions struct {
+	configPath string
+	env        string
+	logLevel   string
+}
 
-	// show version information
-	if *showVer {
-		showVersion()
-		return
-	}
+// loadConfig loads the configuration selected by the persistent flags
+func (o *rootOptions) loadConfig() (*config.Config, error) {
+	return LoadConfig(o.configPath, o.env)
+}
 
-	cfg, err := LoadConfig(*configPath, *envPath)
+// newRootCommand builds the command tree. Without a subcommand the API
+// server is started, like "server serve"
+func newRootCommand() *cobra.Command {
+	opts := &rootOptions{}
 
-	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+	root := &cobra.Command{
+		Use:          "server",
+		Short:        "Todo API server and management commands",
+		Version:      version,
+		SilenceUsage: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// Going through the environment applies the level on every
+			// reload and runs it through the configuration validation
+			if opts.logLevel != "" {
+				return os.Setenv("LOG_LEVEL", opts.logLevel)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return serve(opts)
+		},
 	}
+	root.SetVersionTemplate(versionInfo())
 
-	logger := initLogger(cfg)
-	logger.Printf("Starting Todo API %s in %s mode", version, *envPath)
+	flags := root.PersistentFlags()
+	flags.StringVar(&opts.configPath, "config", "", "path to configuration file")
+	flags.StringVar(&opts.env, "env", "development", "environment (development|production)")
+	flags.StringVar(&opts.logLevel, "log-level", "", "log level (debug|info|warn|error), overrides LOG_LEVEL")
 
-	
+	root.AddCommand(
+		newServeCommand(opts),
+		newWorkerCommand(opts),
+		newMigrateCommand(opts),
+		newSeedCommand(opts),
+		newRoutesCommand(opts),
+		newConfigCommand(opts),
+		newUserCommand(opts),
+		newTokenCommand(opts),
+		newEncryptionCommand(opts),
+		newHealthcheckCommand(opts),
+		newVersionCommand(),
+	)
+	return root
+}
+
+func newVersionCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "Show version information",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Print(versionInfo())
+		},
+	}
 }
 
-func showVersion() {
-	fmt.Printf("Todo API: %s\n", version)
-	fmt.Printf("Build Time: %s\n", buildTime)
-	fmt.Printf("Git Commit: %s\n", gitCommit)
-	fmt.Printf("Go Version: %s\n", runtime.Version())
-	fmt.Printf("OS/Arch: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+func versionInfo() string {
+	return fmt.Sprintf("Todo API: %s\nBuild Time: %s\nGit Commit: %s\nGo Version: %s\nOS/Arch: %s/%s\n",
+		version, buildTime, gitCommit, runtime.Version(), runtime.GOOS, runtime.GOARCH)
 }
 
 // Load Configuration
@@ -59,11 +101,3 @@ func LoadConfig(configPath, env string) (*config.Config, error) {
 	}
 	return config.LoadForEnvironment(env)
 }
-
-func initLogger(cfg *config.Config) *log.Logger {
-	flags := log.LstdFlags
-	if cfg.Server.IsDevelopment() {
-		flags |= log.Lshortfile
-	}
-	return log.New(os.Stdout, "[TODO-API]", flags)
-}
\ No newline at end of file
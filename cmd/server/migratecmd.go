@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage/postgres"
+	"github.com/spf13/cobra"
+)
+
+// migrateCommandTimeout bounds applying all pending migrations
+const migrateCommandTimeout = 10 * time.Minute
+
+func newMigrateCommand(opts *rootOptions) *cobra.Command {
+	var (
+		dir    string
+		status bool
+	)
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply pending database migrations",
+		Long: "Apply the SQL files of the migrations directory that have not been applied yet,\n" +
+			"in name order. Applied files are recorded in the schema_migrations table.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := opts.loadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load configuration: %w", err)
+			}
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), migrateCommandTimeout)
+			defer cancel()
+
+			store, _, err := openDatabase(ctx, cfg, "Migrating")
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			migrations := os.DirFS(dir)
+			if status {
+				pending, err := store.PendingMigrations(ctx, migrations)
+				if err != nil {
+					return err
+				}
+				if len(pending) == 0 {
+					fmt.Println("Database is up to date")
+				}
+				for _, name := range pending {
+					fmt.Printf("Pending %s\n", name)
+				}
+				return nil
+			}
+
+			applied, err := store.Migrate(ctx, migrations)
+			for _, name := range applied {
+				fmt.Printf("Applied %s\n", name)
+			}
+			if err != nil {
+				return err
+			}
+			if len(applied) == 0 {
+				fmt.Println("Database is up to date")
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&dir, "dir", "migrations", "directory holding the migration files")
+	cmd.Flags().BoolVar(&status, "status", false, "list pending migrations without applying them")
+	return cmd
+}
+
+// openDatabase connects to the postgres database for a management command,
+// the audit logger is nil unless auditing is enabled. purpose names the
+// command in the error for the memory driver, which keeps nothing between
+// runs
+func openDatabase(ctx context.Context, cfg *config.Config, purpose string) (*postgres.Store, *service.AuditLogger, error) {
+	if cfg.Database.Driver == "memory" {
+		return nil, nil, fmt.Errorf("%s needs the postgres database driver, the memory driver keeps no data between runs", purpose)
+	}
+
+	log := logger.NewNop()
+	store, err := postgres.New(ctx, &cfg.Database, nil, log)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to the database: %w", err)
+	}
+
+	var audit *service.AuditLogger
+	if cfg.Audit.Enabled {
+		audit = service.NewAuditLogger(store.Audit(), cfg.Pagination, log)
+	}
+	return store, audit, nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/app"
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+	"github.com/spf13/cobra"
+)
+
+// newRoutesCommand lists the registered routes and their middleware. The
+// routers are built from the configuration without starting the server, so
+// the listing shows exactly what it would expose
+func newRoutesCommand(opts *rootOptions) *cobra.Command {
+	var format string
+	cmd := &cobra.Command{
+		Use:   "routes",
+		Short: "List the registered routes and their middleware",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != "table" && format != "json" {
+				return fmt.Errorf("unknown format %q, expected table or json", format)
+			}
+			cfg, err := opts.loadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load configuration: %w", err)
+			}
+			return listRoutes(cfg, format)
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", "table", "output format (table|json)")
+	return cmd
+}
+
+// listRoutes prints the routes of the servers built from cfg
+func listRoutes(cfg *config.Config, format string) error {
+	routes, err := app.New(cfg, logger.NewNop(), version).ListRoutes()
+	if err != nil {
+		return fmt.Errorf("failed to build routes: %w", err)
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(routes); err != nil {
+			return fmt.Errorf("failed to print routes: %w", err)
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SERVER\tMETHOD\tPATH\tHANDLER\tMIDDLEWARE")
+	for _, route := range routes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", route.Server, route.Method, route.Path, route.Handler,
+			strings.Join(route.Middleware, " > "))
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to print routes: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// seedCommandTimeout bounds the database work of the seed command
+const seedCommandTimeout = 5 * time.Minute
+
+// seedFixture is the content of a seed file
+type seedFixture struct {
+	Users []seedUser `yaml:"users" json:"users"`
+}
+
+// seedUser is an account created by the seed command together with its todos
+type seedUser struct {
+	Email    string     `yaml:"email" json:"email"`
+	Name     string     `yaml:"name" json:"name"`
+	Password string     `yaml:"password" json:"password"`
+	Admin    bool       `yaml:"admin" json:"admin"`
+	Todos    []seedTodo `yaml:"todos" json:"todos"`
+}
+
+// seedTodo is a todo created by the seed command, subtasks are created
+// below it
+type seedTodo struct {
+	Title       string     `yaml:"title" json:"title"`
+	Description string     `yaml:"description" json:"description"`
+	Completed   bool       `yaml:"completed" json:"completed"`
+	DueDate     *time.Time `yaml:"due_date" json:"due_date"`
+	Priority    string     `yaml:"priority" json:"priority"`
+	Tags        []string   `yaml:"tags" json:"tags"`
+	Subtasks    []seedTodo `yaml:"subtasks" json:"subtasks"`
+}
+
+// newSeedCommand loads users and todos from fixtures for reproducible
+// development and demo environments. They go through the services like API
+// requests do, so passwords are hashed and every field is validated. With
+// --reset the users and todos already in the database are removed first
+func newSeedCommand(opts *rootOptions) *cobra.Command {
+	var (
+		file  string
+		reset bool
+	)
+	cmd := &cobra.Command{
+		Use:   "seed",
+		Short: "Load users and todos from a YAML or JSON fixture file",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fixture, err := loadFixture(file)
+			if err != nil {
+				return fmt.Errorf("failed to load fixtures: %w", err)
+			}
+			cfg, err := opts.loadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load configuration: %w", err)
+			}
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), seedCommandTimeout)
+			defer cancel()
+
+			store, audit, err := openDatabase(ctx, cfg, "Seeding")
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			if reset {
+				if err := store.Truncate(ctx); err != nil {
+					return fmt.Errorf("failed to reset the database: %w", err)
+				}
+				fmt.Println("Removed all users and todos")
+			}
+
+			users := service.NewUserAdmin(store.Auth(), nil, &cfg.Security, audit)
+			todos := service.NewTodoService(store.Todos(), cfg.Todos, cfg.Pagination, audit, nil, nil, nil)
+			todoCount, err := seed(ctx, users, todos, fixture)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Seeded %d users and %d todos from %s\n", len(fixture.Users), todoCount, file)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&file, "file", "", "YAML or JSON fixture file to load")
+	cmd.Flags().BoolVar(&reset, "reset", false, "remove all users and todos before seeding")
+	cmd.MarkFlagRequired("file")
+	return cmd
+}
+
+// seed creates the users of the fixture with their todos and returns how
+// many todos were created
+func seed(ctx context.Context, users *service.UserAdmin, todos *service.TodoService, fixture *seedFixture) (int, error) {
+	var todoCount int
+	for _, u := range fixture.Users {
+		role := models.RoleUser
+		if u.Admin {
+			role = models.RoleAdmin
+		}
+		user, err := users.CreateUser(ctx, service.CreateUserInput{Email: u.Email, Name: u.Name, Password: u.Password, Role: role})
+		if err != nil {
+			if errors.Is(err, storage.ErrConflict) {
+				return todoCount, fmt.Errorf("a user with email %s already exists, use --reset to start from an empty database", u.Email)
+			}
+			return todoCount, fmt.Errorf("failed to create user %s: %w", u.Email, err)
+		}
+
+		n, err := seedTodos(ctx, todos, user.ID, nil, u.Todos)
+		todoCount += n
+		if err != nil {
+			return todoCount, fmt.Errorf("failed to create todos of user %s: %w", u.Email, err)
+		}
+	}
+	return todoCount, nil
+}
+
+// seedTodos creates the todos below the parent, or at the top level when
+// parentID is nil, and returns how many were created including subtasks
+func seedTodos(ctx context.Context, todos *service.TodoService, userID int64, parentID *int64, fixtures []seedTodo) (int, error) {
+	var count int
+	for _, t := range fixtures {
+		todo, err := todos.Create(ctx, userID, service.TodoInput{
+			ParentID:    parentID,
+			Title:       t.Title,
+			Description: t.Description,
+			Completed:   t.Completed,
+			DueDate:     t.DueDate,
+			Priority:    t.Priority,
+			Tags:        t.Tags,
+		})
+		if err != nil {
+			return count, fmt.Errorf("todo %q: %w", t.Title, err)
+		}
+		count++
+
+		n, err := seedTodos(ctx, todos, userID, &todo.ID, t.Subtasks)
+		count += n
+		if err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+// loadFixture reads a fixture file, files ending in .json are decoded as
+// JSON and everything else as YAML. Unknown fields are rejected so typos
+// do not silently drop data
+func loadFixture(path string) (*seedFixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var fixture seedFixture
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&fixture)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&fixture)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &fixture, nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/app"
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+	"github.com/spf13/cobra"
+)
+
+func newServeCommand(opts *rootOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "serve",
+		Short: "Start the API server",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return serve(opts)
+		},
+	}
+}
+
+// newWorkerCommand processes queued tasks instead of serving the API
+func newWorkerCommand(opts *rootOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "worker",
+		Short: "Process queued tasks instead of serving the API",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			application, log, closeLog, err := newApplication(opts)
+			if err != nil {
+				return err
+			}
+			defer closeLog()
+
+			log.Info("starting Todo API worker", "version", version, "environment", opts.env)
+			if err := application.RunWorker(); err != nil {
+				log.Error("worker error", "error", err)
+				return err
+			}
+			return nil
+		},
+	}
+}
+
+// serve runs the API server until it is shut down, reloading the
+// configuration when it changes
+func serve(opts *rootOptions) error {
+	application, log, closeLog, err := newApplication(opts)
+	if err != nil {
+		return err
+	}
+	defer closeLog()
+
+	log.Info("starting Todo API", "version", version, "environment", opts.env)
+	application.WatchConfig(func() (*config.Config, error) {
+		return opts.loadConfig()
+	})
+	if err := application.Run(); err != nil {
+		log.Error("application error", "error", err)
+		return err
+	}
+
+	log.Info("Todo API stopped")
+	return nil
+}
+
+// newApplication loads the configuration and creates the logger and the
+// application, closeLog flushes the logger
+func newApplication(opts *rootOptions) (*app.App, logger.Logger, func(), error) {
+	cfg, err := opts.loadConfig()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	log, closer, err := logger.New(&cfg.Logger)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	return app.New(cfg, log, version), log, func() { closer.Close() }, nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/cache"
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"github.com/MuthuM3/gin-microservice-template/internal/session"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// userCommandTimeout bounds the database work of a user command
+const userCommandTimeout = 30 * time.Second
+
+// newUserCommand builds "server user create|set-password|promote", which
+// manage accounts directly in the database for bootstrapping the first
+// admin or recovering access. Passwords are read from stdin so they stay
+// out of the process list and shell history
+func newUserCommand(opts *rootOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "user",
+		Short: "Manage user accounts directly in the database",
+	}
+
+	var (
+		createEmail string
+		name        string
+		admin       bool
+	)
+	create := &cobra.Command{
+		Use:   "create",
+		Short: "Create a user with a verified email, reading the password from stdin",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runUserAdmin(cmd, opts, createEmail, func(ctx context.Context, users *service.UserAdmin) (string, error) {
+				password, err := readPassword()
+				if err != nil {
+					return "", err
+				}
+				role := models.RoleUser
+				if admin {
+					role = models.RoleAdmin
+				}
+				user, err := users.CreateUser(ctx, service.CreateUserInput{Email: createEmail, Name: name, Password: password, Role: role})
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("Created user %d (%s) with role %s", user.ID, user.Email, user.Role), nil
+			})
+		},
+	}
+	create.Flags().StringVar(&createEmail, "email", "", "email of the user")
+	create.Flags().StringVar(&name, "name", "", "name of the user")
+	create.Flags().BoolVar(&admin, "admin", false, "give the user the admin role")
+	create.MarkFlagRequired("email")
+
+	var passwordEmail string
+	setPassword := &cobra.Command{
+		Use:   "set-password",
+		Short: "Replace a user's password, reading it from stdin, and revoke their sessions",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runUserAdmin(cmd, opts, passwordEmail, func(ctx context.Context, users *service.UserAdmin) (string, error) {
+				password, err := readPassword()
+				if err != nil {
+					return "", err
+				}
+				user, err := users.SetPassword(ctx, passwordEmail, password)
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("Changed the password of user %d (%s), their sessions were revoked", user.ID, user.Email), nil
+			})
+		},
+	}
+	setPassword.Flags().StringVar(&passwordEmail, "email", "", "email of the user")
+	setPassword.MarkFlagRequired("email")
+
+	var promoteEmail string
+	promote := &cobra.Command{
+		Use:   "promote",
+		Short: "Give a user the admin role",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runUserAdmin(cmd, opts, promoteEmail, func(ctx context.Context, users *service.UserAdmin) (string, error) {
+				user, err := users.SetRole(ctx, promoteEmail, models.RoleAdmin)
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("User %d (%s) now has role %s", user.ID, user.Email, user.Role), nil
+			})
+		},
+	}
+	promote.Flags().StringVar(&promoteEmail, "email", "", "email of the user")
+	promote.MarkFlagRequired("email")
+
+	cmd.AddCommand(create, setPassword, promote)
+	return cmd
+}
+
+// runUserAdmin runs a user command against the database and prints its
+// message
+func runUserAdmin(cmd *cobra.Command, opts *rootOptions, email string, run func(ctx context.Context, users *service.UserAdmin) (string, error)) error {
+	cfg, err := opts.loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), userCommandTimeout)
+	defer cancel()
+
+	store, audit, err := openDatabase(ctx, cfg, "User commands")
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	// Revoking sessions ends the cookie sessions in Redis too
+	var sessions session.Store
+	if cfg.Auth.Sessions.Enabled {
+		client, err := cache.NewRedisClient(ctx, &cfg.Redis)
+		if err != nil {
+			return fmt.Errorf("failed to connect to redis: %w", err)
+		}
+		defer client.Close()
+		sessions = session.NewRedisStore(client, cfg.Cache.KeyPrefix)
+	}
+
+	message, err := run(ctx, service.NewUserAdmin(store.Auth(), sessions, &cfg.Security, audit))
+	switch {
+	case errors.Is(err, storage.ErrConflict):
+		return fmt.Errorf("a user with email %s already exists", email)
+	case errors.Is(err, storage.ErrNotFound):
+		return fmt.Errorf("no user with email %s", email)
+	case err != nil:
+		return fmt.Errorf("failed to %s user: %w", cmd.Name(), err)
+	}
+
+	fmt.Println(message)
+	return nil
+}
+
+// readPassword reads the password from the first line of stdin, prompting
+// for it when stdin is a terminal
+func readPassword() (string, error) {
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprint(os.Stderr, "Password: ")
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", errors.New("no password given on stdin")
+	}
+	return password, nil
+}
@@ -0,0 +1,160 @@
+// Package apierror defines the typed errors returned by the HTTP API and the
+// JSON envelope they are rendered as
+package apierror
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/breaker"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+// Error is an API error carrying the HTTP status and the machine readable
+// code sent to clients. Err holds the underlying cause for logging and is
+// never exposed in responses
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Details any
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// WithDetails returns a copy of the error with details attached
+func (e *Error) WithDetails(details any) *Error {
+	clone := *e
+	clone.Details = details
+	return &clone
+}
+
+// Wrap returns a copy of the error with err recorded as its cause
+func (e *Error) Wrap(err error) *Error {
+	clone := *e
+	clone.Err = err
+	return &clone
+}
+
+// Body renders the error for the response envelope
+func (e *Error) Body(requestID string) Body {
+	return Body{
+		Code:      e.Code,
+		Message:   e.Message,
+		Details:   e.Details,
+		RequestID: requestID,
+	}
+}
+
+// Response is the JSON envelope returned for failed requests
+type Response struct {
+	Error Body `json:"error"`
+}
+
+// Body describes what went wrong
+type Body struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Details   any    `json:"details,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// New creates an error with an arbitrary status and code
+func New(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+// BadRequest reports a malformed request
+func BadRequest(code, message string) *Error {
+	return New(http.StatusBadRequest, code, message)
+}
+
+// Validation reports a request that is well formed but fails validation
+func Validation(message string) *Error {
+	return New(http.StatusBadRequest, "validation_failed", message)
+}
+
+// NotFound reports a missing resource
+func NotFound(message string) *Error {
+	return New(http.StatusNotFound, "not_found", message)
+}
+
+// Conflict reports a resource that already exists
+func Conflict(message string) *Error {
+	return New(http.StatusConflict, "conflict", message)
+}
+
+// Unauthorized reports missing or invalid credentials
+func Unauthorized(message string) *Error {
+	return New(http.StatusUnauthorized, "unauthorized", message)
+}
+
+// Forbidden reports an authenticated client lacking the permission
+func Forbidden(message string) *Error {
+	return New(http.StatusForbidden, "forbidden", message)
+}
+
+// RequestTooLarge reports a body over the allowed size
+func RequestTooLarge(limit int64) *Error {
+	return New(http.StatusRequestEntityTooLarge, "request_too_large", "request body too large").
+		WithDetails(map[string]int64{"max_bytes": limit})
+}
+
+// UnsupportedMediaType reports a body in a format the endpoint does not accept
+func UnsupportedMediaType(expected string) *Error {
+	return New(http.StatusUnsupportedMediaType, "unsupported_media_type", "content type must be "+expected)
+}
+
+// TooManyRequests reports a rate limited client
+func TooManyRequests(retryAfterSeconds int) *Error {
+	return New(http.StatusTooManyRequests, "rate_limited", "too many requests").
+		WithDetails(map[string]int{"retry_after_seconds": retryAfterSeconds})
+}
+
+// Overloaded reports a request shed because the server is at capacity
+func Overloaded(retryAfterSeconds int) *Error {
+	return New(http.StatusServiceUnavailable, "overloaded", "server is overloaded, retry later").
+		WithDetails(map[string]int{"retry_after_seconds": retryAfterSeconds})
+}
+
+// Unavailable reports a request that cannot be served while a dependency is down
+func Unavailable(message string) *Error {
+	return New(http.StatusServiceUnavailable, "service_unavailable", message)
+}
+
+// Internal reports an unexpected failure, the cause is kept for logging only
+func Internal(err error) *Error {
+	return New(http.StatusInternalServerError, "internal_error", "internal server error").Wrap(err)
+}
+
+// From converts err to an API error. API errors are returned as is, storage,
+// circuit breaker and body size errors are mapped to their HTTP equivalents
+// and anything else is internal
+func From(err error) *Error {
+	var apiErr *Error
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr
+	case errors.As(err, &tooLarge):
+		return RequestTooLarge(tooLarge.Limit).Wrap(err)
+	case errors.Is(err, storage.ErrNotFound):
+		return NotFound("resource not found").Wrap(err)
+	case errors.Is(err, storage.ErrConflict):
+		return Conflict("resource already exists").Wrap(err)
+	case errors.Is(err, breaker.ErrOpen):
+		return Unavailable("a dependency is unavailable, retry later").Wrap(err)
+	default:
+		return Internal(err)
+	}
+}
@@ -0,0 +1,102 @@
+package app
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/apierror"
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/handlers"
+	"github.com/MuthuM3/gin-microservice-template/internal/metrics"
+	"github.com/MuthuM3/gin-microservice-template/internal/middleware"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage/postgres"
+	"github.com/gin-gonic/gin"
+)
+
+// newAdminRouter builds the engine of the internal admin server. Health
+// checks are open so probes need no credentials, everything else requires
+// the admin token when one is configured
+func (a *App) newAdminRouter() *gin.Engine {
+	engine := gin.New()
+	if a.inspect != nil {
+		engine.Use(a.inspect)
+	}
+	engine.Use(middleware.Recovery(a.logger, a.reporter), middleware.RequestID(), middleware.Errors())
+	engine.NoRoute(func(c *gin.Context) {
+		middleware.AbortWithError(c, apierror.NotFound("route not found"))
+	})
+
+	engine.GET("/healthz", a.health)
+	engine.GET("/readyz", a.ready)
+
+	ops := engine.Group("")
+	if a.config.Security.AdminToken != "" {
+		ops.Use(middleware.AdminToken(a.config.Security.AdminToken))
+	}
+	if a.config.Metrics.Enabled {
+		ops.GET(a.config.Metrics.PrometheusPath, gin.WrapH(metrics.Handler()))
+	}
+	if a.config.Performance.IsProfilingEnabled() {
+		handlers.RegisterProfiling(ops.Group(a.config.Performance.ProfilingPath))
+	}
+	ops.GET("/config", a.dumpConfig)
+	ops.GET("/health/detailed", a.detailedHealth)
+
+	return engine
+}
+
+// dumpConfig reports the running configuration with secrets masked,
+// including changes applied by reloads
+func (a *App) dumpConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, config.Redacted(a.running.Load()))
+}
+
+// detailedHealth reports the health of the database with the statistics of
+// its connection pool, answering 503 while the database is unreachable
+func (a *App) detailedHealth(c *gin.Context) {
+	status, code := "ok", http.StatusOK
+	if !a.store.IsHealthy() {
+		status, code = "unavailable", http.StatusServiceUnavailable
+	}
+
+	database := gin.H{
+		"driver":  a.config.Database.Driver,
+		"healthy": a.store.IsHealthy(),
+	}
+	if pg, ok := a.store.(*postgres.Store); ok {
+		database["pool"] = poolStats(pg, a.config.Database.MaxOpenConns)
+		database["last_health_check"] = pg.LastHealthCheck().UTC().Format(time.RFC3339)
+	}
+
+	c.JSON(code, gin.H{
+		"status":   status,
+		"version":  a.version,
+		"database": database,
+	})
+}
+
+// poolStats reports the statistics of the connection pool of the primary,
+// those of the pgx pool only when it is in use
+func poolStats(pg *postgres.Store, maxOpen int) gin.H {
+	stats := pg.GetStats()
+	pool := gin.H{
+		"open":                 stats.OpenConnections,
+		"in_use":               stats.InUseConnections,
+		"idle":                 stats.IdleConnection,
+		"max_open":             maxOpen,
+		"wait_count":           stats.WaitCount,
+		"wait_duration_ms":     stats.WaitDuration.Milliseconds(),
+		"max_idle_closed":      stats.MaxIdleClosed,
+		"max_idle_time_closed": stats.MaxIdleTimeClosed,
+		"max_lifetime_closed":  stats.MaxLifeTimeClosed,
+	}
+	if stats.MaxConns > 0 {
+		pool["acquire_count"] = stats.AcquireCount
+		pool["acquire_duration_ms"] = stats.AcquireDuration.Milliseconds()
+		pool["empty_acquire_count"] = stats.EmptyAcquireCount
+		pool["canceled_acquire_count"] = stats.CanceledAcquireCount
+		pool["constructing"] = stats.ConstructingConns
+		pool["max_conns"] = stats.MaxConns
+	}
+	return pool
+}
@@ -1,14 +1,596 @@
 package app
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
 
+	"github.com/MuthuM3/gin-microservice-template/internal/auth"
+	"github.com/MuthuM3/gin-microservice-template/internal/blob"
+	"github.com/MuthuM3/gin-microservice-template/internal/breaker"
+	"github.com/MuthuM3/gin-microservice-template/internal/cache"
+	"github.com/MuthuM3/gin-microservice-template/internal/capture"
+	"github.com/MuthuM3/gin-microservice-template/internal/certs"
 	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/events"
+	"github.com/MuthuM3/gin-microservice-template/internal/handlers"
+	"github.com/MuthuM3/gin-microservice-template/internal/httpclient"
+	"github.com/MuthuM3/gin-microservice-template/internal/lock"
+	"github.com/MuthuM3/gin-microservice-template/internal/lockout"
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+	"github.com/MuthuM3/gin-microservice-template/internal/messaging"
+	"github.com/MuthuM3/gin-microservice-template/internal/metrics"
+	"github.com/MuthuM3/gin-microservice-template/internal/middleware"
+	"github.com/MuthuM3/gin-microservice-template/internal/nonce"
+	"github.com/MuthuM3/gin-microservice-template/internal/oauth"
+	"github.com/MuthuM3/gin-microservice-template/internal/queue"
+	"github.com/MuthuM3/gin-microservice-template/internal/quota"
+	"github.com/MuthuM3/gin-microservice-template/internal/ratelimit"
+	"github.com/MuthuM3/gin-microservice-template/internal/reporting"
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"github.com/MuthuM3/gin-microservice-template/internal/session"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage/memory"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage/postgres"
+	"github.com/MuthuM3/gin-microservice-template/internal/tracing"
+	"github.com/MuthuM3/gin-microservice-template/internal/webhook"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
 )
 
 type App struct {
-	config *config.Config
-	logger *log.Logger
-	server *http.Server
+	config      *config.Config
+	loadConfig  ConfigLoader
+	logger      logger.Logger
+	accessLog   io.Writer
+	server      *http.Server
+	grpc        *grpc.Server
+	auxiliary   []*http.Server
+	store       storage.Store
+	redis       *redis.Client
+	cache       cache.Cache
+	cacheTiers  *cache.Tiers
+	cacheStats  *cache.Stats
+	todoCache   *service.TodoCache
+	tokens      *auth.TokenManager
+	todos       *service.TodoService
+	attachments *service.AttachmentService
+	accounts    *service.AccountService
+	notifier    *service.NotificationService
+	shares      *service.ShareService
+	webhooks    *service.WebhookService
+	quotas      *service.QuotaService
+	audit       *service.AuditLogger
+	securityLog *service.SecurityLog
+	bus         events.Bus
+	feed        *service.TodoFeed
+	graphql     *handlers.GraphQLHandler
+	limiter     ratelimit.Limiter
+	policies    *middleware.RoutePolicies
+	signatures  *middleware.Signatures
+	capture     *middleware.CapturePolicy
+	captures    *capture.Store
+	lockout     lockout.Tracker
+	sessions    session.Store
+	oauth       map[string]oauth.Provider
+	cors        *middleware.CORSPolicy
+	reporter    reporting.Reporter
+	httpClients *httpclient.Factory
+	queue       *queue.Client
+	locker      lock.Locker
+	blobs       blob.Storage
+	registrars  []RouteRegistrar
+	inspect     gin.HandlerFunc
+	tasks       map[string]queue.HandlerFunc
+	inbound     map[string]webhook.Handler
+	requests    *metrics.Window
+	version     string
+	startTime   time.Time
+
+	// running mirrors config for readers outside the run goroutine, which
+	// replaces config on reload
+	running atomic.Pointer[config.Config]
+}
+
+// New creates a new application instance
+func New(cfg *config.Config, log logger.Logger, version string) *App {
+	return &App{
+		config:    cfg,
+		logger:    log,
+		requests:  metrics.NewWindow(errorRateWindow),
+		version:   version,
+		startTime: time.Now(),
+	}
+}
+
+// Run builds the HTTP server, starts listening and blocks until the server
+// stops or a shutdown signal (SIGINT/SIGTERM) is received
+func (a *App) Run() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return a.run(ctx)
+}
+
+func (a *App) run(ctx context.Context) (err error) {
+	a.running.Store(a.config)
+	metrics.RegisterRuntimeMetrics(metrics.Default, a.startTime)
+
+	shutdownTracing, err := tracing.Init(ctx, &a.config.Tracing, a.version)
+	if err != nil {
+		return fmt.Errorf("failed to initialize tracing: %w", err)
+	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			a.logger.Error("failed to flush traces", "error", err)
+		}
+	}()
+
+	reporter, err := reporting.New(&a.config.Sentry, a.config.Server.Environment, a.version)
+	if err != nil {
+		return fmt.Errorf("failed to initialize error reporting: %w", err)
+	}
+	a.reporter = reporter
+	defer reporter.Flush(2 * time.Second)
+
+	// Components stop in dependency order once run returns, however it
+	// returns. A failed shutdown fails a run that would otherwise succeed
+	lc := newLifecycle(a.logger)
+	defer func() {
+		if shutdownErr := lc.shutdown(a.config.Server); err == nil {
+			err = shutdownErr
+		}
+	}()
+
+	store, err := a.newStore(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	a.store = store
+	lc.onClose(stageDatabase, "store", store.Close)
+
+	if a.usesRedis() {
+		client, err := a.connectRedis(ctx)
+		switch {
+		case err == nil:
+			a.redis = client
+			// Closed with the database, every stage before may use it
+			lc.onClose(stageDatabase, "redis", client.Close)
+		case a.config.Server.IsDevelopment():
+			// Keep the template runnable locally without a Redis instance
+			a.logger.Warn("redis unavailable, falling back to in-memory backends", "error", err)
+		default:
+			return err
+		}
+	}
+
+	a.queue = a.newQueueClient()
+	a.locker = a.newLocker()
+
+	a.cacheTiers = cache.NewTiers(a.config.Cache)
+	if a.config.Cache.Enabled {
+		a.cacheStats = &cache.Stats{}
+		a.cache = cache.WithStats(a.newCache(), a.cacheStats)
+		lc.onClose(stageCache, "cache", a.cache.Close)
+		a.todoCache = service.NewTodoCache(a.cache, a.cacheTiers)
+	}
+
+	a.tokens = auth.NewTokenManager(&a.config.JWT)
+
+	a.httpClients = httpclient.NewFactory(a.config.HTTPClient, a.config.CircuitBreaker, a.logger)
+	lc.onClose(stageWorkers, "http clients", func() error {
+		a.httpClients.CloseIdleConnections()
+		return nil
+	})
+
+	if a.config.Audit.Enabled {
+		a.audit = service.NewAuditLogger(a.store.Audit(), a.config.Pagination, a.logger)
+	}
+
+	if a.config.Quotas.Enabled {
+		a.quotas = service.NewQuotaService(a.store.Quotas(), a.store.Auth(), a.store.Todos(), a.newCallCounter(),
+			a.config.Quotas, a.audit, a.logger)
+	}
+
+	if a.config.Notifications.Enabled {
+		if a.notifier, err = a.newNotificationService(); err != nil {
+			return err
+		}
+	}
+
+	var publishers []events.Publisher
+	if a.config.Events.Enabled {
+		a.bus = a.newEventBus()
+		lc.onClose(stageEvents, "event bus", a.bus.Close)
+
+		// Subscriptions end in the events stage rather than with the
+		// signal, so the feed keeps up until the API has drained
+		subscriptionsCtx, endSubscriptions := context.WithCancel(context.Background())
+		lc.onClose(stageEvents, "event subscriptions", func() error {
+			endSubscriptions()
+			return nil
+		})
+
+		todoEvents, err := a.bus.Subscribe(subscriptionsCtx, events.TopicTodos)
+		if err != nil {
+			return fmt.Errorf("failed to subscribe to todo events: %w", err)
+		}
+		a.feed = service.NewTodoFeed(a.config.Events.ReplayBuffer)
+		lc.goroutine(stageEvents, func() { a.feed.Consume(todoEvents) })
+
+		if a.todoCache != nil {
+			invalidations, err := a.bus.Subscribe(subscriptionsCtx, events.TopicTodos)
+			if err != nil {
+				return fmt.Errorf("failed to subscribe to todo events: %w", err)
+			}
+			lc.goroutine(stageEvents, func() { a.todoCache.Consume(invalidations) })
+		}
+		publishers = append(publishers, a.bus)
+	}
+
+	if a.config.Messaging.Enabled {
+		driver, err := messaging.New(&a.config.Messaging)
+		if err != nil {
+			return fmt.Errorf("failed to initialize messaging: %w", err)
+		}
+		broker := messaging.NewPublisher(driver, a.config.Messaging.Source, a.config.Messaging.PublishTimeout)
+		lc.onClose(stageEvents, "message broker", broker.Close)
+		publishers = append(publishers, broker)
+	}
+
+	if a.config.Webhooks.Enabled {
+		sender := webhook.NewSender(a.config.Webhooks.Timeout, a.config.Webhooks.AllowPrivateNetworks,
+			a.config.Tracing.ServiceName+"/"+a.version)
+		a.webhooks = service.NewWebhookService(a.store.Webhooks(), sender, a.config.Webhooks, a.config.Pagination,
+			a.locker, a.audit, a.logger)
+		publishers = append(publishers, a.webhooks)
+	}
+	var collaborators *service.CollaboratorNotifier
+	if a.config.Sharing.Enabled && a.notifier != nil {
+		collaborators = service.NewCollaboratorNotifier(a.store.Todos(), a.notifier, a.logger)
+		publishers = append(publishers, collaborators)
+	}
+
+	// Background jobs stop in the workers stage, after the API has drained
+	// so the changes of its last requests are still relayed
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	lc.onClose(stageWorkers, "background jobs", func() error {
+		stopJobs()
+		return nil
+	})
+
+	var outbox *service.OutboxRelay
+	if publisher := events.Fanout(publishers...); publisher != nil {
+		outbox = service.NewOutboxRelay(a.store.Todos(), publisher, a.config.Outbox, a.locker, a.logger)
+		lc.goroutine(stageWorkers, func() { outbox.Run(jobsCtx) })
+
+		// Changes written by other services sharing the database
+		if pg, ok := a.store.(*postgres.Store); ok && a.config.Events.Enabled && a.config.Events.DatabaseChanges {
+			listener := pg.NewChangeListener(publisher, a.locker)
+			lc.goroutine(stageWorkers, func() { listener.Run(jobsCtx) })
+		}
+	}
+	if a.webhooks != nil {
+		lc.goroutine(stageWorkers, func() { a.webhooks.Run(jobsCtx) })
+	}
+	if collaborators != nil {
+		lc.goroutine(stageWorkers, func() { collaborators.Run(jobsCtx) })
+	}
+	if a.config.SecurityEvents.Enabled {
+		a.securityLog = a.newSecurityLog(jobsCtx, lc)
+	}
+
+	a.todos = service.NewTodoService(a.store.Todos(), a.config.Todos, a.config.Pagination, a.audit, outbox, a.quotas, a.todoCache)
+
+	blobs, err := a.newBlobStorage(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to initialize blob storage: %w", err)
+	}
+	a.blobs = blobs
+	a.attachments = service.NewAttachmentService(a.store.Todos(), a.blobs, a.config.BlobStorage.S3.PresignTTL, &a.config.Security, a.quotas, a.audit, a.logger)
+	if a.config.Auth.Sessions.Enabled {
+		a.sessions = a.newSessionStore()
+		if closer, ok := a.sessions.(io.Closer); ok {
+			lc.onClose(stageCache, "session store", closer.Close)
+		}
+	}
+	a.accounts = service.NewAccountService(a.store, a.sessions, a.todoCache, a.audit, a.config.Accounts)
+	if a.config.Sharing.Enabled {
+		a.shares = service.NewShareService(a.store.Todos(), a.store.Auth(), a.todos, a.notifier, a.audit, a.config.Sharing, a.logger)
+	}
+
+	if a.config.Jobs.Enabled {
+		scheduler, err := a.newScheduler()
+		if err != nil {
+			return fmt.Errorf("failed to schedule background jobs: %w", err)
+		}
+		scheduler.Start(jobsCtx)
+		// Lets running jobs finish before the stores close
+		lc.onStop(stageWorkers, "scheduler", scheduler.Stop)
+	}
+
+	a.oauth, err = oauth.NewProviders(ctx, &a.config.Auth)
+	if err != nil {
+		return fmt.Errorf("failed to initialize oauth providers: %w", err)
+	}
+
+	a.lockout = a.newLockoutTracker()
+	if closer, ok := a.lockout.(io.Closer); ok {
+		lc.onClose(stageCache, "lockout tracker", closer.Close)
+	}
+
+	if a.config.RateLimit.Enabled {
+		a.limiter = a.newRateLimiter()
+		if closer, ok := a.limiter.(io.Closer); ok {
+			lc.onClose(stageCache, "rate limiter", closer.Close)
+		}
+	}
+	a.policies = a.newRoutePolicies()
+	lc.onClose(stageCache, "route policies", a.policies.Close)
+	if a.config.Security.SignedRequests.Enabled {
+		nonces := a.newNonceStore()
+		if closer, ok := nonces.(io.Closer); ok {
+			lc.onClose(stageCache, "nonce store", closer.Close)
+		}
+		a.signatures = middleware.NewSignatures(a.config.Security.SignedRequests, nonces, a.logger)
+	}
+
+	if cfg := a.config.Logger.AccessLog; cfg.Enabled {
+		out, closer, err := logger.OpenOutput(cfg.OutputPath, a.config.Logger.Rotation)
+		if err != nil {
+			return fmt.Errorf("failed to open access log: %w", err)
+		}
+		// Registered before the servers, so closed after they drained
+		lc.onClose(stageHTTP, "access log", closer.Close)
+		a.accessLog = out
+	}
+	if cfg := a.config.Logger.Capture; cfg.Enabled && a.cache != nil {
+		a.capture = middleware.NewCapturePolicy(cfg)
+		a.captures = capture.NewStore(a.cache, cfg.TTL)
+	}
+
+	router, err := a.newRouter()
+	if err != nil {
+		return fmt.Errorf("failed to build router: %w", err)
+	}
+
+	a.server = &http.Server{
+		Addr:         a.config.Server.GetAddress(),
+		Handler:      router,
+		ReadTimeout:  a.config.Server.ReadTimeout,
+		WriteTimeout: a.config.Server.WriteTimeout,
+		IdleTimeout:  a.config.Server.IdleTimeout,
+	}
+	a.configureHTTP2(a.server)
+	if a.graphql != nil {
+		// Upgraded connections are not closed by Shutdown
+		a.server.RegisterOnShutdown(a.graphql.Shutdown)
+	}
+
+	switch tlsConfig := a.config.Server.TLS; {
+	case tlsConfig.Enabled && tlsConfig.Autocert.Enabled:
+		manager := certs.NewManager(tlsConfig.Autocert)
+		a.server.TLSConfig = manager.TLSConfig()
+		a.server.TLSConfig.MinVersion = certs.MinVersion(tlsConfig.MinVersion)
+		// Answers ACME HTTP-01 challenges and redirects everything else
+		a.auxiliary = append(a.auxiliary, &http.Server{
+			Addr:        fmt.Sprintf("%s:%d", a.config.Server.Host, tlsConfig.Autocert.HTTPPort),
+			Handler:     manager.HTTPHandler(nil),
+			ReadTimeout: a.config.Server.ReadTimeout,
+			IdleTimeout: a.config.Server.IdleTimeout,
+		})
+	case tlsConfig.Enabled:
+		reloader, err := certs.New(tlsConfig, a.logger)
+		if err != nil {
+			return fmt.Errorf("failed to load tls certificates: %w", err)
+		}
+		a.server.TLSConfig = reloader.TLSConfig(a.server.Protocols.HTTP2())
+		lc.goroutine(stageWorkers, func() { reloader.Watch(jobsCtx) })
+	}
+
+	if a.config.AdminServer.Enabled {
+		a.auxiliary = append(a.auxiliary, &http.Server{
+			Addr:        a.config.AdminServer.GetAddress(),
+			Handler:     a.newAdminRouter(),
+			ReadTimeout: a.config.Server.ReadTimeout,
+			IdleTimeout: a.config.Server.IdleTimeout,
+			// No write timeout, CPU profiles and traces stream for as long
+			// as the caller asks
+		})
+	}
+
+	if a.config.GRPC.Enabled {
+		a.grpc = a.newGRPCServer()
+		lc.onStop(stageHTTP, "grpc server", a.stopGRPC)
+	}
+	lc.onStop(stageHTTP, "http servers", a.shutdown)
+
+	// Start the server in the background so we can wait for signals
+	serverErr := make(chan error, 1)
+	go func() {
+		a.logger.Info("http server listening", "addr", a.server.Addr, "tls", a.server.TLSConfig != nil)
+		if err := a.listen(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+		close(serverErr)
+	}()
+
+	// The admin, HTTP redirect and gRPC servers, any of them failing stops
+	// the app
+	auxErr := make(chan error, len(a.auxiliary)+1)
+	for _, server := range a.auxiliary {
+		go func() {
+			a.logger.Info("auxiliary http server listening", "addr", server.Addr)
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				auxErr <- fmt.Errorf("http server on %s failed: %w", server.Addr, err)
+			}
+		}()
+	}
+	if a.grpc != nil {
+		go a.serveGRPC(auxErr)
+	}
+
+	// Reloads run on this goroutine so they never race with shutdown
+	hup := make(chan os.Signal, 1)
+	if a.loadConfig != nil {
+		signal.Notify(hup, syscall.SIGHUP)
+		defer signal.Stop(hup)
+	}
+
+	for {
+		select {
+		case err := <-serverErr:
+			if err != nil {
+				return fmt.Errorf("http server failed: %w", err)
+			}
+			return nil
+		case err := <-auxErr:
+			return err
+		case <-hup:
+			a.logger.Info("SIGHUP received, reloading configuration")
+			a.reload()
+		case <-ctx.Done():
+			a.logger.Info("shutdown signal received, draining in-flight requests",
+				"timeout", a.config.Server.ShutdownTimeout)
+			return nil
+		}
+	}
+}
+
+// configureHTTP2 applies the HTTP/2 settings to server. HTTP/1 is always
+// served
+func (a *App) configureHTTP2(server *http.Server) {
+	cfg := a.config.Server.HTTP2
+
+	server.Protocols = new(http.Protocols)
+	server.Protocols.SetHTTP1(true)
+	server.Protocols.SetHTTP2(cfg.Enabled)
+	server.Protocols.SetUnencryptedHTTP2(cfg.H2C)
+	server.HTTP2 = &http.HTTP2Config{
+		MaxConcurrentStreams: cfg.MaxConcurrentStreams,
+		MaxReadFrameSize:     cfg.MaxReadFrameSize,
+	}
+}
+
+// listen serves the API, over TLS when configured. The certificate comes
+// from the TLS config, so no files are passed
+func (a *App) listen() error {
+	if a.server.TLSConfig != nil {
+		return a.server.ListenAndServeTLS("", "")
+	}
+	return a.server.ListenAndServe()
+}
+
+// newStore creates the storage backend selected by the database driver
+func (a *App) newStore(ctx context.Context) (storage.Store, error) {
+	if a.config.Database.Driver == "memory" {
+		a.logger.Warn("using in-memory storage, data is lost on restart")
+		return memory.New(), nil
+	}
+	return postgres.New(ctx, &a.config.Database, a.newBreaker("postgres", postgres.IsOutage), a.logger)
+}
+
+// newBreaker creates the circuit breaker of a dependency, nil when circuit
+// breaking is disabled
+func (a *App) newBreaker(name string, isFailure func(error) bool) *breaker.Breaker {
+	if !a.config.CircuitBreaker.Enabled {
+		return nil
+	}
+	return breaker.New(name, a.config.CircuitBreaker, isFailure, a.logger)
+}
+
+// newRateLimiter creates the limiter for the configured backend
+func (a *App) newRateLimiter() ratelimit.Limiter {
+	return a.newLimiter(a.config.RateLimit.RequestsPerWindow, a.config.RateLimit.Window)
+}
+
+// newLimiter creates a limiter allowing limit requests per window on the
+// configured backend
+func (a *App) newLimiter(limit int, window time.Duration) ratelimit.Limiter {
+	if a.config.RateLimit.Backend == "redis" && a.redis != nil {
+		return ratelimit.NewRedisLimiter(a.redis, limit, window, a.config.Cache.KeyPrefix)
+	}
+	return ratelimit.NewMemoryLimiter(limit, window)
+}
+
+// rateLimitKey returns how rate limits tell clients apart
+func (a *App) rateLimitKey() middleware.KeyFunc {
+	if a.config.RateLimit.UserBased {
+		return middleware.KeyByUser(a.tokens)
+	}
+	return middleware.KeyByIP
+}
+
+// newRoutePolicies compiles the route policies of the configuration,
+// policies with a rate limit of their own get a limiter each
+func (a *App) newRoutePolicies() *middleware.RoutePolicies {
+	return middleware.NewRoutePolicies(a.config.RoutePolicies, a.config.Performance, a.newLimiter, a.rateLimitKey(), a.logger)
+}
+
+// newNonceStore creates the store of the nonces of signed requests, shared
+// through Redis when a client is available so replays are refused by every
+// replica
+func (a *App) newNonceStore() nonce.Store {
+	if a.redis != nil {
+		return nonce.NewRedisStore(a.redis, a.config.Cache.KeyPrefix)
+	}
+	return nonce.NewMemoryStore()
+}
+
+// newCallCounter creates the API call counter of the quotas, shared through
+// Redis when configured and a client is available
+func (a *App) newCallCounter() quota.Counter {
+	if a.config.Quotas.Backend == "redis" && a.redis != nil {
+		return quota.NewRedisCounter(a.redis, a.config.Cache.KeyPrefix)
+	}
+	return quota.NewMemoryCounter()
+}
+
+// newEventBus creates the event bus for the configured backend, Redis
+// delivers events to every replica
+func (a *App) newEventBus() events.Bus {
+	if a.config.Events.Backend == "redis" && a.redis != nil {
+		return events.NewRedisBus(a.redis, a.config.Cache.KeyPrefix)
+	}
+	return events.NewMemoryBus()
+}
+
+// newLockoutTracker creates the login lockout tracker, shared through Redis
+// when a client is available so lockouts apply across replicas
+func (a *App) newLockoutTracker() lockout.Tracker {
+	cfg := a.config.Security
+	if a.redis != nil {
+		return lockout.NewRedisTracker(a.redis, cfg.MaxLoginAttempts, cfg.LoginLogoutDuration, a.config.Cache.KeyPrefix)
+	}
+	return lockout.NewMemoryTracker(cfg.MaxLoginAttempts, cfg.LoginLogoutDuration)
+}
+
+// shutdown gracefully stops the HTTP servers, waiting until ctx is done for
+// in-flight requests to complete
+func (a *App) shutdown(ctx context.Context) error {
+	// Stay observable while the API drains, then stop the auxiliary servers
+	defer func() {
+		for _, server := range a.auxiliary {
+			if err := server.Shutdown(ctx); err != nil {
+				a.logger.Error("failed to shutdown http server", "addr", server.Addr, "error", err)
+			}
+		}
+	}()
+
+	if err := a.server.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to shutdown http server: %w", err)
+	}
+
+	a.logger.Info("http server stopped")
+	return nil
 }
@@ -0,0 +1,56 @@
+package app
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/blob"
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/handlers"
+)
+
+// uploadRoute is the full pattern of the attachment upload route
+const uploadRoute = "/api/v1/todos" + handlers.UploadRoute
+
+// multipartOverhead is allowed on top of the upload size for the boundaries
+// and headers of a multipart body
+const multipartOverhead = 64 << 10
+
+// newBlobStorage creates the storage for attachment contents
+func (a *App) newBlobStorage(ctx context.Context) (blob.Storage, error) {
+	cfg := a.config.BlobStorage
+	if cfg.Backend == "s3" {
+		// Transfers are bounded by the request context rather than the
+		// client timeout, which is meant for small API calls
+		client := a.httpClients.Client("s3")
+		client.Timeout = 0
+		return blob.NewS3Storage(ctx, cfg.S3, client)
+	}
+	return blob.NewLocalStorage(cfg.LocalDir)
+}
+
+// bodySecurity returns the security configuration of the body middleware,
+// letting the upload route take multipart bodies up to the upload size and
+// the inbound webhook route bodies of any type up to its own limit, unless a
+// limit is configured for them
+func (a *App) bodySecurity() config.SecurityConfig {
+	cfg := a.config.Security
+	cfg.RouteMaxRequestSizes = maps.Clone(cfg.RouteMaxRequestSizes)
+	if cfg.RouteMaxRequestSizes == nil {
+		cfg.RouteMaxRequestSizes = make(map[string]int64)
+	}
+	if _, ok := cfg.RouteMaxRequestSizes[uploadRoute]; !ok {
+		cfg.RouteMaxRequestSizes[uploadRoute] = cfg.MaxUploadSize + multipartOverhead
+	}
+	cfg.ContentTypeSkipRoutes = append(slices.Clone(cfg.ContentTypeSkipRoutes), uploadRoute)
+
+	if a.config.Inbound.Enabled {
+		if _, ok := cfg.RouteMaxRequestSizes[inboundRoute]; !ok {
+			cfg.RouteMaxRequestSizes[inboundRoute] = a.config.Inbound.MaxBodySize
+		}
+		// GitHub sends form encoded bodies when configured to
+		cfg.ContentTypeSkipRoutes = append(cfg.ContentTypeSkipRoutes, inboundRoute)
+	}
+	return cfg
+}
@@ -0,0 +1,19 @@
+package app
+
+import (
+	"github.com/MuthuM3/gin-microservice-template/internal/cache"
+	"github.com/MuthuM3/gin-microservice-template/internal/mail"
+	"github.com/MuthuM3/gin-microservice-template/internal/retry"
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+)
+
+// newExportService creates the todo export service. Asynchronous exports are
+// kept in Redis so the API can serve the files built by a worker, they are
+// unavailable without the task queue
+func (a *App) newExportService(mailer mail.Mailer) *service.ExportService {
+	var files cache.Cache
+	if a.queue != nil && a.redis != nil {
+		files = cache.NewRedisCache(a.redis, a.config.Cache.KeyPrefix, a.cacheTiers, retry.New("redis_exports", a.config.Cache.Retry, nil))
+	}
+	return service.NewExportService(a.todos, a.store.Auth(), files, a.queue, mailer, a.config.Export, &a.config.Email)
+}
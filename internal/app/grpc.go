@@ -0,0 +1,64 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/grpcserver"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// newGRPCServer creates the gRPC server of the todo API on the services of
+// the REST API, using its TLS certificates when TLS is enabled
+func (a *App) newGRPCServer() *grpc.Server {
+	var options []grpc.ServerOption
+	if a.config.Server.TLS.Enabled {
+		options = append(options, grpc.Creds(credentials.NewTLS(a.server.TLSConfig.Clone())))
+	}
+
+	return grpcserver.New(grpcserver.Options{
+		Todos:       a.todos,
+		Tokens:      a.tokens,
+		Revocations: a.store.Auth(),
+		Limiter:     a.limiter,
+		UserBased:   a.config.RateLimit.UserBased,
+		Logger:      a.logger,
+	}, options...)
+}
+
+// serveGRPC listens on the gRPC address and serves until stopGRPC, errors
+// are sent to errs
+func (a *App) serveGRPC(errs chan<- error) {
+	addr := a.config.GRPC.GetAddress()
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		errs <- fmt.Errorf("failed to listen for grpc on %s: %w", addr, err)
+		return
+	}
+
+	a.logger.Info("grpc server listening", "addr", addr, "tls", a.config.Server.TLS.Enabled)
+	if err := a.grpc.Serve(listener); err != nil {
+		errs <- fmt.Errorf("grpc server on %s failed: %w", addr, err)
+	}
+}
+
+// stopGRPC lets in-flight calls finish until ctx is done, then closes the
+// connections that remain
+func (a *App) stopGRPC(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		a.grpc.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		a.logger.Info("grpc server stopped")
+		return nil
+	case <-ctx.Done():
+		a.grpc.Stop()
+		return fmt.Errorf("failed to drain grpc server: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,56 @@
+package app
+
+import (
+	"github.com/MuthuM3/gin-microservice-template/internal/handlers"
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"github.com/MuthuM3/gin-microservice-template/internal/webhook"
+)
+
+// inboundRoute is the full pattern of the inbound webhook route
+const inboundRoute = "/webhooks" + handlers.InboundRoute
+
+// HandleWebhook registers the handler of the webhooks received from provider
+// at /webhooks/:provider, one of github, stripe or the name of the generic
+// HMAC provider. Events are handled by the worker process when the task queue
+// is enabled, otherwise before the request is answered
+func (a *App) HandleWebhook(provider string, fn webhook.Handler) {
+	if a.inbound == nil {
+		a.inbound = make(map[string]webhook.Handler)
+	}
+	a.inbound[provider] = fn
+}
+
+// newInboundService creates the inbound webhook service for the enabled
+// providers that have a handler
+func (a *App) newInboundService() *service.InboundWebhookService {
+	cfg := a.config.Inbound
+
+	verifiers := make(map[string]webhook.Verifier)
+	if cfg.GitHub.Enabled {
+		verifiers["github"] = webhook.NewGitHubVerifier(cfg.GitHub.Secret)
+	}
+	if cfg.Stripe.Enabled {
+		verifiers["stripe"] = webhook.NewStripeVerifier(cfg.Stripe.Secret, cfg.Tolerance)
+	}
+	if cfg.HMAC.Enabled {
+		verifiers[cfg.HMAC.Name] = webhook.NewHMACVerifier(cfg.HMAC.Secret, cfg.HMAC.SignatureHeader, cfg.HMAC.Prefix,
+			cfg.HMAC.EventHeader, cfg.HMAC.IDHeader)
+	}
+
+	providers := make(map[string]service.InboundProvider)
+	for name, verifier := range verifiers {
+		fn, ok := a.inbound[name]
+		if !ok {
+			a.logger.Warn("webhook provider enabled without a handler, its webhooks are refused", "provider", name)
+			continue
+		}
+		providers[name] = service.InboundProvider{Verifier: verifier, Handler: fn}
+	}
+	for name := range a.inbound {
+		if _, ok := verifiers[name]; !ok {
+			a.logger.Warn("webhook handler registered for a provider that is not enabled", "provider", name)
+		}
+	}
+
+	return service.NewInboundWebhookService(providers, a.queue, a.logger)
+}
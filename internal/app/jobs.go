@@ -0,0 +1,188 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/jobs"
+	"github.com/MuthuM3/gin-microservice-template/internal/lock"
+)
+
+// cacheWarmupKey is written and read back to open the cache connections
+const cacheWarmupKey = "jobs:warmup"
+
+// jobLockTTL bounds how long a replica that crashed during a job blocks the
+// next run elsewhere
+const jobLockTTL = 30 * time.Second
+
+// newScheduler registers the background jobs. Cleanups run on one replica
+// at a time, the cache warmup on every replica
+func (a *App) newScheduler() (*jobs.Scheduler, error) {
+	scheduler := jobs.NewScheduler(a.logger)
+
+	if err := scheduler.Schedule("trash_purge", a.config.Jobs.TrashPurge, a.exclusive("trash_purge", a.purgeTrash)); err != nil {
+		return nil, err
+	}
+	if err := scheduler.Schedule("token_cleanup", a.config.Jobs.TokenCleanup, a.exclusive("token_cleanup", a.cleanupTokens)); err != nil {
+		return nil, err
+	}
+	if err := scheduler.Schedule("account_erasure", a.config.Jobs.AccountErasure, a.exclusive("account_erasure", a.eraseAccounts)); err != nil {
+		return nil, err
+	}
+	if err := scheduler.Schedule("recurrences", a.config.Jobs.Recurrences, a.exclusive("recurrences", a.materializeRecurrences)); err != nil {
+		return nil, err
+	}
+	if a.notifier != nil {
+		if err := scheduler.Schedule("due_reminders", a.config.Jobs.DueReminders, a.exclusive("due_reminders", a.sendDueReminders)); err != nil {
+			return nil, err
+		}
+		if err := scheduler.Schedule("reminders", a.config.Jobs.Reminders, a.exclusive("reminders", a.dispatchReminders)); err != nil {
+			return nil, err
+		}
+	}
+	if a.cache != nil {
+		scheduler.After("cache_warmup", a.config.Jobs.CacheWarmupDelay, a.warmupCache)
+	}
+
+	return scheduler, nil
+}
+
+// exclusive runs fn only on the replica that gets the job's lock, the
+// others skip the run
+func (a *App) exclusive(name string, fn jobs.Func) jobs.Func {
+	return func(ctx context.Context) error {
+		lease, err := a.locker.Lock(ctx, "jobs:"+name, jobLockTTL)
+		if errors.Is(err, lock.ErrNotAcquired) {
+			a.logger.Debug("job running on another replica, skipping", "job", name)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		defer lease.Unlock()
+
+		return fn(lease.Context())
+	}
+}
+
+// purgeTrash permanently removes todos that outlived the trash retention,
+// then the contents of their attachments
+func (a *App) purgeTrash(ctx context.Context) error {
+	purged, err := a.todos.PurgeTrash(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to purge trashed todos: %w", err)
+	}
+	if purged > 0 {
+		a.logger.Info("purged trashed todos", "count", purged, "retention", a.config.Todos.TrashRetention)
+	}
+
+	// Also catches up on attachments left behind by an earlier failed run
+	removed, err := a.attachments.PurgeOrphaned(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to purge attachments of purged todos: %w", err)
+	}
+	if removed > 0 {
+		a.logger.Info("purged attachments of purged todos", "count", removed)
+	}
+	return nil
+}
+
+// eraseAccounts erases the accounts whose deletion grace period has passed,
+// then the contents of their attachments
+func (a *App) eraseAccounts(ctx context.Context) error {
+	erased, err := a.accounts.EraseDue(ctx)
+	if erased > 0 {
+		a.logger.Info("erased deleted accounts", "count", erased, "grace_period", a.config.Accounts.DeletionGracePeriod)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to erase deleted accounts: %w", err)
+	}
+
+	removed, err := a.attachments.PurgeOrphaned(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to purge attachments of erased accounts: %w", err)
+	}
+	if removed > 0 {
+		a.logger.Info("purged attachments of erased accounts", "count", removed)
+	}
+	return nil
+}
+
+// sendDueReminders reminds users of their todos falling due soon
+func (a *App) sendDueReminders(ctx context.Context) error {
+	sent, err := a.notifier.SendDueReminders(ctx)
+	if sent > 0 {
+		a.logger.Info("sent due date reminders", "count", sent)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to send due date reminders: %w", err)
+	}
+	return nil
+}
+
+// dispatchReminders queues the reminders users set on todos that fall due
+// soon
+func (a *App) dispatchReminders(ctx context.Context) error {
+	dispatched, err := a.newReminderService().Dispatch(ctx)
+	if dispatched > 0 {
+		a.logger.Info("dispatched todo reminders", "count", dispatched)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to dispatch todo reminders: %w", err)
+	}
+	return nil
+}
+
+// materializeRecurrences creates the next occurrences of repeating todos
+// that were completed or fell overdue
+func (a *App) materializeRecurrences(ctx context.Context) error {
+	created, err := a.todos.MaterializeRecurrences(ctx)
+	if created > 0 {
+		a.logger.Info("created occurrences of repeating todos", "count", created)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create occurrences of repeating todos: %w", err)
+	}
+	return nil
+}
+
+// cleanupTokens removes sessions and tokens that expired more than the
+// token retention ago, keeping recent ones around for auditing, and the
+// security events older than their retention
+func (a *App) cleanupTokens(ctx context.Context) error {
+	purged, err := a.store.Auth().PurgeExpired(ctx, time.Now().Add(-a.config.Jobs.TokenRetention))
+	if err != nil {
+		return fmt.Errorf("failed to purge expired tokens: %w", err)
+	}
+	if purged > 0 {
+		a.logger.Info("purged expired sessions and tokens", "count", purged)
+	}
+
+	if a.securityLog != nil {
+		purged, err := a.securityLog.Purge(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to purge security events: %w", err)
+		}
+		if purged > 0 {
+			a.logger.Info("purged security events", "count", purged, "retention", a.config.SecurityEvents.Retention)
+		}
+	}
+	return nil
+}
+
+// warmupCache round-trips a key through the cache so the first requests do
+// not pay for opening its connections
+func (a *App) warmupCache(ctx context.Context) error {
+	start := time.Now()
+	if err := a.cache.Set(ctx, cacheWarmupKey, []byte("ok"), time.Minute); err != nil {
+		return fmt.Errorf("failed to warm up cache: %w", err)
+	}
+	if _, err := a.cache.Get(ctx, cacheWarmupKey); err != nil {
+		return fmt.Errorf("failed to warm up cache: %w", err)
+	}
+
+	a.logger.Info("cache warmed up", "duration", time.Since(start))
+	return nil
+}
@@ -0,0 +1,163 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+)
+
+// Shutdown stages, in the order they are stopped. Each stage only stops
+// once everything in the stages before it has, so nothing is closed while
+// a component above it may still use it
+const (
+	stageHTTP     = "http"
+	stageWorkers  = "workers"
+	stageEvents   = "events"
+	stageCache    = "cache"
+	stageDatabase = "database"
+)
+
+var shutdownOrder = []string{stageHTTP, stageWorkers, stageEvents, stageCache, stageDatabase}
+
+// stopFunc stops a component, giving up when ctx is done
+type stopFunc func(ctx context.Context) error
+
+type component struct {
+	name string
+	stop stopFunc
+}
+
+// lifecycle stops the components of a run in dependency order: the HTTP
+// servers, background workers, the event bus, caches and finally the
+// database. Every stage has its own timeout so one hanging component cannot
+// use up the time of those after it, and waits for the goroutines started
+// for it before the next stage closes what they use
+type lifecycle struct {
+	logger     logger.Logger
+	components map[string][]component
+	goroutines map[string]*sync.WaitGroup
+	started    map[string]int
+	once       sync.Once
+	err        error
+}
+
+func newLifecycle(log logger.Logger) *lifecycle {
+	l := &lifecycle{
+		logger:     log,
+		components: make(map[string][]component),
+		goroutines: make(map[string]*sync.WaitGroup),
+		started:    make(map[string]int),
+	}
+	for _, stage := range shutdownOrder {
+		l.goroutines[stage] = new(sync.WaitGroup)
+	}
+	return l
+}
+
+// onStop registers a component stopped in stage. Components of a stage stop
+// in the reverse order of registration, like deferred calls
+func (l *lifecycle) onStop(stage, name string, stop stopFunc) {
+	l.components[stage] = append(l.components[stage], component{name: name, stop: stop})
+}
+
+// onClose registers a component stopped by closing it
+func (l *lifecycle) onClose(stage, name string, close func() error) {
+	l.onStop(stage, name, func(context.Context) error { return close() })
+}
+
+// goroutine runs fn on a goroutine that stage waits for after stopping its
+// components, fn must return once they are stopped
+func (l *lifecycle) goroutine(stage string, fn func()) {
+	wg := l.goroutines[stage]
+	wg.Add(1)
+	l.started[stage]++
+	go func() {
+		defer wg.Done()
+		fn()
+	}()
+}
+
+// shutdown stops every stage in order with the timeouts of cfg and returns
+// the errors of the components that failed or did not stop in time. Only the
+// first call does anything
+func (l *lifecycle) shutdown(cfg config.ServerConfig) error {
+	l.once.Do(func() {
+		timeouts := map[string]time.Duration{
+			stageHTTP:     cfg.ShutdownTimeout,
+			stageWorkers:  cfg.Shutdown.Workers,
+			stageEvents:   cfg.Shutdown.Events,
+			stageCache:    cfg.Shutdown.Cache,
+			stageDatabase: cfg.Shutdown.Database,
+		}
+
+		var errs []error
+		for _, stage := range shutdownOrder {
+			if err := l.stopStage(stage, timeouts[stage]); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		l.err = errors.Join(errs...)
+	})
+	return l.err
+}
+
+// stopStage stops the components of a stage and waits for its goroutines,
+// abandoning whatever is still running when the timeout expires
+func (l *lifecycle) stopStage(stage string, timeout time.Duration) error {
+	components := l.components[stage]
+	if len(components) == 0 && l.started[stage] == 0 {
+		return nil
+	}
+
+	l.logger.Info("shutting down", "stage", stage, "timeout", timeout)
+	start := time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var errs []error
+	for i := len(components) - 1; i >= 0; i-- {
+		c := components[i]
+		if err := stopWithin(ctx, c.stop); err != nil {
+			l.logger.Error("failed to stop component", "stage", stage, "component", c.name, "error", err)
+			errs = append(errs, fmt.Errorf("failed to stop %s: %w", c.name, err))
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		l.goroutines[stage].Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		l.logger.Error("background goroutines did not stop in time", "stage", stage)
+		errs = append(errs, fmt.Errorf("%s goroutines did not stop within %s", stage, timeout))
+	}
+
+	l.logger.Info("shut down", "stage", stage, "duration", time.Since(start))
+	return errors.Join(errs...)
+}
+
+// stopWithin runs stop and returns when it does or ctx is done, so stop
+// functions that ignore ctx cannot block the shutdown
+func stopWithin(ctx context.Context, stop stopFunc) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- stop(ctx) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
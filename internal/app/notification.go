@@ -0,0 +1,39 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/mail"
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/notifications"
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"github.com/MuthuM3/gin-microservice-template/internal/webhook"
+)
+
+// newNotificationService creates the notification service with a notifier
+// per channel. Notifications are already queued per channel, so emails are
+// sent directly rather than queued a second time
+func (a *App) newNotificationService() (*service.NotificationService, error) {
+	cfg := a.config.Notifications
+	mailer, err := mail.New(&a.config.Email, a.logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create mailer: %w", err)
+	}
+
+	sender := webhook.NewSender(cfg.Timeout, cfg.AllowPrivateNetworks, a.config.Tracing.ServiceName+"/"+a.version)
+	slack := a.httpClients.Client("slack")
+	slack.Timeout = cfg.Timeout
+
+	notifiers := map[string]notifications.Notifier{
+		models.ChannelEmail:   notifications.NewEmailNotifier(mailer),
+		models.ChannelWebhook: notifications.NewWebhookNotifier(sender),
+		models.ChannelSlack:   notifications.NewSlackNotifier(slack),
+	}
+	return service.NewNotificationService(a.store.Auth(), a.store.Todos(), notifiers, a.queue, cfg, a.audit, a.logger), nil
+}
+
+// newReminderService creates the service scheduling the reminders users set
+// on their todos, it needs the notification service
+func (a *App) newReminderService() *service.ReminderService {
+	return service.NewReminderService(a.store.Todos(), a.store.Auth(), a.todos, a.notifier, a.queue, a.config.Notifications, a.logger)
+}
@@ -0,0 +1,64 @@
+package app
+
+import (
+	"context"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/cache"
+	"github.com/MuthuM3/gin-microservice-template/internal/lock"
+	"github.com/MuthuM3/gin-microservice-template/internal/retry"
+	"github.com/MuthuM3/gin-microservice-template/internal/session"
+	"github.com/redis/go-redis/v9"
+)
+
+// usesRedis reports whether any enabled subsystem is backed by Redis
+func (a *App) usesRedis() bool {
+	return (a.config.RateLimit.Enabled && a.config.RateLimit.Backend == "redis") ||
+		(a.config.Cache.Enabled && a.config.Cache.Backend == "redis") ||
+		(a.config.Events.Enabled && a.config.Events.Backend == "redis") ||
+		(a.config.Quotas.Enabled && a.config.Quotas.Backend == "redis") ||
+		a.config.Auth.Sessions.Enabled ||
+		a.config.Queue.Enabled
+}
+
+// connectRedis creates the shared Redis client and verifies connectivity
+func (a *App) connectRedis(ctx context.Context) (*redis.Client, error) {
+	client, err := cache.NewRedisClient(ctx, &a.config.Redis)
+	if err != nil {
+		return nil, err
+	}
+
+	a.logger.Info("redis connection established", "addr", a.config.Redis.GetAddress())
+	return client, nil
+}
+
+// newCache creates the cache for the configured backend, falling back to an
+// in-memory cache when no Redis client is available
+func (a *App) newCache() cache.Cache {
+	cfg := a.config.Cache
+	if cfg.Backend == "redis" && a.redis != nil {
+		redisCache := cache.NewRedisCache(a.redis, cfg.KeyPrefix, a.cacheTiers, retry.New("redis_cache", cfg.Retry, nil))
+		if b := a.newBreaker("redis_cache", cache.IsOutage); b != nil {
+			return cache.WithBreaker(redisCache, b)
+		}
+		return redisCache
+	}
+	return cache.NewMemoryCache(cfg.KeyPrefix, a.cacheTiers)
+}
+
+// newLocker creates the locker electing the replica that runs singleton
+// work, locks only hold within this process when Redis is unavailable
+func (a *App) newLocker() lock.Locker {
+	if a.redis != nil {
+		return lock.NewRedisLocker(a.redis, a.config.Cache.KeyPrefix)
+	}
+	return lock.NewMemoryLocker()
+}
+
+// newSessionStore creates the store of cookie sessions, sessions only live
+// in this process when Redis is unavailable
+func (a *App) newSessionStore() session.Store {
+	if a.redis != nil {
+		return session.NewRedisStore(a.redis, a.config.Cache.KeyPrefix)
+	}
+	return session.NewMemoryStore()
+}
@@ -0,0 +1,109 @@
+package app
+
+import (
+	"strings"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+)
+
+// ConfigLoader re-reads the configuration from its source
+type ConfigLoader func() (*config.Config, error)
+
+// WatchConfig enables reloading the configuration on SIGHUP. Only the log
+// level, rate limit quota, body captures, CORS policy and cache TTLs are
+// applied at runtime; other changes are logged and take effect on the next
+// restart
+func (a *App) WatchConfig(load ConfigLoader) {
+	a.loadConfig = load
+}
+
+// reloadable reports whether the setting at path can be applied without a restart
+func reloadable(path string) bool {
+	switch {
+	case path == "logger.level",
+		path == "rate_limit.requests_per_window",
+		path == "rate_limit.window",
+		strings.HasPrefix(path, "cors."),
+		path == "logger.capture.routes",
+		path == "logger.capture.request_ids",
+		path == "logger.capture.redact_fields",
+		path == "logger.capture.max_body_size",
+		strings.HasPrefix(path, "cache.") && strings.HasSuffix(path, "_ttl"):
+		return true
+	}
+	return false
+}
+
+// reload re-reads the configuration and applies the reloadable sections. An
+// invalid configuration is rejected as a whole and the running one is kept
+func (a *App) reload() {
+	next, err := a.loadConfig()
+	if err != nil {
+		a.logger.Error("config reload failed, keeping current configuration", "error", err)
+		return
+	}
+
+	changes := config.Diff(a.config, next)
+	if len(changes) == 0 {
+		a.logger.Info("config reloaded, no changes")
+		return
+	}
+
+	// Start from the running config so pending restart-only changes keep
+	// being reported until the process is restarted
+	updated := *a.config
+	applied := 0
+
+	for _, change := range changes {
+		if !reloadable(change.Path) {
+			a.logger.Warn("config change requires restart", "path", change.Path, "old", change.Old, "new", change.New)
+			continue
+		}
+		a.logger.Info("config change applied", "path", change.Path, "old", change.Old, "new", change.New)
+		applied++
+	}
+
+	if applied == 0 {
+		return
+	}
+
+	updated.Logger.Level = next.Logger.Level
+	if setter, ok := a.logger.(interface{ SetLevel(string) error }); ok {
+		if err := setter.SetLevel(updated.Logger.Level); err != nil {
+			a.logger.Error("failed to apply log level", "error", err)
+		}
+	}
+
+	updated.RateLimit.RequestsPerWindow = next.RateLimit.RequestsPerWindow
+	updated.RateLimit.Window = next.RateLimit.Window
+	if limiter, ok := a.limiter.(interface{ SetLimit(int, time.Duration) }); ok {
+		limiter.SetLimit(updated.RateLimit.RequestsPerWindow, updated.RateLimit.Window)
+	}
+
+	updated.Logger.Capture.Routes = next.Logger.Capture.Routes
+	updated.Logger.Capture.RequestIDs = next.Logger.Capture.RequestIDs
+	updated.Logger.Capture.RedactFields = next.Logger.Capture.RedactFields
+	updated.Logger.Capture.MaxBodySize = next.Logger.Capture.MaxBodySize
+	if a.capture != nil {
+		a.capture.Update(updated.Logger.Capture)
+	}
+
+	updated.CORS = next.CORS
+	if a.cors != nil {
+		a.cors.Update(updated.CORS)
+	}
+
+	updated.Cache.DefaultTTL = next.Cache.DefaultTTL
+	updated.Cache.LongTTL = next.Cache.LongTTL
+	updated.Cache.ShortTTL = next.Cache.ShortTTL
+	updated.Cache.SessionTTL = next.Cache.SessionTTL
+	updated.Cache.StateTTL = next.Cache.StateTTL
+	if a.cacheTiers != nil {
+		a.cacheTiers.Update(updated.Cache)
+	}
+
+	a.config = &updated
+	a.running.Store(a.config)
+	a.logger.Info("config reloaded", "applied", applied, "changes", len(changes))
+}
@@ -0,0 +1,401 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/apierror"
+	"github.com/MuthuM3/gin-microservice-template/internal/clientinfo"
+	"github.com/MuthuM3/gin-microservice-template/internal/graph"
+	"github.com/MuthuM3/gin-microservice-template/internal/handlers"
+	"github.com/MuthuM3/gin-microservice-template/internal/httpclient"
+	"github.com/MuthuM3/gin-microservice-template/internal/i18n"
+	"github.com/MuthuM3/gin-microservice-template/internal/metrics"
+	"github.com/MuthuM3/gin-microservice-template/internal/middleware"
+	"github.com/MuthuM3/gin-microservice-template/internal/openapi"
+	"github.com/MuthuM3/gin-microservice-template/internal/render"
+	"github.com/MuthuM3/gin-microservice-template/internal/schema"
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"github.com/MuthuM3/gin-microservice-template/internal/validation"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// Routes exposes the router groups to route registrars. The v1 groups are
+// named after their resource, v2 only serves auth, todos and tags so far;
+// routes registered on a retired version answer 410 Gone
+type Routes struct {
+	Engine   *gin.Engine
+	V1       *gin.RouterGroup // /api/v1
+	V2       *gin.RouterGroup // /api/v2
+	Auth     *gin.RouterGroup // /api/v1/auth
+	Todos    *gin.RouterGroup // /api/v1/todos
+	Tags     *gin.RouterGroup // /api/v1/tags
+	Me       *gin.RouterGroup // /api/v1/me
+	Events   *gin.RouterGroup // /api/v1/events
+	Shared   *gin.RouterGroup // /api/v1/shared, nil unless sharing is enabled
+	Webhooks *gin.RouterGroup // /api/v1/webhooks, nil unless webhooks are enabled
+	Inbound  *gin.RouterGroup // /webhooks, nil unless inbound webhooks are enabled
+	SCIM     *gin.RouterGroup // /scim/v2, nil unless SCIM provisioning is enabled
+	GraphQL  *gin.RouterGroup // /api/v1/graphql, nil unless GraphQL is enabled
+	Admin    *gin.RouterGroup // /api/v1/admin, for the admin token and users with the admin role
+
+	// RequireAuth rejects requests without a valid access token
+	RequireAuth gin.HandlerFunc
+
+	// CountCalls counts the requests of the authenticated user against their
+	// daily API call quota, it runs after RequireAuth and lets everything
+	// through when quotas are disabled
+	CountCalls gin.HandlerFunc
+
+	// HTTPClients creates clients for calls to downstream services
+	HTTPClients *httpclient.Factory
+}
+
+// RouteRegistrar registers additional handlers on the application router
+type RouteRegistrar func(r *Routes)
+
+// RegisterRoutes adds route registrars that are applied when the router is
+// built, letting users of the template plug in their own handlers
+func (a *App) RegisterRoutes(registrars ...RouteRegistrar) {
+	a.registrars = append(a.registrars, registrars...)
+}
+
+// newRouter builds the gin engine with all middleware and route groups
+func (a *App) newRouter() (*gin.Engine, error) {
+	if a.config.Server.IsProduction() {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	validator, err := validation.New(&a.config.Security)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request validator: %w", err)
+	}
+	binding.Validator = validator
+
+	bundle, err := i18n.Load(a.config.I18n.Dir, a.config.I18n.DefaultLanguage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load message bundles: %w", err)
+	}
+
+	schemas, err := schema.New()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load request schemas: %w", err)
+	}
+	if err := checkSchemas(schemas); err != nil {
+		return nil, err
+	}
+
+	clients, err := clientinfo.NewResolver(a.config.Server.TrustedProxies, a.config.Server.ClientIPHeaders)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client address resolver: %w", err)
+	}
+
+	engine := gin.New()
+	// Client addresses come from ClientInfo, which only believes trusted
+	// proxies, c.ClientIP falls back to the peer address
+	engine.ForwardedByClientIP = false
+	if a.inspect != nil {
+		engine.Use(a.inspect)
+	}
+	a.cors = middleware.NewCORSPolicy(a.config.CORS)
+	engine.Use(middleware.ErrorRate(a.requests), middleware.Recovery(a.logger, a.reporter), middleware.RequestID(), middleware.ClientInfo(clients), middleware.Tracing(), middleware.CORS(a.cors),
+		middleware.Language(bundle), middleware.Negotiation(a.responseFormats()))
+	if a.config.Logger.RequestLog.Enabled {
+		engine.Use(middleware.RequestLogger(a.logger, a.config.Logger.RequestLog))
+	}
+	if a.accessLog != nil {
+		engine.Use(middleware.AccessLog(a.accessLog, a.config.Logger.AccessLog.Format))
+	}
+	if a.capture != nil {
+		engine.Use(middleware.CaptureBodies(a.capture, a.captures, a.logger))
+	}
+	security := a.bodySecurity()
+	engine.Use(middleware.Errors(), middleware.BodyLimit(security), a.policies.Handler())
+	if a.signatures != nil {
+		engine.Use(a.signatures.Handler())
+	}
+	if security.ContentTypeValidation {
+		engine.Use(middleware.RequireJSON(security))
+	}
+	if a.config.Schemas.Validate {
+		engine.Use(middleware.ValidateSchemas(schemas, requestSchemas))
+	}
+
+	engine.NoRoute(func(c *gin.Context) {
+		middleware.AbortWithError(c, apierror.NotFound("route not found"))
+	})
+
+	// Operational endpoints, metrics and profiling move to the admin server
+	// when it is enabled. The detailed health check is only served here when
+	// the admin token protects it
+	engine.GET("/readyz", a.ready)
+	handlers.NewSchemaHandler(schemas).RegisterRoutes(engine.Group("/schemas"))
+	if !a.config.AdminServer.Enabled {
+		if a.config.Metrics.Enabled {
+			engine.GET(a.config.Metrics.PrometheusPath, gin.WrapH(metrics.Handler()))
+		}
+		if a.config.Performance.IsProfilingEnabled() {
+			handlers.RegisterProfiling(engine.Group(a.config.Performance.ProfilingPath, middleware.AdminToken(a.config.Security.AdminToken)))
+		}
+		if a.config.Security.AdminToken != "" {
+			engine.GET("/health/detailed", middleware.AdminToken(a.config.Security.AdminToken), a.detailedHealth)
+		}
+	}
+
+	// Load shedding and rate limiting are shared by the API versions, one
+	// limit covers them all
+	throttle := []gin.HandlerFunc{
+		middleware.ConcurrencyLimit(a.config.Performance.MaxConcurrentRequests, a.config.Performance.QueueTimeout),
+	}
+	if a.limiter != nil {
+		throttle = append(throttle, a.policies.RateLimit(middleware.RateLimit(a.limiter, a.rateLimitKey(), a.logger)))
+	}
+
+	// The health check is registered first so it is never throttled, and
+	// still answers once v1 is retired
+	v1 := engine.Group("/api/v1")
+	v1.GET("/health", a.health)
+	v1.Use(middleware.APIVersion("v1", a.config.API.V1))
+	v1.Use(throttle...)
+
+	v2 := engine.Group("/api/v2", middleware.APIVersion("v2", a.config.API.V2))
+	v2.Use(throttle...)
+
+	routes := &Routes{
+		Engine: engine,
+		V1:     v1,
+		V2:     v2,
+		Auth:   v1.Group("/auth"),
+		Todos:  v1.Group("/todos"),
+		Tags:   v1.Group("/tags"),
+		Me:     v1.Group("/me"),
+		Events: v1.Group("/events"),
+
+		HTTPClients: a.httpClients,
+	}
+	if a.shares != nil {
+		routes.Shared = v1.Group("/shared")
+	}
+	if a.webhooks != nil {
+		routes.Webhooks = v1.Group("/webhooks")
+	}
+	if a.config.Inbound.Enabled {
+		// Outside /api/v1, providers authenticate by signature rather than
+		// by token and are not rate limited like API clients
+		routes.Inbound = engine.Group("/webhooks")
+	}
+	if a.config.Auth.SCIM.Enabled {
+		// Outside /api/v1, SCIM clients expect its own paths and error format
+		routes.SCIM = engine.Group("/scim/v2")
+	}
+	if a.config.GraphQL.Enabled {
+		routes.GraphQL = v1.Group("/graphql")
+	}
+	routes.Admin = v1.Group("/admin")
+
+	if err := a.registerHandlers(routes); err != nil {
+		return nil, err
+	}
+
+	for _, register := range a.registrars {
+		register(routes)
+	}
+
+	// The document is built last so it covers every route registered above
+	if a.config.Docs.Enabled {
+		if err := a.registerDocs(engine); err != nil {
+			return nil, err
+		}
+	}
+
+	return engine, nil
+}
+
+// registerDocs serves the OpenAPI document of the routes registered so far
+// and, outside production, the Swagger UI
+func (a *App) registerDocs(engine *gin.Engine) error {
+	spec := openapi.New(a.config.Tracing.ServiceName, a.version)
+	handlers.Describe(spec)
+	if v1 := a.config.API.V1; v1.Deprecated != "" || !v1.Enabled {
+		spec.Deprecate("/api/v1/")
+	}
+	if v2 := a.config.API.V2; v2.Deprecated != "" || !v2.Enabled {
+		spec.Deprecate("/api/v2/")
+	}
+	spec.Describe(a.health, openapi.Operation{Summary: "Liveness check", Tags: []string{"health"}})
+	spec.Describe(a.ready, openapi.Operation{Summary: "Readiness check", Tags: []string{"health"}})
+	spec.Describe(a.detailedHealth, openapi.Operation{Summary: "Database health and connection pool statistics", Tags: []string{"health"}})
+	spec.Describe(a.systemInfo, openapi.Operation{
+		Summary: "Operational state of the process", Tags: []string{"admin"},
+		Description: "Runtime, a summary of the configuration with secrets masked, database pool, cache and " +
+			"queue statistics and the rate of server errors over the last 1, 5 and 15 minutes",
+		Response: systemReport{}, Security: openapi.AdminAuth,
+	})
+
+	docs, err := handlers.NewDocsHandler(spec.Build(engine.Routes()))
+	if err != nil {
+		return err
+	}
+	engine.GET("/openapi.json", docs.Spec)
+	if !a.config.Server.IsProduction() {
+		engine.GET("/docs", docs.UI)
+	}
+	return nil
+}
+
+// registerHandlers mounts the built-in API handlers
+func (a *App) registerHandlers(r *Routes) error {
+	mailer, err := a.newMailer()
+	if err != nil {
+		return fmt.Errorf("failed to create mailer: %w", err)
+	}
+
+	authService, err := service.NewAuthService(a.store.Auth(), a.tokens, &a.config.Security, a.lockout, mailer, &a.config.Email, a.audit, a.securityLog, a.sessions)
+	if err != nil {
+		return fmt.Errorf("failed to create auth service: %w", err)
+	}
+	var sessions *service.SessionService
+	var cookies *middleware.CookieSessions
+	if a.sessions != nil {
+		sessions = service.NewSessionService(a.sessions, authService, a.cacheTiers, &a.config.Security, a.audit)
+		cookies = &middleware.CookieSessions{Cookie: a.config.Auth.Sessions.CookieName, Sessions: sessions}
+	}
+	r.RequireAuth = a.policies.Authenticate(middleware.Auth(a.tokens, a.store.Auth(), cookies, a.logger))
+	r.CountCalls = func(c *gin.Context) { c.Next() }
+	if a.quotas != nil {
+		r.CountCalls = middleware.CallQuota(a.quotas, a.logger)
+	}
+	handlers.NewAuthHandler(authService).RegisterRoutes(r.Auth, r.RequireAuth)
+	if a.securityLog != nil {
+		handlers.NewActivityHandler(a.securityLog).RegisterRoutes(r.Auth, r.RequireAuth)
+	}
+	if sessions != nil {
+		handlers.NewSessionHandler(sessions, a.sessionCookie()).RegisterRoutes(r.Auth, r.RequireAuth)
+	}
+	if len(a.oauth) > 0 {
+		handlers.NewOAuthHandler(authService, a.oauth, a.config.Auth.OAuthStateTTL, a.config.Server.IsProduction()).
+			RegisterRoutes(r.Auth)
+	}
+	r.Admin.Use(middleware.RequireAdmin(a.config.Security.AdminToken, r.RequireAuth, a.store.Auth(), a.logger)...)
+	handlers.NewAdminHandler(authService, a.audit).RegisterRoutes(r.Admin)
+	r.Admin.GET("/system", a.systemInfo)
+	if a.captures != nil {
+		handlers.NewCaptureHandler(a.captures).RegisterRoutes(r.Admin)
+	}
+	if a.queue != nil {
+		handlers.NewQueueHandler(service.NewQueueService(a.queue, a.config.Pagination)).RegisterRoutes(r.Admin)
+	}
+
+	r.Todos.Use(r.RequireAuth, r.CountCalls)
+	handlers.NewTodoHandler(a.todos).RegisterRoutes(r.Todos)
+	exports := a.newExportService(mailer)
+	handlers.NewExportHandler(exports).RegisterRoutes(r.Todos, r.V1)
+	handlers.NewAttachmentHandler(a.attachments).RegisterRoutes(r.Todos)
+	if r.Shared != nil {
+		r.Shared.Use(r.RequireAuth, r.CountCalls)
+		handlers.NewShareHandler(a.shares).RegisterRoutes(r.Todos, r.Shared)
+	}
+
+	r.Me.Use(r.RequireAuth, r.CountCalls)
+	handlers.NewProfileHandler(service.NewProfileService(a.store.Auth(), a.store.Todos(), a.audit)).RegisterRoutes(r.Me)
+	handlers.NewAccountHandler(a.accounts, service.NewArchiveService(a.store, a.todos, exports)).RegisterRoutes(r.Me)
+	if a.notifier != nil {
+		handlers.NewNotificationHandler(a.notifier).RegisterRoutes(r.Me)
+		handlers.NewReminderHandler(a.newReminderService()).RegisterRoutes(r.Todos)
+	}
+
+	tagService := service.NewTagService(a.store.Todos(), a.audit, a.todoCache)
+	r.Tags.Use(r.RequireAuth, r.CountCalls)
+	handlers.NewTagHandler(tagService).RegisterRoutes(r.Tags)
+
+	a.registerV2(r, authService, tagService)
+
+	if r.Webhooks != nil {
+		r.Webhooks.Use(r.RequireAuth, r.CountCalls)
+		handlers.NewWebhookHandler(a.webhooks).RegisterRoutes(r.Webhooks)
+	}
+
+	if a.quotas != nil {
+		// Not counted, users can check their usage once the quota is spent
+		handlers.NewQuotaHandler(a.quotas).RegisterRoutes(r.V1.Group("/quota", r.RequireAuth), r.Admin)
+	}
+
+	if r.Inbound != nil {
+		handlers.NewInboundWebhookHandler(a.newInboundService()).RegisterRoutes(r.Inbound)
+	}
+
+	if r.SCIM != nil {
+		provisioning := service.NewUserProvisioning(a.store.Auth(), a.sessions, a.audit, a.logger)
+		handlers.NewSCIMHandler(provisioning, a.config.Auth.SCIM.Token, a.config.Auth.SCIM.MaxResults).RegisterRoutes(r.SCIM)
+	}
+
+	if a.feed != nil {
+		r.Events.Use(r.RequireAuth, r.CountCalls)
+		handlers.NewEventHandler(a.feed, a.config.Events.HeartbeatInterval).RegisterRoutes(r.Events)
+	}
+
+	if r.GraphQL != nil {
+		schema, err := graph.NewSchema(a.todos, tagService, authService, a.feed, a.config.GraphQL.MaxDepth)
+		if err != nil {
+			return err
+		}
+		r.GraphQL.Use(r.RequireAuth, r.CountCalls)
+		a.graphql = handlers.NewGraphQLHandler(schema, a.config.Events.HeartbeatInterval)
+		a.graphql.RegisterRoutes(r.GraphQL)
+	}
+
+	return nil
+}
+
+// registerV2 mounts the API v2 handlers. Resources whose representation did
+// not change are served by their v1 handlers
+func (a *App) registerV2(r *Routes, auth *service.AuthService, tags *service.TagService) {
+	handlers.NewAuthHandler(auth).RegisterRoutes(r.V2.Group("/auth"), r.RequireAuth)
+	handlers.NewTodoV2Handler(a.todos).RegisterRoutes(r.V2.Group("/todos", r.RequireAuth, r.CountCalls))
+	handlers.NewTagHandler(tags).RegisterRoutes(r.V2.Group("/tags", r.RequireAuth, r.CountCalls))
+}
+
+// health reports the liveness of the service
+func (a *App) health(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "ok",
+		"version": a.version,
+	})
+}
+
+// ready reports whether the service can handle traffic, it fails while the
+// database is unreachable so load balancers hold off during degraded starts
+func (a *App) ready(c *gin.Context) {
+	if !a.store.IsHealthy() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ready"})
+}
+
+// responseFormats returns the formats responses can be negotiated in, JSON
+// first as the default
+func (a *App) responseFormats() []string {
+	formats := []string{render.MIMEJSON, render.MIMEXML}
+	if a.config.Responses.MsgPack {
+		formats = append(formats, render.MIMEMsgPack)
+	}
+	return formats
+}
+
+// sessionCookie describes the cookie of cookie sessions, it is only sent
+// over HTTPS in production
+func (a *App) sessionCookie() handlers.SessionCookie {
+	cfg := a.config.Auth.Sessions
+	sameSite := http.SameSiteLaxMode
+	if cfg.SameSite == "strict" {
+		sameSite = http.SameSiteStrictMode
+	}
+	return handlers.SessionCookie{
+		Name:     cfg.CookieName,
+		Domain:   cfg.CookieDomain,
+		SameSite: sameSite,
+		Secure:   a.config.Server.IsProduction(),
+	}
+}
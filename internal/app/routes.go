@@ -0,0 +1,205 @@
+package app
+
+import (
+	"io"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/auth"
+	"github.com/MuthuM3/gin-microservice-template/internal/cache"
+	"github.com/MuthuM3/gin-microservice-template/internal/capture"
+	"github.com/MuthuM3/gin-microservice-template/internal/events"
+	"github.com/MuthuM3/gin-microservice-template/internal/httpclient"
+	"github.com/MuthuM3/gin-microservice-template/internal/middleware"
+	"github.com/MuthuM3/gin-microservice-template/internal/oauth"
+	"github.com/MuthuM3/gin-microservice-template/internal/reporting"
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage/memory"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// RouteInfo describes a registered route. Middleware lists the handlers
+// that run before Handler, in order
+type RouteInfo struct {
+	Server     string   `json:"server"`
+	Method     string   `json:"method"`
+	Path       string   `json:"path"`
+	Middleware []string `json:"middleware"`
+	Handler    string   `json:"handler"`
+}
+
+// closureSuffix matches the suffixes the runtime gives closures and method
+// values, "middleware.Auth.func1" is reported as "middleware.Auth"
+var closureSuffix = regexp.MustCompile(`(\.func\d+)+$|-fm$`)
+
+// ListRoutes builds the routers the configuration would serve, without
+// connecting to any backend or listening, and returns their routes sorted
+// by server, path and method
+func (a *App) ListRoutes() ([]RouteInfo, error) {
+	if err := a.prepareOffline(); err != nil {
+		return nil, err
+	}
+
+	var chains map[string][]string
+	a.inspect = func(c *gin.Context) {
+		chains[c.Request.Method+" "+c.FullPath()] = c.HandlerNames()[1:]
+		c.Abort()
+	}
+
+	engines := map[string]func() (*gin.Engine, error){"api": a.newRouter}
+	if a.config.AdminServer.Enabled {
+		engines["admin"] = func() (*gin.Engine, error) { return a.newAdminRouter(), nil }
+	}
+
+	routes := []RouteInfo{}
+	for server, build := range engines {
+		engine, err := build()
+		if err != nil {
+			return nil, err
+		}
+
+		chains = make(map[string][]string)
+		for _, route := range engine.Routes() {
+			probe := httptest.NewRequest(route.Method, probePath(route.Path), nil)
+			engine.ServeHTTP(httptest.NewRecorder(), probe)
+
+			names := chains[route.Method+" "+route.Path]
+			info := RouteInfo{Server: server, Method: route.Method, Path: route.Path, Middleware: []string{}}
+			for i, name := range names {
+				if i == len(names)-1 {
+					info.Handler = shortHandlerName(name)
+				} else {
+					info.Middleware = append(info.Middleware, shortHandlerName(name))
+				}
+			}
+			if info.Handler == "" {
+				info.Handler = shortHandlerName(route.Handler)
+			}
+			routes = append(routes, info)
+		}
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Server != routes[j].Server {
+			return routes[i].Server < routes[j].Server
+		}
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes, nil
+}
+
+// prepareOffline stands in for the backends run connects to: storage is
+// in memory and the Redis client never dials, so every route the
+// configuration enables is registered without reaching the network
+func (a *App) prepareOffline() error {
+	gin.SetMode(gin.ReleaseMode)
+
+	reporter, err := reporting.New(&a.config.Sentry, a.config.Server.Environment, a.version)
+	if err != nil {
+		return err
+	}
+	a.reporter = reporter
+	a.store = memory.New()
+	if a.usesRedis() {
+		a.redis = redis.NewClient(&redis.Options{Addr: a.config.Redis.GetAddress()})
+	}
+	a.queue = a.newQueueClient()
+	a.locker = a.newLocker()
+	a.cacheTiers = cache.NewTiers(a.config.Cache)
+	if a.config.Cache.Enabled {
+		a.cacheStats = &cache.Stats{}
+		a.cache = cache.WithStats(a.newCache(), a.cacheStats)
+		a.todoCache = service.NewTodoCache(a.cache, a.cacheTiers)
+	}
+	a.tokens = auth.NewTokenManager(&a.config.JWT)
+	a.httpClients = httpclient.NewFactory(a.config.HTTPClient, a.config.CircuitBreaker, a.logger)
+	if a.config.Audit.Enabled {
+		a.audit = service.NewAuditLogger(a.store.Audit(), a.config.Pagination, a.logger)
+	}
+	if a.config.Quotas.Enabled {
+		a.quotas = service.NewQuotaService(a.store.Quotas(), a.store.Auth(), a.store.Todos(), a.newCallCounter(),
+			a.config.Quotas, a.audit, a.logger)
+	}
+	if a.config.Events.Enabled {
+		a.bus = events.NewMemoryBus()
+		a.feed = service.NewTodoFeed(a.config.Events.ReplayBuffer)
+	}
+	if a.config.Webhooks.Enabled {
+		a.webhooks = service.NewWebhookService(a.store.Webhooks(), nil, a.config.Webhooks, a.config.Pagination,
+			a.locker, a.audit, a.logger)
+	}
+	if a.config.SecurityEvents.Enabled {
+		a.securityLog = service.NewSecurityLog(a.store.SecurityEvents(), nil, a.config.SecurityEvents, a.config.Pagination, a.logger)
+	}
+	a.todos = service.NewTodoService(a.store.Todos(), a.config.Todos, a.config.Pagination, a.audit, nil, a.quotas, a.todoCache)
+	a.attachments = service.NewAttachmentService(a.store.Todos(), nil, a.config.BlobStorage.S3.PresignTTL, &a.config.Security, a.quotas, a.audit, a.logger)
+	if a.config.Auth.Sessions.Enabled {
+		a.sessions = a.newSessionStore()
+	}
+	a.accounts = service.NewAccountService(a.store, a.sessions, a.todoCache, a.audit, a.config.Accounts)
+	if a.config.Notifications.Enabled {
+		if a.notifier, err = a.newNotificationService(); err != nil {
+			return err
+		}
+	}
+	if a.config.Sharing.Enabled {
+		a.shares = service.NewShareService(a.store.Todos(), a.store.Auth(), a.todos, a.notifier, a.audit, a.config.Sharing, a.logger)
+	}
+
+	// Providers are only looked up by name when routes are registered, OIDC
+	// discovery would need the network
+	a.oauth = make(map[string]oauth.Provider)
+	for name, enabled := range map[string]bool{
+		"google":                a.config.Auth.Google.Enabled,
+		"github":                a.config.Auth.GitHub.Enabled,
+		a.config.Auth.OIDC.Name: a.config.Auth.OIDC.Enabled,
+	} {
+		if enabled {
+			a.oauth[name] = nil
+		}
+	}
+
+	a.lockout = a.newLockoutTracker()
+	if a.config.RateLimit.Enabled {
+		a.limiter = a.newRateLimiter()
+	}
+	a.policies = a.newRoutePolicies()
+	if a.config.Security.SignedRequests.Enabled {
+		a.signatures = middleware.NewSignatures(a.config.Security.SignedRequests, a.newNonceStore(), a.logger)
+	}
+	if a.config.Logger.AccessLog.Enabled {
+		a.accessLog = io.Discard
+	}
+	if cfg := a.config.Logger.Capture; cfg.Enabled && a.cache != nil {
+		a.capture = middleware.NewCapturePolicy(cfg)
+		a.captures = capture.NewStore(a.cache, cfg.TTL)
+	}
+	return nil
+}
+
+// probePath fills the parameters of a route pattern so a request for it
+// matches the route
+func probePath(pattern string) string {
+	segments := strings.Split(pattern, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "_"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// shortHandlerName trims the import path and closure suffixes from a
+// handler name reported by gin
+func shortHandlerName(name string) string {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return closureSuffix.ReplaceAllString(name, "")
+}
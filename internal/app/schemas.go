@@ -0,0 +1,34 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/schema"
+)
+
+// requestSchemas maps the routes whose bodies are validated, by method and
+// full path, to the key of their schema in the registry
+var requestSchemas = map[string]string{
+	http.MethodPost + " /api/v1/todos":              "todo/v1",
+	http.MethodPut + " /api/v1/todos/:id":           "todo/v1",
+	http.MethodPatch + " /api/v1/todos/:id":         "todo-patch/v1",
+	http.MethodPost + " /api/v1/todos/:id/subtasks": "todo/v1",
+	http.MethodPost + " /api/v1/tags":               "tag/v1",
+	http.MethodPut + " /api/v1/tags/:id":            "tag/v1",
+	http.MethodPost + " /api/v2/todos":              "todo/v2",
+	http.MethodPut + " /api/v2/todos/:id":           "todo/v2",
+	http.MethodPost + " /api/v2/tags":               "tag/v1",
+	http.MethodPut + " /api/v2/tags/:id":            "tag/v1",
+}
+
+// checkSchemas makes sure every route maps to a schema of the registry, so a
+// renamed schema fails at startup rather than on the first request
+func checkSchemas(registry *schema.Registry) error {
+	for route, key := range requestSchemas {
+		if _, ok := registry.Source(key); !ok {
+			return fmt.Errorf("route %s: %w: %s", route, schema.ErrUnknownSchema, key)
+		}
+	}
+	return nil
+}
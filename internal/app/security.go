@@ -0,0 +1,30 @@
+package app
+
+import (
+	"context"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"github.com/MuthuM3/gin-microservice-template/internal/siem"
+)
+
+// newSecurityLog creates the log of authentication events and starts
+// forwarding them to the SIEM until ctx is cancelled when export is enabled,
+// the forwarder is waited for in the workers stage of lc
+func (a *App) newSecurityLog(ctx context.Context, lc *lifecycle) *service.SecurityLog {
+	cfg := a.config.SecurityEvents
+
+	var exporter siem.Exporter
+	switch cfg.Export {
+	case "log":
+		exporter = siem.NewLogExporter(a.logger)
+	case "http":
+		exporter = siem.NewHTTPExporter(a.httpClients.Client("siem"), cfg.ExportURL, cfg.ExportToken)
+	}
+
+	var forwarder *siem.Forwarder
+	if exporter != nil {
+		forwarder = siem.NewForwarder(exporter, cfg, a.logger)
+		lc.goroutine(stageWorkers, func() { forwarder.Run(ctx) })
+	}
+	return service.NewSecurityLog(a.store.SecurityEvents(), forwarder, cfg, a.config.Pagination, a.logger)
+}
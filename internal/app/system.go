@@ -0,0 +1,161 @@
+package app
+
+import (
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/cache"
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/handlers"
+	"github.com/MuthuM3/gin-microservice-template/internal/metrics"
+	"github.com/MuthuM3/gin-microservice-template/internal/middleware"
+	"github.com/MuthuM3/gin-microservice-template/internal/queue"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage/postgres"
+	"github.com/gin-gonic/gin"
+)
+
+// errorRateWindow is how many minutes of requests are kept for the error
+// rates of the system report, the longest period it reports
+const errorRateWindow = 15
+
+// summarizedConfig lists the configuration sections included in the system
+// report, the full configuration is served by the admin server
+var summarizedConfig = []string{"server", "database", "redis", "cache", "queue", "jobs"}
+
+// features lists the optional components reported as enabled or not
+func features(cfg *config.Config) map[string]bool {
+	return map[string]bool{
+		"admin_server":      cfg.AdminServer.Enabled,
+		"audit":             cfg.Audit.Enabled,
+		"circuit_breaker":   cfg.CircuitBreaker.Enabled,
+		"events":            cfg.Events.Enabled,
+		"graphql":           cfg.GraphQL.Enabled,
+		"grpc":              cfg.GRPC.Enabled,
+		"inbound_webhooks":  cfg.Inbound.Enabled,
+		"jobs":              cfg.Jobs.Enabled,
+		"notifications":     cfg.Notifications.Enabled,
+		"queue":             cfg.Queue.Enabled,
+		"quotas":            cfg.Quotas.Enabled,
+		"rate_limit":        cfg.RateLimit.Enabled,
+		"schema_validation": cfg.Schemas.Validate,
+		"security_events":   cfg.SecurityEvents.Enabled,
+		"sharing":           cfg.Sharing.Enabled,
+		"tracing":           cfg.Tracing.Enabled,
+		"webhooks":          cfg.Webhooks.Enabled,
+	}
+}
+
+// systemReport is the operational state of the process served to internal
+// dashboards
+type systemReport struct {
+	Version  string                  `json:"version"`
+	Runtime  runtimeReport           `json:"runtime"`
+	Config   map[string]any          `json:"config"`
+	Features map[string]bool         `json:"features"`
+	Database databaseReport          `json:"database"`
+	Cache    cacheReport             `json:"cache"`
+	Queues   queueReport             `json:"queues"`
+	Errors   map[string]metrics.Rate `json:"errors"`
+}
+
+type runtimeReport struct {
+	GoVersion     string       `json:"go_version"`
+	Goroutines    int          `json:"goroutines"`
+	CPUs          int          `json:"cpus"`
+	StartedAt     time.Time    `json:"started_at"`
+	UptimeSeconds int64        `json:"uptime_seconds"`
+	Memory        memoryReport `json:"memory"`
+}
+
+type memoryReport struct {
+	AllocBytes     uint64 `json:"alloc_bytes"`
+	HeapInuseBytes uint64 `json:"heap_inuse_bytes"`
+	HeapObjects    uint64 `json:"heap_objects"`
+	SysBytes       uint64 `json:"sys_bytes"`
+	GCRuns         uint32 `json:"gc_runs"`
+	GCPauseTotalMS int64  `json:"gc_pause_total_ms"`
+}
+
+type databaseReport struct {
+	Driver  string         `json:"driver"`
+	Healthy bool           `json:"healthy"`
+	Pool    map[string]any `json:"pool,omitempty"`
+}
+
+type cacheReport struct {
+	Enabled bool                 `json:"enabled"`
+	Backend string               `json:"backend,omitempty"`
+	Stats   *cache.StatsSnapshot `json:"stats,omitempty"`
+}
+
+type queueReport struct {
+	Enabled bool          `json:"enabled"`
+	Queues  []queue.Stats `json:"queues,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// systemInfo reports runtime, configuration, database pool, cache, queue
+// and error rate figures in one payload. Figures of a dependency that
+// cannot be read are reported as an error rather than failing the request
+func (a *App) systemInfo(c *gin.Context) {
+	cfg := a.running.Load()
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	redacted := config.Redacted(cfg)
+	summary := make(map[string]any, len(summarizedConfig))
+	for _, section := range summarizedConfig {
+		summary[section] = redacted[section]
+	}
+
+	report := systemReport{
+		Version: a.version,
+		Runtime: runtimeReport{
+			GoVersion:     runtime.Version(),
+			Goroutines:    runtime.NumGoroutine(),
+			CPUs:          runtime.NumCPU(),
+			StartedAt:     a.startTime.UTC(),
+			UptimeSeconds: int64(time.Since(a.startTime).Seconds()),
+			Memory: memoryReport{
+				AllocBytes:     mem.Alloc,
+				HeapInuseBytes: mem.HeapInuse,
+				HeapObjects:    mem.HeapObjects,
+				SysBytes:       mem.Sys,
+				GCRuns:         mem.NumGC,
+				GCPauseTotalMS: time.Duration(mem.PauseTotalNs).Milliseconds(),
+			},
+		},
+		Config:   summary,
+		Features: features(cfg),
+		Database: databaseReport{Driver: cfg.Database.Driver, Healthy: a.store.IsHealthy()},
+		Cache:    cacheReport{Enabled: a.cache != nil},
+		Queues:   queueReport{Enabled: a.queue != nil},
+		Errors: map[string]metrics.Rate{
+			"1m":  a.requests.Rate(1),
+			"5m":  a.requests.Rate(5),
+			"15m": a.requests.Rate(15),
+		},
+	}
+	if pg, ok := a.store.(*postgres.Store); ok {
+		report.Database.Pool = poolStats(pg, cfg.Database.MaxOpenConns)
+	}
+	if a.cache != nil {
+		report.Cache.Backend = "memory"
+		if cfg.Cache.Backend == "redis" && a.redis != nil {
+			report.Cache.Backend = "redis"
+		}
+		stats := a.cacheStats.Snapshot()
+		report.Cache.Stats = &stats
+	}
+	if a.queue != nil {
+		stats, err := a.queue.Stats(c.Request.Context())
+		if err != nil {
+			a.logger.Warn("failed to read queue stats", "error", err)
+			report.Queues.Error = "queue stats unavailable"
+		}
+		report.Queues.Queues = stats
+	}
+
+	middleware.Render(c, http.StatusOK, handlers.Envelope{Data: report})
+}
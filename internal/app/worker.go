@@ -0,0 +1,156 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/cache"
+	"github.com/MuthuM3/gin-microservice-template/internal/httpclient"
+	"github.com/MuthuM3/gin-microservice-template/internal/mail"
+	"github.com/MuthuM3/gin-microservice-template/internal/metrics"
+	"github.com/MuthuM3/gin-microservice-template/internal/queue"
+	"github.com/MuthuM3/gin-microservice-template/internal/reporting"
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+)
+
+// HandleTask registers the handler the worker process runs for queued tasks
+// of taskType, letting users of the template move slow work such as export
+// generation off the request path
+func (a *App) HandleTask(taskType string, fn queue.HandlerFunc) {
+	if a.tasks == nil {
+		a.tasks = make(map[string]queue.HandlerFunc)
+	}
+	a.tasks[taskType] = fn
+}
+
+// newQueueClient creates the task queue client, nil when the queue is
+// disabled or Redis is unavailable in development, work then runs inline
+func (a *App) newQueueClient() *queue.Client {
+	if !a.config.Queue.Enabled {
+		return nil
+	}
+	if a.redis == nil {
+		a.logger.Warn("task queue unavailable without redis, running tasks inline")
+		return nil
+	}
+	return queue.NewClient(a.redis, a.config.Queue, a.config.Cache.KeyPrefix)
+}
+
+// newMailer sends emails through the task queue when there is one, so
+// requests do not wait for the mail server
+func (a *App) newMailer() (mail.Mailer, error) {
+	if a.queue != nil {
+		return mail.NewQueuedMailer(a.queue), nil
+	}
+	return mail.New(&a.config.Email, a.logger)
+}
+
+// RunWorker processes queued tasks until a shutdown signal (SIGINT/SIGTERM)
+// is received, letting running tasks finish first
+func (a *App) RunWorker() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return a.runWorker(ctx)
+}
+
+func (a *App) runWorker(ctx context.Context) (err error) {
+	if !a.config.Queue.Enabled {
+		return errors.New("the task queue is disabled, enable queue.enabled to run a worker")
+	}
+
+	a.running.Store(a.config)
+	metrics.RegisterRuntimeMetrics(metrics.Default, a.startTime)
+
+	reporter, err := reporting.New(&a.config.Sentry, a.config.Server.Environment, a.version)
+	if err != nil {
+		return fmt.Errorf("failed to initialize error reporting: %w", err)
+	}
+	a.reporter = reporter
+	defer reporter.Flush(2 * time.Second)
+
+	// The queue worker has drained by the time run returns, the lifecycle
+	// stops the admin server and the connections after it
+	lc := newLifecycle(a.logger)
+	defer func() {
+		if shutdownErr := lc.shutdown(a.config.Server); err == nil {
+			err = shutdownErr
+		}
+	}()
+
+	store, err := a.newStore(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	a.store = store
+	lc.onClose(stageDatabase, "store", store.Close)
+
+	// Unlike the API there is no inline fallback, a worker needs the queue
+	client, err := a.connectRedis(ctx)
+	if err != nil {
+		return err
+	}
+	a.redis = client
+	lc.onClose(stageDatabase, "redis", client.Close)
+	a.queue = a.newQueueClient()
+
+	mailer, err := mail.New(&a.config.Email, a.logger)
+	if err != nil {
+		return fmt.Errorf("failed to create mailer: %w", err)
+	}
+
+	a.httpClients = httpclient.NewFactory(a.config.HTTPClient, a.config.CircuitBreaker, a.logger)
+	lc.onClose(stageWorkers, "http clients", func() error {
+		a.httpClients.CloseIdleConnections()
+		return nil
+	})
+
+	a.cacheTiers = cache.NewTiers(a.config.Cache)
+	a.todos = service.NewTodoService(a.store.Todos(), a.config.Todos, a.config.Pagination, nil, nil, nil, nil)
+
+	worker := queue.NewWorker(a.queue, a.logger)
+	worker.Handle(mail.TaskSend, mail.SendHandler(mailer))
+	exports := a.newExportService(mailer)
+	worker.Handle(service.TaskExportTodos, exports.HandleTask)
+	worker.Handle(service.TaskExportAccount, service.NewArchiveService(a.store, a.todos, exports).HandleTask)
+	if a.config.Notifications.Enabled {
+		if a.notifier, err = a.newNotificationService(); err != nil {
+			return err
+		}
+		worker.Handle(service.TaskNotify, a.notifier.HandleTask)
+		worker.Handle(service.TaskReminder, a.newReminderService().HandleTask)
+	}
+	if a.config.Inbound.Enabled {
+		worker.Handle(service.TaskInboundWebhook, a.newInboundService().HandleTask)
+	}
+	for taskType, fn := range a.tasks {
+		worker.Handle(taskType, fn)
+	}
+
+	// Health checks, metrics and profiling of the worker
+	if a.config.AdminServer.Enabled {
+		admin := &http.Server{
+			Addr:        a.config.AdminServer.GetAddress(),
+			Handler:     a.newAdminRouter(),
+			ReadTimeout: a.config.Server.ReadTimeout,
+			IdleTimeout: a.config.Server.IdleTimeout,
+		}
+		lc.goroutine(stageHTTP, func() {
+			a.logger.Info("admin http server listening", "addr", admin.Addr)
+			if err := admin.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				a.logger.Error("admin http server failed", "error", err)
+			}
+		})
+		lc.onStop(stageHTTP, "admin http server", admin.Shutdown)
+	}
+
+	err = worker.Run(ctx)
+	a.logger.Info("queue worker stopped")
+	return err
+}
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrInvalidToken is returned when a token cannot be verified
+var ErrInvalidToken = errors.New("invalid token")
+
+// Claims are the JWT claims issued by the service
+type Claims struct {
+	Email     string `json:"email"`
+	SessionID string `json:"sid"`
+	jwt.RegisteredClaims
+}
+
+// UserID returns the user id stored in the subject claim
+func (c *Claims) UserID() (int64, error) {
+	return strconv.ParseInt(c.Subject, 10, 64)
+}
+
+// TokenManager issues and verifies signed JWTs
+type TokenManager struct {
+	secret     []byte
+	issuer     string
+	expiration time.Duration
+}
+
+func NewTokenManager(cfg *config.JWTConfig) *TokenManager {
+	return &TokenManager{
+		secret:     []byte(cfg.Secret),
+		issuer:     cfg.Issuer,
+		expiration: cfg.Expiration,
+	}
+}
+
+// Generate issues a signed access token for the user's session and returns it
+// with its expiry
+func (m *TokenManager) Generate(user *models.User, sessionID string) (string, time.Time, error) {
+	return m.GenerateWithTTL(user, sessionID, m.expiration)
+}
+
+// GenerateWithTTL is Generate with an expiry other than the configured one
+func (m *TokenManager) GenerateWithTTL(user *models.User, sessionID string, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+
+	jti, err := RandomToken(16)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	claims := Claims{
+		Email:     user.Email,
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Issuer:    m.issuer,
+			Subject:   strconv.FormatInt(user.ID, 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.secret)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	return token, expiresAt, nil
+}
+
+// Parse verifies the token signature, issuer and expiry and returns its claims
+func (m *TokenManager) Parse(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(*jwt.Token) (any, error) {
+		return m.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(m.issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, fmt.Errorf("%w: token expired", ErrInvalidToken)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	return claims, nil
+}
+
+// Inspect decodes the claims of a token without verifying it and returns
+// them with the error Parse reports for it, nil when the token is valid.
+// The claims are nil when the token cannot be decoded at all
+func (m *TokenManager) Inspect(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(tokenString, claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	_, err := m.Parse(tokenString)
+	return claims, err
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"fmt"
+	"unicode"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// maxPasswordLength is the longest password bcrypt can hash
+const maxPasswordLength = 72
+
+// HashPassword hashes the password with bcrypt
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// CheckPassword reports whether password matches the bcrypt hash
+func CheckPassword(hash, password string) bool {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	return err == nil
+}
+
+// ValidatePassword checks the password against the configured policy and
+// returns every rule it violates
+func ValidatePassword(policy *config.SecurityConfig, password string) []string {
+	var violations []string
+
+	if len(password) < policy.PasswordMinLength {
+		violations = append(violations, fmt.Sprintf("password must be at least %d characters", policy.PasswordMinLength))
+	}
+	if len(password) > maxPasswordLength {
+		violations = append(violations, fmt.Sprintf("password must be at most %d characters", maxPasswordLength))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+
+	if policy.PasswordRequiredUpper && !hasUpper {
+		violations = append(violations, "password must contain an uppercase letter")
+	}
+	if policy.PasswordRequiredLower && !hasLower {
+		violations = append(violations, "password must contain a lowercase letter")
+	}
+	if policy.PasswordRequiredDigital && !hasDigit {
+		violations = append(violations, "password must contain a digit")
+	}
+	if policy.PasswordRequiredSymbol && !hasSymbol {
+		violations = append(violations, "password must contain a symbol")
+	}
+
+	return violations
+}
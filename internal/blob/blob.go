@@ -0,0 +1,53 @@
+// Package blob stores file contents, such as todo attachments, outside the
+// database. Objects are addressed by slash separated keys chosen by the
+// caller and are immutable once written
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+// ErrNotFound is returned for keys without an object
+var ErrNotFound = errors.New("blob not found")
+
+// Storage stores objects
+type Storage interface {
+	// Put stores size bytes read from r under key, replacing any object
+	// already stored there
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+
+	// Get opens the object stored under key or returns ErrNotFound, the
+	// caller closes it
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete removes the object stored under key, missing keys are ignored
+	Delete(ctx context.Context, key string) error
+}
+
+// Presigner is implemented by storages that can hand out URLs letting
+// clients transfer objects directly, bypassing the API
+type Presigner interface {
+	// PresignPut returns a request uploading exactly size bytes of
+	// contentType to key
+	PresignPut(ctx context.Context, key string, size int64, contentType string, ttl time.Duration) (*PresignedRequest, error)
+
+	// PresignGet returns a request downloading the object stored under key
+	// as a file named filename of contentType
+	PresignGet(ctx context.Context, key, filename, contentType string, ttl time.Duration) (*PresignedRequest, error)
+
+	// Stat returns the size of the object stored under key or ErrNotFound
+	Stat(ctx context.Context, key string) (int64, error)
+}
+
+// PresignedRequest is a request clients send as is until it expires
+type PresignedRequest struct {
+	Method string
+	URL    string
+	// Header holds the headers that were signed and must be sent
+	Header  http.Header
+	Expires time.Time
+}
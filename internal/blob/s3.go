@@ -0,0 +1,260 @@
+package blob
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+)
+
+// unsignedPayload skips hashing request bodies, which would mean reading
+// uploads twice. TLS already protects them in transit
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// S3Storage keeps objects in an S3 bucket, or a bucket of a compatible
+// service. Requests are signed with Signature Version 4 and sent with the
+// given client
+type S3Storage struct {
+	client      *http.Client
+	signer      *v4.Signer
+	credentials aws.CredentialsProvider
+	bucketURL   *url.URL
+	region      string
+}
+
+// NewS3Storage creates a storage for the configured bucket
+func NewS3Storage(ctx context.Context, cfg config.S3Config, client *http.Client) (*S3Storage, error) {
+	bucketURL, err := s3BucketURL(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var credentials aws.CredentialsProvider
+	if cfg.AccessKeyID != "" {
+		static := aws.Credentials{
+			AccessKeyID:     cfg.AccessKeyID,
+			SecretAccessKey: cfg.SecretAccessKey,
+			Source:          "config",
+		}
+		credentials = aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return static, nil
+		})
+	} else {
+		awsCfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(cfg.Region))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load aws configuration: %w", err)
+		}
+		credentials = awsCfg.Credentials
+	}
+
+	return &S3Storage{
+		client:      client,
+		signer:      v4.NewSigner(),
+		credentials: credentials,
+		bucketURL:   bucketURL,
+		region:      cfg.Region,
+	}, nil
+}
+
+// s3BucketURL returns the base URL of the bucket, virtual hosted unless path
+// style is asked for
+func s3BucketURL(cfg config.S3Config) (*url.URL, error) {
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint: %w", err)
+	}
+	if cfg.UsePathStyle {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + cfg.Bucket
+	} else {
+		u.Host = cfg.Bucket + "." + u.Host
+	}
+	return u, nil
+}
+
+func (s *S3Storage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	req, err := s.newRequest(ctx, http.MethodPut, key, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := s.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to store blob %s: %w", key, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open blob %s: %w", key, err)
+	}
+	return resp.Body, nil
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	// S3 answers 204 whether or not the object existed
+	resp, err := s.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete blob %s: %w", key, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// PresignPut returns a request uploading the object directly to the bucket.
+// The length and type are signed so the client cannot send anything else
+func (s *S3Storage) PresignPut(ctx context.Context, key string, size int64, contentType string, ttl time.Duration) (*PresignedRequest, error) {
+	req, err := s.newRequest(ctx, http.MethodPut, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	// The signer signs the length from ContentLength
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+
+	return s.presign(req, key, ttl)
+}
+
+// PresignGet returns a request downloading the object directly from the
+// bucket, S3 answers it as an attachment named filename
+func (s *S3Storage) PresignGet(ctx context.Context, key, filename, contentType string, ttl time.Duration) (*PresignedRequest, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	query := req.URL.Query()
+	query.Set("response-content-disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	query.Set("response-content-type", contentType)
+	query.Set("response-cache-control", "private, no-store")
+	req.URL.RawQuery = query.Encode()
+
+	return s.presign(req, key, ttl)
+}
+
+// Stat returns the size of the object stored under key
+func (s *S3Storage) Stat(ctx context.Context, key string) (int64, error) {
+	req, err := s.newRequest(ctx, http.MethodHead, key, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat blob %s: %w", key, err)
+	}
+	resp.Body.Close()
+	return resp.ContentLength, nil
+}
+
+// presign signs req into a URL valid for ttl, S3 accepts at most a week
+func (s *S3Storage) presign(req *http.Request, key string, ttl time.Duration) (*PresignedRequest, error) {
+	credentials, err := s.credentials.Retrieve(req.Context())
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve aws credentials: %w", err)
+	}
+
+	now := time.Now()
+	query := req.URL.Query()
+	query.Set("X-Amz-Expires", strconv.FormatInt(int64(ttl/time.Second), 10))
+	req.URL.RawQuery = query.Encode()
+
+	signedURL, signedHeader, err := s.signer.PresignHTTP(req.Context(), credentials, req, unsignedPayload, "s3", s.region, now,
+		func(o *v4.SignerOptions) {
+			o.DisableURIPathEscaping = true
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to presign blob %s: %w", key, err)
+	}
+	// Clients derive the host from the URL
+	signedHeader.Del("Host")
+
+	return &PresignedRequest{
+		Method:  req.Method,
+		URL:     signedURL,
+		Header:  signedHeader,
+		Expires: now.Add(ttl),
+	}, nil
+}
+
+func (s *S3Storage) newRequest(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create s3 request: %w", err)
+	}
+	return req, nil
+}
+
+// objectURL returns the URL of the object stored under key
+func (s *S3Storage) objectURL(key string) string {
+	u := *s.bucketURL
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	u.RawPath = strings.TrimSuffix(u.EscapedPath(), "/") + "/" + strings.Join(segments, "/")
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + key
+	return u.String()
+}
+
+// do signs and sends req, turning error responses into errors. A missing
+// object is ErrNotFound
+func (s *S3Storage) do(req *http.Request) (*http.Response, error) {
+	credentials, err := s.credentials.Retrieve(req.Context())
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve aws credentials: %w", err)
+	}
+
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+	err = s.signer.SignHTTP(req.Context(), credentials, req, unsignedPayload, "s3", s.region, time.Now(),
+		func(o *v4.SignerOptions) {
+			// Object keys are escaped once, S3 does not expect them escaped again
+			o.DisableURIPathEscaping = true
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign s3 request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return nil, fmt.Errorf("s3 responded with %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+}
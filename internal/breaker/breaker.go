@@ -0,0 +1,173 @@
+// Package breaker implements a circuit breaker that makes calls to an
+// unavailable dependency fail fast instead of piling up on timeouts
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+	"github.com/MuthuM3/gin-microservice-template/internal/metrics"
+)
+
+// ErrOpen is returned instead of calling the dependency while the breaker is open
+var ErrOpen = errors.New("circuit breaker is open")
+
+// State of a breaker, the values are exported as the state gauge
+type State int
+
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// Breaker opens after FailureThreshold consecutive failures and rejects
+// calls for OpenDuration. It then lets HalfOpenProbes calls through, closing
+// once they all succeed and opening again on the first failure. A nil
+// Breaker lets every call through
+type Breaker struct {
+	name      string
+	cfg       config.CircuitBreakerConfig
+	isFailure func(error) bool
+	log       logger.Logger
+
+	mu        sync.Mutex
+	state     State
+	failures  int
+	openUntil time.Time
+	probes    int
+	successes int
+
+	stateGauge *metrics.Gauge
+	rejected   *metrics.Counter
+}
+
+// New creates a closed breaker for the named dependency. isFailure reports
+// whether an error means the dependency is unavailable, errors such as a
+// missing row prove it is up and count as successes
+func New(name string, cfg config.CircuitBreakerConfig, isFailure func(error) bool, log logger.Logger) *Breaker {
+	labels := metrics.Labels{"breaker": name}
+	return &Breaker{
+		name:      name,
+		cfg:       cfg,
+		isFailure: isFailure,
+		log:       log,
+		stateGauge: metrics.Default.Gauge("circuit_breaker_state",
+			"State of the circuit breaker, 0 closed, 1 open and 2 half-open", labels),
+		rejected: metrics.Default.Counter("circuit_breaker_rejected_total",
+			"Total number of calls rejected by an open circuit breaker", labels),
+	}
+}
+
+// Allow reports whether a call may proceed, returning ErrOpen when it may
+// not. Calls that proceed must report their outcome with Done
+func (b *Breaker) Allow() error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == Open {
+		if time.Now().Before(b.openUntil) {
+			b.rejected.Inc()
+			return ErrOpen
+		}
+		b.setState(HalfOpen)
+	}
+	if b.state == HalfOpen {
+		if b.probes >= b.cfg.HalfOpenProbes {
+			b.rejected.Inc()
+			return ErrOpen
+		}
+		b.probes++
+	}
+	return nil
+}
+
+// Done records the outcome of a call let through by Allow
+func (b *Breaker) Done(err error) {
+	if b == nil {
+		return
+	}
+
+	failed := err != nil && b.isFailure(err)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Closed:
+		if !failed {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.cfg.FailureThreshold {
+			b.trip(err)
+		}
+	case HalfOpen:
+		// Calls let through before the breaker opened may finish here
+		if b.probes > 0 {
+			b.probes--
+		}
+		if failed {
+			b.trip(err)
+			return
+		}
+		b.successes++
+		if b.successes >= b.cfg.HalfOpenProbes {
+			b.setState(Closed)
+		}
+	}
+}
+
+// Do runs fn unless the breaker is open and records its outcome
+func (b *Breaker) Do(fn func() error) error {
+	if err := b.Allow(); err != nil {
+		return err
+	}
+	err := fn()
+	b.Done(err)
+	return err
+}
+
+// State returns the current state
+func (b *Breaker) State() State {
+	if b == nil {
+		return Closed
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+func (b *Breaker) trip(err error) {
+	b.openUntil = time.Now().Add(b.cfg.OpenDuration)
+	b.log.Warn("circuit breaker opened", "breaker", b.name, "open_for", b.cfg.OpenDuration, "error", err)
+	b.setState(Open)
+}
+
+func (b *Breaker) setState(state State) {
+	if state == Closed {
+		b.log.Info("circuit breaker closed", "breaker", b.name)
+	}
+	b.state = state
+	b.failures, b.probes, b.successes = 0, 0, 0
+	b.stateGauge.Set(float64(state))
+}
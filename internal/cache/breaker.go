@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/breaker"
+)
+
+// breakerCache makes calls fail fast with breaker.ErrOpen while the backend
+// is unavailable
+type breakerCache struct {
+	Cache
+	breaker *breaker.Breaker
+}
+
+// WithBreaker guards c with b, b should be created with IsOutage
+func WithBreaker(c Cache, b *breaker.Breaker) Cache {
+	return &breakerCache{Cache: c, breaker: b}
+}
+
+// IsOutage reports whether err means the cache backend is unavailable, a
+// miss or a cancelled request does not
+func IsOutage(err error) bool {
+	return !errors.Is(err, ErrCacheMiss) && !errors.Is(err, context.Canceled) && !errors.Is(err, breaker.ErrOpen)
+}
+
+func (c *breakerCache) Get(ctx context.Context, key string) ([]byte, error) {
+	var value []byte
+	err := c.breaker.Do(func() (err error) {
+		value, err = c.Cache.Get(ctx, key)
+		return err
+	})
+	return value, err
+}
+
+func (c *breakerCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.breaker.Do(func() error {
+		return c.Cache.Set(ctx, key, value, ttl)
+	})
+}
+
+func (c *breakerCache) Delete(ctx context.Context, keys ...string) error {
+	return c.breaker.Do(func() error {
+		return c.Cache.Delete(ctx, keys...)
+	})
+}
+
+func (c *breakerCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	var ttl time.Duration
+	err := c.breaker.Do(func() (err error) {
+		ttl, err = c.Cache.TTL(ctx, key)
+		return err
+	})
+	return ttl, err
+}
@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+)
+
+// ErrCacheMiss is returned when a key is not present in the cache
+var ErrCacheMiss = errors.New("cache miss")
+
+// Cache is a key/value cache with per-entry expiry. Keys are namespaced by
+// the implementation using the configured key prefix
+type Cache interface {
+	// Get returns the value stored under key or ErrCacheMiss
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Set stores value under key, a ttl of zero uses the default tier
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+
+	// Delete removes the given keys, missing keys are ignored
+	Delete(ctx context.Context, keys ...string) error
+
+	// TTL returns the remaining time to live of key or ErrCacheMiss
+	TTL(ctx context.Context, key string) (time.Duration, error)
+
+	// Close releases resources held by the cache
+	Close() error
+}
+
+// Tier classifies cached data by how long it may be served stale
+type Tier int
+
+const (
+	TierDefault Tier = iota
+	TierShort
+	TierLong
+	TierSession
+	TierState
+)
+
+// Tiers resolves TTL tiers from the cache configuration. It is shared by the
+// cache implementations and callers so TTLs can be changed at runtime
+type Tiers struct {
+	cfg atomic.Pointer[config.CacheConfig]
+}
+
+// NewTiers creates tiers from the cache configuration
+func NewTiers(cfg config.CacheConfig) *Tiers {
+	t := &Tiers{}
+	t.Update(cfg)
+	return t
+}
+
+// Update replaces the TTLs, entries already stored keep their expiry
+func (t *Tiers) Update(cfg config.CacheConfig) {
+	t.cfg.Store(&cfg)
+}
+
+// TTL returns the configured TTL for the given tier
+func (t *Tiers) TTL(tier Tier) time.Duration {
+	cfg := t.cfg.Load()
+	switch tier {
+	case TierShort:
+		return cfg.ShortTTL
+	case TierLong:
+		return cfg.LongTTL
+	case TierSession:
+		return cfg.SessionTTL
+	case TierState:
+		return cfg.StateTTL
+	default:
+		return cfg.DefaultTTL
+	}
+}
+
+// GetJSON reads key and decodes the JSON value into dest
+func GetJSON(ctx context.Context, c Cache, key string, dest any) error {
+	data, err := c.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, dest); err != nil {
+		return fmt.Errorf("failed to decode cached value for %s: %w", key, err)
+	}
+	return nil
+}
+
+// SetJSON encodes value as JSON and stores it under key
+func SetJSON(ctx context.Context, c Cache, key string, value any, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode value for %s: %w", key, err)
+	}
+	return c.Set(ctx, key, data, ttl)
+}
@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryCache is an in-process cache used when Redis is not available,
+// entries are not shared between replicas
+type MemoryCache struct {
+	prefix string
+	tiers  *Tiers
+
+	mu      sync.RWMutex
+	entries map[string]memoryEntry
+
+	stop chan struct{}
+	once sync.Once
+}
+
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewMemoryCache creates an in-memory cache and starts its expiry janitor
+func NewMemoryCache(prefix string, tiers *Tiers) *MemoryCache {
+	c := &MemoryCache{
+		prefix:  prefix,
+		tiers:   tiers,
+		entries: make(map[string]memoryEntry),
+		stop:    make(chan struct{}),
+	}
+
+	go c.janitor(time.Minute)
+	return c
+}
+
+func (c *MemoryCache) Get(_ context.Context, key string) ([]byte, error) {
+	c.mu.RLock()
+	entry, ok := c.entries[c.prefix+key]
+	c.mu.RUnlock()
+
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, ErrCacheMiss
+	}
+
+	value := make([]byte, len(entry.value))
+	copy(value, entry.value)
+	return value, nil
+}
+
+func (c *MemoryCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = c.tiers.TTL(TierDefault)
+	}
+
+	stored := make([]byte, len(value))
+	copy(stored, value)
+
+	c.mu.Lock()
+	c.entries[c.prefix+key] = memoryEntry{value: stored, expiresAt: time.Now().Add(ttl)}
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *MemoryCache) Delete(_ context.Context, keys ...string) error {
+	c.mu.Lock()
+	for _, key := range keys {
+		delete(c.entries, c.prefix+key)
+	}
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *MemoryCache) TTL(_ context.Context, key string) (time.Duration, error) {
+	c.mu.RLock()
+	entry, ok := c.entries[c.prefix+key]
+	c.mu.RUnlock()
+
+	remaining := time.Until(entry.expiresAt)
+	if !ok || remaining <= 0 {
+		return 0, ErrCacheMiss
+	}
+	return remaining, nil
+}
+
+// Close stops the expiry janitor
+func (c *MemoryCache) Close() error {
+	c.once.Do(func() { close(c.stop) })
+	return nil
+}
+
+// janitor periodically evicts expired entries
+func (c *MemoryCache) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			now := time.Now()
+
+			c.mu.Lock()
+			for key, entry := range c.entries {
+				if now.After(entry.expiresAt) {
+					delete(c.entries, key)
+				}
+			}
+			c.mu.Unlock()
+		case <-c.stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/retry"
+	"github.com/MuthuM3/gin-microservice-template/internal/tracing"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NewRedisClient creates a Redis client from the configuration and verifies
+// connectivity with a ping
+func NewRedisClient(ctx context.Context, cfg *config.RedisConfig) (*redis.Client, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.GetAddress(),
+		Password: cfg.Password,
+		DB:       cfg.Database,
+	})
+
+	pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if err := client.Ping(pingCtx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to redis at %s: %w", cfg.GetAddress(), err)
+	}
+
+	return client, nil
+}
+
+// RedisCache stores entries in Redis so they are shared by every replica
+type RedisCache struct {
+	client  *redis.Client
+	prefix  string
+	tiers   *Tiers
+	retrier *retry.Retrier
+	tracer  trace.Tracer
+}
+
+// NewRedisCache creates a cache on top of an existing client, the client is
+// owned by the caller and is not closed by Close. Commands failing with a
+// transient network error are retried by retrier, which may be nil
+func NewRedisCache(client *redis.Client, prefix string, tiers *Tiers, retrier *retry.Retrier) *RedisCache {
+	return &RedisCache{
+		client:  client,
+		prefix:  prefix,
+		tiers:   tiers,
+		retrier: retrier,
+		tracer:  tracing.Tracer(),
+	}
+}
+
+func (c *RedisCache) Get(ctx context.Context, key string) ([]byte, error) {
+	ctx, span := c.startSpan(ctx, "GET", key)
+	defer span.End()
+
+	var value []byte
+	err := c.retrier.Do(ctx, func(ctx context.Context) error {
+		var err error
+		value, err = c.client.Get(ctx, c.prefix+key).Bytes()
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			span.SetAttributes(attribute.Bool("cache.hit", false))
+			return nil, ErrCacheMiss
+		}
+		recordError(span, err)
+		return nil, fmt.Errorf("failed to get %s from cache: %w", key, err)
+	}
+
+	span.SetAttributes(attribute.Bool("cache.hit", true))
+	return value, nil
+}
+
+func (c *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	ctx, span := c.startSpan(ctx, "SET", key)
+	defer span.End()
+
+	if ttl <= 0 {
+		ttl = c.tiers.TTL(TierDefault)
+	}
+
+	err := c.retrier.Do(ctx, func(ctx context.Context) error {
+		return c.client.Set(ctx, c.prefix+key, value, ttl).Err()
+	})
+	if err != nil {
+		recordError(span, err)
+		return fmt.Errorf("failed to set %s in cache: %w", key, err)
+	}
+	return nil
+}
+
+func (c *RedisCache) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	ctx, span := c.startSpan(ctx, "DEL", keys[0])
+	defer span.End()
+
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = c.prefix + key
+	}
+
+	err := c.retrier.Do(ctx, func(ctx context.Context) error {
+		return c.client.Del(ctx, prefixed...).Err()
+	})
+	if err != nil {
+		recordError(span, err)
+		return fmt.Errorf("failed to delete keys from cache: %w", err)
+	}
+	return nil
+}
+
+func (c *RedisCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ctx, span := c.startSpan(ctx, "PTTL", key)
+	defer span.End()
+
+	var ttl time.Duration
+	err := c.retrier.Do(ctx, func(ctx context.Context) error {
+		var err error
+		ttl, err = c.client.PTTL(ctx, c.prefix+key).Result()
+		return err
+	})
+	if err != nil {
+		recordError(span, err)
+		return 0, fmt.Errorf("failed to get ttl of %s: %w", key, err)
+	}
+
+	// Redis reports -2 for missing keys and -1 for keys without expiry
+	if ttl == -2*time.Millisecond {
+		return 0, ErrCacheMiss
+	}
+	return ttl, nil
+}
+
+// Close is a no-op, the shared client is closed by its owner
+func (c *RedisCache) Close() error {
+	return nil
+}
+
+func (c *RedisCache) startSpan(ctx context.Context, operation, key string) (context.Context, trace.Span) {
+	return c.tracer.Start(ctx, "cache."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "redis"),
+			attribute.String("db.operation.name", operation),
+			attribute.String("cache.key", key),
+		),
+	)
+}
+
+func recordError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
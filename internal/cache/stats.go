@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// Stats counts the operations of a cache wrapped by WithStats
+type Stats struct {
+	hits    atomic.Int64
+	misses  atomic.Int64
+	sets    atomic.Int64
+	deletes atomic.Int64
+	errors  atomic.Int64
+}
+
+// StatsSnapshot is the state of Stats at one point in time
+type StatsSnapshot struct {
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	HitRate float64 `json:"hit_rate"`
+	Sets    int64   `json:"sets"`
+	Deletes int64   `json:"deletes"`
+	Errors  int64   `json:"errors"`
+}
+
+// Snapshot returns the current counts
+func (s *Stats) Snapshot() StatsSnapshot {
+	snapshot := StatsSnapshot{
+		Hits:    s.hits.Load(),
+		Misses:  s.misses.Load(),
+		Sets:    s.sets.Load(),
+		Deletes: s.deletes.Load(),
+		Errors:  s.errors.Load(),
+	}
+	if lookups := snapshot.Hits + snapshot.Misses; lookups > 0 {
+		snapshot.HitRate = float64(snapshot.Hits) / float64(lookups)
+	}
+	return snapshot
+}
+
+// statsCache counts the operations of the cache it wraps
+type statsCache struct {
+	Cache
+	stats *Stats
+}
+
+// WithStats counts the operations of c in stats
+func WithStats(c Cache, stats *Stats) Cache {
+	return &statsCache{Cache: c, stats: stats}
+}
+
+func (c *statsCache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.Cache.Get(ctx, key)
+	switch {
+	case err == nil:
+		c.stats.hits.Add(1)
+	case errors.Is(err, ErrCacheMiss):
+		c.stats.misses.Add(1)
+	default:
+		c.stats.errors.Add(1)
+	}
+	return value, err
+}
+
+func (c *statsCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	err := c.Cache.Set(ctx, key, value, ttl)
+	c.count(&c.stats.sets, err)
+	return err
+}
+
+func (c *statsCache) Delete(ctx context.Context, keys ...string) error {
+	err := c.Cache.Delete(ctx, keys...)
+	c.count(&c.stats.deletes, err)
+	return err
+}
+
+func (c *statsCache) count(counter *atomic.Int64, err error) {
+	if err != nil {
+		c.stats.errors.Add(1)
+		return
+	}
+	counter.Add(1)
+}
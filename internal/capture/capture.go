@@ -0,0 +1,90 @@
+// Package capture keeps the request and response bodies of selected
+// requests for a short while so operators can debug them. Secrets are
+// redacted before anything is stored
+package capture
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/cache"
+)
+
+// ErrNotFound is returned for requests that were not captured or whose
+// capture expired
+var ErrNotFound = errors.New("capture not found")
+
+// keyPrefix namespaces captures in the cache
+const keyPrefix = "capture:"
+
+// Capture is a request and its response as seen by the API
+type Capture struct {
+	RequestID  string    `json:"request_id"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Route      string    `json:"route"`
+	Query      string    `json:"query,omitempty"`
+	Status     int       `json:"status"`
+	UserID     *int64    `json:"user_id,omitempty"`
+	ClientIP   string    `json:"client_ip"`
+	LatencyMS  int64     `json:"latency_ms"`
+	Request    Message   `json:"request"`
+	Response   Message   `json:"response"`
+	CapturedAt time.Time `json:"captured_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// Message holds the headers and body of a request or response. JSON bodies
+// are kept as JSON, other text bodies as strings. Omitted says why a body
+// was left out
+type Message struct {
+	Headers map[string]string `json:"headers"`
+	Size    int64             `json:"size"`
+	Body    any               `json:"body,omitempty"`
+	Omitted string            `json:"omitted,omitempty"`
+}
+
+// Store keeps captures in the cache until they expire
+type Store struct {
+	cache cache.Cache
+	ttl   time.Duration
+}
+
+// NewStore creates a store keeping captures for ttl
+func NewStore(c cache.Cache, ttl time.Duration) *Store {
+	return &Store{cache: c, ttl: ttl}
+}
+
+// Save stores the capture under its request ID, replacing an earlier
+// capture of a request with the same ID
+func (s *Store) Save(ctx context.Context, capture *Capture) error {
+	capture.ExpiresAt = capture.CapturedAt.Add(s.ttl)
+	data, err := json.Marshal(capture)
+	if err != nil {
+		return fmt.Errorf("failed to encode capture: %w", err)
+	}
+	if err := s.cache.Set(ctx, keyPrefix+capture.RequestID, data, s.ttl); err != nil {
+		return fmt.Errorf("failed to store capture: %w", err)
+	}
+	return nil
+}
+
+// Get returns the capture of the request with the ID
+func (s *Store) Get(ctx context.Context, requestID string) (*Capture, error) {
+	data, err := s.cache.Get(ctx, keyPrefix+requestID)
+	if errors.Is(err, cache.ErrCacheMiss) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read capture: %w", err)
+	}
+
+	var capture Capture
+	if err := json.Unmarshal(data, &capture); err != nil {
+		return nil, fmt.Errorf("failed to decode capture: %w", err)
+	}
+	return &capture, nil
+}
@@ -0,0 +1,131 @@
+package capture
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// redacted replaces the values of redacted fields and headers
+const redacted = "[REDACTED]"
+
+// credentialHeaders are always redacted, whatever the configured fields
+var credentialHeaders = []string{
+	"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Admin-Token", "X-Api-Key", "X-Csrf-Token",
+}
+
+// Redactor masks the values of sensitive fields in headers, query strings
+// and bodies. Field names match case-insensitively, with dashes and
+// underscores alike
+type Redactor struct {
+	fields map[string]struct{}
+}
+
+// NewRedactor returns a redactor masking the named fields
+func NewRedactor(fields []string) *Redactor {
+	r := &Redactor{fields: make(map[string]struct{}, len(fields)+len(credentialHeaders))}
+	for _, field := range append(fields, credentialHeaders...) {
+		r.fields[normalizeField(field)] = struct{}{}
+	}
+	return r
+}
+
+// Headers flattens the headers with redacted values masked
+func (r *Redactor) Headers(header http.Header) map[string]string {
+	headers := make(map[string]string, len(header))
+	for name, values := range header {
+		if r.sensitive(name) {
+			headers[name] = redacted
+			continue
+		}
+		headers[name] = strings.Join(values, ", ")
+	}
+	return headers
+}
+
+// Query returns the query string with redacted parameters masked
+func (r *Redactor) Query(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return redacted
+	}
+	r.values(values)
+	return values.Encode()
+}
+
+// Body returns the body with redacted fields masked: JSON as a value, forms
+// and other text as a string. Bodies that cannot be redacted, such as
+// malformed JSON, and binary bodies are left out, with the reason
+func (r *Redactor) Body(contentType string, body []byte) (any, string) {
+	if len(body) == 0 {
+		return nil, ""
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		var value any
+		if err := decoder.Decode(&value); err != nil {
+			return nil, "malformed JSON"
+		}
+		return r.value(value), ""
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, "malformed form"
+		}
+		r.values(values)
+		return values.Encode(), ""
+	case strings.HasPrefix(mediaType, "text/") || mediaType == "application/xml":
+		if !utf8.Valid(body) {
+			return nil, "binary body"
+		}
+		return string(body), ""
+	}
+	return nil, "binary body"
+}
+
+// value masks the redacted fields of a decoded JSON value
+func (r *Redactor) value(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if r.sensitive(key) {
+				v[key] = redacted
+				continue
+			}
+			v[key] = r.value(field)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = r.value(item)
+		}
+	}
+	return value
+}
+
+func (r *Redactor) values(values url.Values) {
+	for key := range values {
+		if r.sensitive(key) {
+			values[key] = []string{redacted}
+		}
+	}
+}
+
+func (r *Redactor) sensitive(name string) bool {
+	_, ok := r.fields[normalizeField(name)]
+	return ok
+}
+
+func normalizeField(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), "-", "_")
+}
@@ -0,0 +1,18 @@
+package certs
+
+import (
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// NewManager creates an ACME manager that obtains certificates for the
+// configured domains on first use and renews them before they expire. The
+// CA's terms of service are accepted on the operator's behalf
+func NewManager(cfg config.AutocertConfig) *autocert.Manager {
+	return &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		Cache:      autocert.DirCache(cfg.CacheDir),
+		HostPolicy: autocert.HostWhitelist(cfg.Domains...),
+		Email:      cfg.Email,
+	}
+}
@@ -0,0 +1,162 @@
+// Package certs loads the server certificate and the client CAs for TLS and
+// reloads them when the files are rotated on disk
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+)
+
+// Reloader serves the most recently loaded certificate and client CAs to
+// new TLS handshakes, established connections keep the ones they started with
+type Reloader struct {
+	cfg   config.TLSConfig
+	log   logger.Logger
+	state atomic.Pointer[state]
+}
+
+// state is a consistent set of loaded files
+type state struct {
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTimes  []time.Time
+}
+
+// New loads the files of cfg, failing if any of them is missing or invalid
+func New(cfg config.TLSConfig, log logger.Logger) (*Reloader, error) {
+	r := &Reloader{cfg: cfg, log: log}
+	s, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	r.state.Store(s)
+	return r, nil
+}
+
+// TLSConfig returns the server configuration, mutual TLS is required when a
+// client CA file is configured. http2 offers HTTP/2 in ALPN next to HTTP/1.1
+func (r *Reloader) TLSConfig(http2 bool) *tls.Config {
+	minVersion := MinVersion(r.cfg.MinVersion)
+	nextProtos := []string{"http/1.1"}
+	if http2 {
+		nextProtos = []string{"h2", "http/1.1"}
+	}
+
+	return &tls.Config{
+		MinVersion: minVersion,
+		// Resolved per handshake so reloads apply to new connections
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			s := r.state.Load()
+			cfg := &tls.Config{
+				MinVersion:   minVersion,
+				Certificates: []tls.Certificate{*s.cert},
+				NextProtos:   nextProtos,
+			}
+			if s.clientCAs != nil {
+				cfg.ClientCAs = s.clientCAs
+				cfg.ClientAuth = tls.RequireAndVerifyClientCert
+			}
+			return cfg, nil
+		},
+	}
+}
+
+// Watch checks the files every reload interval until ctx is cancelled and
+// reloads them when one changed. A failed reload keeps serving the previous
+// files, rotations often write the certificate and key one after the other
+func (r *Reloader) Watch(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		modTimes, err := r.modTimes()
+		if err != nil {
+			r.log.Warn("failed to check tls files", "error", err)
+			continue
+		}
+		if slices.EqualFunc(modTimes, r.state.Load().modTimes, time.Time.Equal) {
+			continue
+		}
+
+		s, err := r.load()
+		if err != nil {
+			r.log.Error("failed to reload tls files, keeping the current ones", "error", err)
+			continue
+		}
+		r.state.Store(s)
+		r.log.Info("tls certificates reloaded", "cert_file", r.cfg.CertFile, "not_after", s.cert.Leaf.NotAfter)
+	}
+}
+
+func (r *Reloader) load() (*state, error) {
+	// Stat first so a rotation during loading is picked up by the next check
+	modTimes, err := r.modTimes()
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tls certificate: %w", err)
+	}
+	if cert.Leaf == nil {
+		cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse tls certificate: %w", err)
+		}
+	}
+
+	s := &state{cert: &cert, modTimes: modTimes}
+	if r.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client ca file: %w", err)
+		}
+		s.clientCAs = x509.NewCertPool()
+		if !s.clientCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.New("client ca file contains no PEM certificates")
+		}
+	}
+	return s, nil
+}
+
+func (r *Reloader) modTimes() ([]time.Time, error) {
+	files := []string{r.cfg.CertFile, r.cfg.KeyFile}
+	if r.cfg.ClientCAFile != "" {
+		files = append(files, r.cfg.ClientCAFile)
+	}
+
+	times := make([]time.Time, len(files))
+	for i, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat %s: %w", file, err)
+		}
+		times[i] = info.ModTime()
+	}
+	return times, nil
+}
+
+// MinVersion converts a configured minimum version, "1.2" or "1.3"
+func MinVersion(version string) uint16 {
+	if version == "1.3" {
+		return tls.VersionTLS13
+	}
+	return tls.VersionTLS12
+}
@@ -0,0 +1,32 @@
+// Package clientinfo carries the address and user agent of the client
+// through contexts so services can record who made a request
+package clientinfo
+
+import "context"
+
+// maxUserAgentLength bounds stored user agents, clients control them
+const maxUserAgentLength = 255
+
+// Info describes the client of a request
+type Info struct {
+	IP        string
+	UserAgent string
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the client info, overly long
+// user agents are truncated
+func NewContext(ctx context.Context, info Info) context.Context {
+	if len(info.UserAgent) > maxUserAgentLength {
+		info.UserAgent = info.UserAgent[:maxUserAgentLength]
+	}
+	return context.WithValue(ctx, contextKey{}, info)
+}
+
+// FromContext returns the client info stored in ctx, or the zero Info if
+// there is none
+func FromContext(ctx context.Context) Info {
+	info, _ := ctx.Value(contextKey{}).(Info)
+	return info
+}
@@ -0,0 +1,182 @@
+package clientinfo
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// Header names the resolver reads client addresses from
+const (
+	HeaderForwarded     = "Forwarded"
+	HeaderXForwardedFor = "X-Forwarded-For"
+	HeaderXRealIP       = "X-Real-IP"
+)
+
+// Resolver determines the address of the client of a request. Forwarding
+// headers are only believed when the request arrives from a trusted proxy,
+// and their hops are walked from the nearest one so a client cannot pass
+// itself off as another by prepending addresses
+type Resolver struct {
+	trusted []netip.Prefix
+	headers []string
+}
+
+// NewResolver returns a resolver trusting the proxies at the given addresses
+// or CIDR ranges and reading the headers in order of preference. Without
+// trusted proxies the peer address of the connection is the client
+func NewResolver(trustedProxies, headers []string) (*Resolver, error) {
+	r := &Resolver{headers: headers}
+	for _, proxy := range trustedProxies {
+		prefix, err := ParsePrefix(proxy)
+		if err != nil {
+			return nil, err
+		}
+		r.trusted = append(r.trusted, prefix)
+	}
+	return r, nil
+}
+
+// ParsePrefix parses a trusted proxy given as an address or a CIDR range
+func ParsePrefix(proxy string) (netip.Prefix, error) {
+	proxy = strings.TrimSpace(proxy)
+	if strings.Contains(proxy, "/") {
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(proxy)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// ClientIP returns the address of the client of the request. When the peer
+// is a trusted proxy the first configured header present is walked from the
+// right, skipping trusted hops, and the first untrusted hop is the client.
+// Malformed headers are ignored in favour of the peer address
+func (r *Resolver) ClientIP(req *http.Request) string {
+	remote, ok := remoteAddr(req.RemoteAddr)
+	if !ok {
+		return strings.TrimSpace(req.RemoteAddr)
+	}
+	if r == nil || !r.isTrusted(remote) {
+		return remote.String()
+	}
+
+	for _, header := range r.headers {
+		values := req.Header.Values(header)
+		if len(values) == 0 {
+			continue
+		}
+		hops, ok := parseHops(header, values)
+		if !ok || len(hops) == 0 {
+			return remote.String()
+		}
+		for i := len(hops) - 1; i >= 0; i-- {
+			if !r.isTrusted(hops[i]) {
+				return hops[i].String()
+			}
+		}
+		// Every hop is a trusted proxy, the leftmost is nearest the client
+		return hops[0].String()
+	}
+	return remote.String()
+}
+
+func (r *Resolver) isTrusted(addr netip.Addr) bool {
+	for _, prefix := range r.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseHops reads the addresses of a forwarding header from the client to
+// the nearest proxy. Repeated headers are joined in order
+func parseHops(header string, values []string) ([]netip.Addr, bool) {
+	switch http.CanonicalHeaderKey(header) {
+	case HeaderForwarded:
+		return parseForwarded(values)
+	case http.CanonicalHeaderKey(HeaderXRealIP):
+		addr, ok := parseAddr(values[len(values)-1])
+		return []netip.Addr{addr}, ok
+	default:
+		var hops []netip.Addr
+		for _, value := range values {
+			for _, part := range strings.Split(value, ",") {
+				addr, ok := parseAddr(part)
+				if !ok {
+					return nil, false
+				}
+				hops = append(hops, addr)
+			}
+		}
+		return hops, true
+	}
+}
+
+// parseForwarded reads the for parameters of RFC 7239 Forwarded headers.
+// Obfuscated identifiers and unknown nodes cannot be trusted or reported,
+// so they make the header malformed
+func parseForwarded(values []string) ([]netip.Addr, bool) {
+	var hops []netip.Addr
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				name, node, found := strings.Cut(strings.TrimSpace(pair), "=")
+				if !found || !strings.EqualFold(name, "for") {
+					continue
+				}
+				addr, ok := parseNode(strings.Trim(node, `"`))
+				if !ok {
+					return nil, false
+				}
+				hops = append(hops, addr)
+			}
+		}
+	}
+	return hops, true
+}
+
+// parseNode reads a Forwarded node: an IPv4 address or a bracketed IPv6
+// address, either with an optional port
+func parseNode(node string) (netip.Addr, bool) {
+	if strings.HasPrefix(node, "[") {
+		end := strings.IndexByte(node, ']')
+		if end < 0 {
+			return netip.Addr{}, false
+		}
+		return parseAddr(node[1:end])
+	}
+	if host, _, err := net.SplitHostPort(node); err == nil {
+		node = host
+	}
+	return parseAddr(node)
+}
+
+// parseAddr parses a single address, IPv4-mapped IPv6 addresses are reported
+// as IPv4
+func parseAddr(raw string) (netip.Addr, bool) {
+	addr, err := netip.ParseAddr(strings.TrimSpace(raw))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap().WithZone(""), true
+}
+
+// remoteAddr parses the peer address of a connection, which carries a port
+func remoteAddr(raw string) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(raw))
+	if err != nil {
+		host = raw
+	}
+	return parseAddr(host)
+}
@@ -6,70 +6,277 @@ import (
 )
 
 type Config struct {
-	Server      ServerConfig      `yaml:"server"`
-	Database    DatabaseConfig    `yaml:"database"`
-	JWT         JWTConfig         `yaml:"jwt"`
-	Logger      LoggerConfig      `yaml:"logger"`
-	RateLimit   RateLimitConfig   `yaml:"rate_Limit"`
-	CORS        CORSConfig        `yaml:"cors"`
-	Redis       RedisConfig       `yaml:"radis"`
-	Cache       CacheConfig       `yaml:"cache"`
-	Metrics     MetricsConfig     `yaml:"metrics"`
-	Security    SecurityConfig    `yaml:"security"`
-	Performance PerformanceConfig `yaml:"performance"`
+	Server         ServerConfig         `yaml:"server"`
+	Database       DatabaseConfig       `yaml:"database"`
+	JWT            JWTConfig            `yaml:"jwt"`
+	Logger         LoggerConfig         `yaml:"logger"`
+	RateLimit      RateLimitConfig      `yaml:"rate_limit"`
+	CORS           CORSConfig           `yaml:"cors"`
+	Redis          RedisConfig          `yaml:"redis"`
+	Cache          CacheConfig          `yaml:"cache"`
+	Metrics        MetricsConfig        `yaml:"metrics"`
+	Security       SecurityConfig       `yaml:"security"`
+	Performance    PerformanceConfig    `yaml:"performance"`
+	Tracing        TracingConfig        `yaml:"tracing"`
+	Sentry         SentryConfig         `yaml:"sentry"`
+	Secrets        SecretsConfig        `yaml:"secrets"`
+	Email          EmailConfig          `yaml:"email"`
+	Auth           AuthConfig           `yaml:"auth"`
+	Todos          TodosConfig          `yaml:"todos"`
+	Pagination     PaginationConfig     `yaml:"pagination"`
+	Audit          AuditConfig          `yaml:"audit"`
+	SecurityEvents SecurityEventsConfig `yaml:"security_events"`
+	Events         EventsConfig         `yaml:"events"`
+	Messaging      MessagingConfig      `yaml:"messaging"`
+	Outbox         OutboxConfig         `yaml:"outbox"`
+	GRPC           GRPCConfig           `yaml:"grpc"`
+	GraphQL        GraphQLConfig        `yaml:"graphql"`
+	Docs           DocsConfig           `yaml:"docs"`
+	AdminServer    AdminServerConfig    `yaml:"admin_server"`
+	CircuitBreaker CircuitBreakerConfig `yaml:"circuit_breaker"`
+	HTTPClient     HTTPClientConfig     `yaml:"http_client"`
+	Jobs           JobsConfig           `yaml:"jobs"`
+	Queue          QueueConfig          `yaml:"queue"`
+	Export         ExportConfig         `yaml:"export"`
+	Accounts       AccountsConfig       `yaml:"accounts"`
+	Notifications  NotificationsConfig  `yaml:"notifications"`
+	Sharing        SharingConfig        `yaml:"sharing"`
+	BlobStorage    BlobStorageConfig    `yaml:"blob_storage"`
+	Webhooks       WebhooksConfig       `yaml:"webhooks"`
+	Inbound        InboundConfig        `yaml:"inbound_webhooks"`
+	Quotas         QuotasConfig         `yaml:"quotas"`
+	I18n           I18nConfig           `yaml:"i18n"`
+	Responses      ResponsesConfig      `yaml:"responses"`
+	API            APIConfig            `yaml:"api"`
+	Schemas        SchemasConfig        `yaml:"schemas"`
+	RoutePolicies  []RoutePolicyConfig  `yaml:"route_policies"`
 }
 
 // ServerConfig holds server-related configuration
 type ServerConfig struct {
-	Host         string        `yaml:"host" env:"SERVER_HOST" default:"localhost"`
-	Port         int           `yaml:"port" env:"PORT" default:"8080"`
-	ReadTimeout  time.Duration `yaml:"read_timeout" default:"15s"`
-	WriteTimeout time.Duration `yaml:"write_timeout" default:"15s"`
-	IdleTimeout  time.Duration `yaml:"idle_timeout" default:"60s"`
-	Environment  string        `yaml:"environment" env:"APP_ENV" default:"development"`
+	Host            string         `yaml:"host" env:"SERVER_HOST" default:"localhost"`
+	Port            int            `yaml:"port" env:"PORT" default:"8000"`
+	ReadTimeout     time.Duration  `yaml:"read_timeout" default:"15s"`
+	WriteTimeout    time.Duration  `yaml:"write_timeout" default:"15s"`
+	IdleTimeout     time.Duration  `yaml:"idle_timeout" default:"60s"`
+	ShutdownTimeout time.Duration  `yaml:"shutdown_timeout" default:"30s"`
+	Environment     string         `yaml:"environment" env:"APP_ENV" default:"development"`
+	TrustedProxies  []string       `yaml:"trusted_proxies" env:"TRUSTED_PROXIES"`
+	ClientIPHeaders []string       `yaml:"client_ip_headers" default:"Forwarded,X-Forwarded-For,X-Real-IP"`
+	TLS             TLSConfig      `yaml:"tls"`
+	HTTP2           HTTP2Config    `yaml:"http2"`
+	Shutdown        ShutdownConfig `yaml:"shutdown"`
 }
 
-// DatabaseConfig holds database-related configuration
+// ShutdownConfig bounds each stage of a graceful shutdown after the HTTP
+// servers have drained within ShutdownTimeout. Components stop in reverse
+// dependency order: background workers, the event bus and messaging, the
+// cache and sessions, then the database
+type ShutdownConfig struct {
+	Workers  time.Duration `yaml:"workers" default:"15s"`
+	Events   time.Duration `yaml:"events" default:"5s"`
+	Cache    time.Duration `yaml:"cache" default:"5s"`
+	Database time.Duration `yaml:"database" default:"10s"`
+}
+
+// HTTP2Config controls HTTP/2 support. Over TLS it is negotiated with ALPN
+// when Enabled; H2C additionally accepts HTTP/2 without TLS, for load
+// balancers that speak cleartext HTTP/2 to their backends
+type HTTP2Config struct {
+	Enabled              bool `yaml:"enabled" env:"HTTP2_ENABLED" default:"true"`
+	H2C                  bool `yaml:"h2c" env:"HTTP2_H2C" default:"false"`
+	MaxConcurrentStreams int  `yaml:"max_concurrent_streams" default:"250"`
+	MaxReadFrameSize     int  `yaml:"max_read_frame_size" default:"1048576"`
+}
+
+// TLSConfig lets the server terminate TLS itself. Setting ClientCAFile
+// enables mutual TLS, clients must present a certificate signed by one of
+// its CAs. The files are checked every ReloadInterval and reloaded when
+// they change, so rotated certificates apply without a restart. With
+// Autocert the certificates are obtained from Let's Encrypt instead
+type TLSConfig struct {
+	Enabled        bool           `yaml:"enabled" env:"TLS_ENABLED" default:"false"`
+	CertFile       string         `yaml:"cert_file" env:"TLS_CERT_FILE"`
+	KeyFile        string         `yaml:"key_file" env:"TLS_KEY_FILE"`
+	ClientCAFile   string         `yaml:"client_ca_file" env:"TLS_CLIENT_CA_FILE"`
+	MinVersion     string         `yaml:"min_version" env:"TLS_MIN_VERSION" default:"1.2"`
+	ReloadInterval time.Duration  `yaml:"reload_interval" default:"1m"`
+	Autocert       AutocertConfig `yaml:"autocert"`
+}
+
+// AutocertConfig provisions and renews certificates for Domains over ACME,
+// caching them in CacheDir so restarts do not hit the CA's rate limits. A
+// plain HTTP server on HTTPPort answers the ACME challenges and redirects
+// all other requests to HTTPS
+type AutocertConfig struct {
+	Enabled  bool     `yaml:"enabled" env:"AUTOCERT_ENABLED" default:"false"`
+	Domains  []string `yaml:"domains" env:"AUTOCERT_DOMAINS"`
+	CacheDir string   `yaml:"cache_dir" env:"AUTOCERT_CACHE_DIR" default:"certs"`
+	Email    string   `yaml:"email" env:"AUTOCERT_EMAIL"`
+	HTTPPort int      `yaml:"http_port" default:"80"`
+}
+
+// DatabaseConfig holds database-related configuration. Driver selects the
+// storage backend: "postgres" with lib/pq, "pgx" with pgx and its pool, which
+// adds batches and COPY, or "memory" for tests and local development. The initial connection
+// is attempted ConnectAttempts times, doubling ConnectRetryInterval between
+// attempts; with StartDegraded the server starts anyway and keeps reconnecting
+// in the background, reporting not ready until the database is reachable.
+// The todo and auth stores keep up to StatementCacheSize prepared
+// statements, 0 disables the cache. Read-only queries outside transactions
+// are spread over the ReplicaDSNs, falling back to the primary while no
+// replica is healthy. Queries slower than SlowQueryThreshold are logged as
+// warnings, 0 disables it; LogQueries logs every query at debug level.
+// Parameters are logged as their type unless LogQueryParams is set.
+// Postgres cancels statements running longer than StatementTimeout, 0 lets
+// them run; OperationTimeouts replace it for the "export" and "migration"
+// operations. ApplicationName identifies the connections of this service,
+// the change feed ignores the writes made under it
 type DatabaseConfig struct {
-	Host            string        `yaml:"host" env:"DB_HOST" default:"localhost"`
-	Port            int           `yaml:"host" env:"DB_PORT" default:"5432"`
-	User            string        `yaml:"user" env:"DB_USER" default:"postgres"`
-	Password        string        `yaml:"password" env:"DB_PASSWORD" default:"root"`
-	Database        string        `yaml:"database" env:"DB_NAME" default:"go-microservice"`
-	SSLMode         string        `yaml:"ssl_mode" env:"DB_SSL_MODE" default:"disable"`
-	MaxOpenConns    int           `yaml:"max_open_conns" default:"25"`
-	MaxIdleConns    int           `yaml:"max_idle_conns" default:"5"`
-	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" default:"5m"`
+	Driver               string                   `yaml:"driver" env:"DB_DRIVER" default:"postgres"`
+	Host                 string                   `yaml:"host" env:"DB_HOST" default:"localhost"`
+	Port                 int                      `yaml:"port" env:"DB_PORT" default:"5432"`
+	User                 string                   `yaml:"user" env:"DB_USER" default:"postgres"`
+	Password             string                   `yaml:"password" env:"DB_PASSWORD" default:"root"`
+	Database             string                   `yaml:"database" env:"DB_NAME" default:"todo"`
+	SSLMode              string                   `yaml:"ssl_mode" env:"DB_SSL_MODE" default:"disable"`
+	MaxOpenConns         int                      `yaml:"max_open_conns" default:"25"`
+	MaxIdleConns         int                      `yaml:"max_idle_conns" default:"5"`
+	ConnMaxLifetime      time.Duration            `yaml:"conn_max_lifetime" default:"5m"`
+	ConnectAttempts      int                      `yaml:"connect_attempts" env:"DB_CONNECT_ATTEMPTS" default:"5"`
+	ConnectRetryInterval time.Duration            `yaml:"connect_retry_interval" default:"1s"`
+	StartDegraded        bool                     `yaml:"start_degraded" env:"DB_START_DEGRADED" default:"false"`
+	StatementCacheSize   int                      `yaml:"statement_cache_size" env:"DB_STATEMENT_CACHE_SIZE" default:"256"`
+	ReplicaDSNs          []string                 `yaml:"replica_dsns" env:"DB_REPLICA_DSNS"`
+	SlowQueryThreshold   time.Duration            `yaml:"slow_query_threshold" env:"DB_SLOW_QUERY_THRESHOLD" default:"500ms"`
+	LogQueries           bool                     `yaml:"log_queries" env:"DB_LOG_QUERIES" default:"false"`
+	LogQueryParams       bool                     `yaml:"log_query_params" default:"false"`
+	StatementTimeout     time.Duration            `yaml:"statement_timeout" env:"DB_STATEMENT_TIMEOUT" default:"30s"`
+	OperationTimeouts    map[string]time.Duration `yaml:"operation_timeouts"`
+	ApplicationName      string                   `yaml:"application_name" env:"DB_APPLICATION_NAME" default:"todo-api"`
+	MonitorInterval      time.Duration            `yaml:"monitor_interval" env:"DB_MONITOR_INTERVAL" default:"30s"`
+	Retry                RetryConfig              `yaml:"retry"`
+	Encryption           ColumnEncryptionConfig   `yaml:"encryption"`
+}
+
+// ColumnEncryptionConfig encrypts sensitive columns, the secrets of webhooks
+// and notification channels, with AES-256-GCM. KeySecrets lists the keys as
+// "version:key" pairs of base64 encoded 32 byte keys, or secret:// references
+// to such pairs. New values are encrypted with the key of ActiveKey and carry
+// its version, so older keys keep decrypting the values written with them
+// until "server encryption rotate" re-encrypts those
+type ColumnEncryptionConfig struct {
+	Enabled    bool     `yaml:"enabled" env:"DB_ENCRYPTION_ENABLED" default:"false"`
+	KeySecrets []string `yaml:"key_secrets" env:"DB_ENCRYPTION_KEY_SECRETS"`
+	ActiveKey  string   `yaml:"active_key" env:"DB_ENCRYPTION_ACTIVE_KEY"`
+}
+
+// RetryConfig controls how operations failing with a transient error are
+// retried. Delays grow exponentially from BaseDelay up to MaxDelay with full
+// jitter. Every call earns BudgetRatio retries, up to BudgetBurst saved, so
+// retries stop once a dependency fails most calls. MaxAttempts of 1 disables
+// retries and a BudgetRatio of 0 the budget
+type RetryConfig struct {
+	MaxAttempts int           `yaml:"max_attempts" default:"3"`
+	BaseDelay   time.Duration `yaml:"base_delay" default:"50ms"`
+	MaxDelay    time.Duration `yaml:"max_delay" default:"1s"`
+	BudgetRatio float64       `yaml:"budget_ratio" default:"0.1"`
+	BudgetBurst int           `yaml:"budget_burst" default:"10"`
 }
 
 // JWTConfig holds the jwt-related configuration
 type JWTConfig struct {
 	Secret     string        `yaml:"secret" env:"JWT_SECRET"`
-	Expiration time.Duration `yaml:"expiration" default:"24h"`
-	Issuer     string        `yaml:"issuer" default:"microservice-api"`
+	Expiration time.Duration `yaml:"expiration" default:"15m"`
+	Issuer     string        `yaml:"issuer" default:"todo-api"`
 }
 
-// Logger config holds logger related configuration
+// Logger config holds logger related configuration. OutputPath is "stdout",
+// "stderr" or a file path; Outputs lists several of them, plus "syslog", to
+// log to all at once and takes precedence over OutputPath
 type LoggerConfig struct {
-	Level      string `yaml:"level" env:"LOG_LEVEL" default:"info"`
-	Format     string `yaml:"format" default:"json"`
-	OutputPath string `yaml:"output_path" default:"stdout"`
+	Level      string            `yaml:"level" env:"LOG_LEVEL" default:"info"`
+	Format     string            `yaml:"format" env:"LOG_FORMAT" default:"json"`
+	OutputPath string            `yaml:"output_path" default:"stdout"`
+	Outputs    []string          `yaml:"outputs" env:"LOG_OUTPUTS"`
+	Rotation   LogRotationConfig `yaml:"rotation"`
+	Syslog     LogSyslogConfig   `yaml:"syslog"`
+	RequestLog RequestLogConfig  `yaml:"request_log"`
+	AccessLog  AccessLogConfig   `yaml:"access_log"`
+	Capture    BodyCaptureConfig `yaml:"capture"`
+}
+
+// AccessLogConfig writes an access log line per request in the Apache
+// "combined" or "common" log format to OutputPath, alongside the structured
+// request log. File paths rotate like the other log files
+type AccessLogConfig struct {
+	Enabled    bool   `yaml:"enabled" env:"ACCESS_LOG_ENABLED" default:"false"`
+	Format     string `yaml:"format" env:"ACCESS_LOG_FORMAT" default:"combined"`
+	OutputPath string `yaml:"output_path" env:"ACCESS_LOG_OUTPUT_PATH" default:"stdout"`
+}
+
+// BodyCaptureConfig captures the request and response bodies of the routes
+// matching Routes and of the requests whose ID is listed in RequestIDs, for
+// debugging. Routes are patterns such as "POST /api/v1/todos" or
+// "/api/v1/todos/**", where * matches one segment and a trailing ** the
+// rest. The values of RedactFields, in bodies, query strings and headers,
+// are masked, bodies larger than MaxBodySize are left out. Captures are
+// kept in the cache for TTL and read through the admin API
+type BodyCaptureConfig struct {
+	Enabled      bool          `yaml:"enabled" env:"BODY_CAPTURE_ENABLED" default:"false"`
+	Routes       []string      `yaml:"routes"`
+	RequestIDs   []string      `yaml:"request_ids" env:"BODY_CAPTURE_REQUEST_IDS"`
+	RedactFields []string      `yaml:"redact_fields" default:"password,current_password,new_password,token,access_token,refresh_token,id_token,secret,client_secret,api_key,code"`
+	MaxBodySize  int64         `yaml:"max_body_size" default:"65536"`
+	TTL          time.Duration `yaml:"ttl" default:"15m"`
+}
+
+// LogRotationConfig rotates file outputs once they grow past MaxSizeMB,
+// keeping up to MaxBackups rotated files no older than MaxAge, zero keeps
+// them all. Rotated files are gzipped when Compress is set
+type LogRotationConfig struct {
+	Enabled    bool          `yaml:"enabled" default:"true"`
+	MaxSizeMB  int           `yaml:"max_size_mb" default:"100"`
+	MaxBackups int           `yaml:"max_backups" default:"10"`
+	MaxAge     time.Duration `yaml:"max_age" default:"720h"`
+	Compress   bool          `yaml:"compress" default:"true"`
+}
+
+// LogSyslogConfig configures the "syslog" output. An empty Network logs to
+// the local syslog socket, which journald reads on systemd hosts, otherwise
+// entries are sent to Address over "udp", "tcp" or "unix"
+type LogSyslogConfig struct {
+	Network string `yaml:"network" env:"LOG_SYSLOG_NETWORK"`
+	Address string `yaml:"address" env:"LOG_SYSLOG_ADDRESS"`
+	Tag     string `yaml:"tag" default:"todo-api"`
+}
+
+// RequestLogConfig holds request logging configuration. SkipPaths are never
+// logged, SampleRate is the fraction (0-1) of successful requests that are
+// logged and RouteSampleRates overrides it per route pattern
+type RequestLogConfig struct {
+	Enabled          bool               `yaml:"enabled" default:"true"`
+	SkipPaths        []string           `yaml:"skip_paths" default:"/api/v1/health,/readyz,/metrics"`
+	SampleRate       float64            `yaml:"sample_rate" default:"1"`
+	RouteSampleRates map[string]float64 `yaml:"route_sample_rates"`
 }
 
-// RateLimitConfig holds rate limit configuration
+// RateLimitConfig holds rate limit configuration. Backend is "memory" for
+// single instances or "redis" to share limits across replicas
 type RateLimitConfig struct {
 	Enabled           bool          `yaml:"enabled" env:"RATE_LIMIT_ENABLED" default:"true"`
-	RequestsPerWindow int           `yaml:"request_per_window" env:"RATE_LIMIT_REQUEST_PER_WINDOW" default:"100"`
+	RequestsPerWindow int           `yaml:"requests_per_window" env:"RATE_LIMIT_REQUESTS_PER_WINDOW" default:"100"`
 	Window            time.Duration `yaml:"window" env:"RATE_LIMIT_WINDOW" default:"1m"`
 	UserBased         bool          `yaml:"user_based" default:"false"`
+	Backend           string        `yaml:"backend" env:"RATE_LIMIT_BACKEND" default:"memory"`
 }
 
 // CORSConfig holds CORS configuration
 type CORSConfig struct {
 	Enabled        bool     `yaml:"enabled" default:"true"`
-	AllowedOrigins []string `yaml:"allowed_origins" env:"ALLOWED_ORIGINS"`
-	AllowedMethods []string `yaml:"allowed_methods"`
-	AllowedHeaders []string `yaml:"allowed_headers"`
+	AllowedOrigins []string `yaml:"allowed_origins" env:"ALLOWED_ORIGINS" default:"*"`
+	AllowedMethods []string `yaml:"allowed_methods" default:"GET,POST,PUT,PATCH,DELETE,OPTIONS"`
+	AllowedHeaders []string `yaml:"allowed_headers" default:"Content-Type,Authorization,X-CSRF-Token"`
 	MaxAge         int      `yaml:"max_age" default:"86400"`
 }
 
@@ -81,6 +288,360 @@ type RedisConfig struct {
 	Database int    `yaml:"database" env:"REDIS_DATABASE" default:"0"`
 }
 
+// TracingConfig holds distributed tracing configuration
+type TracingConfig struct {
+	Enabled     bool    `yaml:"enabled" env:"TRACING_ENABLED" default:"false"`
+	ServiceName string  `yaml:"service_name" env:"OTEL_SERVICE_NAME" default:"todo-api"`
+	Endpoint    string  `yaml:"endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT" default:"localhost:4318"`
+	Insecure    bool    `yaml:"insecure" default:"true"`
+	SampleRatio float64 `yaml:"sample_ratio" default:"1"`
+}
+
+// SentryConfig holds error reporting configuration. Environment defaults to
+// the server environment when empty
+type SentryConfig struct {
+	Enabled     bool    `yaml:"enabled" env:"SENTRY_ENABLED" default:"false"`
+	DSN         string  `yaml:"dsn" env:"SENTRY_DSN"`
+	Environment string  `yaml:"environment" env:"SENTRY_ENVIRONMENT"`
+	SampleRate  float64 `yaml:"sample_rate" default:"1"`
+}
+
+// SecretsConfig selects the provider that resolves "secret://name" references
+// in string values. Provider is "none", "vault" or "aws"
+type SecretsConfig struct {
+	Provider     string `yaml:"provider" env:"SECRETS_PROVIDER" default:"none"`
+	VaultAddress string `yaml:"vault_address" env:"VAULT_ADDR"`
+	VaultToken   string `yaml:"vault_token" env:"VAULT_TOKEN"`
+	VaultMount   string `yaml:"vault_mount" env:"VAULT_MOUNT" default:"secret"`
+	AWSRegion    string `yaml:"aws_region" env:"AWS_REGION"`
+}
+
+// EmailConfig configures outgoing mail. Provider is "smtp" or "log", the log
+// provider writes messages to the application log for local development.
+// PasswordResetURL is the page that receives the reset token as ?token=,
+// VerificationURL receives email verification tokens the same way
+type EmailConfig struct {
+	Provider         string        `yaml:"provider" env:"EMAIL_PROVIDER" default:"log"`
+	From             string        `yaml:"from" env:"EMAIL_FROM" default:"no-reply@example.com"`
+	SMTPHost         string        `yaml:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort         int           `yaml:"smtp_port" env:"SMTP_PORT" default:"587"`
+	SMTPUsername     string        `yaml:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword     string        `yaml:"smtp_password" env:"SMTP_PASSWORD"`
+	SMTPTimeout      time.Duration `yaml:"smtp_timeout" default:"10s"`
+	PasswordResetURL string        `yaml:"password_reset_url" env:"PASSWORD_RESET_URL" default:"http://localhost:3000/reset-password"`
+	VerificationURL  string        `yaml:"verification_url" env:"EMAIL_VERIFICATION_URL" default:"http://localhost:8000/api/v1/auth/verify"`
+	ExportURL        string        `yaml:"export_url" env:"EMAIL_EXPORT_URL" default:"http://localhost:8000/api/v1/exports/download"`
+}
+
+// AuthConfig holds the external identity providers offered for social
+// login. Callbacks are served at OAuthRedirectURL/{provider}/callback, which
+// must be registered with each provider
+type AuthConfig struct {
+	OAuthRedirectURL string            `yaml:"oauth_redirect_url" env:"OAUTH_REDIRECT_URL" default:"http://localhost:8000/api/v1/auth/oauth"`
+	OAuthStateTTL    time.Duration     `yaml:"oauth_state_ttl" default:"10m"`
+	Google           GoogleOAuthConfig `yaml:"google"`
+	GitHub           GitHubOAuthConfig `yaml:"github"`
+	OIDC             OIDCConfig        `yaml:"oidc"`
+	Sessions         SessionsConfig    `yaml:"sessions"`
+	SCIM             SCIMConfig        `yaml:"scim"`
+}
+
+// SCIMConfig enables the SCIM 2.0 endpoints under /scim/v2 through which an
+// identity provider creates, updates and deactivates users. The provider
+// sends Token as a bearer token, MaxResults caps the users of a list page
+type SCIMConfig struct {
+	Enabled    bool   `yaml:"enabled" env:"SCIM_ENABLED" default:"false"`
+	Token      string `yaml:"token" env:"SCIM_TOKEN"`
+	MaxResults int    `yaml:"max_results" default:"100"`
+}
+
+// SessionsConfig enables cookie sessions kept server-side, in Redis when it
+// is available, for browser clients. A session expires after
+// cache.session_ttl without requests and security.session_timeout after
+// login at the latest. SameSite is "lax" or "strict"; with
+// security.csrf_enabled requests changing state must echo the CSRF token of
+// the session in the X-CSRF-Token header
+type SessionsConfig struct {
+	Enabled      bool   `yaml:"enabled" env:"AUTH_SESSIONS_ENABLED" default:"false"`
+	CookieName   string `yaml:"cookie_name" default:"session"`
+	CookieDomain string `yaml:"cookie_domain" env:"AUTH_SESSIONS_COOKIE_DOMAIN"`
+	SameSite     string `yaml:"same_site" default:"lax"`
+}
+
+// GoogleOAuthConfig enables "Sign in with Google"
+type GoogleOAuthConfig struct {
+	Enabled      bool   `yaml:"enabled" env:"GOOGLE_OAUTH_ENABLED" default:"false"`
+	ClientID     string `yaml:"client_id" env:"GOOGLE_CLIENT_ID"`
+	ClientSecret string `yaml:"client_secret" env:"GOOGLE_CLIENT_SECRET"`
+}
+
+// GitHubOAuthConfig enables "Sign in with GitHub"
+type GitHubOAuthConfig struct {
+	Enabled      bool   `yaml:"enabled" env:"GITHUB_OAUTH_ENABLED" default:"false"`
+	ClientID     string `yaml:"client_id" env:"GITHUB_CLIENT_ID"`
+	ClientSecret string `yaml:"client_secret" env:"GITHUB_CLIENT_SECRET"`
+}
+
+// OIDCConfig enables login with any OpenID Connect provider, its endpoints
+// are discovered from IssuerURL at startup. Name is the provider segment
+// used in the login and callback URLs
+type OIDCConfig struct {
+	Enabled      bool     `yaml:"enabled" env:"OIDC_ENABLED" default:"false"`
+	Name         string   `yaml:"name" env:"OIDC_NAME" default:"oidc"`
+	IssuerURL    string   `yaml:"issuer_url" env:"OIDC_ISSUER_URL"`
+	ClientID     string   `yaml:"client_id" env:"OIDC_CLIENT_ID"`
+	ClientSecret string   `yaml:"client_secret" env:"OIDC_CLIENT_SECRET"`
+	Scopes       []string `yaml:"scopes" default:"openid,email,profile"`
+}
+
+// TodosConfig holds todo behaviour. With CompletionRollup a parent is
+// completed once all of its sub-tasks are and reopened when one of them is,
+// MaxDepth limits how deeply sub-tasks can be nested. Deleted todos stay in
+// the trash for TrashRetention, purged by the trash_purge job. MaxImport
+// bounds the todos of one import request
+type TodosConfig struct {
+	CompletionRollup bool          `yaml:"completion_rollup" env:"TODO_COMPLETION_ROLLUP" default:"true"`
+	MaxDepth         int           `yaml:"max_depth" default:"10"`
+	TrashRetention   time.Duration `yaml:"trash_retention" env:"TODO_TRASH_RETENTION" default:"720h"`
+	MaxImport        int           `yaml:"max_import" default:"10000"`
+}
+
+// PaginationConfig bounds the page sizes of list endpoints, DefaultLimit
+// applies when a request does not ask for a size and larger requests are
+// clamped to MaxLimit
+type PaginationConfig struct {
+	DefaultLimit int `yaml:"default_limit" default:"20"`
+	MaxLimit     int `yaml:"max_limit" default:"100"`
+}
+
+// AuditConfig controls the audit trail of data mutations, served to admins
+// at /api/v1/admin/audit
+type AuditConfig struct {
+	Enabled bool `yaml:"enabled" env:"AUDIT_ENABLED" default:"true"`
+}
+
+// SecurityEventsConfig controls the log of logins, failed logins, lockouts,
+// token refreshes and password changes, users see their own recent activity
+// at /api/v1/auth/activity. Export is "none", "log" to write every event to
+// the application log or "http" to POST them in batches of up to
+// ExportBatchSize to ExportURL for a SIEM; events are dropped rather than
+// slowing logins down when more than ExportBufferSize are waiting
+type SecurityEventsConfig struct {
+	Enabled          bool          `yaml:"enabled" env:"SECURITY_EVENTS_ENABLED" default:"true"`
+	Retention        time.Duration `yaml:"retention" default:"2160h"`
+	Export           string        `yaml:"export" env:"SECURITY_EVENTS_EXPORT" default:"none"`
+	ExportURL        string        `yaml:"export_url" env:"SECURITY_EVENTS_EXPORT_URL"`
+	ExportToken      string        `yaml:"export_token" env:"SECURITY_EVENTS_EXPORT_TOKEN"`
+	ExportBufferSize int           `yaml:"export_buffer_size" default:"1000"`
+	ExportBatchSize  int           `yaml:"export_batch_size" default:"100"`
+	ExportInterval   time.Duration `yaml:"export_interval" default:"5s"`
+}
+
+// EventsConfig controls the domain event bus and the Server-Sent Events
+// stream of todo changes at /api/v1/events. Backend is "memory" for single
+// instances or "redis" to deliver events to every replica. Idle streams
+// receive a heartbeat comment every HeartbeatInterval and the last
+// ReplayBuffer events are kept so clients can resume after reconnecting.
+// DatabaseChanges also publishes the todo changes other services write to
+// the shared Postgres database, received with LISTEN/NOTIFY
+type EventsConfig struct {
+	Enabled           bool          `yaml:"enabled" env:"EVENTS_ENABLED" default:"true"`
+	Backend           string        `yaml:"backend" env:"EVENTS_BACKEND" default:"memory"`
+	HeartbeatInterval time.Duration `yaml:"heartbeat_interval" default:"15s"`
+	ReplayBuffer      int           `yaml:"replay_buffer" default:"1000"`
+	DatabaseChanges   bool          `yaml:"database_changes" env:"EVENTS_DATABASE_CHANGES" default:"false"`
+}
+
+// MessagingConfig forwards todo events to an external message broker so
+// other services can react to them. Driver is "nats" or "kafka" and Source
+// identifies this service in the published messages
+type MessagingConfig struct {
+	Enabled        bool          `yaml:"enabled" env:"MESSAGING_ENABLED" default:"false"`
+	Driver         string        `yaml:"driver" env:"MESSAGING_DRIVER" default:"nats"`
+	Source         string        `yaml:"source" default:"todo-api"`
+	PublishTimeout time.Duration `yaml:"publish_timeout" default:"5s"`
+	NATS           NATSConfig    `yaml:"nats"`
+	Kafka          KafkaConfig   `yaml:"kafka"`
+}
+
+// NATSConfig holds the NATS connection, events are published to
+// SubjectPrefix.<event type>
+type NATSConfig struct {
+	URL            string        `yaml:"url" env:"NATS_URL" default:"nats://localhost:4222"`
+	SubjectPrefix  string        `yaml:"subject_prefix" default:"todo-api"`
+	ConnectTimeout time.Duration `yaml:"connect_timeout" default:"5s"`
+}
+
+// KafkaConfig holds the Kafka brokers and the topic all events are written to
+type KafkaConfig struct {
+	Brokers []string `yaml:"brokers" env:"KAFKA_BROKERS" default:"localhost:9092"`
+	Topic   string   `yaml:"topic" env:"KAFKA_TOPIC" default:"todo-events"`
+}
+
+// OutboxConfig tunes the relay of the transactional outbox, which publishes
+// todo events to the event bus and message broker after their transaction
+// commits. Pending messages are polled every PollInterval in batches of
+// BatchSize and published ones are kept for Retention
+type OutboxConfig struct {
+	PollInterval time.Duration `yaml:"poll_interval" default:"1s"`
+	BatchSize    int           `yaml:"batch_size" default:"100"`
+	Retention    time.Duration `yaml:"retention" default:"168h"`
+}
+
+// GRPCConfig enables the gRPC server of the todo API, described by
+// api/proto/todo/v1/todo.proto. It accepts the access tokens of the REST API
+// and is served over TLS when server.tls is enabled
+type GRPCConfig struct {
+	Enabled bool   `yaml:"enabled" env:"GRPC_ENABLED" default:"false"`
+	Host    string `yaml:"host" env:"GRPC_HOST" default:"0.0.0.0"`
+	Port    int    `yaml:"port" env:"GRPC_PORT" default:"50051"`
+}
+
+// GraphQLConfig enables the GraphQL endpoint at /api/v1/graphql. Queries
+// nested deeper than MaxDepth are rejected before they are executed
+type GraphQLConfig struct {
+	Enabled  bool `yaml:"enabled" env:"GRAPHQL_ENABLED" default:"false"`
+	MaxDepth int  `yaml:"max_depth" default:"10"`
+}
+
+// DocsConfig serves the OpenAPI document generated from the routes at
+// /openapi.json. The Swagger UI at /docs is only served outside production
+type DocsConfig struct {
+	Enabled bool `yaml:"enabled" env:"DOCS_ENABLED" default:"true"`
+}
+
+// CircuitBreakerConfig configures the breakers guarding the database and
+// the Redis cache. A breaker opens after FailureThreshold consecutive
+// failures, rejects calls for OpenDuration and then lets HalfOpenProbes
+// calls through to test whether the dependency recovered
+type CircuitBreakerConfig struct {
+	Enabled          bool          `yaml:"enabled" env:"CIRCUIT_BREAKER_ENABLED" default:"true"`
+	FailureThreshold int           `yaml:"failure_threshold" default:"5"`
+	OpenDuration     time.Duration `yaml:"open_duration" default:"30s"`
+	HalfOpenProbes   int           `yaml:"half_open_probes" default:"1"`
+}
+
+// HTTPClientConfig configures the clients for calls to downstream services.
+// Timeout bounds a whole call including retries. Failed idempotent requests
+// are retried up to MaxRetries times, waiting a random delay of up to
+// RetryBaseDelay doubled per attempt and capped at RetryMaxDelay
+type HTTPClientConfig struct {
+	Timeout             time.Duration `yaml:"timeout" env:"HTTP_CLIENT_TIMEOUT" default:"10s"`
+	MaxRetries          int           `yaml:"max_retries" default:"2"`
+	RetryBaseDelay      time.Duration `yaml:"retry_base_delay" default:"100ms"`
+	RetryMaxDelay       time.Duration `yaml:"retry_max_delay" default:"2s"`
+	MaxIdleConnsPerHost int           `yaml:"max_idle_conns_per_host" default:"10"`
+}
+
+// JobsConfig configures the background job scheduler. Schedules are cron
+// expressions with five fields, descriptors such as @hourly or
+// "@every 15m". Expired sessions and tokens are kept for TokenRetention
+// before the cleanup removes them, and the cache is warmed up
+// CacheWarmupDelay after startup
+type JobsConfig struct {
+	Enabled          bool          `yaml:"enabled" env:"JOBS_ENABLED" default:"true"`
+	TrashPurge       string        `yaml:"trash_purge" env:"JOBS_TRASH_PURGE" default:"@hourly"`
+	TokenCleanup     string        `yaml:"token_cleanup" env:"JOBS_TOKEN_CLEANUP" default:"30 3 * * *"`
+	AccountErasure   string        `yaml:"account_erasure" env:"JOBS_ACCOUNT_ERASURE" default:"@hourly"`
+	DueReminders     string        `yaml:"due_reminders" env:"JOBS_DUE_REMINDERS" default:"*/5 * * * *"`
+	Recurrences      string        `yaml:"recurrences" env:"JOBS_RECURRENCES" default:"*/5 * * * *"`
+	Reminders        string        `yaml:"reminders" env:"JOBS_REMINDERS" default:"@every 1m"`
+	TokenRetention   time.Duration `yaml:"token_retention" default:"24h"`
+	CacheWarmupDelay time.Duration `yaml:"cache_warmup_delay" default:"5s"`
+}
+
+// QueueConfig enables the Redis task queue for slow work taken off the
+// request path, such as sending emails. Workers started with "server worker"
+// run Concurrency tasks at once and poll Queues in order, so earlier queues
+// take priority; tasks are enqueued on "default" unless asked otherwise.
+// Failed tasks are retried up to MaxRetries times with exponential backoff
+// between RetryBaseDelay and RetryMaxDelay, then kept in the dead letter
+// queue for DeadRetention. A task whose worker stops renewing its lease for
+// LeaseDuration is handed to another worker
+type QueueConfig struct {
+	Enabled         bool          `yaml:"enabled" env:"QUEUE_ENABLED" default:"false"`
+	Queues          []string      `yaml:"queues" env:"QUEUE_NAMES" default:"critical,default,low"`
+	Concurrency     int           `yaml:"concurrency" env:"QUEUE_CONCURRENCY" default:"10"`
+	PollInterval    time.Duration `yaml:"poll_interval" default:"1s"`
+	TaskTimeout     time.Duration `yaml:"task_timeout" default:"5m"`
+	LeaseDuration   time.Duration `yaml:"lease_duration" default:"30s"`
+	MaxRetries      int           `yaml:"max_retries" default:"5"`
+	RetryBaseDelay  time.Duration `yaml:"retry_base_delay" default:"10s"`
+	RetryMaxDelay   time.Duration `yaml:"retry_max_delay" default:"10m"`
+	DeadRetention   time.Duration `yaml:"dead_retention" default:"168h"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" default:"30s"`
+}
+
+// ExportConfig holds the todo exports. Asynchronous exports are built by a
+// queue worker and kept in Redis for LinkTTL, exports larger than MaxFileSize
+// bytes fail and must be streamed instead
+type ExportConfig struct {
+	LinkTTL     time.Duration `yaml:"link_ttl" env:"EXPORT_LINK_TTL" default:"24h"`
+	MaxFileSize int64         `yaml:"max_file_size" default:"33554432"`
+}
+
+// AccountsConfig holds the deletion of user accounts. Deleted accounts are
+// erased together with all of their data by the account_erasure job once
+// DeletionGracePeriod has passed, users can cancel the deletion until then
+type AccountsConfig struct {
+	DeletionGracePeriod time.Duration `yaml:"deletion_grace_period" env:"ACCOUNT_DELETION_GRACE_PERIOD" default:"720h"`
+}
+
+// NotificationsConfig holds the notifications sent to users by email and on
+// the webhook and Slack channels they add. Reminders are sent by the
+// due_reminders job for todos due within ReminderLeadTime. The reminders
+// job queues the reminders users set on todos ReminderLookahead before they
+// are due, as delayed tasks. Webhook and Slack requests time out after
+// Timeout and may not reach private addresses unless AllowPrivateNetworks
+// is set
+type NotificationsConfig struct {
+	Enabled              bool          `yaml:"enabled" env:"NOTIFICATIONS_ENABLED" default:"true"`
+	ReminderLeadTime     time.Duration `yaml:"reminder_lead_time" default:"24h"`
+	ReminderLookahead    time.Duration `yaml:"reminder_lookahead" default:"15m"`
+	Timeout              time.Duration `yaml:"timeout" default:"10s"`
+	AllowPrivateNetworks bool          `yaml:"allow_private_networks" env:"NOTIFICATIONS_ALLOW_PRIVATE_NETWORKS" default:"false"`
+}
+
+// SharingConfig holds the sharing of todos with other users. A shared todo
+// has at most MaxCollaborators collaborators, invitations included
+type SharingConfig struct {
+	Enabled          bool `yaml:"enabled" env:"SHARING_ENABLED" default:"true"`
+	MaxCollaborators int  `yaml:"max_collaborators" default:"20"`
+}
+
+// BlobStorageConfig selects where attachment contents are kept. Backend is
+// "local", files below LocalDir, or "s3" for Amazon S3 and compatible
+// services such as MinIO
+type BlobStorageConfig struct {
+	Backend  string   `yaml:"backend" env:"BLOB_BACKEND" default:"local"`
+	LocalDir string   `yaml:"local_dir" env:"BLOB_LOCAL_DIR" default:"./data/blobs"`
+	S3       S3Config `yaml:"s3"`
+}
+
+// S3Config locates the attachment bucket. An empty Endpoint uses AWS, other
+// services usually need UsePathStyle. Without an access key the default AWS
+// credential chain is used. PresignTTL is how long the URLs handed to clients
+// for direct uploads and downloads stay valid
+type S3Config struct {
+	Endpoint        string        `yaml:"endpoint" env:"S3_ENDPOINT"`
+	Region          string        `yaml:"region" env:"S3_REGION" default:"us-east-1"`
+	Bucket          string        `yaml:"bucket" env:"S3_BUCKET"`
+	AccessKeyID     string        `yaml:"access_key_id" env:"S3_ACCESS_KEY_ID"`
+	SecretAccessKey string        `yaml:"secret_access_key" env:"S3_SECRET_ACCESS_KEY"`
+	UsePathStyle    bool          `yaml:"use_path_style" env:"S3_USE_PATH_STYLE" default:"false"`
+	PresignTTL      time.Duration `yaml:"presign_ttl" env:"S3_PRESIGN_TTL" default:"15m"`
+}
+
+// AdminServerConfig enables an internal HTTP server for operational
+// endpoints: metrics, profiling, health checks and a dump of the running
+// configuration. While enabled, metrics and profiling are no longer served on
+// the public port
+type AdminServerConfig struct {
+	Enabled bool   `yaml:"enabled" env:"ADMIN_SERVER_ENABLED" default:"false"`
+	Host    string `yaml:"host" env:"ADMIN_SERVER_HOST" default:"127.0.0.1"`
+	Port    int    `yaml:"port" env:"ADMIN_SERVER_PORT" default:"9090"`
+}
+
 // GetConnectionString return the database connection string
 func (c *DatabaseConfig) GetConnectionString() string {
 	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
@@ -102,6 +663,16 @@ func (c *ServerConfig) GetAddress() string {
 	return fmt.Sprintf("%s:%d", c.Host, c.Port)
 }
 
+// GetAddress returns the gRPC server address
+func (c *GRPCConfig) GetAddress() string {
+	return fmt.Sprintf("%s:%d", c.Host, c.Port)
+}
+
+// GetAddress returns the admin server address
+func (c *AdminServerConfig) GetAddress() string {
+	return fmt.Sprintf("%s:%d", c.Host, c.Port)
+}
+
 // GetConnectionString returns the Redis connection string
 func (c *RedisConfig) GetConnectionString() string {
 	return fmt.Sprintf("%s:%d", c.Host, c.Port)
@@ -112,16 +683,20 @@ func (c *RedisConfig) GetAddress() string {
 	return fmt.Sprintf("%s:%d", c.Host, c.Port)
 }
 
-// CacheConfig hold cache related configuration
+// CacheConfig hold cache related configuration. Backend selects "redis" or
+// "memory"; the TTL tiers are offered to callers by how long their data may
+// safely be served stale
 type CacheConfig struct {
 	Enabled    bool          `yaml:"enabled" env:"CACHE_ENABLED" default:"true"`
+	Backend    string        `yaml:"backend" env:"CACHE_BACKEND" default:"redis"`
 	DefaultTTL time.Duration `yaml:"default_ttl" default:"1h"`
 	LongTTL    time.Duration `yaml:"long_ttl" default:"24h"`
 	ShortTTL   time.Duration `yaml:"short_ttl" default:"5m"`
 	SessionTTL time.Duration `yaml:"session_ttl" default:"30m"`
 	StateTTL   time.Duration `yaml:"state_ttl" default:"15m"`
-	MaxMemory  string        `yaml:"max_memory" default:"256md"`
-	KeyPrefix  string        `yaml:"key_prefix" default:"go-microservice-api"`
+	MaxMemory  string        `yaml:"max_memory" default:"256mb"`
+	KeyPrefix  string        `yaml:"key_prefix" default:"todo-api:"`
+	Retry      RetryConfig   `yaml:"retry"`
 }
 
 // MetricsConfig holds metrics-related configuration
@@ -130,16 +705,16 @@ type MetricsConfig struct {
 	CollectionInterval time.Duration `yaml:"collection_interval" default:"30s"`
 	RetentionPeriod    time.Duration `yaml:"retention_period" default:"24h"`
 	ExportPrometheus   bool          `yaml:"export_prometheus" default:"false"`
-	PrometheusPath     string        `yaml:"prometheus_path" default:"/matrics"`
+	PrometheusPath     string        `yaml:"prometheus_path" default:"/metrics"`
 }
 
 // SecurityConfig holds security-related configuration
 type SecurityConfig struct {
 	PasswordMinLength       int           `yaml:"password_min_length" default:"8"`
-	PasswordRequiredUpper   string        `yaml:"password_required_upper" default:"true"`
-	PasswordRequiredLower   string        `yaml:"password_required_lower" default:"true"`
-	PasswordRequiredDigital string        `yaml:"password_required_digital" default:"true"`
-	PasswordRequiredSymbol  string        `yaml:"password_required_symbol" default:"false"`
+	PasswordRequiredUpper   bool          `yaml:"password_required_upper" default:"true"`
+	PasswordRequiredLower   bool          `yaml:"password_required_lower" default:"true"`
+	PasswordRequiredDigital bool          `yaml:"password_required_digital" default:"true"`
+	PasswordRequiredSymbol  bool          `yaml:"password_required_symbol" default:"false"`
 	MaxLoginAttempts        int           `yaml:"max_login_attempts" default:"5"`
 	LoginLogoutDuration     time.Duration `yaml:"login_logout_duration" default:"15m"`
 	SessionTimeout          time.Duration `yaml:"session_timeout" default:"24h"`
@@ -148,9 +723,44 @@ type SecurityConfig struct {
 	SecureHeaders           bool          `yaml:"secure_header" default:"true"`
 	ContentTypeValidation   bool          `yaml:"content_type_validation" default:"true"`
 	MaxRequestSize          int64         `yaml:"max_request_size" default:"10485760"`
+	AdminToken              string        `yaml:"admin_token" env:"ADMIN_TOKEN"`
+	PasswordResetTTL        time.Duration `yaml:"password_reset_ttl" default:"1h"`
+
+	// EmailVerification sends a verification link on registration and
+	// RequireVerifiedEmail additionally refuses logins until it is followed
+	EmailVerification    bool          `yaml:"email_verification" default:"false"`
+	RequireVerifiedEmail bool          `yaml:"require_verified_email" default:"false"`
+	EmailVerificationTTL time.Duration `yaml:"email_verification_ttl" default:"24h"`
+
+	// RouteMaxRequestSizes overrides MaxRequestSize per route pattern and
+	// ContentTypeSkipRoutes lists route patterns that accept any content type
+	RouteMaxRequestSizes  map[string]int64 `yaml:"route_max_request_sizes"`
+	ContentTypeSkipRoutes []string         `yaml:"content_type_skip_routes"`
+
+	// MaxUploadSize bounds attachment uploads, whose type detected from
+	// their content must match AllowedUploadTypes. Entries are media types
+	// or wildcards such as "image/*"
+	MaxUploadSize      int64    `yaml:"max_upload_size" default:"10485760"`
+	AllowedUploadTypes []string `yaml:"allowed_upload_types" default:"image/png,image/jpeg,image/gif,image/webp,application/pdf,text/plain"`
+
+	// SignedRequests verifies the requests integrations sign with API keys
+	SignedRequests SignedRequestsConfig `yaml:"signed_requests"`
+}
+
+// SignedRequestsConfig verifies requests signed with the secret of an API
+// key, an HMAC-SHA256 over the method, path and query, timestamp, nonce and
+// body. KeySecrets lists the keys as "id:secret". Timestamps further than
+// MaxSkew from the server clock are refused and each nonce is accepted once,
+// so captured requests cannot be replayed. Requests carrying a signature are
+// always verified, route policies with signed set refuse unsigned ones
+type SignedRequestsConfig struct {
+	Enabled    bool          `yaml:"enabled" env:"SIGNED_REQUESTS_ENABLED" default:"false"`
+	KeySecrets []string      `yaml:"key_secrets" env:"SIGNED_REQUESTS_KEY_SECRETS"`
+	MaxSkew    time.Duration `yaml:"max_skew" default:"5m"`
 }
 
-// PerformanceConfig holds performance-related configuration
+// PerformanceConfig holds performance-related configuration. API requests
+// over MaxConcurrentRequests wait up to QueueTimeout and are then rejected
 type PerformanceConfig struct {
 	EnableCompression     bool          `yaml:"enable_compression" default:"true"`
 	CompressionLevel      int           `yaml:"compression_level" default:"6"`
@@ -158,10 +768,11 @@ type PerformanceConfig struct {
 	EnableCaching         bool          `yaml:"enable_caching" default:"true"`
 	CacheControlMaxAge    int           `yaml:"cache_control_max_age" default:"3600"`
 	EnableETag            bool          `yaml:"enable_etag" default:"true"`
-	MaxConcurrentRequests int           `yaml:"max_concurrent_requests" default:"1000"`
+	MaxConcurrentRequests int           `yaml:"max_concurrent_requests" env:"MAX_CONCURRENT_REQUESTS" default:"1000"`
+	QueueTimeout          time.Duration `yaml:"queue_timeout" default:"100ms"`
 	RequestTimeout        time.Duration `yaml:"request_timeout" default:"30s"`
 	KeepAliveTimeout      time.Duration `yaml:"keep_alive_timeout" default:"60s"`
-	EnableProfiling       bool          `yaml:"enable_profiling" default:"false"`
+	EnableProfiling       bool          `yaml:"enable_profiling" env:"ENABLE_PROFILING" default:"false"`
 	ProfilingPath         string        `yaml:"profiling_path" default:"/debug/pprof"`
 }
 
@@ -189,3 +800,166 @@ func (c *PerformanceConfig) IsCachingEnabled() bool {
 func (c *PerformanceConfig) IsProfilingEnabled() bool {
 	return c.EnableProfiling
 }
+
+// WebhooksConfig enables outbound webhooks, which deliver todo events to URLs
+// registered by users. Due deliveries are polled every PollInterval in
+// batches of BatchSize and sent Concurrency at a time, each request bounded by
+// Timeout. Failed deliveries are retried up to MaxAttempts attempts in total
+// with exponential backoff between RetryBaseDelay and RetryMaxDelay. Delivery
+// history is kept for Retention. Unless AllowPrivateNetworks is set, URLs
+// resolving to loopback, private or link-local addresses are refused
+type WebhooksConfig struct {
+	Enabled              bool          `yaml:"enabled" env:"WEBHOOKS_ENABLED" default:"false"`
+	MaxPerUser           int           `yaml:"max_per_user" default:"10"`
+	Timeout              time.Duration `yaml:"timeout" default:"10s"`
+	PollInterval         time.Duration `yaml:"poll_interval" default:"2s"`
+	BatchSize            int           `yaml:"batch_size" default:"50"`
+	Concurrency          int           `yaml:"concurrency" default:"8"`
+	MaxAttempts          int           `yaml:"max_attempts" default:"8"`
+	RetryBaseDelay       time.Duration `yaml:"retry_base_delay" default:"30s"`
+	RetryMaxDelay        time.Duration `yaml:"retry_max_delay" default:"6h"`
+	Retention            time.Duration `yaml:"retention" default:"720h"`
+	AllowPrivateNetworks bool          `yaml:"allow_private_networks" env:"WEBHOOKS_ALLOW_PRIVATE_NETWORKS" default:"false"`
+}
+
+// InboundConfig enables the /webhooks/:provider receiver for events sent by
+// third parties. Each provider is only served when it is enabled and the
+// application registered a handler for it. Bodies larger than MaxBodySize are
+// rejected and Stripe signatures older than Tolerance are refused as replays
+type InboundConfig struct {
+	Enabled     bool                `yaml:"enabled" env:"INBOUND_WEBHOOKS_ENABLED" default:"false"`
+	MaxBodySize int64               `yaml:"max_body_size" default:"1048576"`
+	Tolerance   time.Duration       `yaml:"tolerance" default:"5m"`
+	GitHub      GitHubWebhookConfig `yaml:"github"`
+	Stripe      StripeWebhookConfig `yaml:"stripe"`
+	HMAC        HMACWebhookConfig   `yaml:"hmac"`
+}
+
+// GitHubWebhookConfig verifies GitHub deliveries by their
+// X-Hub-Signature-256 header
+type GitHubWebhookConfig struct {
+	Enabled bool   `yaml:"enabled" env:"GITHUB_WEBHOOK_ENABLED" default:"false"`
+	Secret  string `yaml:"secret" env:"GITHUB_WEBHOOK_SECRET"`
+}
+
+// StripeWebhookConfig verifies Stripe events by their Stripe-Signature header
+type StripeWebhookConfig struct {
+	Enabled bool   `yaml:"enabled" env:"STRIPE_WEBHOOK_ENABLED" default:"false"`
+	Secret  string `yaml:"secret" env:"STRIPE_WEBHOOK_SECRET"`
+}
+
+// HMACWebhookConfig verifies providers signing the body with HMAC-SHA256 in
+// SignatureHeader, hex encoded after an optional Prefix. Name is the provider
+// segment of the URL, the event type and id are read from EventHeader and
+// IDHeader when set
+type HMACWebhookConfig struct {
+	Enabled         bool   `yaml:"enabled" env:"HMAC_WEBHOOK_ENABLED" default:"false"`
+	Name            string `yaml:"name" env:"HMAC_WEBHOOK_NAME" default:"generic"`
+	Secret          string `yaml:"secret" env:"HMAC_WEBHOOK_SECRET"`
+	SignatureHeader string `yaml:"signature_header" default:"X-Signature"`
+	Prefix          string `yaml:"prefix" default:"sha256="`
+	EventHeader     string `yaml:"event_header" default:"X-Event-Type"`
+	IDHeader        string `yaml:"id_header" default:"X-Delivery-ID"`
+}
+
+// QuotasConfig caps the usage of each user, a zero limit is unlimited. Admins
+// can override the limits of a user, overrides are cached for
+// OverrideCacheTTL. Creating todos or storing attachments over the limit
+// fails with 402, API calls over the daily limit with 429 until midnight UTC.
+// Calls are counted in Redis with the redis backend so every replica shares
+// the count
+type QuotasConfig struct {
+	Enabled            bool          `yaml:"enabled" env:"QUOTAS_ENABLED" default:"false"`
+	Backend            string        `yaml:"backend" env:"QUOTAS_BACKEND" default:"memory"`
+	MaxTodos           int           `yaml:"max_todos" env:"QUOTA_MAX_TODOS" default:"10000"`
+	MaxAttachmentBytes int64         `yaml:"max_attachment_bytes" env:"QUOTA_MAX_ATTACHMENT_BYTES" default:"1073741824"`
+	MaxAPICallsPerDay  int           `yaml:"max_api_calls_per_day" env:"QUOTA_MAX_API_CALLS_PER_DAY" default:"10000"`
+	OverrideCacheTTL   time.Duration `yaml:"override_cache_ttl" default:"1m"`
+}
+
+// I18nConfig selects the language of error and validation messages. English
+// and Spanish are built in, <lang>.json files in Dir add languages or replace
+// built-in messages. DefaultLanguage is used when the client accepts none of
+// them
+type I18nConfig struct {
+	DefaultLanguage string `yaml:"default_language" env:"I18N_DEFAULT_LANGUAGE" default:"en"`
+	Dir             string `yaml:"dir" env:"I18N_DIR"`
+}
+
+// ResponsesConfig selects the formats responses can be negotiated in with
+// the Accept header. JSON and XML are always available, JSON being the
+// default
+type ResponsesConfig struct {
+	MsgPack bool `yaml:"msgpack" env:"RESPONSES_MSGPACK" default:"false"`
+}
+
+// APIConfig holds the versions of the REST API, served side by side under
+// /api/v1 and /api/v2
+type APIConfig struct {
+	V1 APIVersionConfig `yaml:"v1"`
+	V2 APIVersionConfig `yaml:"v2"`
+}
+
+// APIVersionConfig controls the lifecycle of an API version. Responses of a
+// version with a Deprecated date carry the Deprecation header, and the
+// Sunset header once Sunset is set, both dates as YYYY-MM-DD or RFC 3339.
+// Link points clients to the migration guide. Retired versions are disabled
+// and answer 410 Gone
+type APIVersionConfig struct {
+	Enabled    bool   `yaml:"enabled" default:"true"`
+	Deprecated string `yaml:"deprecated"`
+	Sunset     string `yaml:"sunset"`
+	Link       string `yaml:"link"`
+}
+
+// SchemasConfig controls the validation of request bodies against the JSON
+// Schemas of their route. The schemas are served under /schemas either way
+type SchemasConfig struct {
+	Validate bool `yaml:"validate" env:"SCHEMA_VALIDATION" default:"true"`
+}
+
+// RoutePolicyConfig changes the middleware of the routes matching Path and,
+// when given, Methods. Path is matched against routes as registered, such
+// as /api/v1/todos/:id, where * matches one segment and a trailing ** any
+// number of them. The first matching policy applies, settings left out keep
+// the default behaviour. Policies are read at startup
+type RoutePolicyConfig struct {
+	Path    string   `yaml:"path"`
+	Methods []string `yaml:"methods"`
+
+	// Auth requires an access token when true, even on routes that are
+	// otherwise public, and lets requests without one through when false
+	Auth *bool `yaml:"auth"`
+
+	// Compression gzips responses of at least
+	// performance.compression_min_length bytes when true
+	Compression *bool `yaml:"compression"`
+
+	// Cost is how many requests of the rate limit a request counts as, so
+	// expensive endpoints use up the limit faster. Zero counts as one
+	Cost int `yaml:"cost"`
+
+	// Signed refuses requests without a valid signature when true, see
+	// security.signed_requests
+	Signed *bool `yaml:"signed"`
+
+	RateLimit *RouteRateLimitConfig `yaml:"rate_limit"`
+	Cache     *RouteCacheConfig     `yaml:"cache"`
+}
+
+// RouteRateLimitConfig replaces the rate limit of the routes of a policy.
+// They are counted apart from the rest of the API, or not limited at all
+// when disabled
+type RouteRateLimitConfig struct {
+	Enabled           bool          `yaml:"enabled"`
+	RequestsPerWindow int           `yaml:"requests_per_window"`
+	Window            time.Duration `yaml:"window"`
+}
+
+// RouteCacheConfig sets the Cache-Control header of successful GET responses
+// of the routes of a policy, no-store when disabled. A MaxAge of zero uses
+// performance.cache_control_max_age
+type RouteCacheConfig struct {
+	Enabled bool `yaml:"enabled"`
+	MaxAge  int  `yaml:"max_age"`
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Change describes a configuration value that differs between two configs.
+// Values of secret fields are masked
+type Change struct {
+	Path string
+	Old  string
+	New  string
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Path, c.Old, c.New)
+}
+
+// Diff returns every leaf value that differs between old and new, keyed by
+// YAML path (e.g. "rate_limit.window")
+func Diff(old, new *Config) []Change {
+	var changes []Change
+	diffStruct(reflect.ValueOf(*old), reflect.ValueOf(*new), "", &changes)
+	return changes
+}
+
+func diffStruct(old, new reflect.Value, prefix string, changes *[]Change) {
+	t := old.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			name = strings.ToLower(field.Name)
+		}
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+
+		oldField, newField := old.Field(i), new.Field(i)
+		if field.Type.Kind() == reflect.Struct && field.Type.NumField() > 0 {
+			diffStruct(oldField, newField, path, changes)
+			continue
+		}
+
+		if reflect.DeepEqual(oldField.Interface(), newField.Interface()) {
+			continue
+		}
+
+		change := Change{
+			Path: path,
+			Old:  fmt.Sprint(oldField.Interface()),
+			New:  fmt.Sprint(newField.Interface()),
+		}
+		if isSecret(field.Name) {
+			change.Old, change.New = "***", "***"
+		}
+		*changes = append(*changes, change)
+	}
+}
+
+// isSecret reports whether a field holds a credential that must not be logged
+func isSecret(name string) bool {
+	return strings.Contains(name, "Password") || strings.Contains(name, "Secret") ||
+		strings.HasSuffix(name, "Token") || strings.HasSuffix(name, "DSN") || strings.HasSuffix(name, "DSNs")
+}
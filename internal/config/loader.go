@@ -20,11 +20,13 @@ func Load(configPath string) (*Config, error) {
 	// Load .env file first if it exists
 	if err := loadDotConfig(".env"); err != nil {
 		// Don't fail if .env doesn't exist, just log it
-		fmt.Printf("Warning: Could not load .env file: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Warning: Could not load .env file: %v\n", err)
 	}
 
 	// set default first
-	setDefaults(cfg)
+	if err := applyDefaults(reflect.ValueOf(cfg).Elem()); err != nil {
+		return nil, fmt.Errorf("failed to apply defaults: %w", err)
+	}
 
 	// Load from file if path is provided
 	if configPath != "" {
@@ -38,6 +40,11 @@ func Load(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to load config from env: %w", err)
 	}
 
+	// Resolve secret references
+	if err := resolveSecrets(cfg); err != nil {
+		return nil, fmt.Errorf("failed to resolve secrets: %w", err)
+	}
+
 	// Validate configuration
 	if err := validate(cfg); err != nil {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
@@ -59,57 +66,6 @@ func LoadForEnvironment(env string) (*Config, error) {
 	return Load(configPath)
 }
 
-
-// set Defaults sets default values from the configration
-func setDefaults(cfg *Config) {
-	// Server defaults
-	cfg.Server.Host = "localhost"
-	cfg.Server.Port = 8000
-	cfg.Server.ReadTimeout = 15 * time.Second
-	cfg.Server.WriteTimeout = 15 * time.Second
-	cfg.Server.IdleTimeout = 60 * time.Second
-	cfg.Server.Environment = "development"
-
-	// Database defaults
-	cfg.Database.Host = "localhost"
-	cfg.Database.Port = 5432
-	cfg.Database.User = "postgres"
-	cfg.Database.Password = "root"
-	cfg.Database.Database = "todo"
-	cfg.Database.SSLMode = "disable"
-	cfg.Database.MaxOpenConns = 25
-	cfg.Database.MaxIdleConns = 5
-	cfg.Database.ConnMaxLifetime = 5 * time.Minute
-
-	// JWT defaults
-	cfg.JWT.Expiration = 24 * time.Hour
-	cfg.JWT.Issuer = "todo-api"
-
-	// Logger defaults
-	cfg.Logger.Level = "info"
-	cfg.Logger.Format = "json"
-	cfg.Logger.OutputPath = "stdout"
-
-	// Rate limit defaults
-	cfg.RateLimit.Enabled = true
-	cfg.RateLimit.RequestsPerWindow = 100
-	cfg.RateLimit.Window = time.Minute
-	cfg.RateLimit.UserBased = false
-
-	// CORS defaults
-	cfg.CORS.Enabled = true
-	cfg.CORS.AllowedOrigins = []string{"*"}
-	cfg.CORS.AllowedMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	cfg.CORS.AllowedHeaders = []string{"Content-Type", "Authorization"}
-	cfg.CORS.MaxAge = 86400
-
-	// Redis defaults
-	cfg.Redis.Host = "localhost"
-	cfg.Redis.Port = 6379
-	cfg.Redis.Password = ""
-	cfg.Redis.Database = 0
-}
-
 func loadDotConfig(fileName string) error {
 	file, err := os.Open(fileName)
 
@@ -188,8 +144,11 @@ func loadStructFromEnv(v reflect.Value, prefix string) error {
 			continue
 		}
 
-		if envTag == "" {
-			envValue := os.Getenv(envTag)
+		if envTag != "" {
+			envValue, err := lookupEnv(envTag)
+			if err != nil {
+				return err
+			}
 			if envValue != "" {
 				if err := setFieldValue(field, envValue); err != nil {
 					return fmt.Errorf("failed to set %s: %w", envTag, err)
@@ -201,6 +160,50 @@ func loadStructFromEnv(v reflect.Value, prefix string) error {
 	return nil
 }
 
+// applyDefaults sets every field from its `default` struct tag, recursing
+// into nested structs. Slices take a comma-separated list
+func applyDefaults(v reflect.Value) error {
+	t := v.Type()
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		fieldType := t.Field(i)
+
+		if field.Kind() == reflect.Struct {
+			if err := applyDefaults(field); err != nil {
+				return err
+			}
+			continue
+		}
+
+		value := fieldType.Tag.Get("default")
+		if value == "" {
+			continue
+		}
+
+		if err := setFieldValue(field, value); err != nil {
+			return fmt.Errorf("invalid default for %s.%s: %w", t.Name(), fieldType.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// lookupEnv returns the value of key, or the contents of the file named by
+// key_FILE when that is set so secrets can be mounted instead of exported
+func lookupEnv(key string) (string, error) {
+	path := os.Getenv(key + "_FILE")
+	if path == "" {
+		return os.Getenv(key), nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s_FILE: %w", key, err)
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
 func setFieldValue(field reflect.Value, value string) error {
 	// handle time.duration first before checking other types
 	if field.Type() == reflect.TypeOf(time.Duration(0)) {
@@ -230,6 +233,12 @@ func setFieldValue(field reflect.Value, value string) error {
 		}
 
 		field.SetBool(boolValue)
+	case reflect.Float32, reflect.Float64:
+		floatValue, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(floatValue)
 	case reflect.Slice:
 		// Handle string slices (comma-sperated)
 		if field.Type().Elem().Kind() == reflect.String {
@@ -239,27 +248,3 @@ func setFieldValue(field reflect.Value, value string) error {
 	}
 	return nil
 }
-
-// validate validates the configuration
-func validate(cfg *Config) error {
-	// Validate JWT secret
-	if cfg.JWT.Secret == "" {
-		// try to generate a default for developement
-		if cfg.Server.Environment == "development" {
-			cfg.JWT.Secret = "development-seecret-do-not-use-in-production"
-		} else {
-			return fmt.Errorf("JWT secret is required")
-		}
-	}
-
-	if cfg.Database.Host == "" || cfg.Database.Database == "" {
-		return fmt.Errorf("database host and name are required")
-	}
-
-	// Validate server configuration
-	if cfg.Server.Port <= 0 || cfg.Server.Port > 65535 {
-		return fmt.Errorf("invalid server port: %d", cfg.Server.Port)
-	}
-
-	return nil
-}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Redacted returns the configuration as a tree keyed by YAML names, safe to
+// expose to operators. Values of secret fields are masked
+func Redacted(cfg *Config) map[string]any {
+	return redactStruct(reflect.ValueOf(*cfg))
+}
+
+func redactStruct(v reflect.Value) map[string]any {
+	t := v.Type()
+	result := make(map[string]any, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		value := v.Field(i)
+		switch {
+		case field.Type.Kind() == reflect.Struct && field.Type.NumField() > 0:
+			result[name] = redactStruct(value)
+		case isSecret(field.Name):
+			// Only reveal whether a secret is set
+			if value.IsZero() {
+				result[name] = ""
+			} else {
+				result[name] = "***"
+			}
+		case field.Type == reflect.TypeOf(time.Duration(0)):
+			result[name] = value.Interface().(time.Duration).String()
+		default:
+			result[name] = value.Interface()
+		}
+	}
+	return result
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/secrets"
+)
+
+// SecretRefPrefix marks a string value as a reference to be resolved through
+// the configured secret provider, e.g. "secret://todo-api/database#password"
+const SecretRefPrefix = "secret://"
+
+// newSecretProvider creates the provider selected in the secrets configuration
+func newSecretProvider(ctx context.Context, cfg *SecretsConfig) (secrets.Provider, error) {
+	switch cfg.Provider {
+	case "vault":
+		return secrets.NewVaultProvider(cfg.VaultAddress, cfg.VaultToken, cfg.VaultMount)
+	case "aws":
+		return secrets.NewAWSProvider(ctx, cfg.AWSRegion)
+	case "none", "":
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown secrets provider: %s", cfg.Provider)
+}
+
+// resolveSecrets replaces every secret reference in cfg with its value
+func resolveSecrets(cfg *Config) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	provider, err := newSecretProvider(ctx, &cfg.Secrets)
+	if err != nil {
+		return err
+	}
+
+	r := &secretResolver{provider: provider, resolved: make(map[string]string)}
+	return r.resolve(ctx, reflect.ValueOf(cfg).Elem(), "")
+}
+
+// secretResolver walks the config and resolves references, fetching each
+// distinct secret only once
+type secretResolver struct {
+	provider secrets.Provider
+	resolved map[string]string
+}
+
+func (r *secretResolver) resolve(ctx context.Context, v reflect.Value, prefix string) error {
+	t := v.Type()
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		path := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+
+		if field.Kind() == reflect.Struct {
+			if err := r.resolve(ctx, field, path); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String {
+			for j := 0; j < field.Len(); j++ {
+				if err := r.resolveString(ctx, field.Index(j), fmt.Sprintf("%s[%d]", path, j)); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if field.Kind() == reflect.String {
+			if err := r.resolveString(ctx, field, path); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// resolveString replaces a string holding a secret reference with its value
+func (r *secretResolver) resolveString(ctx context.Context, field reflect.Value, path string) error {
+	if !strings.HasPrefix(field.String(), SecretRefPrefix) {
+		return nil
+	}
+
+	if r.provider == nil {
+		return fmt.Errorf("%s references a secret but no secrets provider is configured", path)
+	}
+
+	name := strings.TrimPrefix(field.String(), SecretRefPrefix)
+	value, ok := r.resolved[name]
+	if !ok {
+		var err error
+		value, err = r.provider.GetSecret(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", path, err)
+		}
+		r.resolved[name] = value
+	}
+	field.SetString(value)
+	return nil
+}
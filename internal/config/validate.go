@@ -0,0 +1,844 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"net/netip"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/encryption"
+)
+
+// minAdminTokenLength keeps the admin token from being guessable
+const minAdminTokenLength = 32
+
+// minSCIMTokenLength keeps the token of the identity provider from being
+// guessable
+const minSCIMTokenLength = 32
+
+// minSigningSecretLength keeps the secrets of API keys from being guessable
+const minSigningSecretLength = 32
+
+// FieldError describes a single invalid configuration value, Path is the
+// YAML path of the offending key (e.g. "cache.default_ttl")
+type FieldError struct {
+	Path    string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// ValidationError aggregates every violation found in a configuration so
+// they can all be fixed in one pass
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Errors) == 1 {
+		return e.Errors[0].Error()
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d configuration errors:", len(e.Errors))
+	for _, fe := range e.Errors {
+		b.WriteString("\n  - ")
+		b.WriteString(fe.Error())
+	}
+	return b.String()
+}
+
+// validator collects field errors while walking the configuration
+type validator struct {
+	errs []FieldError
+}
+
+func (v *validator) addf(path, format string, args ...any) {
+	v.errs = append(v.errs, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) positive(path string, d time.Duration) {
+	if d <= 0 {
+		v.addf(path, "must be positive, got %s", d)
+	}
+}
+
+func (v *validator) positiveInt(path string, n int) {
+	if n <= 0 {
+		v.addf(path, "must be positive, got %d", n)
+	}
+}
+
+func (v *validator) between(path string, f, min, max float64) {
+	if f < min || f > max {
+		v.addf(path, "must be between %g and %g, got %g", min, max, f)
+	}
+}
+
+func (v *validator) retry(path string, cfg RetryConfig) {
+	v.positiveInt(path+".max_attempts", cfg.MaxAttempts)
+	if cfg.MaxAttempts > 1 {
+		v.positive(path+".base_delay", cfg.BaseDelay)
+		if cfg.MaxDelay < cfg.BaseDelay {
+			v.addf(path+".max_delay", "must not be less than base_delay (%s), got %s", cfg.BaseDelay, cfg.MaxDelay)
+		}
+		if cfg.BudgetRatio < 0 {
+			v.addf(path+".budget_ratio", "must not be negative, got %g", cfg.BudgetRatio)
+		}
+		if cfg.BudgetRatio > 0 {
+			v.positiveInt(path+".budget_burst", cfg.BudgetBurst)
+		}
+	}
+}
+
+func (v *validator) oneOf(path, value string, allowed ...string) {
+	for _, a := range allowed {
+		if strings.EqualFold(value, a) {
+			return
+		}
+	}
+	v.addf(path, "must be one of %s, got %q", strings.Join(allowed, ", "), value)
+}
+
+func (v *validator) port(path string, port int) {
+	if port <= 0 || port > 65535 {
+		v.addf(path, "must be between 1 and 65535, got %d", port)
+	}
+}
+
+func (v *validator) required(path, value string) {
+	if value == "" {
+		v.addf(path, "is required")
+	}
+}
+
+// validate checks every section of the configuration and returns a
+// *ValidationError listing all violations
+func validate(cfg *Config) error {
+	// Validate JWT secret
+	if cfg.JWT.Secret == "" {
+		// try to generate a default for developement
+		if cfg.Server.Environment == "development" {
+			cfg.JWT.Secret = "development-seecret-do-not-use-in-production"
+		}
+	}
+
+	v := &validator{}
+
+	// Server
+	v.required("server.host", cfg.Server.Host)
+	v.port("server.port", cfg.Server.Port)
+	v.positive("server.read_timeout", cfg.Server.ReadTimeout)
+	v.positive("server.write_timeout", cfg.Server.WriteTimeout)
+	v.positive("server.idle_timeout", cfg.Server.IdleTimeout)
+	v.positive("server.shutdown_timeout", cfg.Server.ShutdownTimeout)
+	v.positive("server.shutdown.workers", cfg.Server.Shutdown.Workers)
+	v.positive("server.shutdown.events", cfg.Server.Shutdown.Events)
+	v.positive("server.shutdown.cache", cfg.Server.Shutdown.Cache)
+	v.positive("server.shutdown.database", cfg.Server.Shutdown.Database)
+	v.oneOf("server.environment", cfg.Server.Environment, "development", "staging", "production")
+	for i, proxy := range cfg.Server.TrustedProxies {
+		if !validProxy(proxy) {
+			v.addf(fmt.Sprintf("server.trusted_proxies[%d]", i), "must be an IP address or CIDR range, got %q", proxy)
+		}
+	}
+	for i, header := range cfg.Server.ClientIPHeaders {
+		v.oneOf(fmt.Sprintf("server.client_ip_headers[%d]", i), header, "Forwarded", "X-Forwarded-For", "X-Real-IP")
+	}
+	if tls := cfg.Server.TLS; tls.Enabled {
+		v.oneOf("server.tls.min_version", tls.MinVersion, "1.2", "1.3")
+		if tls.Autocert.Enabled {
+			if len(tls.Autocert.Domains) == 0 {
+				v.addf("server.tls.autocert.domains", "is required")
+			}
+			v.required("server.tls.autocert.cache_dir", tls.Autocert.CacheDir)
+			v.port("server.tls.autocert.http_port", tls.Autocert.HTTPPort)
+			if tls.ClientCAFile != "" {
+				v.addf("server.tls.client_ca_file", "is not supported with autocert")
+			}
+		} else {
+			v.required("server.tls.cert_file", tls.CertFile)
+			v.required("server.tls.key_file", tls.KeyFile)
+			v.positive("server.tls.reload_interval", tls.ReloadInterval)
+		}
+	} else if tls.Autocert.Enabled {
+		v.addf("server.tls.autocert.enabled", "requires server.tls.enabled")
+	}
+	if http2 := cfg.Server.HTTP2; http2.Enabled || http2.H2C {
+		v.positiveInt("server.http2.max_concurrent_streams", http2.MaxConcurrentStreams)
+		// Frame sizes are bounded by RFC 9113
+		if http2.MaxReadFrameSize < 16<<10 || http2.MaxReadFrameSize > 16<<20-1 {
+			v.addf("server.http2.max_read_frame_size", "must be between 16384 and 16777215, got %d", http2.MaxReadFrameSize)
+		}
+	}
+
+	// Database
+	v.oneOf("database.driver", cfg.Database.Driver, "postgres", "pgx", "memory")
+	if cfg.Database.Driver != "memory" {
+		v.required("database.host", cfg.Database.Host)
+		v.port("database.port", cfg.Database.Port)
+		v.required("database.database", cfg.Database.Database)
+		v.oneOf("database.ssl_mode", cfg.Database.SSLMode,
+			"disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+		v.positiveInt("database.max_open_conns", cfg.Database.MaxOpenConns)
+		if cfg.Database.MaxIdleConns < 0 || cfg.Database.MaxIdleConns > cfg.Database.MaxOpenConns {
+			v.addf("database.max_idle_conns", "must be between 0 and max_open_conns (%d), got %d",
+				cfg.Database.MaxOpenConns, cfg.Database.MaxIdleConns)
+		}
+		v.positive("database.conn_max_lifetime", cfg.Database.ConnMaxLifetime)
+		v.positiveInt("database.connect_attempts", cfg.Database.ConnectAttempts)
+		v.positive("database.connect_retry_interval", cfg.Database.ConnectRetryInterval)
+		if cfg.Database.StatementCacheSize < 0 {
+			v.addf("database.statement_cache_size", "must not be negative, got %d", cfg.Database.StatementCacheSize)
+		}
+		if cfg.Database.SlowQueryThreshold < 0 {
+			v.addf("database.slow_query_threshold", "must not be negative, got %s", cfg.Database.SlowQueryThreshold)
+		}
+		if cfg.Database.StatementTimeout < 0 {
+			v.addf("database.statement_timeout", "must not be negative, got %s", cfg.Database.StatementTimeout)
+		}
+		for _, operation := range slices.Sorted(maps.Keys(cfg.Database.OperationTimeouts)) {
+			timeout := cfg.Database.OperationTimeouts[operation]
+			path := "database.operation_timeouts." + operation
+			v.oneOf(path, operation, "export", "migration")
+			if timeout < 0 {
+				v.addf(path, "must not be negative, got %s", timeout)
+			}
+		}
+		if strings.ContainsAny(cfg.Database.ApplicationName, "'\\") {
+			v.addf("database.application_name", "must not contain quotes or backslashes")
+		}
+		for i, dsn := range cfg.Database.ReplicaDSNs {
+			v.required(fmt.Sprintf("database.replica_dsns[%d]", i), dsn)
+		}
+		v.positive("database.monitor_interval", cfg.Database.MonitorInterval)
+		v.retry("database.retry", cfg.Database.Retry)
+		if encrypt := cfg.Database.Encryption; encrypt.Enabled {
+			v.required("database.encryption.active_key", encrypt.ActiveKey)
+			if len(encrypt.KeySecrets) == 0 {
+				v.addf("database.encryption.key_secrets", "is required")
+			} else if _, err := encryption.NewKeyring(encrypt.KeySecrets, encrypt.ActiveKey); err != nil {
+				v.addf("database.encryption.key_secrets", "%v", err)
+			}
+		}
+	}
+
+	// JWT
+	v.required("jwt.secret", cfg.JWT.Secret)
+	v.positive("jwt.expiration", cfg.JWT.Expiration)
+	v.required("jwt.issuer", cfg.JWT.Issuer)
+
+	// Logger
+	v.oneOf("logger.level", cfg.Logger.Level, "debug", "info", "warn", "warning", "error")
+	v.oneOf("logger.format", cfg.Logger.Format, "json", "text", "console")
+	v.required("logger.output_path", cfg.Logger.OutputPath)
+	for i, output := range cfg.Logger.Outputs {
+		v.required(fmt.Sprintf("logger.outputs[%d]", i), strings.TrimSpace(output))
+	}
+	if access := cfg.Logger.AccessLog; access.Enabled {
+		v.oneOf("logger.access_log.format", access.Format, "combined", "common")
+		v.required("logger.access_log.output_path", access.OutputPath)
+	}
+	if capture := cfg.Logger.Capture; capture.Enabled {
+		if !cfg.Cache.Enabled {
+			v.addf("logger.capture.enabled", "requires cache.enabled")
+		}
+		for i, route := range capture.Routes {
+			path := strings.TrimSpace(route)
+			if method, rest, found := strings.Cut(path, " "); found {
+				v.oneOf(fmt.Sprintf("logger.capture.routes[%d]", i), method,
+					"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS")
+				path = strings.TrimSpace(rest)
+			}
+			if !strings.HasPrefix(path, "/") {
+				v.addf(fmt.Sprintf("logger.capture.routes[%d]", i), "must be a path starting with /, got %q", route)
+			}
+		}
+		if capture.MaxBodySize <= 0 {
+			v.addf("logger.capture.max_body_size", "must be positive, got %d", capture.MaxBodySize)
+		}
+		v.positive("logger.capture.ttl", capture.TTL)
+	}
+	if rotation := cfg.Logger.Rotation; rotation.Enabled {
+		v.positiveInt("logger.rotation.max_size_mb", rotation.MaxSizeMB)
+		if rotation.MaxBackups < 0 {
+			v.addf("logger.rotation.max_backups", "must not be negative, got %d", rotation.MaxBackups)
+		}
+		if rotation.MaxAge < 0 {
+			v.addf("logger.rotation.max_age", "must not be negative, got %s", rotation.MaxAge)
+		}
+	}
+	if syslog := cfg.Logger.Syslog; slices.Contains(cfg.Logger.Outputs, "syslog") {
+		v.oneOf("logger.syslog.network", syslog.Network, "", "udp", "tcp", "unix")
+		if syslog.Network != "" {
+			v.required("logger.syslog.address", syslog.Address)
+		}
+	}
+	v.between("logger.request_log.sample_rate", cfg.Logger.RequestLog.SampleRate, 0, 1)
+	routes := make([]string, 0, len(cfg.Logger.RequestLog.RouteSampleRates))
+	for route := range cfg.Logger.RequestLog.RouteSampleRates {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+	for _, route := range routes {
+		v.between("logger.request_log.route_sample_rates."+route, cfg.Logger.RequestLog.RouteSampleRates[route], 0, 1)
+	}
+
+	// Rate limit
+	if cfg.RateLimit.Enabled {
+		v.positiveInt("rate_limit.requests_per_window", cfg.RateLimit.RequestsPerWindow)
+		v.positive("rate_limit.window", cfg.RateLimit.Window)
+		v.oneOf("rate_limit.backend", cfg.RateLimit.Backend, "memory", "redis")
+	}
+
+	// CORS
+	if cfg.CORS.Enabled && cfg.CORS.MaxAge < 0 {
+		v.addf("cors.max_age", "must not be negative, got %d", cfg.CORS.MaxAge)
+	}
+
+	// Redis
+	v.port("redis.port", cfg.Redis.Port)
+	if cfg.Redis.Database < 0 {
+		v.addf("redis.database", "must not be negative, got %d", cfg.Redis.Database)
+	}
+
+	// Cache
+	if cfg.Cache.Enabled {
+		v.oneOf("cache.backend", cfg.Cache.Backend, "memory", "redis")
+		v.positive("cache.default_ttl", cfg.Cache.DefaultTTL)
+		v.positive("cache.long_ttl", cfg.Cache.LongTTL)
+		v.positive("cache.short_ttl", cfg.Cache.ShortTTL)
+		v.positive("cache.session_ttl", cfg.Cache.SessionTTL)
+		v.positive("cache.state_ttl", cfg.Cache.StateTTL)
+		if _, err := ParseByteSize(cfg.Cache.MaxMemory); err != nil {
+			v.addf("cache.max_memory", "%v", err)
+		}
+		v.retry("cache.retry", cfg.Cache.Retry)
+	}
+
+	// Metrics
+	if cfg.Metrics.Enabled {
+		v.positive("metrics.collection_interval", cfg.Metrics.CollectionInterval)
+		v.positive("metrics.retention_period", cfg.Metrics.RetentionPeriod)
+		if !strings.HasPrefix(cfg.Metrics.PrometheusPath, "/") {
+			v.addf("metrics.prometheus_path", "must start with /, got %q", cfg.Metrics.PrometheusPath)
+		}
+	}
+
+	// Security
+	v.positiveInt("security.password_min_length", cfg.Security.PasswordMinLength)
+	v.positiveInt("security.max_login_attempts", cfg.Security.MaxLoginAttempts)
+	v.positive("security.login_logout_duration", cfg.Security.LoginLogoutDuration)
+	v.positive("security.session_timeout", cfg.Security.SessionTimeout)
+	v.positive("security.password_reset_ttl", cfg.Security.PasswordResetTTL)
+	if cfg.Security.EmailVerification {
+		v.positive("security.email_verification_ttl", cfg.Security.EmailVerificationTTL)
+	} else if cfg.Security.RequireVerifiedEmail {
+		v.addf("security.require_verified_email", "requires security.email_verification to be enabled")
+	}
+	if cfg.Security.CSRFEnabled {
+		v.positiveInt("security.csrf_token_length", cfg.Security.CSRFTokenLength)
+	}
+	if cfg.Security.MaxRequestSize <= 0 {
+		v.addf("security.max_request_size", "must be positive, got %d", cfg.Security.MaxRequestSize)
+	}
+	if token := cfg.Security.AdminToken; token != "" && len(token) < minAdminTokenLength {
+		v.addf("security.admin_token", "must be at least %d characters", minAdminTokenLength)
+	}
+	if cfg.Security.MaxUploadSize <= 0 {
+		v.addf("security.max_upload_size", "must be positive, got %d", cfg.Security.MaxUploadSize)
+	}
+	for _, mediaType := range cfg.Security.AllowedUploadTypes {
+		if !strings.Contains(mediaType, "/") {
+			v.addf("security.allowed_upload_types", "must be media types such as image/png, got %q", mediaType)
+		}
+	}
+	if signed := cfg.Security.SignedRequests; signed.Enabled {
+		if len(signed.KeySecrets) == 0 {
+			v.addf("security.signed_requests.key_secrets", "is required")
+		}
+		for i, key := range signed.KeySecrets {
+			id, secret, found := strings.Cut(strings.TrimSpace(key), ":")
+			if !found || id == "" {
+				v.addf(fmt.Sprintf("security.signed_requests.key_secrets[%d]", i), "must be an id:secret pair")
+			} else if len(secret) < minSigningSecretLength {
+				v.addf(fmt.Sprintf("security.signed_requests.key_secrets[%d]", i), "secret of key %q must be at least %d characters", id, minSigningSecretLength)
+			}
+		}
+		v.positive("security.signed_requests.max_skew", signed.MaxSkew)
+	}
+	routes = routes[:0]
+	for route := range cfg.Security.RouteMaxRequestSizes {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+	for _, route := range routes {
+		if size := cfg.Security.RouteMaxRequestSizes[route]; size <= 0 {
+			v.addf("security.route_max_request_sizes."+route, "must be positive, got %d", size)
+		}
+	}
+
+	// Performance
+	if cfg.Performance.EnableCompression {
+		if cfg.Performance.CompressionLevel < 1 || cfg.Performance.CompressionLevel > 9 {
+			v.addf("performance.compression_level", "must be between 1 and 9, got %d",
+				cfg.Performance.CompressionLevel)
+		}
+		if cfg.Performance.CompressionMinLength < 0 {
+			v.addf("performance.compression_min_length", "must not be negative, got %d",
+				cfg.Performance.CompressionMinLength)
+		}
+	}
+	if cfg.Performance.EnableCaching && cfg.Performance.CacheControlMaxAge < 0 {
+		v.addf("performance.cache_control_max_age", "must not be negative, got %d",
+			cfg.Performance.CacheControlMaxAge)
+	}
+	v.positiveInt("performance.max_concurrent_requests", cfg.Performance.MaxConcurrentRequests)
+	v.positive("performance.queue_timeout", cfg.Performance.QueueTimeout)
+	v.positive("performance.request_timeout", cfg.Performance.RequestTimeout)
+	v.positive("performance.keep_alive_timeout", cfg.Performance.KeepAliveTimeout)
+	if cfg.Performance.EnableProfiling {
+		if !strings.HasPrefix(cfg.Performance.ProfilingPath, "/") {
+			v.addf("performance.profiling_path", "must start with /, got %q", cfg.Performance.ProfilingPath)
+		}
+		if cfg.Security.AdminToken == "" && !cfg.AdminServer.Enabled {
+			v.addf("performance.enable_profiling", "requires security.admin_token or admin_server to protect the profiling endpoints")
+		}
+	}
+
+	// Secrets
+	v.oneOf("secrets.provider", cfg.Secrets.Provider, "none", "vault", "aws")
+	if cfg.Secrets.Provider == "vault" {
+		v.required("secrets.vault_address", cfg.Secrets.VaultAddress)
+		v.required("secrets.vault_token", cfg.Secrets.VaultToken)
+	}
+
+	// Email
+	v.oneOf("email.provider", cfg.Email.Provider, "smtp", "log")
+	v.required("email.from", cfg.Email.From)
+	if cfg.Email.Provider == "smtp" {
+		v.required("email.smtp_host", cfg.Email.SMTPHost)
+		v.port("email.smtp_port", cfg.Email.SMTPPort)
+		v.positive("email.smtp_timeout", cfg.Email.SMTPTimeout)
+	}
+	v.required("email.password_reset_url", cfg.Email.PasswordResetURL)
+	if cfg.Security.EmailVerification {
+		v.required("email.verification_url", cfg.Email.VerificationURL)
+	}
+	if cfg.Queue.Enabled {
+		v.required("email.export_url", cfg.Email.ExportURL)
+	}
+
+	// Auth
+	oauth := cfg.Auth
+	if oauth.Google.Enabled || oauth.GitHub.Enabled || oauth.OIDC.Enabled {
+		v.required("auth.oauth_redirect_url", oauth.OAuthRedirectURL)
+		v.positive("auth.oauth_state_ttl", oauth.OAuthStateTTL)
+	}
+	if oauth.Google.Enabled {
+		v.required("auth.google.client_id", oauth.Google.ClientID)
+		v.required("auth.google.client_secret", oauth.Google.ClientSecret)
+	}
+	if oauth.GitHub.Enabled {
+		v.required("auth.github.client_id", oauth.GitHub.ClientID)
+		v.required("auth.github.client_secret", oauth.GitHub.ClientSecret)
+	}
+	if oauth.OIDC.Enabled {
+		v.required("auth.oidc.name", oauth.OIDC.Name)
+		if oauth.OIDC.Name == "google" || oauth.OIDC.Name == "github" {
+			v.addf("auth.oidc.name", "must not collide with a built-in provider, got %q", oauth.OIDC.Name)
+		}
+		v.required("auth.oidc.issuer_url", oauth.OIDC.IssuerURL)
+		v.required("auth.oidc.client_id", oauth.OIDC.ClientID)
+		v.required("auth.oidc.client_secret", oauth.OIDC.ClientSecret)
+	}
+	if sessions := cfg.Auth.Sessions; sessions.Enabled {
+		v.required("auth.sessions.cookie_name", sessions.CookieName)
+		v.oneOf("auth.sessions.same_site", sessions.SameSite, "lax", "strict")
+		// Checked with the cache otherwise
+		if !cfg.Cache.Enabled {
+			v.positive("cache.session_ttl", cfg.Cache.SessionTTL)
+		}
+	}
+	if scim := cfg.Auth.SCIM; scim.Enabled {
+		if len(scim.Token) < minSCIMTokenLength {
+			v.addf("auth.scim.token", "must be at least %d characters", minSCIMTokenLength)
+		}
+		v.positiveInt("auth.scim.max_results", scim.MaxResults)
+	}
+
+	// Tracing
+	if cfg.Tracing.Enabled {
+		v.required("tracing.service_name", cfg.Tracing.ServiceName)
+		v.required("tracing.endpoint", cfg.Tracing.Endpoint)
+	}
+	v.between("tracing.sample_ratio", cfg.Tracing.SampleRatio, 0, 1)
+
+	// Sentry
+	if cfg.Sentry.Enabled {
+		v.required("sentry.dsn", cfg.Sentry.DSN)
+	}
+	v.between("sentry.sample_rate", cfg.Sentry.SampleRate, 0, 1)
+
+	// Todos
+	v.positiveInt("todos.max_depth", cfg.Todos.MaxDepth)
+	v.positive("todos.trash_retention", cfg.Todos.TrashRetention)
+	v.positiveInt("todos.max_import", cfg.Todos.MaxImport)
+
+	// Pagination
+	v.positiveInt("pagination.default_limit", cfg.Pagination.DefaultLimit)
+	v.positiveInt("pagination.max_limit", cfg.Pagination.MaxLimit)
+	if cfg.Pagination.DefaultLimit > cfg.Pagination.MaxLimit {
+		v.addf("pagination.default_limit", "must not exceed pagination.max_limit (%d)", cfg.Pagination.MaxLimit)
+	}
+
+	// Events, GraphQL subscriptions send the same heartbeats
+	if cfg.Events.Enabled || cfg.GraphQL.Enabled {
+		v.positive("events.heartbeat_interval", cfg.Events.HeartbeatInterval)
+	}
+	if cfg.Events.Enabled {
+		v.oneOf("events.backend", cfg.Events.Backend, "memory", "redis")
+		v.positiveInt("events.replay_buffer", cfg.Events.ReplayBuffer)
+		if cfg.Events.DatabaseChanges && cfg.Database.Driver == "memory" {
+			v.addf("events.database_changes", "requires a postgres or pgx database driver")
+		}
+	}
+
+	// Messaging
+	if msg := cfg.Messaging; msg.Enabled {
+		v.oneOf("messaging.driver", msg.Driver, "nats", "kafka")
+		v.required("messaging.source", msg.Source)
+		v.positive("messaging.publish_timeout", msg.PublishTimeout)
+		switch msg.Driver {
+		case "nats":
+			v.required("messaging.nats.url", msg.NATS.URL)
+			v.required("messaging.nats.subject_prefix", msg.NATS.SubjectPrefix)
+			v.positive("messaging.nats.connect_timeout", msg.NATS.ConnectTimeout)
+		case "kafka":
+			if len(msg.Kafka.Brokers) == 0 {
+				v.addf("messaging.kafka.brokers", "is required")
+			}
+			v.required("messaging.kafka.topic", msg.Kafka.Topic)
+		}
+	}
+
+	// Outbox
+	v.positive("outbox.poll_interval", cfg.Outbox.PollInterval)
+	v.positiveInt("outbox.batch_size", cfg.Outbox.BatchSize)
+	v.positive("outbox.retention", cfg.Outbox.Retention)
+
+	// gRPC server
+	if cfg.GRPC.Enabled {
+		v.required("grpc.host", cfg.GRPC.Host)
+		v.port("grpc.port", cfg.GRPC.Port)
+		if cfg.GRPC.Port == cfg.Server.Port {
+			v.addf("grpc.port", "must differ from server.port (%d)", cfg.Server.Port)
+		}
+		if cfg.AdminServer.Enabled && cfg.GRPC.Port == cfg.AdminServer.Port {
+			v.addf("grpc.port", "must differ from admin_server.port (%d)", cfg.AdminServer.Port)
+		}
+	}
+
+	// GraphQL
+	if cfg.GraphQL.Enabled {
+		v.positiveInt("graphql.max_depth", cfg.GraphQL.MaxDepth)
+	}
+
+	// Circuit breaker
+	if cfg.CircuitBreaker.Enabled {
+		v.positiveInt("circuit_breaker.failure_threshold", cfg.CircuitBreaker.FailureThreshold)
+		v.positive("circuit_breaker.open_duration", cfg.CircuitBreaker.OpenDuration)
+		v.positiveInt("circuit_breaker.half_open_probes", cfg.CircuitBreaker.HalfOpenProbes)
+	}
+
+	// HTTP client
+	v.positive("http_client.timeout", cfg.HTTPClient.Timeout)
+	if cfg.HTTPClient.MaxRetries < 0 {
+		v.addf("http_client.max_retries", "must not be negative, got %d", cfg.HTTPClient.MaxRetries)
+	}
+	v.positive("http_client.retry_base_delay", cfg.HTTPClient.RetryBaseDelay)
+	v.positive("http_client.retry_max_delay", cfg.HTTPClient.RetryMaxDelay)
+	v.positiveInt("http_client.max_idle_conns_per_host", cfg.HTTPClient.MaxIdleConnsPerHost)
+
+	// Jobs, schedules are parsed when the scheduler starts
+	if cfg.Jobs.Enabled {
+		v.required("jobs.trash_purge", cfg.Jobs.TrashPurge)
+		v.required("jobs.token_cleanup", cfg.Jobs.TokenCleanup)
+		v.required("jobs.account_erasure", cfg.Jobs.AccountErasure)
+		v.required("jobs.due_reminders", cfg.Jobs.DueReminders)
+		v.required("jobs.recurrences", cfg.Jobs.Recurrences)
+		v.required("jobs.reminders", cfg.Jobs.Reminders)
+		v.positive("jobs.token_retention", cfg.Jobs.TokenRetention)
+		if cfg.Jobs.CacheWarmupDelay < 0 {
+			v.addf("jobs.cache_warmup_delay", "must not be negative, got %s", cfg.Jobs.CacheWarmupDelay)
+		}
+	}
+
+	// Queue
+	if cfg.Queue.Enabled {
+		if !slices.Contains(cfg.Queue.Queues, "default") {
+			v.addf("queue.queues", "must include the default queue, got %v", cfg.Queue.Queues)
+		}
+		v.positiveInt("queue.concurrency", cfg.Queue.Concurrency)
+		v.positive("queue.poll_interval", cfg.Queue.PollInterval)
+		v.positive("queue.task_timeout", cfg.Queue.TaskTimeout)
+		v.positive("queue.lease_duration", cfg.Queue.LeaseDuration)
+		if cfg.Queue.MaxRetries < 0 {
+			v.addf("queue.max_retries", "must not be negative, got %d", cfg.Queue.MaxRetries)
+		}
+		v.positive("queue.retry_base_delay", cfg.Queue.RetryBaseDelay)
+		v.positive("queue.retry_max_delay", cfg.Queue.RetryMaxDelay)
+		v.positive("queue.dead_retention", cfg.Queue.DeadRetention)
+		v.positive("queue.shutdown_timeout", cfg.Queue.ShutdownTimeout)
+	}
+
+	// Export
+	v.positive("export.link_ttl", cfg.Export.LinkTTL)
+	if cfg.Export.MaxFileSize <= 0 {
+		v.addf("export.max_file_size", "must be positive, got %d", cfg.Export.MaxFileSize)
+	}
+
+	// Accounts
+	v.positive("accounts.deletion_grace_period", cfg.Accounts.DeletionGracePeriod)
+
+	// Notifications
+	if cfg.Notifications.Enabled {
+		v.positive("notifications.reminder_lead_time", cfg.Notifications.ReminderLeadTime)
+		v.positive("notifications.reminder_lookahead", cfg.Notifications.ReminderLookahead)
+		v.positive("notifications.timeout", cfg.Notifications.Timeout)
+	}
+
+	// Sharing
+	if cfg.Sharing.Enabled {
+		v.positiveInt("sharing.max_collaborators", cfg.Sharing.MaxCollaborators)
+	}
+
+	// Blob storage
+	v.oneOf("blob_storage.backend", cfg.BlobStorage.Backend, "local", "s3")
+	switch cfg.BlobStorage.Backend {
+	case "local":
+		v.required("blob_storage.local_dir", cfg.BlobStorage.LocalDir)
+	case "s3":
+		s3 := cfg.BlobStorage.S3
+		v.required("blob_storage.s3.bucket", s3.Bucket)
+		v.required("blob_storage.s3.region", s3.Region)
+		if s3.Endpoint != "" && !strings.HasPrefix(s3.Endpoint, "http://") && !strings.HasPrefix(s3.Endpoint, "https://") {
+			v.addf("blob_storage.s3.endpoint", "must be an http or https URL, got %q", s3.Endpoint)
+		}
+		if (s3.AccessKeyID == "") != (s3.SecretAccessKey == "") {
+			v.addf("blob_storage.s3.secret_access_key", "must be set together with access_key_id")
+		}
+		if s3.PresignTTL < time.Second || s3.PresignTTL > 7*24*time.Hour {
+			v.addf("blob_storage.s3.presign_ttl", "must be between 1s and 168h, got %s", s3.PresignTTL)
+		}
+	}
+
+	// Security events
+	if cfg.SecurityEvents.Enabled {
+		se := cfg.SecurityEvents
+		v.positive("security_events.retention", se.Retention)
+		v.oneOf("security_events.export", se.Export, "none", "log", "http")
+		if se.Export != "none" {
+			v.positiveInt("security_events.export_buffer_size", se.ExportBufferSize)
+			v.positiveInt("security_events.export_batch_size", se.ExportBatchSize)
+			v.positive("security_events.export_interval", se.ExportInterval)
+		}
+		if se.Export == "http" && !strings.HasPrefix(se.ExportURL, "http://") && !strings.HasPrefix(se.ExportURL, "https://") {
+			v.addf("security_events.export_url", "must be an http or https URL, got %q", se.ExportURL)
+		}
+	}
+
+	// Webhooks
+	if cfg.Webhooks.Enabled {
+		v.positiveInt("webhooks.max_per_user", cfg.Webhooks.MaxPerUser)
+		v.positive("webhooks.timeout", cfg.Webhooks.Timeout)
+		v.positive("webhooks.poll_interval", cfg.Webhooks.PollInterval)
+		v.positiveInt("webhooks.batch_size", cfg.Webhooks.BatchSize)
+		v.positiveInt("webhooks.concurrency", cfg.Webhooks.Concurrency)
+		v.positiveInt("webhooks.max_attempts", cfg.Webhooks.MaxAttempts)
+		v.positive("webhooks.retry_base_delay", cfg.Webhooks.RetryBaseDelay)
+		v.positive("webhooks.retry_max_delay", cfg.Webhooks.RetryMaxDelay)
+		v.positive("webhooks.retention", cfg.Webhooks.Retention)
+	}
+
+	// Inbound webhooks
+	if inbound := cfg.Inbound; inbound.Enabled {
+		if inbound.MaxBodySize <= 0 {
+			v.addf("inbound_webhooks.max_body_size", "must be positive, got %d", inbound.MaxBodySize)
+		}
+		v.positive("inbound_webhooks.tolerance", inbound.Tolerance)
+		if inbound.GitHub.Enabled {
+			v.required("inbound_webhooks.github.secret", inbound.GitHub.Secret)
+		}
+		if inbound.Stripe.Enabled {
+			v.required("inbound_webhooks.stripe.secret", inbound.Stripe.Secret)
+		}
+		if inbound.HMAC.Enabled {
+			v.required("inbound_webhooks.hmac.name", inbound.HMAC.Name)
+			v.required("inbound_webhooks.hmac.secret", inbound.HMAC.Secret)
+			v.required("inbound_webhooks.hmac.signature_header", inbound.HMAC.SignatureHeader)
+			if name := inbound.HMAC.Name; (name == "github" && inbound.GitHub.Enabled) || (name == "stripe" && inbound.Stripe.Enabled) {
+				v.addf("inbound_webhooks.hmac.name", "%q is already used by the %s provider", name, name)
+			}
+		}
+	}
+
+	// Quotas
+	if quotas := cfg.Quotas; quotas.Enabled {
+		v.oneOf("quotas.backend", quotas.Backend, "memory", "redis")
+		if quotas.MaxTodos < 0 {
+			v.addf("quotas.max_todos", "must not be negative, got %d", quotas.MaxTodos)
+		}
+		if quotas.MaxAttachmentBytes < 0 {
+			v.addf("quotas.max_attachment_bytes", "must not be negative, got %d", quotas.MaxAttachmentBytes)
+		}
+		if quotas.MaxAPICallsPerDay < 0 {
+			v.addf("quotas.max_api_calls_per_day", "must not be negative, got %d", quotas.MaxAPICallsPerDay)
+		}
+		v.positive("quotas.override_cache_ttl", quotas.OverrideCacheTTL)
+	}
+
+	// I18n
+	v.required("i18n.default_language", cfg.I18n.DefaultLanguage)
+
+	// API versions
+	if !cfg.API.V1.Enabled && !cfg.API.V2.Enabled {
+		v.addf("api", "at least one version must be enabled")
+	}
+	versions := []struct {
+		name    string
+		version APIVersionConfig
+	}{{"v1", cfg.API.V1}, {"v2", cfg.API.V2}}
+	for _, entry := range versions {
+		name, version := entry.name, entry.version
+		var deprecated, sunset time.Time
+		var err error
+		if version.Deprecated != "" {
+			if deprecated, err = ParseDate(version.Deprecated); err != nil {
+				v.addf("api."+name+".deprecated", "%v", err)
+			}
+		}
+		if version.Sunset != "" {
+			if sunset, err = ParseDate(version.Sunset); err != nil {
+				v.addf("api."+name+".sunset", "%v", err)
+			}
+			if version.Deprecated == "" {
+				v.addf("api."+name+".sunset", "requires api.%s.deprecated", name)
+			}
+		}
+		if !deprecated.IsZero() && !sunset.IsZero() && sunset.Before(deprecated) {
+			v.addf("api."+name+".sunset", "must not be before api.%s.deprecated", name)
+		}
+	}
+
+	// Route policies
+	methods := []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	for i, policy := range cfg.RoutePolicies {
+		path := fmt.Sprintf("route_policies[%d]", i)
+		if !strings.HasPrefix(policy.Path, "/") {
+			v.addf(path+".path", "must start with /, got %q", policy.Path)
+		}
+		for _, method := range policy.Methods {
+			v.oneOf(path+".methods", method, methods...)
+		}
+		if policy.Auth == nil && policy.Compression == nil && policy.Cost == 0 && policy.Signed == nil &&
+			policy.RateLimit == nil && policy.Cache == nil {
+			v.addf(path, "must set at least one of auth, compression, cost, signed, rate_limit or cache")
+		}
+		if policy.Signed != nil && *policy.Signed && !cfg.Security.SignedRequests.Enabled {
+			v.addf(path+".signed", "requires security.signed_requests.enabled")
+		}
+		if policy.Cost < 0 {
+			v.addf(path+".cost", "must not be negative, got %d", policy.Cost)
+		}
+		if policy.Compression != nil && *policy.Compression && !cfg.Performance.EnableCompression {
+			v.addf(path+".compression", "requires performance.enable_compression")
+		}
+		if limit := policy.RateLimit; limit != nil && limit.Enabled {
+			v.positiveInt(path+".rate_limit.requests_per_window", limit.RequestsPerWindow)
+			v.positive(path+".rate_limit.window", limit.Window)
+		}
+		if cache := policy.Cache; cache != nil && cache.Enabled {
+			if !cfg.Performance.EnableCaching {
+				v.addf(path+".cache", "requires performance.enable_caching")
+			}
+			if cache.MaxAge < 0 {
+				v.addf(path+".cache.max_age", "must not be negative, got %d", cache.MaxAge)
+			}
+		}
+	}
+
+	// Admin server
+	if cfg.AdminServer.Enabled {
+		v.required("admin_server.host", cfg.AdminServer.Host)
+		v.port("admin_server.port", cfg.AdminServer.Port)
+		if cfg.AdminServer.Port == cfg.Server.Port {
+			v.addf("admin_server.port", "must differ from server.port (%d)", cfg.Server.Port)
+		}
+	}
+
+	if len(v.errs) > 0 {
+		return &ValidationError{Errors: v.errs}
+	}
+	return nil
+}
+
+// ParseDate parses a YYYY-MM-DD date, midnight UTC, or an RFC 3339 timestamp
+func ParseDate(s string) (time.Time, error) {
+	for _, layout := range []string{time.DateOnly, time.RFC3339} {
+		if t, err := time.Parse(layout, strings.TrimSpace(s)); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD or RFC 3339", s)
+}
+
+// ParseByteSize parses a human readable size such as "256mb" or "1GiB" into
+// bytes. Units are case-insensitive and use powers of 1024
+func ParseByteSize(s string) (int64, error) {
+	value := strings.ToLower(strings.TrimSpace(s))
+	if value == "" {
+		return 0, fmt.Errorf("size is empty")
+	}
+
+	units := []struct {
+		suffix string
+		mult   int64
+	}{
+		{"gib", 1 << 30}, {"mib", 1 << 20}, {"kib", 1 << 10},
+		{"gb", 1 << 30}, {"mb", 1 << 20}, {"kb", 1 << 10},
+		{"g", 1 << 30}, {"m", 1 << 20}, {"k", 1 << 10},
+		{"b", 1},
+	}
+
+	mult := int64(1)
+	for _, u := range units {
+		if strings.HasSuffix(value, u.suffix) {
+			mult = u.mult
+			value = strings.TrimSpace(strings.TrimSuffix(value, u.suffix))
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return n * mult, nil
+}
+
+// validProxy reports whether a trusted proxy is an IP address or CIDR range
+func validProxy(proxy string) bool {
+	proxy = strings.TrimSpace(proxy)
+	if _, err := netip.ParsePrefix(proxy); err == nil {
+		return true
+	}
+	_, err := netip.ParseAddr(proxy)
+	return err == nil
+}
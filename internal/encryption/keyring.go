@@ -0,0 +1,127 @@
+// Package encryption encrypts sensitive values before they are stored, with
+// keys that can be rotated without rewriting the data at once
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Prefix marks encrypted values, which read "enc:<version>:<ciphertext>"
+// where the ciphertext is the base64 encoded nonce and AES-256-GCM output
+const Prefix = "enc:"
+
+// KeySize is the size of keys in bytes
+const KeySize = 32
+
+// ErrUnknownKey is returned for values encrypted with a key that is not in
+// the keyring
+var ErrUnknownKey = errors.New("unknown encryption key")
+
+// Keyring encrypts values with its active key and decrypts them with the
+// key whose version they carry. A nil keyring stores values as they are
+type Keyring struct {
+	keys   map[string]cipher.AEAD
+	active string
+}
+
+// NewKeyring creates a keyring from "version:key" pairs with base64 encoded
+// keys, encrypting with the key of the active version
+func NewKeyring(keys []string, active string) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string]cipher.AEAD, len(keys)), active: active}
+	for _, pair := range keys {
+		version, encoded, found := strings.Cut(strings.TrimSpace(pair), ":")
+		if !found || version == "" {
+			return nil, errors.New("encryption key must be a version:key pair")
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode encryption key %s: %w", version, err)
+		}
+		if len(key) != KeySize {
+			return nil, fmt.Errorf("encryption key %s must be %d bytes, got %d", version, KeySize, len(key))
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cipher of encryption key %s: %w", version, err)
+		}
+		if k.keys[version], err = cipher.NewGCM(block); err != nil {
+			return nil, fmt.Errorf("failed to create cipher of encryption key %s: %w", version, err)
+		}
+	}
+	if _, ok := k.keys[active]; !ok {
+		return nil, fmt.Errorf("active encryption key %q is not in the keyring", active)
+	}
+	return k, nil
+}
+
+// GenerateKey returns a new random key in the form NewKeyring expects
+func GenerateKey() (string, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate encryption key: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// Encrypt encrypts a value with the active key. Empty values stay empty
+func (k *Keyring) Encrypt(value string) (string, error) {
+	if k == nil || value == "" {
+		return value, nil
+	}
+
+	aead := k.keys[k.active]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	prefix := Prefix + k.active + ":"
+	sealed := aead.Seal(nonce, nonce, []byte(value), []byte(prefix))
+	return prefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts a value encrypted with any key of the keyring. Values
+// without the prefix were stored before encryption was enabled and are
+// returned as they are
+func (k *Keyring) Decrypt(value string) (string, error) {
+	if !strings.HasPrefix(value, Prefix) {
+		return value, nil
+	}
+	if k == nil {
+		return "", errors.New("value is encrypted but no encryption keys are configured")
+	}
+
+	version, encoded, found := strings.Cut(strings.TrimPrefix(value, Prefix), ":")
+	if !found {
+		return "", errors.New("malformed encrypted value")
+	}
+	aead, ok := k.keys[version]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownKey, version)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", errors.New("malformed encrypted value")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(Prefix+version+":"))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value with key %s: %w", version, err)
+	}
+	return string(plaintext), nil
+}
+
+// Stale reports whether a value is not encrypted with the active key, so
+// rotating keys re-encrypts it. Empty values are never stale
+func (k *Keyring) Stale(value string) bool {
+	if k == nil || value == "" {
+		return false
+	}
+	return !strings.HasPrefix(value, Prefix+k.active+":")
+}
@@ -0,0 +1,104 @@
+// Package events carries domain events from the services to the parts of the
+// application that react to them, within one process or across replicas
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TopicTodos carries the todo.* events
+const TopicTodos = "todos"
+
+// subscriberBuffer is the number of events a subscriber may fall behind
+// before further events are dropped for it
+const subscriberBuffer = 256
+
+// Event is a domain event. ID is assigned by the bus on publish and
+// increases monotonically per topic, Key identifies the event itself and is
+// kept when it is delivered more than once. Data holds the JSON encoded entity
+type Event struct {
+	ID         uint64          `json:"id"`
+	Key        string          `json:"key"`
+	Type       string          `json:"type"`
+	UserID     int64           `json:"user_id"`
+	EntityID   int64           `json:"entity_id"`
+	Data       json.RawMessage `json:"data"`
+	OccurredAt time.Time       `json:"occurred_at"`
+}
+
+// New creates an event of the given type about an entity owned by userID
+func New(eventType string, userID, entityID int64, data any) (Event, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+
+	return Event{
+		Key:        NewKey(),
+		Type:       eventType,
+		UserID:     userID,
+		EntityID:   entityID,
+		Data:       raw,
+		OccurredAt: time.Now().UTC(),
+	}, nil
+}
+
+// NewKey returns a random event key
+func NewKey() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand never fails on supported platforms
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// Publisher delivers events to the subscribers of a topic
+type Publisher interface {
+	Publish(ctx context.Context, topic string, event Event) error
+}
+
+// Subscriber receives the events published to a topic. The channel is
+// closed once ctx is done or the bus is closed; events are dropped for
+// subscribers that do not keep up
+type Subscriber interface {
+	Subscribe(ctx context.Context, topic string) (<-chan Event, error)
+}
+
+// Bus is both ends of an event transport
+type Bus interface {
+	Publisher
+	Subscriber
+	Close() error
+}
+
+// Fanout returns a Publisher delivering every event to all of publishers, or
+// nil when there are none
+func Fanout(publishers ...Publisher) Publisher {
+	switch len(publishers) {
+	case 0:
+		return nil
+	case 1:
+		return publishers[0]
+	}
+	return fanout(publishers)
+}
+
+type fanout []Publisher
+
+// Publish hands the event to every publisher, even when an earlier one fails
+func (f fanout) Publish(ctx context.Context, topic string, event Event) error {
+	var errs []error
+	for _, p := range f {
+		if err := p.Publish(ctx, topic, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
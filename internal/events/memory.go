@@ -0,0 +1,89 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryBus delivers events within the process for single instance
+// deployments
+type MemoryBus struct {
+	mu     sync.Mutex
+	seq    map[string]uint64
+	subs   map[string]map[chan Event]struct{}
+	closed bool
+}
+
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{
+		seq:  make(map[string]uint64),
+		subs: make(map[string]map[chan Event]struct{}),
+	}
+}
+
+// Publish assigns the event the next ID of the topic and hands it to every
+// subscriber without waiting for them
+func (b *MemoryBus) Publish(_ context.Context, topic string, event Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq[topic]++
+	event.ID = b.seq[topic]
+
+	for ch := range b.subs[topic] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+	return nil
+}
+
+// Subscribe returns a channel receiving the events published to topic from
+// now on
+func (b *MemoryBus) Subscribe(ctx context.Context, topic string) (<-chan Event, error) {
+	ch := make(chan Event, subscriberBuffer)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		close(ch)
+		return ch, nil
+	}
+	if b.subs[topic] == nil {
+		b.subs[topic] = make(map[chan Event]struct{})
+	}
+	b.subs[topic][ch] = struct{}{}
+
+	go func() {
+		<-ctx.Done()
+		b.unsubscribe(topic, ch)
+	}()
+	return ch, nil
+}
+
+// Close ends every subscription
+func (b *MemoryBus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for topic, subs := range b.subs {
+		for ch := range subs {
+			close(ch)
+		}
+		delete(b.subs, topic)
+	}
+	return nil
+}
+
+func (b *MemoryBus) unsubscribe(topic string, ch chan Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subs[topic][ch]; ok {
+		delete(b.subs[topic], ch)
+		close(ch)
+	}
+}
@@ -0,0 +1,91 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisBus delivers events through Redis pub/sub so subscribers on every
+// replica receive them. IDs come from a per-topic counter in Redis and are
+// therefore shared by all replicas
+type RedisBus struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisBus creates a bus storing its channels and counters under prefix
+func NewRedisBus(client *redis.Client, prefix string) *RedisBus {
+	return &RedisBus{client: client, prefix: prefix}
+}
+
+// Publish assigns the event an ID and sends it to the topic's channel
+func (b *RedisBus) Publish(ctx context.Context, topic string, event Event) error {
+	id, err := b.client.Incr(ctx, b.prefix+"events:seq:"+topic).Result()
+	if err != nil {
+		return fmt.Errorf("failed to assign %s event id: %w", event.Type, err)
+	}
+	event.ID = uint64(id)
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", event.Type, err)
+	}
+	if err := b.client.Publish(ctx, b.channel(topic), payload).Err(); err != nil {
+		return fmt.Errorf("failed to publish %s event: %w", event.Type, err)
+	}
+	return nil
+}
+
+// Subscribe listens on the topic's channel until ctx is done. Messages that
+// cannot be decoded are skipped
+func (b *RedisBus) Subscribe(ctx context.Context, topic string) (<-chan Event, error) {
+	pubsub := b.client.Subscribe(ctx, b.channel(topic))
+
+	// Wait for the subscription to be confirmed so no event published after
+	// Subscribe returns is missed
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", topic, err)
+	}
+
+	ch := make(chan Event, subscriberBuffer)
+	go func() {
+		defer close(ch)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+
+				var event Event
+				if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+					continue
+				}
+				select {
+				case ch <- event:
+				default:
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// Close is a no-op, subscriptions end with their contexts and the client is
+// owned by the caller
+func (b *RedisBus) Close() error {
+	return nil
+}
+
+func (b *RedisBus) channel(topic string) string {
+	return b.prefix + "events:" + topic
+}
@@ -0,0 +1,193 @@
+// Package export encodes todos as downloadable files. Writers encode one
+// todo at a time so exports of any size are streamed
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+)
+
+// Supported formats
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+	FormatXLSX = "xlsx"
+)
+
+// FormatArchive is the JSON archive of everything stored about a user, it is
+// built by the account export rather than a Writer
+const FormatArchive = "archive"
+
+// Formats lists the supported formats
+var Formats = []string{FormatCSV, FormatJSON, FormatXLSX}
+
+// Writer encodes todos to an underlying writer
+type Writer interface {
+	Write(todo *models.Todo) error
+
+	// Close writes whatever the format needs after the last todo, it does
+	// not close the underlying writer
+	Close() error
+}
+
+// NewWriter creates a writer for format
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	case FormatJSON:
+		return &jsonWriter{w: w}, nil
+	case FormatXLSX:
+		return newXLSXWriter(w)
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+// Valid reports whether format is supported
+func Valid(format string) bool {
+	return slices.Contains(Formats, format)
+}
+
+// ContentType returns the media type of format
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "application/json"
+	}
+}
+
+// Filename names an export of format created at t
+func Filename(format string, t time.Time) string {
+	stamp := t.UTC().Format("20060102-150405")
+	if format == FormatArchive {
+		return "account-" + stamp + ".json"
+	}
+	return "todos-" + stamp + "." + format
+}
+
+// columns are the fields of the tabular formats
+var columns = []string{
+	"id", "parent_id", "title", "description", "completed", "priority",
+	"due_date", "tags", "created_at", "updated_at",
+}
+
+// row returns the cells of todo in column order, as strings, int64s and bools
+func row(todo *models.Todo) []any {
+	var parentID, dueDate any = "", ""
+	if todo.ParentID != nil {
+		parentID = *todo.ParentID
+	}
+	if todo.DueDate != nil {
+		dueDate = formatTime(*todo.DueDate)
+	}
+
+	return []any{
+		todo.ID, parentID, todo.Title, todo.Description, todo.Completed, todo.Priority,
+		dueDate, strings.Join(todo.Tags, ", "), formatTime(todo.CreatedAt), formatTime(todo.UpdatedAt),
+	}
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+type csvWriter struct {
+	w      *csv.Writer
+	header bool
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) Write(todo *models.Todo) error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+
+	cells := row(todo)
+	record := make([]string, len(cells))
+	for i, cell := range cells {
+		switch v := cell.(type) {
+		case string:
+			record[i] = escapeFormula(v)
+		case int64:
+			record[i] = strconv.FormatInt(v, 10)
+		case bool:
+			record[i] = strconv.FormatBool(v)
+		}
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvWriter) writeHeader() error {
+	if c.header {
+		return nil
+	}
+	c.header = true
+	return c.w.Write(columns)
+}
+
+func (c *csvWriter) Close() error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// escapeFormula keeps spreadsheets from evaluating user text as a formula
+// when the CSV is opened
+func escapeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// jsonWriter writes a JSON array of todos
+type jsonWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (j *jsonWriter) Write(todo *models.Todo) error {
+	data, err := json.Marshal(todo)
+	if err != nil {
+		return fmt.Errorf("failed to encode todo: %w", err)
+	}
+
+	sep := ",\n"
+	if j.count == 0 {
+		sep = "[\n"
+	}
+	j.count++
+
+	if _, err := io.WriteString(j.w, sep); err != nil {
+		return err
+	}
+	_, err = j.w.Write(data)
+	return err
+}
+
+func (j *jsonWriter) Close() error {
+	end := "\n]\n"
+	if j.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(j.w, end)
+	return err
+}
@@ -0,0 +1,71 @@
+// Package graph exposes the todo domain as a GraphQL schema. Resolvers call
+// the same services as the REST handlers, so validation and authorization
+// behave identically on both transports
+package graph
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"github.com/graph-gophers/graphql-go"
+)
+
+//go:embed schema.graphql
+var schema string
+
+type contextKey struct{}
+
+// errMissingUser means the handler did not attach the authenticated user
+var errMissingUser = errors.New("missing authenticated user")
+
+// WithUserID returns a context carrying the authenticated user, resolvers
+// only ever see the data of this user
+func WithUserID(ctx context.Context, userID int64) context.Context {
+	return context.WithValue(ctx, contextKey{}, userID)
+}
+
+func currentUser(ctx context.Context) (int64, error) {
+	id, ok := ctx.Value(contextKey{}).(int64)
+	if !ok {
+		return 0, errMissingUser
+	}
+	return id, nil
+}
+
+// NewSchema parses the schema and binds it to the services. The todoEvents
+// subscription is unavailable when feed is nil
+func NewSchema(todos *service.TodoService, tags *service.TagService, users *service.AuthService, feed *service.TodoFeed, maxDepth int) (*graphql.Schema, error) {
+	resolver := &Resolver{todos: todos, tags: tags, users: users, feed: feed}
+	parsed, err := graphql.ParseSchema(schema, resolver, graphql.MaxDepth(maxDepth))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse graphql schema: %w", err)
+	}
+	return parsed, nil
+}
+
+func formatID(id int64) graphql.ID {
+	return graphql.ID(strconv.FormatInt(id, 10))
+}
+
+func parseID(id graphql.ID) (int64, error) {
+	n, err := strconv.ParseInt(string(id), 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%w: invalid id %q", service.ErrInvalidInput, id)
+	}
+	return n, nil
+}
+
+func optionalID(id *graphql.ID) (*int64, error) {
+	if id == nil {
+		return nil, nil
+	}
+	n, err := parseID(*id)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
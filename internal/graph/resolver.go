@@ -0,0 +1,493 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"github.com/graph-gophers/graphql-go"
+)
+
+// errSubscriptionsDisabled is returned for subscriptions while the event
+// stream is turned off
+var errSubscriptionsDisabled = errors.New("subscriptions are disabled")
+
+// Resolver is the root resolver of the Query, Mutation and Subscription types
+type Resolver struct {
+	todos *service.TodoService
+	tags  *service.TagService
+	users *service.AuthService
+	feed  *service.TodoFeed
+}
+
+type todoFilterArgs struct {
+	Status    *string
+	Priority  *string
+	Tags      *[]string
+	DueBefore *graphql.Time
+	DueAfter  *graphql.Time
+	Cursor    *string
+	Limit     *int32
+}
+
+type todoInput struct {
+	ParentID    *graphql.ID
+	Title       string
+	Description *string
+	Completed   *bool
+	DueDate     *graphql.Time
+	Recurrence  *string
+	Priority    *string
+	Tags        *[]string
+}
+
+type todoPatch struct {
+	ParentID    *graphql.ID
+	Title       *string
+	Description *string
+	Completed   *bool
+	DueDate     *graphql.Time
+	Recurrence  *string
+	Priority    *string
+	Tags        *[]string
+}
+
+// Me resolves the authenticated user
+func (r *Resolver) Me(ctx context.Context) (*userResolver, error) {
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	user, err := r.users.User(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &userResolver{user: user}, nil
+}
+
+// Todo resolves a single todo of the user
+func (r *Resolver) Todo(ctx context.Context, args struct{ ID graphql.ID }) (*todoResolver, error) {
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	todo, err := r.todos.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return r.todo(todo), nil
+}
+
+// Todos resolves a page of the user's todos
+func (r *Resolver) Todos(ctx context.Context, args todoFilterArgs) (*todoConnectionResolver, error) {
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := service.TodoQuery{
+		Status:   deref(args.Status),
+		Priority: deref(args.Priority),
+	}
+	if args.Tags != nil {
+		query.Tags = *args.Tags
+	}
+	if args.DueBefore != nil {
+		query.DueBefore = &args.DueBefore.Time
+	}
+	if args.DueAfter != nil {
+		query.DueAfter = &args.DueAfter.Time
+	}
+
+	page, err := r.todos.List(ctx, userID, query, deref(args.Cursor), int(deref(args.Limit)))
+	if err != nil {
+		return nil, err
+	}
+	return &todoConnectionResolver{r: r, page: page}, nil
+}
+
+// Search resolves a page of full-text search results
+func (r *Resolver) Search(ctx context.Context, args struct {
+	Q        string
+	Page     *int32
+	PageSize *int32
+}) (*searchConnectionResolver, error) {
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	page, err := r.todos.Search(ctx, userID, args.Q, int(deref(args.Page)), int(deref(args.PageSize)))
+	if err != nil {
+		return nil, err
+	}
+	return &searchConnectionResolver{r: r, page: page}, nil
+}
+
+// Tags resolves all of the user's tags
+func (r *Resolver) Tags(ctx context.Context) ([]*tagResolver, error) {
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := r.tags.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*tagResolver, len(tags))
+	for i, tag := range tags {
+		resolvers[i] = &tagResolver{tag: tag}
+	}
+	return resolvers, nil
+}
+
+// CreateTodo creates a todo
+func (r *Resolver) CreateTodo(ctx context.Context, args struct{ Input todoInput }) (*todoResolver, error) {
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	parentID, err := optionalID(args.Input.ParentID)
+	if err != nil {
+		return nil, err
+	}
+
+	input := service.TodoInput{
+		ParentID:    parentID,
+		Title:       args.Input.Title,
+		Description: deref(args.Input.Description),
+		Completed:   deref(args.Input.Completed),
+		Recurrence:  deref(args.Input.Recurrence),
+		Priority:    deref(args.Input.Priority),
+	}
+	if args.Input.DueDate != nil {
+		input.DueDate = &args.Input.DueDate.Time
+	}
+	if args.Input.Tags != nil {
+		input.Tags = *args.Input.Tags
+	}
+
+	todo, err := r.todos.Create(ctx, userID, input)
+	if err != nil {
+		return nil, err
+	}
+	return r.todo(todo), nil
+}
+
+// UpdateTodo applies a partial update to a todo
+func (r *Resolver) UpdateTodo(ctx context.Context, args struct {
+	ID    graphql.ID
+	Input todoPatch
+}) (*todoResolver, error) {
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	parentID, err := optionalID(args.Input.ParentID)
+	if err != nil {
+		return nil, err
+	}
+
+	patch := service.TodoPatch{
+		ParentID:    parentID,
+		Title:       args.Input.Title,
+		Description: args.Input.Description,
+		Completed:   args.Input.Completed,
+		Recurrence:  args.Input.Recurrence,
+		Priority:    args.Input.Priority,
+	}
+	if args.Input.DueDate != nil {
+		patch.DueDate = &args.Input.DueDate.Time
+	}
+	if args.Input.Tags != nil {
+		// An empty list removes all tags, so it must stay non-nil
+		patch.Tags = append([]string{}, *args.Input.Tags...)
+	}
+
+	todo, err := r.todos.Patch(ctx, userID, id, patch)
+	if err != nil {
+		return nil, err
+	}
+	return r.todo(todo), nil
+}
+
+// DeleteTodo moves a todo to the trash
+func (r *Resolver) DeleteTodo(ctx context.Context, args struct{ ID graphql.ID }) (bool, error) {
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return false, err
+	}
+	id, err := parseID(args.ID)
+	if err != nil {
+		return false, err
+	}
+	if err := r.todos.Delete(ctx, userID, id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RestoreTodo brings a todo back from the trash
+func (r *Resolver) RestoreTodo(ctx context.Context, args struct{ ID graphql.ID }) (*todoResolver, error) {
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	todo, err := r.todos.Restore(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return r.todo(todo), nil
+}
+
+// CreateTag creates a tag
+func (r *Resolver) CreateTag(ctx context.Context, args struct{ Name string }) (*tagResolver, error) {
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tag, err := r.tags.Create(ctx, userID, args.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &tagResolver{tag: tag}, nil
+}
+
+// RenameTag renames a tag
+func (r *Resolver) RenameTag(ctx context.Context, args struct {
+	ID   graphql.ID
+	Name string
+}) (*tagResolver, error) {
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	tag, err := r.tags.Rename(ctx, userID, id, args.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &tagResolver{tag: tag}, nil
+}
+
+// DeleteTag deletes a tag and detaches it from all todos
+func (r *Resolver) DeleteTag(ctx context.Context, args struct{ ID graphql.ID }) (bool, error) {
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return false, err
+	}
+	id, err := parseID(args.ID)
+	if err != nil {
+		return false, err
+	}
+	if err := r.tags.Delete(ctx, userID, id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// TodoEvents streams changes to the user's todos from the event bus until
+// the subscription's context is done
+func (r *Resolver) TodoEvents(ctx context.Context) (<-chan *todoEventResolver, error) {
+	if r.feed == nil {
+		return nil, errSubscriptionsDisabled
+	}
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sub, _, _ := r.feed.Subscribe(userID, 0)
+	out := make(chan *todoEventResolver)
+	go func() {
+		defer close(out)
+		defer sub.Close()
+		for {
+			select {
+			case event, ok := <-sub.Events():
+				if !ok {
+					// Dropped for falling behind, the client has to resubscribe
+					return
+				}
+				select {
+				case out <- &todoEventResolver{r: r, event: event}:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (r *Resolver) todo(todo *models.Todo) *todoResolver {
+	return &todoResolver{r: r, todo: todo}
+}
+
+type userResolver struct {
+	user *models.User
+}
+
+func (u *userResolver) ID() graphql.ID          { return formatID(u.user.ID) }
+func (u *userResolver) Email() string           { return u.user.Email }
+func (u *userResolver) Name() string            { return u.user.Name }
+func (u *userResolver) EmailVerified() bool     { return u.user.IsVerified() }
+func (u *userResolver) CreatedAt() graphql.Time { return graphql.Time{Time: u.user.CreatedAt} }
+
+type todoResolver struct {
+	r    *Resolver
+	todo *models.Todo
+}
+
+func (t *todoResolver) ID() graphql.ID          { return formatID(t.todo.ID) }
+func (t *todoResolver) Title() string           { return t.todo.Title }
+func (t *todoResolver) Description() string     { return t.todo.Description }
+func (t *todoResolver) Completed() bool         { return t.todo.Completed }
+func (t *todoResolver) Priority() string        { return t.todo.Priority }
+func (t *todoResolver) CreatedAt() graphql.Time { return graphql.Time{Time: t.todo.CreatedAt} }
+func (t *todoResolver) UpdatedAt() graphql.Time { return graphql.Time{Time: t.todo.UpdatedAt} }
+
+func (t *todoResolver) ParentID() *graphql.ID {
+	if t.todo.ParentID == nil {
+		return nil
+	}
+	id := formatID(*t.todo.ParentID)
+	return &id
+}
+
+func (t *todoResolver) DueDate() *graphql.Time {
+	if t.todo.DueDate == nil {
+		return nil
+	}
+	return &graphql.Time{Time: *t.todo.DueDate}
+}
+
+func (t *todoResolver) Recurrence() *string {
+	if t.todo.Recurrence == "" {
+		return nil
+	}
+	return &t.todo.Recurrence
+}
+
+func (t *todoResolver) Tags() []string {
+	if t.todo.Tags == nil {
+		return []string{}
+	}
+	return t.todo.Tags
+}
+
+func (t *todoResolver) Subtasks(ctx context.Context) ([]*todoResolver, error) {
+	subtasks, err := t.r.todos.Subtasks(ctx, t.todo.UserID, t.todo.ID)
+	if err != nil {
+		return nil, err
+	}
+	return t.r.todoList(subtasks), nil
+}
+
+func (r *Resolver) todoList(todos []*models.Todo) []*todoResolver {
+	resolvers := make([]*todoResolver, len(todos))
+	for i, todo := range todos {
+		resolvers[i] = r.todo(todo)
+	}
+	return resolvers
+}
+
+type todoConnectionResolver struct {
+	r    *Resolver
+	page *service.TodoPage
+}
+
+func (c *todoConnectionResolver) Nodes() []*todoResolver { return c.r.todoList(c.page.Todos) }
+func (c *todoConnectionResolver) Limit() int32           { return int32(c.page.Limit) }
+func (c *todoConnectionResolver) NextCursor() *string    { return optional(c.page.NextCursor) }
+func (c *todoConnectionResolver) PrevCursor() *string    { return optional(c.page.PrevCursor) }
+
+type searchConnectionResolver struct {
+	r    *Resolver
+	page *service.SearchPage
+}
+
+func (c *searchConnectionResolver) Page() int32       { return int32(c.page.Page) }
+func (c *searchConnectionResolver) PageSize() int32   { return int32(c.page.PageSize) }
+func (c *searchConnectionResolver) Total() int32      { return int32(c.page.Total) }
+func (c *searchConnectionResolver) TotalPages() int32 { return int32(c.page.TotalPages()) }
+
+func (c *searchConnectionResolver) Results() []*searchResultResolver {
+	resolvers := make([]*searchResultResolver, len(c.page.Results))
+	for i, result := range c.page.Results {
+		resolvers[i] = &searchResultResolver{r: c.r, result: result}
+	}
+	return resolvers
+}
+
+type searchResultResolver struct {
+	r      *Resolver
+	result *models.TodoSearchResult
+}
+
+func (s *searchResultResolver) Todo() *todoResolver { return s.r.todo(s.result.Todo) }
+func (s *searchResultResolver) Rank() float64       { return s.result.Rank }
+func (s *searchResultResolver) Snippet() string     { return s.result.Snippet }
+
+type tagResolver struct {
+	tag *models.Tag
+}
+
+func (t *tagResolver) ID() graphql.ID          { return formatID(t.tag.ID) }
+func (t *tagResolver) Name() string            { return t.tag.Name }
+func (t *tagResolver) CreatedAt() graphql.Time { return graphql.Time{Time: t.tag.CreatedAt} }
+
+type todoEventResolver struct {
+	r     *Resolver
+	event service.TodoEvent
+}
+
+func (e *todoEventResolver) ID() graphql.ID           { return graphql.ID(strconv.FormatUint(e.event.ID, 10)) }
+func (e *todoEventResolver) Type() string             { return e.event.Type }
+func (e *todoEventResolver) TodoID() graphql.ID       { return formatID(e.event.TodoID) }
+func (e *todoEventResolver) OccurredAt() graphql.Time { return graphql.Time{Time: e.event.OccurredAt} }
+
+// Todo decodes the snapshot carried by the event, it is null if the payload
+// is not a todo
+func (e *todoEventResolver) Todo() *todoResolver {
+	var todo models.Todo
+	if err := json.Unmarshal(e.event.Todo, &todo); err != nil || todo.ID == 0 {
+		return nil
+	}
+	todo.UserID = e.event.UserID
+	return e.r.todo(&todo)
+}
+
+func deref[T any](v *T) T {
+	var zero T
+	if v == nil {
+		return zero
+	}
+	return *v
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
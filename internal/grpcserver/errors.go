@@ -0,0 +1,52 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/breaker"
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusError converts the errors returned by the services to gRPC status
+// errors, the counterpart of the REST API's error responses. Invalid fields
+// are reported in a BadRequest detail, unexpected errors are logged and
+// hidden from clients
+func statusError(ctx context.Context, log logger.Logger, err error) error {
+	var (
+		invalid  *service.FieldError
+		exceeded *service.QuotaExceededError
+	)
+	switch {
+	case errors.As(err, &invalid):
+		st := status.New(codes.InvalidArgument, invalid.Error())
+		if detailed, detailErr := st.WithDetails(&errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: invalid.Field, Description: invalid.Message}},
+		}); detailErr == nil {
+			st = detailed
+		}
+		return st.Err()
+	case errors.Is(err, service.ErrInvalidInput):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.As(err, &exceeded):
+		return status.Error(codes.ResourceExhausted, exceeded.Error())
+	case errors.Is(err, service.ErrReadOnlyShare):
+		return status.Error(codes.PermissionDenied, service.ErrReadOnlyShare.Error())
+	case errors.Is(err, storage.ErrNotFound):
+		return status.Error(codes.NotFound, "resource not found")
+	case errors.Is(err, storage.ErrConflict):
+		return status.Error(codes.AlreadyExists, "resource already exists")
+	case errors.Is(err, breaker.ErrOpen):
+		return status.Error(codes.Unavailable, "a dependency is unavailable, retry later")
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	}
+
+	logger.FromContext(ctx, log).Error("grpc request failed", "error", err)
+	return status.Error(codes.Internal, "internal server error")
+}
@@ -0,0 +1,181 @@
+// Package grpcserver serves the todo API over gRPC. Like the Gin handlers it
+// only translates between its transport and internal/service, so requests
+// are validated and authorized the same way on both
+package grpcserver
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/auth"
+	"github.com/MuthuM3/gin-microservice-template/internal/clientinfo"
+	todov1 "github.com/MuthuM3/gin-microservice-template/internal/gen/todo/v1"
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+	"github.com/MuthuM3/gin-microservice-template/internal/ratelimit"
+	"github.com/MuthuM3/gin-microservice-template/internal/requestid"
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// requestIDKey is the metadata key of the request correlation id, the gRPC
+// counterpart of the X-Request-ID header
+const requestIDKey = "x-request-id"
+
+type userIDKey struct{}
+
+// Options configures the server. Limiter is nil when rate limiting is
+// disabled, requests are then let through unthrottled
+type Options struct {
+	Todos       *service.TodoService
+	Tokens      *auth.TokenManager
+	Revocations auth.RevocationList
+	Limiter     ratelimit.Limiter
+	// UserBased keys rate limits by user rather than by client address,
+	// like rate_limit.user_based does for the REST API
+	UserBased bool
+	Logger    logger.Logger
+}
+
+// New creates the gRPC server of the todo API and its health service.
+// Requests authenticate with the access tokens of the REST API, sent as
+// "authorization: Bearer <token>" metadata
+func New(opts Options, serverOptions ...grpc.ServerOption) *grpc.Server {
+	interceptors := []grpc.UnaryServerInterceptor{
+		recovery(opts.Logger),
+		requestContext,
+		authenticate(opts.Tokens, opts.Revocations, opts.Logger),
+	}
+	if opts.Limiter != nil {
+		interceptors = append(interceptors, rateLimit(opts.Limiter, opts.UserBased, opts.Logger))
+	}
+
+	server := grpc.NewServer(append(serverOptions, grpc.ChainUnaryInterceptor(interceptors...))...)
+	todov1.RegisterTodoServiceServer(server, &todoServer{todos: opts.Todos, log: opts.Logger})
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	return server
+}
+
+// public reports whether a method is served without authentication
+func public(method string) bool {
+	return strings.HasPrefix(method, "/"+healthpb.Health_ServiceDesc.ServiceName+"/")
+}
+
+// recovery turns panics into Internal errors so one bad request cannot
+// take the server down
+func recovery(log logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				logger.FromContext(ctx, log).Error("panic recovered", "panic", fmt.Sprint(recovered), "method", info.FullMethod)
+				err = status.Error(codes.Internal, "internal server error")
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// requestContext attaches the request id and the client info the services
+// record, the request id is echoed in the response header
+func requestContext(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	id := first(md, requestIDKey)
+	if !requestid.Valid(id) {
+		id = requestid.New()
+	}
+	ctx = requestid.NewContext(ctx, id)
+	grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, id))
+
+	client := clientinfo.Info{UserAgent: first(md, "user-agent")}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		client.IP = p.Addr.String()
+		if host, _, err := net.SplitHostPort(client.IP); err == nil {
+			client.IP = host
+		}
+	}
+	return handler(clientinfo.NewContext(ctx, client), req)
+}
+
+// authenticate requires a valid access token whose session has not been
+// revoked, as the REST API does
+func authenticate(tokens *auth.TokenManager, revocations auth.RevocationList, log logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if public(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		scheme, token, found := strings.Cut(first(md, "authorization"), " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+			return nil, status.Error(codes.Unauthenticated, "missing or malformed authorization metadata")
+		}
+
+		claims, err := tokens.Parse(token)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid or expired token")
+		}
+		userID, err := claims.UserID()
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid token subject")
+		}
+
+		revoked, err := revocations.IsRevoked(ctx, claims.SessionID)
+		if err != nil {
+			logger.FromContext(ctx, log).Error("failed to check session revocation", "session_id", claims.SessionID, "error", err)
+			return nil, status.Error(codes.Internal, "internal server error")
+		}
+		if revoked {
+			return nil, status.Error(codes.Unauthenticated, "session has been revoked")
+		}
+
+		return handler(context.WithValue(ctx, userIDKey{}, userID), req)
+	}
+}
+
+// rateLimit rejects requests over the limit with ResourceExhausted. It
+// shares the limiter of the REST API, so a client has one budget on both.
+// Limiter failures are logged and the request is let through
+func rateLimit(limiter ratelimit.Limiter, userBased bool, log logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		key := "ip:" + clientinfo.FromContext(ctx).IP
+		if userID, ok := currentUserID(ctx); ok && userBased {
+			key = "user:" + strconv.FormatInt(userID, 10)
+		}
+
+		result, err := limiter.Allow(ctx, key)
+		if err != nil {
+			logger.FromContext(ctx, log).Error("rate limiter unavailable", "error", err)
+			return handler(ctx, req)
+		}
+		if !result.Allowed {
+			retryAfter := max(time.Duration(math.Ceil(result.RetryAfter.Seconds()))*time.Second, time.Second)
+			grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(int(retryAfter.Seconds()))))
+			return nil, status.Errorf(codes.ResourceExhausted, "rate limit exceeded, retry in %s", retryAfter)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// currentUserID returns the user authenticated by authenticate
+func currentUserID(ctx context.Context) (int64, bool) {
+	id, ok := ctx.Value(userIDKey{}).(int64)
+	return id, ok
+}
+
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -0,0 +1,186 @@
+package grpcserver
+
+import (
+	"context"
+	"time"
+
+	todov1 "github.com/MuthuM3/gin-microservice-template/internal/gen/todo/v1"
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// todoServer implements todo.v1.TodoService on service.TodoService
+type todoServer struct {
+	todov1.UnimplementedTodoServiceServer
+	todos *service.TodoService
+	log   logger.Logger
+}
+
+func (s *todoServer) CreateTodo(ctx context.Context, req *todov1.CreateTodoRequest) (*todov1.Todo, error) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+	dueDate, err := optionalTime("due_date", req.GetDueDate())
+	if err != nil {
+		return nil, statusError(ctx, s.log, err)
+	}
+
+	todo, err := s.todos.Create(ctx, userID, service.TodoInput{
+		ParentID:    req.ParentId,
+		Title:       req.GetTitle(),
+		Description: req.GetDescription(),
+		Completed:   req.GetCompleted(),
+		DueDate:     dueDate,
+		Recurrence:  req.GetRecurrence(),
+		Priority:    req.GetPriority(),
+		Tags:        req.GetTags(),
+	})
+	if err != nil {
+		return nil, statusError(ctx, s.log, err)
+	}
+	return todoMessage(todo), nil
+}
+
+func (s *todoServer) ListTodos(ctx context.Context, req *todov1.ListTodosRequest) (*todov1.ListTodosResponse, error) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+	dueBefore, err := optionalTime("due_before", req.GetDueBefore())
+	if err != nil {
+		return nil, statusError(ctx, s.log, err)
+	}
+	dueAfter, err := optionalTime("due_after", req.GetDueAfter())
+	if err != nil {
+		return nil, statusError(ctx, s.log, err)
+	}
+
+	page, err := s.todos.List(ctx, userID, service.TodoQuery{
+		Status:    req.GetStatus(),
+		Priority:  req.GetPriority(),
+		DueBefore: dueBefore,
+		DueAfter:  dueAfter,
+		Tags:      req.GetTags(),
+	}, req.GetCursor(), int(req.GetLimit()))
+	if err != nil {
+		return nil, statusError(ctx, s.log, err)
+	}
+
+	resp := &todov1.ListTodosResponse{
+		Data:       make([]*todov1.Todo, len(page.Todos)),
+		Limit:      int32(page.Limit),
+		NextCursor: page.NextCursor,
+		PrevCursor: page.PrevCursor,
+	}
+	for i, todo := range page.Todos {
+		resp.Data[i] = todoMessage(todo)
+	}
+	return resp, nil
+}
+
+func (s *todoServer) GetTodo(ctx context.Context, req *todov1.GetTodoRequest) (*todov1.Todo, error) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+
+	todo, err := s.todos.Get(ctx, userID, req.GetId())
+	if err != nil {
+		return nil, statusError(ctx, s.log, err)
+	}
+	return todoMessage(todo), nil
+}
+
+func (s *todoServer) UpdateTodo(ctx context.Context, req *todov1.UpdateTodoRequest) (*todov1.Todo, error) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+	dueDate, err := optionalTime("due_date", req.GetDueDate())
+	if err != nil {
+		return nil, statusError(ctx, s.log, err)
+	}
+
+	todo, err := s.todos.Update(ctx, userID, req.GetId(), service.TodoInput{
+		ParentID:    req.ParentId,
+		Title:       req.GetTitle(),
+		Description: req.GetDescription(),
+		Completed:   req.GetCompleted(),
+		DueDate:     dueDate,
+		Recurrence:  req.GetRecurrence(),
+		Priority:    req.GetPriority(),
+		Tags:        req.GetTags(),
+	})
+	if err != nil {
+		return nil, statusError(ctx, s.log, err)
+	}
+	return todoMessage(todo), nil
+}
+
+func (s *todoServer) DeleteTodo(ctx context.Context, req *todov1.DeleteTodoRequest) (*emptypb.Empty, error) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+
+	if err := s.todos.Delete(ctx, userID, req.GetId()); err != nil {
+		return nil, statusError(ctx, s.log, err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *todoServer) RestoreTodo(ctx context.Context, req *todov1.RestoreTodoRequest) (*todov1.Todo, error) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+
+	todo, err := s.todos.Restore(ctx, userID, req.GetId())
+	if err != nil {
+		return nil, statusError(ctx, s.log, err)
+	}
+	return todoMessage(todo), nil
+}
+
+// optionalTime converts a timestamp field, unset fields are nil
+func optionalTime(field string, ts *timestamppb.Timestamp) (*time.Time, error) {
+	if ts == nil {
+		return nil, nil
+	}
+	if err := ts.CheckValid(); err != nil {
+		return nil, &service.FieldError{Field: field, Message: field + " must be a valid timestamp"}
+	}
+	t := ts.AsTime()
+	return &t, nil
+}
+
+func optionalTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func todoMessage(todo *models.Todo) *todov1.Todo {
+	return &todov1.Todo{
+		Id:          todo.ID,
+		UserId:      todo.UserID,
+		ParentId:    todo.ParentID,
+		Title:       todo.Title,
+		Description: todo.Description,
+		Completed:   todo.Completed,
+		DueDate:     optionalTimestamp(todo.DueDate),
+		Priority:    todo.Priority,
+		Tags:        todo.Tags,
+		CreatedAt:   timestamppb.New(todo.CreatedAt),
+		UpdatedAt:   timestamppb.New(todo.UpdatedAt),
+		DeletedAt:   optionalTimestamp(todo.DeletedAt),
+		Recurrence:  todo.Recurrence,
+	}
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// AccountHandler serves the deletion and the data export of the signed in
+// user's account
+type AccountHandler struct {
+	accounts *service.AccountService
+	archives *service.ArchiveService
+}
+
+func NewAccountHandler(accounts *service.AccountService, archives *service.ArchiveService) *AccountHandler {
+	return &AccountHandler{accounts: accounts, archives: archives}
+}
+
+// deletionResponse tells when a deleted account will be erased
+type deletionResponse struct {
+	Message             string    `json:"message"`
+	DeletionScheduledAt time.Time `json:"deletion_scheduled_at"`
+}
+
+// RegisterRoutes mounts the account endpoints on the /me group
+func (h *AccountHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.DELETE("", h.Delete)
+	rg.DELETE("/deletion", h.CancelDeletion)
+	rg.GET("/export", h.Export)
+}
+
+// Delete handles DELETE /me
+func (h *AccountHandler) Delete(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	at, err := h.accounts.ScheduleDeletion(c.Request.Context(), userID)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusAccepted, deletionResponse{
+		Message:             "the account and all of its data will be erased, cancel the deletion before then to keep it",
+		DeletionScheduledAt: at,
+	})
+}
+
+// CancelDeletion handles DELETE /me/deletion
+func (h *AccountHandler) CancelDeletion(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.accounts.CancelDeletion(c.Request.Context(), userID); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Export handles GET /me/export
+func (h *AccountHandler) Export(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.archives.Schedule(c.Request.Context(), userID); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusAccepted, gin.H{
+		"message": "your data is being collected, a download link will be emailed to you",
+	})
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ActivityHandler lets users review the security events of their account,
+// such as logins from unfamiliar devices
+type ActivityHandler struct {
+	events *service.SecurityLog
+}
+
+func NewActivityHandler(events *service.SecurityLog) *ActivityHandler {
+	return &ActivityHandler{events: events}
+}
+
+// RegisterRoutes mounts the activity endpoint on the auth group behind
+// authenticated
+func (h *ActivityHandler) RegisterRoutes(rg *gin.RouterGroup, authenticated gin.HandlerFunc) {
+	rg.GET("/activity", authenticated, h.List)
+}
+
+// List handles GET /auth/activity?page=&page_size=
+func (h *ActivityHandler) List(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	page, ok := queryInt(c, "page", 1)
+	if !ok {
+		return
+	}
+	pageSize, ok := queryInt(c, "page_size", 0)
+	if !ok {
+		return
+	}
+
+	result, err := h.events.Activity(c.Request.Context(), userID, page, pageSize)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respondPage(c, result.Events, Pagination{
+		Page:       result.Page,
+		PageSize:   result.PageSize,
+		Total:      result.Total,
+		TotalPages: result.TotalPages(),
+	})
+}
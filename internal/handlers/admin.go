@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/apierror"
+	"github.com/MuthuM3/gin-microservice-template/internal/middleware"
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler serves administrative endpoints
+type AdminHandler struct {
+	auth  *service.AuthService
+	audit *service.AuditLogger
+}
+
+// NewAdminHandler creates the admin handler, the audit endpoint is only
+// mounted when audit is non-nil
+func NewAdminHandler(auth *service.AuthService, audit *service.AuditLogger) *AdminHandler {
+	return &AdminHandler{auth: auth, audit: audit}
+}
+
+// RegisterRoutes mounts the admin endpoints on rg
+func (h *AdminHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.DELETE("/lockouts", h.ClearLockout)
+	if h.audit != nil {
+		rg.GET("/audit", h.ListAudit)
+	}
+}
+
+// ClearLockout handles DELETE /admin/lockouts?email=&ip=
+func (h *AdminHandler) ClearLockout(c *gin.Context) {
+	email, ip := c.Query("email"), c.Query("ip")
+	if email == "" && ip == "" {
+		middleware.AbortWithError(c, apierror.Validation("email or ip is required"))
+		return
+	}
+	if ip != "" && net.ParseIP(ip) == nil {
+		middleware.AbortWithError(c, apierror.BadRequest("invalid_query", "invalid ip"))
+		return
+	}
+
+	if err := h.auth.Unlock(c.Request.Context(), email, ip); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListAudit handles GET /admin/audit?user_id=&action=&entity_type=&entity_id=&since=&until=&page=&page_size=
+func (h *AdminHandler) ListAudit(c *gin.Context) {
+	filter := storage.AuditFilter{
+		Action:     c.Query("action"),
+		EntityType: c.Query("entity_type"),
+		EntityID:   c.Query("entity_id"),
+	}
+
+	if c.Query("user_id") != "" {
+		userID, ok := queryInt(c, "user_id", 0)
+		if !ok {
+			return
+		}
+		id := int64(userID)
+		filter.UserID = &id
+	}
+
+	var ok bool
+	if filter.Since, ok = queryTime(c, "since"); !ok {
+		return
+	}
+	if filter.Until, ok = queryTime(c, "until"); !ok {
+		return
+	}
+
+	page, ok := queryInt(c, "page", 1)
+	if !ok {
+		return
+	}
+	pageSize, ok := queryInt(c, "page_size", 0)
+	if !ok {
+		return
+	}
+
+	result, err := h.audit.List(c.Request.Context(), filter, page, pageSize)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respondPage(c, result.Events, Pagination{
+		Page:       result.Page,
+		PageSize:   result.PageSize,
+		Total:      result.Total,
+		TotalPages: result.TotalPages(),
+	})
+}
@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/apierror"
+	"github.com/MuthuM3/gin-microservice-template/internal/blob"
+	"github.com/MuthuM3/gin-microservice-template/internal/middleware"
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// UploadRoute is the pattern of the attachment upload route relative to the
+// todos group, it accepts multipart bodies up to the upload size limit
+const UploadRoute = "/:id/attachments"
+
+type presignUploadRequest struct {
+	Filename    string `json:"filename" binding:"required,max=255"`
+	ContentType string `json:"content_type" binding:"required,max=255"`
+	Size        int64  `json:"size" binding:"required,min=1"`
+}
+
+// presignedRequest is a request the client sends as is, with the given
+// headers, straight to blob storage
+type presignedRequest struct {
+	Method    string            `json:"method"`
+	URL       string            `json:"url"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+type presignUploadResponse struct {
+	Attachment *models.Attachment `json:"attachment"`
+	Upload     presignedRequest   `json:"upload"`
+}
+
+func newPresignedRequest(req *blob.PresignedRequest) presignedRequest {
+	headers := make(map[string]string, len(req.Header))
+	for name := range req.Header {
+		headers[name] = req.Header.Get(name)
+	}
+	return presignedRequest{Method: req.Method, URL: req.URL, Headers: headers, ExpiresAt: req.Expires}
+}
+
+// AttachmentHandler serves the files attached to todos
+type AttachmentHandler struct {
+	attachments *service.AttachmentService
+}
+
+func NewAttachmentHandler(attachments *service.AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{attachments: attachments}
+}
+
+// RegisterRoutes mounts the attachment endpoints on the todos group, direct
+// transfers only when the blob storage supports them
+func (h *AttachmentHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST(UploadRoute, h.Upload)
+	rg.GET("/:id/attachments", h.List)
+	rg.GET("/:id/attachments/:attachment_id", h.Download)
+	rg.DELETE("/:id/attachments/:attachment_id", h.Delete)
+
+	if h.attachments.CanPresign() {
+		rg.POST("/:id/attachments/presign", h.PresignUpload)
+		rg.POST("/:id/attachments/:attachment_id/confirm", h.Confirm)
+		rg.GET("/:id/attachments/:attachment_id/url", h.PresignDownload)
+	}
+}
+
+// Upload handles POST /todos/:id/attachments with the file in the "file"
+// field of a multipart/form-data body. The file is streamed to the service
+// rather than parsed into memory
+func (h *AttachmentHandler) Upload(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	todoID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		middleware.AbortWithError(c, apierror.UnsupportedMediaType("multipart/form-data"))
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			invalidRequest(c, err)
+			return
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		attachment, err := h.attachments.Upload(c.Request.Context(), userID, todoID, part.FileName(), part)
+		part.Close()
+		if err != nil {
+			handleError(c, err)
+			return
+		}
+
+		respond(c, http.StatusCreated, attachment)
+		return
+	}
+
+	middleware.AbortWithError(c, apierror.Validation("the file field is required").WithDetails(gin.H{
+		"fields": map[string][]string{"file": {"file is required"}},
+	}))
+}
+
+// PresignUpload handles POST /todos/:id/attachments/presign. The response
+// holds a pending attachment and the request uploading its file, which is
+// confirmed with POST /todos/:id/attachments/:attachment_id/confirm
+func (h *AttachmentHandler) PresignUpload(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	todoID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req presignUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	attachment, upload, err := h.attachments.PresignUpload(c.Request.Context(), userID, todoID, req.Filename, req.ContentType, req.Size)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusCreated, presignUploadResponse{Attachment: attachment, Upload: newPresignedRequest(upload)})
+}
+
+// Confirm handles POST /todos/:id/attachments/:attachment_id/confirm, called
+// by the client once its direct upload has finished
+func (h *AttachmentHandler) Confirm(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	todoID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	id, ok := parseID(c, "attachment_id")
+	if !ok {
+		return
+	}
+
+	attachment, err := h.attachments.Confirm(c.Request.Context(), userID, todoID, id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, attachment)
+}
+
+// PresignDownload handles GET /todos/:id/attachments/:attachment_id/url
+func (h *AttachmentHandler) PresignDownload(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	todoID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	id, ok := parseID(c, "attachment_id")
+	if !ok {
+		return
+	}
+
+	download, err := h.attachments.PresignDownload(c.Request.Context(), userID, todoID, id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "private, no-store")
+	respond(c, http.StatusOK, newPresignedRequest(download))
+}
+
+// List handles GET /todos/:id/attachments
+func (h *AttachmentHandler) List(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	todoID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	attachments, err := h.attachments.List(c.Request.Context(), userID, todoID)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, attachments)
+}
+
+// Download handles GET /todos/:id/attachments/:attachment_id
+func (h *AttachmentHandler) Download(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	todoID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	id, ok := parseID(c, "attachment_id")
+	if !ok {
+		return
+	}
+
+	attachment, content, err := h.attachments.Open(c.Request.Context(), userID, todoID, id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	defer content.Close()
+
+	// Always a download, files are never rendered in the API's origin
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, content, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}),
+		"X-Content-Type-Options": "nosniff",
+		"Cache-Control":          "private, no-store",
+	})
+}
+
+// Delete handles DELETE /todos/:id/attachments/:attachment_id
+func (h *AttachmentHandler) Delete(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	todoID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	id, ok := parseID(c, "attachment_id")
+	if !ok {
+		return
+	}
+
+	if err := h.attachments.Delete(c.Request.Context(), userID, todoID, id); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
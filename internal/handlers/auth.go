@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/apierror"
+	"github.com/MuthuM3/gin-microservice-template/internal/middleware"
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// AuthHandler serves the registration and login endpoints
+type AuthHandler struct {
+	service *service.AuthService
+}
+
+func NewAuthHandler(service *service.AuthService) *AuthHandler {
+	return &AuthHandler{service: service}
+}
+
+type registerRequest struct {
+	Email    string `json:"email" binding:"required,email,max=255"`
+	Password string `json:"password" binding:"required,password"`
+	Name     string `json:"name" binding:"max=255"`
+}
+
+type loginRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type forgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,password"`
+}
+
+type authResponse struct {
+	User         *models.User `json:"user"`
+	AccessToken  string       `json:"access_token"`
+	TokenType    string       `json:"token_type"`
+	ExpiresAt    time.Time    `json:"expires_at"`
+	RefreshToken string       `json:"refresh_token"`
+}
+
+// RegisterRoutes mounts the auth endpoints on the given group, authenticated
+// is applied to endpoints that require a valid access token
+func (h *AuthHandler) RegisterRoutes(rg *gin.RouterGroup, authenticated gin.HandlerFunc) {
+	rg.POST("/register", h.Register)
+	rg.POST("/login", h.Login)
+	rg.POST("/refresh", h.Refresh)
+	rg.POST("/logout", authenticated, h.Logout)
+	rg.GET("/devices", authenticated, h.Devices)
+	rg.DELETE("/devices", authenticated, h.RevokeOtherDevices)
+	rg.DELETE("/devices/:id", authenticated, h.RevokeDevice)
+	rg.POST("/forgot-password", h.ForgotPassword)
+	rg.POST("/reset-password", h.ResetPassword)
+	rg.GET("/verify", h.VerifyEmail)
+}
+
+// Register handles POST /auth/register
+func (h *AuthHandler) Register(c *gin.Context) {
+	var req registerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	result, err := h.service.Register(c.Request.Context(), service.RegisterInput{
+		Email:    req.Email,
+		Password: req.Password,
+		Name:     req.Name,
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	if result.VerificationRequired {
+		respond(c, http.StatusCreated, gin.H{
+			"user":                  result.User,
+			"verification_required": true,
+		})
+		return
+	}
+
+	respond(c, http.StatusCreated, newAuthResponse(result))
+}
+
+// Login handles POST /auth/login
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req loginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	result, err := h.service.Login(c.Request.Context(), req.Email, req.Password, middleware.ClientIP(c))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, newAuthResponse(result))
+}
+
+// Refresh handles POST /auth/refresh
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req refreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	result, err := h.service.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, newAuthResponse(result))
+}
+
+// Logout handles POST /auth/logout
+func (h *AuthHandler) Logout(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	claims, ok := middleware.Claims(c)
+	if !ok {
+		middleware.AbortWithError(c, apierror.Unauthorized("authentication required"))
+		return
+	}
+
+	if err := h.service.Logout(c.Request.Context(), userID, claims.SessionID); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Devices handles GET /auth/devices, listing the sessions of the user's
+// tokens with the device that last used each
+func (h *AuthHandler) Devices(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.service.Sessions(c.Request.Context(), userID)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	currentID := currentTokenSessionID(c)
+	response := make([]sessionResponse, len(sessions))
+	for i, session := range sessions {
+		response[i] = newDeviceResponse(session, currentID)
+	}
+	respond(c, http.StatusOK, response)
+}
+
+// RevokeDevice handles DELETE /auth/devices/:id
+func (h *AuthHandler) RevokeDevice(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.RevokeSession(c.Request.Context(), userID, c.Param("id")); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RevokeOtherDevices handles DELETE /auth/devices, signing out of every
+// device but the one of the request
+func (h *AuthHandler) RevokeOtherDevices(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	revoked, err := h.service.RevokeOtherSessions(c.Request.Context(), userID, currentTokenSessionID(c))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, revokedSessionsResponse{Revoked: revoked})
+}
+
+// ForgotPassword handles POST /auth/forgot-password. The response is the same
+// whether or not the email is registered
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req forgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	if err := h.service.ForgotPassword(c.Request.Context(), req.Email); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusAccepted, gin.H{
+		"message": "if the account exists, a password reset link has been sent",
+	})
+}
+
+// ResetPassword handles POST /auth/reset-password
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req resetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	if err := h.service.ResetPassword(c.Request.Context(), req.Token, req.Password); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// VerifyEmail handles GET /auth/verify?token=
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		middleware.AbortWithError(c, apierror.BadRequest("invalid_query", "token is required"))
+		return
+	}
+
+	if err := h.service.VerifyEmail(c.Request.Context(), token); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{"message": "email verified"})
+}
+
+func newAuthResponse(result *service.AuthResult) authResponse {
+	return authResponse{
+		User:         result.User,
+		AccessToken:  result.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresAt:    result.ExpiresAt,
+		RefreshToken: result.RefreshToken,
+	}
+}
+
+// currentTokenSessionID returns the session of the access token the request
+// was authenticated with, empty for cookie sessions
+func currentTokenSessionID(c *gin.Context) string {
+	if claims, ok := middleware.Claims(c); ok {
+		return claims.SessionID
+	}
+	return ""
+}
+
+func newDeviceResponse(session *models.Session, currentID string) sessionResponse {
+	return sessionResponse{
+		ID:         session.ID,
+		IPAddress:  session.IPAddress,
+		UserAgent:  session.UserAgent,
+		CreatedAt:  session.CreatedAt,
+		LastSeenAt: session.LastSeenAt,
+		ExpiresAt:  session.ExpiresAt,
+		Current:    session.ID == currentID,
+	}
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/capture"
+	"github.com/gin-gonic/gin"
+)
+
+// CaptureHandler serves the request captures taken for debugging
+type CaptureHandler struct {
+	captures *capture.Store
+}
+
+func NewCaptureHandler(captures *capture.Store) *CaptureHandler {
+	return &CaptureHandler{captures: captures}
+}
+
+// RegisterRoutes mounts the capture endpoints on rg
+func (h *CaptureHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/captures/:request_id", h.Get)
+}
+
+// Get handles GET /admin/captures/:request_id
+func (h *CaptureHandler) Get(c *gin.Context) {
+	entry, err := h.captures.Get(c.Request.Context(), c.Param("request_id"))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, entry)
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// notModified sets the ETag and Last-Modified validators of a resource last
+// modified at modified, and answers 304 when If-None-Match or, without it,
+// If-Modified-Since shows the client already has this version. The ETag
+// covers the representation: the negotiated format and selected fields
+func notModified(c *gin.Context, kind string, id int64, modified time.Time) bool {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s:%d:%d:%s:%s", kind, id, modified.UnixNano(), middleware.Format(c), c.Query("fields"))
+	etag := fmt.Sprintf(`W/"%x"`, h.Sum64())
+
+	c.Header("ETag", etag)
+	c.Header("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		return false
+	}
+
+	if match := c.GetHeader("If-None-Match"); match != "" {
+		if !etagMatches(match, etag) {
+			return false
+		}
+	} else {
+		since, err := http.ParseTime(c.GetHeader("If-Modified-Since"))
+		// Last-Modified has a resolution of one second
+		if err != nil || modified.Truncate(time.Second).After(since) {
+			return false
+		}
+	}
+
+	c.Status(http.StatusNotModified)
+	c.Writer.WriteHeaderNow()
+	c.Abort()
+	return true
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, using the
+// weak comparison
+func etagMatches(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for candidate := range strings.SplitSeq(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
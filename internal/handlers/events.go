@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// EventHandler streams todo changes to clients as Server-Sent Events
+type EventHandler struct {
+	feed      *service.TodoFeed
+	heartbeat time.Duration
+}
+
+func NewEventHandler(feed *service.TodoFeed, heartbeat time.Duration) *EventHandler {
+	return &EventHandler{feed: feed, heartbeat: heartbeat}
+}
+
+// RegisterRoutes mounts the event stream on rg
+func (h *EventHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("", h.Stream)
+}
+
+// Stream handles GET /events. Clients resume after a reconnect by sending
+// the id of the last event they received in Last-Event-ID; a "reset" event
+// tells them that some changes were lost and their state must be reloaded
+func (h *EventHandler) Stream(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	lastEventID, _ := strconv.ParseUint(c.GetHeader("Last-Event-ID"), 10, 64)
+	sub, missed, complete := h.feed.Subscribe(userID, lastEventID)
+	defer sub.Close()
+
+	if !startEventStream(c) {
+		return
+	}
+
+	if !complete {
+		fmt.Fprint(c.Writer, "event: reset\ndata: {}\n\n")
+	}
+	for _, event := range missed {
+		writeEvent(c.Writer, event)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				// Dropped for falling behind, the client resumes on reconnect
+				return
+			}
+			writeEvent(c.Writer, event)
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
+		case <-c.Request.Context().Done():
+			return
+		}
+		c.Writer.Flush()
+	}
+}
+
+// startEventStream prepares the response for Server-Sent Events, it responds
+// with an error and returns false if that is not possible
+func startEventStream(c *gin.Context) bool {
+	// The stream outlives the server's write timeout
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		handleError(c, err)
+		return false
+	}
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	return true
+}
+
+func writeEvent(w io.Writer, event service.TodoEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/export"
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ExportHandler serves todo exports
+type ExportHandler struct {
+	exports *service.ExportService
+}
+
+func NewExportHandler(exports *service.ExportService) *ExportHandler {
+	return &ExportHandler{exports: exports}
+}
+
+// RegisterRoutes mounts the export endpoint on the authenticated todos group
+// and the download of asynchronous exports on v1, where the token in the
+// emailed link authorizes it
+func (h *ExportHandler) RegisterRoutes(todos, v1 *gin.RouterGroup) {
+	todos.GET("/export", h.Export)
+	v1.GET("/exports/download", h.Download)
+}
+
+// Export handles GET /todos/export?format=&status=&priority=&due_before=&due_after=&tags=&async=
+func (h *ExportHandler) Export(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	dueBefore, ok := queryTime(c, "due_before")
+	if !ok {
+		return
+	}
+	dueAfter, ok := queryTime(c, "due_after")
+	if !ok {
+		return
+	}
+
+	format := c.DefaultQuery("format", export.FormatCSV)
+	query := service.TodoQuery{
+		Status:    c.Query("status"),
+		Priority:  c.Query("priority"),
+		DueBefore: dueBefore,
+		DueAfter:  dueAfter,
+		Tags:      queryList(c, "tags"),
+	}
+
+	if async, _ := strconv.ParseBool(c.Query("async")); async {
+		if err := h.exports.Schedule(c.Request.Context(), userID, format, query); err != nil {
+			handleError(c, err)
+			return
+		}
+		respond(c, http.StatusAccepted, gin.H{
+			"message": "the export is being prepared, a download link will be emailed to you",
+		})
+		return
+	}
+
+	// Large exports outlive the server's write timeout
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		handleError(c, err)
+		return
+	}
+
+	w := &attachmentWriter{c: c, format: format}
+	if err := h.exports.Stream(c.Request.Context(), userID, format, query, w); err != nil {
+		if !c.Writer.Written() {
+			handleError(c, err)
+			return
+		}
+		// Part of the file was sent, abort the connection so the client does
+		// not mistake it for a complete export
+		_ = c.Error(err)
+		panic(http.ErrAbortHandler)
+	}
+	if !c.Writer.Written() {
+		w.writeHeader()
+	}
+}
+
+// Download handles GET /exports/download?token=
+func (h *ExportHandler) Download(c *gin.Context) {
+	file, err := h.exports.Download(c.Request.Context(), c.Query("token"))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+export.Filename(file.Format, time.Now())+`"`)
+	c.Data(http.StatusOK, export.ContentType(file.Format), file.Data)
+}
+
+// attachmentWriter sends the download headers with the first chunk of an
+// export, so an export failing before it still gets a JSON error response
+type attachmentWriter struct {
+	c      *gin.Context
+	format string
+}
+
+func (w *attachmentWriter) Write(p []byte) (int, error) {
+	if !w.c.Writer.Written() {
+		w.writeHeader()
+	}
+	return w.c.Writer.Write(p)
+}
+
+func (w *attachmentWriter) writeHeader() {
+	header := w.c.Writer.Header()
+	header.Set("Content-Type", export.ContentType(w.format))
+	header.Set("Content-Disposition", `attachment; filename="`+export.Filename(w.format, time.Now())+`"`)
+	header.Set("Cache-Control", "no-store")
+	w.c.Status(http.StatusOK)
+	w.c.Writer.WriteHeaderNow()
+}
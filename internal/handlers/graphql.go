@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/apierror"
+	"github.com/MuthuM3/gin-microservice-template/internal/graph"
+	"github.com/MuthuM3/gin-microservice-template/internal/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+)
+
+// GraphQLHandler serves the GraphQL schema. Queries and mutations POSTed
+// get a single JSON response; requests accepting text/event-stream receive
+// every result as a "next" event followed by "complete", as in the GraphQL
+// over SSE protocol. Subscriptions are also served over websockets with the
+// graphql-transport-ws protocol of the graphql-ws clients
+type GraphQLHandler struct {
+	schema    *graphql.Schema
+	heartbeat time.Duration
+	closing   chan struct{}
+	shutdown  sync.Once
+}
+
+func NewGraphQLHandler(schema *graphql.Schema, heartbeat time.Duration) *GraphQLHandler {
+	return &GraphQLHandler{schema: schema, heartbeat: heartbeat, closing: make(chan struct{})}
+}
+
+type graphqlRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// RegisterRoutes mounts the GraphQL endpoint on rg
+func (h *GraphQLHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("", h.Serve)
+	rg.GET("", h.ServeWebSocket)
+}
+
+// Serve handles POST /graphql
+func (h *GraphQLHandler) Serve(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req graphqlRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		middleware.AbortWithError(c, apierror.Validation("query is required"))
+		return
+	}
+
+	ctx := graph.WithUserID(c.Request.Context(), userID)
+	if !strings.Contains(c.GetHeader("Accept"), "text/event-stream") {
+		resp := h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+		resolverErrors(c, resp.Errors)
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
+	results, err := h.schema.Subscribe(ctx, req.Query, req.OperationName, req.Variables)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	if !startEventStream(c) {
+		return
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case result, ok := <-results:
+			if !ok {
+				fmt.Fprint(c.Writer, "event: complete\ndata:\n\n")
+				c.Writer.Flush()
+				return
+			}
+			resp, ok := result.(*graphql.Response)
+			if !ok {
+				continue
+			}
+			resolverErrors(c, resp.Errors)
+			data, err := json.Marshal(resp)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(c.Writer, "event: next\ndata: %s\n\n", data)
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
+		case <-c.Request.Context().Done():
+			return
+		}
+		c.Writer.Flush()
+	}
+}
+
+// resolverErrors gives the errors returned by resolvers the messages and
+// codes of the REST API, the code and details are sent in the extensions.
+// Unexpected errors are hidden from clients and recorded for the request log
+func resolverErrors(c *gin.Context, errs []*gqlerrors.QueryError) {
+	for _, queryErr := range errs {
+		if queryErr.ResolverError == nil {
+			continue
+		}
+
+		apiErr := apierror.From(serviceError(queryErr.ResolverError))
+		if apiErr.Status >= http.StatusInternalServerError {
+			c.Error(queryErr.ResolverError)
+		}
+		queryErr.Message = apiErr.Message
+		queryErr.Extensions = map[string]any{"code": apiErr.Code}
+		if apiErr.Details != nil {
+			queryErr.Extensions["details"] = apiErr.Details
+		}
+	}
+}
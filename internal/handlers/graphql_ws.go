@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/apierror"
+	"github.com/MuthuM3/gin-microservice-template/internal/graph"
+	"github.com/MuthuM3/gin-microservice-template/internal/middleware"
+	"github.com/coder/websocket"
+	"github.com/gin-gonic/gin"
+	"github.com/graph-gophers/graphql-go"
+)
+
+// graphqlWSProtocol is the websocket subprotocol of GraphQL over WebSocket,
+// https://github.com/enisdenjo/graphql-ws/blob/master/PROTOCOL.md
+const graphqlWSProtocol = "graphql-transport-ws"
+
+const (
+	// graphqlInitTimeout is how long a client has to send connection_init
+	graphqlInitTimeout = 10 * time.Second
+	// graphqlWriteTimeout bounds each message sent to a client
+	graphqlWriteTimeout = 10 * time.Second
+)
+
+// Close codes of the graphql-transport-ws protocol
+const (
+	closeBadRequest    websocket.StatusCode = 4400
+	closeUnauthorized  websocket.StatusCode = 4401
+	closeNotAcceptable websocket.StatusCode = 4406
+	closeInitTimeout   websocket.StatusCode = 4408
+	closeDuplicateID   websocket.StatusCode = 4409
+	closeTooManyInits  websocket.StatusCode = 4429
+)
+
+type graphqlWSMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// ServeWebSocket handles GET /graphql, upgrading it to a graphql-transport-ws
+// connection. The upgrade request is authenticated like any other, so every
+// operation on the connection runs as that user. Cross-origin upgrades are
+// refused, a cookie session cannot be used from another site
+func (h *GraphQLHandler) ServeWebSocket(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	if !c.IsWebsocket() {
+		c.Header("Upgrade", "websocket")
+		middleware.AbortWithError(c, apierror.New(http.StatusUpgradeRequired, "upgrade_required", "graphql subscriptions over GET require a websocket upgrade"))
+		return
+	}
+
+	conn, err := websocket.Accept(upgradeWriter{c.Writer}, c.Request, &websocket.AcceptOptions{
+		Subprotocols: []string{graphqlWSProtocol},
+	})
+	if err != nil {
+		// Accept has already written the response
+		c.Error(err)
+		return
+	}
+	defer conn.CloseNow()
+
+	if conn.Subprotocol() != graphqlWSProtocol {
+		conn.Close(closeNotAcceptable, "Subprotocol not acceptable")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(graph.WithUserID(c.Request.Context(), userID))
+	defer cancel()
+
+	socket := &graphqlSocket{
+		c:          c,
+		conn:       conn,
+		schema:     h.schema,
+		operations: make(map[string]*graphqlOperation),
+	}
+	go socket.keepAlive(ctx, h.heartbeat, h.closing)
+	socket.serve(ctx)
+}
+
+// Shutdown closes the websocket connections, which the HTTP server does not
+// track once they are upgraded
+func (h *GraphQLHandler) Shutdown() {
+	h.shutdown.Do(func() { close(h.closing) })
+}
+
+// graphqlSocket is one graphql-transport-ws connection
+type graphqlSocket struct {
+	c      *gin.Context
+	conn   *websocket.Conn
+	schema *graphql.Schema
+
+	// running counts the operations, which use c until they return
+	running sync.WaitGroup
+
+	mu         sync.Mutex
+	acked      bool
+	operations map[string]*graphqlOperation
+}
+
+// graphqlOperation is a subscribe message being served
+type graphqlOperation struct {
+	cancel context.CancelFunc
+}
+
+// serve reads messages until the connection closes, then cancels the
+// operations still running and waits for them to return
+func (s *graphqlSocket) serve(ctx context.Context) {
+	defer s.running.Wait()
+	defer func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for _, op := range s.operations {
+			op.cancel()
+		}
+	}()
+
+	for {
+		_, data, err := s.conn.Read(ctx)
+		if err != nil {
+			return
+		}
+
+		var msg graphqlWSMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			s.conn.Close(closeBadRequest, "Invalid message received")
+			return
+		}
+
+		switch msg.Type {
+		case "connection_init":
+			s.mu.Lock()
+			acked := s.acked
+			s.acked = true
+			s.mu.Unlock()
+			if acked {
+				s.conn.Close(closeTooManyInits, "Too many initialisation requests")
+				return
+			}
+			s.send(ctx, graphqlWSMessage{Type: "connection_ack"})
+		case "ping":
+			s.send(ctx, graphqlWSMessage{Type: "pong"})
+		case "pong":
+			// Answers the server's pings, nothing to do
+		case "subscribe":
+			if !s.subscribe(ctx, msg) {
+				return
+			}
+		case "complete":
+			s.mu.Lock()
+			if op, ok := s.operations[msg.ID]; ok {
+				op.cancel()
+				delete(s.operations, msg.ID)
+			}
+			s.mu.Unlock()
+		default:
+			s.conn.Close(closeBadRequest, "Invalid message received")
+			return
+		}
+	}
+}
+
+// subscribe starts an operation, the connection is closed and false returned
+// when the message breaks the protocol
+func (s *graphqlSocket) subscribe(ctx context.Context, msg graphqlWSMessage) bool {
+	var req graphqlRequest
+	if msg.ID == "" || json.Unmarshal(msg.Payload, &req) != nil || strings.TrimSpace(req.Query) == "" {
+		s.conn.Close(closeBadRequest, "Invalid message received")
+		return false
+	}
+
+	s.mu.Lock()
+	if !s.acked {
+		s.mu.Unlock()
+		s.conn.Close(closeUnauthorized, "Unauthorized")
+		return false
+	}
+	if _, ok := s.operations[msg.ID]; ok {
+		s.mu.Unlock()
+		s.conn.Close(closeDuplicateID, "Subscriber for "+msg.ID+" already exists")
+		return false
+	}
+	opCtx, cancel := context.WithCancel(ctx)
+	op := &graphqlOperation{cancel: cancel}
+	s.operations[msg.ID] = op
+	s.mu.Unlock()
+
+	s.running.Add(1)
+	go s.run(ctx, opCtx, msg.ID, op, req)
+	return true
+}
+
+// run sends the results of an operation as next messages, followed by
+// complete unless the client completed it first
+func (s *graphqlSocket) run(ctx, opCtx context.Context, id string, op *graphqlOperation, req graphqlRequest) {
+	defer s.running.Done()
+	defer func() {
+		op.cancel()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		// The client may have completed the operation and reused its id
+		if s.operations[id] == op {
+			delete(s.operations, id)
+		}
+	}()
+
+	results, err := s.schema.Subscribe(opCtx, req.Query, req.OperationName, req.Variables)
+	if err != nil {
+		payload, _ := json.Marshal([]map[string]string{{"message": err.Error()}})
+		s.send(ctx, graphqlWSMessage{ID: id, Type: "error", Payload: payload})
+		return
+	}
+
+	// Drain the results even once cancelled so the resolvers can finish
+	for result := range results {
+		resp, ok := result.(*graphql.Response)
+		if !ok || opCtx.Err() != nil {
+			continue
+		}
+		s.mu.Lock()
+		resolverErrors(s.c, resp.Errors)
+		s.mu.Unlock()
+		payload, err := json.Marshal(resp)
+		if err != nil {
+			continue
+		}
+		s.send(ctx, graphqlWSMessage{ID: id, Type: "next", Payload: payload})
+	}
+
+	if opCtx.Err() == nil {
+		s.send(ctx, graphqlWSMessage{ID: id, Type: "complete"})
+	}
+}
+
+// keepAlive closes connections that are not initialised in time, pings the
+// client every interval and closes the connection when the server shuts down
+func (s *graphqlSocket) keepAlive(ctx context.Context, interval time.Duration, closing <-chan struct{}) {
+	initTimeout := time.NewTimer(graphqlInitTimeout)
+	defer initTimeout.Stop()
+	heartbeat := time.NewTicker(interval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-initTimeout.C:
+			s.mu.Lock()
+			acked := s.acked
+			s.mu.Unlock()
+			if !acked {
+				s.conn.Close(closeInitTimeout, "Connection initialisation timeout")
+				return
+			}
+		case <-heartbeat.C:
+			s.send(ctx, graphqlWSMessage{Type: "ping"})
+		case <-closing:
+			s.conn.Close(websocket.StatusGoingAway, "server shutting down")
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *graphqlSocket) send(ctx context.Context, msg graphqlWSMessage) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, graphqlWriteTimeout)
+	defer cancel()
+	if err := s.conn.Write(ctx, websocket.MessageText, data); err != nil && !errors.Is(err, context.Canceled) {
+		s.conn.CloseNow()
+	}
+}
+
+// upgradeWriter lets websocket.Accept take over the connection. Accept
+// flushes the status of gin writers before hijacking, which gin refuses
+// once the response is written, so the handshake response is written here
+// after the hijack instead
+type upgradeWriter struct {
+	gin.ResponseWriter
+}
+
+func (w upgradeWriter) WriteHeaderNow() {}
+
+func (w upgradeWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := w.ResponseWriter.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fmt.Fprintf(rw, "HTTP/1.1 %d %s\r\n", w.Status(), http.StatusText(w.Status()))
+	w.Header().Write(rw)
+	rw.WriteString("\r\n")
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("failed to write handshake response: %w", err)
+	}
+	return conn, rw, nil
+}
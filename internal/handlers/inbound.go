@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// InboundRoute is the pattern of the webhook receiver relative to the
+// /webhooks group, it accepts signed bodies in any content type
+const InboundRoute = "/:provider"
+
+// InboundWebhookHandler receives the webhooks of third parties
+type InboundWebhookHandler struct {
+	service *service.InboundWebhookService
+}
+
+func NewInboundWebhookHandler(service *service.InboundWebhookService) *InboundWebhookHandler {
+	return &InboundWebhookHandler{service: service}
+}
+
+// RegisterRoutes mounts the receiver on the given group
+func (h *InboundWebhookHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST(InboundRoute, h.Receive)
+}
+
+// Receive handles POST /webhooks/:provider, answering 202 once the event is
+// queued and 204 once it was handled
+func (h *InboundWebhookHandler) Receive(c *gin.Context) {
+	// The signature covers the exact bytes sent, so the body is read as is
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	queued, err := h.service.Receive(c.Request.Context(), c.Param("provider"), c.Request.Header, body)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	if queued {
+		c.Status(http.StatusAccepted)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationHandler serves the notification channels of the signed in user
+type NotificationHandler struct {
+	service *service.NotificationService
+}
+
+func NewNotificationHandler(service *service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{service: service}
+}
+
+type channelRequest struct {
+	URL     *string `json:"url" binding:"omitempty,max=2048"`
+	Enabled *bool   `json:"enabled"`
+}
+
+// channelCreatedResponse carries the secret of a new webhook channel, the
+// only response that does
+type channelCreatedResponse struct {
+	*models.NotificationChannel
+	Secret string `json:"secret,omitempty"`
+}
+
+// RegisterRoutes mounts the notification channel endpoints on the /me group
+func (h *NotificationHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/notification-channels", h.List)
+	rg.PUT("/notification-channels/:channel", h.Set)
+	rg.DELETE("/notification-channels/:channel", h.Delete)
+}
+
+// List handles GET /me/notification-channels
+func (h *NotificationHandler) List(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	channels, err := h.service.Channels(c.Request.Context(), userID)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, channels)
+}
+
+// Set handles PUT /me/notification-channels/:channel
+func (h *NotificationHandler) Set(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req channelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	channel, created, err := h.service.SetChannel(c.Request.Context(), userID, c.Param("channel"), service.ChannelInput{
+		URL:     req.URL,
+		Enabled: req.Enabled,
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	if created {
+		respond(c, http.StatusCreated, channelCreatedResponse{NotificationChannel: channel, Secret: channel.Secret})
+		return
+	}
+	respond(c, http.StatusOK, channel)
+}
+
+// Delete handles DELETE /me/notification-channels/:channel
+func (h *NotificationHandler) Delete(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteChannel(c.Request.Context(), userID, c.Param("channel")); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
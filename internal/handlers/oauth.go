@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/apierror"
+	"github.com/MuthuM3/gin-microservice-template/internal/auth"
+	"github.com/MuthuM3/gin-microservice-template/internal/middleware"
+	"github.com/MuthuM3/gin-microservice-template/internal/oauth"
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	stateCookie    = "oauth_state"
+	verifierCookie = "oauth_verifier"
+)
+
+// OAuthHandler serves social login through external identity providers
+type OAuthHandler struct {
+	service      *service.AuthService
+	providers    map[string]oauth.Provider
+	stateTTL     time.Duration
+	secureCookie bool
+}
+
+// NewOAuthHandler creates the handler, secureCookie marks the state cookies
+// as HTTPS only
+func NewOAuthHandler(service *service.AuthService, providers map[string]oauth.Provider, stateTTL time.Duration, secureCookie bool) *OAuthHandler {
+	return &OAuthHandler{
+		service:      service,
+		providers:    providers,
+		stateTTL:     stateTTL,
+		secureCookie: secureCookie,
+	}
+}
+
+// RegisterRoutes mounts the login and callback endpoints on the auth group
+func (h *OAuthHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/oauth/:provider/login", h.Login)
+	rg.GET("/oauth/:provider/callback", h.Callback)
+}
+
+// Login handles GET /auth/oauth/:provider/login by redirecting to the
+// provider. The state and PKCE verifier are kept in short-lived cookies
+func (h *OAuthHandler) Login(c *gin.Context) {
+	provider, ok := h.provider(c)
+	if !ok {
+		return
+	}
+
+	state, err := auth.RandomToken(16)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	verifier := oauth.NewVerifier()
+
+	h.setCookie(c, stateCookie, state, int(h.stateTTL.Seconds()))
+	h.setCookie(c, verifierCookie, verifier, int(h.stateTTL.Seconds()))
+	c.Redirect(http.StatusFound, provider.AuthCodeURL(state, verifier))
+}
+
+// Callback handles GET /auth/oauth/:provider/callback, signing in the user
+// and returning the same tokens as a password login
+func (h *OAuthHandler) Callback(c *gin.Context) {
+	provider, ok := h.provider(c)
+	if !ok {
+		return
+	}
+
+	state, _ := c.Cookie(stateCookie)
+	verifier, _ := c.Cookie(verifierCookie)
+	h.setCookie(c, stateCookie, "", -1)
+	h.setCookie(c, verifierCookie, "", -1)
+
+	if reason := c.Query("error"); reason != "" {
+		middleware.AbortWithError(c, apierror.Unauthorized("authorization denied by provider").WithDetails(reason))
+		return
+	}
+
+	if state == "" || verifier == "" || subtle.ConstantTimeCompare([]byte(state), []byte(c.Query("state"))) != 1 {
+		middleware.AbortWithError(c, apierror.BadRequest("invalid_oauth_state", "login session expired or invalid, please retry"))
+		return
+	}
+
+	code := c.Query("code")
+	if code == "" {
+		middleware.AbortWithError(c, apierror.BadRequest("invalid_query", "code is required"))
+		return
+	}
+
+	identity, err := provider.Exchange(c.Request.Context(), code, verifier)
+	if err != nil {
+		middleware.AbortWithError(c, apierror.New(http.StatusBadGateway, "oauth_failed", "failed to complete login with "+provider.Name()).Wrap(err))
+		return
+	}
+
+	result, err := h.service.LoginWithIdentity(c.Request.Context(), identity)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, newAuthResponse(result))
+}
+
+// provider looks up the provider named in the path, responding with 404 when
+// it is not configured
+func (h *OAuthHandler) provider(c *gin.Context) (oauth.Provider, bool) {
+	provider, ok := h.providers[c.Param("provider")]
+	if !ok {
+		middleware.AbortWithError(c, apierror.NotFound("unknown login provider"))
+		return nil, false
+	}
+	return provider, true
+}
+
+func (h *OAuthHandler) setCookie(c *gin.Context, name, value string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(name, value, maxAge, "/", "", h.secureCookie, true)
+}
@@ -0,0 +1,680 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/capture"
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/openapi"
+	"github.com/MuthuM3/gin-microservice-template/internal/queue"
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// messageResponse is the body of endpoints that only confirm an action
+type messageResponse struct {
+	Message string `json:"message"`
+}
+
+var pageParams = []openapi.Param{
+	{Name: "page", Type: "integer", Description: "Page number, starting at 1"},
+	{Name: "page_size", Type: "integer", Description: "Items per page"},
+}
+
+// conditionalGet describes the detail endpoints answering conditional requests
+const conditionalGet = "Responses carry ETag and Last-Modified, send them back in If-None-Match or " +
+	"If-Modified-Since to get 304 Not Modified while the resource is unchanged"
+
+var fieldsParam = openapi.Param{
+	Name: "fields", Type: "string",
+	Description: "Comma separated fields to return, e.g. id,title,due_date. All fields when omitted",
+}
+
+// Describe documents the routes of the built-in handlers. Handlers are
+// matched by method, so nil receivers are enough
+func Describe(spec *openapi.Spec) {
+	spec.Envelope(Envelope{})
+
+	var (
+		auth    *AuthHandler
+		oauth   *OAuthHandler
+		logins  *SessionHandler
+		history *ActivityHandler
+		admin   *AdminHandler
+		queues  *QueueHandler
+		debug   *CaptureHandler
+		todos   *TodoHandler
+		todosV2 *TodoV2Handler
+		exports *ExportHandler
+		files   *AttachmentHandler
+		tags    *TagHandler
+		profile *ProfileHandler
+		account *AccountHandler
+		notify  *NotificationHandler
+		remind  *ReminderHandler
+		shares  *ShareHandler
+		hooks   *WebhookHandler
+		inbound *InboundWebhookHandler
+		scim    *SCIMHandler
+		quotas  *QuotaHandler
+		events  *EventHandler
+		gql     *GraphQLHandler
+		schemas *SchemaHandler
+	)
+
+	spec.Describe(auth.Register, openapi.Operation{
+		Summary: "Register an account", Tags: []string{"auth"},
+		Description: "Returns a session, or the user and verification_required when the email must be verified first",
+		Request:     registerRequest{}, Status: http.StatusCreated, Response: authResponse{},
+	})
+	spec.Describe(auth.Login, openapi.Operation{
+		Summary: "Sign in with email and password", Tags: []string{"auth"},
+		Request: loginRequest{}, Response: authResponse{},
+	})
+	spec.Describe(auth.Refresh, openapi.Operation{
+		Summary: "Exchange a refresh token for a new session", Tags: []string{"auth"},
+		Request: refreshRequest{}, Response: authResponse{},
+	})
+	spec.Describe(auth.Logout, openapi.Operation{
+		Summary: "Revoke the current session", Tags: []string{"auth"},
+		Status: http.StatusNoContent, Security: openapi.BearerAuth,
+	})
+	spec.Describe(auth.Devices, openapi.Operation{
+		Summary: "List the signed in devices of the user", Tags: []string{"auth"},
+		Description: "Active token sessions with the address and user agent that last used them, updated on " +
+			"login and refresh, most recently seen first",
+		Response: []sessionResponse{}, Security: openapi.BearerAuth,
+	})
+	spec.Describe(auth.RevokeDevice, openapi.Operation{
+		Summary: "Sign out of a device", Tags: []string{"auth"},
+		Status: http.StatusNoContent, Security: openapi.BearerAuth,
+	})
+	spec.Describe(auth.RevokeOtherDevices, openapi.Operation{
+		Summary: "Sign out of every other device", Tags: []string{"auth"},
+		Response: revokedSessionsResponse{}, Security: openapi.BearerAuth,
+	})
+	spec.Describe(auth.ForgotPassword, openapi.Operation{
+		Summary: "Send a password reset link", Tags: []string{"auth"},
+		Request: forgotPasswordRequest{}, Status: http.StatusAccepted, Response: messageResponse{},
+	})
+	spec.Describe(auth.ResetPassword, openapi.Operation{
+		Summary: "Choose a new password with a reset token", Tags: []string{"auth"},
+		Request: resetPasswordRequest{}, Status: http.StatusNoContent,
+	})
+	spec.Describe(auth.VerifyEmail, openapi.Operation{
+		Summary: "Confirm an email address", Tags: []string{"auth"},
+		Query:    []openapi.Param{{Name: "token", Type: "string", Required: true}},
+		Response: messageResponse{},
+	})
+	spec.Describe(oauth.Login, openapi.Operation{
+		Summary: "Redirect to an identity provider", Tags: []string{"auth"},
+		Status: http.StatusFound,
+	})
+	spec.Describe(oauth.Callback, openapi.Operation{
+		Summary: "Sign in with the identity provider's authorization code", Tags: []string{"auth"},
+		Query: []openapi.Param{
+			{Name: "code", Type: "string", Required: true},
+			{Name: "state", Type: "string", Required: true},
+		},
+		Response: authResponse{},
+	})
+
+	spec.Describe(logins.Login, openapi.Operation{
+		Summary: "Sign in with a session cookie", Tags: []string{"auth"},
+		Description: "Sets an HTTP only session cookie, the csrf_token must be sent in the X-CSRF-Token header " +
+			"of requests changing state",
+		Request: loginRequest{}, Status: http.StatusCreated, Response: sessionLoginResponse{},
+	})
+	spec.Describe(logins.Logout, openapi.Operation{
+		Summary: "End the session of the cookie", Tags: []string{"auth"},
+		Status: http.StatusNoContent, Security: openapi.BearerAuth,
+	})
+	spec.Describe(logins.List, openapi.Operation{
+		Summary: "List the active sessions of the user", Tags: []string{"auth"},
+		Response: []sessionResponse{}, Security: openapi.BearerAuth,
+	})
+	spec.Describe(logins.Revoke, openapi.Operation{
+		Summary: "End a session on any device", Tags: []string{"auth"},
+		Status: http.StatusNoContent, Security: openapi.BearerAuth,
+	})
+	spec.Describe(logins.RevokeOthers, openapi.Operation{
+		Summary: "Log out every other device", Tags: []string{"auth"},
+		Response: revokedSessionsResponse{}, Security: openapi.BearerAuth,
+	})
+
+	spec.Describe(history.List, openapi.Operation{
+		Summary: "List the recent security events of the account", Tags: []string{"auth"},
+		Description: "Logins, failed logins, lockouts, token refreshes and password changes with the client " +
+			"address and user agent, newest first",
+		Query: pageParams, Response: openapi.List{Items: models.SecurityEvent{}}, Security: openapi.BearerAuth,
+	})
+
+	spec.Describe(admin.ClearLockout, openapi.Operation{
+		Summary: "Clear the lockout of an account or client IP", Tags: []string{"admin"},
+		Query: []openapi.Param{
+			{Name: "email", Type: "string"},
+			{Name: "ip", Type: "string"},
+		},
+		Status: http.StatusNoContent, Security: openapi.AdminAuth,
+	})
+	spec.Describe(admin.ListAudit, openapi.Operation{
+		Summary: "List audit events", Tags: []string{"admin"},
+		Query: append([]openapi.Param{
+			{Name: "user_id", Type: "integer", Format: "int64"},
+			{Name: "action", Type: "string"},
+			{Name: "entity_type", Type: "string"},
+			{Name: "entity_id", Type: "string"},
+			{Name: "since", Type: "string", Format: "date-time"},
+			{Name: "until", Type: "string", Format: "date-time"},
+		}, pageParams...),
+		Response: openapi.List{Items: models.AuditEvent{}},
+		Security: openapi.AdminAuth,
+	})
+
+	spec.Describe(queues.Stats, openapi.Operation{
+		Summary: "Count the tasks of every queue by state", Tags: []string{"admin"},
+		Response: openapi.List{Items: queue.Stats{}}, Security: openapi.AdminAuth,
+	})
+	spec.Describe(queues.ListDead, openapi.Operation{
+		Summary: "List the dead tasks of a queue, most recent failures first", Tags: []string{"admin"},
+		Query:    pageParams,
+		Response: openapi.List{Items: queue.Task{}},
+		Security: openapi.AdminAuth,
+	})
+	spec.Describe(queues.RetryDead, openapi.Operation{
+		Summary: "Queue a dead task again", Tags: []string{"admin"},
+		Status: http.StatusNoContent, Security: openapi.AdminAuth,
+	})
+	spec.Describe(queues.DeleteDead, openapi.Operation{
+		Summary: "Delete a dead task", Tags: []string{"admin"},
+		Status: http.StatusNoContent, Security: openapi.AdminAuth,
+	})
+	spec.Describe(debug.Get, openapi.Operation{
+		Summary: "Get the captured request and response of a request", Tags: []string{"admin"},
+		Description: "Only requests matching logger.capture are captured, with secrets redacted. Captures " +
+			"expire after logger.capture.ttl",
+		Response: capture.Capture{}, Security: openapi.AdminAuth,
+	})
+
+	spec.Describe(todos.Create, openapi.Operation{
+		Summary: "Create a todo", Tags: []string{"todos"},
+		Description: "A todo with a due date repeats by an RFC 5545 recurrence rule such as " +
+			"FREQ=WEEKLY;BYDAY=MO,TH, supporting FREQ, INTERVAL, COUNT, UNTIL, BYDAY for weekly and BYMONTHDAY " +
+			"for monthly rules. Completing it creates the todo of the next occurrence, which takes over the rule",
+		Request: todoRequest{}, Status: http.StatusCreated, Response: models.Todo{},
+		Security: openapi.BearerAuth,
+	})
+	spec.Describe(todos.Import, openapi.Operation{
+		Summary: "Import todos in bulk", Tags: []string{"todos"},
+		Description: "Stores every todo or none of them. Imported todos are top level and untagged and are not announced as events",
+		Request:     todoImportRequest{}, Status: http.StatusCreated, Response: todoImportResponse{},
+		Security: openapi.BearerAuth,
+	})
+	spec.Describe(todos.List, openapi.Operation{
+		Summary: "List todos, newest first", Tags: []string{"todos"},
+		Query: []openapi.Param{
+			{Name: "cursor", Type: "string", Description: "next_cursor or prev_cursor of the previous page"},
+			{Name: "limit", Type: "integer"},
+			{Name: "status", Type: "string", Description: "all, open, completed or overdue"},
+			{Name: "priority", Type: "string", Description: "low, medium or high"},
+			{Name: "due_before", Type: "string", Format: "date-time"},
+			{Name: "due_after", Type: "string", Format: "date-time"},
+			{Name: "tags", Type: "string", Description: "Comma separated, todos must have all of them"},
+			fieldsParam,
+		},
+		Response: openapi.List{Items: models.Todo{}},
+		Security: openapi.BearerAuth,
+	})
+	spec.Describe(todos.Search, openapi.Operation{
+		Summary: "Full-text search of todos", Tags: []string{"todos"},
+		Query:    append([]openapi.Param{{Name: "q", Type: "string", Required: true}}, pageParams...),
+		Response: openapi.List{Items: models.TodoSearchResult{}},
+		Security: openapi.BearerAuth,
+	})
+	spec.Describe(todos.Trash, openapi.Operation{
+		Summary: "List deleted todos", Tags: []string{"todos"},
+		Query:    append([]openapi.Param{fieldsParam}, pageParams...),
+		Response: openapi.List{Items: models.Todo{}},
+		Security: openapi.BearerAuth,
+	})
+	spec.Describe(todos.Get, openapi.Operation{
+		Summary: "Get a todo", Tags: []string{"todos"},
+		Description: conditionalGet,
+		Query:       []openapi.Param{fieldsParam},
+		Response:    models.Todo{}, Security: openapi.BearerAuth,
+	})
+	spec.Describe(todos.Update, openapi.Operation{
+		Summary: "Replace a todo", Tags: []string{"todos"},
+		Request: todoRequest{}, Response: models.Todo{}, Security: openapi.BearerAuth,
+	})
+	spec.Describe(todos.Patch, openapi.Operation{
+		Summary: "Update some fields of a todo", Tags: []string{"todos"},
+		Description: "Fields left out are unchanged, a parent_id of 0 moves the todo to the top level and an " +
+			"empty recurrence stops it repeating",
+		Request: todoPatchRequest{}, Response: models.Todo{}, Security: openapi.BearerAuth,
+	})
+	spec.Describe(todos.Delete, openapi.Operation{
+		Summary: "Move a todo to the trash", Tags: []string{"todos"},
+		Status: http.StatusNoContent, Security: openapi.BearerAuth,
+	})
+	spec.Describe(todos.Restore, openapi.Operation{
+		Summary: "Restore a todo from the trash", Tags: []string{"todos"},
+		Response: models.Todo{}, Security: openapi.BearerAuth,
+	})
+	spec.Describe(todos.CreateSubtask, openapi.Operation{
+		Summary: "Create a sub-task", Tags: []string{"todos"},
+		Request: todoRequest{}, Status: http.StatusCreated, Response: models.Todo{},
+		Security: openapi.BearerAuth,
+	})
+	spec.Describe(todos.ListSubtasks, openapi.Operation{
+		Summary: "List the direct sub-tasks of a todo", Tags: []string{"todos"},
+		Query:    []openapi.Param{fieldsParam},
+		Response: openapi.List{Items: models.Todo{}}, Security: openapi.BearerAuth,
+	})
+	spec.Describe(todos.ListOccurrences, openapi.Operation{
+		Summary: "Preview the next occurrences of a repeating todo", Tags: []string{"todos"},
+		Description: "Due dates of the occurrences following the current one, empty for todos that do not " +
+			"repeat. Overdue todos continue from now",
+		Query:    []openapi.Param{{Name: "limit", Type: "integer", Description: "At most 50, 5 when omitted"}},
+		Response: []time.Time{}, Security: openapi.BearerAuth,
+	})
+	spec.Describe(remind.Set, openapi.Operation{
+		Summary: "Set a reminder on a todo", Tags: []string{"todos"},
+		Description: "remind_at is an RFC 3339 time, or a time without offset such as 2026-11-02T09:00 read " +
+			"in the timezone of the user's profile. The reminder is sent through the notification channels " +
+			"unless due date reminders are turned off, and not for completed todos",
+		Request: reminderRequest{}, Response: models.Todo{}, Security: openapi.BearerAuth,
+	})
+	spec.Describe(remind.Delete, openapi.Operation{
+		Summary: "Remove the reminder of a todo", Tags: []string{"todos"},
+		Status: http.StatusNoContent, Security: openapi.BearerAuth,
+	})
+
+	spec.Describe(shares.List, openapi.Operation{
+		Summary: "List who a todo is shared with", Tags: []string{"sharing"},
+		Description: "Shares without accepted_at are invitations the user has not accepted yet",
+		Response:    []models.TodoShare{}, Security: openapi.BearerAuth,
+	})
+	spec.Describe(shares.Create, openapi.Operation{
+		Summary: "Share a todo with another user", Tags: []string{"sharing"},
+		Description: "Invites the user with the email address as viewer or editor of the todo and its sub-tasks. " +
+			"The todo is shared once they accept. Answers 409 when the todo is already shared with them or has " +
+			"reached the collaborator limit",
+		Request: shareRequest{}, Status: http.StatusCreated, Response: models.TodoShare{},
+		Security: openapi.BearerAuth,
+	})
+	spec.Describe(shares.Update, openapi.Operation{
+		Summary: "Change the role of a collaborator", Tags: []string{"sharing"},
+		Request: shareRoleRequest{}, Response: models.TodoShare{}, Security: openapi.BearerAuth,
+	})
+	spec.Describe(shares.Delete, openapi.Operation{
+		Summary: "Stop sharing a todo with a user", Tags: []string{"sharing"},
+		Status: http.StatusNoContent, Security: openapi.BearerAuth,
+	})
+	spec.Describe(shares.ListShared, openapi.Operation{
+		Summary: "List the todos shared with the signed in user", Tags: []string{"sharing"},
+		Description: "Includes the invitations not accepted yet, most recently shared first",
+		Response:    []models.SharedTodo{}, Security: openapi.BearerAuth,
+	})
+	spec.Describe(shares.GetShared, openapi.Operation{
+		Summary: "Get a todo shared with the signed in user", Tags: []string{"sharing"},
+		Description: "Sub-tasks of a shared todo are shared too",
+		Response:    models.Todo{}, Security: openapi.BearerAuth,
+	})
+	spec.Describe(shares.PatchShared, openapi.Operation{
+		Summary: "Update some fields of a todo shared with the signed in user", Tags: []string{"sharing"},
+		Description: "Only editors may change shared todos, viewers get 403. parent_id and tags belong to the " +
+			"owner and cannot be changed",
+		Request: todoPatchRequest{}, Response: models.Todo{}, Security: openapi.BearerAuth,
+	})
+	spec.Describe(shares.ListSharedSubtasks, openapi.Operation{
+		Summary: "List the direct sub-tasks of a todo shared with the signed in user", Tags: []string{"sharing"},
+		Response: []models.Todo{}, Security: openapi.BearerAuth,
+	})
+	spec.Describe(shares.Accept, openapi.Operation{
+		Summary: "Accept the invitation to a shared todo", Tags: []string{"sharing"},
+		Response: models.SharedTodo{}, Security: openapi.BearerAuth,
+	})
+	spec.Describe(shares.Leave, openapi.Operation{
+		Summary: "Decline the invitation to a todo or stop collaborating on it", Tags: []string{"sharing"},
+		Status: http.StatusNoContent, Security: openapi.BearerAuth,
+	})
+
+	spec.Describe(todosV2.Create, openapi.Operation{
+		Summary: "Create a todo", Tags: []string{"todos v2"},
+		Request: todoV2Request{}, Status: http.StatusCreated, Response: todoV2{},
+		Security: openapi.BearerAuth,
+	})
+	spec.Describe(todosV2.List, openapi.Operation{
+		Summary: "List todos, newest first", Tags: []string{"todos v2"},
+		Query: []openapi.Param{
+			{Name: "cursor", Type: "string", Description: "next_cursor or prev_cursor of the previous page"},
+			{Name: "limit", Type: "integer"},
+			{Name: "status", Type: "string", Description: "all, open, completed or overdue"},
+			{Name: "priority", Type: "string", Description: "low, medium or high"},
+			{Name: "due_before", Type: "string", Format: "date-time"},
+			{Name: "due_after", Type: "string", Format: "date-time"},
+			{Name: "tags", Type: "string", Description: "Comma separated, todos must have all of them"},
+		},
+		Response: openapi.List{Items: todoV2{}},
+		Security: openapi.BearerAuth,
+	})
+	spec.Describe(todosV2.Get, openapi.Operation{
+		Summary: "Get a todo", Tags: []string{"todos v2"},
+		Description: conditionalGet,
+		Response:    todoV2{}, Security: openapi.BearerAuth,
+	})
+	spec.Describe(todosV2.Update, openapi.Operation{
+		Summary: "Replace a todo", Tags: []string{"todos v2"},
+		Request: todoV2Request{}, Response: todoV2{}, Security: openapi.BearerAuth,
+	})
+	spec.Describe(todosV2.Delete, openapi.Operation{
+		Summary: "Move a todo to the trash", Tags: []string{"todos v2"},
+		Status: http.StatusNoContent, Security: openapi.BearerAuth,
+	})
+
+	spec.Describe(files.Upload, openapi.Operation{
+		Summary: "Attach a file to a todo", Tags: []string{"attachments"},
+		Description: "Send the file in the file field of a multipart/form-data body. Its type is detected " +
+			"from its content and must be one of the allowed upload types",
+		Status: http.StatusCreated, Response: models.Attachment{}, Security: openapi.BearerAuth,
+	})
+	spec.Describe(files.List, openapi.Operation{
+		Summary: "List the attachments of a todo, oldest first", Tags: []string{"attachments"},
+		Response: openapi.List{Items: models.Attachment{}}, Security: openapi.BearerAuth,
+	})
+	spec.Describe(files.Download, openapi.Operation{
+		Summary: "Download an attachment", Tags: []string{"attachments"},
+		ContentType: "application/octet-stream", Response: &openapi.Schema{Type: "string", Format: "binary"},
+		Security: openapi.BearerAuth,
+	})
+	spec.Describe(files.Delete, openapi.Operation{
+		Summary: "Delete an attachment", Tags: []string{"attachments"},
+		Status: http.StatusNoContent, Security: openapi.BearerAuth,
+	})
+	spec.Describe(files.PresignUpload, openapi.Operation{
+		Summary: "Start a direct upload to blob storage", Tags: []string{"attachments"},
+		Description: "Creates a pending attachment and returns the request uploading the file, send it with " +
+			"the returned headers before it expires and then confirm the upload. Only available with S3 storage",
+		Request: presignUploadRequest{}, Status: http.StatusCreated, Response: presignUploadResponse{},
+		Security: openapi.BearerAuth,
+	})
+	spec.Describe(files.Confirm, openapi.Operation{
+		Summary: "Confirm a direct upload", Tags: []string{"attachments"},
+		Description: "Checks the uploaded file like a regular upload and makes the attachment visible. " +
+			"Files that fail the checks are deleted with their attachment",
+		Response: models.Attachment{}, Security: openapi.BearerAuth,
+	})
+	spec.Describe(files.PresignDownload, openapi.Operation{
+		Summary: "Get a direct download URL for an attachment", Tags: []string{"attachments"},
+		Response: presignedRequest{}, Security: openapi.BearerAuth,
+	})
+
+	spec.Describe(profile.Get, openapi.Operation{
+		Summary: "Get the profile of the signed in user", Tags: []string{"profile"},
+		Response: models.Profile{}, Security: openapi.BearerAuth,
+	})
+	spec.Describe(profile.Update, openapi.Operation{
+		Summary: "Update the profile of the signed in user", Tags: []string{"profile"},
+		Description: "Only the fields sent are changed. avatar_id names one of the user's image attachments, 0 " +
+			"removes the avatar. notifications only change the settings they name",
+		Request: profileRequest{}, Response: models.Profile{}, Security: openapi.BearerAuth,
+	})
+	spec.Describe(account.Delete, openapi.Operation{
+		Summary: "Delete the account of the signed in user", Tags: []string{"profile"},
+		Description: "The account and all of its data are erased at deletion_scheduled_at, after the configured " +
+			"grace period. Until then the account keeps working and the deletion can be cancelled",
+		Status: http.StatusAccepted, Response: deletionResponse{}, Security: openapi.BearerAuth,
+	})
+	spec.Describe(account.CancelDeletion, openapi.Operation{
+		Summary: "Cancel the scheduled deletion of the account", Tags: []string{"profile"},
+		Status: http.StatusNoContent, Security: openapi.BearerAuth,
+	})
+	spec.Describe(account.Export, openapi.Operation{
+		Summary: "Export all data stored about the account", Tags: []string{"profile"},
+		Description: "A JSON archive of the profile, todos including the trash, tags, attachment metadata, webhooks, " +
+			"notification channels and the audit and security events is built in the background, its download " +
+			"link is emailed. " +
+			"Answers 503 when the task queue is disabled",
+		Status: http.StatusAccepted, Response: messageResponse{}, Security: openapi.BearerAuth,
+	})
+	spec.Describe(notify.List, openapi.Operation{
+		Summary: "List the notification channels of the signed in user", Tags: []string{"profile"},
+		Description: "The email channel is listed enabled until the user changes it, notifications are sent to " +
+			"every enabled channel. Which notifications are sent follows the notification preferences of the profile",
+		Response: []models.NotificationChannel{}, Security: openapi.BearerAuth,
+	})
+	spec.Describe(notify.Set, openapi.Operation{
+		Summary: "Add or change a notification channel", Tags: []string{"profile"},
+		Description: "channel is email, webhook or slack. The webhook and Slack channels need a url, Slack URLs must " +
+			"be incoming webhooks. Adding a channel answers 201 and, for webhooks, carries the secret signing " +
+			"its requests like webhook deliveries",
+		Request: channelRequest{}, Response: channelCreatedResponse{}, Security: openapi.BearerAuth,
+	})
+	spec.Describe(notify.Delete, openapi.Operation{
+		Summary: "Remove a notification channel", Tags: []string{"profile"},
+		Description: "Removing the email channel restores emailing the account address, disable it to stop emails",
+		Status:      http.StatusNoContent, Security: openapi.BearerAuth,
+	})
+
+	spec.Describe(tags.Create, openapi.Operation{
+		Summary: "Create a tag", Tags: []string{"tags"},
+		Request: tagRequest{}, Status: http.StatusCreated, Response: models.Tag{},
+		Security: openapi.BearerAuth,
+	})
+	spec.Describe(tags.List, openapi.Operation{
+		Summary: "List tags", Tags: []string{"tags"},
+		Response: openapi.List{Items: models.Tag{}}, Security: openapi.BearerAuth,
+	})
+	spec.Describe(tags.Get, openapi.Operation{
+		Summary: "Get a tag", Tags: []string{"tags"},
+		Response: models.Tag{}, Security: openapi.BearerAuth,
+	})
+	spec.Describe(tags.Rename, openapi.Operation{
+		Summary: "Rename a tag", Tags: []string{"tags"},
+		Request: tagRequest{}, Response: models.Tag{}, Security: openapi.BearerAuth,
+	})
+	spec.Describe(tags.Delete, openapi.Operation{
+		Summary: "Delete a tag and detach it from all todos", Tags: []string{"tags"},
+		Status: http.StatusNoContent, Security: openapi.BearerAuth,
+	})
+
+	spec.Describe(hooks.Create, openapi.Operation{
+		Summary: "Register a webhook", Tags: []string{"webhooks"},
+		Description: "Todo events of the subscribed types, or all of them when events is empty, are POSTed to the URL. " +
+			"Each delivery is signed in the X-Webhook-Signature header as t=<unix time>,v1=<hex HMAC-SHA256 of " +
+			"\"<t>.<body>\"> with the secret, which is generated when omitted and only returned here",
+		Request: webhookRequest{}, Status: http.StatusCreated, Response: webhookCreatedResponse{},
+		Security: openapi.BearerAuth,
+	})
+	spec.Describe(hooks.List, openapi.Operation{
+		Summary: "List webhooks, oldest first", Tags: []string{"webhooks"},
+		Response: openapi.List{Items: models.Webhook{}}, Security: openapi.BearerAuth,
+	})
+	spec.Describe(hooks.Get, openapi.Operation{
+		Summary: "Get a webhook", Tags: []string{"webhooks"},
+		Description: conditionalGet,
+		Response:    models.Webhook{}, Security: openapi.BearerAuth,
+	})
+	spec.Describe(hooks.Update, openapi.Operation{
+		Summary: "Update a webhook", Tags: []string{"webhooks"},
+		Description: "Only the fields present are changed, inactive webhooks receive no deliveries",
+		Request:     webhookPatchRequest{}, Response: models.Webhook{}, Security: openapi.BearerAuth,
+	})
+	spec.Describe(hooks.Delete, openapi.Operation{
+		Summary: "Delete a webhook and its delivery history", Tags: []string{"webhooks"},
+		Status: http.StatusNoContent, Security: openapi.BearerAuth,
+	})
+	spec.Describe(hooks.Deliveries, openapi.Operation{
+		Summary: "List the deliveries of a webhook, newest first", Tags: []string{"webhooks"},
+		Query:    pageParams,
+		Response: openapi.List{Items: models.WebhookDelivery{}},
+		Security: openapi.BearerAuth,
+	})
+	spec.Describe(hooks.Redeliver, openapi.Operation{
+		Summary: "Send a past delivery again", Tags: []string{"webhooks"},
+		Status: http.StatusAccepted, Response: models.WebhookDelivery{}, Security: openapi.BearerAuth,
+	})
+
+	spec.Describe(inbound.Receive, openapi.Operation{
+		Summary: "Receive a webhook from a third party", Tags: []string{"webhooks"},
+		Description: "The request is authenticated by the provider's signature: X-Hub-Signature-256 for github, " +
+			"Stripe-Signature for stripe and the configured header for the generic HMAC provider. Answers 202 " +
+			"once the event is queued, or 204 once it was handled when there is no task queue",
+		Request: &openapi.Schema{Type: "object"}, Status: http.StatusAccepted,
+	})
+
+	scimUsers := "SCIM 2.0 users, userName is the email of the account. Errors use the SCIM error schema"
+	spec.Describe(scim.ServiceProviderConfig, openapi.Operation{
+		Summary: "Describe the supported SCIM features", Tags: []string{"scim"},
+		Response: scimProviderConfig{}, ContentType: scimContentType, Raw: true, Security: openapi.SCIMAuth,
+	})
+	spec.Describe(scim.List, openapi.Operation{
+		Summary: "List provisioned users", Tags: []string{"scim"},
+		Description: scimUsers + `. filter supports "eq" comparisons of userName, externalId and emails.value ` +
+			`joined by "and"`,
+		Query: []openapi.Param{
+			{Name: "filter", Type: "string"},
+			{Name: "startIndex", Type: "integer", Description: "1-based index of the first user"},
+			{Name: "count", Type: "integer", Description: "Users per page, at most auth.scim.max_results"},
+		},
+		Response: scimListResponse{}, ContentType: scimContentType, Raw: true, Security: openapi.SCIMAuth,
+	})
+	spec.Describe(scim.Create, openapi.Operation{
+		Summary: "Provision a user", Tags: []string{"scim"}, Description: scimUsers,
+		Request: scimUser{}, Status: http.StatusCreated, Response: scimUser{},
+		ContentType: scimContentType, Raw: true, Security: openapi.SCIMAuth,
+	})
+	spec.Describe(scim.Get, openapi.Operation{
+		Summary: "Get a provisioned user", Tags: []string{"scim"}, Description: scimUsers,
+		Response: scimUser{}, ContentType: scimContentType, Raw: true, Security: openapi.SCIMAuth,
+	})
+	spec.Describe(scim.Replace, openapi.Operation{
+		Summary: "Replace a provisioned user", Tags: []string{"scim"},
+		Description: scimUsers + ". Setting active to false deactivates the account and revokes its sessions",
+		Request:     scimUser{}, Response: scimUser{}, ContentType: scimContentType, Raw: true, Security: openapi.SCIMAuth,
+	})
+	spec.Describe(scim.Patch, openapi.Operation{
+		Summary: "Patch a provisioned user", Tags: []string{"scim"},
+		Description: "Supports add, replace and remove of active, userName, externalId, displayName and name, " +
+			"with a path or an object of attributes",
+		Request: scimPatchRequest{}, Response: scimUser{}, ContentType: scimContentType, Raw: true, Security: openapi.SCIMAuth,
+	})
+	spec.Describe(scim.Deactivate, openapi.Operation{
+		Summary: "Deactivate a provisioned user", Tags: []string{"scim"},
+		Description: "The account is kept, deactivated, and its sessions are revoked",
+		Status:      http.StatusNoContent, Security: openapi.SCIMAuth,
+	})
+
+	spec.Describe(quotas.Usage, openapi.Operation{
+		Summary: "Get the quota usage of the current user", Tags: []string{"quotas"},
+		Description: "Zero limits are unlimited. Creating todos or storing attachments over a limit fails with " +
+			"402 quota_exceeded, API calls over the daily limit with 429 until resets_at",
+		Response: service.QuotaReport{}, Security: openapi.BearerAuth,
+	})
+	spec.Describe(quotas.UserUsage, openapi.Operation{
+		Summary: "Get the quota usage and override of a user", Tags: []string{"admin"},
+		Response: service.QuotaReport{}, Security: openapi.AdminAuth,
+	})
+	spec.Describe(quotas.SetOverride, openapi.Operation{
+		Summary: "Override the quota limits of a user", Tags: []string{"admin"},
+		Description: "Omitted limits keep the configured ones and 0 lifts a limit",
+		Request:     quotaOverrideRequest{}, Response: models.QuotaOverride{}, Security: openapi.AdminAuth,
+	})
+	spec.Describe(quotas.ClearOverride, openapi.Operation{
+		Summary: "Restore the configured quota limits of a user", Tags: []string{"admin"},
+		Status: http.StatusNoContent, Security: openapi.AdminAuth,
+	})
+
+	spec.Describe(exports.Export, openapi.Operation{
+		Summary: "Export todos as a file", Tags: []string{"todos"},
+		Description: "Streams the todos matching the filters, with async=true the file is built in the " +
+			"background and a download link is emailed instead, answering 202 with a message",
+		Query: []openapi.Param{
+			{Name: "format", Type: "string", Description: "csv (default), json or xlsx"},
+			{Name: "status", Type: "string", Description: "all, open, completed or overdue"},
+			{Name: "priority", Type: "string", Description: "low, medium or high"},
+			{Name: "due_before", Type: "string", Format: "date-time"},
+			{Name: "due_after", Type: "string", Format: "date-time"},
+			{Name: "tags", Type: "string", Description: "Comma separated, todos must have all of them"},
+			{Name: "async", Type: "boolean", Description: "Email a download link instead of streaming"},
+		},
+		ContentType: "application/octet-stream", Response: &openapi.Schema{Type: "string", Format: "binary"},
+		Security: openapi.BearerAuth,
+	})
+	spec.Describe(exports.Download, openapi.Operation{
+		Summary: "Download an asynchronous export", Tags: []string{"todos"},
+		Query:       []openapi.Param{{Name: "token", Type: "string", Required: true}},
+		ContentType: "application/octet-stream", Response: &openapi.Schema{Type: "string", Format: "binary"},
+	})
+
+	spec.Describe(events.Stream, openapi.Operation{
+		Summary: "Stream todo changes as Server-Sent Events", Tags: []string{"events"},
+		Description: "Send Last-Event-ID to resume after a reconnect, a reset event means changes were missed",
+		ContentType: "text/event-stream", Response: &openapi.Schema{Type: "string"},
+		Security: openapi.BearerAuth,
+	})
+	spec.Describe(gql.Serve, openapi.Operation{
+		Summary: "Execute a GraphQL operation", Tags: []string{"graphql"},
+		Description: "Send Accept: text/event-stream to receive subscription results as Server-Sent Events",
+		Request:     graphqlRequest{}, Response: &openapi.Schema{Type: "object"}, Raw: true,
+		Security: openapi.BearerAuth,
+	})
+
+	spec.Describe(schemas.List, openapi.Operation{
+		Summary: "List the JSON Schemas of request bodies", Tags: []string{"schemas"},
+		Response: schemaList{}, Raw: true,
+	})
+	spec.Describe(schemas.Get, openapi.Operation{
+		Summary: "Get a JSON Schema", Tags: []string{"schemas"},
+		Description: "Request bodies breaking the schema of their route are rejected with 400 validation_failed " +
+			"and the violations, each with the JSON Pointer of the offending value",
+		ContentType: schemaMIME, Response: &openapi.Schema{Type: "object"},
+	})
+}
+
+// DocsHandler serves the OpenAPI document and a Swagger UI rendering it
+type DocsHandler struct {
+	spec []byte
+}
+
+// NewDocsHandler encodes doc once, the routes do not change after startup
+func NewDocsHandler(doc *openapi.Document) (*DocsHandler, error) {
+	spec, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode openapi document: %w", err)
+	}
+	return &DocsHandler{spec: spec}, nil
+}
+
+// Spec handles GET /openapi.json
+func (h *DocsHandler) Spec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json", h.spec)
+}
+
+// UI handles GET /docs
+func (h *DocsHandler) UI(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUI))
+}
+
+const swaggerUI = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>API documentation</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+    };
+  </script>
+</body>
+</html>
+`
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ProfileHandler serves the profile of the signed in user
+type ProfileHandler struct {
+	service *service.ProfileService
+}
+
+func NewProfileHandler(service *service.ProfileService) *ProfileHandler {
+	return &ProfileHandler{service: service}
+}
+
+// profileRequest removes the avatar when avatar_id is 0, notifications only
+// change the settings they name
+type profileRequest struct {
+	Name          *string         `json:"name" binding:"omitempty,max=255"`
+	Timezone      *string         `json:"timezone" binding:"omitempty,max=64"`
+	AvatarID      *int64          `json:"avatar_id" binding:"omitempty,min=0"`
+	Notifications map[string]bool `json:"notifications" binding:"max=20"`
+}
+
+// RegisterRoutes mounts the profile endpoints on the given group
+func (h *ProfileHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("", h.Get)
+	rg.PATCH("", h.Update)
+}
+
+// Get handles GET /me
+func (h *ProfileHandler) Get(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	profile, err := h.service.Get(c.Request.Context(), userID)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, profile)
+}
+
+// Update handles PATCH /me
+func (h *ProfileHandler) Update(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req profileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	profile, err := h.service.Update(c.Request.Context(), userID, service.ProfileInput{
+		Name:          req.Name,
+		Timezone:      req.Timezone,
+		AvatarID:      req.AvatarID,
+		Notifications: req.Notifications,
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, profile)
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	runtimepprof "runtime/pprof"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/apierror"
+	"github.com/MuthuM3/gin-microservice-template/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterProfiling mounts the net/http/pprof handlers on rg, which must be
+// protected since profiles expose the internals of the process
+func RegisterProfiling(rg *gin.RouterGroup) {
+	rg.GET("/", gin.WrapF(pprof.Index))
+	rg.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+	rg.GET("/profile", gin.WrapF(pprof.Profile))
+	rg.GET("/symbol", gin.WrapF(pprof.Symbol))
+	rg.POST("/symbol", gin.WrapF(pprof.Symbol))
+	rg.GET("/trace", gin.WrapF(pprof.Trace))
+
+	// pprof.Index only resolves profile names below /debug/pprof/, so the
+	// named profiles are mounted explicitly to work under any path
+	for _, profile := range runtimepprof.Profiles() {
+		rg.GET("/"+profile.Name(), gin.WrapH(pprof.Handler(profile.Name())))
+	}
+
+	rg.GET("/dump/:kind", Dump)
+}
+
+// Dump handles GET /dump/:kind, downloading a heap profile taken right after
+// a garbage collection or the stack traces of all goroutines
+func Dump(c *gin.Context) {
+	kind := c.Param("kind")
+
+	var (
+		profile *runtimepprof.Profile
+		debug   int
+		ext     string
+	)
+	switch kind {
+	case "heap":
+		// Report live objects only, not garbage waiting to be collected
+		runtime.GC()
+		profile, ext = runtimepprof.Lookup("heap"), "pb.gz"
+	case "goroutine":
+		profile, debug, ext = runtimepprof.Lookup("goroutine"), 2, "txt"
+	default:
+		middleware.AbortWithError(c, apierror.NotFound("unknown dump "+kind))
+		return
+	}
+
+	filename := fmt.Sprintf("%s-%s.%s", kind, time.Now().UTC().Format("20060102T150405Z"), ext)
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Header("Content-Type", "application/octet-stream")
+	c.Status(http.StatusOK)
+	if err := profile.WriteTo(c.Writer, debug); err != nil {
+		c.Error(err)
+	}
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// QueueHandler serves the task queue inspection endpoints
+type QueueHandler struct {
+	queues *service.QueueService
+}
+
+func NewQueueHandler(queues *service.QueueService) *QueueHandler {
+	return &QueueHandler{queues: queues}
+}
+
+// RegisterRoutes mounts the queue endpoints on rg
+func (h *QueueHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/queues", h.Stats)
+	rg.GET("/queues/:queue/dead", h.ListDead)
+	rg.POST("/queues/:queue/dead/:id/retry", h.RetryDead)
+	rg.DELETE("/queues/:queue/dead/:id", h.DeleteDead)
+}
+
+// Stats handles GET /admin/queues
+func (h *QueueHandler) Stats(c *gin.Context) {
+	stats, err := h.queues.Stats(c.Request.Context())
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, stats)
+}
+
+// ListDead handles GET /admin/queues/:queue/dead?page=&page_size=
+func (h *QueueHandler) ListDead(c *gin.Context) {
+	page, ok := queryInt(c, "page", 1)
+	if !ok {
+		return
+	}
+	pageSize, ok := queryInt(c, "page_size", 0)
+	if !ok {
+		return
+	}
+
+	result, err := h.queues.ListDead(c.Request.Context(), c.Param("queue"), page, pageSize)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respondPage(c, result.Tasks, Pagination{
+		Page:       result.Page,
+		PageSize:   result.PageSize,
+		Total:      result.Total,
+		TotalPages: result.TotalPages(),
+	})
+}
+
+// RetryDead handles POST /admin/queues/:queue/dead/:id/retry
+func (h *QueueHandler) RetryDead(c *gin.Context) {
+	if err := h.queues.RetryDead(c.Request.Context(), c.Param("queue"), c.Param("id")); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// DeleteDead handles DELETE /admin/queues/:queue/dead/:id
+func (h *QueueHandler) DeleteDead(c *gin.Context) {
+	if err := h.queues.DeleteDead(c.Request.Context(), c.Param("queue"), c.Param("id")); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
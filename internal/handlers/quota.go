@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// QuotaHandler reports quota usage and manages the per-user overrides
+type QuotaHandler struct {
+	quotas *service.QuotaService
+}
+
+func NewQuotaHandler(quotas *service.QuotaService) *QuotaHandler {
+	return &QuotaHandler{quotas: quotas}
+}
+
+type quotaOverrideRequest struct {
+	MaxTodos           *int   `json:"max_todos" binding:"omitempty,min=0"`
+	MaxAttachmentBytes *int64 `json:"max_attachment_bytes" binding:"omitempty,min=0"`
+	MaxAPICallsPerDay  *int   `json:"max_api_calls_per_day" binding:"omitempty,min=0"`
+}
+
+// RegisterRoutes mounts the usage endpoint on rg and, when admin is not nil,
+// the override endpoints on admin
+func (h *QuotaHandler) RegisterRoutes(rg, admin *gin.RouterGroup) {
+	rg.GET("", h.Usage)
+
+	if admin != nil {
+		admin.GET("/users/:id/quota", h.UserUsage)
+		admin.PUT("/users/:id/quota", h.SetOverride)
+		admin.DELETE("/users/:id/quota", h.ClearOverride)
+	}
+}
+
+// Usage handles GET /quota
+func (h *QuotaHandler) Usage(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.quotas.Report(c.Request.Context(), userID)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, report)
+}
+
+// UserUsage handles GET /admin/users/:id/quota
+func (h *QuotaHandler) UserUsage(c *gin.Context) {
+	userID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	report, err := h.quotas.AdminReport(c.Request.Context(), userID)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, report)
+}
+
+// SetOverride handles PUT /admin/users/:id/quota, omitted limits keep the
+// configured ones and 0 lifts a limit
+func (h *QuotaHandler) SetOverride(c *gin.Context) {
+	userID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req quotaOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	override := &models.QuotaOverride{
+		UserID:             userID,
+		MaxTodos:           req.MaxTodos,
+		MaxAttachmentBytes: req.MaxAttachmentBytes,
+		MaxAPICallsPerDay:  req.MaxAPICallsPerDay,
+	}
+	if err := h.quotas.SetOverride(c.Request.Context(), override); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, override)
+}
+
+// ClearOverride handles DELETE /admin/users/:id/quota
+func (h *QuotaHandler) ClearOverride(c *gin.Context) {
+	userID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.quotas.ClearOverride(c.Request.Context(), userID); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ReminderHandler serves the reminders users set on their todos
+type ReminderHandler struct {
+	service *service.ReminderService
+}
+
+func NewReminderHandler(service *service.ReminderService) *ReminderHandler {
+	return &ReminderHandler{service: service}
+}
+
+type reminderRequest struct {
+	RemindAt string `json:"remind_at" binding:"required"`
+}
+
+// RegisterRoutes mounts the reminder endpoints on the todos group
+func (h *ReminderHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.PUT("/:id/reminder", h.Set)
+	rg.DELETE("/:id/reminder", h.Delete)
+}
+
+// Set handles PUT /todos/:id/reminder
+func (h *ReminderHandler) Set(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req reminderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	todo, err := h.service.Set(c.Request.Context(), userID, id, req.RemindAt)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, todo)
+}
+
+// Delete handles DELETE /todos/:id/reminder
+func (h *ReminderHandler) Delete(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Clear(c.Request.Context(), userID, id); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
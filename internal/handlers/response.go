@@ -0,0 +1,321 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/apierror"
+	"github.com/MuthuM3/gin-microservice-template/internal/capture"
+	"github.com/MuthuM3/gin-microservice-template/internal/i18n"
+	"github.com/MuthuM3/gin-microservice-template/internal/middleware"
+	"github.com/MuthuM3/gin-microservice-template/internal/queue"
+	"github.com/MuthuM3/gin-microservice-template/internal/render"
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"github.com/MuthuM3/gin-microservice-template/internal/validation"
+	"github.com/MuthuM3/gin-microservice-template/internal/webhook"
+	"github.com/gin-gonic/gin"
+)
+
+// Pagination describes the position of a page within a list
+type Pagination struct {
+	Page       int `json:"page"`
+	PageSize   int `json:"page_size"`
+	Total      int `json:"total"`
+	TotalPages int `json:"total_pages"`
+}
+
+// CursorPagination links to the neighbouring pages of a cursor paginated
+// list, a cursor is omitted when there is no page in that direction
+type CursorPagination struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// Envelope is the body of every successful response. Data holds the resource
+// or the items of a list, Meta and Links are only set on paginated lists
+type Envelope struct {
+	Data  any    `json:"data"`
+	Meta  *Meta  `json:"meta,omitempty"`
+	Links *Links `json:"links,omitempty"`
+}
+
+// Meta describes the position of a page, Pagination is set on offset
+// paginated lists and Cursor on cursor paginated ones
+type Meta struct {
+	Pagination *Pagination       `json:"pagination,omitempty"`
+	Cursor     *CursorPagination `json:"cursor,omitempty"`
+}
+
+// Links are the URLs of a page and its neighbours, relative to the host. A
+// link is omitted when there is no such page
+type Links struct {
+	Self  string `json:"self"`
+	First string `json:"first,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+	Last  string `json:"last,omitempty"`
+}
+
+// respond renders data in the envelope, in the format negotiated with the
+// client
+func respond(c *gin.Context, status int, data any) {
+	middleware.Render(c, status, Envelope{Data: data})
+}
+
+// respondPage renders a page of an offset paginated list
+func respondPage(c *gin.Context, data any, pagination Pagination) {
+	links := &Links{Self: c.Request.URL.RequestURI()}
+	if pagination.TotalPages > 0 {
+		links.First = pageLink(c, "page", "1")
+		links.Last = pageLink(c, "page", strconv.Itoa(pagination.TotalPages))
+	}
+	if pagination.Page > 1 {
+		links.Prev = pageLink(c, "page", strconv.Itoa(min(pagination.Page-1, max(pagination.TotalPages, 1))))
+	}
+	if pagination.Page < pagination.TotalPages {
+		links.Next = pageLink(c, "page", strconv.Itoa(pagination.Page+1))
+	}
+
+	middleware.Render(c, http.StatusOK, Envelope{
+		Data:  data,
+		Meta:  &Meta{Pagination: &pagination},
+		Links: links,
+	})
+}
+
+// respondCursor renders a page of a cursor paginated list
+func respondCursor(c *gin.Context, data any, pagination CursorPagination) {
+	links := &Links{Self: c.Request.URL.RequestURI()}
+	if pagination.PrevCursor != "" {
+		links.Prev = pageLink(c, "cursor", pagination.PrevCursor)
+	}
+	if pagination.NextCursor != "" {
+		links.Next = pageLink(c, "cursor", pagination.NextCursor)
+	}
+
+	middleware.Render(c, http.StatusOK, Envelope{
+		Data:  data,
+		Meta:  &Meta{Cursor: &pagination},
+		Links: links,
+	})
+}
+
+// pageLink returns the URL of the request with the query parameter name set
+// to value
+func pageLink(c *gin.Context, name, value string) string {
+	link := *c.Request.URL
+	query := link.Query()
+	query.Set(name, value)
+	link.RawQuery = query.Encode()
+	return link.RequestURI()
+}
+
+// handleError maps service errors to API errors, storage errors and
+// anything unexpected are converted by the Errors middleware
+func handleError(c *gin.Context, err error) {
+	var locked *service.AccountLockedError
+	if errors.As(err, &locked) {
+		c.Header("Retry-After", strconv.Itoa(retryAfterSeconds(locked)))
+	}
+	middleware.AbortWithError(c, serviceError(err))
+}
+
+// serviceError converts the errors returned by the services to API errors,
+// other errors are returned unchanged
+func serviceError(err error) error {
+	var (
+		locked   *service.AccountLockedError
+		invalid  *service.FieldError
+		tooLarge *service.UploadTooLargeError
+		exceeded *service.QuotaExceededError
+	)
+	switch {
+	case errors.As(err, &locked):
+		err = apierror.New(http.StatusLocked, "account_locked", locked.Error()).WithDetails(gin.H{
+			"locked_until":        locked.Until.UTC(),
+			"retry_after_seconds": retryAfterSeconds(locked),
+		})
+	case errors.Is(err, service.ErrInvalidCredentials):
+		err = apierror.New(http.StatusUnauthorized, "invalid_credentials", service.ErrInvalidCredentials.Error()).Wrap(err)
+	case errors.Is(err, service.ErrInvalidRefreshToken):
+		err = apierror.New(http.StatusUnauthorized, "invalid_refresh_token", service.ErrInvalidRefreshToken.Error()).Wrap(err)
+	case errors.Is(err, service.ErrInvalidResetToken):
+		err = apierror.BadRequest("invalid_reset_token", service.ErrInvalidResetToken.Error()).Wrap(err)
+	case errors.Is(err, service.ErrInvalidVerificationToken):
+		err = apierror.BadRequest("invalid_verification_token", service.ErrInvalidVerificationToken.Error()).Wrap(err)
+	case errors.Is(err, service.ErrIdentityConflict):
+		err = apierror.New(http.StatusConflict, "account_exists", service.ErrIdentityConflict.Error()).Wrap(err)
+	case errors.Is(err, service.ErrEmailNotVerified):
+		err = apierror.New(http.StatusForbidden, "email_not_verified", service.ErrEmailNotVerified.Error()).Wrap(err)
+	case errors.Is(err, service.ErrAccountDeactivated):
+		err = apierror.New(http.StatusForbidden, "account_deactivated", service.ErrAccountDeactivated.Error()).Wrap(err)
+	case errors.As(err, &invalid):
+		err = apierror.Validation(invalid.Error()).WithDetails(gin.H{
+			"fields": map[string][]string{invalid.Field: {invalid.Message}},
+		})
+	case errors.Is(err, service.ErrInvalidInput):
+		err = apierror.Validation(err.Error())
+	case errors.As(err, &tooLarge):
+		err = apierror.RequestTooLarge(tooLarge.Limit).Wrap(err)
+	case errors.Is(err, service.ErrUnsupportedFileType):
+		err = apierror.New(http.StatusUnsupportedMediaType, "unsupported_file_type", err.Error()).Wrap(err)
+	case errors.Is(err, service.ErrUploadIncomplete):
+		err = apierror.New(http.StatusConflict, "upload_incomplete", service.ErrUploadIncomplete.Error()).Wrap(err)
+	case errors.Is(err, service.ErrPresignUnsupported):
+		err = apierror.New(http.StatusNotImplemented, "presign_unsupported", service.ErrPresignUnsupported.Error()).Wrap(err)
+	case errors.As(err, &exceeded):
+		err = apierror.New(http.StatusPaymentRequired, "quota_exceeded", exceeded.Error()).WithDetails(gin.H{
+			"resource": exceeded.Resource,
+			"limit":    exceeded.Limit,
+		})
+	case errors.Is(err, service.ErrExportUnavailable):
+		err = apierror.New(http.StatusServiceUnavailable, "export_unavailable", service.ErrExportUnavailable.Error()).Wrap(err)
+	case errors.Is(err, service.ErrTooManyWebhooks):
+		err = apierror.New(http.StatusConflict, "webhook_limit_reached", err.Error()).Wrap(err)
+	case errors.Is(err, service.ErrReadOnlyShare):
+		err = apierror.New(http.StatusForbidden, "read_only_share", service.ErrReadOnlyShare.Error()).Wrap(err)
+	case errors.Is(err, service.ErrTooManyCollaborators):
+		err = apierror.New(http.StatusConflict, "collaborator_limit_reached", err.Error()).Wrap(err)
+	case errors.Is(err, service.ErrUnknownProvider):
+		err = apierror.NotFound("webhook provider not found").Wrap(err)
+	case errors.Is(err, webhook.ErrInvalidSignature):
+		err = apierror.New(http.StatusUnauthorized, "invalid_signature", webhook.ErrInvalidSignature.Error()).Wrap(err)
+	case errors.Is(err, webhook.ErrInvalidPayload):
+		err = apierror.BadRequest("invalid_payload", webhook.ErrInvalidPayload.Error()).Wrap(err)
+	case errors.Is(err, queue.ErrUnknownQueue):
+		err = apierror.NotFound("queue not found").Wrap(err)
+	case errors.Is(err, queue.ErrNotFound):
+		err = apierror.NotFound("task not found").Wrap(err)
+	case errors.Is(err, capture.ErrNotFound):
+		err = apierror.NotFound("capture not found").Wrap(err)
+	}
+	return err
+}
+
+func retryAfterSeconds(locked *service.AccountLockedError) int {
+	return int(math.Ceil(time.Until(locked.Until).Seconds()))
+}
+
+// invalidRequest aborts with the error from binding the request body. Failed
+// rules are reported per field in the client's language and bodies cut off by
+// the size limit as 413
+func invalidRequest(c *gin.Context, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		middleware.AbortWithError(c, err)
+		return
+	}
+	if violations, ok := validation.FromBindError(err); ok {
+		loc := i18n.FromContext(c.Request.Context())
+		middleware.AbortWithError(c, apierror.Validation(loc.T("error.validation_failed")).WithDetails(gin.H{
+			"fields": validation.Localize(violations, loc),
+		}))
+		return
+	}
+	middleware.AbortWithError(c, apierror.BadRequest("invalid_request", "invalid request body").WithDetails(err.Error()))
+}
+
+// currentUserID returns the authenticated user's id, responding with 401 when
+// the request has not been authenticated
+func currentUserID(c *gin.Context) (int64, bool) {
+	userID, ok := middleware.UserID(c)
+	if !ok {
+		middleware.AbortWithError(c, apierror.Unauthorized("authentication required"))
+		return 0, false
+	}
+	return userID, true
+}
+
+// parseID reads a positive integer path parameter
+func parseID(c *gin.Context, name string) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param(name), 10, 64)
+	if err != nil || id <= 0 {
+		middleware.AbortWithError(c, apierror.BadRequest("invalid_id", "invalid "+name))
+		return 0, false
+	}
+	return id, true
+}
+
+// queryInt reads an integer query parameter, falling back to def when absent
+func queryInt(c *gin.Context, name string, def int) (int, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return def, true
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		middleware.AbortWithError(c, apierror.BadRequest("invalid_query", "invalid "+name))
+		return 0, false
+	}
+	return value, true
+}
+
+// queryTime reads an RFC 3339 timestamp or a YYYY-MM-DD date (midnight UTC)
+// query parameter, returning nil when absent
+func queryTime(c *gin.Context, name string) (*time.Time, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, true
+	}
+
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if value, err := time.Parse(layout, raw); err == nil {
+			return &value, true
+		}
+	}
+
+	middleware.AbortWithError(c, apierror.BadRequest("invalid_query", "invalid "+name))
+	return nil, false
+}
+
+// queryList reads a comma separated query parameter, skipping empty items
+func queryList(c *gin.Context, name string) []string {
+	var items []string
+	for item := range strings.SplitSeq(c.Query(name), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// queryFields reads the fields query parameter selecting the JSON fields of
+// model to render, responding with 400 when one is unknown. Nil means every
+// field
+func queryFields(c *gin.Context, model any) ([]string, bool) {
+	fields := queryList(c, "fields")
+	if len(fields) == 0 {
+		return nil, true
+	}
+
+	known := render.FieldNames(model)
+	var unknown []string
+	for _, field := range fields {
+		if !slices.Contains(known, field) {
+			unknown = append(unknown, field)
+		}
+	}
+	if len(unknown) > 0 {
+		middleware.AbortWithError(c, apierror.BadRequest("invalid_query", "unknown fields: "+strings.Join(unknown, ", ")).
+			WithDetails(gin.H{"fields": known}))
+		return nil, false
+	}
+	return fields, true
+}
+
+// project keeps the selected fields of data, a resource or a list of them
+func project(c *gin.Context, data any, fields []string) (any, bool) {
+	projected, err := render.Project(data, fields)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return nil, false
+	}
+	return projected, true
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/apierror"
+	"github.com/MuthuM3/gin-microservice-template/internal/middleware"
+	"github.com/MuthuM3/gin-microservice-template/internal/schema"
+	"github.com/gin-gonic/gin"
+)
+
+// schemaMIME is the media type of JSON Schema documents
+const schemaMIME = "application/schema+json"
+
+// SchemaHandler publishes the JSON Schemas request bodies are validated
+// against, so clients can validate their payloads before sending them
+type SchemaHandler struct {
+	registry *schema.Registry
+}
+
+func NewSchemaHandler(registry *schema.Registry) *SchemaHandler {
+	return &SchemaHandler{registry: registry}
+}
+
+// schemaRef is an entry of the schema list
+type schemaRef struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	URL     string `json:"url"`
+}
+
+type schemaList struct {
+	Schemas []schemaRef `json:"schemas"`
+}
+
+// RegisterRoutes mounts the schema endpoints on the given group
+func (h *SchemaHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("", h.List)
+	rg.GET("/:name/:version", h.Get)
+}
+
+// List handles GET /schemas
+func (h *SchemaHandler) List(c *gin.Context) {
+	refs := make([]schemaRef, 0)
+	for _, key := range h.registry.Keys() {
+		name, version, _ := strings.Cut(key, "/")
+		refs = append(refs, schemaRef{Name: name, Version: version, URL: "/schemas/" + key})
+	}
+	c.JSON(http.StatusOK, schemaList{Schemas: refs})
+}
+
+// Get handles GET /schemas/:name/:version
+func (h *SchemaHandler) Get(c *gin.Context) {
+	document, ok := h.registry.Source(schema.Key(c.Param("name"), c.Param("version")))
+	if !ok {
+		middleware.AbortWithError(c, apierror.NotFound("schema not found"))
+		return
+	}
+	c.Data(http.StatusOK, schemaMIME, document)
+}
@@ -0,0 +1,567 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/apierror"
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// SCIM schema URNs
+const (
+	scimUserSchema     = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimListSchema     = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	scimErrorSchema    = "urn:ietf:params:scim:api:messages:2.0:Error"
+	scimProviderSchema = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+)
+
+// scimContentType is the media type of SCIM responses
+const scimContentType = "application/scim+json"
+
+// scimTypes are the error types of RFC 7644 used by the handler
+var scimTypes = map[string]bool{
+	"invalidFilter": true, "invalidSyntax": true, "invalidPath": true, "invalidValue": true,
+	"noTarget": true, "mutability": true,
+}
+
+// errInvalidFilter is returned for filters outside the supported subset
+var errInvalidFilter = errors.New(`filter must compare userName, externalId or emails.value with "eq", joined by "and"`)
+
+// SCIMHandler serves the subset of SCIM 2.0 identity providers use to
+// provision users: creating, reading, listing by filter, replacing,
+// patching and deactivating them. userName is the email of the account.
+// Responses and errors follow RFC 7644 rather than the API envelope
+type SCIMHandler struct {
+	service    *service.UserProvisioning
+	token      []byte
+	maxResults int
+}
+
+func NewSCIMHandler(service *service.UserProvisioning, token string, maxResults int) *SCIMHandler {
+	return &SCIMHandler{service: service, token: []byte(token), maxResults: maxResults}
+}
+
+type scimUser struct {
+	Schemas     []string    `json:"schemas"`
+	ID          string      `json:"id,omitempty"`
+	ExternalID  string      `json:"externalId,omitempty"`
+	UserName    string      `json:"userName"`
+	Name        *scimName   `json:"name,omitempty"`
+	DisplayName string      `json:"displayName,omitempty"`
+	Emails      []scimEmail `json:"emails,omitempty"`
+	Active      *bool       `json:"active,omitempty"`
+	Meta        *scimMeta   `json:"meta,omitempty"`
+}
+
+type scimName struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+type scimEmail struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type scimMeta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location"`
+}
+
+type scimListResponse struct {
+	Schemas      []string   `json:"schemas"`
+	TotalResults int        `json:"totalResults"`
+	StartIndex   int        `json:"startIndex"`
+	ItemsPerPage int        `json:"itemsPerPage"`
+	Resources    []scimUser `json:"Resources"`
+}
+
+type scimPatchRequest struct {
+	Schemas    []string             `json:"schemas"`
+	Operations []scimPatchOperation `json:"Operations" binding:"required,min=1"`
+}
+
+type scimPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+type scimErrorResponse struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail"`
+}
+
+// scimSupported is a capability of the service provider configuration
+type scimSupported struct {
+	Supported  bool `json:"supported"`
+	MaxResults int  `json:"maxResults,omitempty"`
+}
+
+type scimProviderConfig struct {
+	Schemas               []string          `json:"schemas"`
+	Patch                 scimSupported     `json:"patch"`
+	Bulk                  scimSupported     `json:"bulk"`
+	Filter                scimSupported     `json:"filter"`
+	ChangePassword        scimSupported     `json:"changePassword"`
+	Sort                  scimSupported     `json:"sort"`
+	ETag                  scimSupported     `json:"etag"`
+	AuthenticationSchemes []scimAuthScheme  `json:"authenticationSchemes"`
+	Meta                  map[string]string `json:"meta"`
+}
+
+type scimAuthScheme struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// RegisterRoutes mounts the SCIM endpoints on the /scim/v2 group, they are
+// authenticated with the configured bearer token
+func (h *SCIMHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.Use(h.authenticate)
+	rg.GET("/ServiceProviderConfig", h.ServiceProviderConfig)
+	rg.GET("/Users", h.List)
+	rg.POST("/Users", h.Create)
+	rg.GET("/Users/:id", h.Get)
+	rg.PUT("/Users/:id", h.Replace)
+	rg.PATCH("/Users/:id", h.Patch)
+	rg.DELETE("/Users/:id", h.Deactivate)
+}
+
+// authenticate requires the SCIM token as a bearer token
+func (h *SCIMHandler) authenticate(c *gin.Context) {
+	token, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !found || subtle.ConstantTimeCompare([]byte(token), h.token) != 1 {
+		c.Header("WWW-Authenticate", `Bearer realm="scim"`)
+		scimFail(c, apierror.Unauthorized("invalid SCIM token"))
+		return
+	}
+	c.Next()
+}
+
+// ServiceProviderConfig handles GET /scim/v2/ServiceProviderConfig
+func (h *SCIMHandler) ServiceProviderConfig(c *gin.Context) {
+	scimRespond(c, http.StatusOK, scimProviderConfig{
+		Schemas:        []string{scimProviderSchema},
+		Patch:          scimSupported{Supported: true},
+		Filter:         scimSupported{Supported: true, MaxResults: h.maxResults},
+		ChangePassword: scimSupported{},
+		AuthenticationSchemes: []scimAuthScheme{{
+			Type:        "oauthbearertoken",
+			Name:        "Bearer token",
+			Description: "The token configured in auth.scim.token",
+		}},
+		Meta: map[string]string{"resourceType": "ServiceProviderConfig", "location": c.Request.URL.Path},
+	})
+}
+
+// List handles GET /scim/v2/Users, startIndex is 1-based
+func (h *SCIMHandler) List(c *gin.Context) {
+	filter, err := parseSCIMFilter(c.Query("filter"))
+	if err != nil {
+		scimFail(c, apierror.BadRequest("invalidFilter", err.Error()))
+		return
+	}
+	startIndex, count := 1, h.maxResults
+	if value := c.Query("startIndex"); value != "" {
+		if startIndex, err = strconv.Atoi(value); err != nil {
+			scimFail(c, apierror.BadRequest("invalidValue", "startIndex must be an integer"))
+			return
+		}
+		startIndex = max(startIndex, 1)
+	}
+	if value := c.Query("count"); value != "" {
+		if count, err = strconv.Atoi(value); err != nil {
+			scimFail(c, apierror.BadRequest("invalidValue", "count must be an integer"))
+			return
+		}
+		count = min(max(count, 0), h.maxResults)
+	}
+
+	users, total, err := h.service.List(c.Request.Context(), filter, count, startIndex-1)
+	if err != nil {
+		scimFail(c, err)
+		return
+	}
+
+	resources := make([]scimUser, len(users))
+	for i, user := range users {
+		resources[i] = newSCIMUser(c, user)
+	}
+	scimRespond(c, http.StatusOK, scimListResponse{
+		Schemas:      []string{scimListSchema},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// Get handles GET /scim/v2/Users/:id
+func (h *SCIMHandler) Get(c *gin.Context) {
+	id, ok := scimUserID(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		scimFail(c, err)
+		return
+	}
+	scimRespond(c, http.StatusOK, newSCIMUser(c, user))
+}
+
+// Create handles POST /scim/v2/Users
+func (h *SCIMHandler) Create(c *gin.Context) {
+	var req scimUser
+	if err := c.ShouldBindJSON(&req); err != nil {
+		scimFail(c, apierror.BadRequest("invalidSyntax", "request body must be a SCIM user"))
+		return
+	}
+
+	user, err := h.service.Create(c.Request.Context(), req.input())
+	if err != nil {
+		scimFail(c, err)
+		return
+	}
+	c.Header("Location", scimLocation(c, user.ID))
+	scimRespond(c, http.StatusCreated, newSCIMUser(c, user))
+}
+
+// Replace handles PUT /scim/v2/Users/:id
+func (h *SCIMHandler) Replace(c *gin.Context) {
+	id, ok := scimUserID(c)
+	if !ok {
+		return
+	}
+	var req scimUser
+	if err := c.ShouldBindJSON(&req); err != nil {
+		scimFail(c, apierror.BadRequest("invalidSyntax", "request body must be a SCIM user"))
+		return
+	}
+
+	user, err := h.service.Replace(c.Request.Context(), id, req.input())
+	if err != nil {
+		scimFail(c, err)
+		return
+	}
+	scimRespond(c, http.StatusOK, newSCIMUser(c, user))
+}
+
+// Patch handles PATCH /scim/v2/Users/:id. The operations are applied to the
+// current user in order and the result replaces it
+func (h *SCIMHandler) Patch(c *gin.Context) {
+	id, ok := scimUserID(c)
+	if !ok {
+		return
+	}
+	var req scimPatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		scimFail(c, apierror.BadRequest("invalidSyntax", "request body must be a SCIM PatchOp with Operations"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	current, err := h.service.Get(ctx, id)
+	if err != nil {
+		scimFail(c, err)
+		return
+	}
+	input := service.ProvisionInput{
+		Email:      current.Email,
+		Name:       current.Name,
+		ExternalID: current.ExternalID,
+		Active:     !current.IsDeactivated(),
+	}
+	for _, op := range req.Operations {
+		if err := applySCIMPatch(&input, op); err != nil {
+			scimFail(c, err)
+			return
+		}
+	}
+
+	user, err := h.service.Replace(ctx, id, input)
+	if err != nil {
+		scimFail(c, err)
+		return
+	}
+	scimRespond(c, http.StatusOK, newSCIMUser(c, user))
+}
+
+// Deactivate handles DELETE /scim/v2/Users/:id. The account is deactivated
+// rather than erased, so a user deprovisioned by mistake keeps their data
+func (h *SCIMHandler) Deactivate(c *gin.Context) {
+	id, ok := scimUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Deactivate(c.Request.Context(), id); err != nil {
+		scimFail(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// input converts a SCIM user to the provisioned state, a missing active
+// attribute means active
+func (u *scimUser) input() service.ProvisionInput {
+	input := service.ProvisionInput{
+		Email:      u.UserName,
+		Name:       u.DisplayName,
+		ExternalID: u.ExternalID,
+		Active:     u.Active == nil || *u.Active,
+	}
+	if u.Name != nil {
+		input.Name = u.Name.fullName(input.Name)
+	}
+	return input
+}
+
+// fullName returns the formatted name, the given and family names or
+// fallback when there are none
+func (n *scimName) fullName(fallback string) string {
+	if n.Formatted != "" {
+		return n.Formatted
+	}
+	if name := strings.TrimSpace(n.GivenName + " " + n.FamilyName); name != "" {
+		return name
+	}
+	return fallback
+}
+
+// applySCIMPatch applies one operation of a PatchOp. Operations without a
+// path carry an object of attributes, as sent by most identity providers
+func applySCIMPatch(input *service.ProvisionInput, op scimPatchOperation) error {
+	kind := strings.ToLower(op.Op)
+	if kind != "add" && kind != "replace" && kind != "remove" {
+		return apierror.BadRequest("invalidSyntax", fmt.Sprintf("unsupported patch operation %q", op.Op))
+	}
+
+	if op.Path == "" {
+		if kind == "remove" {
+			return apierror.BadRequest("noTarget", "remove operations need a path")
+		}
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(op.Value, &values); err != nil {
+			return apierror.BadRequest("invalidValue", "value of a patch without a path must be an object")
+		}
+		for path, value := range values {
+			if err := setSCIMAttribute(input, path, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if kind == "remove" {
+		switch strings.ToLower(op.Path) {
+		case "externalid":
+			input.ExternalID = ""
+		case "displayname", "name", "name.formatted":
+			input.Name = ""
+		default:
+			return apierror.BadRequest("mutability", fmt.Sprintf("%s cannot be removed", op.Path))
+		}
+		return nil
+	}
+	return setSCIMAttribute(input, op.Path, op.Value)
+}
+
+// setSCIMAttribute sets an attribute of the provisioned state from its SCIM
+// path, attribute names are case-insensitive
+func setSCIMAttribute(input *service.ProvisionInput, path string, value json.RawMessage) error {
+	invalid := apierror.BadRequest("invalidValue", fmt.Sprintf("invalid value of %s", path))
+	switch strings.ToLower(path) {
+	case "active":
+		active, err := scimBool(value)
+		if err != nil {
+			return invalid
+		}
+		input.Active = active
+	case "username":
+		if err := json.Unmarshal(value, &input.Email); err != nil {
+			return invalid
+		}
+	case "externalid":
+		if err := json.Unmarshal(value, &input.ExternalID); err != nil {
+			return invalid
+		}
+	case "displayname", "name.formatted":
+		if err := json.Unmarshal(value, &input.Name); err != nil {
+			return invalid
+		}
+	case "name":
+		var name scimName
+		if err := json.Unmarshal(value, &name); err != nil {
+			return invalid
+		}
+		input.Name = name.fullName(input.Name)
+	default:
+		return apierror.BadRequest("invalidPath", fmt.Sprintf("unsupported attribute %s", path))
+	}
+	return nil
+}
+
+// scimBool decodes a boolean, some identity providers send "True" and
+// "False" as strings
+func scimBool(value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return false, err
+	}
+	return strconv.ParseBool(s)
+}
+
+// parseSCIMFilter parses the supported filters: "eq" comparisons of
+// userName, externalId and emails.value joined by "and"
+func parseSCIMFilter(filter string) (storage.UserFilter, error) {
+	var result storage.UserFilter
+	rest := strings.TrimSpace(filter)
+	for rest != "" {
+		attribute, after, found := strings.Cut(rest, " ")
+		if !found {
+			return result, errInvalidFilter
+		}
+		operator, after, found := strings.Cut(strings.TrimLeft(after, " "), " ")
+		if !found || !strings.EqualFold(operator, "eq") {
+			return result, errInvalidFilter
+		}
+		value, after, err := scimFilterValue(strings.TrimLeft(after, " "))
+		if err != nil {
+			return result, err
+		}
+
+		var target *string
+		switch strings.ToLower(attribute) {
+		case "username", "emails.value", "emails":
+			target = &result.Email
+		case "externalid":
+			target = &result.ExternalID
+		default:
+			return result, errInvalidFilter
+		}
+		if *target != "" && !strings.EqualFold(*target, value) {
+			return result, errInvalidFilter
+		}
+		*target = value
+
+		rest = strings.TrimSpace(after)
+		if rest == "" {
+			break
+		}
+		conjunction, after, found := strings.Cut(rest, " ")
+		if !found || !strings.EqualFold(conjunction, "and") {
+			return result, errInvalidFilter
+		}
+		if rest = strings.TrimSpace(after); rest == "" {
+			return result, errInvalidFilter
+		}
+	}
+	return result, nil
+}
+
+// scimFilterValue decodes the JSON string at the start of s and returns the
+// remainder
+func scimFilterValue(s string) (string, string, error) {
+	if !strings.HasPrefix(s, `"`) {
+		return "", "", errInvalidFilter
+	}
+	decoder := json.NewDecoder(strings.NewReader(s))
+	var value string
+	if err := decoder.Decode(&value); err != nil {
+		return "", "", errInvalidFilter
+	}
+	return value, s[decoder.InputOffset():], nil
+}
+
+func newSCIMUser(c *gin.Context, user *models.User) scimUser {
+	active := !user.IsDeactivated()
+	resource := scimUser{
+		Schemas:     []string{scimUserSchema},
+		ID:          strconv.FormatInt(user.ID, 10),
+		ExternalID:  user.ExternalID,
+		UserName:    user.Email,
+		DisplayName: user.Name,
+		Emails:      []scimEmail{{Value: user.Email, Type: "work", Primary: true}},
+		Active:      &active,
+		Meta: &scimMeta{
+			ResourceType: "User",
+			Created:      user.CreatedAt,
+			LastModified: user.UpdatedAt,
+			Location:     scimLocation(c, user.ID),
+		},
+	}
+	if user.Name != "" {
+		resource.Name = &scimName{Formatted: user.Name}
+	}
+	return resource
+}
+
+// scimLocation returns the URL of a user, relative to the host
+func scimLocation(c *gin.Context, id int64) string {
+	base := strings.TrimSuffix(c.FullPath(), "/:id")
+	return strings.TrimSuffix(base, "/Users") + "/Users/" + strconv.FormatInt(id, 10)
+}
+
+// scimUserID parses the id parameter, unknown ids are not found like
+// missing users
+func scimUserID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		scimFail(c, storage.ErrNotFound)
+		return 0, false
+	}
+	return id, true
+}
+
+func scimRespond(c *gin.Context, status int, body any) {
+	c.Header("Content-Type", scimContentType+"; charset=utf-8")
+	c.JSON(status, body)
+}
+
+// scimFail aborts with a SCIM error. Service errors map to statuses like in
+// the rest of the API, bad requests built here carry their scimType as code
+func scimFail(c *gin.Context, err error) {
+	apiErr := apierror.From(serviceError(err))
+	scimType := ""
+	switch {
+	case apiErr.Status == http.StatusConflict:
+		scimType = "uniqueness"
+	case apiErr.Status == http.StatusBadRequest && scimTypes[apiErr.Code]:
+		scimType = apiErr.Code
+	case apiErr.Status == http.StatusBadRequest:
+		scimType = "invalidValue"
+	}
+
+	_ = c.Error(err)
+	c.Abort()
+	scimRespond(c, apiErr.Status, scimErrorResponse{
+		Schemas:  []string{scimErrorSchema},
+		Status:   strconv.Itoa(apiErr.Status),
+		ScimType: scimType,
+		Detail:   apiErr.Message,
+	})
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/middleware"
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"github.com/MuthuM3/gin-microservice-template/internal/session"
+	"github.com/gin-gonic/gin"
+)
+
+// SessionCookie describes the cookie carrying the session, it is always
+// HTTP only
+type SessionCookie struct {
+	Name     string
+	Domain   string
+	SameSite http.SameSite
+	Secure   bool
+}
+
+// SessionHandler serves cookie session login, logout and the management of
+// the user's sessions across devices
+type SessionHandler struct {
+	service *service.SessionService
+	cookie  SessionCookie
+}
+
+func NewSessionHandler(service *service.SessionService, cookie SessionCookie) *SessionHandler {
+	return &SessionHandler{service: service, cookie: cookie}
+}
+
+// sessionResponse describes a session, Current marks the one of the request
+type sessionResponse struct {
+	ID         string    `json:"id"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+// sessionLoginResponse is the only response carrying the CSRF token, to be
+// sent in the X-CSRF-Token header of requests changing state
+type sessionLoginResponse struct {
+	User      *models.User    `json:"user"`
+	Session   sessionResponse `json:"session"`
+	CSRFToken string          `json:"csrf_token"`
+}
+
+type revokedSessionsResponse struct {
+	Revoked int `json:"revoked"`
+}
+
+// RegisterRoutes mounts the session endpoints on the auth group,
+// authenticated is applied to endpoints that require a logged in user
+func (h *SessionHandler) RegisterRoutes(rg *gin.RouterGroup, authenticated gin.HandlerFunc) {
+	rg.POST("/session", h.Login)
+	rg.DELETE("/session", authenticated, h.Logout)
+	rg.GET("/sessions", authenticated, h.List)
+	rg.DELETE("/sessions", authenticated, h.RevokeOthers)
+	rg.DELETE("/sessions/:id", authenticated, h.Revoke)
+}
+
+// Login handles POST /auth/session, starting a session held in a cookie
+func (h *SessionHandler) Login(c *gin.Context) {
+	var req loginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	result, err := h.service.Login(c.Request.Context(), req.Email, req.Password, middleware.ClientIP(c), c.Request.UserAgent())
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	h.setCookie(c, result.Token, int(time.Until(result.Session.ExpiresAt).Seconds()))
+	respond(c, http.StatusCreated, sessionLoginResponse{
+		User:      result.User,
+		Session:   newSessionResponse(result.Session, result.Session.ID),
+		CSRFToken: result.Session.CSRFToken,
+	})
+}
+
+// Logout handles DELETE /auth/session, ending the session of the cookie
+func (h *SessionHandler) Logout(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if sess, ok := middleware.Session(c); ok {
+		if err := h.service.Logout(c.Request.Context(), userID, sess.ID); err != nil {
+			handleError(c, err)
+			return
+		}
+	}
+
+	h.setCookie(c, "", -1)
+	c.Status(http.StatusNoContent)
+}
+
+// List handles GET /auth/sessions
+func (h *SessionHandler) List(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.service.List(c.Request.Context(), userID)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	currentID := currentSessionID(c)
+	response := make([]sessionResponse, len(sessions))
+	for i, sess := range sessions {
+		response[i] = newSessionResponse(sess, currentID)
+	}
+	respond(c, http.StatusOK, response)
+}
+
+// Revoke handles DELETE /auth/sessions/:id
+func (h *SessionHandler) Revoke(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Revoke(c.Request.Context(), userID, c.Param("id")); err != nil {
+		handleError(c, err)
+		return
+	}
+	if c.Param("id") == currentSessionID(c) {
+		h.setCookie(c, "", -1)
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RevokeOthers handles DELETE /auth/sessions, logging out every other device
+func (h *SessionHandler) RevokeOthers(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	revoked, err := h.service.RevokeOthers(c.Request.Context(), userID, currentSessionID(c))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, revokedSessionsResponse{Revoked: revoked})
+}
+
+// setCookie sets the session cookie, a negative maxAge removes it
+func (h *SessionHandler) setCookie(c *gin.Context, value string, maxAge int) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     h.cookie.Name,
+		Value:    value,
+		Path:     "/",
+		Domain:   h.cookie.Domain,
+		MaxAge:   maxAge,
+		Secure:   h.cookie.Secure,
+		HttpOnly: true,
+		SameSite: h.cookie.SameSite,
+	})
+}
+
+// currentSessionID returns the id of the session the request was
+// authenticated with, empty for bearer tokens
+func currentSessionID(c *gin.Context) string {
+	if sess, ok := middleware.Session(c); ok {
+		return sess.ID
+	}
+	return ""
+}
+
+func newSessionResponse(sess *session.Session, currentID string) sessionResponse {
+	return sessionResponse{
+		ID:         sess.ID,
+		IPAddress:  sess.IPAddress,
+		UserAgent:  sess.UserAgent,
+		CreatedAt:  sess.CreatedAt,
+		LastSeenAt: sess.LastSeenAt,
+		ExpiresAt:  sess.ExpiresAt,
+		Current:    sess.ID == currentID,
+	}
+}
@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ShareHandler serves the sharing of todos: owners manage who their todos
+// are shared with below /todos, collaborators work on the todos shared with
+// them below /shared
+type ShareHandler struct {
+	service *service.ShareService
+}
+
+func NewShareHandler(service *service.ShareService) *ShareHandler {
+	return &ShareHandler{service: service}
+}
+
+type shareRequest struct {
+	Email string `json:"email" binding:"required,email,max=255"`
+	Role  string `json:"role" binding:"required"`
+}
+
+type shareRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// RegisterRoutes mounts the owner endpoints on the todos group and the
+// collaborator endpoints on the shared group
+func (h *ShareHandler) RegisterRoutes(todos, shared *gin.RouterGroup) {
+	todos.GET("/:id/shares", h.List)
+	todos.POST("/:id/shares", h.Create)
+	todos.PATCH("/:id/shares/:user_id", h.Update)
+	todos.DELETE("/:id/shares/:user_id", h.Delete)
+
+	shared.GET("", h.ListShared)
+	shared.GET("/:id", h.GetShared)
+	shared.PATCH("/:id", h.PatchShared)
+	shared.GET("/:id/subtasks", h.ListSharedSubtasks)
+	shared.POST("/:id/accept", h.Accept)
+	shared.DELETE("/:id", h.Leave)
+}
+
+// List handles GET /todos/:id/shares
+func (h *ShareHandler) List(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	shares, err := h.service.Shares(c.Request.Context(), userID, id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, shares)
+}
+
+// Create handles POST /todos/:id/shares
+func (h *ShareHandler) Create(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req shareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	share, err := h.service.Share(c.Request.Context(), userID, id, service.ShareInput{Email: req.Email, Role: req.Role})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusCreated, share)
+}
+
+// Update handles PATCH /todos/:id/shares/:user_id
+func (h *ShareHandler) Update(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	collaboratorID, ok := parseID(c, "user_id")
+	if !ok {
+		return
+	}
+
+	var req shareRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	share, err := h.service.SetRole(c.Request.Context(), userID, id, collaboratorID, req.Role)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, share)
+}
+
+// Delete handles DELETE /todos/:id/shares/:user_id
+func (h *ShareHandler) Delete(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	collaboratorID, ok := parseID(c, "user_id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Revoke(c.Request.Context(), userID, id, collaboratorID); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListShared handles GET /shared
+func (h *ShareHandler) ListShared(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	shared, err := h.service.SharedWith(c.Request.Context(), userID)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, shared)
+}
+
+// GetShared handles GET /shared/:id
+func (h *ShareHandler) GetShared(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	todo, err := h.service.Get(c.Request.Context(), userID, id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	if notModified(c, "todo", todo.ID, todo.UpdatedAt) {
+		return
+	}
+
+	respond(c, http.StatusOK, todo)
+}
+
+// PatchShared handles PATCH /shared/:id
+func (h *ShareHandler) PatchShared(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req todoPatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	todo, err := h.service.Update(c.Request.Context(), userID, id, service.TodoPatch{
+		ParentID:    req.ParentID,
+		Title:       req.Title,
+		Description: req.Description,
+		Completed:   req.Completed,
+		DueDate:     req.DueDate.patch(),
+		Recurrence:  req.Recurrence,
+		Priority:    req.Priority,
+		Tags:        req.Tags,
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, todo)
+}
+
+// ListSharedSubtasks handles GET /shared/:id/subtasks
+func (h *ShareHandler) ListSharedSubtasks(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	todos, err := h.service.Subtasks(c.Request.Context(), userID, id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, todos)
+}
+
+// Accept handles POST /shared/:id/accept
+func (h *ShareHandler) Accept(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	shared, err := h.service.Accept(c.Request.Context(), userID, id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, shared)
+}
+
+// Leave handles DELETE /shared/:id
+func (h *ShareHandler) Leave(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Leave(c.Request.Context(), userID, id); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
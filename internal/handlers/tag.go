@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// TagHandler serves the tag REST endpoints
+type TagHandler struct {
+	service *service.TagService
+}
+
+func NewTagHandler(service *service.TagService) *TagHandler {
+	return &TagHandler{service: service}
+}
+
+type tagRequest struct {
+	Name string `json:"name" binding:"required,max=50"`
+}
+
+// RegisterRoutes mounts the tag endpoints on the given group
+func (h *TagHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("", h.Create)
+	rg.GET("", h.List)
+	rg.GET("/:id", h.Get)
+	rg.PUT("/:id", h.Rename)
+	rg.DELETE("/:id", h.Delete)
+}
+
+// Create handles POST /tags
+func (h *TagHandler) Create(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req tagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	tag, err := h.service.Create(c.Request.Context(), userID, req.Name)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusCreated, tag)
+}
+
+// List handles GET /tags
+func (h *TagHandler) List(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	tags, err := h.service.List(c.Request.Context(), userID)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, tags)
+}
+
+// Get handles GET /tags/:id
+func (h *TagHandler) Get(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	tag, err := h.service.Get(c.Request.Context(), userID, id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, tag)
+}
+
+// Rename handles PUT /tags/:id
+func (h *TagHandler) Rename(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req tagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	tag, err := h.service.Rename(c.Request.Context(), userID, id, req.Name)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, tag)
+}
+
+// Delete handles DELETE /tags/:id
+func (h *TagHandler) Delete(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), userID, id); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,526 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// TodoHandler serves the todo REST endpoints
+type TodoHandler struct {
+	service *service.TodoService
+}
+
+func NewTodoHandler(service *service.TodoService) *TodoHandler {
+	return &TodoHandler{service: service}
+}
+
+type todoRequest struct {
+	ParentID    *int64     `json:"parent_id" binding:"omitempty,min=1"`
+	Title       string     `json:"title" binding:"required,max=255"`
+	Description string     `json:"description" binding:"max=2000"`
+	Completed   bool       `json:"completed"`
+	DueDate     *time.Time `json:"due_date"`
+	Recurrence  string     `json:"recurrence" binding:"max=255"`
+	Priority    string     `json:"priority" binding:"omitempty,priority"`
+	Tags        []string   `json:"tags" binding:"max=20,dive,max=50"`
+}
+
+// todoPatchRequest moves the todo to the top level when parent_id is 0 and
+// removes its due date when due_date is null
+type todoPatchRequest struct {
+	ParentID    *int64     `json:"parent_id" binding:"omitempty,min=0"`
+	Title       *string    `json:"title" binding:"omitempty,max=255"`
+	Description *string    `json:"description" binding:"omitempty,max=2000"`
+	Completed   *bool      `json:"completed"`
+	DueDate     timeOrNull `json:"due_date"`
+	Recurrence  *string    `json:"recurrence" binding:"omitempty,max=255"`
+	Priority    *string    `json:"priority" binding:"omitempty,priority"`
+	Tags        []string   `json:"tags" binding:"max=20,dive,max=50"`
+}
+
+// todoImportRequest is a batch of todos to import, imported todos are top
+// level and untagged
+type todoImportRequest struct {
+	Todos []todoImportItem `json:"todos" binding:"required,dive"`
+}
+
+type todoImportItem struct {
+	Title       string     `json:"title" binding:"required,max=255"`
+	Description string     `json:"description" binding:"max=2000"`
+	Completed   bool       `json:"completed"`
+	DueDate     *time.Time `json:"due_date"`
+	Priority    string     `json:"priority" binding:"omitempty,priority"`
+}
+
+type todoImportResponse struct {
+	Imported int64 `json:"imported"`
+}
+
+// timeOrNull tells a field set to null from a missing one
+type timeOrNull struct {
+	set  bool
+	time *time.Time
+}
+
+func (t *timeOrNull) UnmarshalJSON(data []byte) error {
+	t.set = true
+	if string(data) == "null" {
+		t.time = nil
+		return nil
+	}
+	return json.Unmarshal(data, &t.time)
+}
+
+// patch returns the value of a partial update: nil when the field is
+// missing and the zero time when it is null
+func (t timeOrNull) patch() *time.Time {
+	if t.set && t.time == nil {
+		return &time.Time{}
+	}
+	return t.time
+}
+
+// RegisterRoutes mounts the todo endpoints on the given group
+func (h *TodoHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("", h.Create)
+	rg.POST("/import", h.Import)
+	rg.GET("", h.List)
+	rg.GET("/search", h.Search)
+	rg.GET("/trash", h.Trash)
+	rg.GET("/:id", h.Get)
+	rg.PUT("/:id", h.Update)
+	rg.PATCH("/:id", h.Patch)
+	rg.DELETE("/:id", h.Delete)
+	rg.POST("/:id/restore", h.Restore)
+	rg.POST("/:id/subtasks", h.CreateSubtask)
+	rg.GET("/:id/subtasks", h.ListSubtasks)
+	rg.GET("/:id/occurrences", h.ListOccurrences)
+}
+
+// Create handles POST /todos
+func (h *TodoHandler) Create(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req todoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	todo, err := h.service.Create(c.Request.Context(), userID, service.TodoInput{
+		ParentID:    req.ParentID,
+		Title:       req.Title,
+		Description: req.Description,
+		Completed:   req.Completed,
+		DueDate:     req.DueDate,
+		Recurrence:  req.Recurrence,
+		Priority:    req.Priority,
+		Tags:        req.Tags,
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusCreated, todo)
+}
+
+// Import handles POST /todos/import
+func (h *TodoHandler) Import(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req todoImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	inputs := make([]service.TodoInput, len(req.Todos))
+	for i, item := range req.Todos {
+		inputs[i] = service.TodoInput{
+			Title:       item.Title,
+			Description: item.Description,
+			Completed:   item.Completed,
+			DueDate:     item.DueDate,
+			Priority:    item.Priority,
+		}
+	}
+
+	imported, err := h.service.Import(c.Request.Context(), userID, inputs)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusCreated, todoImportResponse{Imported: imported})
+}
+
+// List handles GET /todos?cursor=&limit=&status=&priority=&due_before=&due_after=&tags=&fields=
+func (h *TodoHandler) List(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	fields, ok := queryFields(c, models.Todo{})
+	if !ok {
+		return
+	}
+
+	limit, ok := queryInt(c, "limit", 0)
+	if !ok {
+		return
+	}
+	query, ok := todoQuery(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.List(c.Request.Context(), userID, query, c.Query("cursor"), limit)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	data, ok := project(c, result.Todos, fields)
+	if !ok {
+		return
+	}
+
+	respondCursor(c, data, CursorPagination{
+		Limit:      result.Limit,
+		NextCursor: result.NextCursor,
+		PrevCursor: result.PrevCursor,
+	})
+}
+
+// todoQuery reads the filters of the todo list
+func todoQuery(c *gin.Context) (service.TodoQuery, bool) {
+	dueBefore, ok := queryTime(c, "due_before")
+	if !ok {
+		return service.TodoQuery{}, false
+	}
+	dueAfter, ok := queryTime(c, "due_after")
+	if !ok {
+		return service.TodoQuery{}, false
+	}
+
+	return service.TodoQuery{
+		Status:    c.Query("status"),
+		Priority:  c.Query("priority"),
+		DueBefore: dueBefore,
+		DueAfter:  dueAfter,
+		Tags:      queryList(c, "tags"),
+	}, true
+}
+
+// Search handles GET /todos/search?q=&page=&page_size=
+func (h *TodoHandler) Search(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	page, ok := queryInt(c, "page", 1)
+	if !ok {
+		return
+	}
+	pageSize, ok := queryInt(c, "page_size", 0)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.Search(c.Request.Context(), userID, c.Query("q"), page, pageSize)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respondPage(c, result.Results, Pagination{
+		Page:       result.Page,
+		PageSize:   result.PageSize,
+		Total:      result.Total,
+		TotalPages: result.TotalPages(),
+	})
+}
+
+// Get handles GET /todos/:id?fields=
+func (h *TodoHandler) Get(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	fields, ok := queryFields(c, models.Todo{})
+	if !ok {
+		return
+	}
+
+	todo, err := h.service.Get(c.Request.Context(), userID, id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	if notModified(c, "todo", todo.ID, todo.UpdatedAt) {
+		return
+	}
+	data, ok := project(c, todo, fields)
+	if !ok {
+		return
+	}
+
+	respond(c, http.StatusOK, data)
+}
+
+// Update handles PUT /todos/:id
+func (h *TodoHandler) Update(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req todoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	todo, err := h.service.Update(c.Request.Context(), userID, id, service.TodoInput{
+		ParentID:    req.ParentID,
+		Title:       req.Title,
+		Description: req.Description,
+		Completed:   req.Completed,
+		DueDate:     req.DueDate,
+		Recurrence:  req.Recurrence,
+		Priority:    req.Priority,
+		Tags:        req.Tags,
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, todo)
+}
+
+// Patch handles PATCH /todos/:id
+func (h *TodoHandler) Patch(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req todoPatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	todo, err := h.service.Patch(c.Request.Context(), userID, id, service.TodoPatch{
+		ParentID:    req.ParentID,
+		Title:       req.Title,
+		Description: req.Description,
+		Completed:   req.Completed,
+		DueDate:     req.DueDate.patch(),
+		Recurrence:  req.Recurrence,
+		Priority:    req.Priority,
+		Tags:        req.Tags,
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, todo)
+}
+
+// Delete handles DELETE /todos/:id
+func (h *TodoHandler) Delete(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), userID, id); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// CreateSubtask handles POST /todos/:id/subtasks
+func (h *TodoHandler) CreateSubtask(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req todoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	todo, err := h.service.CreateSubtask(c.Request.Context(), userID, id, service.TodoInput{
+		Title:       req.Title,
+		Description: req.Description,
+		Completed:   req.Completed,
+		DueDate:     req.DueDate,
+		Recurrence:  req.Recurrence,
+		Priority:    req.Priority,
+		Tags:        req.Tags,
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusCreated, todo)
+}
+
+// ListSubtasks handles GET /todos/:id/subtasks?fields=
+func (h *TodoHandler) ListSubtasks(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	fields, ok := queryFields(c, models.Todo{})
+	if !ok {
+		return
+	}
+
+	todos, err := h.service.Subtasks(c.Request.Context(), userID, id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	data, ok := project(c, todos, fields)
+	if !ok {
+		return
+	}
+
+	respond(c, http.StatusOK, data)
+}
+
+// ListOccurrences handles GET /todos/:id/occurrences?limit=
+func (h *TodoHandler) ListOccurrences(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	limit, ok := queryInt(c, "limit", 0)
+	if !ok {
+		return
+	}
+
+	occurrences, err := h.service.Occurrences(c.Request.Context(), userID, id, limit)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, occurrences)
+}
+
+// Trash handles GET /todos/trash?page=&page_size=&fields=
+func (h *TodoHandler) Trash(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	fields, ok := queryFields(c, models.Todo{})
+	if !ok {
+		return
+	}
+
+	page, ok := queryInt(c, "page", 1)
+	if !ok {
+		return
+	}
+	pageSize, ok := queryInt(c, "page_size", 0)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.Trash(c.Request.Context(), userID, page, pageSize)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	data, ok := project(c, result.Todos, fields)
+	if !ok {
+		return
+	}
+
+	respondPage(c, data, Pagination{
+		Page:       result.Page,
+		PageSize:   result.PageSize,
+		Total:      result.Total,
+		TotalPages: result.TotalPages(),
+	})
+}
+
+// Restore handles POST /todos/:id/restore
+func (h *TodoHandler) Restore(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	todo, err := h.service.Restore(c.Request.Context(), userID, id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, todo)
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// Todo statuses of API v2, which replaces the completed flag of v1
+const (
+	todoStatusOpen      = "open"
+	todoStatusCompleted = "completed"
+)
+
+// TodoV2Handler serves the todo endpoints of API v2. It maps the v2
+// representation to the same service as TodoHandler
+type TodoV2Handler struct {
+	service *service.TodoService
+}
+
+func NewTodoV2Handler(service *service.TodoService) *TodoV2Handler {
+	return &TodoV2Handler{service: service}
+}
+
+// todoV2 is the v2 representation of a todo: status replaces completed,
+// due_at replaces due_date and the owner is not exposed
+type todoV2 struct {
+	ID          int64      `json:"id"`
+	ParentID    *int64     `json:"parent_id,omitempty"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Status      string     `json:"status"`
+	DueAt       *time.Time `json:"due_at,omitempty"`
+	Recurrence  string     `json:"recurrence,omitempty"`
+	Priority    string     `json:"priority"`
+	Tags        []string   `json:"tags"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+type todoV2Request struct {
+	ParentID    *int64     `json:"parent_id" binding:"omitempty,min=1"`
+	Title       string     `json:"title" binding:"required,max=255"`
+	Description string     `json:"description" binding:"max=2000"`
+	Status      string     `json:"status" binding:"omitempty,oneof=open completed"`
+	DueAt       *time.Time `json:"due_at"`
+	Recurrence  string     `json:"recurrence" binding:"max=255"`
+	Priority    string     `json:"priority" binding:"omitempty,priority"`
+	Tags        []string   `json:"tags" binding:"max=20,dive,max=50"`
+}
+
+func newTodoV2(todo *models.Todo) todoV2 {
+	status := todoStatusOpen
+	if todo.Completed {
+		status = todoStatusCompleted
+	}
+	return todoV2{
+		ID:          todo.ID,
+		ParentID:    todo.ParentID,
+		Title:       todo.Title,
+		Description: todo.Description,
+		Status:      status,
+		DueAt:       todo.DueDate,
+		Recurrence:  todo.Recurrence,
+		Priority:    todo.Priority,
+		Tags:        todo.Tags,
+		CreatedAt:   todo.CreatedAt,
+		UpdatedAt:   todo.UpdatedAt,
+	}
+}
+
+func (r todoV2Request) input() service.TodoInput {
+	return service.TodoInput{
+		ParentID:    r.ParentID,
+		Title:       r.Title,
+		Description: r.Description,
+		Completed:   r.Status == todoStatusCompleted,
+		DueDate:     r.DueAt,
+		Recurrence:  r.Recurrence,
+		Priority:    r.Priority,
+		Tags:        r.Tags,
+	}
+}
+
+// RegisterRoutes mounts the todo endpoints on the given group
+func (h *TodoV2Handler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("", h.Create)
+	rg.GET("", h.List)
+	rg.GET("/:id", h.Get)
+	rg.PUT("/:id", h.Update)
+	rg.DELETE("/:id", h.Delete)
+}
+
+// Create handles POST /v2/todos
+func (h *TodoV2Handler) Create(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req todoV2Request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	todo, err := h.service.Create(c.Request.Context(), userID, req.input())
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusCreated, newTodoV2(todo))
+}
+
+// List handles GET /v2/todos?cursor=&limit=&status=&priority=&due_before=&due_after=&tags=
+func (h *TodoV2Handler) List(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	limit, ok := queryInt(c, "limit", 0)
+	if !ok {
+		return
+	}
+	query, ok := todoQuery(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.List(c.Request.Context(), userID, query, c.Query("cursor"), limit)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	todos := make([]todoV2, 0, len(result.Todos))
+	for _, todo := range result.Todos {
+		todos = append(todos, newTodoV2(todo))
+	}
+	respondCursor(c, todos, CursorPagination{
+		Limit:      result.Limit,
+		NextCursor: result.NextCursor,
+		PrevCursor: result.PrevCursor,
+	})
+}
+
+// Get handles GET /v2/todos/:id
+func (h *TodoV2Handler) Get(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	todo, err := h.service.Get(c.Request.Context(), userID, id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	if notModified(c, "todo.v2", todo.ID, todo.UpdatedAt) {
+		return
+	}
+
+	respond(c, http.StatusOK, newTodoV2(todo))
+}
+
+// Update handles PUT /v2/todos/:id
+func (h *TodoV2Handler) Update(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req todoV2Request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	todo, err := h.service.Update(c.Request.Context(), userID, id, req.input())
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, newTodoV2(todo))
+}
+
+// Delete handles DELETE /v2/todos/:id
+func (h *TodoV2Handler) Delete(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), userID, id); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler serves the webhook registration and delivery endpoints
+type WebhookHandler struct {
+	service *service.WebhookService
+}
+
+func NewWebhookHandler(service *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+type webhookRequest struct {
+	URL    string   `json:"url" binding:"required,max=2048"`
+	Secret string   `json:"secret" binding:"max=255"`
+	Events []string `json:"events" binding:"max=20,dive,max=100"`
+}
+
+type webhookPatchRequest struct {
+	URL    *string   `json:"url" binding:"omitempty,max=2048"`
+	Secret *string   `json:"secret" binding:"omitempty,max=255"`
+	Events *[]string `json:"events" binding:"omitempty,max=20,dive,max=100"`
+	Active *bool     `json:"active"`
+}
+
+// webhookCreatedResponse is the only response carrying the secret
+type webhookCreatedResponse struct {
+	*models.Webhook
+	Secret string `json:"secret"`
+}
+
+// RegisterRoutes mounts the webhook endpoints on the given group
+func (h *WebhookHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("", h.Create)
+	rg.GET("", h.List)
+	rg.GET("/:id", h.Get)
+	rg.PATCH("/:id", h.Update)
+	rg.DELETE("/:id", h.Delete)
+	rg.GET("/:id/deliveries", h.Deliveries)
+	rg.POST("/:id/deliveries/:delivery_id/redeliver", h.Redeliver)
+}
+
+// Create handles POST /webhooks
+func (h *WebhookHandler) Create(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	hook, err := h.service.Create(c.Request.Context(), userID, service.WebhookInput{
+		URL:    req.URL,
+		Secret: req.Secret,
+		Events: req.Events,
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusCreated, webhookCreatedResponse{Webhook: hook, Secret: hook.Secret})
+}
+
+// List handles GET /webhooks
+func (h *WebhookHandler) List(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	hooks, err := h.service.List(c.Request.Context(), userID)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, hooks)
+}
+
+// Get handles GET /webhooks/:id
+func (h *WebhookHandler) Get(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	hook, err := h.service.Get(c.Request.Context(), userID, id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	if notModified(c, "webhook", hook.ID, hook.UpdatedAt) {
+		return
+	}
+
+	respond(c, http.StatusOK, hook)
+}
+
+// Update handles PATCH /webhooks/:id
+func (h *WebhookHandler) Update(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req webhookPatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	hook, err := h.service.Update(c.Request.Context(), userID, id, service.WebhookPatch{
+		URL:    req.URL,
+		Secret: req.Secret,
+		Events: req.Events,
+		Active: req.Active,
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, hook)
+}
+
+// Delete handles DELETE /webhooks/:id
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), userID, id); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Deliveries handles GET /webhooks/:id/deliveries?page=&page_size=
+func (h *WebhookHandler) Deliveries(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	page, ok := queryInt(c, "page", 1)
+	if !ok {
+		return
+	}
+	pageSize, ok := queryInt(c, "page_size", 0)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.Deliveries(c.Request.Context(), userID, id, page, pageSize)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respondPage(c, result.Deliveries, Pagination{
+		Page:       result.Page,
+		PageSize:   result.PageSize,
+		Total:      result.Total,
+		TotalPages: result.TotalPages(),
+	})
+}
+
+// Redeliver handles POST /webhooks/:id/deliveries/:delivery_id/redeliver
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	deliveryID, ok := parseID(c, "delivery_id")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.service.Redeliver(c.Request.Context(), userID, id, deliveryID)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	respond(c, http.StatusAccepted, delivery)
+}
@@ -0,0 +1,56 @@
+// Package httpclient builds the HTTP clients used to call downstream
+// services, so every call gets the same timeouts, retries, circuit breaking,
+// request id and trace propagation, and metrics
+package httpclient
+
+import (
+	"net/http"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/breaker"
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+	"github.com/MuthuM3/gin-microservice-template/internal/requestid"
+)
+
+// Factory creates clients sharing one connection pool
+type Factory struct {
+	cfg     config.HTTPClientConfig
+	breaker config.CircuitBreakerConfig
+	log     logger.Logger
+	base    *http.Transport
+}
+
+// NewFactory creates a factory, clients get a circuit breaker when
+// breakerCfg is enabled
+func NewFactory(cfg config.HTTPClientConfig, breakerCfg config.CircuitBreakerConfig, log logger.Logger) *Factory {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+
+	return &Factory{cfg: cfg, breaker: breakerCfg, log: log, base: base}
+}
+
+// Client returns a client for the named downstream service. The name labels
+// its metrics and circuit breaker, create one client per service and reuse it
+func (f *Factory) Client(name string) *http.Client {
+	var b *breaker.Breaker
+	if f.breaker.Enabled {
+		b = breaker.New("http_"+name, f.breaker, isOutage, f.log)
+	}
+
+	// Outermost first: every attempt is traced and counted separately and
+	// an open breaker ends the retries
+	var transport http.RoundTripper = &requestid.Transport{Base: f.base}
+	transport = &breakerTransport{base: transport, breaker: b}
+	transport = newInstrumentedTransport(name, transport)
+	transport = &retryTransport{base: transport, cfg: f.cfg, name: name}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   f.cfg.Timeout,
+	}
+}
+
+// CloseIdleConnections closes the idle connections of every client
+func (f *Factory) CloseIdleConnections() {
+	f.base.CloseIdleConnections()
+}
@@ -0,0 +1,101 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/breaker"
+	"github.com/MuthuM3/gin-microservice-template/internal/metrics"
+	"github.com/MuthuM3/gin-microservice-template/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// errServerError records a 5xx response as a breaker failure
+var errServerError = errors.New("server error response")
+
+// isOutage counts transport errors and server errors against the breaker,
+// cancelled calls say nothing about the downstream service
+func isOutage(err error) bool {
+	return !errors.Is(err, context.Canceled)
+}
+
+// breakerTransport fails fast with breaker.ErrOpen while the downstream
+// service keeps failing
+type breakerTransport struct {
+	base    http.RoundTripper
+	breaker *breaker.Breaker
+}
+
+func (t *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.breaker.Allow(); err != nil {
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	switch {
+	case err != nil:
+		t.breaker.Done(err)
+	case resp.StatusCode >= http.StatusInternalServerError:
+		t.breaker.Done(errServerError)
+	default:
+		t.breaker.Done(nil)
+	}
+	return resp, err
+}
+
+// instrumentedTransport records a client span and counts every request,
+// propagating the trace context to the downstream service
+type instrumentedTransport struct {
+	base   http.RoundTripper
+	name   string
+	tracer trace.Tracer
+}
+
+func newInstrumentedTransport(name string, base http.RoundTripper) *instrumentedTransport {
+	return &instrumentedTransport{base: base, name: name, tracer: tracing.Tracer()}
+}
+
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := t.tracer.Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Host),
+			attribute.String("url.path", req.URL.Path),
+			attribute.String("peer.service", t.name),
+		),
+	)
+	defer span.End()
+
+	// RoundTrippers must not modify the caller's request
+	req = req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := t.base.RoundTrip(req)
+
+	status := "error"
+	switch {
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	default:
+		status = strconv.Itoa(resp.StatusCode)
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+		if resp.StatusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+		}
+	}
+
+	metrics.Default.Counter("http_client_requests_total",
+		"Total number of requests sent to downstream services",
+		metrics.Labels{"client": t.name, "method": req.Method, "status": status},
+	).Inc()
+
+	return resp, err
+}
@@ -0,0 +1,228 @@
+// Package i18n loads the message bundles of the API and picks the language of
+// a request from its Accept-Language header
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// SourceLanguage is the language the messages of the API are written in
+const SourceLanguage = "en"
+
+//go:embed locales/*.json
+var locales embed.FS
+
+// Bundle holds the message templates of each language, keyed by message key.
+// Templates contain {name} placeholders replaced by the arguments of T
+type Bundle struct {
+	fallback string
+	messages map[string]map[string]string
+}
+
+var (
+	defaultOnce   sync.Once
+	defaultBundle *Bundle
+)
+
+// Default returns the bundle of the shipped languages with English as
+// fallback
+func Default() *Bundle {
+	defaultOnce.Do(func() {
+		bundle, err := Load("", SourceLanguage)
+		if err != nil {
+			panic(fmt.Sprintf("failed to load embedded locales: %v", err))
+		}
+		defaultBundle = bundle
+	})
+	return defaultBundle
+}
+
+// Load returns the shipped bundles merged with the <lang>.json files of dir,
+// whose messages take precedence. dir may be empty. fallback is the language
+// used when the client accepts none of the others
+func Load(dir, fallback string) (*Bundle, error) {
+	b := &Bundle{fallback: strings.ToLower(fallback), messages: make(map[string]map[string]string)}
+
+	embedded, err := fs.Sub(locales, "locales")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open embedded locales: %w", err)
+	}
+	if err := b.loadFS(embedded); err != nil {
+		return nil, err
+	}
+	if dir != "" {
+		if err := b.loadFS(os.DirFS(dir)); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, ok := b.messages[b.fallback]; !ok {
+		return nil, fmt.Errorf("no messages for fallback language %q", fallback)
+	}
+	return b, nil
+}
+
+func (b *Bundle) loadFS(fsys fs.FS) error {
+	files, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return fmt.Errorf("failed to list locales: %w", err)
+	}
+
+	for _, name := range files {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return fmt.Errorf("failed to read locale %s: %w", name, err)
+		}
+
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return fmt.Errorf("failed to parse locale %s: %w", name, err)
+		}
+		if len(messages) == 0 {
+			return fmt.Errorf("locale %s has no messages", name)
+		}
+
+		lang := strings.ToLower(strings.TrimSuffix(filepath.Base(name), ".json"))
+		if b.messages[lang] == nil {
+			b.messages[lang] = make(map[string]string, len(messages))
+		}
+		for key, message := range messages {
+			b.messages[lang][key] = message
+		}
+	}
+	return nil
+}
+
+// Languages returns the languages of the bundle, sorted
+func (b *Bundle) Languages() []string {
+	langs := make([]string, 0, len(b.messages))
+	for lang := range b.messages {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Negotiate picks the best language of the bundle for an Accept-Language
+// header. A tag matches a language of the same name, or of its primary subtag
+func (b *Bundle) Negotiate(acceptLanguage string) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		lang, ok := b.match(tag)
+		if !ok {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{lang: lang, q: q})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return b.fallback
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].lang
+}
+
+func (b *Bundle) match(tag string) (string, bool) {
+	if _, ok := b.messages[tag]; ok {
+		return tag, true
+	}
+	primary, _, _ := strings.Cut(tag, "-")
+	if _, ok := b.messages[primary]; ok {
+		return primary, true
+	}
+	return "", false
+}
+
+// Localizer returns the localizer of the best language for an
+// Accept-Language header
+func (b *Bundle) Localizer(acceptLanguage string) *Localizer {
+	return &Localizer{bundle: b, lang: b.Negotiate(acceptLanguage)}
+}
+
+// Localizer renders the messages of a bundle in one language
+type Localizer struct {
+	bundle *Bundle
+	lang   string
+}
+
+// Language returns the language of the localizer
+func (l *Localizer) Language() string {
+	return l.lang
+}
+
+// T renders the message of key, args are name/value pairs replacing the
+// {name} placeholders. Keys missing in the language fall back to the bundle's
+// fallback language, and to the key itself
+func (l *Localizer) T(key string, args ...string) string {
+	template, ok := l.bundle.messages[l.lang][key]
+	if !ok {
+		template, ok = l.bundle.messages[l.bundle.fallback][key]
+	}
+	if !ok {
+		return key
+	}
+	return render(template, args)
+}
+
+// Lookup renders the message of key only if the language of the localizer
+// has it
+func (l *Localizer) Lookup(key string, args ...string) (string, bool) {
+	template, ok := l.bundle.messages[l.lang][key]
+	if !ok {
+		return "", false
+	}
+	return render(template, args), true
+}
+
+func render(template string, args []string) string {
+	if len(args) < 2 {
+		return template
+	}
+	pairs := make([]string, 0, len(args))
+	for i := 0; i+1 < len(args); i += 2 {
+		pairs = append(pairs, "{"+args[i]+"}", args[i+1])
+	}
+	return strings.NewReplacer(pairs...).Replace(template)
+}
+
+type contextKey struct{}
+
+// WithLocalizer returns a copy of ctx carrying l
+func WithLocalizer(ctx context.Context, l *Localizer) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the localizer of ctx, or one of the default bundle in
+// its fallback language
+func FromContext(ctx context.Context) *Localizer {
+	if l, ok := ctx.Value(contextKey{}).(*Localizer); ok {
+		return l
+	}
+	return Default().Localizer("")
+}
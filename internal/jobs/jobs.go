@@ -0,0 +1,178 @@
+// Package jobs runs background work: jobs on a cron schedule and one-off
+// jobs after a delay. Runs of a job never overlap, a panicking job is
+// recovered and reported like a failed run, and every run is counted in the
+// job's metrics
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+	"github.com/MuthuM3/gin-microservice-template/internal/metrics"
+)
+
+// Func is the work of a job, ctx is cancelled when the scheduler stops
+type Func func(ctx context.Context) error
+
+// Scheduler runs registered jobs between Start and Stop
+type Scheduler struct {
+	log logger.Logger
+
+	mu      sync.Mutex
+	jobs    []*job
+	ctx     context.Context
+	cancel  context.CancelFunc
+	running sync.WaitGroup
+}
+
+type job struct {
+	name     string
+	schedule Schedule
+	// delay of a one-off job, used when schedule is nil
+	delay time.Duration
+	fn    Func
+}
+
+// NewScheduler creates a scheduler, jobs are added before Start
+func NewScheduler(log logger.Logger) *Scheduler {
+	return &Scheduler{log: log}
+}
+
+// Schedule registers fn to run on the schedule described by spec, see Parse
+func (s *Scheduler) Schedule(name, spec string, fn Func) error {
+	schedule, err := Parse(spec)
+	if err != nil {
+		return fmt.Errorf("failed to schedule job %s: %w", name, err)
+	}
+	s.add(&job{name: name, schedule: schedule, fn: fn})
+	return nil
+}
+
+// After registers fn to run once, delay after the scheduler starts or after
+// this call when it is already running
+func (s *Scheduler) After(name string, delay time.Duration, fn Func) {
+	s.add(&job{name: name, delay: delay, fn: fn})
+}
+
+func (s *Scheduler) add(j *job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.jobs = append(s.jobs, j)
+	if s.ctx != nil {
+		s.start(j)
+	}
+}
+
+// Start runs the registered jobs until ctx is cancelled or Stop is called
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	for _, j := range s.jobs {
+		s.start(j)
+	}
+}
+
+// Stop cancels the jobs and waits for running ones to return, giving up when
+// ctx is done
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("jobs did not stop in time: %w", ctx.Err())
+	}
+}
+
+// start runs the loop of a job, s.mu must be held
+func (s *Scheduler) start(j *job) {
+	ctx := s.ctx
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+
+		next := time.Now().Add(j.delay)
+		for {
+			if j.schedule != nil {
+				next = j.schedule.Next(time.Now())
+				if next.IsZero() {
+					s.log.Warn("job schedule has no further runs", "job", j.name)
+					return
+				}
+			}
+
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			}
+
+			s.run(ctx, j)
+			if j.schedule == nil {
+				return
+			}
+		}
+	}()
+}
+
+// run executes one run of a job and records its outcome
+func (s *Scheduler) run(ctx context.Context, j *job) {
+	labels := metrics.Labels{"job": j.name}
+	running := metrics.Default.Gauge("job_running", "Whether the job is currently running", labels)
+	running.Set(1)
+	defer running.Set(0)
+
+	start := time.Now()
+	err := safeRun(ctx, j.fn)
+	duration := time.Since(start)
+
+	status := "success"
+	switch {
+	case err == nil:
+		metrics.Default.Gauge("job_last_success_timestamp_seconds",
+			"Unix time of the last successful run of the job", labels,
+		).Set(float64(time.Now().Unix()))
+	case ctx.Err() != nil:
+		status = "cancelled"
+	default:
+		status = "failure"
+		s.log.Error("job failed", "job", j.name, "duration", duration, "error", err)
+	}
+
+	metrics.Default.Counter("job_runs_total", "Total number of job runs by outcome",
+		metrics.Labels{"job": j.name, "status": status},
+	).Inc()
+	metrics.Default.Counter("job_duration_seconds_total", "Total time spent running the job", labels).
+		Add(duration.Seconds())
+}
+
+// safeRun calls fn, turning a panic into an error so one job cannot take
+// the process down
+func safeRun(ctx context.Context, fn Func) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v\n%s", recovered, debug.Stack())
+		}
+	}()
+	return fn(ctx)
+}
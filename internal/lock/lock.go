@@ -0,0 +1,137 @@
+// Package lock provides locks held across replicas, so work such as
+// scheduled jobs runs on exactly one of them. A held lock is a Lease: it is
+// renewed in the background and its context is cancelled as soon as the
+// lock is released or lost, and its fencing token lets storage reject
+// writes from an owner whose lock has since passed to another
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrNotAcquired is returned by Lock when another owner holds the key
+	ErrNotAcquired = errors.New("lock is held by another owner")
+
+	// ErrLost is the cause of a lease context cancelled because the lock
+	// could not be renewed before it expired
+	ErrLost = errors.New("lock lost")
+)
+
+// Locker acquires locks
+type Locker interface {
+	// Lock acquires key for ttl without waiting, returning ErrNotAcquired
+	// when it is held. The lease is renewed until it is unlocked or ctx is
+	// done, ttl only bounds how long a crashed owner blocks others
+	Lock(ctx context.Context, key string, ttl time.Duration) (*Lease, error)
+}
+
+// backend stores the locks of a Locker
+type backend interface {
+	acquire(ctx context.Context, key, owner string, ttl time.Duration) (token int64, err error)
+	renew(ctx context.Context, key, owner string, ttl time.Duration) (bool, error)
+	release(ctx context.Context, key, owner string) error
+}
+
+// Lease is a held lock
+type Lease struct {
+	// Key is the locked key
+	Key string
+	// Token increases with every acquisition of Key
+	Token int64
+
+	ctx     context.Context
+	cancel  context.CancelCauseFunc
+	backend backend
+	owner   string
+	done    chan struct{}
+	err     error
+}
+
+// lock acquires key on b and starts renewing it
+func lock(ctx context.Context, b backend, key string, ttl time.Duration) (*Lease, error) {
+	owner, err := newOwner()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := b.acquire(ctx, key, owner, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	leaseCtx, cancel := context.WithCancelCause(ctx)
+	l := &Lease{
+		Key:     key,
+		Token:   token,
+		ctx:     leaseCtx,
+		cancel:  cancel,
+		backend: b,
+		owner:   owner,
+		done:    make(chan struct{}),
+	}
+	go l.keep(ttl)
+	return l, nil
+}
+
+// Context is done once the lock is released or lost, run the guarded work
+// under it. context.Cause returns ErrLost for a lost lock
+func (l *Lease) Context() context.Context {
+	return l.ctx
+}
+
+// Unlock stops renewing the lock and releases it
+func (l *Lease) Unlock() error {
+	l.cancel(nil)
+	<-l.done
+	return l.err
+}
+
+// keep renews the lock every third of its ttl until the lease context is
+// done, then releases it. Failed renewals are retried until the lock would
+// have expired
+func (l *Lease) keep(ttl time.Duration) {
+	defer close(l.done)
+
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+	renewed := time.Now()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-l.ctx.Done():
+			if errors.Is(context.Cause(l.ctx), ErrLost) {
+				return
+			}
+			// Released with a fresh context, the lease context is done
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(l.ctx), 5*time.Second)
+			l.err = l.backend.release(ctx, l.Key, l.owner)
+			cancel()
+			return
+		}
+
+		held, err := l.backend.renew(l.ctx, l.Key, l.owner, ttl)
+		switch {
+		case err == nil && !held:
+			l.cancel(ErrLost)
+		case err == nil:
+			renewed = time.Now()
+		case time.Since(renewed) >= ttl:
+			l.cancel(fmt.Errorf("%w: %w", ErrLost, err))
+		}
+	}
+}
+
+func newOwner() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate lock owner: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,70 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryLocker holds locks in process, for single instance deployments and
+// local development
+type MemoryLocker struct {
+	mu     sync.Mutex
+	locks  map[string]memoryLock
+	tokens map[string]int64
+}
+
+type memoryLock struct {
+	owner   string
+	expires time.Time
+}
+
+func NewMemoryLocker() *MemoryLocker {
+	return &MemoryLocker{
+		locks:  make(map[string]memoryLock),
+		tokens: make(map[string]int64),
+	}
+}
+
+// Lock acquires key for ttl, see Locker
+func (l *MemoryLocker) Lock(ctx context.Context, key string, ttl time.Duration) (*Lease, error) {
+	return lock(ctx, l, key, ttl)
+}
+
+func (l *MemoryLocker) acquire(_ context.Context, key, owner string, ttl time.Duration) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if held, ok := l.locks[key]; ok && now.Before(held.expires) {
+		return 0, ErrNotAcquired
+	}
+
+	l.locks[key] = memoryLock{owner: owner, expires: now.Add(ttl)}
+	l.tokens[key]++
+	return l.tokens[key], nil
+}
+
+func (l *MemoryLocker) renew(_ context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	held, ok := l.locks[key]
+	if !ok || held.owner != owner || !time.Now().Before(held.expires) {
+		return false, nil
+	}
+
+	held.expires = time.Now().Add(ttl)
+	l.locks[key] = held
+	return true, nil
+}
+
+func (l *MemoryLocker) release(_ context.Context, key, owner string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if held, ok := l.locks[key]; ok && held.owner == owner {
+		delete(l.locks, key)
+	}
+	return nil
+}
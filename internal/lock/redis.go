@@ -0,0 +1,88 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// acquireScript sets the lock if it is free and returns the next fencing
+// token, or 0 when the lock is held
+//
+// KEYS: lock, fencing counter
+// ARGV: owner, ttl in ms
+var acquireScript = redis.NewScript(`
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+	return redis.call('INCR', KEYS[2])
+end
+return 0
+`)
+
+// renewScript extends the lock if the caller still owns it
+//
+// KEYS: lock
+// ARGV: owner, ttl in ms
+var renewScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseScript deletes the lock if the caller still owns it
+//
+// KEYS: lock
+// ARGV: owner
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// RedisLocker holds locks in Redis so they are shared by all replicas
+type RedisLocker struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisLocker creates a locker, keys are namespaced under prefix
+func NewRedisLocker(client *redis.Client, prefix string) *RedisLocker {
+	return &RedisLocker{client: client, prefix: prefix + "lock:"}
+}
+
+// Lock acquires key for ttl, see Locker
+func (l *RedisLocker) Lock(ctx context.Context, key string, ttl time.Duration) (*Lease, error) {
+	return lock(ctx, l, key, ttl)
+}
+
+func (l *RedisLocker) acquire(ctx context.Context, key, owner string, ttl time.Duration) (int64, error) {
+	token, err := acquireScript.Run(ctx, l.client,
+		[]string{l.prefix + key, l.prefix + key + ":fence"},
+		owner, ttl.Milliseconds(),
+	).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("failed to acquire lock %s: %w", key, err)
+	}
+	if token == 0 {
+		return 0, ErrNotAcquired
+	}
+	return token, nil
+}
+
+func (l *RedisLocker) renew(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	held, err := renewScript.Run(ctx, l.client, []string{l.prefix + key}, owner, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return false, fmt.Errorf("failed to renew lock %s: %w", key, err)
+	}
+	return held == 1, nil
+}
+
+func (l *RedisLocker) release(ctx context.Context, key, owner string) error {
+	if err := releaseScript.Run(ctx, l.client, []string{l.prefix + key}, owner).Err(); err != nil {
+		return fmt.Errorf("failed to release lock %s: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+// Package lockout tracks failed login attempts and locks keys out once too
+// many failures accumulate
+package lockout
+
+import (
+	"context"
+	"time"
+)
+
+// Tracker counts failures per key. After maxAttempts failures within the
+// lockout duration the key is locked for that duration
+type Tracker interface {
+	// LockedUntil returns when the key's lockout ends, or the zero time when
+	// the key is not locked
+	LockedUntil(ctx context.Context, key string) (time.Time, error)
+
+	// Fail records a failed attempt and returns when the lockout ends if this
+	// failure reached the threshold, or the zero time otherwise
+	Fail(ctx context.Context, key string) (time.Time, error)
+
+	// Reset clears the failures and any lockout of the key
+	Reset(ctx context.Context, key string) error
+}
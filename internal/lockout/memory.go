@@ -0,0 +1,111 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryTracker tracks failures in process for single instance deployments
+type MemoryTracker struct {
+	maxAttempts int
+	duration    time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*entry
+
+	stop chan struct{}
+	once sync.Once
+}
+
+type entry struct {
+	failures    int
+	expiresAt   time.Time // end of the failure counting window
+	lockedUntil time.Time
+}
+
+// NewMemoryTracker creates a tracker and starts a janitor that evicts
+// expired entries
+func NewMemoryTracker(maxAttempts int, duration time.Duration) *MemoryTracker {
+	t := &MemoryTracker{
+		maxAttempts: maxAttempts,
+		duration:    duration,
+		entries:     make(map[string]*entry),
+		stop:        make(chan struct{}),
+	}
+
+	go t.cleanup(duration)
+	return t
+}
+
+// LockedUntil returns when the key's lockout ends
+func (t *MemoryTracker) LockedUntil(_ context.Context, key string) (time.Time, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	e, ok := t.entries[key]
+	if !ok || !e.lockedUntil.After(time.Now()) {
+		return time.Time{}, nil
+	}
+	return e.lockedUntil, nil
+}
+
+// Fail records a failed attempt, locking the key once the threshold is reached
+func (t *MemoryTracker) Fail(_ context.Context, key string) (time.Time, error) {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	e, ok := t.entries[key]
+	if !ok || (now.After(e.expiresAt) && now.After(e.lockedUntil)) {
+		e = &entry{expiresAt: now.Add(t.duration)}
+		t.entries[key] = e
+	}
+
+	e.failures++
+	if e.failures < t.maxAttempts {
+		return time.Time{}, nil
+	}
+
+	e.failures = 0
+	e.lockedUntil = now.Add(t.duration)
+	e.expiresAt = e.lockedUntil
+	return e.lockedUntil, nil
+}
+
+// Reset forgets the key
+func (t *MemoryTracker) Reset(_ context.Context, key string) error {
+	t.mu.Lock()
+	delete(t.entries, key)
+	t.mu.Unlock()
+	return nil
+}
+
+// Close stops the janitor goroutine
+func (t *MemoryTracker) Close() error {
+	t.once.Do(func() { close(t.stop) })
+	return nil
+}
+
+// cleanup periodically removes entries whose window and lockout have passed
+func (t *MemoryTracker) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			now := time.Now()
+			t.mu.Lock()
+			for key, e := range t.entries {
+				if now.After(e.expiresAt) && now.After(e.lockedUntil) {
+					delete(t.entries, key)
+				}
+			}
+			t.mu.Unlock()
+		case <-t.stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package lockout
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// failScript counts a failure in a counter that expires after the lockout
+// duration. When the threshold is reached the counter is replaced by a lock
+// key and the lock duration in milliseconds is returned, otherwise 0
+var failScript = redis.NewScript(`
+local failures = KEYS[1]
+local lock = KEYS[2]
+local max = tonumber(ARGV[1])
+local duration = tonumber(ARGV[2])
+
+local count = redis.call('INCR', failures)
+if count == 1 then
+	redis.call('PEXPIRE', failures, duration)
+end
+
+if count >= max then
+	redis.call('SET', lock, '1', 'PX', duration)
+	redis.call('DEL', failures)
+	return duration
+end
+return 0
+`)
+
+// RedisTracker tracks failures in Redis so lockouts apply across replicas
+type RedisTracker struct {
+	client      *redis.Client
+	prefix      string
+	maxAttempts int
+	duration    time.Duration
+}
+
+// NewRedisTracker creates a tracker storing its keys under prefix
+func NewRedisTracker(client *redis.Client, maxAttempts int, duration time.Duration, prefix string) *RedisTracker {
+	return &RedisTracker{
+		client:      client,
+		prefix:      prefix,
+		maxAttempts: maxAttempts,
+		duration:    duration,
+	}
+}
+
+// LockedUntil returns when the key's lockout ends
+func (t *RedisTracker) LockedUntil(ctx context.Context, key string) (time.Time, error) {
+	ttl, err := t.client.PTTL(ctx, t.lockKey(key)).Result()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to check lockout: %w", err)
+	}
+	if ttl <= 0 {
+		return time.Time{}, nil
+	}
+	return time.Now().Add(ttl), nil
+}
+
+// Fail records a failed attempt, locking the key once the threshold is reached
+func (t *RedisTracker) Fail(ctx context.Context, key string) (time.Time, error) {
+	lockedFor, err := failScript.Run(ctx, t.client,
+		[]string{t.failuresKey(key), t.lockKey(key)},
+		t.maxAttempts, t.duration.Milliseconds(),
+	).Int64()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to record login failure: %w", err)
+	}
+	if lockedFor == 0 {
+		return time.Time{}, nil
+	}
+	return time.Now().Add(time.Duration(lockedFor) * time.Millisecond), nil
+}
+
+// Reset deletes the key's failure counter and lock
+func (t *RedisTracker) Reset(ctx context.Context, key string) error {
+	if err := t.client.Del(ctx, t.failuresKey(key), t.lockKey(key)).Err(); err != nil {
+		return fmt.Errorf("failed to reset lockout: %w", err)
+	}
+	return nil
+}
+
+func (t *RedisTracker) failuresKey(key string) string {
+	return t.prefix + "lockout:failures:" + key
+}
+
+func (t *RedisTracker) lockKey(key string) string {
+	return t.prefix + "lockout:locked:" + key
+}
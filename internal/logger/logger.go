@@ -0,0 +1,193 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/requestid"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Logger is the structured logger used throughout the application. Arguments
+// after the message are alternating key/value pairs
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+
+	// With returns a logger that adds the given key/value pairs to every entry
+	With(args ...any) Logger
+}
+
+type slogLogger struct {
+	l     *slog.Logger
+	level *slog.LevelVar
+}
+
+// New builds a logger from the logger configuration. Entries are written to
+// every configured output; the returned closer releases the output files and
+// syslog connections and should be called on shutdown
+func New(cfg *config.LoggerConfig) (Logger, io.Closer, error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, nil, err
+	}
+	format := strings.ToLower(cfg.Format)
+	if format != "json" && format != "text" && format != "console" {
+		return nil, nil, fmt.Errorf("unknown log format %q", cfg.Format)
+	}
+
+	levelVar := new(slog.LevelVar)
+	levelVar.Set(level)
+	opts := &slog.HandlerOptions{Level: levelVar}
+
+	outputs := cfg.Outputs
+	if len(outputs) == 0 {
+		outputs = []string{cfg.OutputPath}
+	}
+
+	handlers := make([]slog.Handler, 0, len(outputs))
+	closers := make(multiCloser, 0, len(outputs))
+	for _, output := range outputs {
+		output = strings.TrimSpace(output)
+
+		var handler slog.Handler
+		var closer io.Closer
+		if strings.EqualFold(output, "syslog") {
+			handler, closer, err = newSyslogHandler(cfg.Syslog, format, opts)
+		} else {
+			var out io.Writer
+			out, closer, err = OpenOutput(output, cfg.Rotation)
+			handler = newHandler(out, format, opts)
+		}
+		if err != nil {
+			closers.Close()
+			return nil, nil, err
+		}
+
+		handlers = append(handlers, handler)
+		closers = append(closers, closer)
+	}
+
+	handler := handlers[0]
+	if len(handlers) > 1 {
+		handler = fanoutHandler(handlers)
+	}
+	return &slogLogger{l: slog.New(handler), level: levelVar}, closers, nil
+}
+
+// newHandler creates the handler writing entries to out in the format
+func newHandler(out io.Writer, format string, opts *slog.HandlerOptions) slog.Handler {
+	if format == "json" {
+		return slog.NewJSONHandler(out, opts)
+	}
+	return slog.NewTextHandler(out, opts)
+}
+
+// NewNop returns a logger that discards everything
+func NewNop() Logger {
+	return &slogLogger{l: slog.New(slog.NewTextHandler(io.Discard, nil)), level: new(slog.LevelVar)}
+}
+
+func (s *slogLogger) Debug(msg string, args ...any) { s.l.Debug(msg, args...) }
+func (s *slogLogger) Info(msg string, args ...any)  { s.l.Info(msg, args...) }
+func (s *slogLogger) Warn(msg string, args ...any)  { s.l.Warn(msg, args...) }
+func (s *slogLogger) Error(msg string, args ...any) { s.l.Error(msg, args...) }
+
+func (s *slogLogger) With(args ...any) Logger {
+	return &slogLogger{l: s.l.With(args...), level: s.level}
+}
+
+// SetLevel changes the minimum level at runtime. Loggers derived with With
+// share the level of their parent
+func (s *slogLogger) SetLevel(level string) error {
+	parsed, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	s.level.Set(parsed)
+	return nil
+}
+
+// ParseLevel converts a configured level name into a slog level
+func ParseLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info", "":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("unknown log level %q", level)
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
+
+// multiCloser closes every output, returning the first error
+type multiCloser []io.Closer
+
+func (m multiCloser) Close() error {
+	var first error
+	for _, closer := range m {
+		if err := closer.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// OpenOutput resolves an output path, "stdout" and "stderr" map to the
+// standard streams and anything else is opened as an append-only file,
+// rotated by size when rotation is enabled
+func OpenOutput(path string, rotation config.LogRotationConfig) (io.Writer, io.Closer, error) {
+	switch strings.ToLower(path) {
+	case "", "stdout":
+		return os.Stdout, nopCloser{}, nil
+	case "stderr":
+		return os.Stderr, nopCloser{}, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	if rotation.Enabled {
+		file := &lumberjack.Logger{
+			Filename:   path,
+			MaxSize:    rotation.MaxSizeMB,
+			MaxBackups: rotation.MaxBackups,
+			MaxAge:     int(math.Ceil(rotation.MaxAge.Hours() / 24)),
+			Compress:   rotation.Compress,
+		}
+		return file, file, nil
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	return file, file, nil
+}
+
+// FromContext returns log annotated with the correlation fields carried by
+// ctx, such as the request id
+func FromContext(ctx context.Context, log Logger) Logger {
+	if id := requestid.FromContext(ctx); id != "" {
+		return log.With("request_id", id)
+	}
+	return log
+}
@@ -0,0 +1,74 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"log/syslog"
+	"sync"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+)
+
+// syslogHandler formats entries like the other outputs and sends each one
+// to syslog with the priority of its level. Handlers derived with WithAttrs
+// and WithGroup share the connection and the formatting buffer
+type syslogHandler struct {
+	inner slog.Handler
+	out   *syslogOutput
+}
+
+type syslogOutput struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	writer *syslog.Writer
+}
+
+// newSyslogHandler connects to the syslog daemon of cfg
+func newSyslogHandler(cfg config.LogSyslogConfig, format string, opts *slog.HandlerOptions) (slog.Handler, io.Closer, error) {
+	writer, err := syslog.Dial(cfg.Network, cfg.Address, syslog.LOG_INFO|syslog.LOG_DAEMON, cfg.Tag)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+
+	out := &syslogOutput{writer: writer}
+	return &syslogHandler{inner: newHandler(&out.buf, format, opts), out: out}, writer, nil
+}
+
+func (h *syslogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h *syslogHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.out.mu.Lock()
+	defer h.out.mu.Unlock()
+
+	h.out.buf.Reset()
+	if err := h.inner.Handle(ctx, r); err != nil {
+		return err
+	}
+	message := string(bytes.TrimRight(h.out.buf.Bytes(), "\n"))
+
+	switch {
+	case r.Level >= slog.LevelError:
+		return h.out.writer.Err(message)
+	case r.Level >= slog.LevelWarn:
+		return h.out.writer.Warning(message)
+	case r.Level >= slog.LevelInfo:
+		return h.out.writer.Info(message)
+	default:
+		return h.out.writer.Debug(message)
+	}
+}
+
+func (h *syslogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &syslogHandler{inner: h.inner.WithAttrs(attrs), out: h.out}
+}
+
+func (h *syslogHandler) WithGroup(name string) slog.Handler {
+	return &syslogHandler{inner: h.inner.WithGroup(name), out: h.out}
+}
@@ -0,0 +1,15 @@
+//go:build windows || plan9
+
+package logger
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+)
+
+func newSyslogHandler(config.LogSyslogConfig, string, *slog.HandlerOptions) (slog.Handler, io.Closer, error) {
+	return nil, nil, errors.New("the syslog log output is not supported on this platform")
+}
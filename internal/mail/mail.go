@@ -0,0 +1,51 @@
+// Package mail sends transactional emails such as password reset links
+package mail
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+)
+
+// Message is a plain text email
+type Message struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// Mailer delivers messages
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New creates the mailer for the configured provider
+func New(cfg *config.EmailConfig, log logger.Logger) (Mailer, error) {
+	switch cfg.Provider {
+	case "smtp":
+		return NewSMTPMailer(cfg), nil
+	case "log":
+		return NewLogMailer(log), nil
+	default:
+		return nil, fmt.Errorf("unknown email provider %q", cfg.Provider)
+	}
+}
+
+// LogMailer writes messages to the log instead of sending them, for local
+// development where no mail server is available
+type LogMailer struct {
+	logger logger.Logger
+}
+
+func NewLogMailer(log logger.Logger) *LogMailer {
+	return &LogMailer{logger: log}
+}
+
+// Send logs the message
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	logger.FromContext(ctx, m.logger).Info("email not sent, logging instead",
+		"to", msg.To, "subject", msg.Subject, "body", msg.Body)
+	return nil
+}
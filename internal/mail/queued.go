@@ -0,0 +1,41 @@
+package mail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/queue"
+)
+
+// TaskSend is the queue task type delivering a Message
+const TaskSend = "email:send"
+
+// QueuedMailer hands messages to the task queue so requests do not wait for
+// the mail server, a worker delivers them with SendHandler
+type QueuedMailer struct {
+	client *queue.Client
+}
+
+func NewQueuedMailer(client *queue.Client) *QueuedMailer {
+	return &QueuedMailer{client: client}
+}
+
+// Send enqueues the message
+func (m *QueuedMailer) Send(ctx context.Context, msg Message) error {
+	if _, err := m.client.Enqueue(ctx, TaskSend, msg); err != nil {
+		return fmt.Errorf("failed to queue email: %w", err)
+	}
+	return nil
+}
+
+// SendHandler delivers queued messages with mailer
+func SendHandler(mailer Mailer) queue.HandlerFunc {
+	return func(ctx context.Context, task *queue.Task) error {
+		var msg Message
+		if err := json.Unmarshal(task.Payload, &msg); err != nil {
+			return fmt.Errorf("%w: invalid email task: %v", queue.ErrSkipRetry, err)
+		}
+		return mailer.Send(ctx, msg)
+	}
+}
@@ -0,0 +1,54 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaDriver writes every event to one topic keyed by entity id, so the
+// events of an entity stay ordered within their partition. The event type is
+// carried in the "type" header
+type KafkaDriver struct {
+	writer *kafka.Writer
+}
+
+func NewKafkaDriver(cfg *config.KafkaConfig, clientID string) *KafkaDriver {
+	return &KafkaDriver{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(cfg.Brokers...),
+			Topic:        cfg.Topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			// Events are sent one at a time from request handlers, waiting
+			// for a batch to fill up would only add latency
+			BatchTimeout: 10 * time.Millisecond,
+			Transport:    &kafka.Transport{ClientID: clientID},
+		},
+	}
+}
+
+// Send writes the message and waits for the brokers to acknowledge it
+func (d *KafkaDriver) Send(ctx context.Context, msg Message) error {
+	err := d.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(msg.Key),
+		Value: msg.Body,
+		Headers: []kafka.Header{
+			{Key: "id", Value: []byte(msg.ID)},
+			{Key: "type", Value: []byte(msg.Subject)},
+			{Key: "content-type", Value: []byte("application/json")},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write to %s: %w", d.writer.Topic, err)
+	}
+	return nil
+}
+
+// Close flushes pending messages and closes the writer
+func (d *KafkaDriver) Close() error {
+	return d.writer.Close()
+}
@@ -0,0 +1,107 @@
+// Package messaging publishes domain events to an external message broker so
+// other services can react to them
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/events"
+)
+
+// SchemaVersion is the version of the Envelope format. It is bumped on
+// incompatible changes so consumers can handle old and new messages side by
+// side
+const SchemaVersion = 1
+
+// Envelope is the JSON document published for every event. ID stays the same
+// when an event is delivered more than once so consumers can drop
+// duplicates, Data holds the entity as returned by the REST API
+type Envelope struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Version    int             `json:"version"`
+	Source     string          `json:"source"`
+	UserID     int64           `json:"user_id"`
+	EntityID   int64           `json:"entity_id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// Message is an encoded envelope ready to be sent. Subject is the event type
+// and Key the entity id, brokers that partition keep messages with the same
+// key in order
+type Message struct {
+	ID      string
+	Subject string
+	Key     string
+	Body    []byte
+}
+
+// Driver sends messages to a broker
+type Driver interface {
+	Send(ctx context.Context, msg Message) error
+	Close() error
+}
+
+// New creates the driver for the configured broker
+func New(cfg *config.MessagingConfig) (Driver, error) {
+	switch cfg.Driver {
+	case "nats":
+		return NewNATSDriver(&cfg.NATS, cfg.Source)
+	case "kafka":
+		return NewKafkaDriver(&cfg.Kafka, cfg.Source), nil
+	default:
+		return nil, fmt.Errorf("unknown messaging driver %q", cfg.Driver)
+	}
+}
+
+// Publisher forwards events to a broker wrapped in an Envelope, it is an
+// events.Publisher so it can sit next to the in-process event bus
+type Publisher struct {
+	driver  Driver
+	source  string
+	timeout time.Duration
+}
+
+func NewPublisher(driver Driver, source string, timeout time.Duration) *Publisher {
+	return &Publisher{driver: driver, source: source, timeout: timeout}
+}
+
+// Publish encodes the event and sends it, waiting at most the publish timeout
+func (p *Publisher) Publish(ctx context.Context, _ string, event events.Event) error {
+	envelope := Envelope{
+		ID:         event.Key,
+		Type:       event.Type,
+		Version:    SchemaVersion,
+		Source:     p.source,
+		UserID:     event.UserID,
+		EntityID:   event.EntityID,
+		OccurredAt: event.OccurredAt,
+		Data:       event.Data,
+	}
+
+	body, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s message: %w", event.Type, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	return p.driver.Send(ctx, Message{
+		ID:      envelope.ID,
+		Subject: event.Type,
+		Key:     strconv.FormatInt(event.EntityID, 10),
+		Body:    body,
+	})
+}
+
+// Close releases the broker connection
+func (p *Publisher) Close() error {
+	return p.driver.Close()
+}
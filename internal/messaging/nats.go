@@ -0,0 +1,54 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/nats-io/nats.go"
+)
+
+// NATSDriver publishes messages to NATS subjects named
+// SubjectPrefix.<event type>, e.g. "todo-api.todo.created"
+type NATSDriver struct {
+	conn   *nats.Conn
+	prefix string
+}
+
+// NewNATSDriver connects to the NATS server, the client reconnects on its own
+// when the connection drops later on
+func NewNATSDriver(cfg *config.NATSConfig, name string) (*NATSDriver, error) {
+	conn, err := nats.Connect(cfg.URL,
+		nats.Name(name),
+		nats.Timeout(cfg.ConnectTimeout),
+		nats.MaxReconnects(-1),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to nats: %w", err)
+	}
+
+	return &NATSDriver{conn: conn, prefix: cfg.SubjectPrefix}, nil
+}
+
+// Send publishes the message and waits for the server to acknowledge it. The
+// message id is passed in Nats-Msg-Id so JetStream streams deduplicate it
+func (d *NATSDriver) Send(ctx context.Context, msg Message) error {
+	header := nats.Header{}
+	header.Set("Nats-Msg-Id", msg.ID)
+	header.Set("Content-Type", "application/json")
+
+	subject := d.prefix + "." + msg.Subject
+	err := d.conn.PublishMsg(&nats.Msg{Subject: subject, Header: header, Data: msg.Body})
+	if err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", subject, err)
+	}
+	if err := d.conn.FlushWithContext(ctx); err != nil {
+		return fmt.Errorf("failed to flush %s: %w", subject, err)
+	}
+	return nil
+}
+
+// Close delivers buffered messages and closes the connection
+func (d *NATSDriver) Close() error {
+	return d.conn.Drain()
+}
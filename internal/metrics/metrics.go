@@ -0,0 +1,312 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Labels are the label name/value pairs identifying a single series
+type Labels map[string]string
+
+// Default is the process-wide registry used by the package level helpers
+var Default = NewRegistry()
+
+type metricType string
+
+const (
+	typeCounter   metricType = "counter"
+	typeGauge     metricType = "gauge"
+	typeHistogram metricType = "histogram"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the latency
+// histograms that do not choose their own
+var DefaultBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// family groups all series sharing a metric name
+type family struct {
+	name   string
+	help   string
+	typ    metricType
+	series map[string]*series
+}
+
+type series struct {
+	labels    string
+	value     atomicFloat
+	fn        func() float64
+	histogram *histogram
+}
+
+// histogram counts observations per bucket, counts[i] holds those up to
+// bounds[i] and the last count those above every bound
+type histogram struct {
+	bounds []float64
+	counts []atomic.Uint64
+	sum    atomicFloat
+}
+
+// Registry holds named metrics and renders them in the Prometheus text format
+type Registry struct {
+	mu       sync.RWMutex
+	families map[string]*family
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// Counter is a monotonically increasing value
+type Counter struct{ s *series }
+
+// Inc increments the counter by one
+func (c *Counter) Inc() { c.s.value.add(1) }
+
+// Add increments the counter by v, negative values are ignored
+func (c *Counter) Add(v float64) {
+	if v > 0 {
+		c.s.value.add(v)
+	}
+}
+
+// Value returns the current counter value
+func (c *Counter) Value() float64 { return c.s.value.load() }
+
+// Gauge is a value that can go up and down
+type Gauge struct{ s *series }
+
+// Set sets the gauge to v
+func (g *Gauge) Set(v float64) { g.s.value.store(v) }
+
+// Inc increments the gauge by one
+func (g *Gauge) Inc() { g.s.value.add(1) }
+
+// Dec decrements the gauge by one
+func (g *Gauge) Dec() { g.s.value.add(-1) }
+
+// Add adds v to the gauge
+func (g *Gauge) Add(v float64) { g.s.value.add(v) }
+
+// Value returns the current gauge value
+func (g *Gauge) Value() float64 { return g.s.value.load() }
+
+// Histogram records the distribution of observed values, such as latencies
+type Histogram struct{ h *histogram }
+
+// Observe records v in the bucket it falls in
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.h.bounds, v)
+	h.h.counts[i].Add(1)
+	h.h.sum.add(v)
+}
+
+// Counter returns the counter series for name and labels, creating it on first use
+func (r *Registry) Counter(name, help string, labels Labels) *Counter {
+	return &Counter{s: r.series(name, help, typeCounter, labels)}
+}
+
+// Gauge returns the gauge series for name and labels, creating it on first use
+func (r *Registry) Gauge(name, help string, labels Labels) *Gauge {
+	return &Gauge{s: r.series(name, help, typeGauge, labels)}
+}
+
+// Histogram returns the histogram series for name and labels with the given
+// bucket upper bounds, creating it on first use. Buckets must be sorted, the
+// buckets of the first use are kept
+func (r *Registry) Histogram(name, help string, buckets []float64, labels Labels) *Histogram {
+	s := r.series(name, help, typeHistogram, labels)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s.histogram == nil {
+		s.histogram = &histogram{bounds: buckets, counts: make([]atomic.Uint64, len(buckets)+1)}
+	}
+	return &Histogram{h: s.histogram}
+}
+
+// GaugeFunc registers a gauge whose value is computed by fn at scrape time
+func (r *Registry) GaugeFunc(name, help string, labels Labels, fn func() float64) {
+	s := r.series(name, help, typeGauge, labels)
+
+	r.mu.Lock()
+	s.fn = fn
+	r.mu.Unlock()
+}
+
+func (r *Registry) series(name, help string, typ metricType, labels Labels) *series {
+	key := formatLabels(labels)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, ok := r.families[name]
+	if !ok {
+		f = &family{name: name, help: help, typ: typ, series: make(map[string]*series)}
+		r.families[name] = f
+	}
+
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labels: key}
+		f.series[key] = s
+	}
+	return s
+}
+
+// WriteTo renders every registered metric in the Prometheus text exposition format
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		f := r.families[name]
+		fmt.Fprintf(&b, "# HELP %s %s\n", f.name, f.help)
+		fmt.Fprintf(&b, "# TYPE %s %s\n", f.name, f.typ)
+
+		keys := make([]string, 0, len(f.series))
+		for key := range f.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			s := f.series[key]
+			if s.histogram != nil {
+				writeHistogram(&b, f.name, s)
+				continue
+			}
+			value := s.value.load()
+			if s.fn != nil {
+				value = s.fn()
+			}
+			fmt.Fprintf(&b, "%s%s %s\n", f.name, s.labels, formatValue(value))
+		}
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// writeHistogram renders the cumulative buckets, sum and count of a
+// histogram series
+func writeHistogram(b *strings.Builder, name string, s *series) {
+	withBound := func(bound string) string {
+		if s.labels == "" {
+			return `{le="` + bound + `"}`
+		}
+		return strings.TrimSuffix(s.labels, "}") + `,le="` + bound + `"}`
+	}
+
+	var count uint64
+	for i := range s.histogram.counts {
+		count += s.histogram.counts[i].Load()
+		bound := "+Inf"
+		if i < len(s.histogram.bounds) {
+			bound = formatValue(s.histogram.bounds[i])
+		}
+		fmt.Fprintf(b, "%s_bucket%s %d\n", name, withBound(bound), count)
+	}
+	fmt.Fprintf(b, "%s_sum%s %s\n", name, s.labels, formatValue(s.histogram.sum.load()))
+	fmt.Fprintf(b, "%s_count%s %d\n", name, s.labels, count)
+}
+
+// Handler returns an http.Handler serving the registry contents
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteTo(w)
+	})
+}
+
+// NewCounter returns a counter from the default registry
+func NewCounter(name, help string, labels Labels) *Counter {
+	return Default.Counter(name, help, labels)
+}
+
+// NewGauge returns a gauge from the default registry
+func NewGauge(name, help string, labels Labels) *Gauge {
+	return Default.Gauge(name, help, labels)
+}
+
+// NewHistogram returns a histogram from the default registry
+func NewHistogram(name, help string, buckets []float64, labels Labels) *Histogram {
+	return Default.Histogram(name, help, buckets, labels)
+}
+
+// NewGaugeFunc registers a computed gauge on the default registry
+func NewGaugeFunc(name, help string, labels Labels, fn func() float64) {
+	Default.GaugeFunc(name, help, labels, fn)
+}
+
+// Handler returns an http.Handler serving the default registry
+func Handler() http.Handler {
+	return Default.Handler()
+}
+
+func formatLabels(labels Labels) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(labels[name])
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, name, value))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return fmt.Sprintf("%g", v)
+}
+
+// atomicFloat is a float64 that can be updated concurrently
+type atomicFloat struct {
+	bits atomic.Uint64
+}
+
+func (f *atomicFloat) load() float64 {
+	return math.Float64frombits(f.bits.Load())
+}
+
+func (f *atomicFloat) store(v float64) {
+	f.bits.Store(math.Float64bits(v))
+}
+
+func (f *atomicFloat) add(delta float64) {
+	for {
+		old := f.bits.Load()
+		updated := math.Float64bits(math.Float64frombits(old) + delta)
+		if f.bits.CompareAndSwap(old, updated) {
+			return
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package metrics
+
+import (
+	"runtime"
+	"time"
+)
+
+// RegisterRuntimeMetrics registers Go runtime and process gauges on the registry
+func RegisterRuntimeMetrics(r *Registry, startTime time.Time) {
+	r.GaugeFunc("go_goroutines", "Number of goroutines that currently exist.", nil, func() float64 {
+		return float64(runtime.NumGoroutine())
+	})
+
+	r.GaugeFunc("go_memstats_alloc_bytes", "Number of bytes allocated and still in use.", nil, func() float64 {
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+		return float64(m.Alloc)
+	})
+
+	r.GaugeFunc("process_uptime_seconds", "Time since the process started in seconds.", nil, func() float64 {
+		return time.Since(startTime).Seconds()
+	})
+}
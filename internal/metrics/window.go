@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// Window counts events and the failed ones among them in one minute buckets
+// over the last minutes, so rates over recent periods can be reported
+// without a metrics backend
+type Window struct {
+	mu      sync.Mutex
+	buckets []windowBucket
+	now     func() time.Time
+}
+
+type windowBucket struct {
+	minute int64
+	total  int64
+	failed int64
+}
+
+// Rate summarizes the events of a period
+type Rate struct {
+	Total     int64   `json:"total"`
+	Failed    int64   `json:"failed"`
+	ErrorRate float64 `json:"error_rate"`
+}
+
+// NewWindow creates a window keeping the given number of minutes
+func NewWindow(minutes int) *Window {
+	return &Window{buckets: make([]windowBucket, max(minutes, 1)), now: time.Now}
+}
+
+// Observe counts an event of the current minute
+func (w *Window) Observe(failed bool) {
+	minute := w.now().Unix() / 60
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	b := &w.buckets[minute%int64(len(w.buckets))]
+	if b.minute != minute {
+		*b = windowBucket{minute: minute}
+	}
+	b.total++
+	if failed {
+		b.failed++
+	}
+}
+
+// Rate sums the events of the last minutes including the current one,
+// periods longer than the window are cut to it
+func (w *Window) Rate(minutes int) Rate {
+	current := w.now().Unix() / 60
+	oldest := current - int64(min(minutes, len(w.buckets))) + 1
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var rate Rate
+	for _, b := range w.buckets {
+		if b.minute >= oldest && b.minute <= current {
+			rate.Total += b.total
+			rate.Failed += b.failed
+		}
+	}
+	if rate.Total > 0 {
+		rate.ErrorRate = float64(rate.Failed) / float64(rate.Total)
+	}
+	return rate
+}
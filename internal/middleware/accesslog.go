@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// clfTimeFormat is the timestamp layout of the Common Log Format
+const clfTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// AccessLog writes a line per request to out in the Apache Common Log
+// Format, or in the Combined Log Format that adds the referer and user
+// agent when format is "combined". The user field holds the id of the
+// authenticated user
+func AccessLog(out io.Writer, format string) gin.HandlerFunc {
+	combined := strings.EqualFold(format, "combined")
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		user := "-"
+		if userID, ok := UserID(c); ok {
+			user = strconv.FormatInt(userID, 10)
+		}
+		size := "-"
+		if n := c.Writer.Size(); n > 0 {
+			size = strconv.Itoa(n)
+		}
+
+		var b strings.Builder
+		b.WriteString(ClientIP(c))
+		b.WriteString(" - ")
+		b.WriteString(user)
+		b.WriteString(" [")
+		b.WriteString(start.Format(clfTimeFormat))
+		b.WriteString(`] "`)
+		b.WriteString(clfEscape(c.Request.Method + " " + c.Request.RequestURI + " " + c.Request.Proto))
+		b.WriteString(`" `)
+		b.WriteString(strconv.Itoa(c.Writer.Status()))
+		b.WriteString(" ")
+		b.WriteString(size)
+		if combined {
+			b.WriteString(` "`)
+			b.WriteString(clfField(c.Request.Referer()))
+			b.WriteString(`" "`)
+			b.WriteString(clfField(c.Request.UserAgent()))
+			b.WriteString(`"`)
+		}
+		b.WriteString("\n")
+
+		// A single write per line keeps concurrent requests from interleaving
+		io.WriteString(out, b.String())
+	}
+}
+
+// clfField escapes a quoted header value, missing values are logged as "-"
+func clfField(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return clfEscape(value)
+}
+
+// clfEscape escapes quotes, backslashes and control characters like Apache
+// so clients cannot forge log lines
+func clfEscape(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		switch ch := value[i]; {
+		case ch == '"' || ch == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(ch)
+		case ch < 0x20 || ch == 0x7f:
+			b.WriteString(`\x`)
+			b.WriteString(strconv.FormatUint(uint64(ch)>>4, 16))
+			b.WriteString(strconv.FormatUint(uint64(ch)&0xf, 16))
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/apierror"
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminTokenHeader carries the shared secret for administrative endpoints
+const AdminTokenHeader = "X-Admin-Token"
+
+// adminTokenKey marks requests authenticated with the admin token
+const adminTokenKey = "admin_token"
+
+// AdminToken requires the admin token in the X-Admin-Token header. Every
+// request is rejected when no token is configured
+func AdminToken(token string) gin.HandlerFunc {
+	expected := []byte(token)
+
+	return func(c *gin.Context) {
+		provided := []byte(c.GetHeader(AdminTokenHeader))
+		if token == "" || subtle.ConstantTimeCompare(provided, expected) != 1 {
+			abortUnauthorized(c, "invalid admin token")
+			return
+		}
+		c.Next()
+	}
+}
+
+// UserLookup loads the user checked by RequireAdmin, see
+// storage.UserRepository
+type UserLookup interface {
+	GetUserByID(ctx context.Context, id int64) (*models.User, error)
+}
+
+// RequireAdmin accepts requests carrying the admin token in the
+// X-Admin-Token header and authenticates all others with authenticate,
+// letting them through when their user has the admin role. An empty token
+// leaves the role as the only way in
+func RequireAdmin(token string, authenticate gin.HandlerFunc, users UserLookup, log logger.Logger) []gin.HandlerFunc {
+	expected := []byte(token)
+
+	byToken := func(c *gin.Context) {
+		provided := c.GetHeader(AdminTokenHeader)
+		if token == "" || provided == "" {
+			authenticate(c)
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(provided), expected) != 1 {
+			abortUnauthorized(c, "invalid admin token")
+			return
+		}
+		c.Set(adminTokenKey, true)
+		c.Next()
+	}
+
+	byRole := func(c *gin.Context) {
+		if c.GetBool(adminTokenKey) {
+			c.Next()
+			return
+		}
+
+		userID, ok := UserID(c)
+		if !ok {
+			abortUnauthorized(c, "authentication required")
+			return
+		}
+		user, err := users.GetUserByID(c.Request.Context(), userID)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				abortUnauthorized(c, "unknown user")
+				return
+			}
+			logger.FromContext(c.Request.Context(), log).Error("failed to load user", "user_id", userID, "error", err)
+			AbortWithError(c, apierror.Internal(err))
+			return
+		}
+		if !user.IsAdmin() {
+			AbortWithError(c, apierror.Forbidden("admin role required"))
+			return
+		}
+		c.Next()
+	}
+
+	return []gin.HandlerFunc{byToken, byRole}
+}
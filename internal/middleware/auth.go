@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/apierror"
+	"github.com/MuthuM3/gin-microservice-template/internal/auth"
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+	"github.com/MuthuM3/gin-microservice-template/internal/requestid"
+	"github.com/MuthuM3/gin-microservice-template/internal/session"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	userIDKey    = "user_id"
+	claimsKey    = "claims"
+	sessionKey   = "session"
+	requestIDKey = "request_id"
+
+	// RequestIDHeader carries the request correlation id
+	RequestIDHeader = requestid.Header
+
+	// CSRFHeader carries the CSRF token of a cookie session
+	CSRFHeader = "X-CSRF-Token"
+)
+
+// SessionAuthenticator resolves cookie sessions, see service.SessionService
+type SessionAuthenticator interface {
+	// Authenticate returns the session of a cookie or session.ErrNotFound
+	Authenticate(ctx context.Context, token string) (*session.Session, error)
+
+	// CheckCSRF reports whether token is the CSRF token of the session
+	CheckCSRF(sess *session.Session, token string) bool
+}
+
+// CookieSessions lets Auth accept the session cookie named Cookie from
+// clients that send no bearer token
+type CookieSessions struct {
+	Cookie   string
+	Sessions SessionAuthenticator
+}
+
+// Auth requires a valid bearer token whose session has not been revoked and
+// stores the authenticated user in the context. With cookies set, requests
+// without an Authorization header may authenticate with a session cookie
+// instead; those changing state must carry the CSRF token of the session
+func Auth(tokens *auth.TokenManager, revocations auth.RevocationList, cookies *CookieSessions, log logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if header == "" && cookies != nil {
+			if cookie, err := c.Cookie(cookies.Cookie); err == nil {
+				authenticateSession(c, cookies.Sessions, cookie, log)
+				return
+			}
+		}
+
+		scheme, token, found := strings.Cut(header, " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+			abortUnauthorized(c, "missing or malformed authorization header")
+			return
+		}
+
+		claims, err := tokens.Parse(token)
+		if err != nil {
+			abortUnauthorized(c, "invalid or expired token")
+			return
+		}
+
+		userID, err := claims.UserID()
+		if err != nil {
+			abortUnauthorized(c, "invalid token subject")
+			return
+		}
+
+		revoked, err := revocations.IsRevoked(c.Request.Context(), claims.SessionID)
+		if err != nil {
+			logger.FromContext(c.Request.Context(), log).
+				Error("failed to check session revocation", "session_id", claims.SessionID, "error", err)
+			AbortWithError(c, apierror.Internal(err))
+			return
+		}
+		if revoked {
+			abortUnauthorized(c, "session has been revoked")
+			return
+		}
+
+		c.Set(userIDKey, userID)
+		c.Set(claimsKey, claims)
+		c.Next()
+	}
+}
+
+// authenticateSession authenticates the request with the session cookie
+func authenticateSession(c *gin.Context, sessions SessionAuthenticator, cookie string, log logger.Logger) {
+	sess, err := sessions.Authenticate(c.Request.Context(), cookie)
+	if err != nil {
+		if errors.Is(err, session.ErrNotFound) {
+			abortUnauthorized(c, "invalid or expired session")
+			return
+		}
+		logger.FromContext(c.Request.Context(), log).Error("failed to check session", "error", err)
+		AbortWithError(c, apierror.Internal(err))
+		return
+	}
+
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		if !sessions.CheckCSRF(sess, c.GetHeader(CSRFHeader)) {
+			AbortWithError(c, apierror.New(http.StatusForbidden, "invalid_csrf_token",
+				"missing or invalid "+CSRFHeader+" header"))
+			return
+		}
+	}
+
+	c.Set(userIDKey, sess.UserID)
+	c.Set(sessionKey, sess)
+	c.Next()
+}
+
+// UserID returns the authenticated user's id
+func UserID(c *gin.Context) (int64, bool) {
+	value, ok := c.Get(userIDKey)
+	if !ok {
+		return 0, false
+	}
+	id, ok := value.(int64)
+	return id, ok
+}
+
+// Claims returns the verified token claims of the authenticated user
+func Claims(c *gin.Context) (*auth.Claims, bool) {
+	value, ok := c.Get(claimsKey)
+	if !ok {
+		return nil, false
+	}
+	claims, ok := value.(*auth.Claims)
+	return claims, ok
+}
+
+// Session returns the cookie session the request was authenticated with,
+// false for bearer tokens
+func Session(c *gin.Context) (*session.Session, bool) {
+	value, ok := c.Get(sessionKey)
+	if !ok {
+		return nil, false
+	}
+	sess, ok := value.(*session.Session)
+	return sess, ok
+}
+
+func abortUnauthorized(c *gin.Context, message string) {
+	AbortWithError(c, apierror.Unauthorized(message))
+}
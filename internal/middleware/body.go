@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/apierror"
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+// BodyLimit rejects request bodies larger than MaxRequestSize, or the
+// per-route override, with 413. Requests declaring a larger Content-Length
+// are rejected before the body is read, bodies without a length are cut off
+// while they are being read
+func BodyLimit(cfg config.SecurityConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := cfg.MaxRequestSize
+		if routeLimit, ok := cfg.RouteMaxRequestSizes[c.FullPath()]; ok {
+			limit = routeLimit
+		}
+
+		if c.Request.ContentLength > limit {
+			AbortWithError(c, apierror.RequestTooLarge(limit))
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
+
+// RequireJSON rejects POST, PUT and PATCH requests with a body that is not
+// application/json, or JSON with a +json suffix such as application/scim+json,
+// with 415. Routes listed in ContentTypeSkipRoutes are exempt
+func RequireJSON(cfg config.SecurityConfig) gin.HandlerFunc {
+	skip := make(map[string]struct{}, len(cfg.ContentTypeSkipRoutes))
+	for _, route := range cfg.ContentTypeSkipRoutes {
+		skip[route] = struct{}{}
+	}
+
+	return func(c *gin.Context) {
+		if _, ok := skip[c.FullPath()]; ok || !hasBody(c.Request) {
+			c.Next()
+			return
+		}
+
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+			mediaType, _, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+			if err != nil || mediaType != gin.MIMEJSON && !strings.HasSuffix(mediaType, "+json") {
+				AbortWithError(c, apierror.UnsupportedMediaType(gin.MIMEJSON))
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// hasBody reports whether the request carries a body, chunked requests have
+// an unknown length and are assumed to
+func hasBody(r *http.Request) bool {
+	return r.ContentLength != 0 && r.Body != nil && r.Body != http.NoBody
+}
@@ -0,0 +1,202 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/capture"
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// CapturePolicy selects the requests whose bodies CaptureBodies captures. It
+// can be replaced at runtime, so captures can be started and stopped by
+// reloading the configuration
+type CapturePolicy struct {
+	rules atomic.Pointer[captureRules]
+}
+
+type captureRules struct {
+	routes      []captureRoute
+	requestIDs  map[string]struct{}
+	redactor    *capture.Redactor
+	maxBodySize int64
+}
+
+// captureRoute is a route pattern of the capture configuration, an empty
+// method matches all
+type captureRoute struct {
+	method   string
+	segments []string
+}
+
+// NewCapturePolicy creates a policy from the capture configuration
+func NewCapturePolicy(cfg config.BodyCaptureConfig) *CapturePolicy {
+	p := &CapturePolicy{}
+	p.Update(cfg)
+	return p
+}
+
+// Update replaces the routes and request IDs to capture, the redacted fields
+// and the body size limit
+func (p *CapturePolicy) Update(cfg config.BodyCaptureConfig) {
+	rules := &captureRules{
+		requestIDs:  make(map[string]struct{}, len(cfg.RequestIDs)),
+		redactor:    capture.NewRedactor(cfg.RedactFields),
+		maxBodySize: cfg.MaxBodySize,
+	}
+	for _, pattern := range cfg.Routes {
+		var route captureRoute
+		path := strings.TrimSpace(pattern)
+		if method, rest, found := strings.Cut(path, " "); found {
+			route.method, path = strings.ToUpper(method), strings.TrimSpace(rest)
+		}
+		route.segments = strings.Split(strings.Trim(path, "/"), "/")
+		rules.routes = append(rules.routes, route)
+	}
+	for _, id := range cfg.RequestIDs {
+		rules.requestIDs[strings.TrimSpace(id)] = struct{}{}
+	}
+	p.rules.Store(rules)
+}
+
+// match reports whether the request is captured
+func (r *captureRules) match(c *gin.Context) bool {
+	if _, ok := r.requestIDs[GetRequestID(c)]; ok {
+		return true
+	}
+	route := c.FullPath()
+	if route == "" {
+		return false
+	}
+	segments := strings.Split(strings.Trim(route, "/"), "/")
+	for _, pattern := range r.routes {
+		if (pattern.method == "" || pattern.method == c.Request.Method) && matchSegments(pattern.segments, segments) {
+			return true
+		}
+	}
+	return false
+}
+
+// CaptureBodies stores the request and response of the requests selected by
+// the policy, with the redacted fields masked. It must run after RequestID
+// and ClientInfo. Failing to store a capture never fails the request
+func CaptureBodies(policy *CapturePolicy, store *capture.Store, log logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rules := policy.rules.Load()
+		if !rules.match(c) {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		request := rules.readBody(c)
+		writer := &captureWriter{ResponseWriter: c.Writer, limit: rules.maxBodySize}
+		c.Writer = writer
+		defer func() { c.Writer = writer.ResponseWriter }()
+
+		c.Next()
+
+		entry := &capture.Capture{
+			RequestID:  GetRequestID(c),
+			Method:     c.Request.Method,
+			Path:       c.Request.URL.Path,
+			Route:      c.FullPath(),
+			Query:      rules.redactor.Query(c.Request.URL.RawQuery),
+			Status:     writer.Status(),
+			ClientIP:   ClientIP(c),
+			LatencyMS:  time.Since(start).Milliseconds(),
+			Request:    request,
+			Response:   rules.response(writer),
+			CapturedAt: start.UTC(),
+		}
+		if userID, ok := UserID(c); ok {
+			entry.UserID = &userID
+		}
+
+		// Store after the request may have been cancelled by the client
+		if err := store.Save(context.WithoutCancel(c.Request.Context()), entry); err != nil {
+			log.Error("failed to store request capture", "error", err, "request_id", entry.RequestID)
+		}
+	}
+}
+
+// readBody reads the request body up to the size limit and puts it back for
+// the handlers
+func (r *captureRules) readBody(c *gin.Context) capture.Message {
+	message := capture.Message{
+		Headers: r.redactor.Headers(c.Request.Header),
+		Size:    c.Request.ContentLength,
+	}
+	if !hasBody(c.Request) {
+		message.Size = 0
+		return message
+	}
+
+	head, err := io.ReadAll(io.LimitReader(c.Request.Body, r.maxBodySize+1))
+	c.Request.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(head), c.Request.Body), Closer: c.Request.Body}
+	switch {
+	case err != nil:
+		message.Omitted = "body could not be read"
+	case int64(len(head)) > r.maxBodySize:
+		message.Omitted = "body larger than max_body_size"
+	default:
+		message.Size = int64(len(head))
+		message.Body, message.Omitted = r.redactor.Body(c.GetHeader("Content-Type"), head)
+	}
+	return message
+}
+
+// response builds the captured response from what the handlers wrote
+func (r *captureRules) response(w *captureWriter) capture.Message {
+	message := capture.Message{
+		Headers: r.redactor.Headers(w.Header()),
+		Size:    w.size,
+	}
+	switch {
+	case w.size == 0:
+	case w.size > r.maxBodySize:
+		message.Omitted = "body larger than max_body_size"
+	case w.Header().Get("Content-Encoding") != "":
+		message.Omitted = "encoded body"
+	default:
+		message.Body, message.Omitted = r.redactor.Body(w.Header().Get("Content-Type"), w.body.Bytes())
+	}
+	return message
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// captureWriter copies the response body up to the size limit while it is
+// written
+type captureWriter struct {
+	gin.ResponseWriter
+	limit int64
+	body  bytes.Buffer
+	size  int64
+}
+
+func (w *captureWriter) Write(data []byte) (int, error) {
+	w.copy(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *captureWriter) WriteString(s string) (int, error) {
+	w.copy([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *captureWriter) copy(data []byte) {
+	w.size += int64(len(data))
+	if room := w.limit - int64(w.body.Len()); room > 0 {
+		w.body.Write(data[:min(int64(len(data)), room)])
+	}
+}
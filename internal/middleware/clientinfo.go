@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"github.com/MuthuM3/gin-microservice-template/internal/clientinfo"
+	"github.com/gin-gonic/gin"
+)
+
+// ClientInfo stores the client address and user agent in the request
+// context, the address is resolved from the forwarding headers of trusted
+// proxies only
+func ClientInfo(resolver *clientinfo.Resolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		info := clientinfo.Info{IP: resolver.ClientIP(c.Request), UserAgent: c.Request.UserAgent()}
+		c.Request = c.Request.WithContext(clientinfo.NewContext(c.Request.Context(), info))
+
+		c.Next()
+	}
+}
+
+// ClientIP returns the client address resolved by ClientInfo, or the peer
+// address of requests it did not run for
+func ClientIP(c *gin.Context) string {
+	if ip := clientinfo.FromContext(c.Request.Context()).IP; ip != "" {
+		return ip
+	}
+	return c.RemoteIP()
+}
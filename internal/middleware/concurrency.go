@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/apierror"
+	"github.com/MuthuM3/gin-microservice-template/internal/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// ConcurrencyLimit serves at most limit requests at once. Requests over the
+// limit wait up to queueTimeout for a slot and are then shed with 503 and a
+// Retry-After header, keeping latency bounded for the requests that are
+// served instead of letting every request slow down under overload
+func ConcurrencyLimit(limit int, queueTimeout time.Duration) gin.HandlerFunc {
+	slots := make(chan struct{}, limit)
+
+	inFlight := metrics.Default.Gauge("http_requests_in_flight",
+		"Number of requests currently being served", nil)
+	queued := metrics.Default.Gauge("http_requests_queued",
+		"Number of requests waiting for a concurrency slot", nil)
+	shed := metrics.Default.Counter("http_requests_shed_total",
+		"Total number of requests rejected because the concurrency limit was reached", nil)
+	metrics.Default.GaugeFunc("http_requests_concurrency_limit",
+		"Maximum number of requests served at once", nil,
+		func() float64 { return float64(limit) })
+
+	retryAfter := max(int(math.Ceil(queueTimeout.Seconds())), 1)
+
+	return func(c *gin.Context) {
+		select {
+		case slots <- struct{}{}:
+		default:
+			if !waitForSlot(c, slots, queueTimeout, queued) {
+				if c.Request.Context().Err() != nil {
+					// The client went away while queued
+					c.Abort()
+					return
+				}
+				shed.Inc()
+				c.Header("Retry-After", strconv.Itoa(retryAfter))
+				AbortWithError(c, apierror.Overloaded(retryAfter))
+				return
+			}
+		}
+
+		inFlight.Inc()
+		defer func() {
+			inFlight.Dec()
+			<-slots
+		}()
+
+		c.Next()
+	}
+}
+
+// waitForSlot queues the request until a slot frees up, the timeout expires
+// or the client disconnects
+func waitForSlot(c *gin.Context, slots chan struct{}, timeout time.Duration, queued *metrics.Gauge) bool {
+	queued.Inc()
+	defer queued.Dec()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-c.Request.Context().Done():
+		return false
+	}
+}
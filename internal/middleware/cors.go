@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+// CORSPolicy holds the CORS configuration applied by the CORS middleware. It
+// can be replaced at runtime, requests in flight keep the policy they started with
+type CORSPolicy struct {
+	cfg atomic.Pointer[config.CORSConfig]
+}
+
+// NewCORSPolicy creates a policy from the CORS configuration
+func NewCORSPolicy(cfg config.CORSConfig) *CORSPolicy {
+	p := &CORSPolicy{}
+	p.Update(cfg)
+	return p
+}
+
+// Update replaces the active policy
+func (p *CORSPolicy) Update(cfg config.CORSConfig) {
+	p.cfg.Store(&cfg)
+}
+
+// allowOrigin returns the value for Access-Control-Allow-Origin, or "" when
+// the origin is not allowed
+func allowOrigin(cfg *config.CORSConfig, origin string) string {
+	for _, allowed := range cfg.AllowedOrigins {
+		if allowed == "*" {
+			return "*"
+		}
+		if strings.EqualFold(allowed, origin) {
+			return origin
+		}
+	}
+	return ""
+}
+
+// CORS sets the CORS response headers for allowed origins and answers
+// preflight requests directly
+func CORS(policy *CORSPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := policy.cfg.Load()
+		origin := c.GetHeader("Origin")
+		if !cfg.Enabled || origin == "" {
+			c.Next()
+			return
+		}
+
+		c.Header("Vary", "Origin")
+
+		allowed := allowOrigin(cfg, origin)
+		if allowed == "" {
+			c.Next()
+			return
+		}
+		c.Header("Access-Control-Allow-Origin", allowed)
+
+		// Preflight requests never reach the route handlers
+		if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+			c.Header("Access-Control-Allow-Methods", strings.Join(cfg.AllowedMethods, ", "))
+			c.Header("Access-Control-Allow-Headers", strings.Join(cfg.AllowedHeaders, ", "))
+			if cfg.MaxAge > 0 {
+				c.Header("Access-Control-Max-Age", strconv.Itoa(cfg.MaxAge))
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		c.Next()
+	}
+}
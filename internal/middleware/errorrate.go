@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorRate counts the requests served and those failing with a server
+// error in window
+func ErrorRate(window *metrics.Window) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		window.Observe(c.Writer.Status() >= http.StatusInternalServerError)
+	}
+}
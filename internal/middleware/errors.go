@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"github.com/MuthuM3/gin-microservice-template/internal/apierror"
+	"github.com/gin-gonic/gin"
+)
+
+// Errors renders the last error attached to the context as the standard error
+// envelope, in the negotiated format. It must be registered after the request logger so the
+// logger sees the final status, and does nothing if a response was written
+func Errors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := apierror.From(c.Errors.Last().Err)
+		Render(c, err.Status, apierror.Response{Error: localizedBody(c, err)})
+	}
+}
+
+// AbortWithError stops the handler chain and records err to be rendered by
+// the Errors middleware
+func AbortWithError(c *gin.Context, err error) {
+	c.Abort()
+	c.Error(err)
+}
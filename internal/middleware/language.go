@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"github.com/MuthuM3/gin-microservice-template/internal/apierror"
+	"github.com/MuthuM3/gin-microservice-template/internal/i18n"
+	"github.com/gin-gonic/gin"
+)
+
+// Language picks the language of the response from the Accept-Language
+// header and stores its localizer in the request context, see
+// i18n.FromContext
+func Language(bundle *i18n.Bundle) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		loc := bundle.Localizer(c.GetHeader("Accept-Language"))
+		c.Request = c.Request.WithContext(i18n.WithLocalizer(c.Request.Context(), loc))
+
+		c.Header("Content-Language", loc.Language())
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		c.Next()
+	}
+}
+
+// localizedBody renders err for the response envelope, with its message
+// translated when the client's language has one for its code. Messages are
+// kept in the source language otherwise
+func localizedBody(c *gin.Context, err *apierror.Error) apierror.Body {
+	body := err.Body(GetRequestID(c))
+
+	loc := i18n.FromContext(c.Request.Context())
+	if loc.Language() == i18n.SourceLanguage {
+		return body
+	}
+	if message, ok := loc.Lookup("error." + err.Code); ok {
+		body.Message = message
+	}
+	return body
+}
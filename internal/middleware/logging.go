@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// RequestLogger logs one structured entry per request. Paths listed in
+// SkipPaths are never logged, successful requests are sampled according to
+// the per-route or global sample rate and failed requests are always logged
+func RequestLogger(log logger.Logger, cfg config.RequestLogConfig) gin.HandlerFunc {
+	skip := make(map[string]struct{}, len(cfg.SkipPaths))
+	for _, path := range cfg.SkipPaths {
+		skip[path] = struct{}{}
+	}
+
+	return func(c *gin.Context) {
+		if _, ok := skip[c.Request.URL.Path]; ok {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		c.Next()
+		latency := time.Since(start)
+
+		route := c.FullPath()
+		status := c.Writer.Status()
+
+		if status < 500 && !sampled(cfg, route) {
+			return
+		}
+
+		args := []any{
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"route", route,
+			"status", status,
+			"latency", latency,
+			"client_ip", ClientIP(c),
+			"response_size", c.Writer.Size(),
+			"request_id", GetRequestID(c),
+		}
+		if userID, ok := UserID(c); ok {
+			args = append(args, "user_id", userID)
+		}
+		if keyID, ok := APIKeyID(c); ok {
+			args = append(args, "api_key", keyID)
+		}
+		if len(c.Errors) > 0 {
+			args = append(args, "errors", c.Errors.String())
+		}
+
+		switch {
+		case status >= 500:
+			log.Error("request completed", args...)
+		case status >= 400:
+			log.Warn("request completed", args...)
+		default:
+			log.Info("request completed", args...)
+		}
+	}
+}
+
+// sampled decides whether a successful request on route should be logged
+func sampled(cfg config.RequestLogConfig, route string) bool {
+	rate := cfg.SampleRate
+	if routeRate, ok := cfg.RouteSampleRates[route]; ok {
+		rate = routeRate
+	}
+
+	switch {
+	case rate >= 1:
+		return true
+	case rate <= 0:
+		return false
+	}
+	return rand.Float64() < rate
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/render"
+	"github.com/gin-gonic/gin"
+)
+
+const formatKey = "response_format"
+
+// Negotiation picks the format responses are rendered in from the Accept
+// header, one of formats with the first as default. See Render
+func Negotiation(formats []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(formatKey, render.Negotiate(c.GetHeader("Accept"), formats))
+		c.Writer.Header().Add("Vary", "Accept")
+		c.Next()
+	}
+}
+
+// Render writes body in the negotiated format, JSON when the request was not
+// negotiated or the body cannot be rendered in that format
+func Render(c *gin.Context, status int, body any) {
+	format := Format(c)
+	data, err := render.Encode(format, body)
+	if err != nil && format != render.MIMEJSON {
+		format = render.MIMEJSON
+		data, err = render.Encode(format, body)
+	}
+	if err != nil {
+		_ = c.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	contentType := format
+	if format != render.MIMEMsgPack {
+		contentType += "; charset=utf-8"
+	}
+	c.Data(status, contentType, data)
+}
+
+// Format returns the format negotiated for the response, JSON when the
+// request was not negotiated
+func Format(c *gin.Context) string {
+	if format := c.GetString(formatKey); format != "" {
+		return format
+	}
+	return render.MIMEJSON
+}
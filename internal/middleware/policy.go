@@ -0,0 +1,257 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+	"github.com/MuthuM3/gin-microservice-template/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// routePolicyKey holds the policy of the route of a request
+const routePolicyKey = "route_policy"
+
+// RoutePolicies applies the route policies of the configuration. Handler
+// runs on every request and enforces the policy of its route, Authenticate
+// and RateLimit wrap the middleware of the route groups so they step aside
+// on the routes whose policy takes over from them
+type RoutePolicies struct {
+	policies     []*routePolicy
+	performance  config.PerformanceConfig
+	keyFunc      KeyFunc
+	log          logger.Logger
+	authenticate gin.HandlerFunc
+
+	// matches caches the policy of every route seen, nil for none
+	matches sync.Map
+}
+
+// routePolicy is a policy compiled for matching
+type routePolicy struct {
+	config.RoutePolicyConfig
+	name     string
+	segments []string
+	limiter  ratelimit.Limiter
+}
+
+// NewRoutePolicies compiles the policies. newLimiter creates the limiter of
+// each policy with a rate limit of its own, requests are keyed by keyFunc
+func NewRoutePolicies(
+	policies []config.RoutePolicyConfig,
+	performance config.PerformanceConfig,
+	newLimiter func(limit int, window time.Duration) ratelimit.Limiter,
+	keyFunc KeyFunc,
+	log logger.Logger,
+) *RoutePolicies {
+	p := &RoutePolicies{performance: performance, keyFunc: keyFunc, log: log}
+	for i, cfg := range policies {
+		policy := &routePolicy{
+			RoutePolicyConfig: cfg,
+			name:              "policy" + strconv.Itoa(i),
+			segments:          strings.Split(strings.Trim(cfg.Path, "/"), "/"),
+		}
+		if cfg.RateLimit != nil && cfg.RateLimit.Enabled {
+			policy.limiter = newLimiter(cfg.RateLimit.RequestsPerWindow, cfg.RateLimit.Window)
+		}
+		p.policies = append(p.policies, policy)
+	}
+	return p
+}
+
+// Close stops the limiters of the policies
+func (p *RoutePolicies) Close() error {
+	var errs []error
+	for _, policy := range p.policies {
+		if closer, ok := policy.limiter.(io.Closer); ok {
+			errs = append(errs, closer.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Handler enforces the policy of the route of each request. It runs before
+// the middleware of the route groups, Authenticate must have been called
+// before the first request when a policy requires auth
+func (p *RoutePolicies) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		policy := p.match(c.Request.Method, c.FullPath())
+		if policy == nil {
+			c.Next()
+			return
+		}
+		c.Set(routePolicyKey, policy)
+		writer := c.Writer
+		defer func() { c.Writer = writer }()
+
+		if limit := policy.RateLimit; limit != nil && limit.Enabled {
+			RateLimit(policy.limiter, func(c *gin.Context) string {
+				return policy.name + ":" + p.keyFunc(c)
+			}, p.log)(c)
+			if c.IsAborted() {
+				return
+			}
+		}
+		if policy.Auth != nil && *policy.Auth && p.authenticate != nil {
+			p.authenticate(c)
+			if c.IsAborted() {
+				return
+			}
+		}
+		if cache := policy.Cache; cache != nil && (c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead) {
+			c.Writer = &cacheControlWriter{ResponseWriter: c.Writer, value: p.cacheControl(cache)}
+		}
+		if policy.Compression != nil && *policy.Compression && acceptsGzip(c) {
+			gz := newGzipWriter(c.Writer, p.performance.CompressionLevel, p.performance.CompressionMinLength)
+			c.Writer = gz
+			defer gz.finish()
+		}
+		c.Next()
+	}
+}
+
+// Authenticate wraps the auth middleware of the route groups: routes whose
+// policy turns auth off let requests through unauthenticated, and requests
+// already authenticated by their policy are not authenticated twice. It is
+// also what Handler authenticates with
+func (p *RoutePolicies) Authenticate(authenticate gin.HandlerFunc) gin.HandlerFunc {
+	p.authenticate = authenticate
+	if len(p.policies) == 0 {
+		return authenticate
+	}
+
+	return func(c *gin.Context) {
+		if _, ok := UserID(c); ok {
+			c.Next()
+			return
+		}
+		if policy := policyOf(c); policy != nil && policy.Auth != nil && !*policy.Auth {
+			c.Next()
+			return
+		}
+		authenticate(c)
+	}
+}
+
+// RateLimit wraps the rate limit middleware of the route groups so it skips
+// the routes whose policy sets a rate limit of its own
+func (p *RoutePolicies) RateLimit(limit gin.HandlerFunc) gin.HandlerFunc {
+	if len(p.policies) == 0 {
+		return limit
+	}
+
+	return func(c *gin.Context) {
+		if policy := policyOf(c); policy != nil && policy.RateLimit != nil {
+			c.Next()
+			return
+		}
+		limit(c)
+	}
+}
+
+// match returns the first policy matching the route, nil for requests
+// matching no route
+func (p *RoutePolicies) match(method, route string) *routePolicy {
+	if len(p.policies) == 0 || route == "" {
+		return nil
+	}
+
+	key := method + " " + route
+	if policy, ok := p.matches.Load(key); ok {
+		return policy.(*routePolicy)
+	}
+
+	var found *routePolicy
+	segments := strings.Split(strings.Trim(route, "/"), "/")
+	for _, policy := range p.policies {
+		if len(policy.Methods) > 0 && !slices.ContainsFunc(policy.Methods, func(m string) bool {
+			return strings.EqualFold(m, method)
+		}) {
+			continue
+		}
+		if matchSegments(policy.segments, segments) {
+			found = policy
+			break
+		}
+	}
+	p.matches.Store(key, found)
+	return found
+}
+
+// cacheControl returns the Cache-Control header of a cache policy
+func (p *RoutePolicies) cacheControl(cache *config.RouteCacheConfig) string {
+	if !cache.Enabled {
+		return "no-store"
+	}
+	maxAge := cache.MaxAge
+	if maxAge == 0 {
+		maxAge = p.performance.CacheControlMaxAge
+	}
+	return "private, max-age=" + strconv.Itoa(maxAge)
+}
+
+// matchSegments matches route segments against a pattern where * matches one
+// segment and a trailing ** the remaining ones, none included
+func matchSegments(pattern, route []string) bool {
+	for i, segment := range pattern {
+		if segment == "**" && i == len(pattern)-1 {
+			return true
+		}
+		if i >= len(route) || segment != "*" && segment != route[i] {
+			return false
+		}
+	}
+	return len(pattern) == len(route)
+}
+
+// requestCost returns how many requests of the rate limit the request counts
+// as
+func requestCost(c *gin.Context) int {
+	if policy := policyOf(c); policy != nil && policy.Cost > 0 {
+		return policy.Cost
+	}
+	return 1
+}
+
+func policyOf(c *gin.Context) *routePolicy {
+	if policy, ok := c.Get(routePolicyKey); ok {
+		return policy.(*routePolicy)
+	}
+	return nil
+}
+
+// cacheControlWriter sets the Cache-Control header of successful GET and
+// HEAD responses
+type cacheControlWriter struct {
+	gin.ResponseWriter
+	value string
+}
+
+func (w *cacheControlWriter) setHeader() {
+	if w.ResponseWriter.Written() || w.Status() >= 300 {
+		return
+	}
+	w.Header().Set("Cache-Control", w.value)
+}
+
+func (w *cacheControlWriter) WriteHeaderNow() {
+	w.setHeader()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *cacheControlWriter) Write(data []byte) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *cacheControlWriter) WriteString(s string) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/apierror"
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+	"github.com/MuthuM3/gin-microservice-template/internal/quota"
+	"github.com/gin-gonic/gin"
+)
+
+// CallMeter counts the API calls of users against their daily quota
+type CallMeter interface {
+	CountCall(ctx context.Context, userID int64) (quota.Result, error)
+}
+
+// CallQuota counts the requests of the authenticated user and rejects those
+// over the daily quota with 429 until midnight UTC. It runs after the auth
+// middleware, anonymous requests are not counted. Meter failures are logged
+// and the request is let through, like rate limiter failures
+func CallQuota(meter CallMeter, log logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := UserID(c)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		result, err := meter.CountCall(c.Request.Context(), userID)
+		if err != nil {
+			logger.FromContext(c.Request.Context(), log).Error("api call quota unavailable", "error", err)
+			c.Next()
+			return
+		}
+		if result.Limit == 0 {
+			c.Next()
+			return
+		}
+
+		c.Header("X-Quota-Limit", strconv.FormatInt(result.Limit, 10))
+		c.Header("X-Quota-Remaining", strconv.FormatInt(result.Remaining, 10))
+		c.Header("X-Quota-Reset", strconv.FormatInt(result.ResetAt.Unix(), 10))
+
+		if !result.Allowed {
+			retryAfter := max(int(math.Ceil(time.Until(result.ResetAt).Seconds())), 1)
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			AbortWithError(c, apierror.New(http.StatusTooManyRequests, "quota_exceeded", "daily api call quota exceeded").
+				WithDetails(map[string]any{
+					"resource":            "api_calls",
+					"limit":               result.Limit,
+					"retry_after_seconds": retryAfter,
+				}))
+			return
+		}
+
+		c.Next()
+	}
+}
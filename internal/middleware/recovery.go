@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+	"syscall"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/apierror"
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+	"github.com/MuthuM3/gin-microservice-template/internal/metrics"
+	"github.com/MuthuM3/gin-microservice-template/internal/reporting"
+	"github.com/gin-gonic/gin"
+)
+
+// maxStackFrames bounds the number of frames captured for a panic
+const maxStackFrames = 64
+
+// Recovery turns panics in later handlers into a 500 response using the
+// standard error envelope. The panic is logged with its stack as a list of
+// frames, counted in http_panics_total and sent to the reporter. Panics
+// caused by the client going away are logged without a response
+func Recovery(log logger.Logger, reporter reporting.Reporter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			// net/http uses this sentinel to abort a response on purpose
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			ctx := c.Request.Context()
+			log := logger.FromContext(ctx, log)
+			route := c.FullPath()
+
+			if isBrokenConnection(recovered) {
+				log.Warn("client connection lost", "path", c.Request.URL.Path, "error", recovered)
+				c.Abort()
+				return
+			}
+
+			log.Error("panic recovered",
+				"panic", fmt.Sprint(recovered),
+				"method", c.Request.Method,
+				"path", c.Request.URL.Path,
+				"route", route,
+				"stack", stackFrames(),
+			)
+
+			metrics.Default.Counter("http_panics_total",
+				"Total number of panics recovered while serving HTTP requests",
+				metrics.Labels{"route": route},
+			).Inc()
+
+			reporter.ReportPanic(ctx, recovered, map[string]string{
+				"method":     c.Request.Method,
+				"route":      route,
+				"request_id": GetRequestID(c),
+			})
+
+			c.Error(fmt.Errorf("panic: %v", recovered))
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+
+			err := apierror.Internal(nil)
+			c.Abort()
+			Render(c, err.Status, apierror.Response{Error: localizedBody(c, err)})
+		}()
+
+		c.Next()
+	}
+}
+
+// stackFrames returns the stack of the panicking goroutine, starting at the
+// frame that panicked, formatted as "function file:line"
+func stackFrames() []string {
+	pcs := make([]uintptr, maxStackFrames)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var stack []string
+	panicking := false
+	for {
+		frame, more := frames.Next()
+		// Skip the recovery machinery up to and including runtime.gopanic
+		if !panicking {
+			panicking = frame.Function == "runtime.gopanic"
+		} else if !strings.HasPrefix(frame.Function, "runtime.") || len(stack) > 0 {
+			stack = append(stack, fmt.Sprintf("%s %s:%d", frame.Function, frame.File, frame.Line))
+		}
+		if !more {
+			break
+		}
+	}
+	return stack
+}
+
+// isBrokenConnection reports whether the panic was caused by writing to a
+// connection the client already closed
+func isBrokenConnection(recovered any) bool {
+	err, ok := recovered.(error)
+	return ok && (errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET))
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"github.com/MuthuM3/gin-microservice-template/internal/requestid"
+	"github.com/gin-gonic/gin"
+)
+
+// RequestID assigns every request a correlation id, reusing a valid id sent
+// by the client. The id is echoed in the response, stored in the gin context
+// and in the request context so logs and outgoing calls can include it
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+
+		c.Set(requestIDKey, id)
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(requestid.NewContext(c.Request.Context(), id))
+
+		c.Next()
+	}
+}
+
+// GetRequestID returns the correlation id assigned to the request
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
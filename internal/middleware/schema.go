@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/apierror"
+	"github.com/MuthuM3/gin-microservice-template/internal/i18n"
+	"github.com/MuthuM3/gin-microservice-template/internal/schema"
+	"github.com/gin-gonic/gin"
+)
+
+// ValidateSchemas checks request bodies against the schema of their route
+// before they are bound, routes are keyed by method and path, such as
+// "POST /api/v1/todos". Bodies breaking the schema are rejected with 400 and
+// the violations, each with the JSON Pointer of the offending value. The body
+// is put back for the handler, it must be registered after BodyLimit
+func ValidateSchemas(registry *schema.Registry, routes map[string]string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, ok := routes[c.Request.Method+" "+c.FullPath()]
+		if !ok || !hasBody(c.Request) {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			AbortWithError(c, err)
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		violations, err := registry.Validate(key, body)
+		if err != nil {
+			AbortWithError(c, err)
+			return
+		}
+		if len(violations) > 0 {
+			loc := i18n.FromContext(c.Request.Context())
+			AbortWithError(c, apierror.Validation(loc.T("error.validation_failed")).WithDetails(gin.H{
+				"schema":     key,
+				"violations": violations,
+			}))
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/apierror"
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+	"github.com/MuthuM3/gin-microservice-template/internal/nonce"
+	"github.com/gin-gonic/gin"
+)
+
+// Headers of signed requests
+const (
+	APIKeyHeader    = "X-Api-Key"
+	TimestampHeader = "X-Timestamp"
+	NonceHeader     = "X-Nonce"
+	SignatureHeader = "X-Signature"
+)
+
+// apiKeyKey holds the ID of the API key a request was signed with
+const apiKeyKey = "api_key"
+
+// Nonces are 16 to 128 characters of letters, digits, - and _
+const (
+	minNonceLength = 16
+	maxNonceLength = 128
+)
+
+// Signatures verifies requests signed with the secret of an API key.
+// Clients send the key ID, the Unix time in seconds, a nonce and the hex
+// encoded HMAC-SHA256 of the string built by SignatureBase
+type Signatures struct {
+	secrets map[string][]byte
+	maxSkew time.Duration
+	nonces  nonce.Store
+	log     logger.Logger
+}
+
+// NewSignatures creates the verifier of the configured API keys, nonces are
+// remembered in nonces
+func NewSignatures(cfg config.SignedRequestsConfig, nonces nonce.Store, log logger.Logger) *Signatures {
+	s := &Signatures{
+		secrets: make(map[string][]byte, len(cfg.KeySecrets)),
+		maxSkew: cfg.MaxSkew,
+		nonces:  nonces,
+		log:     log,
+	}
+	for _, key := range cfg.KeySecrets {
+		id, secret, _ := strings.Cut(strings.TrimSpace(key), ":")
+		s.secrets[id] = []byte(secret)
+	}
+	return s
+}
+
+// Handler verifies the requests carrying a signature and refuses unsigned
+// requests to routes whose policy requires one. It runs after the route
+// policies and BodyLimit, whose limit bounds the body it reads
+func (s *Signatures) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader(SignatureHeader) == "" {
+			if policy := policyOf(c); policy != nil && policy.Signed != nil && *policy.Signed {
+				abortInvalidSignature(c, "request must be signed")
+				return
+			}
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				AbortWithError(c, apierror.RequestTooLarge(tooLarge.Limit))
+				return
+			}
+			AbortWithError(c, apierror.BadRequest("invalid_body", "failed to read request body"))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		id := c.GetHeader(APIKeyHeader)
+		if !s.verify(c, id, body) {
+			return
+		}
+		c.Set(apiKeyKey, id)
+		c.Next()
+	}
+}
+
+// verify checks the signature, timestamp and nonce of a signed request and
+// aborts it when one is invalid
+func (s *Signatures) verify(c *gin.Context, id string, body []byte) bool {
+	secret, ok := s.secrets[id]
+	if !ok {
+		abortInvalidSignature(c, "unknown API key")
+		return false
+	}
+
+	timestamp := c.GetHeader(TimestampHeader)
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		abortInvalidSignature(c, "timestamp must be a Unix time in seconds")
+		return false
+	}
+	if skew := time.Since(time.Unix(seconds, 0)); skew > s.maxSkew || skew < -s.maxSkew {
+		abortInvalidSignature(c, "timestamp is outside the allowed clock skew")
+		return false
+	}
+
+	value := c.GetHeader(NonceHeader)
+	if !validNonce(value) {
+		abortInvalidSignature(c, "nonce must be 16 to 128 letters, digits, - or _")
+		return false
+	}
+
+	expected := Sign(secret, SignatureBase(c.Request.Method, c.Request.URL.RequestURI(), timestamp, value, body))
+	signature, err := hex.DecodeString(c.GetHeader(SignatureHeader))
+	if err != nil || !hmac.Equal(signature, expected) {
+		abortInvalidSignature(c, "signature does not match")
+		return false
+	}
+
+	// Only nonces of valid signatures are recorded, so forged requests cannot
+	// use up the nonces of a client. A nonce is remembered as long as its
+	// timestamp is accepted
+	fresh, err := s.nonces.Use(c.Request.Context(), id+":"+value, 2*s.maxSkew)
+	if err != nil {
+		s.log.Error("failed to check request nonce", "error", err, "api_key", id)
+		AbortWithError(c, apierror.Unavailable("signed requests cannot be verified right now"))
+		return false
+	}
+	if !fresh {
+		abortInvalidSignature(c, "nonce was already used")
+		return false
+	}
+	return true
+}
+
+// SignatureBase returns the string signed for a request: the method, path
+// with query, timestamp, nonce and hex encoded SHA-256 of the body, one per
+// line
+func SignatureBase(method, uri, timestamp, nonce string, body []byte) string {
+	sum := sha256.Sum256(body)
+	return strings.Join([]string{strings.ToUpper(method), uri, timestamp, nonce, hex.EncodeToString(sum[:])}, "\n")
+}
+
+// Sign returns the HMAC-SHA256 of a signature base
+func Sign(secret []byte, base string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(base))
+	return mac.Sum(nil)
+}
+
+// APIKeyID returns the ID of the API key the request was signed with
+func APIKeyID(c *gin.Context) (string, bool) {
+	id := c.GetString(apiKeyKey)
+	return id, id != ""
+}
+
+func validNonce(value string) bool {
+	if len(value) < minNonceLength || len(value) > maxNonceLength {
+		return false
+	}
+	for _, r := range value {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+func abortInvalidSignature(c *gin.Context, message string) {
+	AbortWithError(c, apierror.New(http.StatusUnauthorized, "invalid_signature", message))
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/apierror"
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// APIVersionHeader reports the API version that served a request
+const APIVersionHeader = "API-Version"
+
+// APIVersion marks the responses of an API version. Retired versions answer
+// 410 Gone, deprecated ones announce it with the Deprecation (RFC 9745) and
+// Sunset (RFC 8594) headers and count their requests in
+// api_deprecated_requests_total so their remaining clients can be tracked.
+// The dates were checked when the configuration was loaded
+func APIVersion(version string, cfg config.APIVersionConfig) gin.HandlerFunc {
+	if !cfg.Enabled {
+		return func(c *gin.Context) {
+			c.Header(APIVersionHeader, version)
+			AbortWithError(c, apierror.New(http.StatusGone, "api_version_retired",
+				"API version "+version+" has been retired"))
+		}
+	}
+
+	headers := make(map[string]string)
+	if deprecated, err := config.ParseDate(cfg.Deprecated); err == nil {
+		headers["Deprecation"] = "@" + strconv.FormatInt(deprecated.Unix(), 10)
+	}
+	if sunset, err := config.ParseDate(cfg.Sunset); err == nil {
+		headers["Sunset"] = sunset.UTC().Format(http.TimeFormat)
+	}
+	if cfg.Link != "" && len(headers) > 0 {
+		headers["Link"] = "<" + cfg.Link + `>; rel="deprecation"; type="text/html"`
+	}
+
+	return func(c *gin.Context) {
+		c.Header(APIVersionHeader, version)
+		if len(headers) == 0 {
+			c.Next()
+			return
+		}
+
+		for name, value := range headers {
+			c.Header(name, value)
+		}
+		metrics.Default.Counter("api_deprecated_requests_total",
+			"Total number of requests to deprecated API versions",
+			metrics.Labels{"version": version},
+		).Inc()
+		c.Next()
+	}
+}
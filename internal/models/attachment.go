@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Attachment statuses. Files uploaded directly to blob storage are pending
+// until the upload has been confirmed
+const (
+	AttachmentPending = "pending"
+	AttachmentReady   = "ready"
+)
+
+// Attachment is a file attached to a todo, its content is kept in blob
+// storage under StorageKey. TodoID is zero once the todo has been purged
+type Attachment struct {
+	ID          int64     `json:"id"`
+	TodoID      int64     `json:"todo_id"`
+	UserID      int64     `json:"user_id"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Status      string    `json:"status"`
+	StorageKey  string    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
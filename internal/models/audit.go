@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// AuditEvent records a single data mutation. UserID is the acting user and
+// is nil for changes made by administrators or background jobs
+type AuditEvent struct {
+	ID         int64                  `json:"id"`
+	UserID     *int64                 `json:"user_id,omitempty"`
+	Action     string                 `json:"action"`
+	EntityType string                 `json:"entity_type"`
+	EntityID   string                 `json:"entity_id"`
+	Changes    map[string]AuditChange `json:"changes,omitempty"`
+	IPAddress  string                 `json:"ip_address,omitempty"`
+	RequestID  string                 `json:"request_id,omitempty"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+// AuditChange holds the value of a field before and after a mutation, Before
+// is nil for created entities and After is nil for deleted ones
+type AuditChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Notification channels
+const (
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+	ChannelSlack   = "slack"
+)
+
+// Channels lists the notification channels
+var Channels = []string{ChannelEmail, ChannelWebhook, ChannelSlack}
+
+// NotificationChannel is where a user receives notifications. Email goes to
+// the account address and needs no URL, the webhook and Slack channels post
+// to URL. Secret signs webhook requests and is only returned when the
+// channel is created. The timestamps are zero for the default email channel
+// of users who have not changed it
+type NotificationChannel struct {
+	UserID    int64     `json:"-"`
+	Channel   string    `json:"channel"`
+	URL       string    `json:"url,omitempty"`
+	Secret    string    `json:"-"`
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at,omitzero"`
+	UpdatedAt time.Time `json:"updated_at,omitzero"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OutboxMessage is an event waiting in the transactional outbox.
+// PublishedAt is nil until the relay has delivered it
+type OutboxMessage struct {
+	ID             int64
+	IdempotencyKey string
+	Topic          string
+	EventType      string
+	UserID         int64
+	EntityID       int64
+	Payload        json.RawMessage
+	Attempts       int
+	LastError      string
+	CreatedAt      time.Time
+	PublishedAt    *time.Time
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Profile holds the details users manage about themselves. The avatar is one
+// of their image attachments, AvatarID is kept when that attachment is no
+// longer available and Avatar is then nil. DeletionScheduledAt is set while
+// the account is about to be erased
+type Profile struct {
+	UserID        int64                   `json:"id"`
+	Email         string                  `json:"email"`
+	Name          string                  `json:"name"`
+	Timezone      string                  `json:"timezone"`
+	AvatarID      *int64                  `json:"-"`
+	Avatar        *Attachment             `json:"avatar"`
+	Notifications NotificationPreferences `json:"notifications"`
+	UpdatedAt     time.Time               `json:"updated_at"`
+
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+}
+
+// NotificationPreferences are the notifications a user wants to receive
+type NotificationPreferences struct {
+	DueReminders   bool `json:"due_reminders"`
+	WeeklyDigest   bool `json:"weekly_digest"`
+	SecurityAlerts bool `json:"security_alerts"`
+	ProductUpdates bool `json:"product_updates"`
+	SharedTodos    bool `json:"shared_todos"`
+}
+
+// NotificationSettings are the JSON names of the notification preferences
+var NotificationSettings = []string{"due_reminders", "weekly_digest", "security_alerts", "product_updates", "shared_todos"}
+
+// Enabled reports whether the setting named by its JSON name is on, unknown
+// settings are off
+func (p NotificationPreferences) Enabled(setting string) bool {
+	switch setting {
+	case "due_reminders":
+		return p.DueReminders
+	case "weekly_digest":
+		return p.WeeklyDigest
+	case "security_alerts":
+		return p.SecurityAlerts
+	case "product_updates":
+		return p.ProductUpdates
+	case "shared_todos":
+		return p.SharedTodos
+	default:
+		return false
+	}
+}
+
+// DefaultNotificationPreferences returns the preferences of users who have
+// not changed any
+func DefaultNotificationPreferences() NotificationPreferences {
+	return NotificationPreferences{
+		DueReminders:   true,
+		WeeklyDigest:   false,
+		SecurityAlerts: true,
+		ProductUpdates: false,
+		SharedTodos:    true,
+	}
+}
+
+// DecodeNotificationPreferences applies the preferences a user changed, a
+// JSON object keyed by setting, to the defaults. Unknown settings are ignored
+func DecodeNotificationPreferences(changed []byte) (NotificationPreferences, error) {
+	preferences := DefaultNotificationPreferences()
+	if len(changed) == 0 {
+		return preferences, nil
+	}
+	if err := json.Unmarshal(changed, &preferences); err != nil {
+		return preferences, err
+	}
+	return preferences, nil
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// QuotaLimits caps the usage of a user, a zero limit is unlimited
+type QuotaLimits struct {
+	MaxTodos           int   `json:"max_todos"`
+	MaxAttachmentBytes int64 `json:"max_attachment_bytes"`
+	MaxAPICallsPerDay  int   `json:"max_api_calls_per_day"`
+}
+
+// QuotaOverride replaces the configured limits of one user, nil limits keep
+// the configured ones
+type QuotaOverride struct {
+	UserID             int64     `json:"user_id"`
+	MaxTodos           *int      `json:"max_todos"`
+	MaxAttachmentBytes *int64    `json:"max_attachment_bytes"`
+	MaxAPICallsPerDay  *int      `json:"max_api_calls_per_day"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// Apply returns limits with the overridden ones replaced
+func (o *QuotaOverride) Apply(limits QuotaLimits) QuotaLimits {
+	if o.MaxTodos != nil {
+		limits.MaxTodos = *o.MaxTodos
+	}
+	if o.MaxAttachmentBytes != nil {
+		limits.MaxAttachmentBytes = *o.MaxAttachmentBytes
+	}
+	if o.MaxAPICallsPerDay != nil {
+		limits.MaxAPICallsPerDay = *o.MaxAPICallsPerDay
+	}
+	return limits
+}
+
+// QuotaUsage is what a user currently consumes of each quota
+type QuotaUsage struct {
+	Todos           int   `json:"todos"`
+	AttachmentBytes int64 `json:"attachment_bytes"`
+	APICallsToday   int64 `json:"api_calls_today"`
+}
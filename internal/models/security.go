@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// SecurityEvent records an authentication event such as a login, a failed
+// login or a lockout. UserID is nil when the event could not be tied to an
+// account, such as a failed login with an unknown email
+type SecurityEvent struct {
+	ID        int64             `json:"id"`
+	UserID    *int64            `json:"user_id,omitempty"`
+	Type      string            `json:"type"`
+	Email     string            `json:"email,omitempty"`
+	IPAddress string            `json:"ip_address"`
+	UserAgent string            `json:"user_agent"`
+	Details   map[string]string `json:"details,omitempty"`
+	RequestID string            `json:"request_id,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Session is a login session, refresh tokens are rotated within a session.
+// The device is the client that last used the session, LastSeenAt moves on
+// login and each refresh
+type Session struct {
+	ID         string     `json:"id"`
+	UserID     int64      `json:"user_id"`
+	IPAddress  string     `json:"ip_address"`
+	UserAgent  string     `json:"user_agent"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// IsActive reports whether the session can still be used
+func (s *Session) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// RefreshToken is a single-use token exchanged for a new token pair, only
+// its hash is stored
+type RefreshToken struct {
+	TokenHash string     `json:"-"`
+	SessionID string     `json:"session_id"`
+	UserID    int64      `json:"user_id"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Share roles, editors may change the todo, viewers only read it
+const (
+	ShareViewer = "viewer"
+	ShareEditor = "editor"
+)
+
+// ShareRoles lists the share roles
+var ShareRoles = []string{ShareViewer, ShareEditor}
+
+// TodoShare gives a user access to a todo of someone else and to its
+// sub-tasks. It is an invitation until AcceptedAt is set. Email is only
+// filled in for the owner listing a todo's collaborators
+type TodoShare struct {
+	TodoID     int64      `json:"todo_id"`
+	OwnerID    int64      `json:"owner_id"`
+	UserID     int64      `json:"user_id"`
+	Email      string     `json:"email,omitempty"`
+	Role       string     `json:"role"`
+	CreatedAt  time.Time  `json:"created_at"`
+	AcceptedAt *time.Time `json:"accepted_at"`
+}
+
+// Accepted reports whether the invitation was accepted
+func (s *TodoShare) Accepted() bool {
+	return s.AcceptedAt != nil
+}
+
+// SharedTodo is a todo shared with the user together with the share giving
+// them access
+type SharedTodo struct {
+	Todo  *Todo      `json:"todo"`
+	Share *TodoShare `json:"share"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// Todo priorities, PriorityMedium is used when none is given
+const (
+	PriorityLow    = "low"
+	PriorityMedium = "medium"
+	PriorityHigh   = "high"
+)
+
+// Todo represents a single todo item
+type Todo struct {
+	ID          int64      `json:"id"`
+	UserID      int64      `json:"user_id"`
+	ParentID    *int64     `json:"parent_id,omitempty"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Completed   bool       `json:"completed"`
+	DueDate     *time.Time `json:"due_date,omitempty"`
+	Recurrence  string     `json:"recurrence,omitempty"`
+	RemindAt    *time.Time `json:"remind_at,omitempty"`
+	Priority    string     `json:"priority"`
+	Tags        []string   `json:"tags"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+}
+
+// TodoSearchResult is a todo matched by a search. Snippet is an excerpt with
+// the matched terms wrapped in <mark> tags, the surrounding text is not HTML
+// escaped
+type TodoSearchResult struct {
+	Todo    *Todo   `json:"todo"`
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+// Tag is a user defined label that can be attached to any of the user's todos
+type Tag struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
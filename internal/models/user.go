@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// User roles, admins may use the /api/v1/admin endpoints
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// User represents a registered account. ExternalID is the id of the account
+// at the identity provider that provisioned it, deactivated users cannot
+// sign in
+type User struct {
+	ID              int64      `json:"id"`
+	Email           string     `json:"email"`
+	Name            string     `json:"name"`
+	Role            string     `json:"role"`
+	ExternalID      string     `json:"external_id,omitempty"`
+	PasswordHash    string     `json:"-"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	DeactivatedAt   *time.Time `json:"deactivated_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// IsVerified reports whether the user has confirmed their email address
+func (u *User) IsVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// IsDeactivated reports whether the user was deactivated
+func (u *User) IsDeactivated() bool {
+	return u.DeactivatedAt != nil
+}
+
+// IsAdmin reports whether the user has the admin role
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
+// PasswordReset is a single-use token allowing a user to choose a new
+// password, only its hash is stored
+type PasswordReset struct {
+	TokenHash string     `json:"-"`
+	UserID    int64      `json:"user_id"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// EmailVerification is a single-use token confirming that a user owns their
+// email address, only its hash is stored
+type EmailVerification struct {
+	TokenHash string     `json:"-"`
+	UserID    int64      `json:"user_id"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Identity links an account at an external identity provider to a user,
+// Subject is the provider's stable id for the account
+type Identity struct {
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	UserID    int64     `json:"user_id"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,70 @@
+package nonce
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps nonces in process for single instance deployments
+type MemoryStore struct {
+	mu     sync.Mutex
+	nonces map[string]time.Time // expiry of each nonce
+
+	stop chan struct{}
+	once sync.Once
+}
+
+// NewMemoryStore creates a store and starts a janitor that evicts expired
+// nonces
+func NewMemoryStore() *MemoryStore {
+	s := &MemoryStore{
+		nonces: make(map[string]time.Time),
+		stop:   make(chan struct{}),
+	}
+
+	go s.cleanup(time.Minute)
+	return s
+}
+
+// Use records the nonce unless it is still remembered
+func (s *MemoryStore) Use(_ context.Context, nonce string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if expiresAt, ok := s.nonces[nonce]; ok && now.Before(expiresAt) {
+		return false, nil
+	}
+	s.nonces[nonce] = now.Add(ttl)
+	return true, nil
+}
+
+// Close stops the janitor goroutine
+func (s *MemoryStore) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	return nil
+}
+
+// cleanup periodically removes expired nonces
+func (s *MemoryStore) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			now := time.Now()
+			s.mu.Lock()
+			for nonce, expiresAt := range s.nonces {
+				if !now.Before(expiresAt) {
+					delete(s.nonces, nonce)
+				}
+			}
+			s.mu.Unlock()
+		case <-s.stop:
+			return
+		}
+	}
+}
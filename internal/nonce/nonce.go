@@ -0,0 +1,15 @@
+// Package nonce remembers the nonces of signed requests so each is accepted
+// only once
+package nonce
+
+import (
+	"context"
+	"time"
+)
+
+// Store records used nonces until they expire
+type Store interface {
+	// Use records the nonce for ttl and reports whether it was unused, false
+	// means the nonce was seen before and the request is a replay
+	Use(ctx context.Context, nonce string, ttl time.Duration) (bool, error)
+}
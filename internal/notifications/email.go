@@ -0,0 +1,21 @@
+package notifications
+
+import (
+	"context"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/mail"
+)
+
+// EmailNotifier emails notifications
+type EmailNotifier struct {
+	mailer mail.Mailer
+}
+
+func NewEmailNotifier(mailer mail.Mailer) *EmailNotifier {
+	return &EmailNotifier{mailer: mailer}
+}
+
+// Notify emails the notification to the recipient's address
+func (e *EmailNotifier) Notify(ctx context.Context, to Recipient, n Notification) error {
+	return e.mailer.Send(ctx, mail.Message{To: to.Address, Subject: n.Subject, Body: n.Body})
+}
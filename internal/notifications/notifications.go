@@ -0,0 +1,27 @@
+// Package notifications delivers notifications to users over the channels
+// they set up. Each channel has a Notifier, callers decide which
+// notifications a user wants and which channels to use
+package notifications
+
+import "context"
+
+// Notification is one message for a user
+type Notification struct {
+	Type    string         `json:"type"`
+	Subject string         `json:"subject"`
+	Body    string         `json:"body"`
+	Data    map[string]any `json:"data,omitempty"`
+}
+
+// Recipient is where a notification goes. Address is the email address or
+// the URL of the channel, Secret signs webhook deliveries
+type Recipient struct {
+	UserID  int64
+	Address string
+	Secret  string
+}
+
+// Notifier delivers notifications over one channel
+type Notifier interface {
+	Notify(ctx context.Context, to Recipient, n Notification) error
+}
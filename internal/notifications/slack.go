@@ -0,0 +1,51 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// SlackNotifier posts notifications to Slack incoming webhooks
+type SlackNotifier struct {
+	client *http.Client
+}
+
+func NewSlackNotifier(client *http.Client) *SlackNotifier {
+	return &SlackNotifier{client: client}
+}
+
+// slackMessage is the body of an incoming webhook request
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+// Notify posts the subject in bold followed by the body to the recipient's
+// incoming webhook URL
+func (s *SlackNotifier) Notify(ctx context.Context, to Recipient, n Notification) error {
+	body, err := json.Marshal(slackMessage{Text: "*" + n.Subject + "*\n" + n.Body})
+	if err != nil {
+		return fmt.Errorf("failed to encode slack message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, to.Address, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid slack webhook url: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post to slack: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("slack responded with %s", resp.Status)
+	}
+	return nil
+}
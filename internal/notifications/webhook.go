@@ -0,0 +1,38 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/webhook"
+)
+
+// EventPrefix prefixes the event type of notifications posted to webhooks
+const EventPrefix = "notification."
+
+// WebhookNotifier posts notifications as signed JSON to the recipient's URL,
+// the same way events are delivered to webhook subscriptions
+type WebhookNotifier struct {
+	sender *webhook.Sender
+}
+
+func NewWebhookNotifier(sender *webhook.Sender) *WebhookNotifier {
+	return &WebhookNotifier{sender: sender}
+}
+
+// Notify posts the notification, signed with the recipient's secret
+func (w *WebhookNotifier) Notify(ctx context.Context, to Recipient, n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	result := w.sender.Send(ctx, webhook.Request{
+		URL:       to.Address,
+		Secret:    to.Secret,
+		EventType: EventPrefix + n.Type,
+		Body:      body,
+	})
+	return result.Err
+}
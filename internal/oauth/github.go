@@ -0,0 +1,74 @@
+package oauth
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"golang.org/x/oauth2"
+)
+
+const githubAPIURL = "https://api.github.com"
+
+type githubUser struct {
+	ID    int64  `json:"id"`
+	Login string `json:"login"`
+	Name  string `json:"name"`
+}
+
+type githubEmail struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+// newGitHub configures GitHub, which is plain OAuth2 so the identity is read
+// from its REST API
+func newGitHub(cfg *config.AuthConfig) *provider {
+	return &provider{
+		name: "github",
+		config: &oauth2.Config{
+			ClientID:     cfg.GitHub.ClientID,
+			ClientSecret: cfg.GitHub.ClientSecret,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  "https://github.com/login/oauth/authorize",
+				TokenURL: "https://github.com/login/oauth/access_token",
+			},
+			RedirectURL: redirectURL(cfg, "github"),
+			Scopes:      []string{"read:user", "user:email"},
+		},
+		fetchIdentity: fetchGitHubIdentity,
+	}
+}
+
+// fetchGitHubIdentity reads the user and their primary email, which is not
+// part of the profile when the user keeps it private
+func fetchGitHubIdentity(ctx context.Context, client *http.Client) (*Identity, error) {
+	var user githubUser
+	if err := getJSON(ctx, client, githubAPIURL+"/user", &user); err != nil {
+		return nil, err
+	}
+
+	var emails []githubEmail
+	if err := getJSON(ctx, client, githubAPIURL+"/user/emails", &emails); err != nil {
+		return nil, err
+	}
+
+	identity := &Identity{
+		Subject: strconv.FormatInt(user.ID, 10),
+		Name:    user.Name,
+	}
+	if identity.Name == "" {
+		identity.Name = user.Login
+	}
+	for _, email := range emails {
+		if email.Primary {
+			identity.Email = email.Email
+			identity.EmailVerified = email.Verified
+			break
+		}
+	}
+
+	return identity, nil
+}
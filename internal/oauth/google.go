@@ -0,0 +1,27 @@
+package oauth
+
+import (
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"golang.org/x/oauth2"
+)
+
+const googleUserInfoURL = "https://openidconnect.googleapis.com/v1/userinfo"
+
+// newGoogle configures Google, an OIDC provider whose endpoints are fixed so
+// no discovery is needed at startup
+func newGoogle(cfg *config.AuthConfig) *provider {
+	return &provider{
+		name: "google",
+		config: &oauth2.Config{
+			ClientID:     cfg.Google.ClientID,
+			ClientSecret: cfg.Google.ClientSecret,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  "https://accounts.google.com/o/oauth2/v2/auth",
+				TokenURL: "https://oauth2.googleapis.com/token",
+			},
+			RedirectURL: redirectURL(cfg, "google"),
+			Scopes:      []string{"openid", "email", "profile"},
+		},
+		fetchIdentity: fetchUserInfo(googleUserInfoURL),
+	}
+}
@@ -0,0 +1,131 @@
+// Package oauth implements social login with external OAuth2 and OpenID
+// Connect identity providers
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"golang.org/x/oauth2"
+)
+
+// maxResponseSize bounds the provider API responses that are decoded
+const maxResponseSize = 1 << 20
+
+// Identity is the account a user authenticated with at a provider
+type Identity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Provider is an external identity provider using the authorization code
+// flow protected with PKCE
+type Provider interface {
+	Name() string
+
+	// AuthCodeURL returns the provider's consent page for the given state and
+	// PKCE code verifier
+	AuthCodeURL(state, verifier string) string
+
+	// Exchange redeems the authorization code and fetches the user's identity
+	Exchange(ctx context.Context, code, verifier string) (*Identity, error)
+}
+
+// NewVerifier returns a new PKCE code verifier
+func NewVerifier() string {
+	return oauth2.GenerateVerifier()
+}
+
+// NewProviders creates the enabled providers keyed by name. OIDC providers
+// are configured through discovery, which requires reaching the issuer
+func NewProviders(ctx context.Context, cfg *config.AuthConfig) (map[string]Provider, error) {
+	providers := make(map[string]Provider)
+
+	if cfg.Google.Enabled {
+		providers["google"] = newGoogle(cfg)
+	}
+	if cfg.GitHub.Enabled {
+		providers["github"] = newGitHub(cfg)
+	}
+	if cfg.OIDC.Enabled {
+		p, err := newOIDC(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		providers[p.name] = p
+	}
+
+	return providers, nil
+}
+
+// provider implements the flow shared by all providers, fetchIdentity reads
+// the user from the provider's API with an authenticated client
+type provider struct {
+	name          string
+	config        *oauth2.Config
+	fetchIdentity func(ctx context.Context, client *http.Client) (*Identity, error)
+}
+
+func (p *provider) Name() string {
+	return p.name
+}
+
+func (p *provider) AuthCodeURL(state, verifier string) string {
+	return p.config.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier))
+}
+
+func (p *provider) Exchange(ctx context.Context, code, verifier string) (*Identity, error) {
+	token, err := p.config.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+
+	identity, err := p.fetchIdentity(ctx, p.config.Client(ctx, token))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s identity: %w", p.name, err)
+	}
+	if identity.Subject == "" {
+		return nil, errors.New("provider returned an identity without a subject")
+	}
+
+	identity.Provider = p.name
+	return identity, nil
+}
+
+// redirectURL returns the callback URL registered for the named provider
+func redirectURL(cfg *config.AuthConfig, name string) string {
+	return strings.TrimRight(cfg.OAuthRedirectURL, "/") + "/" + name + "/callback"
+}
+
+// getJSON fetches url with client and decodes the JSON response into v
+func getJSON(ctx context.Context, client *http.Client, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response from %s: %w", url, err)
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+// Package openapi builds an OpenAPI 3 document from the routes registered on
+// the gin engine. Paths and methods come from the router and schemas are
+// reflected from the Go types handlers bind and render, so the document
+// follows the code; handlers only add what cannot be derived, such as
+// summaries and query parameters
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// Version is the OpenAPI version of the generated documents
+const Version = "3.0.3"
+
+// Security schemes an operation can require
+const (
+	BearerAuth = "bearerAuth"
+	AdminAuth  = "adminToken"
+	SCIMAuth   = "scimToken"
+)
+
+// Operation describes a handler. Request and Response are values of the
+// types bound from and rendered to the JSON body, nil when there is none
+type Operation struct {
+	Summary     string
+	Description string
+	Tags        []string
+	Query       []Param
+	Request     any
+	// Status is the success status, 200 when zero
+	Status   int
+	Response any
+	// ContentType of the success response, application/json when empty
+	ContentType string
+	Security    string
+	// Raw JSON responses are not wrapped in the envelope of the spec
+	Raw bool
+}
+
+// Param is a query parameter, Type is a JSON schema type
+type Param struct {
+	Name        string
+	Type        string
+	Format      string
+	Description string
+	Required    bool
+}
+
+// List documents a list envelope whose "data" property holds Items. Without
+// an Envelope the envelope of the spec is used, or an object with only "data"
+type List struct {
+	Envelope any
+	Items    any
+}
+
+// Spec collects the operations of the API handlers
+type Spec struct {
+	title      string
+	version    string
+	envelope   any
+	deprecated []string
+	operations map[string]Operation
+}
+
+func New(title, version string) *Spec {
+	return &Spec{title: title, version: version, operations: make(map[string]Operation)}
+}
+
+// Envelope documents JSON responses as wrapped in the struct type of v, whose
+// "data" property holds the response of the operation
+func (s *Spec) Envelope(v any) {
+	s.envelope = v
+}
+
+// Deprecate marks the operations of the paths starting with prefix as
+// deprecated, such as the routes of an old API version
+func (s *Spec) Deprecate(prefix string) {
+	s.deprecated = append(s.deprecated, prefix)
+}
+
+// Describe documents the routes served by handler
+func (s *Spec) Describe(handler gin.HandlerFunc, op Operation) {
+	s.operations[handlerName(handler)] = op
+}
+
+// Build generates the document for the given routes. Routes whose handler
+// was not described are listed with a placeholder summary
+func (s *Spec) Build(routes gin.RoutesInfo) *Document {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: s.title, Version: s.version},
+		Paths:   make(map[string]PathItem),
+		Components: Components{
+			Schemas: make(map[string]*Schema),
+			SecuritySchemes: map[string]SecurityScheme{
+				BearerAuth: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+				AdminAuth:  {Type: "apiKey", In: "header", Name: middleware.AdminTokenHeader},
+				SCIMAuth:   {Type: "http", Scheme: "bearer"},
+			},
+		},
+	}
+	schemas := &generator{components: doc.Components.Schemas}
+	errorSchema := schemas.schema(reflect.TypeOf(errorResponse{}))
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+
+	for _, route := range routes {
+		op, ok := s.operations[route.Handler]
+		if !ok {
+			op = Operation{Summary: route.Method + " " + route.Path}
+		}
+
+		path, params := convertPath(route.Path)
+		item := doc.Paths[path]
+		if item == nil {
+			item = make(PathItem)
+			doc.Paths[path] = item
+		}
+		operation := s.operation(schemas, op, params, errorSchema)
+		for _, prefix := range s.deprecated {
+			if strings.HasPrefix(route.Path, prefix) {
+				operation.Deprecated = true
+			}
+		}
+		item[strings.ToLower(route.Method)] = operation
+	}
+	return doc
+}
+
+func (s *Spec) operation(schemas *generator, op Operation, params []Parameter, errorSchema *Schema) *OperationObject {
+	result := &OperationObject{
+		Summary:     op.Summary,
+		Description: op.Description,
+		Tags:        op.Tags,
+		Parameters:  params,
+		Responses: map[string]Response{
+			"default": {
+				Description: "Error",
+				Content:     map[string]MediaType{"application/json": {Schema: errorSchema}},
+			},
+		},
+	}
+	for _, param := range op.Query {
+		result.Parameters = append(result.Parameters, Parameter{
+			Name:        param.Name,
+			In:          "query",
+			Description: param.Description,
+			Required:    param.Required,
+			Schema:      &Schema{Type: param.Type, Format: param.Format},
+		})
+	}
+	if op.Security != "" {
+		result.Security = []map[string][]string{{op.Security: {}}}
+	}
+
+	if op.Request != nil {
+		result.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{"application/json": {Schema: schemas.value(op.Request)}},
+		}
+	}
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	response := Response{Description: http.StatusText(status)}
+	if op.Response != nil {
+		contentType := op.ContentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		response.Content = map[string]MediaType{contentType: {Schema: s.response(schemas, op, contentType)}}
+	}
+	result.Responses[strconv.Itoa(status)] = response
+	return result
+}
+
+// response returns the schema of the success response of op, wrapped in the
+// envelope unless op is raw or not JSON
+func (s *Spec) response(schemas *generator, op Operation, contentType string) *Schema {
+	if s.envelope == nil || op.Raw || contentType != "application/json" {
+		return schemas.value(op.Response)
+	}
+	if list, ok := op.Response.(List); ok {
+		if list.Envelope == nil {
+			list.Envelope = s.envelope
+		}
+		return schemas.value(list)
+	}
+
+	envelope := schemas.inline(reflect.TypeOf(s.envelope))
+	envelope.Properties["data"] = schemas.value(op.Response)
+	return envelope
+}
+
+// convertPath turns gin's :name and *name parameters into {name} and
+// returns them as path parameters
+func convertPath(path string) (string, []Parameter) {
+	var params []Parameter
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if segment == "" || segment[0] != ':' && segment[0] != '*' {
+			continue
+		}
+		name := segment[1:]
+		segments[i] = "{" + name + "}"
+
+		schema := &Schema{Type: "string"}
+		if name == "id" {
+			schema = &Schema{Type: "integer", Format: "int64"}
+		}
+		params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: schema})
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// handlerName returns the name gin reports for handler in RoutesInfo
+func handlerName(handler gin.HandlerFunc) string {
+	return runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name()
+}
@@ -0,0 +1,352 @@
+// Package queue is a Redis task queue for slow work taken off the request
+// path. Clients enqueue tasks, workers started in a separate process run
+// them with retries, and tasks that keep failing end up in a dead letter
+// queue where they can be inspected, retried or deleted.
+//
+// Each queue is kept in four Redis structures: a pending list, a scheduled
+// set of delayed and retried tasks scored by when they are due, an active
+// set scored by the lease deadline of running tasks, and a dead set scored
+// by failure time. Task data is stored once under its id and moved between
+// them by scripts, so a task is never lost or in two states at once
+package queue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/metrics"
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultQueue receives tasks enqueued without WithQueue
+const DefaultQueue = "default"
+
+var (
+	// ErrNotFound is returned for tasks that are not in the dead letter queue
+	ErrNotFound = errors.New("task not found")
+
+	// ErrUnknownQueue is returned for queues missing from the configuration
+	ErrUnknownQueue = errors.New("unknown queue")
+)
+
+// Task is a unit of work, Payload is decoded by the handler of its Type
+type Task struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Queue      string          `json:"queue"`
+	Payload    json.RawMessage `json:"payload"`
+	MaxRetries int             `json:"max_retries"`
+	Retried    int             `json:"retried"`
+	LastError  string          `json:"last_error,omitempty"`
+	EnqueuedAt time.Time       `json:"enqueued_at"`
+	FailedAt   *time.Time      `json:"failed_at,omitempty"`
+}
+
+// Stats counts the tasks of a queue by state
+type Stats struct {
+	Queue     string `json:"queue"`
+	Pending   int64  `json:"pending"`
+	Scheduled int64  `json:"scheduled"`
+	Active    int64  `json:"active"`
+	Dead      int64  `json:"dead"`
+}
+
+// Option customizes an enqueued task
+type Option func(*options)
+
+type options struct {
+	queue      string
+	maxRetries int
+	delay      time.Duration
+}
+
+// WithQueue enqueues the task on the named queue
+func WithQueue(name string) Option {
+	return func(o *options) { o.queue = name }
+}
+
+// WithMaxRetries overrides the configured number of retries
+func WithMaxRetries(n int) Option {
+	return func(o *options) { o.maxRetries = n }
+}
+
+// WithDelay runs the task no earlier than delay from now
+func WithDelay(delay time.Duration) Option {
+	return func(o *options) { o.delay = delay }
+}
+
+// Client enqueues tasks and inspects the queues
+type Client struct {
+	redis  *redis.Client
+	cfg    config.QueueConfig
+	prefix string
+
+	mu        sync.Mutex
+	stats     map[string]Stats
+	statsTime time.Time
+}
+
+// NewClient creates a client, keys are namespaced under prefix
+func NewClient(client *redis.Client, cfg config.QueueConfig, prefix string) *Client {
+	c := &Client{redis: client, cfg: cfg, prefix: prefix + "queue:"}
+
+	for _, queue := range cfg.Queues {
+		for _, state := range []string{"pending", "scheduled", "active", "dead"} {
+			metrics.Default.GaugeFunc("queue_depth", "Number of tasks in the queue by state",
+				metrics.Labels{"queue": queue, "state": state},
+				func() float64 { return c.depth(queue, state) },
+			)
+		}
+	}
+
+	return c
+}
+
+func (c *Client) key(queue, state string) string {
+	return c.prefix + queue + ":" + state
+}
+
+func (c *Client) taskPrefix() string {
+	return c.prefix + "task:"
+}
+
+func (c *Client) checkQueue(queue string) error {
+	if !slices.Contains(c.cfg.Queues, queue) {
+		return fmt.Errorf("%w: %s", ErrUnknownQueue, queue)
+	}
+	return nil
+}
+
+// Enqueue stores a task of taskType with payload encoded as JSON
+func (c *Client) Enqueue(ctx context.Context, taskType string, payload any, opts ...Option) (*Task, error) {
+	o := options{queue: DefaultQueue, maxRetries: c.cfg.MaxRetries}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err := c.checkQueue(o.queue); err != nil {
+		return nil, err
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode task payload: %w", err)
+	}
+
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	task := &Task{
+		ID:         id,
+		Type:       taskType,
+		Queue:      o.queue,
+		Payload:    raw,
+		MaxRetries: o.maxRetries,
+		EnqueuedAt: time.Now().UTC(),
+	}
+	data, err := json.Marshal(task)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode task: %w", err)
+	}
+
+	var runAt int64
+	if o.delay > 0 {
+		runAt = time.Now().Add(o.delay).UnixMilli()
+	}
+	err = enqueueScript.Run(ctx, c.redis,
+		[]string{c.taskPrefix() + id, c.key(o.queue, "pending"), c.key(o.queue, "scheduled")},
+		data, id, runAt,
+	).Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to enqueue task: %w", err)
+	}
+
+	metrics.Default.Counter("queue_tasks_enqueued_total", "Total number of tasks enqueued",
+		metrics.Labels{"queue": o.queue, "type": taskType},
+	).Inc()
+	return task, nil
+}
+
+// Stats counts the tasks of every queue
+func (c *Client) Stats(ctx context.Context) ([]Stats, error) {
+	pipe := c.redis.Pipeline()
+	cmds := make([][4]*redis.IntCmd, len(c.cfg.Queues))
+	for i, queue := range c.cfg.Queues {
+		cmds[i] = [4]*redis.IntCmd{
+			pipe.LLen(ctx, c.key(queue, "pending")),
+			pipe.ZCard(ctx, c.key(queue, "scheduled")),
+			pipe.ZCard(ctx, c.key(queue, "active")),
+			pipe.ZCard(ctx, c.key(queue, "dead")),
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to read queue stats: %w", err)
+	}
+
+	stats := make([]Stats, len(c.cfg.Queues))
+	for i, queue := range c.cfg.Queues {
+		stats[i] = Stats{
+			Queue:     queue,
+			Pending:   cmds[i][0].Val(),
+			Scheduled: cmds[i][1].Val(),
+			Active:    cmds[i][2].Val(),
+			Dead:      cmds[i][3].Val(),
+		}
+	}
+	return stats, nil
+}
+
+// depthRefresh limits how often a metrics scrape reads the queue stats
+const depthRefresh = 5 * time.Second
+
+// depth returns the size of one state of a queue for the queue_depth gauge,
+// reusing stats read in the last few seconds so a scrape costs one round trip
+func (c *Client) depth(queue, state string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.statsTime) > depthRefresh {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		stats, err := c.Stats(ctx)
+		cancel()
+		// Keep reporting the last known depth while Redis is unreachable
+		if err == nil {
+			c.stats = make(map[string]Stats, len(stats))
+			for _, s := range stats {
+				c.stats[s.Queue] = s
+			}
+			c.statsTime = time.Now()
+		}
+	}
+
+	s := c.stats[queue]
+	switch state {
+	case "pending":
+		return float64(s.Pending)
+	case "scheduled":
+		return float64(s.Scheduled)
+	case "active":
+		return float64(s.Active)
+	default:
+		return float64(s.Dead)
+	}
+}
+
+// ListDead returns a page of the dead tasks of queue, most recent failures
+// first, and the total number of dead tasks
+func (c *Client) ListDead(ctx context.Context, queue string, offset, limit int) ([]*Task, int, error) {
+	if err := c.checkQueue(queue); err != nil {
+		return nil, 0, err
+	}
+
+	deadKey := c.key(queue, "dead")
+	total, err := c.redis.ZCard(ctx, deadKey).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count dead tasks: %w", err)
+	}
+
+	ids, err := c.redis.ZRevRange(ctx, deadKey, int64(offset), int64(offset+limit-1)).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list dead tasks: %w", err)
+	}
+	if len(ids) == 0 {
+		return []*Task{}, int(total), nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = c.taskPrefix() + id
+	}
+	values, err := c.redis.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to load dead tasks: %w", err)
+	}
+
+	tasks := make([]*Task, 0, len(values))
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			// Purged between the two reads
+			continue
+		}
+		var task Task
+		if err := json.Unmarshal([]byte(data), &task); err != nil {
+			return nil, 0, fmt.Errorf("failed to decode dead task: %w", err)
+		}
+		tasks = append(tasks, &task)
+	}
+	return tasks, int(total), nil
+}
+
+// RetryDead moves a dead task back to the pending list with its retries reset
+func (c *Client) RetryDead(ctx context.Context, queue, id string) error {
+	if err := c.checkQueue(queue); err != nil {
+		return err
+	}
+
+	data, err := c.redis.Get(ctx, c.taskPrefix()+id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load dead task: %w", err)
+	}
+
+	var task Task
+	if err := json.Unmarshal(data, &task); err != nil {
+		return fmt.Errorf("failed to decode dead task: %w", err)
+	}
+	task.Retried = 0
+	task.FailedAt = nil
+	if data, err = json.Marshal(&task); err != nil {
+		return fmt.Errorf("failed to encode task: %w", err)
+	}
+
+	moved, err := retryDeadScript.Run(ctx, c.redis,
+		[]string{c.key(queue, "dead"), c.key(queue, "pending"), c.taskPrefix() + id},
+		id, data,
+	).Int64()
+	if err != nil {
+		return fmt.Errorf("failed to retry dead task: %w", err)
+	}
+	if moved == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteDead removes a dead task for good
+func (c *Client) DeleteDead(ctx context.Context, queue, id string) error {
+	if err := c.checkQueue(queue); err != nil {
+		return err
+	}
+
+	deleted, err := deleteDeadScript.Run(ctx, c.redis,
+		[]string{c.key(queue, "dead"), c.taskPrefix() + id},
+		id,
+	).Int64()
+	if err != nil {
+		return fmt.Errorf("failed to delete dead task: %w", err)
+	}
+	if deleted == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate task id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,150 @@
+package queue
+
+import "github.com/redis/go-redis/v9"
+
+// enqueueScript stores a task and queues its id, on the scheduled set when
+// it has a run time
+//
+// KEYS: task, pending, scheduled
+// ARGV: data, id, run time in ms or 0
+var enqueueScript = redis.NewScript(`
+redis.call('SET', KEYS[1], ARGV[1])
+if tonumber(ARGV[3]) > 0 then
+	redis.call('ZADD', KEYS[3], ARGV[3], ARGV[2])
+else
+	redis.call('LPUSH', KEYS[2], ARGV[2])
+end
+return 1
+`)
+
+// dequeueScript takes the oldest pending task and leases it to the caller
+// until the lease deadline, returning its data or false when none is pending
+//
+// KEYS: pending, active
+// ARGV: lease deadline in ms, task key prefix
+var dequeueScript = redis.NewScript(`
+local id = redis.call('RPOP', KEYS[1])
+if not id then
+	return false
+end
+local data = redis.call('GET', ARGV[2] .. id)
+if not data then
+	return false
+end
+redis.call('ZADD', KEYS[2], ARGV[1], id)
+return data
+`)
+
+// extendLeaseScript moves the lease deadline of a running task, returning 0
+// when the lease was lost to another worker
+//
+// KEYS: active
+// ARGV: id, lease deadline in ms
+var extendLeaseScript = redis.NewScript(`
+if not redis.call('ZSCORE', KEYS[1], ARGV[1]) then
+	return 0
+end
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[1])
+return 1
+`)
+
+// completeScript drops a finished task. Tasks whose lease was lost are left
+// alone, they have been handed to another worker
+//
+// KEYS: active, task
+// ARGV: id
+var completeScript = redis.NewScript(`
+if redis.call('ZREM', KEYS[1], ARGV[1]) == 1 then
+	redis.call('DEL', KEYS[2])
+end
+return 1
+`)
+
+// retryScript schedules a failed task to run again
+//
+// KEYS: active, scheduled, task
+// ARGV: id, data, run time in ms
+var retryScript = redis.NewScript(`
+if redis.call('ZREM', KEYS[1], ARGV[1]) == 1 then
+	redis.call('SET', KEYS[3], ARGV[2])
+	redis.call('ZADD', KEYS[2], ARGV[3], ARGV[1])
+end
+return 1
+`)
+
+// killScript moves a task that failed for good to the dead set
+//
+// KEYS: active, dead, task
+// ARGV: id, data, failure time in ms
+var killScript = redis.NewScript(`
+if redis.call('ZREM', KEYS[1], ARGV[1]) == 1 then
+	redis.call('SET', KEYS[3], ARGV[2])
+	redis.call('ZADD', KEYS[2], ARGV[3], ARGV[1])
+end
+return 1
+`)
+
+// requeueScript returns a task interrupted by shutdown to the front of the
+// pending list
+//
+// KEYS: active, pending
+// ARGV: id
+var requeueScript = redis.NewScript(`
+if redis.call('ZREM', KEYS[1], ARGV[1]) == 1 then
+	redis.call('RPUSH', KEYS[2], ARGV[1])
+end
+return 1
+`)
+
+// forwardScript moves due scheduled tasks to the pending list, hands tasks
+// whose lease expired back to it and drops dead tasks past the retention.
+// It works in batches and returns the number of tasks moved
+//
+// KEYS: scheduled, active, pending, dead
+// ARGV: now in ms, dead cutoff in ms, task key prefix
+var forwardScript = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, 100)
+for _, id in ipairs(due) do
+	redis.call('ZREM', KEYS[1], id)
+	redis.call('LPUSH', KEYS[3], id)
+end
+
+local expired = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[1], 'LIMIT', 0, 100)
+for _, id in ipairs(expired) do
+	redis.call('ZREM', KEYS[2], id)
+	redis.call('RPUSH', KEYS[3], id)
+end
+
+local purged = redis.call('ZRANGEBYSCORE', KEYS[4], '-inf', ARGV[2], 'LIMIT', 0, 100)
+for _, id in ipairs(purged) do
+	redis.call('ZREM', KEYS[4], id)
+	redis.call('DEL', ARGV[3] .. id)
+end
+
+return #due + #expired
+`)
+
+// retryDeadScript moves a dead task back to the pending list
+//
+// KEYS: dead, pending, task
+// ARGV: id, data
+var retryDeadScript = redis.NewScript(`
+if redis.call('ZREM', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call('SET', KEYS[3], ARGV[2])
+redis.call('LPUSH', KEYS[2], ARGV[1])
+return 1
+`)
+
+// deleteDeadScript removes a dead task and its data
+//
+// KEYS: dead, task
+// ARGV: id
+var deleteDeadScript = redis.NewScript(`
+if redis.call('ZREM', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call('DEL', KEYS[2])
+return 1
+`)
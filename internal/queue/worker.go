@@ -0,0 +1,306 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+	"github.com/MuthuM3/gin-microservice-template/internal/metrics"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrSkipRetry marks failures that retrying cannot fix, wrap it to send the
+// task straight to the dead letter queue
+var ErrSkipRetry = errors.New("skip retry")
+
+// HandlerFunc runs a task, returning an error to retry it
+type HandlerFunc func(ctx context.Context, task *Task) error
+
+// Worker runs tasks with the handlers registered for their type
+type Worker struct {
+	client   *Client
+	log      logger.Logger
+	handlers map[string]HandlerFunc
+}
+
+// NewWorker creates a worker taking tasks from the queues of client
+func NewWorker(client *Client, log logger.Logger) *Worker {
+	return &Worker{client: client, log: log, handlers: make(map[string]HandlerFunc)}
+}
+
+// Handle registers fn for tasks of taskType, must be called before Run
+func (w *Worker) Handle(taskType string, fn HandlerFunc) {
+	w.handlers[taskType] = fn
+}
+
+// Run processes tasks until ctx is cancelled, then waits for running tasks
+// for up to the shutdown timeout. Tasks still running after it are
+// cancelled and returned to their queue
+func (w *Worker) Run(ctx context.Context) error {
+	cfg := w.client.cfg
+
+	// Tasks outlive ctx so they can finish during shutdown
+	taskCtx, cancelTasks := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelTasks()
+
+	var wg sync.WaitGroup
+	for range cfg.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.process(ctx, taskCtx)
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w.forward(ctx)
+	}()
+
+	w.log.Info("queue worker started", "queues", cfg.Queues, "concurrency", cfg.Concurrency)
+	<-ctx.Done()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(cfg.ShutdownTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return nil
+	case <-timer.C:
+		cancelTasks()
+		<-done
+		return fmt.Errorf("queue worker did not stop within %s, running tasks were requeued", cfg.ShutdownTimeout)
+	}
+}
+
+// process runs tasks one at a time until ctx is cancelled
+func (w *Worker) process(ctx, taskCtx context.Context) {
+	for ctx.Err() == nil {
+		task, err := w.dequeue(ctx)
+		if err != nil && ctx.Err() == nil {
+			w.log.Error("failed to dequeue task", "error", err)
+		}
+		if task == nil {
+			select {
+			case <-time.After(w.client.cfg.PollInterval):
+			case <-ctx.Done():
+			}
+			continue
+		}
+		w.run(taskCtx, task)
+	}
+}
+
+// dequeue leases the next task, trying the queues in priority order
+func (w *Worker) dequeue(ctx context.Context) (*Task, error) {
+	c := w.client
+	for _, queue := range c.cfg.Queues {
+		deadline := time.Now().Add(c.cfg.LeaseDuration).UnixMilli()
+		data, err := dequeueScript.Run(ctx, c.redis,
+			[]string{c.key(queue, "pending"), c.key(queue, "active")},
+			deadline, c.taskPrefix(),
+		).Text()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to dequeue from %s: %w", queue, err)
+		}
+
+		var task Task
+		if err := json.Unmarshal([]byte(data), &task); err != nil {
+			return nil, fmt.Errorf("failed to decode task: %w", err)
+		}
+		return &task, nil
+	}
+	return nil, nil
+}
+
+// run executes a task while renewing its lease and records the outcome
+func (w *Worker) run(ctx context.Context, task *Task) {
+	c := w.client
+	log := w.log.With("task_id", task.ID, "task_type", task.Type, "queue", task.Queue)
+
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.TaskTimeout)
+	defer cancel()
+
+	stopLease := w.keepLease(ctx, task, log)
+	start := time.Now()
+	err := w.call(ctx, task)
+	duration := time.Since(start)
+	stopLease()
+
+	// Use a fresh context, ctx may have just been cancelled
+	stateCtx, stateCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer stateCancel()
+
+	var status string
+	switch {
+	case err == nil:
+		status = "success"
+		err = completeScript.Run(stateCtx, c.redis,
+			[]string{c.key(task.Queue, "active"), c.taskPrefix() + task.ID},
+			task.ID,
+		).Err()
+	case errors.Is(ctx.Err(), context.Canceled):
+		// Interrupted by shutdown, another worker picks it up
+		status = "requeued"
+		err = requeueScript.Run(stateCtx, c.redis,
+			[]string{c.key(task.Queue, "active"), c.key(task.Queue, "pending")},
+			task.ID,
+		).Err()
+	default:
+		task.LastError = err.Error()
+		if errors.Is(err, ErrSkipRetry) || task.Retried >= task.MaxRetries {
+			status = "dead"
+			log.Error("task failed, moved to the dead letter queue", "retried", task.Retried, "error", err)
+			err = w.kill(stateCtx, task)
+		} else {
+			status = "retry"
+			delay := w.backoff(task.Retried)
+			task.Retried++
+			log.Warn("task failed, retrying", "retry", task.Retried, "delay", delay, "error", err)
+			err = w.retry(stateCtx, task, delay)
+		}
+	}
+	if err != nil {
+		log.Error("failed to update task state", "status", status, "error", err)
+	}
+
+	metrics.Default.Counter("queue_tasks_processed_total", "Total number of tasks processed by outcome",
+		metrics.Labels{"queue": task.Queue, "type": task.Type, "status": status},
+	).Inc()
+	metrics.Default.Counter("queue_task_duration_seconds_total", "Total time spent running tasks",
+		metrics.Labels{"queue": task.Queue, "type": task.Type},
+	).Add(duration.Seconds())
+}
+
+// call runs the handler of task, turning a panic into an error
+func (w *Worker) call(ctx context.Context, task *Task) (err error) {
+	fn, ok := w.handlers[task.Type]
+	if !ok {
+		return fmt.Errorf("%w: no handler for task type %q", ErrSkipRetry, task.Type)
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v\n%s", recovered, debug.Stack())
+		}
+	}()
+	return fn(ctx, task)
+}
+
+// keepLease renews the lease of task until the returned func is called
+func (w *Worker) keepLease(ctx context.Context, task *Task, log logger.Logger) func() {
+	c := w.client
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(c.cfg.LeaseDuration / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+
+			deadline := time.Now().Add(c.cfg.LeaseDuration).UnixMilli()
+			held, err := extendLeaseScript.Run(ctx, c.redis,
+				[]string{c.key(task.Queue, "active")},
+				task.ID, deadline,
+			).Int64()
+			switch {
+			case err != nil && ctx.Err() == nil:
+				log.Warn("failed to extend task lease", "error", err)
+			case err == nil && held == 0:
+				log.Warn("task lease lost, it may run twice")
+				return
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func (w *Worker) retry(ctx context.Context, task *Task, delay time.Duration) error {
+	c := w.client
+	data, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("failed to encode task: %w", err)
+	}
+	return retryScript.Run(ctx, c.redis,
+		[]string{c.key(task.Queue, "active"), c.key(task.Queue, "scheduled"), c.taskPrefix() + task.ID},
+		task.ID, data, time.Now().Add(delay).UnixMilli(),
+	).Err()
+}
+
+func (w *Worker) kill(ctx context.Context, task *Task) error {
+	c := w.client
+	now := time.Now().UTC()
+	task.FailedAt = &now
+	data, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("failed to encode task: %w", err)
+	}
+	return killScript.Run(ctx, c.redis,
+		[]string{c.key(task.Queue, "active"), c.key(task.Queue, "dead"), c.taskPrefix() + task.ID},
+		task.ID, data, now.UnixMilli(),
+	).Err()
+}
+
+// backoff returns a random delay of up to the base delay doubled per retry
+// (full jitter), capped at the maximum delay
+func (w *Worker) backoff(retried int) time.Duration {
+	cfg := w.client.cfg
+	ceiling := cfg.RetryMaxDelay
+	if retried < 30 {
+		ceiling = min(cfg.RetryBaseDelay<<retried, cfg.RetryMaxDelay)
+	}
+	return rand.N(ceiling) + 1
+}
+
+// forward moves due and orphaned tasks to their pending lists every poll
+// interval until ctx is cancelled. Every worker does this, the scripts make
+// it safe to run concurrently
+func (w *Worker) forward(ctx context.Context) {
+	c := w.client
+	ticker := time.NewTicker(c.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		now := time.Now()
+		for _, queue := range c.cfg.Queues {
+			err := forwardScript.Run(ctx, c.redis,
+				[]string{c.key(queue, "scheduled"), c.key(queue, "active"), c.key(queue, "pending"), c.key(queue, "dead")},
+				now.UnixMilli(), now.Add(-c.cfg.DeadRetention).UnixMilli(), c.taskPrefix(),
+			).Err()
+			if err != nil && ctx.Err() == nil {
+				w.log.Error("failed to forward scheduled tasks", "queue", queue, "error", err)
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
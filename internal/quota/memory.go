@@ -0,0 +1,45 @@
+package quota
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryCounter counts calls in process for single instance deployments
+type MemoryCounter struct {
+	mu     sync.Mutex
+	day    string
+	counts map[int64]int64
+}
+
+func NewMemoryCounter() *MemoryCounter {
+	return &MemoryCounter{counts: make(map[int64]int64)}
+}
+
+// Incr records a call of the user today
+func (c *MemoryCounter) Incr(_ context.Context, userID int64) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.rollover()
+	c.counts[userID]++
+	return c.counts[userID], nil
+}
+
+// Count returns the calls the user made today
+func (c *MemoryCounter) Count(_ context.Context, userID int64) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.rollover()
+	return c.counts[userID], nil
+}
+
+// rollover drops the counts of previous days, the caller holds the lock
+func (c *MemoryCounter) rollover() {
+	if today := Day(time.Now()); today != c.day {
+		c.day = today
+		clear(c.counts)
+	}
+}
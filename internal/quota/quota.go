@@ -0,0 +1,38 @@
+// Package quota counts the API calls of each user per UTC day, the other
+// quotas are enforced from the stored data by the services
+package quota
+
+import (
+	"context"
+	"time"
+)
+
+// Counter counts API calls per user and UTC day
+type Counter interface {
+	// Incr records a call of the user today and returns the calls made today
+	// including it
+	Incr(ctx context.Context, userID int64) (int64, error)
+
+	// Count returns the calls the user made today
+	Count(ctx context.Context, userID int64) (int64, error)
+}
+
+// Result is the state of a user's daily call quota after a call. Limit is
+// zero when the user's calls are unlimited
+type Result struct {
+	Allowed   bool
+	Limit     int64
+	Remaining int64
+	ResetAt   time.Time
+}
+
+// Day returns the UTC day t falls in, formatted as YYYY-MM-DD
+func Day(t time.Time) string {
+	return t.UTC().Format(time.DateOnly)
+}
+
+// ResetAt returns when the counts of the day t falls in reset, midnight UTC
+func ResetAt(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,60 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// keyTTL keeps a day's counts a little past its end so calls made around
+// midnight are not counted against a key that already expired
+const keyTTL = 48 * time.Hour
+
+// incrScript increments the count and sets the expiry of a new key in one
+// round trip
+var incrScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if count == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return count
+`)
+
+// RedisCounter counts calls in Redis so every replica shares the count
+type RedisCounter struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedisCounter(client *redis.Client, prefix string) *RedisCounter {
+	return &RedisCounter{client: client, prefix: prefix}
+}
+
+func (c *RedisCounter) key(userID int64) string {
+	return c.prefix + "quota:calls:" + Day(time.Now()) + ":" + strconv.FormatInt(userID, 10)
+}
+
+// Incr records a call of the user today
+func (c *RedisCounter) Incr(ctx context.Context, userID int64) (int64, error) {
+	count, err := incrScript.Run(ctx, c.client, []string{c.key(userID)}, keyTTL.Milliseconds()).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count api call: %w", err)
+	}
+	return count, nil
+}
+
+// Count returns the calls the user made today
+func (c *RedisCounter) Count(ctx context.Context, userID int64) (int64, error) {
+	count, err := c.client.Get(ctx, c.key(userID)).Int64()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get api call count: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,124 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// MemoryLimiter is a token bucket limiter for single instance deployments.
+// Each key gets a bucket holding up to limit tokens that refills evenly over
+// the window
+type MemoryLimiter struct {
+	limit      int
+	window     time.Duration
+	refillRate float64 // tokens per second
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+
+	stop chan struct{}
+	once sync.Once
+}
+
+type bucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// NewMemoryLimiter creates a limiter allowing limit requests per window and
+// starts a janitor that evicts idle buckets
+func NewMemoryLimiter(limit int, window time.Duration) *MemoryLimiter {
+	l := &MemoryLimiter{
+		limit:      limit,
+		window:     window,
+		refillRate: float64(limit) / window.Seconds(),
+		buckets:    make(map[string]*bucket),
+		stop:       make(chan struct{}),
+	}
+
+	go l.cleanup(window)
+	return l
+}
+
+// SetLimit changes the quota at runtime, existing buckets keep their tokens
+// and are capped to the new limit on their next request
+func (l *MemoryLimiter) SetLimit(limit int, window time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.limit = limit
+	l.window = window
+	l.refillRate = float64(limit) / window.Seconds()
+}
+
+// Allow takes a token from the key's bucket if one is available
+func (l *MemoryLimiter) Allow(ctx context.Context, key string) (Result, error) {
+	return l.AllowN(ctx, key, 1)
+}
+
+// AllowN takes n tokens from the key's bucket if that many are available
+func (l *MemoryLimiter) AllowN(_ context.Context, key string, n int) (Result, error) {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	cost := float64(min(max(n, 1), l.limit))
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.limit), lastSeen: now}
+		l.buckets[key] = b
+	}
+
+	// Refill based on the time elapsed since the last request
+	elapsed := now.Sub(b.lastSeen).Seconds()
+	b.tokens = math.Min(float64(l.limit), b.tokens+elapsed*l.refillRate)
+	b.lastSeen = now
+
+	if b.tokens < cost {
+		wait := (cost - b.tokens) / l.refillRate
+		return Result{
+			Allowed:    false,
+			Limit:      l.limit,
+			Remaining:  0,
+			RetryAfter: time.Duration(wait * float64(time.Second)),
+		}, nil
+	}
+
+	b.tokens -= cost
+	return Result{
+		Allowed:   true,
+		Limit:     l.limit,
+		Remaining: int(b.tokens),
+	}, nil
+}
+
+// Close stops the janitor goroutine
+func (l *MemoryLimiter) Close() error {
+	l.once.Do(func() { close(l.stop) })
+	return nil
+}
+
+// cleanup periodically removes buckets that have been idle long enough to be full again
+func (l *MemoryLimiter) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			l.mu.Lock()
+			cutoff := time.Now().Add(-l.window)
+			for key, b := range l.buckets {
+				if b.lastSeen.Before(cutoff) {
+					delete(l.buckets, key)
+				}
+			}
+			l.mu.Unlock()
+		case <-l.stop:
+			return
+		}
+	}
+}
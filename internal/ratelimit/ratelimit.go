@@ -0,0 +1,23 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Result describes the outcome of a rate limit check
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// Limiter decides whether a request identified by key may proceed
+type Limiter interface {
+	Allow(ctx context.Context, key string) (Result, error)
+
+	// AllowN admits a request costing n requests of the limit, costs above
+	// the limit count as the whole limit so the request can still pass
+	AllowN(ctx context.Context, key string, n int) (Result, error)
+}
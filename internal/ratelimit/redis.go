@@ -0,0 +1,106 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// slidingWindowScript implements a sliding window log in a sorted set. It
+// drops entries older than the window, admits a request costing cost entries
+// if they fit under limit and returns {allowed, remaining, retry_after_ms},
+// the retry being when enough entries have left the window
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+local member = ARGV[4]
+local cost = tonumber(ARGV[5])
+
+redis.call('ZREMRANGEBYSCORE', key, 0, now - window)
+local count = redis.call('ZCARD', key)
+
+if count + cost <= limit then
+	for i = 1, cost do
+		redis.call('ZADD', key, now, member .. ':' .. i)
+	end
+	redis.call('PEXPIRE', key, window)
+	return {1, limit - count - cost, 0}
+end
+
+local expiring = count + cost - limit - 1
+local oldest = redis.call('ZRANGE', key, expiring, expiring, 'WITHSCORES')
+local retry = window
+if oldest[2] then
+	retry = tonumber(oldest[2]) + window - now
+end
+return {0, 0, retry}
+`)
+
+// RedisLimiter is a sliding window limiter shared by all replicas through Redis
+type RedisLimiter struct {
+	client *redis.Client
+	prefix string
+
+	mu     sync.RWMutex
+	limit  int
+	window time.Duration
+}
+
+// NewRedisLimiter creates a limiter allowing limit requests per window per key
+func NewRedisLimiter(client *redis.Client, limit int, window time.Duration, prefix string) *RedisLimiter {
+	return &RedisLimiter{
+		client: client,
+		limit:  limit,
+		window: window,
+		prefix: prefix,
+	}
+}
+
+// SetLimit changes the quota at runtime
+func (l *RedisLimiter) SetLimit(limit int, window time.Duration) {
+	l.mu.Lock()
+	l.limit = limit
+	l.window = window
+	l.mu.Unlock()
+}
+
+// Allow records the request in the key's window if the limit has not been reached
+func (l *RedisLimiter) Allow(ctx context.Context, key string) (Result, error) {
+	return l.AllowN(ctx, key, 1)
+}
+
+// AllowN records n entries for the request in the key's window if they fit
+// under the limit
+func (l *RedisLimiter) AllowN(ctx context.Context, key string, n int) (Result, error) {
+	now := time.Now()
+	nowMs := now.UnixMilli()
+	member := strconv.FormatInt(now.UnixNano(), 10)
+
+	l.mu.RLock()
+	limit, window := l.limit, l.window
+	l.mu.RUnlock()
+
+	values, err := slidingWindowScript.Run(ctx, l.client,
+		[]string{l.prefix + "ratelimit:" + key},
+		nowMs, window.Milliseconds(), limit, member, min(max(n, 1), limit),
+	).Int64Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to evaluate rate limit: %w", err)
+	}
+	if len(values) != 3 {
+		return Result{}, fmt.Errorf("unexpected rate limit script result: %v", values)
+	}
+
+	return Result{
+		Allowed:    values[0] == 1,
+		Limit:      limit,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+	}, nil
+}
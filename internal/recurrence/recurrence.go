@@ -0,0 +1,361 @@
+// Package recurrence parses and expands the subset of RFC 5545 recurrence
+// rules todos repeat by: a DAILY, WEEKLY, MONTHLY or YEARLY frequency with
+// INTERVAL, COUNT or UNTIL, BYDAY for weekly rules and BYMONTHDAY for
+// monthly ones. A rule starts at the due date of the todo it belongs to,
+// occurrences keep its time of day and are computed in its time zone
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequencies of a rule
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+	Yearly  = "YEARLY"
+)
+
+const (
+	// MaxInterval is the largest INTERVAL accepted
+	MaxInterval = 999
+
+	// MaxCount is the largest COUNT accepted
+	MaxCount = 1000
+
+	// maxPeriods bounds how many periods are expanded looking for an
+	// occurrence, rules such as the 31st of every February never match
+	maxPeriods = 10000
+
+	untilLayout     = "20060102T150405Z"
+	untilDateLayout = "20060102"
+)
+
+// weekdays maps the two letter day names of BYDAY to weekdays
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// Rule is a parsed recurrence rule
+type Rule struct {
+	Freq     string
+	Interval int
+
+	// Count is the number of occurrences including the first, zero repeats
+	// until Until or forever
+	Count int
+
+	// Until is the last time an occurrence may fall on, inclusive
+	Until *time.Time
+
+	ByDay      []time.Weekday
+	ByMonthDay []int
+}
+
+// Parse parses a rule such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH". An
+// "RRULE:" prefix is allowed, parts are case-insensitive
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(s)), "RRULE:")
+	if s == "" {
+		return nil, errors.New("rule is empty")
+	}
+
+	rule := &Rule{Interval: 1}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("rule part %q must have the form NAME=VALUE", part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("rule part %s is given twice", name)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			if !slices.Contains([]string{Daily, Weekly, Monthly, Yearly}, value) {
+				return nil, fmt.Errorf("FREQ must be one of %s, %s, %s or %s", Daily, Weekly, Monthly, Yearly)
+			}
+			rule.Freq = value
+		case "INTERVAL":
+			rule.Interval, err = parseNumber(name, value, 1, MaxInterval)
+		case "COUNT":
+			rule.Count, err = parseNumber(name, value, 1, MaxCount)
+		case "UNTIL":
+			rule.Until, err = parseUntil(value)
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(value)
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseByMonthDay(value)
+		default:
+			return nil, fmt.Errorf("rule part %s is not supported", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case rule.Freq == "":
+		return nil, errors.New("FREQ is required")
+	case rule.Count > 0 && rule.Until != nil:
+		return nil, errors.New("COUNT and UNTIL cannot be combined")
+	case rule.ByDay != nil && rule.Freq != Weekly:
+		return nil, errors.New("BYDAY is only supported with FREQ=WEEKLY")
+	case rule.ByMonthDay != nil && rule.Freq != Monthly:
+		return nil, errors.New("BYMONTHDAY is only supported with FREQ=MONTHLY")
+	}
+	return rule, nil
+}
+
+func parseNumber(name, value string, lo, hi int) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < lo || n > hi {
+		return 0, fmt.Errorf("%s must be a number between %d and %d", name, lo, hi)
+	}
+	return n, nil
+}
+
+// parseUntil accepts a UTC date-time or a date, which includes the whole day
+func parseUntil(value string) (*time.Time, error) {
+	if until, err := time.Parse(untilLayout, value); err == nil {
+		return &until, nil
+	}
+	if day, err := time.Parse(untilDateLayout, value); err == nil {
+		until := day.Add(24*time.Hour - time.Second)
+		return &until, nil
+	}
+	return nil, errors.New("UNTIL must be a UTC date-time such as 20261231T235959Z or a date such as 20261231")
+}
+
+func parseByDay(value string) ([]time.Weekday, error) {
+	var days []time.Weekday
+	for _, name := range strings.Split(value, ",") {
+		day, ok := weekdays[name]
+		if !ok {
+			return nil, fmt.Errorf("BYDAY must list days of MO, TU, WE, TH, FR, SA and SU, got %q", name)
+		}
+		if !slices.Contains(days, day) {
+			days = append(days, day)
+		}
+	}
+	slices.SortFunc(days, func(a, b time.Weekday) int { return weekdayOffset(a) - weekdayOffset(b) })
+	return days, nil
+}
+
+func parseByMonthDay(value string) ([]int, error) {
+	var days []int
+	for _, field := range strings.Split(value, ",") {
+		day, err := strconv.Atoi(field)
+		if err != nil || day == 0 || day < -31 || day > 31 {
+			return nil, fmt.Errorf("BYMONTHDAY must list days between 1 and 31 or -31 and -1, got %q", field)
+		}
+		if !slices.Contains(days, day) {
+			days = append(days, day)
+		}
+	}
+	return days, nil
+}
+
+// String returns the canonical form of the rule, which Parse accepts
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		names := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			names[i] = strings.ToUpper(day.String()[:2])
+		}
+		parts = append(parts, "BYDAY="+strings.Join(names, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, day := range r.ByMonthDay {
+			days[i] = strconv.Itoa(day)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilLayout))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next returns the first occurrence of the rule starting at start that falls
+// after after, along with the rule continuing the series from there: its
+// COUNT is reduced by the occurrences before it. ok is false once the rule
+// has ended
+func (r *Rule) Next(start, after time.Time) (next time.Time, rest *Rule, ok bool) {
+	index := 0
+	r.each(start, after, func(t time.Time) bool {
+		if t.After(after) {
+			next, ok = t, true
+			return false
+		}
+		index++
+		return true
+	})
+	if !ok {
+		return time.Time{}, nil, false
+	}
+
+	continued := *r
+	if r.Count > 0 {
+		continued.Count = r.Count - index
+	}
+	return next, &continued, true
+}
+
+// Upcoming returns up to n occurrences of the rule starting at start that
+// fall after after, earliest first
+func (r *Rule) Upcoming(start, after time.Time, n int) []time.Time {
+	if n <= 0 {
+		return []time.Time{}
+	}
+
+	occurrences := make([]time.Time, 0, n)
+
+	r.each(start, after, func(t time.Time) bool {
+		if t.After(after) {
+			occurrences = append(occurrences, t)
+		}
+		return len(occurrences) < n
+	})
+	return occurrences
+}
+
+// each calls fn with the occurrences of the rule starting at start in order
+// until fn returns false or the rule ends. Rules without COUNT skip the
+// periods that end before after, the occurrences of rules with one are
+// counted from start
+func (r *Rule) each(start, after time.Time, fn func(time.Time) bool) {
+	first := 0
+	if r.Count == 0 {
+		first = max(r.periodsBetween(start, after)-1, 0)
+	}
+
+	seen := 0
+	for k := first; k < first+maxPeriods; k++ {
+		for _, t := range r.period(start, k) {
+			if t.Before(start) {
+				continue
+			}
+			if r.Until != nil && t.After(*r.Until) {
+				return
+			}
+			if !fn(t) {
+				return
+			}
+			if seen++; r.Count > 0 && seen >= r.Count {
+				return
+			}
+		}
+	}
+}
+
+// periodsBetween estimates how many whole periods of the rule lie between
+// start and t, never overestimating
+func (r *Rule) periodsBetween(start, t time.Time) int {
+	if !t.After(start) {
+		return 0
+	}
+
+	days := int(t.Sub(start).Hours() / 24)
+	switch r.Freq {
+	case Daily:
+		return days / r.Interval
+	case Weekly:
+		return days / 7 / r.Interval
+	case Monthly:
+		months := (t.Year()-start.Year())*12 + int(t.Month()-start.Month())
+		return (months - 1) / r.Interval
+	default:
+		return (t.Year() - start.Year() - 1) / r.Interval
+	}
+}
+
+// period returns the candidate occurrences of the k-th period of the rule in
+// order, some may lie before start
+func (r *Rule) period(start time.Time, k int) []time.Time {
+	step := k * r.Interval
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+	}
+
+	switch r.Freq {
+	case Daily:
+		return []time.Time{start.AddDate(0, 0, step)}
+
+	case Weekly:
+		monday := start.AddDate(0, 0, -weekdayOffset(start.Weekday())+7*step)
+		days := r.ByDay
+		if len(days) == 0 {
+			days = []time.Weekday{start.Weekday()}
+		}
+		candidates := make([]time.Time, len(days))
+		for i, day := range days {
+			candidates[i] = monday.AddDate(0, 0, weekdayOffset(day))
+		}
+		return candidates
+
+	case Monthly:
+		month := at(start.Year(), start.Month()+time.Month(step), 1)
+		length := at(month.Year(), month.Month()+1, 0).Day()
+		days := r.ByMonthDay
+		if len(days) == 0 {
+			days = []int{start.Day()}
+		}
+
+		// Negative days count from the end of the month, days the month does
+		// not have are skipped
+		var resolved []int
+		for _, day := range days {
+			if day < 0 {
+				day += length + 1
+			}
+			if day >= 1 && day <= length && !slices.Contains(resolved, day) {
+				resolved = append(resolved, day)
+			}
+		}
+		slices.Sort(resolved)
+
+		candidates := make([]time.Time, len(resolved))
+		for i, day := range resolved {
+			candidates[i] = at(month.Year(), month.Month(), day)
+		}
+		return candidates
+
+	default:
+		// February 29th only occurs in leap years
+		t := at(start.Year()+step, start.Month(), start.Day())
+		if t.Month() != start.Month() {
+			return nil
+		}
+		return []time.Time{t}
+	}
+}
+
+// weekdayOffset returns the number of days from Monday to day, weeks start on
+// Monday as in RFC 5545
+func weekdayOffset(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
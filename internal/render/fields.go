@@ -0,0 +1,95 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// FieldNames returns the JSON names of the fields of the struct type of v,
+// including the promoted fields of embedded structs
+func FieldNames(v any) []string {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	return fieldNames(t, nil)
+}
+
+func fieldNames(t reflect.Type, names []string) []string {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		embedded := field.Type
+		if embedded.Kind() == reflect.Pointer {
+			embedded = embedded.Elem()
+		}
+		if field.Anonymous && name == "" && embedded.Kind() == reflect.Struct {
+			names = fieldNames(embedded, names)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// Project returns v with only the named members of its JSON object, or of
+// each object when v is a list. Other values are returned as they are, an
+// empty fields keeps every member
+func Project(v any, fields []string) (any, error) {
+	if len(fields) == 0 {
+		return v, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode response: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var decoded any
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	keep := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		keep[field] = true
+	}
+
+	switch decoded := decoded.(type) {
+	case map[string]any:
+		project(decoded, keep)
+	case []any:
+		for _, item := range decoded {
+			if object, ok := item.(map[string]any); ok {
+				project(object, keep)
+			}
+		}
+	}
+	return decoded, nil
+}
+
+func project(object map[string]any, keep map[string]bool) {
+	for key := range object {
+		if !keep[key] {
+			delete(object, key)
+		}
+	}
+}
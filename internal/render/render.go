@@ -0,0 +1,240 @@
+// Package render encodes response bodies in the format negotiated with the
+// client. Bodies are encoded to JSON first and converted from there, so XML
+// and MessagePack documents have the same field names and shape as the JSON
+// ones
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ugorji/go/codec"
+)
+
+// Formats responses can be rendered in
+const (
+	MIMEJSON    = "application/json"
+	MIMEXML     = "application/xml"
+	MIMEMsgPack = "application/msgpack"
+)
+
+// aliases maps the other media types clients send for a format
+var aliases = map[string]string{
+	"text/xml":              MIMEXML,
+	"application/x-msgpack": MIMEMsgPack,
+}
+
+// Negotiate returns the format of offers the Accept header prefers, ties
+// going to the type listed first. The first offer is returned when the header
+// is empty or accepts none of them
+func Negotiate(accept string, offers []string) string {
+	if len(offers) == 0 {
+		return MIMEJSON
+	}
+
+	type mediaRange struct {
+		mediaType string
+		q         float64
+	}
+	var ranges []mediaRange
+	refused := make(map[string]bool)
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+		if alias, ok := aliases[mediaType]; ok {
+			mediaType = alias
+		}
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q <= 0 {
+			refused[mediaType] = true
+			continue
+		}
+		ranges = append(ranges, mediaRange{mediaType: mediaType, q: q})
+	}
+
+	best, bestQ, bestSpecific := offers[0], 0.0, false
+	for _, r := range ranges {
+		for _, offer := range offers {
+			specific := r.mediaType == offer
+			if !specific && (refused[offer] || !wildcard(r.mediaType, offer)) {
+				continue
+			}
+			// A wildcard only wins over an explicit type with a higher weight
+			if r.q > bestQ || r.q == bestQ && specific && !bestSpecific {
+				best, bestQ, bestSpecific = offer, r.q, specific
+			}
+			break
+		}
+	}
+	return best
+}
+
+func wildcard(mediaType, offer string) bool {
+	if mediaType == "*/*" {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(mediaType, "/*")
+	return ok && strings.HasPrefix(offer, prefix+"/")
+}
+
+// Encode encodes v in format, anything but XML and MessagePack as JSON
+func Encode(format string, v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode response: %w", err)
+	}
+
+	switch format {
+	case MIMEXML:
+		return toXML(data)
+	case MIMEMsgPack:
+		return toMsgPack(data)
+	default:
+		return data, nil
+	}
+}
+
+// toMsgPack converts a JSON document to MessagePack, integers are kept as
+// integers
+func toMsgPack(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	var out []byte
+	if err := codec.NewEncoderBytes(&out, new(codec.MsgpackHandle)).Encode(numbers(value)); err != nil {
+		return nil, fmt.Errorf("failed to encode response as msgpack: %w", err)
+	}
+	return out, nil
+}
+
+// numbers replaces the json.Numbers of a decoded document by int64 or
+// float64 values
+func numbers(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		for key, item := range v {
+			v[key] = numbers(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = numbers(item)
+		}
+	}
+	return value
+}
+
+// toXML converts a JSON document to XML under a response element. Object
+// members become elements named after their key, or entry elements with a
+// key attribute when the key is not a valid name, and array elements become
+// item elements. Nulls are empty elements with nil="true"
+func toXML(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	if err := writeXML(&buf, decoder, "response", ""); err != nil {
+		return nil, fmt.Errorf("failed to encode response as xml: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func writeXML(buf *bytes.Buffer, decoder *json.Decoder, name, key string) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	buf.WriteString("<" + name)
+	if key != "" {
+		buf.WriteString(` key="`)
+		escape(buf, key)
+		buf.WriteString(`"`)
+	}
+
+	switch token := token.(type) {
+	case json.Delim:
+		buf.WriteString(">")
+		if token == '{' {
+			for decoder.More() {
+				member, err := decoder.Token()
+				if err != nil {
+					return err
+				}
+				memberKey, _ := member.(string)
+				if validName(memberKey) {
+					err = writeXML(buf, decoder, memberKey, "")
+				} else {
+					err = writeXML(buf, decoder, "entry", memberKey)
+				}
+				if err != nil {
+					return err
+				}
+			}
+		} else {
+			for decoder.More() {
+				if err := writeXML(buf, decoder, "item", ""); err != nil {
+					return err
+				}
+			}
+		}
+		// Consume the closing delimiter
+		if _, err := decoder.Token(); err != nil {
+			return err
+		}
+	case nil:
+		buf.WriteString(` nil="true"/>`)
+		return nil
+	case string:
+		buf.WriteString(">")
+		escape(buf, token)
+	default:
+		buf.WriteString(">")
+		fmt.Fprint(buf, token)
+	}
+	buf.WriteString("</" + name + ">")
+	return nil
+}
+
+func escape(buf *bytes.Buffer, s string) {
+	// Writing to a bytes.Buffer cannot fail
+	_ = xml.EscapeText(buf, []byte(s))
+}
+
+// validName reports whether key can be used as an element name as is
+func validName(key string) bool {
+	if key == "" || strings.HasPrefix(strings.ToLower(key), "xml") {
+		return false
+	}
+	for i, r := range key {
+		switch {
+		case r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z':
+		case i > 0 && (r == '-' || r == '.' || r >= '0' && r <= '9'):
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,70 @@
+// Package reporting forwards unexpected failures to an error tracking service
+package reporting
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/getsentry/sentry-go"
+)
+
+// Reporter sends recovered panics to an error tracking service
+type Reporter interface {
+	// ReportPanic records a recovered panic value with tags describing the request
+	ReportPanic(ctx context.Context, recovered any, tags map[string]string)
+
+	// Flush waits up to timeout for buffered reports to be delivered
+	Flush(timeout time.Duration) bool
+}
+
+// New creates the reporter for cfg. When Sentry is disabled a reporter that
+// drops everything is returned so callers never need a nil check
+func New(cfg *config.SentryConfig, environment, version string) (Reporter, error) {
+	if !cfg.Enabled {
+		return nopReporter{}, nil
+	}
+
+	if cfg.Environment != "" {
+		environment = cfg.Environment
+	}
+
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:              cfg.DSN,
+		Environment:      environment,
+		Release:          version,
+		SampleRate:       cfg.SampleRate,
+		AttachStacktrace: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sentry client: %w", err)
+	}
+
+	return &SentryReporter{hub: sentry.NewHub(client, sentry.NewScope())}, nil
+}
+
+// SentryReporter reports panics to Sentry
+type SentryReporter struct {
+	hub *sentry.Hub
+}
+
+// ReportPanic sends the panic on a cloned hub so tags never leak between requests
+func (r *SentryReporter) ReportPanic(ctx context.Context, recovered any, tags map[string]string) {
+	hub := r.hub.Clone()
+	hub.WithScope(func(scope *sentry.Scope) {
+		scope.SetTags(tags)
+		hub.RecoverWithContext(ctx, recovered)
+	})
+}
+
+// Flush waits for queued events to be sent
+func (r *SentryReporter) Flush(timeout time.Duration) bool {
+	return r.hub.Flush(timeout)
+}
+
+type nopReporter struct{}
+
+func (nopReporter) ReportPanic(context.Context, any, map[string]string) {}
+
+func (nopReporter) Flush(time.Duration) bool { return true }
@@ -0,0 +1,47 @@
+package retry
+
+import "sync"
+
+// Budget caps retries at a fraction of the calls made. Every call deposits
+// ratio tokens and every retry withdraws one, at most burst tokens are kept.
+// While a dependency fails every call, retries therefore settle at ratio
+// times the call rate instead of multiplying it
+type Budget struct {
+	mu     sync.Mutex
+	ratio  float64
+	burst  float64
+	tokens float64
+}
+
+// NewBudget creates a full budget. A ratio of zero or less creates a nil
+// budget, which allows every retry
+func NewBudget(ratio float64, burst int) *Budget {
+	if ratio <= 0 {
+		return nil
+	}
+	return &Budget{ratio: ratio, burst: float64(burst), tokens: float64(burst)}
+}
+
+// Deposit records a call
+func (b *Budget) Deposit() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = min(b.tokens+b.ratio, b.burst)
+}
+
+// Withdraw reports whether a retry is allowed and spends a token for it
+func (b *Budget) Withdraw() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
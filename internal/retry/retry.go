@@ -0,0 +1,150 @@
+// Package retry runs operations again when they fail with a transient error,
+// waiting an exponentially growing, jittered delay between attempts and
+// giving up when a shared retry budget runs out so retries cannot multiply
+// the load on a struggling dependency
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/metrics"
+)
+
+// Retrier retries the operations of one dependency. A nil Retrier runs every
+// operation once
+type Retrier struct {
+	cfg       config.RetryConfig
+	retryable func(error) bool
+	budget    *Budget
+
+	retries   *metrics.Counter
+	exhausted *metrics.Counter
+}
+
+// New creates the retrier of the named dependency. retryable classifies the
+// errors worth another attempt, IsTransient when nil. The retrier is nil when
+// retries are disabled
+func New(name string, cfg config.RetryConfig, retryable func(error) bool) *Retrier {
+	if cfg.MaxAttempts <= 1 {
+		return nil
+	}
+	if retryable == nil {
+		retryable = IsTransient
+	}
+
+	labels := metrics.Labels{"dependency": name}
+	return &Retrier{
+		cfg:       cfg,
+		retryable: retryable,
+		budget:    NewBudget(cfg.BudgetRatio, cfg.BudgetBurst),
+		retries: metrics.Default.Counter("retries_total",
+			"Total number of operations retried after a transient failure", labels),
+		exhausted: metrics.Default.Counter("retry_budget_exhausted_total",
+			"Total number of retries skipped because the retry budget was spent", labels),
+	}
+}
+
+// Do calls fn until it succeeds, fails with an error that is not retryable,
+// runs out of attempts or the budget, or ctx is done. The error of the last
+// attempt is returned, unwrapped from Permanent
+func (r *Retrier) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if r == nil {
+		return unwrapPermanent(fn(ctx))
+	}
+
+	r.budget.Deposit()
+	for attempt := 0; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || attempt+1 >= r.cfg.MaxAttempts || !r.shouldRetry(ctx, err) {
+			return unwrapPermanent(err)
+		}
+		if !r.budget.Withdraw() {
+			r.exhausted.Inc()
+			return err
+		}
+		r.retries.Inc()
+
+		timer := time.NewTimer(Backoff(attempt, r.cfg.BaseDelay, r.cfg.MaxDelay))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
+}
+
+func (r *Retrier) shouldRetry(ctx context.Context, err error) bool {
+	var permanent *permanentError
+	if ctx.Err() != nil || errors.As(err, &permanent) {
+		return false
+	}
+	return r.retryable(err)
+}
+
+// Backoff returns a random delay of up to base doubled per attempt (full
+// jitter), capped at max. attempt counts from 0 for the delay after the
+// first failure
+func Backoff(attempt int, base, max time.Duration) time.Duration {
+	ceiling := max
+	if attempt < 30 {
+		ceiling = min(base<<attempt, max)
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling) + 1
+}
+
+// IsTransient reports whether err is a network failure that may not happen
+// again: timeouts, refused, reset or aborted connections and connections
+// closed mid-response. Cancelled and expired contexts are not transient
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.ECONNABORTED),
+		errors.Is(err, syscall.EPIPE),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, io.EOF):
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// Permanent marks err as not worth retrying whatever its classification.
+// Do returns err itself
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+func unwrapPermanent(err error) error {
+	var permanent *permanentError
+	if errors.As(err, &permanent) {
+		return permanent.err
+	}
+	return err
+}
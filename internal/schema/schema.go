@@ -0,0 +1,167 @@
+// Package schema validates request bodies against the versioned JSON Schemas
+// embedded in the binary. Schemas live in schemas/<name>/<version>.json and
+// support the subset of JSON Schema the API's contracts use: type, enum,
+// properties, required, additionalProperties, items, minItems, maxItems,
+// minLength, maxLength, minimum, maximum, pattern and the date-time, email
+// and uri formats
+package schema
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+//go:embed schemas/*/*.json
+var files embed.FS
+
+// ErrUnknownSchema is returned for a schema the registry does not hold
+var ErrUnknownSchema = errors.New("unknown schema")
+
+// Violation is a rule of the schema the payload breaks. Path is the JSON
+// Pointer of the offending value, empty for the document itself
+type Violation struct {
+	Path    string `json:"path"`
+	Keyword string `json:"keyword"`
+	Message string `json:"message"`
+}
+
+// Registry holds the parsed schemas by name and version
+type Registry struct {
+	schemas map[string]*Schema
+	sources map[string][]byte
+}
+
+// New parses the embedded schemas
+func New() (*Registry, error) {
+	r := &Registry{schemas: make(map[string]*Schema), sources: make(map[string][]byte)}
+
+	names, err := fs.Glob(files, "schemas/*/*.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schemas: %w", err)
+	}
+	for _, name := range names {
+		data, err := files.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read schema %s: %w", name, err)
+		}
+
+		var schema Schema
+		if err := json.Unmarshal(data, &schema); err != nil {
+			return nil, fmt.Errorf("failed to parse schema %s: %w", name, err)
+		}
+		if err := schema.compile(); err != nil {
+			return nil, fmt.Errorf("failed to compile schema %s: %w", name, err)
+		}
+
+		key := Key(path.Base(path.Dir(name)), strings.TrimSuffix(path.Base(name), ".json"))
+		r.schemas[key] = &schema
+		r.sources[key] = data
+	}
+	return r, nil
+}
+
+// Key returns the registry key of a schema version, such as todo/v1
+func Key(name, version string) string {
+	return name + "/" + version
+}
+
+// Keys returns the keys of the schemas of the registry, sorted
+func (r *Registry) Keys() []string {
+	keys := make([]string, 0, len(r.schemas))
+	for key := range r.schemas {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Source returns the JSON document of a schema
+func (r *Registry) Source(key string) ([]byte, bool) {
+	data, ok := r.sources[key]
+	return data, ok
+}
+
+// Validate checks a JSON payload against a schema, a payload that is not
+// JSON is reported as a violation of the document
+func (r *Registry) Validate(key string, payload []byte) ([]Violation, error) {
+	schema, ok := r.schemas[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSchema, key)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return []Violation{{Keyword: "json", Message: "body is not valid JSON"}}, nil
+	}
+
+	var violations []Violation
+	schema.validate(value, "", &violations)
+	return violations, nil
+}
+
+// Schema is a parsed JSON Schema, unsupported keywords are ignored
+type Schema struct {
+	Type                 types              `json:"type"`
+	Enum                 []any              `json:"enum"`
+	Properties           map[string]*Schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+	Items                *Schema            `json:"items"`
+	MinItems             *int               `json:"minItems"`
+	MaxItems             *int               `json:"maxItems"`
+	MinLength            *int               `json:"minLength"`
+	MaxLength            *int               `json:"maxLength"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	Pattern              string             `json:"pattern"`
+	Format               string             `json:"format"`
+
+	pattern *regexp.Regexp
+}
+
+// types is the type keyword, a single type or a list of them
+type types []string
+
+func (t *types) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = types{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("type must be a string or a list of strings")
+	}
+	*t = list
+	return nil
+}
+
+// compile prepares the patterns of the schema and its subschemas
+func (s *Schema) compile() error {
+	if s.Pattern != "" {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", s.Pattern, err)
+		}
+		s.pattern = pattern
+	}
+	for name, property := range s.Properties {
+		if err := property.compile(); err != nil {
+			return fmt.Errorf("property %s: %w", name, err)
+		}
+	}
+	if s.Items != nil {
+		return s.Items.compile()
+	}
+	return nil
+}
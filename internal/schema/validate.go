@@ -0,0 +1,170 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// validate appends the violations of value, found at pointer, to violations
+func (s *Schema) validate(value any, pointer string, violations *[]Violation) {
+	add := func(keyword, format string, args ...any) {
+		*violations = append(*violations, Violation{Path: pointer, Keyword: keyword, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(s.Type) > 0 && !slices.ContainsFunc(s.Type, func(t string) bool { return hasType(value, t) }) {
+		add("type", "must be of type %s", strings.Join(s.Type, " or "))
+		return
+	}
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(allowed any) bool { return equal(value, allowed) }) {
+		add("enum", "must be one of %s", enumList(s.Enum))
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		s.validateObject(value, pointer, violations)
+	case []any:
+		if s.MinItems != nil && len(value) < *s.MinItems {
+			add("minItems", "must have at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(value) > *s.MaxItems {
+			add("maxItems", "must have at most %d items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range value {
+				s.Items.validate(item, pointer+"/"+strconv.Itoa(i), violations)
+			}
+		}
+	case string:
+		length := utf8.RuneCountInString(value)
+		if s.MinLength != nil && length < *s.MinLength {
+			add("minLength", "must be at least %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			add("maxLength", "must be at most %d characters", *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(value) {
+			add("pattern", "must match %s", s.Pattern)
+		}
+		if s.Format != "" && !validFormat(s.Format, value) {
+			add("format", "must be a valid %s", s.Format)
+		}
+	case json.Number:
+		number, _ := value.Float64()
+		if s.Minimum != nil && number < *s.Minimum {
+			add("minimum", "must be at least %v", *s.Minimum)
+		}
+		if s.Maximum != nil && number > *s.Maximum {
+			add("maximum", "must be at most %v", *s.Maximum)
+		}
+	}
+}
+
+func (s *Schema) validateObject(object map[string]any, pointer string, violations *[]Violation) {
+	for _, name := range s.Required {
+		if _, ok := object[name]; !ok {
+			*violations = append(*violations, Violation{
+				Path:    pointer + "/" + escape(name),
+				Keyword: "required",
+				Message: "is required",
+			})
+		}
+	}
+
+	// Sorted so violations are reported in a stable order
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		property, ok := s.Properties[name]
+		if !ok {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				*violations = append(*violations, Violation{
+					Path:    pointer + "/" + escape(name),
+					Keyword: "additionalProperties",
+					Message: "is not allowed",
+				})
+			}
+			continue
+		}
+		property.validate(object[name], pointer+"/"+escape(name), violations)
+	}
+}
+
+// hasType reports whether a decoded JSON value is of a JSON Schema type
+func hasType(value any, t string) bool {
+	switch value := value.(type) {
+	case nil:
+		return t == "null"
+	case bool:
+		return t == "boolean"
+	case string:
+		return t == "string"
+	case json.Number:
+		if t == "number" {
+			return true
+		}
+		_, err := value.Int64()
+		return t == "integer" && err == nil
+	case []any:
+		return t == "array"
+	case map[string]any:
+		return t == "object"
+	}
+	return false
+}
+
+// equal compares a decoded value with an enum value of the schema, whose
+// numbers are float64
+func equal(value, allowed any) bool {
+	if number, ok := value.(json.Number); ok {
+		f, err := number.Float64()
+		return err == nil && f == allowed
+	}
+	switch value.(type) {
+	case map[string]any, []any:
+		return false
+	}
+	return value == allowed
+}
+
+func enumList(values []any) string {
+	items := make([]string, 0, len(values))
+	for _, value := range values {
+		encoded, _ := json.Marshal(value)
+		items = append(items, string(encoded))
+	}
+	return strings.Join(items, ", ")
+}
+
+func validFormat(format, value string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, value)
+		return err == nil
+	case "email":
+		_, err := mail.ParseAddress(value)
+		return err == nil
+	case "uri":
+		parsed, err := url.Parse(value)
+		return err == nil && parsed.Scheme != ""
+	default:
+		// Unknown formats are annotations only
+		return true
+	}
+}
+
+// escape encodes a property name as a JSON Pointer reference token
+func escape(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
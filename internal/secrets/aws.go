@@ -0,0 +1,61 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+// AWSProvider reads secrets from AWS Secrets Manager. Names without a key
+// selector return the whole secret string, with one the secret is decoded as
+// a JSON object and the key's value is returned
+type AWSProvider struct {
+	client *secretsmanager.Client
+}
+
+// NewAWSProvider creates a provider using the default AWS credential chain
+func NewAWSProvider(ctx context.Context, region string) (*AWSProvider, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if region != "" {
+		opts = append(opts, awsconfig.WithRegion(region))
+	}
+
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load aws configuration: %w", err)
+	}
+
+	return &AWSProvider{client: secretsmanager.NewFromConfig(cfg)}, nil
+}
+
+func (p *AWSProvider) GetSecret(ctx context.Context, name string) (string, error) {
+	id, key := splitName(name)
+
+	out, err := p.client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(id),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to read aws secret %s: %w", id, err)
+	}
+	if out.SecretString == nil {
+		return "", fmt.Errorf("%w: %s has no string value", ErrNotFound, id)
+	}
+	if key == "" {
+		return *out.SecretString, nil
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(*out.SecretString), &fields); err != nil {
+		return "", fmt.Errorf("failed to decode aws secret %s: %w", id, err)
+	}
+
+	value, ok := fields[key].(string)
+	if !ok {
+		return "", fmt.Errorf("%w: %s#%s", ErrNotFound, id, key)
+	}
+	return value, nil
+}
@@ -0,0 +1,22 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// ErrNotFound is returned when a secret or a key within it does not exist
+var ErrNotFound = errors.New("secret not found")
+
+// Provider resolves a secret name to its value. Names may select a key within
+// a structured secret with a "#key" suffix, e.g. "todo-api/database#password"
+type Provider interface {
+	GetSecret(ctx context.Context, name string) (string, error)
+}
+
+// splitName separates the secret path from the optional key selector
+func splitName(name string) (path, key string) {
+	path, key, _ = strings.Cut(name, "#")
+	return path, key
+}
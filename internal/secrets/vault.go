@@ -0,0 +1,50 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// VaultProvider reads secrets from a HashiCorp Vault KV v2 engine. Names
+// without a key selector read the "value" key
+type VaultProvider struct {
+	client *vault.Client
+	mount  string
+}
+
+// NewVaultProvider creates a provider for the KV v2 engine mounted at mount
+func NewVaultProvider(address, token, mount string) (*VaultProvider, error) {
+	cfg := vault.DefaultConfig()
+	cfg.Address = address
+
+	client, err := vault.NewClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create vault client: %w", err)
+	}
+	client.SetToken(token)
+
+	return &VaultProvider{client: client, mount: mount}, nil
+}
+
+func (p *VaultProvider) GetSecret(ctx context.Context, name string) (string, error) {
+	path, key := splitName(name)
+	if key == "" {
+		key = "value"
+	}
+
+	secret, err := p.client.KVv2(p.mount).Get(ctx, path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read vault secret %s: %w", path, err)
+	}
+	if secret == nil {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, path)
+	}
+
+	value, ok := secret.Data[key].(string)
+	if !ok {
+		return "", fmt.Errorf("%w: %s#%s", ErrNotFound, path, key)
+	}
+	return value, nil
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/session"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+// erasureBatchSize is how many due accounts EraseDue reads per query
+const erasureBatchSize = 100
+
+// AccountService deletes user accounts. Deleting an account only schedules
+// its erasure, the account and everything stored about it are erased by
+// EraseDue once the grace period has passed unless the user cancels first
+type AccountService struct {
+	store    storage.Store
+	sessions session.Store // nil unless cookie sessions are enabled
+	cache    *TodoCache
+	audit    *AuditLogger
+	cfg      config.AccountsConfig
+}
+
+func NewAccountService(store storage.Store, sessions session.Store, cache *TodoCache, audit *AuditLogger, cfg config.AccountsConfig) *AccountService {
+	return &AccountService{
+		store:    store,
+		sessions: sessions,
+		cache:    cache,
+		audit:    audit,
+		cfg:      cfg,
+	}
+}
+
+// ScheduleDeletion schedules the erasure of the user's account after the
+// grace period and returns when it will happen. Deleting an account whose
+// deletion is already scheduled keeps the earlier date
+func (s *AccountService) ScheduleDeletion(ctx context.Context, userID int64) (time.Time, error) {
+	profile, err := s.store.Auth().GetProfile(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if profile.DeletionScheduledAt != nil {
+		return *profile.DeletionScheduledAt, nil
+	}
+
+	at := time.Now().Add(s.cfg.DeletionGracePeriod).UTC().Truncate(time.Second)
+	if err := s.store.Auth().ScheduleDeletion(ctx, userID, at); err != nil {
+		return time.Time{}, err
+	}
+
+	s.audit.Record(ctx, AuditEntry{
+		UserID:     &userID,
+		Action:     "user.schedule_deletion",
+		EntityType: EntityUser,
+		EntityID:   userID,
+		After:      map[string]any{"deletion_scheduled_at": at},
+	})
+	return at, nil
+}
+
+// CancelDeletion keeps the user's account, returning storage.ErrNotFound
+// when no deletion is scheduled
+func (s *AccountService) CancelDeletion(ctx context.Context, userID int64) error {
+	if err := s.store.Auth().CancelDeletion(ctx, userID); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, AuditEntry{
+		UserID:     &userID,
+		Action:     "user.cancel_deletion",
+		EntityType: EntityUser,
+		EntityID:   userID,
+	})
+	return nil
+}
+
+// EraseDue erases the accounts whose grace period has passed and returns
+// how many were erased. The audit events and cookie sessions of the users
+// are erased with them, the erasure itself is recorded without an actor
+func (s *AccountService) EraseDue(ctx context.Context) (int64, error) {
+	now := time.Now()
+	var erased int64
+	for {
+		ids, err := s.store.Auth().DueDeletions(ctx, now, erasureBatchSize)
+		if err != nil {
+			return erased, err
+		}
+
+		for _, userID := range ids {
+			err := s.store.EraseUser(ctx, userID, now)
+			if errors.Is(err, storage.ErrNotFound) {
+				// Cancelled since it was listed
+				continue
+			}
+			if err != nil {
+				return erased, err
+			}
+			erased++
+
+			s.cache.Invalidate(ctx, userID)
+			if err := session.EndUserSessions(ctx, s.sessions, userID); err != nil {
+				return erased, fmt.Errorf("failed to end cookie sessions of user %d: %w", userID, err)
+			}
+			s.audit.Record(ctx, AuditEntry{
+				Action:     "user.erase",
+				EntityType: EntityUser,
+				EntityID:   userID,
+			})
+		}
+
+		if len(ids) < erasureBatchSize {
+			return erased, nil
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/export"
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/queue"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+// TaskExportAccount is the queue task type building an account archive
+const TaskExportAccount = "account:export"
+
+// archiveBatchSize is how many trashed todos or events the archive reads
+// per query
+const archiveBatchSize = 500
+
+// ErrExportUnavailable is returned when archives cannot be built because
+// there is no task queue
+var ErrExportUnavailable = errors.New("data exports are not available")
+
+// ArchiveService builds the archive of everything stored about a user, so
+// users can obtain a copy of their data. Archives are built by a queue
+// worker and delivered like asynchronous todo exports
+type ArchiveService struct {
+	store   storage.Store
+	todos   *TodoService
+	exports *ExportService
+}
+
+func NewArchiveService(store storage.Store, todos *TodoService, exports *ExportService) *ArchiveService {
+	return &ArchiveService{
+		store:   store,
+		todos:   todos,
+		exports: exports,
+	}
+}
+
+// archiveTask is the payload of a TaskExportAccount task
+type archiveTask struct {
+	UserID int64 `json:"user_id"`
+}
+
+// Schedule enqueues an archive of the user's data, the download link is
+// emailed to the user once it is ready
+func (s *ArchiveService) Schedule(ctx context.Context, userID int64) error {
+	if s.exports.queue == nil || s.exports.files == nil {
+		return ErrExportUnavailable
+	}
+
+	if _, err := s.exports.queue.Enqueue(ctx, TaskExportAccount, archiveTask{UserID: userID}); err != nil {
+		return fmt.Errorf("failed to queue account export: %w", err)
+	}
+	return nil
+}
+
+// HandleTask builds a scheduled archive, stores it and emails its download
+// link to the user
+func (s *ArchiveService) HandleTask(ctx context.Context, task *queue.Task) error {
+	var payload archiveTask
+	if err := json.Unmarshal(task.Payload, &payload); err != nil {
+		return fmt.Errorf("%w: invalid account export task: %v", queue.ErrSkipRetry, err)
+	}
+
+	user, err := s.store.Auth().GetUserByID(ctx, payload.UserID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("%w: user %d no longer exists", queue.ErrSkipRetry, payload.UserID)
+	}
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	limited := &limitedWriter{w: &buf, remaining: s.exports.cfg.MaxFileSize}
+	err = s.write(ctx, user, limited)
+	if errors.Is(err, ErrExportTooLarge) {
+		return fmt.Errorf("%w: %w", queue.ErrSkipRetry, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.exports.deliver(ctx, user, export.FormatArchive, buf.Bytes(),
+		"Your account data is ready", "The copy of all data stored about your account you requested is ready.")
+}
+
+// write encodes the archive of the user as a single JSON object. Todos and
+// events are written as they are read rather than collected first
+func (s *ArchiveService) write(ctx context.Context, user *models.User, w io.Writer) error {
+	userID := user.ID
+	profile, err := s.store.Auth().GetProfile(ctx, userID)
+	if err != nil {
+		return err
+	}
+	tags, err := s.store.Todos().ListTags(ctx, userID)
+	if err != nil {
+		return err
+	}
+	attachments, err := s.store.Todos().ListUserAttachments(ctx, userID)
+	if err != nil {
+		return err
+	}
+	webhooks, err := s.store.Webhooks().ListWebhooks(ctx, userID)
+	if err != nil {
+		return err
+	}
+	channels, err := s.store.Auth().ListNotificationChannels(ctx, userID)
+	if err != nil {
+		return err
+	}
+	shares, err := s.store.Todos().ListUserShares(ctx, userID)
+	if err != nil {
+		return err
+	}
+	quota, err := s.store.Quotas().GetQuotaOverride(ctx, userID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return err
+	}
+
+	enc := &archiveEncoder{w: w}
+	fields := []struct {
+		name  string
+		value any
+	}{
+		{"exported_at", time.Now().UTC()},
+		{"account", user},
+		{"profile", profile},
+		{"tags", tags},
+		{"attachments", attachments},
+		{"webhooks", webhooks},
+		{"notification_channels", channels},
+		{"shared_with_you", shares},
+		{"quota_override", quota},
+	}
+	for _, field := range fields {
+		if err := enc.field(field.name, field.value); err != nil {
+			return err
+		}
+	}
+
+	err = enc.list("todos", func(add func(any) error) error {
+		return s.todos.Export(ctx, userID, TodoQuery{}, func(todo *models.Todo) error { return add(todo) })
+	})
+	if err != nil {
+		return err
+	}
+	err = enc.list("trash", func(add func(any) error) error {
+		return paginate(func(offset int) (int, error) {
+			todos, _, err := s.store.Todos().ListDeleted(ctx, userID, archiveBatchSize, offset)
+			if err != nil {
+				return 0, err
+			}
+			return len(todos), addAll(add, todos)
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	filter := storage.AuditFilter{UserID: &userID}
+	err = enc.list("audit_events", func(add func(any) error) error {
+		return paginate(func(offset int) (int, error) {
+			events, _, err := s.store.Audit().ListAuditEvents(ctx, filter, archiveBatchSize, offset)
+			if err != nil {
+				return 0, err
+			}
+			return len(events), addAll(add, events)
+		})
+	})
+	if err != nil {
+		return err
+	}
+	err = enc.list("security_events", func(add func(any) error) error {
+		return paginate(func(offset int) (int, error) {
+			events, _, err := s.store.SecurityEvents().ListSecurityEvents(ctx, storage.SecurityEventFilter{UserID: &userID}, archiveBatchSize, offset)
+			if err != nil {
+				return 0, err
+			}
+			return len(events), addAll(add, events)
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	return enc.close()
+}
+
+// paginate calls page with increasing offsets until it returns fewer than
+// archiveBatchSize items
+func paginate(page func(offset int) (int, error)) error {
+	for offset := 0; ; offset += archiveBatchSize {
+		n, err := page(offset)
+		if err != nil {
+			return err
+		}
+		if n < archiveBatchSize {
+			return nil
+		}
+	}
+}
+
+func addAll[T any](add func(any) error, items []T) error {
+	for _, item := range items {
+		if err := add(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// archiveEncoder writes a JSON object one field at a time. Lists are written
+// element by element, so they need not be held in memory
+type archiveEncoder struct {
+	w      io.Writer
+	fields int
+}
+
+func (e *archiveEncoder) field(name string, value any) error {
+	if err := e.key(name); err != nil {
+		return err
+	}
+	return e.value(value)
+}
+
+// list writes a field holding the array of the values fn adds
+func (e *archiveEncoder) list(name string, fn func(add func(any) error) error) error {
+	if err := e.key(name); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(e.w, "["); err != nil {
+		return err
+	}
+
+	var count int
+	err := fn(func(value any) error {
+		if count > 0 {
+			if _, err := io.WriteString(e.w, ","); err != nil {
+				return err
+			}
+		}
+		count++
+		return e.value(value)
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(e.w, "]")
+	return err
+}
+
+func (e *archiveEncoder) key(name string) error {
+	sep := ",\n"
+	if e.fields == 0 {
+		sep = "{\n"
+	}
+	e.fields++
+	_, err := io.WriteString(e.w, sep+strconv.Quote(name)+": ")
+	return err
+}
+
+func (e *archiveEncoder) value(value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode account export: %w", err)
+	}
+	_, err = e.w.Write(data)
+	return err
+}
+
+func (e *archiveEncoder) close() error {
+	end := "\n}\n"
+	if e.fields == 0 {
+		end = "{}\n"
+	}
+	_, err := io.WriteString(e.w, end)
+	return err
+}
@@ -0,0 +1,475 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/auth"
+	"github.com/MuthuM3/gin-microservice-template/internal/blob"
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+const (
+	maxFilenameLength = 255
+
+	// sniffLength is how much of an upload is inspected to detect its type
+	sniffLength = 512
+
+	// orphanBatchSize is how many attachments of purged todos are removed
+	// per query
+	orphanBatchSize = 100
+
+	// pendingGrace is how long a pending upload is kept after its URL
+	// expired, giving clients time to confirm an upload that just finished
+	pendingGrace = time.Hour
+)
+
+var (
+	// ErrUnsupportedFileType is returned for uploads whose detected type is
+	// not allowed by the security configuration
+	ErrUnsupportedFileType = errors.New("file type is not allowed")
+
+	// ErrPresignUnsupported is returned for direct uploads and downloads when
+	// the blob storage cannot presign requests
+	ErrPresignUnsupported = errors.New("blob storage does not support direct transfers")
+
+	// ErrUploadIncomplete is returned when a direct upload is confirmed
+	// before its content has been stored
+	ErrUploadIncomplete = errors.New("upload has not been completed")
+)
+
+// UploadTooLargeError is returned for uploads over the maximum size
+type UploadTooLargeError struct {
+	Limit int64
+}
+
+func (e *UploadTooLargeError) Error() string {
+	return fmt.Sprintf("file is larger than %d bytes", e.Limit)
+}
+
+// AttachmentService manages the files attached to todos. Metadata is kept
+// with the todos and contents in blob storage
+type AttachmentService struct {
+	store      storage.TodoRepository
+	blobs      blob.Storage
+	presigner  blob.Presigner
+	presignTTL time.Duration
+	security   *config.SecurityConfig
+	quotas     *QuotaService
+	audit      *AuditLogger
+	log        logger.Logger
+}
+
+// NewAttachmentService creates the service, direct transfers are available
+// when blobs implements blob.Presigner and use URLs valid for presignTTL.
+// The attachment storage quota is enforced unless quotas is nil
+func NewAttachmentService(
+	store storage.TodoRepository,
+	blobs blob.Storage,
+	presignTTL time.Duration,
+	security *config.SecurityConfig,
+	quotas *QuotaService,
+	audit *AuditLogger,
+	log logger.Logger,
+) *AttachmentService {
+	presigner, _ := blobs.(blob.Presigner)
+	return &AttachmentService{
+		store:      store,
+		blobs:      blobs,
+		presigner:  presigner,
+		presignTTL: presignTTL,
+		security:   security,
+		quotas:     quotas,
+		audit:      audit,
+		log:        log,
+	}
+}
+
+// CanPresign reports whether files can be transferred directly to and from
+// the blob storage
+func (s *AttachmentService) CanPresign() bool {
+	return s.presigner != nil
+}
+
+// Upload attaches the file read from r to one of the user's todos. The file
+// is spooled to disk to enforce the size limit and detect its type before
+// anything is stored; the type declared by the client is not trusted
+func (s *AttachmentService) Upload(ctx context.Context, userID, todoID int64, filename string, r io.Reader) (*models.Attachment, error) {
+	filename, err := cleanFilename(filename)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.store.GetByID(ctx, userID, todoID); err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp("", "attachment-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to buffer upload: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	size, err := io.Copy(tmp, io.LimitReader(r, s.security.MaxUploadSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	if size > s.security.MaxUploadSize {
+		return nil, &UploadTooLargeError{Limit: s.security.MaxUploadSize}
+	}
+	if size == 0 {
+		return nil, invalidField("file", "file is empty")
+	}
+	if err := s.quotas.CheckAttachmentBytes(ctx, userID, size); err != nil {
+		return nil, err
+	}
+
+	head := make([]byte, sniffLength)
+	n, err := tmp.ReadAt(head, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	contentType := http.DetectContentType(head[:n])
+	if !s.allowedType(contentType) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFileType, contentType)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+
+	key, err := attachmentKey(userID, todoID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.blobs.Put(ctx, key, tmp, size, contentType); err != nil {
+		return nil, err
+	}
+
+	attachment := &models.Attachment{
+		TodoID:      todoID,
+		UserID:      userID,
+		Filename:    filename,
+		ContentType: contentType,
+		Size:        size,
+		StorageKey:  key,
+	}
+	if err := s.store.CreateAttachment(ctx, attachment); err != nil {
+		// The todo was deleted while the file was stored
+		s.deleteBlob(ctx, key)
+		return nil, err
+	}
+
+	s.record(ctx, "attachment.create", nil, attachment)
+	return attachment, nil
+}
+
+// PresignUpload creates a pending attachment and returns a request the client
+// sends to upload the file straight to blob storage. The declared size and
+// type are signed into the request; the attachment stays hidden until the
+// upload is confirmed
+func (s *AttachmentService) PresignUpload(ctx context.Context, userID, todoID int64, filename, contentType string, size int64) (*models.Attachment, *blob.PresignedRequest, error) {
+	if s.presigner == nil {
+		return nil, nil, ErrPresignUnsupported
+	}
+
+	filename, err := cleanFilename(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	if size > s.security.MaxUploadSize {
+		return nil, nil, &UploadTooLargeError{Limit: s.security.MaxUploadSize}
+	}
+	if size <= 0 {
+		return nil, nil, invalidField("size", "size must be positive")
+	}
+	if !s.allowedType(contentType) {
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnsupportedFileType, contentType)
+	}
+	// Pending uploads count with their declared size
+	if err := s.quotas.CheckAttachmentBytes(ctx, userID, size); err != nil {
+		return nil, nil, err
+	}
+
+	key, err := attachmentKey(userID, todoID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	attachment := &models.Attachment{
+		TodoID:      todoID,
+		UserID:      userID,
+		Filename:    filename,
+		ContentType: contentType,
+		Size:        size,
+		Status:      models.AttachmentPending,
+		StorageKey:  key,
+	}
+	if err := s.store.CreateAttachment(ctx, attachment); err != nil {
+		return nil, nil, err
+	}
+
+	upload, err := s.presigner.PresignPut(ctx, key, size, contentType, s.presignTTL)
+	if err != nil {
+		// Nothing was uploaded, the purge job would only find an empty key
+		if err := s.store.DeleteAttachment(context.WithoutCancel(ctx), userID, attachment.ID); err != nil {
+			s.log.Warn("failed to delete pending attachment", "error", err, "attachment_id", attachment.ID)
+		}
+		return nil, nil, err
+	}
+	return attachment, upload, nil
+}
+
+// Confirm finalizes a direct upload once the client has sent the file. The
+// stored content is checked against the size limit and its type detected
+// again; content that fails is deleted with its attachment. Confirming a
+// ready attachment returns it unchanged
+func (s *AttachmentService) Confirm(ctx context.Context, userID, todoID, id int64) (*models.Attachment, error) {
+	if s.presigner == nil {
+		return nil, ErrPresignUnsupported
+	}
+
+	attachment, err := s.store.GetAttachment(ctx, userID, todoID, id)
+	if err != nil {
+		return nil, err
+	}
+	if attachment.Status == models.AttachmentReady {
+		return attachment, nil
+	}
+
+	size, err := s.presigner.Stat(ctx, attachment.StorageKey)
+	if errors.Is(err, blob.ErrNotFound) {
+		return nil, ErrUploadIncomplete
+	}
+	if err != nil {
+		return nil, err
+	}
+	if size > s.security.MaxUploadSize {
+		return nil, s.reject(ctx, attachment, &UploadTooLargeError{Limit: s.security.MaxUploadSize})
+	}
+
+	contentType, err := s.detectType(ctx, attachment.StorageKey)
+	if err != nil {
+		return nil, err
+	}
+	if !s.allowedType(contentType) {
+		return nil, s.reject(ctx, attachment, fmt.Errorf("%w: %s", ErrUnsupportedFileType, contentType))
+	}
+
+	if err := s.store.ConfirmAttachment(ctx, userID, id, size, contentType); err != nil {
+		return nil, err
+	}
+	attachment.Status = models.AttachmentReady
+	attachment.Size = size
+	attachment.ContentType = contentType
+
+	s.record(ctx, "attachment.create", nil, attachment)
+	return attachment, nil
+}
+
+// PresignDownload returns a request the client sends to download an
+// attachment straight from blob storage
+func (s *AttachmentService) PresignDownload(ctx context.Context, userID, todoID, id int64) (*blob.PresignedRequest, error) {
+	if s.presigner == nil {
+		return nil, ErrPresignUnsupported
+	}
+
+	attachment, err := s.getReady(ctx, userID, todoID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.presigner.PresignGet(ctx, attachment.StorageKey, attachment.Filename, attachment.ContentType, s.presignTTL)
+}
+
+// List returns the attachments of one of the user's todos, oldest first
+func (s *AttachmentService) List(ctx context.Context, userID, todoID int64) ([]*models.Attachment, error) {
+	return s.store.ListAttachments(ctx, userID, todoID)
+}
+
+// Open returns an attachment of one of the user's todos with its content,
+// the caller closes the reader
+func (s *AttachmentService) Open(ctx context.Context, userID, todoID, id int64) (*models.Attachment, io.ReadCloser, error) {
+	attachment, err := s.getReady(ctx, userID, todoID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, err := s.blobs.Get(ctx, attachment.StorageKey)
+	if errors.Is(err, blob.ErrNotFound) {
+		return nil, nil, fmt.Errorf("content of attachment %d is missing: %w", id, err)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return attachment, content, nil
+}
+
+// Delete removes an attachment of one of the user's todos, pending uploads
+// included
+func (s *AttachmentService) Delete(ctx context.Context, userID, todoID, id int64) error {
+	attachment, err := s.store.GetAttachment(ctx, userID, todoID, id)
+	if err != nil {
+		return err
+	}
+	if err := s.store.DeleteAttachment(ctx, userID, id); err != nil {
+		return err
+	}
+
+	// The metadata goes first, a failed blob deletion only wastes space
+	s.deleteBlob(ctx, attachment.StorageKey)
+	if attachment.Status == models.AttachmentReady {
+		s.record(ctx, "attachment.delete", attachment, nil)
+	}
+	return nil
+}
+
+// PurgeOrphaned removes the attachments of purged todos and direct uploads
+// that were never confirmed, returning how many were removed. Run it after
+// the trash has been purged
+func (s *AttachmentService) PurgeOrphaned(ctx context.Context) (int64, error) {
+	pendingBefore := time.Now().Add(-s.presignTTL - pendingGrace)
+
+	var purged int64
+	for {
+		attachments, err := s.store.ListOrphanedAttachments(ctx, pendingBefore, orphanBatchSize)
+		if err != nil {
+			return purged, err
+		}
+
+		for _, attachment := range attachments {
+			// Keep the row while the blob remains so the next run retries it
+			if err := s.blobs.Delete(ctx, attachment.StorageKey); err != nil {
+				return purged, err
+			}
+			if err := s.store.DeleteAttachment(ctx, attachment.UserID, attachment.ID); err != nil && !errors.Is(err, storage.ErrNotFound) {
+				return purged, err
+			}
+			purged++
+		}
+
+		if len(attachments) < orphanBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+// allowedType reports whether the detected media type matches one of the
+// allowed types or wildcards
+func (s *AttachmentService) allowedType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, allowed := range s.security.AllowedUploadTypes {
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return true
+			}
+		} else if mediaType == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// getReady returns an attachment whose content has been stored, pending
+// uploads are not found
+func (s *AttachmentService) getReady(ctx context.Context, userID, todoID, id int64) (*models.Attachment, error) {
+	attachment, err := s.store.GetAttachment(ctx, userID, todoID, id)
+	if err != nil {
+		return nil, err
+	}
+	if attachment.Status != models.AttachmentReady {
+		return nil, storage.ErrNotFound
+	}
+	return attachment, nil
+}
+
+// detectType detects the type of a stored object from its first bytes
+func (s *AttachmentService) detectType(ctx context.Context, key string) (string, error) {
+	content, err := s.blobs.Get(ctx, key)
+	if errors.Is(err, blob.ErrNotFound) {
+		return "", ErrUploadIncomplete
+	}
+	if err != nil {
+		return "", err
+	}
+	defer content.Close()
+
+	head, err := io.ReadAll(io.LimitReader(content, sniffLength))
+	if err != nil {
+		return "", fmt.Errorf("failed to read upload: %w", err)
+	}
+	return http.DetectContentType(head), nil
+}
+
+// reject removes a direct upload that failed its checks and returns err
+func (s *AttachmentService) reject(ctx context.Context, attachment *models.Attachment, err error) error {
+	if err := s.store.DeleteAttachment(context.WithoutCancel(ctx), attachment.UserID, attachment.ID); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		s.log.Warn("failed to delete rejected attachment", "error", err, "attachment_id", attachment.ID)
+	}
+	s.deleteBlob(ctx, attachment.StorageKey)
+	return err
+}
+
+func (s *AttachmentService) deleteBlob(ctx context.Context, key string) {
+	if err := s.blobs.Delete(context.WithoutCancel(ctx), key); err != nil {
+		s.log.Warn("failed to delete attachment content", "error", err, "key", key)
+	}
+}
+
+// record audits a change to an attachment made by its owner, one of before
+// and after may be nil
+func (s *AttachmentService) record(ctx context.Context, action string, before, after *models.Attachment) {
+	subject := after
+	if subject == nil {
+		subject = before
+	}
+
+	s.audit.Record(ctx, AuditEntry{
+		UserID:     &subject.UserID,
+		Action:     action,
+		EntityType: EntityAttachment,
+		EntityID:   subject.ID,
+		Before:     before,
+		After:      after,
+	})
+}
+
+// attachmentKey returns a new blob key for an attachment of the todo. Keys
+// are random so filenames chosen by users never reach the storage
+func attachmentKey(userID, todoID int64) (string, error) {
+	id, err := auth.RandomToken(16)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("attachments/%d/%d/%s", userID, todoID, id), nil
+}
+
+// cleanFilename keeps the base name of a client supplied filename
+func cleanFilename(filename string) (string, error) {
+	filename = strings.ReplaceAll(filename, `\`, "/")
+	filename = strings.TrimSpace(path.Base(filename))
+	if filename == "" || filename == "." || filename == "/" {
+		return "", invalidField("file", "filename is required")
+	}
+	if !utf8.ValidString(filename) || strings.ContainsFunc(filename, func(r rune) bool { return r < 0x20 || r == 0x7f }) {
+		return "", invalidField("file", "filename contains invalid characters")
+	}
+	if len(filename) > maxFilenameLength {
+		return "", invalidField("file", "filename must be at most %d bytes", maxFilenameLength)
+	}
+	return filename, nil
+}
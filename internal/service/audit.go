@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strconv"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/clientinfo"
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/requestid"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+// Audited entity types
+const (
+	EntityTodo       = "todo"
+	EntityTag        = "tag"
+	EntityUser       = "user"
+	EntityAttachment = "attachment"
+	EntityWebhook    = "webhook"
+	EntityQuota      = "quota"
+	EntitySession    = "session"
+	EntityChannel    = "notification_channel"
+)
+
+// ignoredAuditFields change on every write and would only add noise to diffs
+var ignoredAuditFields = map[string]bool{"updated_at": true}
+
+// AuditLogger records data mutations made through the services. A nil
+// *AuditLogger is valid and records nothing, so auditing can be disabled
+type AuditLogger struct {
+	store      storage.AuditRepository
+	pagination config.PaginationConfig
+	log        logger.Logger
+}
+
+func NewAuditLogger(store storage.AuditRepository, pagination config.PaginationConfig, log logger.Logger) *AuditLogger {
+	return &AuditLogger{store: store, pagination: pagination, log: log}
+}
+
+// AuditEntry describes a mutation to record. Before and After are snapshots
+// of the entity, nil for creations and deletions respectively; only the
+// fields that differ between them are stored
+type AuditEntry struct {
+	UserID     *int64
+	Action     string
+	EntityType string
+	EntityID   int64
+	Before     any
+	After      any
+}
+
+// Record stores an audit event for a mutation that has already been
+// committed. Failures are logged rather than returned so a broken audit
+// trail never undoes or fails the user's change
+func (l *AuditLogger) Record(ctx context.Context, entry AuditEntry) {
+	if l == nil {
+		return
+	}
+
+	event := &models.AuditEvent{
+		UserID:     entry.UserID,
+		Action:     entry.Action,
+		EntityType: entry.EntityType,
+		EntityID:   strconv.FormatInt(entry.EntityID, 10),
+		Changes:    auditDiff(entry.Before, entry.After),
+		IPAddress:  clientinfo.FromContext(ctx).IP,
+		RequestID:  requestid.FromContext(ctx),
+	}
+
+	// Record after the request may have been cancelled by the client
+	if err := l.store.CreateAuditEvent(context.WithoutCancel(ctx), event); err != nil {
+		l.log.Error("failed to record audit event",
+			"error", err,
+			"action", entry.Action,
+			"entity_type", entry.EntityType,
+			"entity_id", entry.EntityID,
+		)
+	}
+}
+
+// AuditPage is a single page of audit events
+type AuditPage struct {
+	Events   []*models.AuditEvent
+	Page     int
+	PageSize int
+	Total    int
+}
+
+// TotalPages returns the number of pages available with the current page size
+func (p *AuditPage) TotalPages() int {
+	return totalPages(p.Total, p.PageSize)
+}
+
+// List returns the requested page of audit events matching the filter,
+// newest first
+func (l *AuditLogger) List(ctx context.Context, filter storage.AuditFilter, page, pageSize int) (*AuditPage, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = l.pagination.DefaultLimit
+	}
+	pageSize = min(pageSize, l.pagination.MaxLimit)
+
+	events, total, err := l.store.ListAuditEvents(ctx, filter, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuditPage{
+		Events:   events,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	}, nil
+}
+
+// auditDiff compares the JSON representations of two snapshots field by
+// field, fields hidden from JSON (such as password hashes) never appear
+func auditDiff(before, after any) map[string]models.AuditChange {
+	b, a := auditFields(before), auditFields(after)
+
+	changes := make(map[string]models.AuditChange)
+	for field, value := range a {
+		if old, ok := b[field]; !ok || !reflect.DeepEqual(old, value) {
+			changes[field] = models.AuditChange{Before: old, After: value}
+		}
+	}
+	for field, old := range b {
+		if _, ok := a[field]; !ok {
+			changes[field] = models.AuditChange{Before: old}
+		}
+	}
+
+	for field := range ignoredAuditFields {
+		delete(changes, field)
+	}
+	return changes
+}
+
+func auditFields(snapshot any) map[string]any {
+	fields := make(map[string]any)
+	if v := reflect.ValueOf(snapshot); !v.IsValid() || v.Kind() == reflect.Pointer && v.IsNil() {
+		return fields
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fields
+	}
+	json.Unmarshal(data, &fields)
+	return fields
+}
@@ -0,0 +1,843 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	netmail "net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/auth"
+	"github.com/MuthuM3/gin-microservice-template/internal/clientinfo"
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/lockout"
+	"github.com/MuthuM3/gin-microservice-template/internal/mail"
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/oauth"
+	"github.com/MuthuM3/gin-microservice-template/internal/session"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+var (
+	// ErrInvalidCredentials is returned when the email or password is wrong
+	ErrInvalidCredentials = errors.New("invalid email or password")
+
+	// ErrInvalidRefreshToken is returned when a refresh token is unknown,
+	// expired, reused or belongs to a revoked session
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+	// ErrInvalidResetToken is returned when a password reset token is
+	// unknown, expired or already used
+	ErrInvalidResetToken = errors.New("invalid or expired reset token")
+
+	// ErrInvalidVerificationToken is returned when an email verification
+	// token is unknown, expired or already used
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+
+	// ErrIdentityConflict is returned when an external identity with an
+	// unverified email matches an existing account, linking it would let
+	// anyone take over the account by claiming the address at the provider
+	ErrIdentityConflict = errors.New("an account with this email already exists")
+
+	// ErrEmailNotVerified is returned by Login when verified emails are
+	// required and the account has not been verified yet
+	ErrEmailNotVerified = errors.New("email address has not been verified")
+
+	// ErrAccountDeactivated is returned by Login for accounts deactivated by
+	// their identity provider
+	ErrAccountDeactivated = errors.New("account has been deactivated")
+)
+
+// AccountLockedError is returned by Login while the account or the client
+// is locked out after too many failed attempts
+type AccountLockedError struct {
+	Until time.Time
+}
+
+func (e *AccountLockedError) Error() string {
+	return "too many failed login attempts"
+}
+
+const (
+	// refreshTokenBytes is the entropy of generated refresh tokens
+	refreshTokenBytes = 32
+
+	// resetTokenBytes is the entropy of generated password reset and email
+	// verification tokens
+	resetTokenBytes = 32
+
+	maxEmailLength = 255
+	maxNameLength  = 255
+)
+
+// AuthService implements registration and login
+type AuthService struct {
+	store    storage.AuthRepository
+	tokens   *auth.TokenManager
+	security *config.SecurityConfig
+	lockout  lockout.Tracker
+	mailer   mail.Mailer
+	email    *config.EmailConfig
+	audit    *AuditLogger
+	events   *SecurityLog
+	sessions session.Store // nil unless cookie sessions are enabled
+
+	// dummyHash is compared against when a user does not exist so that login
+	// timing does not reveal which emails are registered
+	dummyHash string
+}
+
+func NewAuthService(
+	store storage.AuthRepository,
+	tokens *auth.TokenManager,
+	security *config.SecurityConfig,
+	tracker lockout.Tracker,
+	mailer mail.Mailer,
+	email *config.EmailConfig,
+	audit *AuditLogger,
+	events *SecurityLog,
+	sessions session.Store,
+) (*AuthService, error) {
+	dummyHash, err := auth.HashPassword("dummy-password-for-timing")
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthService{
+		store:     store,
+		tokens:    tokens,
+		security:  security,
+		lockout:   tracker,
+		mailer:    mailer,
+		email:     email,
+		audit:     audit,
+		events:    events,
+		sessions:  sessions,
+		dummyHash: dummyHash,
+	}, nil
+}
+
+// RegisterInput holds the fields required to create an account
+type RegisterInput struct {
+	Email    string
+	Password string
+	Name     string
+}
+
+// AuthResult is returned after a successful registration, login or refresh.
+// VerificationRequired is set instead of issuing tokens when a new account
+// must verify its email before logging in
+type AuthResult struct {
+	User                 *models.User
+	SessionID            string
+	AccessToken          string
+	ExpiresAt            time.Time
+	RefreshToken         string
+	VerificationRequired bool
+}
+
+// Register creates a new account and issues an access token for it. With
+// email verification enabled a verification link is sent as well, and when
+// verified emails are required no token is issued until it is followed
+func (s *AuthService) Register(ctx context.Context, input RegisterInput) (*AuthResult, error) {
+	email := normalizeEmail(input.Email)
+	if err := validateEmail(email); err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(input.Name)
+	if len(name) > maxNameLength {
+		return nil, invalidField("name", "name must be at most %d characters", maxNameLength)
+	}
+
+	if violations := auth.ValidatePassword(s.security, input.Password); len(violations) > 0 {
+		return nil, invalidField("password", "%s", strings.Join(violations, "; "))
+	}
+
+	hash, err := auth.HashPassword(input.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Email:        email,
+		Name:         name,
+		PasswordHash: hash,
+	}
+
+	// Create the account, its verification and its first session atomically
+	// so a failure never leaves behind a user that cannot log in. The email
+	// is sent inside the transaction so a delivery failure can be retried by
+	// registering again
+	var result *AuthResult
+	err = s.store.InTx(ctx, func(repo storage.AuthRepository) error {
+		if err := repo.CreateUser(ctx, user); err != nil {
+			return err
+		}
+
+		if s.security.EmailVerification {
+			if err := s.sendVerification(ctx, repo, user); err != nil {
+				return err
+			}
+		}
+		if s.security.RequireVerifiedEmail {
+			result = &AuthResult{User: user, VerificationRequired: true}
+			return nil
+		}
+
+		result, err = s.startSession(ctx, repo, user)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, AuditEntry{
+		UserID:     &user.ID,
+		Action:     "user.create",
+		EntityType: EntityUser,
+		EntityID:   user.ID,
+		After:      user,
+	})
+	return result, nil
+}
+
+// Login verifies the credentials and issues an access token
+func (s *AuthService) Login(ctx context.Context, email, password, clientIP string) (*AuthResult, error) {
+	user, err := s.Authenticate(ctx, email, password, clientIP)
+	if err != nil {
+		return nil, err
+	}
+	return s.startSession(ctx, s.store, user)
+}
+
+// Authenticate verifies the credentials and returns their user. Failures
+// are counted per account and per client IP, once either reaches the
+// configured maximum further attempts are rejected until the lockout expires
+func (s *AuthService) Authenticate(ctx context.Context, email, password, clientIP string) (*models.User, error) {
+	email = normalizeEmail(email)
+	if err := validateEmail(email); err != nil {
+		return nil, err
+	}
+	if password == "" {
+		return nil, invalidField("password", "password is required")
+	}
+	keys := lockoutKeys(email, clientIP)
+
+	for _, key := range keys {
+		until, err := s.lockout.LockedUntil(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if !until.IsZero() {
+			s.events.Record(ctx, SecurityEntry{
+				Type:    SecurityLoginFailed,
+				Email:   email,
+				Details: map[string]string{"reason": "locked_out"},
+			})
+			return nil, &AccountLockedError{Until: until}
+		}
+	}
+
+	user, err := s.store.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			auth.CheckPassword(s.dummyHash, password)
+			return nil, s.loginFailed(ctx, keys, email, nil, "unknown_email")
+		}
+		return nil, err
+	}
+
+	if !auth.CheckPassword(user.PasswordHash, password) {
+		return nil, s.loginFailed(ctx, keys, email, &user.ID, "invalid_password")
+	}
+
+	if s.security.RequireVerifiedEmail && !user.IsVerified() {
+		s.events.Record(ctx, SecurityEntry{
+			Type:    SecurityLoginFailed,
+			UserID:  &user.ID,
+			Email:   email,
+			Details: map[string]string{"reason": "email_not_verified"},
+		})
+		return nil, ErrEmailNotVerified
+	}
+
+	if user.IsDeactivated() {
+		s.events.Record(ctx, SecurityEntry{
+			Type:    SecurityLoginFailed,
+			UserID:  &user.ID,
+			Email:   email,
+			Details: map[string]string{"reason": "deactivated"},
+		})
+		return nil, ErrAccountDeactivated
+	}
+
+	// Only the account is forgiven, a client guessing across many accounts
+	// keeps its failures
+	if err := s.lockout.Reset(ctx, keys[0]); err != nil {
+		return nil, err
+	}
+
+	s.events.Record(ctx, SecurityEntry{
+		Type:    SecurityLogin,
+		UserID:  &user.ID,
+		Email:   email,
+		Details: map[string]string{"method": "password"},
+	})
+	return user, nil
+}
+
+// LoginWithIdentity signs in the user linked to an external identity.
+// Unknown identities are linked to the account with the same email when the
+// provider has verified the address, otherwise a new account without a
+// password is created
+func (s *AuthService) LoginWithIdentity(ctx context.Context, identity *oauth.Identity) (*AuthResult, error) {
+	var result *AuthResult
+	var change *AuditEntry
+	err := s.store.InTx(ctx, func(repo storage.AuthRepository) error {
+		var user *models.User
+		var err error
+		user, change, err = s.identityUser(ctx, repo, identity)
+		if err != nil {
+			return err
+		}
+
+		if s.security.RequireVerifiedEmail && !user.IsVerified() {
+			return ErrEmailNotVerified
+		}
+		if user.IsDeactivated() {
+			return ErrAccountDeactivated
+		}
+
+		result, err = s.startSession(ctx, repo, user)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if change != nil {
+		s.audit.Record(ctx, *change)
+	}
+	s.events.Record(ctx, SecurityEntry{
+		Type:    SecurityLogin,
+		UserID:  &result.User.ID,
+		Email:   result.User.Email,
+		Details: map[string]string{"method": identity.Provider},
+	})
+	return result, nil
+}
+
+// identityUser returns the user linked to the identity, linking or creating
+// one on first login. The returned audit entry describes the account change
+// and is nil when the identity was already linked
+func (s *AuthService) identityUser(ctx context.Context, repo storage.AuthRepository, identity *oauth.Identity) (*models.User, *AuditEntry, error) {
+	linked, err := repo.GetIdentity(ctx, identity.Provider, identity.Subject)
+	if err == nil {
+		user, err := repo.GetUserByID(ctx, linked.UserID)
+		return user, nil, err
+	}
+	if !errors.Is(err, storage.ErrNotFound) {
+		return nil, nil, err
+	}
+
+	email := normalizeEmail(identity.Email)
+	if email == "" {
+		return nil, nil, fmt.Errorf("%w: %s did not share an email address", ErrInvalidInput, identity.Provider)
+	}
+
+	action := "user.link_identity"
+	user, err := repo.GetUserByEmail(ctx, email)
+	switch {
+	case err == nil:
+		if !identity.EmailVerified {
+			return nil, nil, ErrIdentityConflict
+		}
+	case errors.Is(err, storage.ErrNotFound):
+		action = "user.create"
+		user = &models.User{Email: email, Name: strings.TrimSpace(identity.Name)}
+		if err := repo.CreateUser(ctx, user); err != nil {
+			return nil, nil, err
+		}
+	default:
+		return nil, nil, err
+	}
+
+	if identity.EmailVerified && !user.IsVerified() {
+		if err := repo.MarkEmailVerified(ctx, user.ID); err != nil {
+			return nil, nil, err
+		}
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
+	err = repo.CreateIdentity(ctx, &models.Identity{
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		UserID:   user.ID,
+		Email:    email,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	change := &AuditEntry{
+		UserID:     &user.ID,
+		Action:     action,
+		EntityType: EntityUser,
+		EntityID:   user.ID,
+		After:      map[string]string{"identity_provider": identity.Provider},
+	}
+	if action == "user.create" {
+		change.After = user
+	}
+	return user, change, nil
+}
+
+// User returns the account with the given id
+func (s *AuthService) User(ctx context.Context, id int64) (*models.User, error) {
+	return s.store.GetUserByID(ctx, id)
+}
+
+// Unlock clears the failed attempts and lockouts of an account and/or a
+// client IP, empty values are ignored
+func (s *AuthService) Unlock(ctx context.Context, email, clientIP string) error {
+	var keys []string
+	if email != "" {
+		keys = append(keys, "email:"+normalizeEmail(email))
+	}
+	if clientIP != "" {
+		keys = append(keys, "ip:"+clientIP)
+	}
+
+	for _, key := range keys {
+		if err := s.lockout.Reset(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loginFailed records a failure against every key, returning an
+// AccountLockedError when one of them reached the threshold and
+// ErrInvalidCredentials otherwise. userID is nil for unknown emails
+func (s *AuthService) loginFailed(ctx context.Context, keys []string, email string, userID *int64, reason string) error {
+	s.events.Record(ctx, SecurityEntry{
+		Type:    SecurityLoginFailed,
+		UserID:  userID,
+		Email:   email,
+		Details: map[string]string{"reason": reason},
+	})
+
+	var until time.Time
+	for _, key := range keys {
+		lockedUntil, err := s.lockout.Fail(ctx, key)
+		if err != nil {
+			return err
+		}
+		if lockedUntil.After(until) {
+			until = lockedUntil
+		}
+	}
+
+	if !until.IsZero() {
+		s.events.Record(ctx, SecurityEntry{
+			Type:    SecurityLockout,
+			UserID:  userID,
+			Email:   email,
+			Details: map[string]string{"until": until.UTC().Format(time.RFC3339)},
+		})
+		return &AccountLockedError{Until: until}
+	}
+	return ErrInvalidCredentials
+}
+
+// Refresh exchanges a refresh token for a new access and refresh token pair.
+// Refresh tokens are single use, presenting one twice revokes the whole
+// session since it indicates the token was stolen
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*AuthResult, error) {
+	if refreshToken == "" {
+		return nil, invalidField("refresh_token", "refresh_token is required")
+	}
+	token, err := s.store.GetRefreshToken(ctx, auth.HashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	session, err := s.store.GetSession(ctx, token.SessionID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if !session.IsActive(now) || now.After(token.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// Consume the old token and issue its replacement in one transaction so a
+	// failed rotation does not burn the client's only refresh token
+	var result *AuthResult
+	reused := false
+	err = s.store.InTx(ctx, func(repo storage.AuthRepository) error {
+		consumed, err := repo.MarkRefreshTokenUsed(ctx, token.TokenHash)
+		if err != nil {
+			return err
+		}
+		if !consumed {
+			reused = true
+			return nil
+		}
+
+		user, err := repo.GetUserByID(ctx, token.UserID)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				return ErrInvalidRefreshToken
+			}
+			return err
+		}
+		if user.IsDeactivated() {
+			return ErrInvalidRefreshToken
+		}
+
+		client := clientinfo.FromContext(ctx)
+		if err := repo.TouchSession(ctx, session.ID, client.IP, client.UserAgent); err != nil {
+			return err
+		}
+
+		result, err = s.issue(ctx, repo, user, session)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if reused {
+		// Reuse of a rotated token, revoke the session to lock out both parties
+		if err := s.store.RevokeSession(ctx, session.ID); err != nil {
+			return nil, err
+		}
+		s.events.Record(ctx, SecurityEntry{
+			Type:    SecurityTokenReuse,
+			UserID:  &token.UserID,
+			Details: map[string]string{"session_id": session.ID},
+		})
+		return nil, ErrInvalidRefreshToken
+	}
+
+	s.events.Record(ctx, SecurityEntry{
+		Type:    SecurityTokenRefresh,
+		UserID:  &token.UserID,
+		Details: map[string]string{"session_id": session.ID},
+	})
+	return result, nil
+}
+
+// Logout revokes the session so its refresh and access tokens stop working
+func (s *AuthService) Logout(ctx context.Context, userID int64, sessionID string) error {
+	if err := s.store.RevokeSession(ctx, sessionID); err != nil {
+		return err
+	}
+
+	s.events.Record(ctx, SecurityEntry{
+		Type:    SecurityLogout,
+		UserID:  &userID,
+		Details: map[string]string{"session_id": sessionID},
+	})
+	return nil
+}
+
+// Sessions returns the active login sessions of the user with the device
+// that last used each, most recently seen first
+func (s *AuthService) Sessions(ctx context.Context, userID int64) ([]*models.Session, error) {
+	return s.store.ListSessions(ctx, userID)
+}
+
+// RevokeSession signs the user out of one of their sessions, on whichever
+// device it is used. Sessions of other users and inactive ones yield
+// storage.ErrNotFound
+func (s *AuthService) RevokeSession(ctx context.Context, userID int64, sessionID string) error {
+	session, err := s.store.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if session.UserID != userID || !session.IsActive(time.Now()) {
+		return storage.ErrNotFound
+	}
+
+	if err := s.store.RevokeSession(ctx, sessionID); err != nil {
+		return err
+	}
+	s.events.Record(ctx, SecurityEntry{
+		Type:    SecuritySessionRevoke,
+		UserID:  &userID,
+		Details: map[string]string{"session_id": sessionID},
+	})
+	return nil
+}
+
+// RevokeOtherSessions signs the user out of every session but the current
+// one and returns how many were revoked. currentID is empty for requests not
+// authenticated with a token of a session, which sign out every device
+func (s *AuthService) RevokeOtherSessions(ctx context.Context, userID int64, currentID string) (int, error) {
+	revoked, err := s.store.RevokeOtherSessions(ctx, userID, currentID)
+	if err != nil {
+		return 0, err
+	}
+
+	if revoked > 0 {
+		s.events.Record(ctx, SecurityEntry{
+			Type:    SecuritySessionRevoke,
+			UserID:  &userID,
+			Details: map[string]string{"kept_session_id": currentID, "revoked": strconv.FormatInt(revoked, 10)},
+		})
+	}
+	return int(revoked), nil
+}
+
+// ForgotPassword emails a single-use password reset link to the account.
+// Unknown emails succeed silently so the endpoint cannot be used to find
+// registered accounts
+func (s *AuthService) ForgotPassword(ctx context.Context, email string) error {
+	email = normalizeEmail(email)
+	if err := validateEmail(email); err != nil {
+		return err
+	}
+
+	user, err := s.store.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	token, err := auth.RandomToken(resetTokenBytes)
+	if err != nil {
+		return err
+	}
+
+	err = s.store.CreatePasswordReset(ctx, &models.PasswordReset{
+		TokenHash: auth.HashToken(token),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(s.security.PasswordResetTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	link := s.email.PasswordResetURL + "?token=" + url.QueryEscape(token)
+	err = s.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("A password reset was requested for your account.\n\n"+
+			"Open the link below within %s to choose a new password:\n\n%s\n\n"+
+			"If you did not request this, you can ignore this email.\n",
+			s.security.PasswordResetTTL, link),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send password reset email: %w", err)
+	}
+
+	return nil
+}
+
+// ResetPassword sets a new password using a reset token. The token is
+// consumed, the password changed and every session of the user revoked in
+// one transaction so stolen sessions do not survive the reset. Cookie
+// sessions are not kept in the database and end after it
+func (s *AuthService) ResetPassword(ctx context.Context, token, password string) error {
+	if token == "" {
+		return invalidField("token", "token is required")
+	}
+	if violations := auth.ValidatePassword(s.security, password); len(violations) > 0 {
+		return invalidField("password", "%s", strings.Join(violations, "; "))
+	}
+
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	var userID int64
+	err = s.store.InTx(ctx, func(repo storage.AuthRepository) error {
+		reset, err := repo.ConsumePasswordReset(ctx, auth.HashToken(token))
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				return ErrInvalidResetToken
+			}
+			return err
+		}
+
+		userID = reset.UserID
+		if err := repo.UpdatePassword(ctx, reset.UserID, hash); err != nil {
+			return err
+		}
+		return repo.RevokeUserSessions(ctx, reset.UserID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, AuditEntry{
+		UserID:     &userID,
+		Action:     "user.reset_password",
+		EntityType: EntityUser,
+		EntityID:   userID,
+	})
+	s.events.Record(ctx, SecurityEntry{
+		Type:    SecurityPasswordChange,
+		UserID:  &userID,
+		Details: map[string]string{"method": "reset"},
+	})
+
+	if err := session.EndUserSessions(ctx, s.sessions, userID); err != nil {
+		return fmt.Errorf("failed to end cookie sessions: %w", err)
+	}
+	return nil
+}
+
+// VerifyEmail redeems an email verification token and marks its user verified
+func (s *AuthService) VerifyEmail(ctx context.Context, token string) error {
+	var userID int64
+	err := s.store.InTx(ctx, func(repo storage.AuthRepository) error {
+		verification, err := repo.ConsumeEmailVerification(ctx, auth.HashToken(token))
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				return ErrInvalidVerificationToken
+			}
+			return err
+		}
+
+		userID = verification.UserID
+		return repo.MarkEmailVerified(ctx, verification.UserID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, AuditEntry{
+		UserID:     &userID,
+		Action:     "user.verify_email",
+		EntityType: EntityUser,
+		EntityID:   userID,
+	})
+	return nil
+}
+
+// sendVerification stores a new verification token for the user and emails
+// the link to redeem it
+func (s *AuthService) sendVerification(ctx context.Context, repo storage.AuthRepository, user *models.User) error {
+	token, err := auth.RandomToken(resetTokenBytes)
+	if err != nil {
+		return err
+	}
+
+	err = repo.CreateEmailVerification(ctx, &models.EmailVerification{
+		TokenHash: auth.HashToken(token),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(s.security.EmailVerificationTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	link := s.email.VerificationURL + "?token=" + url.QueryEscape(token)
+	err = s.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Welcome! Please confirm your email address.\n\n"+
+			"Open the link below within %s to verify it:\n\n%s\n",
+			s.security.EmailVerificationTTL, link),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send verification email: %w", err)
+	}
+
+	return nil
+}
+
+// startSession creates a new session governed by the configured session
+// timeout and issues its first token pair using the given repository
+func (s *AuthService) startSession(ctx context.Context, repo storage.AuthRepository, user *models.User) (*AuthResult, error) {
+	sessionID, err := auth.RandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	client := clientinfo.FromContext(ctx)
+	session := &models.Session{
+		ID:        sessionID,
+		UserID:    user.ID,
+		IPAddress: client.IP,
+		UserAgent: client.UserAgent,
+		ExpiresAt: time.Now().Add(s.security.SessionTimeout),
+	}
+	if err := repo.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return s.issue(ctx, repo, user, session)
+}
+
+// issue generates an access token and a refresh token for the session
+func (s *AuthService) issue(ctx context.Context, repo storage.AuthRepository, user *models.User, session *models.Session) (*AuthResult, error) {
+	accessToken, expiresAt, err := s.tokens.Generate(user, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := auth.RandomToken(refreshTokenBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	err = repo.CreateRefreshToken(ctx, &models.RefreshToken{
+		TokenHash: auth.HashToken(refreshToken),
+		SessionID: session.ID,
+		UserID:    user.ID,
+		ExpiresAt: session.ExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthResult{
+		User:         user,
+		SessionID:    session.ID,
+		AccessToken:  accessToken,
+		ExpiresAt:    expiresAt,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// lockoutKeys returns the lockout keys for a login attempt, the account key first
+func lockoutKeys(email, clientIP string) []string {
+	return []string{"email:" + email, "ip:" + clientIP}
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// validateEmail checks a normalized email address, display names and other
+// decorations accepted by RFC 5322 are rejected
+func validateEmail(email string) error {
+	if email == "" {
+		return invalidField("email", "email is required")
+	}
+	if len(email) > maxEmailLength {
+		return invalidField("email", "email must be at most %d characters", maxEmailLength)
+	}
+	if addr, err := netmail.ParseAddress(email); err != nil || addr.Address != email {
+		return invalidField("email", "email must be a valid address")
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+// cursorToken is the payload of an opaque page cursor. Clients must treat
+// cursors as opaque, the encoding may change between releases
+type cursorToken struct {
+	CreatedAt time.Time `json:"t"`
+	ID        int64     `json:"i"`
+	Backward  bool      `json:"b,omitempty"`
+}
+
+// encodeCursor returns the cursor for the page after (or before, when
+// backward is set) the given position
+func encodeCursor(position storage.Cursor, backward bool) string {
+	data, _ := json.Marshal(cursorToken{CreatedAt: position.CreatedAt, ID: position.ID, Backward: backward})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses a cursor produced by encodeCursor, an empty cursor
+// selects the first page
+func decodeCursor(cursor string) (storage.PageRequest, error) {
+	if cursor == "" {
+		return storage.PageRequest{}, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return storage.PageRequest{}, invalidField("cursor", "invalid cursor")
+	}
+
+	var token cursorToken
+	if err := json.Unmarshal(data, &token); err != nil || token.ID <= 0 {
+		return storage.PageRequest{}, invalidField("cursor", "invalid cursor")
+	}
+
+	return storage.PageRequest{
+		Cursor:   &storage.Cursor{CreatedAt: token.CreatedAt, ID: token.ID},
+		Backward: token.Backward,
+	}, nil
+}
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/auth"
+	"github.com/MuthuM3/gin-microservice-template/internal/cache"
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/export"
+	"github.com/MuthuM3/gin-microservice-template/internal/mail"
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/queue"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+// TaskExportTodos is the queue task type building an asynchronous export
+const TaskExportTodos = "todos:export"
+
+const (
+	// exportBufferSize is how much of an export is buffered before the
+	// response is committed, errors in the first batch still render as JSON
+	exportBufferSize = 32 << 10
+
+	// exportTokenBytes is the entropy of download tokens
+	exportTokenBytes = 32
+)
+
+// ErrExportTooLarge is returned when an asynchronous export exceeds the
+// configured maximum file size
+var ErrExportTooLarge = errors.New("export is too large")
+
+// ExportService exports the user's todos, either streamed in the response or
+// built by a queue worker and sent as an emailed download link
+type ExportService struct {
+	todos  *TodoService
+	users  storage.AuthRepository
+	files  cache.Cache
+	queue  *queue.Client
+	mailer mail.Mailer
+	cfg    config.ExportConfig
+	email  *config.EmailConfig
+}
+
+// NewExportService creates an export service. Asynchronous exports are
+// unavailable when queue or files is nil
+func NewExportService(
+	todos *TodoService,
+	users storage.AuthRepository,
+	files cache.Cache,
+	queue *queue.Client,
+	mailer mail.Mailer,
+	cfg config.ExportConfig,
+	email *config.EmailConfig,
+) *ExportService {
+	return &ExportService{
+		todos:  todos,
+		users:  users,
+		files:  files,
+		queue:  queue,
+		mailer: mailer,
+		cfg:    cfg,
+		email:  email,
+	}
+}
+
+// ExportFile is a finished asynchronous export
+type ExportFile struct {
+	Format string
+	Data   []byte
+}
+
+// exportTask is the payload of a TaskExportTodos task
+type exportTask struct {
+	UserID    int64      `json:"user_id"`
+	Format    string     `json:"format"`
+	Status    string     `json:"status,omitempty"`
+	Priority  string     `json:"priority,omitempty"`
+	DueBefore *time.Time `json:"due_before,omitempty"`
+	DueAfter  *time.Time `json:"due_after,omitempty"`
+	Tags      []string   `json:"tags,omitempty"`
+}
+
+func (t exportTask) query() TodoQuery {
+	return TodoQuery{
+		Status:    t.Status,
+		Priority:  t.Priority,
+		DueBefore: t.DueBefore,
+		DueAfter:  t.DueAfter,
+		Tags:      t.Tags,
+	}
+}
+
+// Stream writes the user's todos matching the query to w in format. Output
+// is written in chunks as todos are read, so w receives nothing when the
+// export fails before the first chunk
+func (s *ExportService) Stream(ctx context.Context, userID int64, format string, query TodoQuery, w io.Writer) error {
+	if err := validateFormat(format); err != nil {
+		return err
+	}
+
+	buf := bufio.NewWriterSize(w, exportBufferSize)
+	if err := s.write(ctx, userID, format, query, buf); err != nil {
+		return err
+	}
+	return buf.Flush()
+}
+
+// Schedule enqueues an export of the user's todos matching the query, the
+// download link is emailed to the user once it is ready
+func (s *ExportService) Schedule(ctx context.Context, userID int64, format string, query TodoQuery) error {
+	if s.queue == nil || s.files == nil {
+		return invalidField("async", "asynchronous exports are not available")
+	}
+	if err := validateFormat(format); err != nil {
+		return err
+	}
+	// Reject bad filters now rather than in the worker
+	if _, err := todoFilter(query, time.Now()); err != nil {
+		return err
+	}
+
+	_, err := s.queue.Enqueue(ctx, TaskExportTodos, exportTask{
+		UserID:    userID,
+		Format:    format,
+		Status:    query.Status,
+		Priority:  query.Priority,
+		DueBefore: query.DueBefore,
+		DueAfter:  query.DueAfter,
+		Tags:      query.Tags,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to queue export: %w", err)
+	}
+	return nil
+}
+
+// HandleTask builds a scheduled export, stores it and emails its download
+// link to the user
+func (s *ExportService) HandleTask(ctx context.Context, task *queue.Task) error {
+	var payload exportTask
+	if err := json.Unmarshal(task.Payload, &payload); err != nil {
+		return fmt.Errorf("%w: invalid export task: %v", queue.ErrSkipRetry, err)
+	}
+
+	user, err := s.users.GetUserByID(ctx, payload.UserID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("%w: user %d no longer exists", queue.ErrSkipRetry, payload.UserID)
+	}
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	limited := &limitedWriter{w: &buf, remaining: s.cfg.MaxFileSize}
+	err = s.write(ctx, payload.UserID, payload.Format, payload.query(), limited)
+	if errors.Is(err, ErrExportTooLarge) {
+		return fmt.Errorf("%w: %w", queue.ErrSkipRetry, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.deliver(ctx, user, payload.Format, buf.Bytes(),
+		"Your todo export is ready", "The export of your todos you requested is ready.")
+}
+
+// deliver stores a finished file and emails its download link to the user
+func (s *ExportService) deliver(ctx context.Context, user *models.User, format string, data []byte, subject, intro string) error {
+	token, err := auth.RandomToken(exportTokenBytes)
+	if err != nil {
+		return err
+	}
+	// The format is stored ahead of the file so a download needs one lookup
+	file := append([]byte(format+"\n"), data...)
+	if err := s.files.Set(ctx, exportKey(token), file, s.cfg.LinkTTL); err != nil {
+		return fmt.Errorf("failed to store export: %w", err)
+	}
+
+	link := s.email.ExportURL + "?token=" + url.QueryEscape(token)
+	err = s.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: subject,
+		Body: fmt.Sprintf("%s\n\nOpen the link below within %s to download it:\n\n%s\n",
+			intro, s.cfg.LinkTTL, link),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send export email: %w", err)
+	}
+
+	return nil
+}
+
+// Download returns the export stored under a download token, or
+// storage.ErrNotFound once the link has expired
+func (s *ExportService) Download(ctx context.Context, token string) (*ExportFile, error) {
+	if s.files == nil || token == "" {
+		return nil, storage.ErrNotFound
+	}
+
+	data, err := s.files.Get(ctx, exportKey(token))
+	if errors.Is(err, cache.ErrCacheMiss) {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load export: %w", err)
+	}
+
+	format, body, ok := bytes.Cut(data, []byte("\n"))
+	if !ok {
+		return nil, errors.New("failed to load export: malformed file")
+	}
+	return &ExportFile{Format: string(format), Data: body}, nil
+}
+
+// write encodes the user's todos matching the query to w
+func (s *ExportService) write(ctx context.Context, userID int64, format string, query TodoQuery, w io.Writer) error {
+	enc, err := export.NewWriter(format, w)
+	if err != nil {
+		return err
+	}
+	if err := s.todos.Export(ctx, userID, query, enc.Write); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func validateFormat(format string) error {
+	if !export.Valid(format) {
+		return invalidField("format", "must be one of %s", strings.Join(export.Formats, ", "))
+	}
+	return nil
+}
+
+// exportKey stores exports under the hash of their token so the cache does
+// not hold usable links
+func exportKey(token string) string {
+	return "export:" + auth.HashToken(token)
+}
+
+// limitedWriter fails with ErrExportTooLarge once more than remaining bytes
+// are written
+type limitedWriter struct {
+	w         io.Writer
+	remaining int64
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) > l.remaining {
+		return 0, ErrExportTooLarge
+	}
+	l.remaining -= int64(len(p))
+	return l.w.Write(p)
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/events"
+)
+
+// Todo change event types
+const (
+	TodoCreated  = "todo.created"
+	TodoUpdated  = "todo.updated"
+	TodoDeleted  = "todo.deleted"
+	TodoRestored = "todo.restored"
+)
+
+// subscriberBuffer is the number of events a subscriber may fall behind
+// before it is dropped and has to resume from the replay buffer
+const subscriberBuffer = 64
+
+// TodoEvent describes a change to one of a user's todos. IDs are assigned by
+// the event bus and increase monotonically so clients can resume a stream
+// after the last one they saw
+type TodoEvent struct {
+	ID         uint64          `json:"id"`
+	Type       string          `json:"type"`
+	UserID     int64           `json:"-"`
+	TodoID     int64           `json:"todo_id"`
+	Todo       json.RawMessage `json:"todo"`
+	OccurredAt time.Time       `json:"occurred_at"`
+}
+
+// TodoFeed fans the todo events received from the event bus out to the
+// stream subscribers of their owner and keeps the most recent ones for replay
+type TodoFeed struct {
+	mu     sync.Mutex
+	lastID uint64
+	replay []TodoEvent
+	size   int
+	subs   map[*TodoSubscription]struct{}
+}
+
+func NewTodoFeed(replaySize int) *TodoFeed {
+	return &TodoFeed{
+		replay: make([]TodoEvent, 0, replaySize),
+		size:   replaySize,
+		subs:   make(map[*TodoSubscription]struct{}),
+	}
+}
+
+// TodoSubscription receives the events of a single user. Events is closed
+// when the subscription is cancelled or falls too far behind
+type TodoSubscription struct {
+	feed   *TodoFeed
+	userID int64
+	events chan TodoEvent
+}
+
+// Events returns the channel new events are delivered on
+func (s *TodoSubscription) Events() <-chan TodoEvent {
+	return s.events
+}
+
+// Close cancels the subscription
+func (s *TodoSubscription) Close() {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+	s.feed.drop(s)
+}
+
+// Consume feeds the events received on ch to the subscribers until ch is
+// closed
+func (f *TodoFeed) Consume(ch <-chan events.Event) {
+	for event := range ch {
+		f.add(TodoEvent{
+			ID:         event.ID,
+			Type:       event.Type,
+			UserID:     event.UserID,
+			TodoID:     event.EntityID,
+			Todo:       event.Data,
+			OccurredAt: event.OccurredAt,
+		})
+	}
+}
+
+// add stores the event for replay and delivers it to the owner's subscribers
+func (f *TodoFeed) add(event TodoEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.lastID = max(f.lastID, event.ID)
+	if len(f.replay) == f.size {
+		f.replay = append(f.replay[:0], f.replay[1:]...)
+	}
+	f.replay = append(f.replay, event)
+
+	for sub := range f.subs {
+		if sub.userID != event.UserID {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			// Never block the bus on a slow client, it reconnects and
+			// resumes from the replay buffer
+			f.drop(sub)
+		}
+	}
+}
+
+// Subscribe starts delivering the user's events. With a non-zero
+// lastEventID the events received after it are returned for replay;
+// complete is false when some of them are no longer available, in which
+// case the client should reload its state
+func (f *TodoFeed) Subscribe(userID int64, lastEventID uint64) (sub *TodoSubscription, missed []TodoEvent, complete bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sub = &TodoSubscription{
+		feed:   f,
+		userID: userID,
+		events: make(chan TodoEvent, subscriberBuffer),
+	}
+	f.subs[sub] = struct{}{}
+
+	if lastEventID == 0 {
+		return sub, nil, true
+	}
+
+	// IDs restart when the bus does, so an ID from the future is as stale as
+	// one that has already left the buffer
+	complete = lastEventID <= f.lastID &&
+		(len(f.replay) == 0 || lastEventID+1 >= f.replay[0].ID)
+	for _, event := range f.replay {
+		if event.ID > lastEventID && event.UserID == userID {
+			missed = append(missed, event)
+		}
+	}
+	return sub, missed, complete
+}
+
+func (f *TodoFeed) drop(sub *TodoSubscription) {
+	if _, ok := f.subs[sub]; ok {
+		delete(f.subs, sub)
+		close(sub.events)
+	}
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+	"github.com/MuthuM3/gin-microservice-template/internal/metrics"
+	"github.com/MuthuM3/gin-microservice-template/internal/queue"
+	"github.com/MuthuM3/gin-microservice-template/internal/webhook"
+)
+
+// TaskInboundWebhook is the queue task type processing a received webhook
+const TaskInboundWebhook = "webhooks:inbound"
+
+// ErrUnknownProvider is returned for webhooks of providers that are not
+// enabled or have no handler
+var ErrUnknownProvider = errors.New("unknown webhook provider")
+
+// InboundProvider pairs the verifier of a provider with the handler of its
+// events
+type InboundProvider struct {
+	Verifier webhook.Verifier
+	Handler  webhook.Handler
+}
+
+// InboundWebhookService receives the webhooks of third parties. Verified
+// events are handed to the task queue so providers get a quick response,
+// without a queue they are handled before responding
+type InboundWebhookService struct {
+	providers map[string]InboundProvider
+	queue     *queue.Client
+	log       logger.Logger
+}
+
+// NewInboundWebhookService creates an inbound webhook service for the
+// providers keyed by the name used in the URL, queue may be nil
+func NewInboundWebhookService(providers map[string]InboundProvider, queue *queue.Client, log logger.Logger) *InboundWebhookService {
+	return &InboundWebhookService{
+		providers: providers,
+		queue:     queue,
+		log:       log,
+	}
+}
+
+// Receive verifies a webhook of provider and processes its event, reporting
+// whether it was queued rather than handled
+func (s *InboundWebhookService) Receive(ctx context.Context, provider string, header http.Header, body []byte) (bool, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return false, ErrUnknownProvider
+	}
+
+	event, err := p.Verifier.Verify(header, body)
+	if err != nil {
+		countInbound(provider, "rejected")
+		return false, err
+	}
+	event.Provider = provider
+	event.ReceivedAt = time.Now().UTC()
+
+	if s.queue == nil {
+		if err := p.Handler(ctx, event); err != nil {
+			countInbound(provider, "failed")
+			return false, fmt.Errorf("failed to handle %s webhook: %w", provider, err)
+		}
+		countInbound(provider, "handled")
+		return false, nil
+	}
+
+	if _, err := s.queue.Enqueue(ctx, TaskInboundWebhook, event); err != nil {
+		countInbound(provider, "failed")
+		return false, fmt.Errorf("failed to queue %s webhook: %w", provider, err)
+	}
+	countInbound(provider, "queued")
+	return true, nil
+}
+
+// HandleTask runs the handler of a queued webhook
+func (s *InboundWebhookService) HandleTask(ctx context.Context, task *queue.Task) error {
+	var event webhook.Event
+	if err := json.Unmarshal(task.Payload, &event); err != nil {
+		return fmt.Errorf("%w: invalid webhook task: %v", queue.ErrSkipRetry, err)
+	}
+
+	p, ok := s.providers[event.Provider]
+	if !ok {
+		return fmt.Errorf("%w: no handler for %s webhooks", queue.ErrSkipRetry, event.Provider)
+	}
+
+	s.log.Debug("handling webhook", "provider", event.Provider, "id", event.ID, "type", event.Type)
+	return p.Handler(ctx, &event)
+}
+
+func countInbound(provider, outcome string) {
+	metrics.Default.Counter("webhook_inbound_total", "Total number of received webhooks by provider and outcome",
+		metrics.Labels{"provider": provider, "outcome": outcome},
+	).Inc()
+}
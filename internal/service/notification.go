@@ -0,0 +1,375 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/auth"
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/notifications"
+	"github.com/MuthuM3/gin-microservice-template/internal/queue"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+	"github.com/MuthuM3/gin-microservice-template/internal/webhook"
+)
+
+// TaskNotify is the queue task type delivering a notification over one
+// channel
+const TaskNotify = "notifications:send"
+
+// Notification types, named after the preference that turns them off
+const (
+	NotificationDueReminder = "due_reminders"
+	NotificationSharedTodo  = "shared_todos"
+)
+
+const (
+	// reminderBatchSize is how many todos SendDueReminders and
+	// ReminderService.Dispatch read per query
+	reminderBatchSize = 100
+
+	// reminderTimeFormat shows times in reminders
+	reminderTimeFormat = "Mon, 02 Jan 2006 15:04 MST"
+
+	// slackWebhookPrefix is the start of every Slack incoming webhook URL
+	slackWebhookPrefix = "https://hooks.slack.com/"
+)
+
+// ChannelInput holds the fields of a channel update, nil fields are left
+// untouched. New channels are enabled unless Enabled says otherwise
+type ChannelInput struct {
+	URL     *string
+	Enabled *bool
+}
+
+// NotificationService sends notifications to users over the channels they
+// set up. Which notifications a user receives follows the notification
+// preferences of their profile, every notification goes to each enabled
+// channel. Users without an email channel of their own are emailed at their
+// account address. Deliveries run on the task queue when there is one and
+// are retried there, without a queue they are sent right away
+type NotificationService struct {
+	users     storage.AuthRepository
+	todos     storage.TodoRepository
+	notifiers map[string]notifications.Notifier
+	queue     *queue.Client
+	cfg       config.NotificationsConfig
+	audit     *AuditLogger
+	log       logger.Logger
+}
+
+func NewNotificationService(
+	users storage.AuthRepository,
+	todos storage.TodoRepository,
+	notifiers map[string]notifications.Notifier,
+	queue *queue.Client,
+	cfg config.NotificationsConfig,
+	audit *AuditLogger,
+	log logger.Logger,
+) *NotificationService {
+	return &NotificationService{
+		users:     users,
+		todos:     todos,
+		notifiers: notifiers,
+		queue:     queue,
+		cfg:       cfg,
+		audit:     audit,
+		log:       log,
+	}
+}
+
+// notifyTask is the payload of a TaskNotify task
+type notifyTask struct {
+	UserID       int64                      `json:"user_id"`
+	Channel      string                     `json:"channel"`
+	Notification notifications.Notification `json:"notification"`
+}
+
+// Channels returns the user's channels ordered by name, including the
+// default email channel when the user has not changed it
+func (s *NotificationService) Channels(ctx context.Context, userID int64) ([]*models.NotificationChannel, error) {
+	channels, err := s.users.ListNotificationChannels(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !slices.ContainsFunc(channels, func(c *models.NotificationChannel) bool { return c.Channel == models.ChannelEmail }) {
+		channels = append([]*models.NotificationChannel{defaultEmailChannel(userID)}, channels...)
+	}
+	return channels, nil
+}
+
+// SetChannel creates or changes a channel of the user and reports whether
+// it was created. The webhook channel gets a signing secret when created
+func (s *NotificationService) SetChannel(ctx context.Context, userID int64, name string, input ChannelInput) (*models.NotificationChannel, bool, error) {
+	if !slices.Contains(models.Channels, name) {
+		return nil, false, invalidField("channel", "unknown channel %q, expected one of %s", name, strings.Join(models.Channels, ", "))
+	}
+
+	before, err := s.users.GetNotificationChannel(ctx, userID, name)
+	created := errors.Is(err, storage.ErrNotFound)
+	if err != nil && !created {
+		return nil, false, err
+	}
+
+	channel := &models.NotificationChannel{UserID: userID, Channel: name, Enabled: true}
+	if !created {
+		*channel = *before
+	}
+
+	if input.URL != nil {
+		if channel.URL, err = s.validateChannelURL(name, *input.URL); err != nil {
+			return nil, false, err
+		}
+	}
+	if name != models.ChannelEmail && channel.URL == "" {
+		return nil, false, invalidField("url", "url is required for the %s channel", name)
+	}
+	if input.Enabled != nil {
+		channel.Enabled = *input.Enabled
+	}
+	if name == models.ChannelWebhook && channel.Secret == "" {
+		if channel.Secret, err = auth.RandomToken(32); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if err := s.users.SetNotificationChannel(ctx, channel); err != nil {
+		return nil, false, err
+	}
+
+	action := "notification_channel.update"
+	if created {
+		action = "notification_channel.create"
+	}
+	s.record(ctx, action, before, channel)
+	return channel, created, nil
+}
+
+// DeleteChannel removes a channel of the user. Removing the email channel
+// restores the default of emailing the account address, disable it instead
+// to stop emails
+func (s *NotificationService) DeleteChannel(ctx context.Context, userID int64, name string) error {
+	before, err := s.users.GetNotificationChannel(ctx, userID, name)
+	if err != nil {
+		return err
+	}
+	if err := s.users.DeleteNotificationChannel(ctx, userID, name); err != nil {
+		return err
+	}
+
+	s.record(ctx, "notification_channel.delete", before, nil)
+	return nil
+}
+
+// Notify sends the notification to every enabled channel of the user, unless
+// the user turned off the preference named by its type
+func (s *NotificationService) Notify(ctx context.Context, userID int64, n notifications.Notification) error {
+	if s == nil {
+		return nil
+	}
+
+	profile, err := s.users.GetProfile(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !profile.Notifications.Enabled(n.Type) {
+		return nil
+	}
+	return s.send(ctx, profile, n)
+}
+
+func (s *NotificationService) send(ctx context.Context, profile *models.Profile, n notifications.Notification) error {
+	channels, err := s.Channels(ctx, profile.UserID)
+	if err != nil {
+		return err
+	}
+
+	for _, channel := range channels {
+		if !channel.Enabled {
+			continue
+		}
+
+		if s.queue != nil {
+			task := notifyTask{UserID: profile.UserID, Channel: channel.Channel, Notification: n}
+			if _, err := s.queue.Enqueue(ctx, TaskNotify, task); err != nil {
+				return fmt.Errorf("failed to queue %s notification: %w", channel.Channel, err)
+			}
+			continue
+		}
+
+		if err := s.deliver(ctx, profile, channel, n); err != nil {
+			s.log.Warn("failed to send notification", "error", err, "user_id", profile.UserID, "channel", channel.Channel, "type", n.Type)
+		}
+	}
+	return nil
+}
+
+// HandleTask delivers a queued notification. Notifications for users or
+// channels that are gone or disabled by now are dropped
+func (s *NotificationService) HandleTask(ctx context.Context, task *queue.Task) error {
+	var payload notifyTask
+	if err := json.Unmarshal(task.Payload, &payload); err != nil {
+		return fmt.Errorf("%w: invalid notification task: %v", queue.ErrSkipRetry, err)
+	}
+
+	profile, err := s.users.GetProfile(ctx, payload.UserID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("%w: user %d no longer exists", queue.ErrSkipRetry, payload.UserID)
+	}
+	if err != nil {
+		return err
+	}
+
+	channel, err := s.users.GetNotificationChannel(ctx, payload.UserID, payload.Channel)
+	if errors.Is(err, storage.ErrNotFound) && payload.Channel == models.ChannelEmail {
+		channel, err = defaultEmailChannel(payload.UserID), nil
+	}
+	if errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("%w: %s channel of user %d no longer exists", queue.ErrSkipRetry, payload.Channel, payload.UserID)
+	}
+	if err != nil {
+		return err
+	}
+	if !channel.Enabled {
+		return nil
+	}
+
+	err = s.deliver(ctx, profile, channel, payload.Notification)
+	if errors.Is(err, webhook.ErrForbiddenAddress) {
+		return fmt.Errorf("%w: %w", queue.ErrSkipRetry, err)
+	}
+	return err
+}
+
+func (s *NotificationService) deliver(ctx context.Context, profile *models.Profile, channel *models.NotificationChannel, n notifications.Notification) error {
+	notifier, ok := s.notifiers[channel.Channel]
+	if !ok {
+		return fmt.Errorf("no notifier for the %s channel", channel.Channel)
+	}
+
+	to := notifications.Recipient{UserID: profile.UserID, Address: channel.URL, Secret: channel.Secret}
+	if channel.Channel == models.ChannelEmail {
+		to.Address = profile.Email
+	}
+	return notifier.Notify(ctx, to, n)
+}
+
+// SendDueReminders reminds users of their todos falling due within the
+// reminder lead time and returns how many todos they were reminded of. Each
+// todo is reminded of once, unless its due date changes
+func (s *NotificationService) SendDueReminders(ctx context.Context) (int, error) {
+	now := time.Now()
+	var sent int
+	for {
+		todos, err := s.todos.DueReminders(ctx, now, now.Add(s.cfg.ReminderLeadTime), reminderBatchSize)
+		if err != nil {
+			return sent, err
+		}
+
+		reminded := make([]int64, 0, len(todos))
+		profiles := make(map[int64]*models.Profile)
+		for _, todo := range todos {
+			profile, ok := profiles[todo.UserID]
+			if !ok {
+				if profile, err = s.users.GetProfile(ctx, todo.UserID); err != nil {
+					break
+				}
+				profiles[todo.UserID] = profile
+			}
+
+			if profile.Notifications.DueReminders {
+				if err = s.send(ctx, profile, dueReminder(todo, profile.Timezone)); err != nil {
+					break
+				}
+				sent++
+			}
+			reminded = append(reminded, todo.ID)
+		}
+
+		// Todos reminded of before a failure must not be reminded of again
+		if markErr := s.todos.MarkReminded(ctx, reminded); markErr != nil {
+			return sent, markErr
+		}
+		if err != nil {
+			return sent, err
+		}
+		if len(todos) < reminderBatchSize {
+			return sent, nil
+		}
+	}
+}
+
+// dueReminder builds the reminder of a todo, showing the due date in the
+// user's time zone
+func dueReminder(todo *models.Todo, timezone string) notifications.Notification {
+	due := todo.DueDate.In(userLocation(timezone)).Format(reminderTimeFormat)
+
+	return notifications.Notification{
+		Type:    NotificationDueReminder,
+		Subject: "Due soon: " + todo.Title,
+		Body:    fmt.Sprintf("Your todo %q is due %s.", todo.Title, due),
+		Data: map[string]any{
+			"todo_id":  todo.ID,
+			"title":    todo.Title,
+			"due_date": todo.DueDate.UTC(),
+		},
+	}
+}
+
+// userLocation returns the location of a profile timezone, UTC when it is
+// unknown
+func userLocation(timezone string) *time.Location {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// validateChannelURL checks the URL of a channel. Webhook URLs follow the
+// rules of webhook subscriptions, Slack URLs must be incoming webhooks
+func (s *NotificationService) validateChannelURL(name, raw string) (string, error) {
+	switch name {
+	case models.ChannelWebhook:
+		return validateWebhookURL(raw, s.cfg.AllowPrivateNetworks)
+	case models.ChannelSlack:
+		raw = strings.TrimSpace(raw)
+		if len(raw) > maxWebhookURLLength {
+			return "", invalidField("url", "url must be at most %d characters", maxWebhookURLLength)
+		}
+		if !strings.HasPrefix(raw, slackWebhookPrefix) {
+			return "", invalidField("url", "url must be a Slack incoming webhook URL starting with %s", slackWebhookPrefix)
+		}
+		return raw, nil
+	default:
+		return "", invalidField("url", "the %s channel has no url", name)
+	}
+}
+
+func (s *NotificationService) record(ctx context.Context, action string, before, after *models.NotificationChannel) {
+	subject := after
+	if subject == nil {
+		subject = before
+	}
+
+	s.audit.Record(ctx, AuditEntry{
+		UserID:     &subject.UserID,
+		Action:     action,
+		EntityType: EntityChannel,
+		EntityID:   subject.UserID,
+		Before:     before,
+		After:      after,
+	})
+}
+
+// defaultEmailChannel is the email channel of users who have not changed it
+func defaultEmailChannel(userID int64) *models.NotificationChannel {
+	return &models.NotificationChannel{UserID: userID, Channel: models.ChannelEmail, Enabled: true}
+}
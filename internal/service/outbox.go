@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/events"
+	"github.com/MuthuM3/gin-microservice-template/internal/lock"
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+// outboxPurgeInterval is how often published messages past the retention
+// are removed
+const outboxPurgeInterval = time.Hour
+
+// outboxLockTTL bounds how long a crashed relay keeps the others waiting
+const outboxLockTTL = 15 * time.Second
+
+// OutboxRelay publishes the events written to the transactional outbox.
+// Delivery is at least once: a message is marked published only after the
+// publisher accepted it, so a crash in between publishes it again with the
+// same event key. A nil *OutboxRelay is valid and disables the outbox
+type OutboxRelay struct {
+	store     storage.TodoRepository
+	publisher events.Publisher
+	cfg       config.OutboxConfig
+	locker    lock.Locker
+	log       logger.Logger
+	wake      chan struct{}
+}
+
+func NewOutboxRelay(store storage.TodoRepository, publisher events.Publisher, cfg config.OutboxConfig, locker lock.Locker, log logger.Logger) *OutboxRelay {
+	return &OutboxRelay{
+		store:     store,
+		publisher: publisher,
+		cfg:       cfg,
+		locker:    locker,
+		log:       log,
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// Notify tells the relay that new messages were committed so they are
+// published without waiting for the next poll
+func (r *OutboxRelay) Notify() {
+	if r == nil {
+		return
+	}
+
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run relays pending messages until ctx is done. Only the replica holding
+// the outbox lock relays, so messages are published in order; the others
+// try to take over every poll interval
+func (r *OutboxRelay) Run(ctx context.Context) {
+	for {
+		lease, err := r.locker.Lock(ctx, "outbox_relay", outboxLockTTL)
+		switch {
+		case err == nil:
+			r.log.Info("outbox relay lock acquired", "token", lease.Token)
+			r.relay(lease.Context())
+			if err := lease.Unlock(); err != nil {
+				r.log.Warn("failed to release outbox relay lock", "error", err)
+			}
+		case !errors.Is(err, lock.ErrNotAcquired) && ctx.Err() == nil:
+			r.log.Error("failed to acquire outbox relay lock", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.cfg.PollInterval):
+		}
+	}
+}
+
+// relay publishes pending messages whenever it is notified and every poll
+// interval, picking up messages left behind by crashed replicas, until ctx
+// is done
+func (r *OutboxRelay) relay(ctx context.Context) {
+	poll := time.NewTicker(r.cfg.PollInterval)
+	defer poll.Stop()
+	purge := time.NewTicker(outboxPurgeInterval)
+	defer purge.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.wake:
+		case <-poll.C:
+		case <-purge.C:
+			r.purge(ctx)
+			continue
+		}
+
+		// Keep going while full batches come back so a backlog drains quickly
+		for {
+			n, err := r.Relay(ctx)
+			if err != nil && ctx.Err() == nil {
+				r.log.Error("failed to relay outbox messages", "error", err)
+			}
+			if err != nil || n < r.cfg.BatchSize {
+				break
+			}
+		}
+	}
+}
+
+// Relay publishes one batch of pending messages in order and returns how
+// many were published. It stops at the first failure so events about the
+// same todo are never delivered out of order, the failed message is retried
+// on the next run
+func (r *OutboxRelay) Relay(ctx context.Context) (int, error) {
+	published := 0
+	err := r.store.InTx(ctx, func(repo storage.TodoRepository) error {
+		pending, err := repo.PendingOutboxMessages(ctx, r.cfg.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, msg := range pending {
+			event := events.Event{
+				Key:        msg.IdempotencyKey,
+				Type:       msg.EventType,
+				UserID:     msg.UserID,
+				EntityID:   msg.EntityID,
+				Data:       msg.Payload,
+				OccurredAt: msg.CreatedAt.UTC(),
+			}
+
+			if err := r.publisher.Publish(ctx, msg.Topic, event); err != nil {
+				r.log.Warn("failed to publish outbox message, will retry",
+					"error", err, "id", msg.ID, "type", msg.EventType, "attempts", msg.Attempts+1)
+				return repo.MarkOutboxFailed(ctx, msg.ID, err.Error())
+			}
+			if err := repo.MarkOutboxPublished(ctx, msg.ID); err != nil {
+				return err
+			}
+			published++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return published, nil
+}
+
+func (r *OutboxRelay) purge(ctx context.Context) {
+	purged, err := r.store.PurgeOutbox(ctx, time.Now().Add(-r.cfg.Retention))
+	if err != nil {
+		if ctx.Err() == nil {
+			r.log.Error("failed to purge outbox", "error", err)
+		}
+		return
+	}
+	if purged > 0 {
+		r.log.Info("purged published outbox messages", "count", purged)
+	}
+}
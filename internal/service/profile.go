@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+// ProfileInput holds the fields of a partial profile update, nil fields are
+// left untouched. An AvatarID of 0 removes the avatar and Notifications only
+// change the settings they name
+type ProfileInput struct {
+	Name          *string
+	Timezone      *string
+	AvatarID      *int64
+	Notifications map[string]bool
+}
+
+// ProfileService manages the details users keep about themselves. Avatars
+// are image attachments of the user's todos, so they are uploaded, stored
+// and downloaded like any other attachment
+type ProfileService struct {
+	users       storage.AuthRepository
+	attachments storage.AttachmentRepository
+	audit       *AuditLogger
+}
+
+func NewProfileService(users storage.AuthRepository, attachments storage.AttachmentRepository, audit *AuditLogger) *ProfileService {
+	return &ProfileService{
+		users:       users,
+		attachments: attachments,
+		audit:       audit,
+	}
+}
+
+// Get returns the user's profile. An avatar whose todo is in the trash is
+// left out until the todo is restored
+func (s *ProfileService) Get(ctx context.Context, userID int64) (*models.Profile, error) {
+	profile, err := s.users.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadAvatar(ctx, profile); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// Update applies a partial update to the user's profile
+func (s *ProfileService) Update(ctx context.Context, userID int64, input ProfileInput) (*models.Profile, error) {
+	update := storage.ProfileUpdate{
+		AvatarID:      input.AvatarID,
+		Notifications: input.Notifications,
+	}
+	if input.Name != nil {
+		name := strings.TrimSpace(*input.Name)
+		if len(name) > maxNameLength {
+			return nil, invalidField("name", "name must be at most %d characters", maxNameLength)
+		}
+		update.Name = &name
+	}
+	if input.Timezone != nil {
+		if err := validateTimezone(*input.Timezone); err != nil {
+			return nil, err
+		}
+		update.Timezone = input.Timezone
+	}
+	if input.AvatarID != nil && *input.AvatarID != 0 {
+		if _, err := s.getAvatar(ctx, userID, *input.AvatarID); err != nil {
+			return nil, err
+		}
+	}
+	for setting := range input.Notifications {
+		if !slices.Contains(models.NotificationSettings, setting) {
+			return nil, invalidField("notifications", "unknown notification setting %q, expected one of %s",
+				setting, strings.Join(models.NotificationSettings, ", "))
+		}
+	}
+
+	profile, err := s.users.UpdateProfile(ctx, userID, update)
+	if errors.Is(err, storage.ErrNotFound) && input.AvatarID != nil {
+		// The avatar was deleted between the check and the update
+		return nil, invalidField("avatar_id", "attachment %d does not exist", *input.AvatarID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadAvatar(ctx, profile); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, AuditEntry{
+		UserID:     &userID,
+		Action:     "user.update_profile",
+		EntityType: EntityUser,
+		EntityID:   userID,
+		After:      profile,
+	})
+	return profile, nil
+}
+
+// loadAvatar fills in the attachment of the profile's avatar when it is
+// available
+func (s *ProfileService) loadAvatar(ctx context.Context, profile *models.Profile) error {
+	if profile.AvatarID == nil {
+		return nil
+	}
+
+	avatar, err := s.attachments.GetUserAttachment(ctx, profile.UserID, *profile.AvatarID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if avatar.Status == models.AttachmentReady {
+		profile.Avatar = avatar
+	}
+	return nil
+}
+
+// getAvatar returns an attachment that may become the user's avatar: a ready
+// image of one of their todos
+func (s *ProfileService) getAvatar(ctx context.Context, userID, id int64) (*models.Attachment, error) {
+	attachment, err := s.attachments.GetUserAttachment(ctx, userID, id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, invalidField("avatar_id", "attachment %d does not exist", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if attachment.Status != models.AttachmentReady {
+		return nil, invalidField("avatar_id", "attachment %d has not been uploaded yet", id)
+	}
+	if !strings.HasPrefix(attachment.ContentType, "image/") {
+		return nil, invalidField("avatar_id", "attachment %d is not an image", id)
+	}
+	return attachment, nil
+}
+
+// validateTimezone accepts IANA time zone names such as Europe/Berlin
+func validateTimezone(name string) error {
+	if name == "" || name == "Local" {
+		return invalidField("timezone", "timezone must be an IANA time zone name")
+	}
+	if _, err := time.LoadLocation(name); err != nil {
+		return invalidField("timezone", "unknown timezone %q", name)
+	}
+	return nil
+}
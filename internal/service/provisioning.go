@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/session"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+// maxExternalIDLength matches the external_id column
+const maxExternalIDLength = 255
+
+// UserProvisioning creates, updates and deactivates accounts on behalf of
+// an identity provider. Provisioned accounts have a verified email and no
+// password, their users sign in through the provider or choose a password
+// with a reset link. Like those of UserAdmin, audit entries have no user
+type UserProvisioning struct {
+	store    storage.AuthRepository
+	sessions session.Store // nil unless cookie sessions are enabled
+	audit    *AuditLogger
+	log      logger.Logger
+}
+
+func NewUserProvisioning(store storage.AuthRepository, sessions session.Store, audit *AuditLogger, log logger.Logger) *UserProvisioning {
+	return &UserProvisioning{store: store, sessions: sessions, audit: audit, log: log}
+}
+
+// ProvisionInput is the state of a provisioned account, replacing the one
+// stored before
+type ProvisionInput struct {
+	Email      string
+	Name       string
+	ExternalID string
+	Active     bool
+}
+
+// provisionedFields is the audited state of a provisioned account
+type provisionedFields struct {
+	Email      string `json:"email"`
+	Name       string `json:"name"`
+	ExternalID string `json:"external_id"`
+	Active     bool   `json:"active"`
+}
+
+// List returns a page of the accounts matching the filter ordered by id, and
+// the number of matching accounts
+func (s *UserProvisioning) List(ctx context.Context, filter storage.UserFilter, limit, offset int) ([]*models.User, int, error) {
+	filter.Email = normalizeEmail(filter.Email)
+	return s.store.ListUsers(ctx, filter, limit, offset)
+}
+
+// Get returns the account with the id
+func (s *UserProvisioning) Get(ctx context.Context, id int64) (*models.User, error) {
+	return s.store.GetUserByID(ctx, id)
+}
+
+// Create creates an account, returning storage.ErrConflict when the email
+// or external id is taken
+func (s *UserProvisioning) Create(ctx context.Context, input ProvisionInput) (*models.User, error) {
+	input, err := validateProvisionInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var user *models.User
+	err = s.store.InTx(ctx, func(repo storage.AuthRepository) error {
+		created := &models.User{Email: input.Email, Name: input.Name, ExternalID: input.ExternalID}
+		if err := repo.CreateUser(ctx, created); err != nil {
+			return err
+		}
+		if err := repo.MarkEmailVerified(ctx, created.ID); err != nil {
+			return err
+		}
+		if !input.Active {
+			if err := repo.SetDeactivated(ctx, created.ID, true); err != nil {
+				return err
+			}
+		}
+		user, err = repo.GetUserByID(ctx, created.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, AuditEntry{
+		Action:     "user.provision",
+		EntityType: EntityUser,
+		EntityID:   user.ID,
+		After:      fieldsOf(user),
+	})
+	return user, nil
+}
+
+// Replace replaces the state of the account with the id. Deactivating it
+// revokes its sessions, so its tokens stop working at once
+func (s *UserProvisioning) Replace(ctx context.Context, id int64, input ProvisionInput) (*models.User, error) {
+	input, err := validateProvisionInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var before, user *models.User
+	err = s.store.InTx(ctx, func(repo storage.AuthRepository) error {
+		before, err = repo.GetUserByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		updated := *before
+		updated.Email, updated.Name, updated.ExternalID = input.Email, input.Name, input.ExternalID
+		if err := repo.UpdateUser(ctx, &updated); err != nil {
+			return err
+		}
+		if input.Active == before.IsDeactivated() {
+			if err := repo.SetDeactivated(ctx, id, !input.Active); err != nil {
+				return err
+			}
+		}
+		if !input.Active {
+			if err := repo.RevokeUserSessions(ctx, id); err != nil {
+				return err
+			}
+		}
+		user, err = repo.GetUserByID(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !input.Active {
+		s.endCookieSessions(ctx, id)
+	}
+
+	if fieldsOf(before) != fieldsOf(user) {
+		s.audit.Record(ctx, AuditEntry{
+			Action:     "user.provision_update",
+			EntityType: EntityUser,
+			EntityID:   user.ID,
+			Before:     fieldsOf(before),
+			After:      fieldsOf(user),
+		})
+	}
+	return user, nil
+}
+
+// Deactivate deactivates the account with the id and revokes its sessions,
+// keeping everything else
+func (s *UserProvisioning) Deactivate(ctx context.Context, id int64) error {
+	user, err := s.store.GetUserByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	_, err = s.Replace(ctx, id, ProvisionInput{Email: user.Email, Name: user.Name, ExternalID: user.ExternalID})
+	return err
+}
+
+// endCookieSessions ends the cookie sessions of a deactivated account, they
+// are not kept in the database. Failures are logged, the account cannot
+// sign in again either way
+func (s *UserProvisioning) endCookieSessions(ctx context.Context, userID int64) {
+	if err := session.EndUserSessions(ctx, s.sessions, userID); err != nil {
+		logger.FromContext(ctx, s.log).Error("failed to end cookie sessions of deactivated user", "user_id", userID, "error", err)
+	}
+}
+
+func validateProvisionInput(input ProvisionInput) (ProvisionInput, error) {
+	input.Email = normalizeEmail(input.Email)
+	if err := validateEmail(input.Email); err != nil {
+		return input, err
+	}
+	input.Name = strings.TrimSpace(input.Name)
+	if len(input.Name) > maxNameLength {
+		return input, invalidField("name", "name must be at most %d characters", maxNameLength)
+	}
+	input.ExternalID = strings.TrimSpace(input.ExternalID)
+	if len(input.ExternalID) > maxExternalIDLength {
+		return input, invalidField("external_id", "external_id must be at most %d characters", maxExternalIDLength)
+	}
+	return input, nil
+}
+
+func fieldsOf(user *models.User) provisionedFields {
+	return provisionedFields{
+		Email:      user.Email,
+		Name:       user.Name,
+		ExternalID: user.ExternalID,
+		Active:     !user.IsDeactivated(),
+	}
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/queue"
+)
+
+// QueueService inspects the task queues and their dead letter queues
+type QueueService struct {
+	client     *queue.Client
+	pagination config.PaginationConfig
+}
+
+func NewQueueService(client *queue.Client, pagination config.PaginationConfig) *QueueService {
+	return &QueueService{client: client, pagination: pagination}
+}
+
+// DeadTaskPage is a single page of dead tasks
+type DeadTaskPage struct {
+	Tasks    []*queue.Task
+	Page     int
+	PageSize int
+	Total    int
+}
+
+// TotalPages returns the number of pages available with the current page size
+func (p *DeadTaskPage) TotalPages() int {
+	return totalPages(p.Total, p.PageSize)
+}
+
+// Stats counts the tasks of every queue by state
+func (s *QueueService) Stats(ctx context.Context) ([]queue.Stats, error) {
+	return s.client.Stats(ctx)
+}
+
+// ListDead returns the requested page of the dead tasks of a queue, most
+// recent failures first
+func (s *QueueService) ListDead(ctx context.Context, name string, page, pageSize int) (*DeadTaskPage, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = s.pagination.DefaultLimit
+	}
+	pageSize = min(pageSize, s.pagination.MaxLimit)
+
+	tasks, total, err := s.client.ListDead(ctx, name, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeadTaskPage{
+		Tasks:    tasks,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	}, nil
+}
+
+// RetryDead queues a dead task again with its retries reset
+func (s *QueueService) RetryDead(ctx context.Context, name, id string) error {
+	return s.client.RetryDead(ctx, name, id)
+}
+
+// DeleteDead removes a dead task for good
+func (s *QueueService) DeleteDead(ctx context.Context, name, id string) error {
+	return s.client.DeleteDead(ctx, name, id)
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/quota"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+// Quota resources reported by QuotaExceededError
+const (
+	QuotaTodos           = "todos"
+	QuotaAttachmentBytes = "attachment_bytes"
+)
+
+// QuotaExceededError is returned when an operation would take the user over
+// one of their quotas
+type QuotaExceededError struct {
+	Resource string
+	Limit    int64
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s quota of %d exceeded", e.Resource, e.Limit)
+}
+
+// QuotaReport is the usage of each quota of a user next to its limit
+type QuotaReport struct {
+	Limits models.QuotaLimits `json:"limits"`
+	Usage  models.QuotaUsage  `json:"usage"`
+
+	// ResetsAt is when the API call count starts over
+	ResetsAt time.Time `json:"resets_at"`
+
+	// Override is only reported to admins
+	Override *models.QuotaOverride `json:"override,omitempty"`
+}
+
+// QuotaService enforces the quotas of users. A nil *QuotaService enforces
+// nothing, so quotas can be disabled. The todo quota is checked in the
+// transaction creating the todo and holds; attachment storage is read
+// without a lock, concurrent uploads may take a user slightly over it
+type QuotaService struct {
+	overrides storage.QuotaRepository
+	users     storage.UserRepository
+	todos     storage.TodoRepository
+	calls     quota.Counter
+	cfg       config.QuotasConfig
+	audit     *AuditLogger
+	log       logger.Logger
+
+	mu     sync.Mutex
+	limits map[int64]cachedLimits
+}
+
+type cachedLimits struct {
+	limits  models.QuotaLimits
+	expires time.Time
+}
+
+func NewQuotaService(
+	overrides storage.QuotaRepository,
+	users storage.UserRepository,
+	todos storage.TodoRepository,
+	calls quota.Counter,
+	cfg config.QuotasConfig,
+	audit *AuditLogger,
+	log logger.Logger,
+) *QuotaService {
+	return &QuotaService{
+		overrides: overrides,
+		users:     users,
+		todos:     todos,
+		calls:     calls,
+		cfg:       cfg,
+		audit:     audit,
+		log:       log,
+		limits:    make(map[int64]cachedLimits),
+	}
+}
+
+// Limits returns the limits of the user, the configured ones with the user's
+// override applied. Overrides are cached for the configured TTL
+func (s *QuotaService) Limits(ctx context.Context, userID int64) (models.QuotaLimits, error) {
+	now := time.Now()
+	s.mu.Lock()
+	cached, ok := s.limits[userID]
+	s.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.limits, nil
+	}
+
+	limits := models.QuotaLimits{
+		MaxTodos:           s.cfg.MaxTodos,
+		MaxAttachmentBytes: s.cfg.MaxAttachmentBytes,
+		MaxAPICallsPerDay:  s.cfg.MaxAPICallsPerDay,
+	}
+	override, err := s.overrides.GetQuotaOverride(ctx, userID)
+	switch {
+	case err == nil:
+		limits = override.Apply(limits)
+	case !errors.Is(err, storage.ErrNotFound):
+		return models.QuotaLimits{}, err
+	}
+
+	s.mu.Lock()
+	// Entries of users who stopped calling are dropped on the way
+	for id, entry := range s.limits {
+		if !now.Before(entry.expires) {
+			delete(s.limits, id)
+		}
+	}
+	s.limits[userID] = cachedLimits{limits: limits, expires: now.Add(s.cfg.OverrideCacheTTL)}
+	s.mu.Unlock()
+	return limits, nil
+}
+
+// CheckTodos returns a QuotaExceededError when the user may not create n
+// more todos. It must run in the transaction that creates them, repo locks
+// the user until it commits
+func (s *QuotaService) CheckTodos(ctx context.Context, repo storage.TodoRepository, userID int64, n int) error {
+	if s == nil {
+		return nil
+	}
+
+	limits, err := s.Limits(ctx, userID)
+	if err != nil || limits.MaxTodos == 0 {
+		return err
+	}
+
+	count, err := repo.CountLocked(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if count+n > limits.MaxTodos {
+		return &QuotaExceededError{Resource: QuotaTodos, Limit: int64(limits.MaxTodos)}
+	}
+	return nil
+}
+
+// CheckAttachmentBytes returns a QuotaExceededError when storing size more
+// bytes would take the user over their attachment storage
+func (s *QuotaService) CheckAttachmentBytes(ctx context.Context, userID, size int64) error {
+	if s == nil {
+		return nil
+	}
+
+	limits, err := s.Limits(ctx, userID)
+	if err != nil || limits.MaxAttachmentBytes == 0 {
+		return err
+	}
+
+	used, err := s.todos.AttachmentBytes(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if used+size > limits.MaxAttachmentBytes {
+		return &QuotaExceededError{Resource: QuotaAttachmentBytes, Limit: limits.MaxAttachmentBytes}
+	}
+	return nil
+}
+
+// CountCall records an API call of the user and reports whether it is within
+// their daily quota. Calls over the quota are counted too
+func (s *QuotaService) CountCall(ctx context.Context, userID int64) (quota.Result, error) {
+	limits, err := s.Limits(ctx, userID)
+	if err != nil {
+		return quota.Result{}, err
+	}
+	result := quota.Result{Allowed: true, Limit: int64(limits.MaxAPICallsPerDay), ResetAt: quota.ResetAt(time.Now())}
+	if result.Limit == 0 {
+		return result, nil
+	}
+
+	count, err := s.calls.Incr(ctx, userID)
+	if err != nil {
+		return quota.Result{}, err
+	}
+	result.Allowed = count <= result.Limit
+	result.Remaining = max(result.Limit-count, 0)
+	return result, nil
+}
+
+// Report returns the user's usage of each quota
+func (s *QuotaService) Report(ctx context.Context, userID int64) (*QuotaReport, error) {
+	limits, err := s.Limits(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &QuotaReport{Limits: limits, ResetsAt: quota.ResetAt(time.Now())}
+	if report.Usage.Todos, err = s.todos.Count(ctx, userID); err != nil {
+		return nil, err
+	}
+	if report.Usage.AttachmentBytes, err = s.todos.AttachmentBytes(ctx, userID); err != nil {
+		return nil, err
+	}
+	if report.Usage.APICallsToday, err = s.calls.Count(ctx, userID); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// AdminReport returns the usage of a user together with their override
+func (s *QuotaService) AdminReport(ctx context.Context, userID int64) (*QuotaReport, error) {
+	if _, err := s.users.GetUserByID(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	report, err := s.Report(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	override, err := s.overrides.GetQuotaOverride(ctx, userID)
+	switch {
+	case err == nil:
+		report.Override = override
+	case !errors.Is(err, storage.ErrNotFound):
+		return nil, err
+	}
+	return report, nil
+}
+
+// SetOverride replaces the limits of a user, nil limits keep the configured
+// ones and zero lifts a limit. Other replicas apply it once their cached
+// limits expire
+func (s *QuotaService) SetOverride(ctx context.Context, override *models.QuotaOverride) error {
+	switch {
+	case override.MaxTodos != nil && *override.MaxTodos < 0:
+		return invalidField("max_todos", "must not be negative")
+	case override.MaxAttachmentBytes != nil && *override.MaxAttachmentBytes < 0:
+		return invalidField("max_attachment_bytes", "must not be negative")
+	case override.MaxAPICallsPerDay != nil && *override.MaxAPICallsPerDay < 0:
+		return invalidField("max_api_calls_per_day", "must not be negative")
+	}
+	if _, err := s.users.GetUserByID(ctx, override.UserID); err != nil {
+		return err
+	}
+
+	before, err := s.overrides.GetQuotaOverride(ctx, override.UserID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return err
+	}
+	if err := s.overrides.SetQuotaOverride(ctx, override); err != nil {
+		return err
+	}
+
+	s.forget(override.UserID)
+	s.audit.Record(ctx, AuditEntry{
+		Action:     "quota.override",
+		EntityType: EntityQuota,
+		EntityID:   override.UserID,
+		Before:     before,
+		After:      override,
+	})
+	return nil
+}
+
+// ClearOverride restores the configured limits of a user
+func (s *QuotaService) ClearOverride(ctx context.Context, userID int64) error {
+	before, err := s.overrides.GetQuotaOverride(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.overrides.DeleteQuotaOverride(ctx, userID); err != nil {
+		return err
+	}
+
+	s.forget(userID)
+	s.audit.Record(ctx, AuditEntry{
+		Action:     "quota.clear",
+		EntityType: EntityQuota,
+		EntityID:   userID,
+		Before:     before,
+	})
+	return nil
+}
+
+// forget drops the cached limits of a user
+func (s *QuotaService) forget(userID int64) {
+	s.mu.Lock()
+	delete(s.limits, userID)
+	s.mu.Unlock()
+}
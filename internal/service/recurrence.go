@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/recurrence"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+const (
+	// recurrenceBatchSize is how many todos MaterializeRecurrences reads per
+	// query
+	recurrenceBatchSize = 100
+
+	// defaultOccurrences and maxOccurrences bound how many occurrences
+	// Occurrences previews
+	defaultOccurrences = 5
+	maxOccurrences     = 50
+)
+
+// Occurrences previews up to n occurrences of a repeating todo owned by the
+// user that follow the current one, zero selects the default. Overdue todos
+// continue from now, as they do once completed. Todos that do not repeat
+// have none
+func (s *TodoService) Occurrences(ctx context.Context, userID, id int64, n int) ([]time.Time, error) {
+	todo, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if todo.Recurrence == "" || todo.DueDate == nil {
+		return []time.Time{}, nil
+	}
+
+	rule, err := recurrence.Parse(todo.Recurrence)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse recurrence of todo %d: %w", todo.ID, err)
+	}
+	if n <= 0 {
+		n = defaultOccurrences
+	}
+	return rule.Upcoming(*todo.DueDate, occurrenceAfter(*todo.DueDate, time.Now()), min(n, maxOccurrences)), nil
+}
+
+// MaterializeRecurrences creates the next occurrence of the repeating todos
+// that were completed or whose due date passed and returns how many it
+// created. Completing a todo creates its next occurrence right away, the job
+// catches the todos completed along with their sub-tasks and those left open
+// past their due date, which keep their place but no longer repeat
+func (s *TodoService) MaterializeRecurrences(ctx context.Context) (int, error) {
+	var created int
+	for {
+		now := time.Now()
+		todos, err := s.store.DueRecurrences(ctx, now, recurrenceBatchSize)
+		if err != nil {
+			return created, err
+		}
+
+		for _, todo := range todos {
+			next, err := s.recur(ctx, todo.UserID, todo.ID, now)
+			if err != nil {
+				return created, err
+			}
+			if next != nil {
+				created++
+			}
+		}
+		if len(todos) < recurrenceBatchSize {
+			return created, nil
+		}
+	}
+}
+
+// recur moves the recurrence rule of a todo listed by DueRecurrences to its
+// next occurrence, unless the todo changed since
+func (s *TodoService) recur(ctx context.Context, userID, id int64, now time.Time) (*models.Todo, error) {
+	var todo, before, next *models.Todo
+	err := s.store.InTx(ctx, func(repo storage.TodoRepository) error {
+		var err error
+		todo, err = repo.GetByID(ctx, userID, id)
+		if err != nil {
+			return err
+		}
+		if todo.Recurrence == "" || !todo.Completed && todo.DueDate != nil && !todo.DueDate.Before(now) {
+			return nil
+		}
+		snapshot := *todo
+		before = &snapshot
+
+		if next, err = nextOccurrence(todo, now); err != nil {
+			return err
+		}
+		if err := repo.Update(ctx, todo); err != nil {
+			return err
+		}
+		if err := s.enqueue(ctx, repo, TodoUpdated, todo); err != nil {
+			return err
+		}
+		return s.createOccurrence(ctx, repo, next, todo.Tags)
+	})
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil || before == nil {
+		return nil, err
+	}
+
+	s.cache.Invalidate(ctx, userID)
+	s.record(ctx, "todo.update", before, todo)
+	if next != nil {
+		s.record(ctx, "todo.create", nil, next)
+	}
+	s.outbox.Notify()
+	return next, nil
+}
+
+// nextOccurrence moves the recurrence rule of a todo to a new todo for its
+// next occurrence, which is not stored yet. A reminder keeps its distance to
+// the due date. It returns nil when the todo does not repeat or its rule has
+// ended
+func nextOccurrence(todo *models.Todo, now time.Time) (*models.Todo, error) {
+	if todo.Recurrence == "" || todo.DueDate == nil {
+		return nil, nil
+	}
+
+	rule, err := recurrence.Parse(todo.Recurrence)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse recurrence of todo %d: %w", todo.ID, err)
+	}
+	todo.Recurrence = ""
+
+	due, rest, ok := rule.Next(*todo.DueDate, occurrenceAfter(*todo.DueDate, now))
+	if !ok {
+		return nil, nil
+	}
+	next := &models.Todo{
+		UserID:      todo.UserID,
+		ParentID:    todo.ParentID,
+		Title:       todo.Title,
+		Description: todo.Description,
+		DueDate:     &due,
+		Recurrence:  rest.String(),
+		Priority:    todo.Priority,
+	}
+	if todo.RemindAt != nil {
+		remindAt := due.Add(todo.RemindAt.Sub(*todo.DueDate))
+		next.RemindAt = &remindAt
+	}
+	return next, nil
+}
+
+// createOccurrence stores the next occurrence of a repeating todo with the
+// tags of the previous one, nothing when next is nil
+func (s *TodoService) createOccurrence(ctx context.Context, repo storage.TodoRepository, next *models.Todo, tags []string) error {
+	if next == nil {
+		return nil
+	}
+
+	if err := repo.Create(ctx, next); err != nil {
+		return err
+	}
+	next.Tags = append([]string{}, tags...)
+	if err := repo.SetTodoTags(ctx, next.UserID, next.ID, next.Tags); err != nil {
+		return err
+	}
+	if err := s.rollup(ctx, repo, next.UserID, next.ParentID); err != nil {
+		return err
+	}
+	return s.enqueue(ctx, repo, TodoCreated, next)
+}
+
+// occurrenceAfter returns the time the next occurrence of a todo due at due
+// must fall after, so completing an overdue todo does not create another
+// overdue one
+func occurrenceAfter(due, now time.Time) time.Time {
+	if now.After(due) {
+		return now
+	}
+	return due
+}
+
+// validateRecurrence checks the recurrence rule of a todo, which needs a due
+// date to start from, and brings it into canonical form
+func validateRecurrence(todo *models.Todo) error {
+	if todo.Recurrence == "" {
+		return nil
+	}
+
+	rule, err := recurrence.Parse(todo.Recurrence)
+	if err != nil {
+		return invalidField("recurrence", "recurrence must be a valid RRULE: %v", err)
+	}
+	if todo.DueDate == nil {
+		return invalidField("recurrence", "recurrence needs a due date to repeat from")
+	}
+	todo.Recurrence = rule.String()
+	return nil
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/notifications"
+	"github.com/MuthuM3/gin-microservice-template/internal/queue"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+// TaskReminder is the queue task type sending the reminder a user set on a
+// todo
+const TaskReminder = "reminders:send"
+
+// localTimeLayouts are the accepted forms of reminder times without a UTC
+// offset, which are read in the timezone of the user
+var localTimeLayouts = []string{"2006-01-02T15:04:05", "2006-01-02T15:04"}
+
+// ReminderService schedules the reminders users set on their todos. The
+// reminders job dispatches the reminders falling due within the lookahead
+// as delayed queue tasks, so a worker sends them on time and they survive
+// restarts of both. Without a queue the job sends the reminders that are due
+// itself. Reminders of completed or trashed todos are not sent
+type ReminderService struct {
+	store    storage.TodoRepository
+	users    storage.AuthRepository
+	todos    *TodoService
+	notifier *NotificationService
+	queue    *queue.Client
+	cfg      config.NotificationsConfig
+	log      logger.Logger
+}
+
+func NewReminderService(
+	store storage.TodoRepository,
+	users storage.AuthRepository,
+	todos *TodoService,
+	notifier *NotificationService,
+	queue *queue.Client,
+	cfg config.NotificationsConfig,
+	log logger.Logger,
+) *ReminderService {
+	return &ReminderService{
+		store:    store,
+		users:    users,
+		todos:    todos,
+		notifier: notifier,
+		queue:    queue,
+		cfg:      cfg,
+		log:      log,
+	}
+}
+
+// reminderTask is the payload of a TaskReminder task
+type reminderTask struct {
+	UserID   int64     `json:"user_id"`
+	TodoID   int64     `json:"todo_id"`
+	RemindAt time.Time `json:"remind_at"`
+}
+
+// Set sets when the user wants to be reminded of a todo. Times without a UTC
+// offset, such as 2026-11-02T09:00, are read in the timezone of the user's
+// profile
+func (s *ReminderService) Set(ctx context.Context, userID, id int64, at string) (*models.Todo, error) {
+	profile, err := s.users.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	remindAt, err := parseReminderTime(at, profile.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	return s.todos.SetReminder(ctx, userID, id, &remindAt)
+}
+
+// Clear removes the reminder of a todo
+func (s *ReminderService) Clear(ctx context.Context, userID, id int64) error {
+	_, err := s.todos.SetReminder(ctx, userID, id, nil)
+	return err
+}
+
+// Dispatch queues the reminders due within the lookahead as delayed tasks,
+// or sends the reminders that are due when there is no queue, and returns
+// how many it dispatched
+func (s *ReminderService) Dispatch(ctx context.Context) (int, error) {
+	now := time.Now()
+	before := now
+	if s.queue != nil {
+		before = now.Add(s.cfg.ReminderLookahead)
+	}
+
+	var dispatched int
+	for {
+		todos, err := s.store.PendingReminders(ctx, before, reminderBatchSize)
+		if err != nil {
+			return dispatched, err
+		}
+
+		ids := make([]int64, 0, len(todos))
+		for _, todo := range todos {
+			if err = s.dispatch(ctx, todo, now); err != nil {
+				break
+			}
+			ids = append(ids, todo.ID)
+		}
+
+		// Reminders dispatched before a failure must not be dispatched again
+		if markErr := s.store.MarkRemindersDispatched(ctx, ids); markErr != nil {
+			return dispatched, markErr
+		}
+		dispatched += len(ids)
+		if err != nil {
+			return dispatched, err
+		}
+		if len(todos) < reminderBatchSize {
+			return dispatched, nil
+		}
+	}
+}
+
+func (s *ReminderService) dispatch(ctx context.Context, todo *models.Todo, now time.Time) error {
+	if s.queue == nil {
+		return s.send(ctx, todo)
+	}
+
+	task := reminderTask{UserID: todo.UserID, TodoID: todo.ID, RemindAt: *todo.RemindAt}
+	if _, err := s.queue.Enqueue(ctx, TaskReminder, task, queue.WithDelay(todo.RemindAt.Sub(now))); err != nil {
+		return fmt.Errorf("failed to queue reminder of todo %d: %w", todo.ID, err)
+	}
+	return nil
+}
+
+// HandleTask sends a queued reminder. Reminders of todos that were deleted,
+// completed or given another reminder since are dropped
+func (s *ReminderService) HandleTask(ctx context.Context, task *queue.Task) error {
+	var payload reminderTask
+	if err := json.Unmarshal(task.Payload, &payload); err != nil {
+		return fmt.Errorf("%w: invalid reminder task: %v", queue.ErrSkipRetry, err)
+	}
+
+	todo, err := s.store.GetByID(ctx, payload.UserID, payload.TodoID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if todo.Completed || todo.RemindAt == nil || !todo.RemindAt.Equal(payload.RemindAt) {
+		return nil
+	}
+	return s.send(ctx, todo)
+}
+
+// send reminds the user of the todo unless they turned off reminders
+func (s *ReminderService) send(ctx context.Context, todo *models.Todo) error {
+	profile, err := s.users.GetProfile(ctx, todo.UserID)
+	if err != nil {
+		return err
+	}
+	if !profile.Notifications.Enabled(NotificationDueReminder) {
+		return nil
+	}
+	return s.notifier.send(ctx, profile, todoReminder(todo, profile.Timezone))
+}
+
+// SetReminder sets or, with a nil remindAt, clears the reminder of a todo
+// owned by the user
+func (s *TodoService) SetReminder(ctx context.Context, userID, id int64, remindAt *time.Time) (*models.Todo, error) {
+	var todo, before *models.Todo
+	err := s.store.InTx(ctx, func(repo storage.TodoRepository) error {
+		var err error
+		before, err = repo.GetByID(ctx, userID, id)
+		if err != nil {
+			return err
+		}
+		if err := repo.SetReminder(ctx, userID, id, remindAt); err != nil {
+			return err
+		}
+		if todo, err = repo.GetByID(ctx, userID, id); err != nil {
+			return err
+		}
+		return s.enqueue(ctx, repo, TodoUpdated, todo)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache.Invalidate(ctx, userID)
+	s.record(ctx, "todo.update", before, todo)
+	s.outbox.Notify()
+	return todo, nil
+}
+
+// todoReminder builds the reminder a user set on a todo, showing times in
+// the user's timezone
+func todoReminder(todo *models.Todo, timezone string) notifications.Notification {
+	loc := userLocation(timezone)
+	body := fmt.Sprintf("You asked to be reminded of your todo %q.", todo.Title)
+	data := map[string]any{
+		"todo_id":   todo.ID,
+		"title":     todo.Title,
+		"remind_at": todo.RemindAt.UTC(),
+	}
+	if todo.DueDate != nil {
+		body = fmt.Sprintf("You asked to be reminded of your todo %q, due %s.", todo.Title, todo.DueDate.In(loc).Format(reminderTimeFormat))
+		data["due_date"] = todo.DueDate.UTC()
+	}
+
+	return notifications.Notification{
+		Type:    NotificationDueReminder,
+		Subject: "Reminder: " + todo.Title,
+		Body:    body,
+		Data:    data,
+	}
+}
+
+// parseReminderTime reads a reminder time given in RFC 3339 or, without a
+// UTC offset, as a wall clock time in the timezone
+func parseReminderTime(raw, timezone string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if at, err := time.Parse(time.RFC3339, raw); err == nil {
+		return at.UTC(), nil
+	}
+	for _, layout := range localTimeLayouts {
+		if at, err := time.ParseInLocation(layout, raw, userLocation(timezone)); err == nil {
+			return at.UTC(), nil
+		}
+	}
+	return time.Time{}, invalidField("remind_at", "remind_at must be a time such as 2026-11-02T09:00:00Z, or 2026-11-02T09:00 in your timezone")
+}
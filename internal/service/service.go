@@ -0,0 +1,34 @@
+// Package service implements the business logic of the API independently of
+// any transport. Handlers only translate requests and responses, every input
+// rule is enforced here so all transports validate the same way
+package service
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidInput is returned when the input to a service method fails validation
+var ErrInvalidInput = errors.New("invalid input")
+
+// FieldError is a validation failure of a single input field. It matches
+// ErrInvalidInput so transports can map it like any other invalid input
+// while still pointing clients at the offending field
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return ErrInvalidInput.Error() + ": " + e.Message
+}
+
+// Is makes errors.Is(err, ErrInvalidInput) hold for field errors
+func (e *FieldError) Is(target error) bool {
+	return target == ErrInvalidInput
+}
+
+// invalidField returns a *FieldError for field with a formatted message
+func invalidField(field, format string, args ...any) error {
+	return &FieldError{Field: field, Message: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/auth"
+	"github.com/MuthuM3/gin-microservice-template/internal/cache"
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/session"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+const (
+	// sessionTokenBytes is the entropy of session cookies
+	sessionTokenBytes = 32
+
+	// sessionTouchInterval is how often the activity of a session is
+	// saved, sliding its expiry, rather than on every request
+	sessionTouchInterval = time.Minute
+
+	maxUserAgentLength = 255
+)
+
+// SessionService manages the cookie sessions of browser clients. Sessions
+// slide: each request extends them by the session TTL of the cache tiers,
+// up to the session timeout after login
+type SessionService struct {
+	store    session.Store
+	auth     *AuthService
+	tiers    *cache.Tiers
+	security *config.SecurityConfig
+	audit    *AuditLogger
+}
+
+func NewSessionService(store session.Store, authService *AuthService, tiers *cache.Tiers, security *config.SecurityConfig, audit *AuditLogger) *SessionService {
+	return &SessionService{store: store, auth: authService, tiers: tiers, security: security, audit: audit}
+}
+
+// SessionLogin is returned after a successful login. Token is the cookie
+// value, only its hash is stored
+type SessionLogin struct {
+	User    *models.User
+	Session *session.Session
+	Token   string
+}
+
+// Login verifies the credentials like AuthService.Login and starts a
+// session for the client
+func (s *SessionService) Login(ctx context.Context, email, password, clientIP, userAgent string) (*SessionLogin, error) {
+	user, err := s.auth.Authenticate(ctx, email, password, clientIP)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := auth.RandomToken(sessionTokenBytes)
+	if err != nil {
+		return nil, err
+	}
+	csrfToken, err := auth.RandomToken(s.security.CSRFTokenLength)
+	if err != nil {
+		return nil, err
+	}
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	now := time.Now().UTC()
+	sess := &session.Session{
+		ID:         auth.HashToken(token),
+		UserID:     user.ID,
+		CSRFToken:  csrfToken,
+		IPAddress:  clientIP,
+		UserAgent:  userAgent,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(s.security.SessionTimeout),
+	}
+	if err := s.store.Save(ctx, sess, s.ttl(sess, now)); err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, "session.create", nil, sess)
+	return &SessionLogin{User: user, Session: sess, Token: token}, nil
+}
+
+// Authenticate returns the session of a cookie and slides its expiry. It
+// returns session.ErrNotFound when the session is unknown, expired or
+// revoked. The user is checked again whenever the session is touched, the
+// sessions of erased or deactivated users end within sessionTouchInterval
+// even if ending them failed
+func (s *SessionService) Authenticate(ctx context.Context, token string) (*session.Session, error) {
+	if token == "" {
+		return nil, session.ErrNotFound
+	}
+	sess, err := s.store.Get(ctx, auth.HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if !now.Before(sess.ExpiresAt) {
+		return nil, session.ErrNotFound
+	}
+	if now.Sub(sess.LastSeenAt) >= sessionTouchInterval {
+		user, err := s.auth.store.GetUserByID(ctx, sess.UserID)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return nil, err
+		}
+		if user == nil || user.IsDeactivated() {
+			if err := s.store.Delete(ctx, sess.UserID, sess.ID); err != nil {
+				return nil, err
+			}
+			return nil, session.ErrNotFound
+		}
+
+		sess.LastSeenAt = now
+		if err := s.store.Touch(ctx, sess, s.ttl(sess, now)); err != nil {
+			return nil, err
+		}
+	}
+	return sess, nil
+}
+
+// CheckCSRF reports whether token is the CSRF token of the session. It
+// always holds when CSRF protection is disabled
+func (s *SessionService) CheckCSRF(sess *session.Session, token string) bool {
+	if !s.security.CSRFEnabled {
+		return true
+	}
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(sess.CSRFToken)) == 1
+}
+
+// List returns the active sessions of the user, most recently used first
+func (s *SessionService) List(ctx context.Context, userID int64) ([]*session.Session, error) {
+	sessions, err := s.store.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(sessions, func(a, b *session.Session) int {
+		return b.LastSeenAt.Compare(a.LastSeenAt)
+	})
+	return sessions, nil
+}
+
+// Revoke ends a session of the user, on whichever device it is used
+func (s *SessionService) Revoke(ctx context.Context, userID int64, id string) error {
+	sess, err := s.store.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, session.ErrNotFound) {
+			return storage.ErrNotFound
+		}
+		return err
+	}
+	if sess.UserID != userID {
+		return storage.ErrNotFound
+	}
+
+	if err := s.store.Delete(ctx, userID, id); err != nil {
+		return err
+	}
+	s.record(ctx, "session.revoke", sess, nil)
+	return nil
+}
+
+// Logout ends the session of the request like Revoke and records the
+// logout in the security log
+func (s *SessionService) Logout(ctx context.Context, userID int64, id string) error {
+	if err := s.Revoke(ctx, userID, id); err != nil {
+		return err
+	}
+
+	s.auth.events.Record(ctx, SecurityEntry{
+		Type:    SecurityLogout,
+		UserID:  &userID,
+		Details: map[string]string{"session_id": id},
+	})
+	return nil
+}
+
+// RevokeOthers ends every session of the user but the current one, logging
+// out their other devices. It returns the number of sessions ended
+func (s *SessionService) RevokeOthers(ctx context.Context, userID int64, currentID string) (int, error) {
+	sessions, err := s.store.List(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]string, 0, len(sessions))
+	for _, sess := range sessions {
+		if sess.ID != currentID {
+			ids = append(ids, sess.ID)
+		}
+	}
+	if err := s.store.Delete(ctx, userID, ids...); err != nil {
+		return 0, err
+	}
+
+	for _, sess := range sessions {
+		if sess.ID != currentID {
+			s.record(ctx, "session.revoke", sess, nil)
+		}
+	}
+	return len(ids), nil
+}
+
+// ttl returns how long the session lives without further requests
+func (s *SessionService) ttl(sess *session.Session, now time.Time) time.Duration {
+	return min(s.tiers.TTL(cache.TierSession), sess.ExpiresAt.Sub(now))
+}
+
+// record audits a change to a session, the CSRF token is left out. Session
+// ids are not numeric, the id is part of the snapshot instead
+func (s *SessionService) record(ctx context.Context, action string, before, after *session.Session) {
+	subject := after
+	if subject == nil {
+		subject = before
+	}
+
+	redact := func(sess *session.Session) any {
+		if sess == nil {
+			return nil
+		}
+		redacted := *sess
+		redacted.CSRFToken = ""
+		return redacted
+	}
+	s.audit.Record(ctx, AuditEntry{
+		UserID:     &subject.UserID,
+		Action:     action,
+		EntityType: EntitySession,
+		Before:     redact(before),
+		After:      redact(after),
+	})
+}
@@ -0,0 +1,481 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/events"
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/notifications"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+var (
+	// ErrReadOnlyShare is returned when a viewer tries to change a todo
+	// shared with them
+	ErrReadOnlyShare = errors.New("the todo is shared with you read only")
+
+	// ErrTooManyCollaborators is returned when a todo is shared with more
+	// users than allowed
+	ErrTooManyCollaborators = errors.New("collaborator limit reached")
+)
+
+// ShareInput holds an invitation to a todo, the invited user is looked up
+// by the email address of their account
+type ShareInput struct {
+	Email string
+	Role  string
+}
+
+// ShareService shares todos with other users. Sharing a todo shares its
+// sub-tasks too, viewers may read them and editors may also change them.
+// Every share starts as an invitation the invited user has to accept.
+// Only owners manage shares, move shared todos and change their tags; the
+// access of collaborators is checked here rather than in the store, which
+// scopes todos to their owner
+type ShareService struct {
+	store    storage.TodoRepository
+	users    storage.AuthRepository
+	todos    *TodoService
+	notifier *NotificationService
+	audit    *AuditLogger
+	cfg      config.SharingConfig
+	log      logger.Logger
+}
+
+// NewShareService creates the share service, collaborators are notified
+// unless notifier is nil
+func NewShareService(
+	store storage.TodoRepository,
+	users storage.AuthRepository,
+	todos *TodoService,
+	notifier *NotificationService,
+	audit *AuditLogger,
+	cfg config.SharingConfig,
+	log logger.Logger,
+) *ShareService {
+	return &ShareService{
+		store:    store,
+		users:    users,
+		todos:    todos,
+		notifier: notifier,
+		audit:    audit,
+		cfg:      cfg,
+		log:      log,
+	}
+}
+
+// Shares returns the shares of a todo owned by the user, oldest first
+func (s *ShareService) Shares(ctx context.Context, ownerID, todoID int64) ([]*models.TodoShare, error) {
+	if _, err := s.store.GetByID(ctx, ownerID, todoID); err != nil {
+		return nil, err
+	}
+
+	shares, err := s.store.ListShares(ctx, todoID)
+	if err != nil {
+		return nil, err
+	}
+	for _, share := range shares {
+		user, err := s.users.GetUserByID(ctx, share.UserID)
+		if err != nil {
+			return nil, err
+		}
+		share.Email = user.Email
+	}
+	return shares, nil
+}
+
+// Share invites the user with the given email address to a todo owned by
+// the user and notifies them
+func (s *ShareService) Share(ctx context.Context, ownerID, todoID int64, input ShareInput) (*models.TodoShare, error) {
+	if err := validateShareRole(input.Role); err != nil {
+		return nil, err
+	}
+	todo, err := s.store.GetByID(ctx, ownerID, todoID)
+	if err != nil {
+		return nil, err
+	}
+
+	invitee, err := s.users.GetUserByEmail(ctx, normalizeEmail(input.Email))
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, invalidField("email", "no user has the email address %q", input.Email)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if invitee.ID == ownerID {
+		return nil, invalidField("email", "todos cannot be shared with their owner")
+	}
+
+	existing, err := s.store.ListShares(ctx, todoID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= s.cfg.MaxCollaborators {
+		return nil, fmt.Errorf("%w: at most %d collaborators per todo", ErrTooManyCollaborators, s.cfg.MaxCollaborators)
+	}
+
+	owner, err := s.users.GetUserByID(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	share := &models.TodoShare{TodoID: todoID, OwnerID: ownerID, UserID: invitee.ID, Role: input.Role}
+	if err := s.store.CreateShare(ctx, share); err != nil {
+		return nil, err
+	}
+	share.Email = invitee.Email
+
+	s.record(ctx, "todo.share", nil, share)
+	s.notify(ctx, invitee.ID, notifications.Notification{
+		Type:    NotificationSharedTodo,
+		Subject: owner.Name + " shared a todo with you",
+		Body: fmt.Sprintf("%s (%s) invited you as %s of the todo %q. Accept the invitation to find it among the todos shared with you.",
+			owner.Name, owner.Email, share.Role, todo.Title),
+		Data: map[string]any{"todo_id": todoID, "role": share.Role, "owner_id": ownerID},
+	})
+	return share, nil
+}
+
+// SetRole changes the role of a user on a todo owned by the user
+func (s *ShareService) SetRole(ctx context.Context, ownerID, todoID, userID int64, role string) (*models.TodoShare, error) {
+	if err := validateShareRole(role); err != nil {
+		return nil, err
+	}
+	if _, err := s.store.GetByID(ctx, ownerID, todoID); err != nil {
+		return nil, err
+	}
+
+	before, err := s.store.GetShare(ctx, todoID, userID)
+	if err != nil {
+		return nil, err
+	}
+	share := *before
+	share.Role = role
+	if err := s.store.UpdateShare(ctx, &share); err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, "todo.share_update", before, &share)
+	return &share, nil
+}
+
+// Revoke stops sharing a todo owned by the user with another user
+func (s *ShareService) Revoke(ctx context.Context, ownerID, todoID, userID int64) error {
+	if _, err := s.store.GetByID(ctx, ownerID, todoID); err != nil {
+		return err
+	}
+	return s.remove(ctx, todoID, userID, "todo.unshare")
+}
+
+// SharedWith returns the todos shared with the user outside the trash,
+// including invitations not accepted yet, most recently shared first
+func (s *ShareService) SharedWith(ctx context.Context, userID int64) ([]*models.SharedTodo, error) {
+	shares, err := s.store.ListUserShares(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	shared := make([]*models.SharedTodo, 0, len(shares))
+	for _, share := range shares {
+		todo, err := s.store.GetByID(ctx, share.OwnerID, share.TodoID)
+		if errors.Is(err, storage.ErrNotFound) {
+			// Trashed since the shares were listed
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		shared = append(shared, &models.SharedTodo{Todo: todo, Share: share})
+	}
+	return shared, nil
+}
+
+// Accept accepts the invitation of the user to a todo and notifies its
+// owner. Accepting twice keeps the first acceptance
+func (s *ShareService) Accept(ctx context.Context, userID, todoID int64) (*models.SharedTodo, error) {
+	share, err := s.store.GetShare(ctx, todoID, userID)
+	if err != nil {
+		return nil, err
+	}
+	todo, err := s.store.GetByID(ctx, share.OwnerID, todoID)
+	if err != nil {
+		return nil, err
+	}
+	if share.Accepted() {
+		return &models.SharedTodo{Todo: todo, Share: share}, nil
+	}
+
+	user, err := s.users.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *share
+	now := time.Now().UTC()
+	share.AcceptedAt = &now
+	if err := s.store.UpdateShare(ctx, share); err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, "todo.share_accept", &before, share)
+	s.notify(ctx, share.OwnerID, notifications.Notification{
+		Type:    NotificationSharedTodo,
+		Subject: user.Name + " accepted your invitation",
+		Body:    fmt.Sprintf("%s (%s) is now %s of your todo %q.", user.Name, user.Email, share.Role, todo.Title),
+		Data:    map[string]any{"todo_id": todoID, "role": share.Role, "user_id": userID},
+	})
+	return &models.SharedTodo{Todo: todo, Share: share}, nil
+}
+
+// Leave declines the invitation of the user to a todo or gives up their
+// access to it
+func (s *ShareService) Leave(ctx context.Context, userID, todoID int64) error {
+	return s.remove(ctx, todoID, userID, "todo.leave")
+}
+
+// Get returns a todo the user has access to through a share of it or of
+// one of the todos above it
+func (s *ShareService) Get(ctx context.Context, userID, id int64) (*models.Todo, error) {
+	todo, _, err := s.access(ctx, userID, id)
+	return todo, err
+}
+
+// Subtasks returns the direct sub-tasks of a todo shared with the user
+func (s *ShareService) Subtasks(ctx context.Context, userID, id int64) ([]*models.Todo, error) {
+	todo, _, err := s.access(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.ListChildren(ctx, todo.UserID, id)
+}
+
+// Update applies a partial update by an editor to a todo shared with them.
+// The change is made on behalf of the owner, so it is announced and rolled
+// up like the owner's own changes. Editors cannot move todos or change
+// their tags, which belong to the owner
+func (s *ShareService) Update(ctx context.Context, userID, id int64, patch TodoPatch) (*models.Todo, error) {
+	todo, role, err := s.access(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if role != models.ShareEditor {
+		return nil, ErrReadOnlyShare
+	}
+	if patch.ParentID != nil {
+		return nil, invalidField("parent_id", "only the owner can move a shared todo")
+	}
+	if patch.Tags != nil {
+		return nil, invalidField("tags", "only the owner can change the tags of a shared todo")
+	}
+
+	return s.todos.Patch(ctx, todo.UserID, id, patch)
+}
+
+// access returns a todo the user has access to and their role on it. The
+// user's accepted shares grant access to the shared todos and everything
+// below them, the highest role wins
+func (s *ShareService) access(ctx context.Context, userID, id int64) (*models.Todo, string, error) {
+	shares, err := s.store.ListUserShares(ctx, userID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var todo *models.Todo
+	var tree []int64
+	var role string
+	for _, share := range shares {
+		if !share.Accepted() {
+			continue
+		}
+		if todo == nil {
+			// Only the todo's owner has it, shares of todos of other owners
+			// are skipped once it is found
+			found, err := s.store.GetByID(ctx, share.OwnerID, id)
+			if errors.Is(err, storage.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, "", err
+			}
+			ancestors, err := s.store.Ancestors(ctx, share.OwnerID, id)
+			if err != nil {
+				return nil, "", err
+			}
+			todo, tree = found, append(ancestors, id)
+		}
+		if share.OwnerID != todo.UserID || !slices.Contains(tree, share.TodoID) {
+			continue
+		}
+		if role == "" || share.Role == models.ShareEditor {
+			role = share.Role
+		}
+	}
+
+	if role == "" {
+		return nil, "", storage.ErrNotFound
+	}
+	return todo, role, nil
+}
+
+func (s *ShareService) remove(ctx context.Context, todoID, userID int64, action string) error {
+	share, err := s.store.GetShare(ctx, todoID, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.store.DeleteShare(ctx, todoID, userID); err != nil {
+		return err
+	}
+
+	s.record(ctx, action, share, nil)
+	return nil
+}
+
+// notify sends a sharing notification in response to a change that has
+// already been made, failures are logged rather than failing the change
+func (s *ShareService) notify(ctx context.Context, userID int64, n notifications.Notification) {
+	if err := s.notifier.Notify(ctx, userID, n); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		s.log.Warn("failed to notify collaborator", "error", err, "user_id", userID, "todo_id", n.Data["todo_id"])
+	}
+}
+
+// record audits a change to a share as a change of the shared todo, in the
+// trail of its owner
+func (s *ShareService) record(ctx context.Context, action string, before, after *models.TodoShare) {
+	subject := after
+	if subject == nil {
+		subject = before
+	}
+
+	s.audit.Record(ctx, AuditEntry{
+		UserID:     &subject.OwnerID,
+		Action:     action,
+		EntityType: EntityTodo,
+		EntityID:   subject.TodoID,
+		Before:     before,
+		After:      after,
+	})
+}
+
+// validateShareRole checks that role is one of the share roles
+func validateShareRole(role string) error {
+	if !slices.Contains(models.ShareRoles, role) {
+		return invalidField("role", "unknown role %q, expected one of %s", role, strings.Join(models.ShareRoles, ", "))
+	}
+	return nil
+}
+
+// collaboratorBacklog is how many events the collaborator notifier may fall
+// behind before further events are dropped
+const collaboratorBacklog = 256
+
+// CollaboratorNotifier tells the collaborators of shared todos when they
+// are changed or deleted, including about their own changes. It is an
+// events.Publisher fed todo events by the outbox relay. The relay publishes
+// inside a store transaction, so events are handed to Run rather than
+// looked into right away; notifications are best effort
+type CollaboratorNotifier struct {
+	store    storage.TodoRepository
+	notifier *NotificationService
+	log      logger.Logger
+	pending  chan events.Event
+}
+
+func NewCollaboratorNotifier(store storage.TodoRepository, notifier *NotificationService, log logger.Logger) *CollaboratorNotifier {
+	return &CollaboratorNotifier{
+		store:    store,
+		notifier: notifier,
+		log:      log,
+		pending:  make(chan events.Event, collaboratorBacklog),
+	}
+}
+
+// Publish hands todo update and delete events to Run without waiting
+func (n *CollaboratorNotifier) Publish(_ context.Context, topic string, event events.Event) error {
+	if topic != events.TopicTodos || event.Type != TodoUpdated && event.Type != TodoDeleted {
+		return nil
+	}
+
+	select {
+	case n.pending <- event:
+	default:
+		n.log.Warn("collaborator notifications falling behind, dropping event", "event_key", event.Key, "todo_id", event.EntityID)
+	}
+	return nil
+}
+
+// Run notifies the collaborators of the todos of published events until ctx
+// is done
+func (n *CollaboratorNotifier) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-n.pending:
+			if err := n.notify(ctx, event); err != nil && ctx.Err() == nil {
+				n.log.Warn("failed to notify collaborators", "error", err, "event_key", event.Key, "todo_id", event.EntityID)
+			}
+		}
+	}
+}
+
+func (n *CollaboratorNotifier) notify(ctx context.Context, event events.Event) error {
+	verb := "updated"
+	if event.Type == TodoDeleted {
+		verb = "deleted"
+	}
+
+	var todo models.Todo
+	if err := json.Unmarshal(event.Data, &todo); err != nil {
+		return fmt.Errorf("failed to decode %s event: %w", event.Type, err)
+	}
+
+	shares, err := n.collaborators(ctx, &todo)
+	if err != nil {
+		return err
+	}
+	for _, share := range shares {
+		err := n.notifier.Notify(ctx, share.UserID, notifications.Notification{
+			Type:    NotificationSharedTodo,
+			Subject: fmt.Sprintf("Shared todo %s: %s", verb, todo.Title),
+			Body:    fmt.Sprintf("The todo %q shared with you was %s.", todo.Title, verb),
+			Data:    map[string]any{"todo_id": todo.ID, "event": event.Type, "event_key": event.Key},
+		})
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// collaborators returns the accepted shares giving access to the todo,
+// those of the todo itself and of the todos above it
+func (n *CollaboratorNotifier) collaborators(ctx context.Context, todo *models.Todo) ([]*models.TodoShare, error) {
+	ids, err := n.store.Ancestors(ctx, todo.UserID, todo.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var accepted []*models.TodoShare
+	seen := make(map[int64]bool)
+	for _, id := range append([]int64{todo.ID}, ids...) {
+		shares, err := n.store.ListShares(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		for _, share := range shares {
+			if share.Accepted() && !seen[share.UserID] {
+				seen[share.UserID] = true
+				accepted = append(accepted, share)
+			}
+		}
+	}
+	return accepted, nil
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+const (
+	maxTagLength   = 50
+	maxTagsPerTodo = 20
+)
+
+// TagService manages the user's tags, tags are attached to todos through
+// the todo service. Renaming or deleting a tag changes the todos carrying
+// it, so their cached copies are invalidated
+type TagService struct {
+	store     storage.TagRepository
+	audit     *AuditLogger
+	todoCache *TodoCache
+}
+
+func NewTagService(store storage.TagRepository, audit *AuditLogger, todoCache *TodoCache) *TagService {
+	return &TagService{store: store, audit: audit, todoCache: todoCache}
+}
+
+// Create stores a new tag owned by the user
+func (s *TagService) Create(ctx context.Context, userID int64, name string) (*models.Tag, error) {
+	name, err := normalizeTag("name", name)
+	if err != nil {
+		return nil, err
+	}
+
+	tag := &models.Tag{UserID: userID, Name: name}
+	if err := s.store.CreateTag(ctx, tag); err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, "tag.create", nil, tag)
+	return tag, nil
+}
+
+// Get returns a single tag owned by the user
+func (s *TagService) Get(ctx context.Context, userID, id int64) (*models.Tag, error) {
+	return s.store.GetTag(ctx, userID, id)
+}
+
+// List returns all of the user's tags ordered by name
+func (s *TagService) List(ctx context.Context, userID int64) ([]*models.Tag, error) {
+	return s.store.ListTags(ctx, userID)
+}
+
+// Rename changes the name of a tag, todos carrying it pick up the new name
+func (s *TagService) Rename(ctx context.Context, userID, id int64, name string) (*models.Tag, error) {
+	name, err := normalizeTag("name", name)
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := s.store.GetTag(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	tag := &models.Tag{ID: id, UserID: userID, Name: name}
+	if err := s.store.UpdateTag(ctx, tag); err != nil {
+		return nil, err
+	}
+
+	s.todoCache.Invalidate(ctx, userID)
+	s.record(ctx, "tag.update", before, tag)
+	return tag, nil
+}
+
+// Delete removes a tag and detaches it from all todos
+func (s *TagService) Delete(ctx context.Context, userID, id int64) error {
+	tag, err := s.store.GetTag(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if err := s.store.DeleteTag(ctx, userID, id); err != nil {
+		return err
+	}
+
+	s.todoCache.Invalidate(ctx, userID)
+	s.record(ctx, "tag.delete", tag, nil)
+	return nil
+}
+
+// record audits a change to a tag made by its owner, one of before and after
+// may be nil
+func (s *TagService) record(ctx context.Context, action string, before, after *models.Tag) {
+	subject := after
+	if subject == nil {
+		subject = before
+	}
+
+	s.audit.Record(ctx, AuditEntry{
+		UserID:     &subject.UserID,
+		Action:     action,
+		EntityType: EntityTag,
+		EntityID:   subject.ID,
+		Before:     before,
+		After:      after,
+	})
+}
+
+// normalizeTag trims and lowercases a tag name so "Work" and "work " are the
+// same tag. Commas are rejected since ?tags= is comma separated, errors are
+// reported against field
+func normalizeTag(field, name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return "", invalidField(field, "tag name is required")
+	}
+	if len(name) > maxTagLength {
+		return "", invalidField(field, "tag names must be at most %d characters", maxTagLength)
+	}
+	if strings.Contains(name, ",") {
+		return "", invalidField(field, "tag names cannot contain commas")
+	}
+	return name, nil
+}
+
+// normalizeTags normalizes a list of tag names, returning them sorted and
+// without duplicates
+func normalizeTags(names []string) ([]string, error) {
+	normalized := make([]string, 0, len(names))
+	for _, name := range names {
+		name, err := normalizeTag("tags", name)
+		if err != nil {
+			return nil, err
+		}
+		normalized = append(normalized, name)
+	}
+
+	slices.Sort(normalized)
+	normalized = slices.Compact(normalized)
+	if len(normalized) > maxTagsPerTodo {
+		return nil, invalidField("tags", "a todo can have at most %d tags", maxTagsPerTodo)
+	}
+	return normalized, nil
+}
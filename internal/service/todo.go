@@ -0,0 +1,878 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/cache"
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/events"
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+const (
+	maxTitleLength       = 255
+	maxDescriptionLength = 2000
+	maxQueryLength       = 200
+)
+
+// TodoService implements the todo business logic on top of the todo store
+type TodoService struct {
+	store      storage.TodoRepository
+	cfg        config.TodosConfig
+	pagination config.PaginationConfig
+	audit      *AuditLogger
+	outbox     *OutboxRelay
+	quotas     *QuotaService
+	cache      *TodoCache
+}
+
+// NewTodoService creates the todo service, changes are announced through the
+// outbox unless it is nil, the todo quota is enforced unless quotas is nil
+// and reads are cached unless todoCache is nil
+func NewTodoService(store storage.TodoRepository, cfg config.TodosConfig, pagination config.PaginationConfig, audit *AuditLogger, outbox *OutboxRelay, quotas *QuotaService, todoCache *TodoCache) *TodoService {
+	return &TodoService{store: store, cfg: cfg, pagination: pagination, audit: audit, outbox: outbox, quotas: quotas, cache: todoCache}
+}
+
+// TodoInput holds the fields required to create or replace a todo, a nil
+// ParentID makes it a top-level todo and an empty Recurrence one that does
+// not repeat
+type TodoInput struct {
+	ParentID    *int64
+	Title       string
+	Description string
+	Completed   bool
+	DueDate     *time.Time
+	Recurrence  string
+	Priority    string
+	Tags        []string
+}
+
+// TodoPatch holds the fields of a partial todo update, nil fields are left
+// untouched. A ParentID of 0 moves the todo to the top level, a zero DueDate
+// removes the due date, an empty Recurrence stops it repeating and an empty,
+// non-nil Tags removes all tags
+type TodoPatch struct {
+	ParentID    *int64
+	Title       *string
+	Description *string
+	Completed   *bool
+	DueDate     *time.Time
+	Recurrence  *string
+	Priority    *string
+	Tags        []string
+}
+
+// Todo list statuses, StatusOverdue selects open todos whose due date has passed
+const (
+	StatusAll       = "all"
+	StatusOpen      = "open"
+	StatusCompleted = "completed"
+	StatusOverdue   = "overdue"
+)
+
+// TodoQuery holds the list filters, zero fields match everything
+type TodoQuery struct {
+	Status    string
+	Priority  string
+	DueBefore *time.Time
+	DueAfter  *time.Time
+	Tags      []string
+}
+
+// TodoPage is a single page of todos, the cursors are empty when there is no
+// page in that direction
+type TodoPage struct {
+	Todos      []*models.Todo
+	Limit      int
+	NextCursor string
+	PrevCursor string
+}
+
+// SearchPage is a single page of search results
+type SearchPage struct {
+	Results  []*models.TodoSearchResult
+	Page     int
+	PageSize int
+	Total    int
+}
+
+// TotalPages returns the number of pages available with the current page size
+func (p *SearchPage) TotalPages() int {
+	return totalPages(p.Total, p.PageSize)
+}
+
+// TrashPage is a single page of trashed todos
+type TrashPage struct {
+	Todos    []*models.Todo
+	Page     int
+	PageSize int
+	Total    int
+}
+
+// TotalPages returns the number of pages available with the current page size
+func (p *TrashPage) TotalPages() int {
+	return totalPages(p.Total, p.PageSize)
+}
+
+// Create validates the input and stores a new todo owned by the user
+func (s *TodoService) Create(ctx context.Context, userID int64, input TodoInput) (*models.Todo, error) {
+	todo := &models.Todo{
+		UserID:      userID,
+		ParentID:    input.ParentID,
+		Title:       strings.TrimSpace(input.Title),
+		Description: input.Description,
+		Completed:   input.Completed,
+		DueDate:     input.DueDate,
+		Recurrence:  input.Recurrence,
+		Priority:    priorityOrDefault(input.Priority),
+	}
+
+	if err := validateTodo(todo); err != nil {
+		return nil, err
+	}
+	tags, err := normalizeTags(input.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.store.InTx(ctx, func(repo storage.TodoRepository) error {
+		if err := s.quotas.CheckTodos(ctx, repo, userID, 1); err != nil {
+			return err
+		}
+		if err := s.checkParent(ctx, repo, todo); err != nil {
+			return err
+		}
+		if err := repo.Create(ctx, todo); err != nil {
+			return err
+		}
+		if err := repo.SetTodoTags(ctx, userID, todo.ID, tags); err != nil {
+			return err
+		}
+		todo.Tags = tags
+		if err := s.rollup(ctx, repo, userID, todo.ParentID); err != nil {
+			return err
+		}
+		return s.enqueue(ctx, repo, TodoCreated, todo)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache.Invalidate(ctx, userID)
+	s.record(ctx, "todo.create", nil, todo)
+	s.outbox.Notify()
+	return todo, nil
+}
+
+// Import stores todos for the user in bulk, all of them or none, and returns
+// how many were stored. Imported todos are top level and untagged, and
+// unlike created ones they are not announced as todo events
+func (s *TodoService) Import(ctx context.Context, userID int64, inputs []TodoInput) (int64, error) {
+	if len(inputs) == 0 {
+		return 0, invalidField("todos", "at least one todo is required")
+	}
+	if len(inputs) > s.cfg.MaxImport {
+		return 0, invalidField("todos", "at most %d todos can be imported at once", s.cfg.MaxImport)
+	}
+
+	todos := make([]*models.Todo, len(inputs))
+	for i, input := range inputs {
+		if input.ParentID != nil || len(input.Tags) > 0 {
+			return 0, invalidField(fmt.Sprintf("todos[%d]", i), "imported todos cannot have a parent or tags")
+		}
+		todo := &models.Todo{
+			UserID:      userID,
+			Title:       strings.TrimSpace(input.Title),
+			Description: input.Description,
+			Completed:   input.Completed,
+			DueDate:     input.DueDate,
+			Priority:    priorityOrDefault(input.Priority),
+		}
+		if err := validateTodo(todo); err != nil {
+			var invalid *FieldError
+			if errors.As(err, &invalid) {
+				invalid.Field = fmt.Sprintf("todos[%d].%s", i, invalid.Field)
+			}
+			return 0, err
+		}
+		todos[i] = todo
+	}
+
+	var imported int64
+	err := s.store.InTx(ctx, func(repo storage.TodoRepository) error {
+		if err := s.quotas.CheckTodos(ctx, repo, userID, len(todos)); err != nil {
+			return err
+		}
+
+		var err error
+		imported, err = repo.ImportTodos(ctx, todos)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	s.cache.Invalidate(ctx, userID)
+	s.audit.Record(ctx, AuditEntry{
+		UserID:     &userID,
+		Action:     "todo.import",
+		EntityType: EntityTodo,
+		After:      map[string]int64{"imported": imported},
+	})
+	return imported, nil
+}
+
+// CreateSubtask stores a new todo below an existing one
+func (s *TodoService) CreateSubtask(ctx context.Context, userID, parentID int64, input TodoInput) (*models.Todo, error) {
+	if _, err := s.store.GetByID(ctx, userID, parentID); err != nil {
+		return nil, err
+	}
+
+	input.ParentID = &parentID
+	return s.Create(ctx, userID, input)
+}
+
+// Get returns a single todo owned by the user
+func (s *TodoService) Get(ctx context.Context, userID, id int64) (*models.Todo, error) {
+	cached, key := s.cache.todo(ctx, userID, id)
+	if cached != nil {
+		return cached, nil
+	}
+
+	todo, err := s.store.GetByID(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.set(ctx, key, todo, cache.TierDefault)
+	return todo, nil
+}
+
+// List returns a page of the user's todos matching the query, newest first.
+// An empty cursor returns the first page, later pages are fetched with the
+// cursors of the previous response
+func (s *TodoService) List(ctx context.Context, userID int64, query TodoQuery, cursor string, limit int) (*TodoPage, error) {
+	filter, err := todoFilter(query, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	page, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	// Which todos are overdue changes with the time, not only with writes
+	var key string
+	if query.Status != StatusOverdue {
+		var cached *TodoPage
+		if cached, key = s.cache.page(ctx, userID, query, cursor, s.limit(limit)); cached != nil {
+			return cached, nil
+		}
+	}
+
+	// Ask for one extra todo to learn whether another page follows
+	page.Limit = s.limit(limit) + 1
+	todos, err := s.store.List(ctx, userID, filter, page)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TodoPage{Limit: page.Limit - 1}
+	more := len(todos) == page.Limit
+	if more {
+		if page.Backward {
+			todos = todos[1:]
+		} else {
+			todos = todos[:len(todos)-1]
+		}
+	}
+	result.Todos = todos
+	if len(todos) == 0 {
+		s.cache.set(ctx, key, result, cache.TierShort)
+		return result, nil
+	}
+
+	// Coming from a cursor means there is a page on the side we came from
+	first, last := todos[0], todos[len(todos)-1]
+	if more && !page.Backward || page.Cursor != nil && page.Backward {
+		result.NextCursor = encodeCursor(storage.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}, false)
+	}
+	if more && page.Backward || page.Cursor != nil && !page.Backward {
+		result.PrevCursor = encodeCursor(storage.Cursor{CreatedAt: first.CreatedAt, ID: first.ID}, true)
+	}
+	s.cache.set(ctx, key, result, cache.TierShort)
+	return result, nil
+}
+
+// exportBatchSize is how many todos Export reads per query
+const exportBatchSize = 500
+
+// Export calls fn with every todo of the user matching the query, newest
+// first. Todos are read in batches so exports do not hold every todo in memory
+func (s *TodoService) Export(ctx context.Context, userID int64, query TodoQuery, fn func(*models.Todo) error) error {
+	filter, err := todoFilter(query, time.Now())
+	if err != nil {
+		return err
+	}
+
+	// Each page is read in its own transaction so the export timeout of the
+	// store applies without holding a connection while the todos are written
+	ctx = storage.WithOperation(ctx, storage.OperationExport)
+	page := storage.PageRequest{Limit: exportBatchSize}
+	for {
+		var todos []*models.Todo
+		err := s.store.InTx(ctx, func(repo storage.TodoRepository) error {
+			var err error
+			todos, err = repo.List(ctx, userID, filter, page)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		for _, todo := range todos {
+			if err := fn(todo); err != nil {
+				return err
+			}
+		}
+		if len(todos) < page.Limit {
+			return nil
+		}
+
+		last := todos[len(todos)-1]
+		page.Cursor = &storage.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+}
+
+// Search returns the requested page of the user's todos matching a full-text
+// query, best matches first
+func (s *TodoService) Search(ctx context.Context, userID int64, query string, page, pageSize int) (*SearchPage, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, invalidField("q", "search query is required")
+	}
+	if len(query) > maxQueryLength {
+		return nil, invalidField("q", "search query must be at most %d characters", maxQueryLength)
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	pageSize = s.limit(pageSize)
+	results, total, err := s.store.Search(ctx, userID, query, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SearchPage{
+		Results:  results,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	}, nil
+}
+
+// Subtasks returns the direct sub-tasks of a todo owned by the user
+func (s *TodoService) Subtasks(ctx context.Context, userID, id int64) ([]*models.Todo, error) {
+	if _, err := s.store.GetByID(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	return s.store.ListChildren(ctx, userID, id)
+}
+
+// Update replaces all mutable fields of an existing todo
+func (s *TodoService) Update(ctx context.Context, userID, id int64, input TodoInput) (*models.Todo, error) {
+	todo := &models.Todo{
+		ID:          id,
+		UserID:      userID,
+		ParentID:    input.ParentID,
+		Title:       strings.TrimSpace(input.Title),
+		Description: input.Description,
+		Completed:   input.Completed,
+		DueDate:     input.DueDate,
+		Recurrence:  input.Recurrence,
+		Priority:    priorityOrDefault(input.Priority),
+	}
+
+	if err := validateTodo(todo); err != nil {
+		return nil, err
+	}
+	tags, err := normalizeTags(input.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	var before, next *models.Todo
+	err = s.store.InTx(ctx, func(repo storage.TodoRepository) error {
+		var err error
+		before, err = repo.GetByID(ctx, userID, id)
+		if err != nil {
+			return err
+		}
+		if todo.Completed && !before.Completed {
+			if next, err = nextOccurrence(todo, time.Now()); err != nil {
+				return err
+			}
+		}
+		if err := s.save(ctx, repo, todo, before.ParentID); err != nil {
+			return err
+		}
+		if err := repo.SetTodoTags(ctx, userID, id, tags); err != nil {
+			return err
+		}
+		todo.Tags = tags
+		if err := s.enqueue(ctx, repo, TodoUpdated, todo); err != nil {
+			return err
+		}
+		return s.createOccurrence(ctx, repo, next, tags)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache.Invalidate(ctx, userID)
+	s.record(ctx, "todo.update", before, todo)
+	if next != nil {
+		s.record(ctx, "todo.create", nil, next)
+	}
+	s.outbox.Notify()
+	return todo, nil
+}
+
+// Patch applies a partial update to an existing todo
+func (s *TodoService) Patch(ctx context.Context, userID, id int64, patch TodoPatch) (*models.Todo, error) {
+	var tags []string
+	if patch.Tags != nil {
+		var err error
+		if tags, err = normalizeTags(patch.Tags); err != nil {
+			return nil, err
+		}
+	}
+
+	var todo, before, next *models.Todo
+	err := s.store.InTx(ctx, func(repo storage.TodoRepository) error {
+		var err error
+		todo, err = repo.GetByID(ctx, userID, id)
+		if err != nil {
+			return err
+		}
+		snapshot := *todo
+		before = &snapshot
+		oldParentID := todo.ParentID
+
+		if patch.ParentID != nil {
+			todo.ParentID = patch.ParentID
+			if *patch.ParentID == 0 {
+				todo.ParentID = nil
+			}
+		}
+		if patch.Title != nil {
+			todo.Title = strings.TrimSpace(*patch.Title)
+		}
+		if patch.Description != nil {
+			todo.Description = *patch.Description
+		}
+		if patch.Completed != nil {
+			todo.Completed = *patch.Completed
+		}
+		if patch.DueDate != nil {
+			todo.DueDate = patch.DueDate
+			if patch.DueDate.IsZero() {
+				todo.DueDate = nil
+			}
+		}
+		if patch.Recurrence != nil {
+			todo.Recurrence = *patch.Recurrence
+		}
+		if patch.Priority != nil {
+			todo.Priority = *patch.Priority
+		}
+
+		if err := validateTodo(todo); err != nil {
+			return err
+		}
+		if todo.Completed && !before.Completed {
+			if next, err = nextOccurrence(todo, time.Now()); err != nil {
+				return err
+			}
+		}
+		if err := s.save(ctx, repo, todo, oldParentID); err != nil {
+			return err
+		}
+
+		if tags != nil {
+			if err := repo.SetTodoTags(ctx, userID, id, tags); err != nil {
+				return err
+			}
+			todo.Tags = tags
+		}
+		if err := s.enqueue(ctx, repo, TodoUpdated, todo); err != nil {
+			return err
+		}
+		return s.createOccurrence(ctx, repo, next, todo.Tags)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache.Invalidate(ctx, userID)
+	s.record(ctx, "todo.update", before, todo)
+	if next != nil {
+		s.record(ctx, "todo.create", nil, next)
+	}
+	s.outbox.Notify()
+	return todo, nil
+}
+
+// Delete moves a todo together with its sub-tasks to the trash
+func (s *TodoService) Delete(ctx context.Context, userID, id int64) error {
+	var todo *models.Todo
+	err := s.store.InTx(ctx, func(repo storage.TodoRepository) error {
+		var err error
+		todo, err = repo.GetByID(ctx, userID, id)
+		if err != nil {
+			return err
+		}
+		if err := repo.Delete(ctx, userID, id); err != nil {
+			return err
+		}
+		if err := s.rollup(ctx, repo, userID, todo.ParentID); err != nil {
+			return err
+		}
+		return s.enqueue(ctx, repo, TodoDeleted, todo)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.cache.Invalidate(ctx, userID)
+	s.record(ctx, "todo.delete", todo, nil)
+	s.outbox.Notify()
+	return nil
+}
+
+// Trash returns the requested page of the user's trashed todos, most
+// recently deleted first
+func (s *TodoService) Trash(ctx context.Context, userID int64, page, pageSize int) (*TrashPage, error) {
+	if page < 1 {
+		page = 1
+	}
+	pageSize = s.limit(pageSize)
+
+	todos, total, err := s.store.ListDeleted(ctx, userID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TrashPage{
+		Todos:    todos,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	}, nil
+}
+
+// Restore takes a todo out of the trash along with the sub-tasks deleted with
+// it. A sub-task can only be restored once its parent is
+func (s *TodoService) Restore(ctx context.Context, userID, id int64) (*models.Todo, error) {
+	var todo *models.Todo
+	err := s.store.InTx(ctx, func(repo storage.TodoRepository) error {
+		if err := s.quotas.CheckTodos(ctx, repo, userID, 1); err != nil {
+			return err
+		}
+
+		var err error
+		todo, err = repo.Restore(ctx, userID, id)
+		if err != nil {
+			return err
+		}
+
+		if todo.ParentID != nil {
+			if _, err := repo.GetByID(ctx, userID, *todo.ParentID); err != nil {
+				if errors.Is(err, storage.ErrNotFound) {
+					return fmt.Errorf("%w: the parent todo is in the trash, restore it first", ErrInvalidInput)
+				}
+				return err
+			}
+		}
+		if err := s.rollup(ctx, repo, userID, todo.ParentID); err != nil {
+			return err
+		}
+		return s.enqueue(ctx, repo, TodoRestored, todo)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache.Invalidate(ctx, userID)
+	s.record(ctx, "todo.restore", nil, todo)
+	s.outbox.Notify()
+	return todo, nil
+}
+
+// PurgeTrash permanently removes the todos that have been in the trash for
+// longer than the configured retention
+func (s *TodoService) PurgeTrash(ctx context.Context) (int64, error) {
+	return s.store.PurgeDeleted(ctx, time.Now().Add(-s.cfg.TrashRetention))
+}
+
+// record audits a change to a todo made by its owner, one of before and after
+// may be nil
+func (s *TodoService) record(ctx context.Context, action string, before, after *models.Todo) {
+	subject := after
+	if subject == nil {
+		subject = before
+	}
+
+	s.audit.Record(ctx, AuditEntry{
+		UserID:     &subject.UserID,
+		Action:     action,
+		EntityType: EntityTodo,
+		EntityID:   subject.ID,
+		Before:     before,
+		After:      after,
+	})
+}
+
+// enqueue adds an event about the todo to the outbox in the transaction of
+// the change, the relay publishes it once the transaction has committed
+func (s *TodoService) enqueue(ctx context.Context, repo storage.TodoRepository, eventType string, todo *models.Todo) error {
+	if s.outbox == nil {
+		return nil
+	}
+
+	event, err := events.New(eventType, todo.UserID, todo.ID, todo)
+	if err != nil {
+		return err
+	}
+
+	return repo.AddOutboxMessage(ctx, &models.OutboxMessage{
+		IdempotencyKey: event.Key,
+		Topic:          events.TopicTodos,
+		EventType:      event.Type,
+		UserID:         event.UserID,
+		EntityID:       event.EntityID,
+		Payload:        event.Data,
+	})
+}
+
+// save persists an updated todo and rolls completion up through both its
+// previous and its current parent
+func (s *TodoService) save(ctx context.Context, repo storage.TodoRepository, todo *models.Todo, oldParentID *int64) error {
+	if !sameParent(todo.ParentID, oldParentID) {
+		if err := s.checkParent(ctx, repo, todo); err != nil {
+			return err
+		}
+	}
+	if err := repo.Update(ctx, todo); err != nil {
+		return err
+	}
+
+	if !sameParent(todo.ParentID, oldParentID) {
+		if err := s.rollup(ctx, repo, todo.UserID, oldParentID); err != nil {
+			return err
+		}
+	}
+	return s.rollup(ctx, repo, todo.UserID, todo.ParentID)
+}
+
+// checkParent verifies that the todo's parent exists, that placing the todo
+// below it does not create a cycle and that the hierarchy stays within the
+// configured depth
+func (s *TodoService) checkParent(ctx context.Context, repo storage.TodoRepository, todo *models.Todo) error {
+	if todo.ParentID == nil {
+		return nil
+	}
+	parentID := *todo.ParentID
+
+	if parentID <= 0 {
+		return invalidField("parent_id", "parent_id must be positive")
+	}
+	if parentID == todo.ID {
+		return invalidField("parent_id", "a todo cannot be its own parent")
+	}
+	if _, err := repo.GetByID(ctx, todo.UserID, parentID); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return invalidField("parent_id", "parent todo %d not found", parentID)
+		}
+		return err
+	}
+
+	ancestors, err := repo.Ancestors(ctx, todo.UserID, parentID)
+	if err != nil {
+		return err
+	}
+	if todo.ID != 0 && slices.Contains(ancestors, todo.ID) {
+		return invalidField("parent_id", "a todo cannot be moved below one of its own sub-tasks")
+	}
+
+	// Depth of the parent plus the levels the todo brings along with it
+	height := 1
+	if todo.ID != 0 {
+		height, err = subtreeHeight(ctx, repo, todo.UserID, todo.ID)
+		if err != nil {
+			return err
+		}
+	}
+	if len(ancestors)+1+height > s.cfg.MaxDepth {
+		return invalidField("parent_id", "sub-tasks can be nested at most %d levels deep", s.cfg.MaxDepth)
+	}
+	return nil
+}
+
+// rollup walks up from parentID marking each todo completed when all of its
+// sub-tasks are, and reopening it when one of them is not. It stops at the
+// first todo whose state does not change
+func (s *TodoService) rollup(ctx context.Context, repo storage.TodoRepository, userID int64, parentID *int64) error {
+	if !s.cfg.CompletionRollup {
+		return nil
+	}
+
+	for parentID != nil {
+		parent, err := repo.GetByID(ctx, userID, *parentID)
+		if err != nil {
+			return err
+		}
+
+		children, err := repo.ListChildren(ctx, userID, parent.ID)
+		if err != nil {
+			return err
+		}
+		if len(children) == 0 {
+			return nil
+		}
+
+		completed := true
+		for _, child := range children {
+			completed = completed && child.Completed
+		}
+		if parent.Completed == completed {
+			return nil
+		}
+
+		parent.Completed = completed
+		if err := repo.Update(ctx, parent); err != nil {
+			return err
+		}
+		parentID = parent.ParentID
+	}
+	return nil
+}
+
+// subtreeHeight returns the number of levels in the hierarchy rooted at id,
+// counting the todo itself
+func subtreeHeight(ctx context.Context, repo storage.TodoRepository, userID, id int64) (int, error) {
+	children, err := repo.ListChildren(ctx, userID, id)
+	if err != nil {
+		return 0, err
+	}
+
+	height := 0
+	for _, child := range children {
+		h, err := subtreeHeight(ctx, repo, userID, child.ID)
+		if err != nil {
+			return 0, err
+		}
+		height = max(height, h)
+	}
+	return height + 1, nil
+}
+
+func sameParent(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// limit applies the configured page size bounds, zero selects the default
+func (s *TodoService) limit(n int) int {
+	if n < 1 {
+		return s.pagination.DefaultLimit
+	}
+	return min(n, s.pagination.MaxLimit)
+}
+
+func totalPages(total, pageSize int) int {
+	if pageSize <= 0 {
+		return 0
+	}
+	return (total + pageSize - 1) / pageSize
+}
+
+// todoFilter translates a list query into a store filter, overdue becomes
+// "open and due before now"
+func todoFilter(query TodoQuery, now time.Time) (storage.TodoFilter, error) {
+	filter := storage.TodoFilter{
+		Priority:  query.Priority,
+		DueBefore: query.DueBefore,
+		DueAfter:  query.DueAfter,
+	}
+
+	if filter.Priority != "" && !validPriority(filter.Priority) {
+		return filter, invalidField("priority", "priority must be one of low, medium or high")
+	}
+	if len(query.Tags) > 0 {
+		tags, err := normalizeTags(query.Tags)
+		if err != nil {
+			return filter, err
+		}
+		filter.Tags = tags
+	}
+
+	completed := true
+	open := false
+	switch query.Status {
+	case "", StatusAll:
+	case StatusOpen:
+		filter.Completed = &open
+	case StatusCompleted:
+		filter.Completed = &completed
+	case StatusOverdue:
+		filter.Completed = &open
+		if filter.DueBefore == nil || now.Before(*filter.DueBefore) {
+			filter.DueBefore = &now
+		}
+	default:
+		return filter, invalidField("status", "status must be one of all, open, completed or overdue")
+	}
+
+	return filter, nil
+}
+
+// validateTodo checks the fields of a todo and brings its recurrence rule
+// into canonical form
+func validateTodo(todo *models.Todo) error {
+	if todo.Title == "" {
+		return invalidField("title", "title is required")
+	}
+	if len(todo.Title) > maxTitleLength {
+		return invalidField("title", "title must be at most %d characters", maxTitleLength)
+	}
+	if len(todo.Description) > maxDescriptionLength {
+		return invalidField("description", "description must be at most %d characters", maxDescriptionLength)
+	}
+	if !validPriority(todo.Priority) {
+		return invalidField("priority", "priority must be one of low, medium or high")
+	}
+	return validateRecurrence(todo)
+}
+
+func validPriority(priority string) bool {
+	switch priority {
+	case models.PriorityLow, models.PriorityMedium, models.PriorityHigh:
+		return true
+	}
+	return false
+}
+
+func priorityOrDefault(priority string) string {
+	if priority == "" {
+		return models.PriorityMedium
+	}
+	return priority
+}
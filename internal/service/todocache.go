@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/cache"
+	"github.com/MuthuM3/gin-microservice-template/internal/events"
+	"github.com/MuthuM3/gin-microservice-template/internal/metrics"
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+)
+
+// TodoCache keeps the todos and list pages read through the todo service.
+// The cache cannot delete by prefix, so every key of a user carries the
+// user's generation and a change to any of their todos or tags starts a
+// new generation, orphaning the old entries until they expire. A read
+// racing a change stores its result under the generation it started with,
+// so it is never served afterwards. Changes written outside the service
+// are picked up from the todo events. A nil *TodoCache disables caching
+type TodoCache struct {
+	cache cache.Cache
+	tiers *cache.Tiers
+}
+
+func NewTodoCache(c cache.Cache, tiers *cache.Tiers) *TodoCache {
+	return &TodoCache{cache: c, tiers: tiers}
+}
+
+// todo returns the cached todo of the user, nil on a miss along with the
+// key to store it under
+func (c *TodoCache) todo(ctx context.Context, userID, id int64) (*models.Todo, string) {
+	if c == nil {
+		return nil, ""
+	}
+
+	generation, ok := c.generation(ctx, userID)
+	if !ok {
+		countLookup("todo", "miss")
+		return nil, ""
+	}
+	key := fmt.Sprintf("todos:%d:%s:todo:%d", userID, generation, id)
+	var todo models.Todo
+	if !c.get(ctx, "todo", key, &todo) {
+		return nil, key
+	}
+	return &todo, key
+}
+
+// page returns the cached list page of the user for the given request, nil
+// on a miss along with the key to store it under
+func (c *TodoCache) page(ctx context.Context, userID int64, query TodoQuery, cursor string, limit int) (*TodoPage, string) {
+	if c == nil {
+		return nil, ""
+	}
+
+	request, err := json.Marshal(struct {
+		Query  TodoQuery
+		Cursor string
+		Limit  int
+	}{query, cursor, limit})
+	if err != nil {
+		return nil, ""
+	}
+	sum := sha256.Sum256(request)
+
+	generation, ok := c.generation(ctx, userID)
+	if !ok {
+		countLookup("list", "miss")
+		return nil, ""
+	}
+	key := fmt.Sprintf("todos:%d:%s:list:%s", userID, generation, hex.EncodeToString(sum[:16]))
+	var page TodoPage
+	if !c.get(ctx, "list", key, &page) {
+		return nil, key
+	}
+	return &page, key
+}
+
+// set stores value under a key returned by todo or page, the empty key of
+// a failed lookup stores nothing. Lists change with every todo of the user
+// and live for the short tier, single todos for the default one
+func (c *TodoCache) set(ctx context.Context, key string, value any, tier cache.Tier) {
+	if c == nil || key == "" {
+		return
+	}
+	// The response does not depend on the cache, errors only cost a miss
+	_ = cache.SetJSON(ctx, c.cache, key, value, c.tiers.TTL(tier))
+}
+
+// Invalidate starts a new generation for the user, the cached todos and
+// pages are no longer served
+func (c *TodoCache) Invalidate(ctx context.Context, userID int64) {
+	if c == nil {
+		return
+	}
+	generation := strconv.FormatInt(time.Now().UnixNano(), 36)
+	if err := c.cache.Set(ctx, generationKey(userID), []byte(generation), c.tiers.TTL(cache.TierLong)); err != nil {
+		// Without a new generation stale entries would be served, drop the
+		// current one so the next read starts afresh
+		_ = c.cache.Delete(ctx, generationKey(userID))
+	}
+}
+
+// Consume invalidates the cache of the owner of every todo event received
+// on ch until it is closed, covering changes made by other replicas with an
+// in-memory cache and by other services writing to the database
+func (c *TodoCache) Consume(ch <-chan events.Event) {
+	for event := range ch {
+		c.Invalidate(context.Background(), event.UserID)
+	}
+}
+
+// generation returns the current generation of the user. When there is
+// none it starts one and reports false, the read is not cached: it may
+// have overwritten the generation of a change committed after the read
+func (c *TodoCache) generation(ctx context.Context, userID int64) (string, bool) {
+	value, err := c.cache.Get(ctx, generationKey(userID))
+	if err == nil {
+		return string(value), true
+	}
+
+	if errors.Is(err, cache.ErrCacheMiss) {
+		generation := strconv.FormatInt(time.Now().UnixNano(), 36)
+		_ = c.cache.Set(ctx, generationKey(userID), []byte(generation), c.tiers.TTL(cache.TierLong))
+	}
+	return "", false
+}
+
+// get decodes the entry under key into dest and records the lookup under
+// kind, "todo" or "list"
+func (c *TodoCache) get(ctx context.Context, kind, key string, dest any) bool {
+	result := "hit"
+	err := cache.GetJSON(ctx, c.cache, key, dest)
+	switch {
+	case errors.Is(err, cache.ErrCacheMiss):
+		result = "miss"
+	case err != nil:
+		result = "error"
+	}
+
+	countLookup(kind, result)
+	return err == nil
+}
+
+// countLookup counts a cache lookup of kind by its result, hit, miss or error
+func countLookup(kind, result string) {
+	metrics.Default.Counter("todo_cache_requests_total", "Total number of todo cache lookups by result",
+		metrics.Labels{"kind": kind, "result": result}).Inc()
+}
+
+func generationKey(userID int64) string {
+	return fmt.Sprintf("todos:%d:generation", userID)
+}
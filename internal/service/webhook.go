@@ -0,0 +1,583 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/auth"
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/events"
+	"github.com/MuthuM3/gin-microservice-template/internal/lock"
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+	"github.com/MuthuM3/gin-microservice-template/internal/metrics"
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/retry"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+	"github.com/MuthuM3/gin-microservice-template/internal/webhook"
+)
+
+const (
+	maxWebhookURLLength = 2048
+	minSecretLength     = 16
+	maxSecretLength     = 255
+
+	// maxDeliveryError bounds the error stored with a failed attempt
+	maxDeliveryError = 1000
+
+	// webhookPurgeInterval is how often deliveries past the retention are removed
+	webhookPurgeInterval = time.Hour
+
+	// webhookLockTTL bounds how long a crashed dispatcher keeps the others waiting
+	webhookLockTTL = 15 * time.Second
+)
+
+// WebhookEvents are the event types webhooks can subscribe to
+var WebhookEvents = []string{TodoCreated, TodoUpdated, TodoDeleted, TodoRestored}
+
+// ErrTooManyWebhooks is returned when a user registers more webhooks than allowed
+var ErrTooManyWebhooks = errors.New("webhook limit reached")
+
+// WebhookService manages the webhooks of users and delivers todo events to
+// them. It is an events.Publisher fed by the outbox relay: each event becomes
+// a pending delivery per subscribed webhook, which the dispatcher sends
+// with retries. Delivery is at least once, receivers drop duplicates by the
+// event id
+type WebhookService struct {
+	store      storage.WebhookRepository
+	sender     *webhook.Sender
+	cfg        config.WebhooksConfig
+	pagination config.PaginationConfig
+	locker     lock.Locker
+	audit      *AuditLogger
+	log        logger.Logger
+	wake       chan struct{}
+}
+
+func NewWebhookService(
+	store storage.WebhookRepository,
+	sender *webhook.Sender,
+	cfg config.WebhooksConfig,
+	pagination config.PaginationConfig,
+	locker lock.Locker,
+	audit *AuditLogger,
+	log logger.Logger,
+) *WebhookService {
+	return &WebhookService{
+		store:      store,
+		sender:     sender,
+		cfg:        cfg,
+		pagination: pagination,
+		locker:     locker,
+		audit:      audit,
+		log:        log,
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+// WebhookInput holds the fields of a new webhook. An empty Secret is
+// generated and an empty Events subscribes to every event type
+type WebhookInput struct {
+	URL    string
+	Secret string
+	Events []string
+}
+
+// WebhookPatch holds the fields to change on a webhook, nil fields are left
+// unchanged
+type WebhookPatch struct {
+	URL    *string
+	Secret *string
+	Events *[]string
+	Active *bool
+}
+
+// DeliveryPage is a single page of webhook deliveries
+type DeliveryPage struct {
+	Deliveries []*models.WebhookDelivery
+	Page       int
+	PageSize   int
+	Total      int
+}
+
+// TotalPages returns the number of pages available with the current page size
+func (p *DeliveryPage) TotalPages() int {
+	return totalPages(p.Total, p.PageSize)
+}
+
+// webhookPayload is the JSON body of a delivery
+type webhookPayload struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// Create registers a webhook for the user, the returned webhook carries its
+// secret
+func (s *WebhookService) Create(ctx context.Context, userID int64, input WebhookInput) (*models.Webhook, error) {
+	webhookURL, err := s.validateURL(input.URL)
+	if err != nil {
+		return nil, err
+	}
+	subscribed, err := validateWebhookEvents(input.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := input.Secret
+	if secret == "" {
+		if secret, err = auth.RandomToken(32); err != nil {
+			return nil, err
+		}
+	} else if err := validateSecret(secret); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.store.ListWebhooks(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= s.cfg.MaxPerUser {
+		return nil, fmt.Errorf("%w: at most %d webhooks per user", ErrTooManyWebhooks, s.cfg.MaxPerUser)
+	}
+
+	hook := &models.Webhook{
+		UserID: userID,
+		URL:    webhookURL,
+		Secret: secret,
+		Events: subscribed,
+		Active: true,
+	}
+	if err := s.store.CreateWebhook(ctx, hook); err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, "webhook.create", nil, hook)
+	return hook, nil
+}
+
+// Get returns a webhook of the user
+func (s *WebhookService) Get(ctx context.Context, userID, id int64) (*models.Webhook, error) {
+	return s.store.GetWebhook(ctx, userID, id)
+}
+
+// List returns the user's webhooks, oldest first
+func (s *WebhookService) List(ctx context.Context, userID int64) ([]*models.Webhook, error) {
+	return s.store.ListWebhooks(ctx, userID)
+}
+
+// Update changes the fields set in patch. Reactivating a webhook does not
+// resend the events it missed
+func (s *WebhookService) Update(ctx context.Context, userID, id int64, patch WebhookPatch) (*models.Webhook, error) {
+	before, err := s.store.GetWebhook(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	hook := *before
+	if patch.URL != nil {
+		if hook.URL, err = s.validateURL(*patch.URL); err != nil {
+			return nil, err
+		}
+	}
+	if patch.Secret != nil {
+		if err := validateSecret(*patch.Secret); err != nil {
+			return nil, err
+		}
+		hook.Secret = *patch.Secret
+	}
+	if patch.Events != nil {
+		if hook.Events, err = validateWebhookEvents(*patch.Events); err != nil {
+			return nil, err
+		}
+	}
+	if patch.Active != nil {
+		hook.Active = *patch.Active
+	}
+
+	if err := s.store.UpdateWebhook(ctx, &hook); err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, "webhook.update", before, &hook)
+	return &hook, nil
+}
+
+// Delete removes a webhook together with its delivery history
+func (s *WebhookService) Delete(ctx context.Context, userID, id int64) error {
+	hook, err := s.store.GetWebhook(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if err := s.store.DeleteWebhook(ctx, userID, id); err != nil {
+		return err
+	}
+
+	s.record(ctx, "webhook.delete", hook, nil)
+	return nil
+}
+
+// Deliveries returns the requested page of the delivery history of a
+// webhook, newest first
+func (s *WebhookService) Deliveries(ctx context.Context, userID, webhookID int64, page, pageSize int) (*DeliveryPage, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = s.pagination.DefaultLimit
+	}
+	pageSize = min(pageSize, s.pagination.MaxLimit)
+
+	deliveries, total, err := s.store.ListDeliveries(ctx, userID, webhookID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeliveryPage{
+		Deliveries: deliveries,
+		Page:       page,
+		PageSize:   pageSize,
+		Total:      total,
+	}, nil
+}
+
+// Redeliver sends the event of a past delivery again as a new delivery with
+// a fresh set of attempts, whatever the outcome of the original
+func (s *WebhookService) Redeliver(ctx context.Context, userID, webhookID, deliveryID int64) (*models.WebhookDelivery, error) {
+	original, err := s.store.GetDelivery(ctx, userID, webhookID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	delivery := &models.WebhookDelivery{
+		WebhookID:     original.WebhookID,
+		UserID:        original.UserID,
+		EventID:       original.EventID,
+		EventType:     original.EventType,
+		Payload:       original.Payload,
+		Status:        models.DeliveryPending,
+		RedeliveryOf:  &original.ID,
+		NextAttemptAt: &now,
+	}
+	if _, err := s.store.CreateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+
+	s.notify()
+	return delivery, nil
+}
+
+// Publish queues a delivery of the event to every active webhook of its
+// owner subscribed to its type. An event published again, as the outbox
+// does after a failure, is not delivered twice
+func (s *WebhookService) Publish(ctx context.Context, _ string, event events.Event) error {
+	hooks, err := s.store.ListWebhooksForEvent(ctx, event.UserID, event.Type)
+	if err != nil {
+		return err
+	}
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(webhookPayload{
+		ID:         event.Key,
+		Type:       event.Type,
+		OccurredAt: event.OccurredAt,
+		Data:       event.Data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode %s webhook payload: %w", event.Type, err)
+	}
+
+	now := time.Now()
+	for _, hook := range hooks {
+		delivery := &models.WebhookDelivery{
+			WebhookID:     hook.ID,
+			UserID:        hook.UserID,
+			EventID:       event.Key,
+			EventType:     event.Type,
+			Payload:       body,
+			Status:        models.DeliveryPending,
+			NextAttemptAt: &now,
+		}
+		// The webhook may have been deleted meanwhile
+		if _, err := s.store.CreateDelivery(ctx, delivery); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return err
+		}
+	}
+
+	s.notify()
+	return nil
+}
+
+// Run dispatches due deliveries until ctx is done. Only the replica holding
+// the dispatcher lock sends, the others try to take over every poll interval
+func (s *WebhookService) Run(ctx context.Context) {
+	for {
+		lease, err := s.locker.Lock(ctx, "webhook_dispatcher", webhookLockTTL)
+		switch {
+		case err == nil:
+			s.log.Info("webhook dispatcher lock acquired", "token", lease.Token)
+			s.dispatch(lease.Context())
+			if err := lease.Unlock(); err != nil {
+				s.log.Warn("failed to release webhook dispatcher lock", "error", err)
+			}
+		case !errors.Is(err, lock.ErrNotAcquired) && ctx.Err() == nil:
+			s.log.Error("failed to acquire webhook dispatcher lock", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.cfg.PollInterval):
+		}
+	}
+}
+
+// dispatch sends due deliveries whenever new ones are queued and every poll
+// interval until ctx is done
+func (s *WebhookService) dispatch(ctx context.Context) {
+	poll := time.NewTicker(s.cfg.PollInterval)
+	defer poll.Stop()
+	purge := time.NewTicker(webhookPurgeInterval)
+	defer purge.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-poll.C:
+		case <-purge.C:
+			s.purge(ctx)
+			continue
+		}
+
+		// Keep going while full batches come back so a backlog drains quickly
+		for {
+			n, err := s.Dispatch(ctx)
+			if err != nil && ctx.Err() == nil {
+				s.log.Error("failed to dispatch webhook deliveries", "error", err)
+			}
+			if err != nil || n < s.cfg.BatchSize {
+				break
+			}
+		}
+	}
+}
+
+// Dispatch sends one batch of due deliveries, Concurrency at a time, and
+// returns how many were attempted
+func (s *WebhookService) Dispatch(ctx context.Context) (int, error) {
+	due, err := s.store.DueDeliveries(ctx, time.Now(), s.cfg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, s.cfg.Concurrency)
+	for _, delivery := range due {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			s.attempt(ctx, delivery)
+		}()
+	}
+	wg.Wait()
+
+	return len(due), nil
+}
+
+// attempt sends a delivery once and saves the outcome, scheduling a retry
+// with exponential backoff until the attempts run out
+func (s *WebhookService) attempt(ctx context.Context, delivery *models.WebhookDelivery) {
+	hook, err := s.store.GetWebhook(ctx, delivery.UserID, delivery.WebhookID)
+	if errors.Is(err, storage.ErrNotFound) {
+		// Deleted with its deliveries
+		return
+	}
+	if err != nil {
+		s.log.Error("failed to load webhook", "error", err, "webhook_id", delivery.WebhookID)
+		return
+	}
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.ResponseStatus = nil
+
+	var result webhook.Result
+	if hook.Active {
+		result = s.sender.Send(ctx, webhook.Request{
+			URL:        hook.URL,
+			Secret:     hook.Secret,
+			EventType:  delivery.EventType,
+			DeliveryID: delivery.ID,
+			Body:       delivery.Payload,
+		})
+	} else {
+		result = webhook.Result{Err: errors.New("webhook is inactive")}
+		delivery.Attempts = max(delivery.Attempts, s.cfg.MaxAttempts)
+	}
+	if ctx.Err() != nil {
+		// Shutting down or the lock was lost, the attempt does not count
+		return
+	}
+	if result.StatusCode != 0 {
+		delivery.ResponseStatus = &result.StatusCode
+	}
+
+	switch {
+	case result.Err == nil:
+		delivery.Status = models.DeliverySucceeded
+		delivery.LastError = ""
+		delivery.NextAttemptAt = nil
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= s.cfg.MaxAttempts:
+		delivery.Status = models.DeliveryFailed
+		delivery.LastError = truncate(result.Err.Error(), maxDeliveryError)
+		delivery.NextAttemptAt = nil
+		s.log.Warn("webhook delivery failed", "error", result.Err, "delivery_id", delivery.ID,
+			"webhook_id", delivery.WebhookID, "attempts", delivery.Attempts)
+	default:
+		next := now.Add(s.backoff(delivery.Attempts))
+		delivery.LastError = truncate(result.Err.Error(), maxDeliveryError)
+		delivery.NextAttemptAt = &next
+	}
+
+	metrics.Default.Counter("webhook_delivery_attempts_total", "Total number of webhook delivery attempts by outcome",
+		metrics.Labels{"outcome": deliveryOutcome(delivery)},
+	).Inc()
+
+	if err := s.store.UpdateDelivery(context.WithoutCancel(ctx), delivery); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		s.log.Error("failed to save webhook delivery", "error", err, "delivery_id", delivery.ID)
+	}
+}
+
+// backoff returns the delay before the next attempt after a number of failed
+// attempts
+func (s *WebhookService) backoff(attempts int) time.Duration {
+	return retry.Backoff(attempts-1, s.cfg.RetryBaseDelay, s.cfg.RetryMaxDelay)
+}
+
+func (s *WebhookService) purge(ctx context.Context) {
+	purged, err := s.store.PurgeDeliveries(ctx, time.Now().Add(-s.cfg.Retention))
+	if err != nil {
+		if ctx.Err() == nil {
+			s.log.Error("failed to purge webhook deliveries", "error", err)
+		}
+		return
+	}
+	if purged > 0 {
+		s.log.Info("purged webhook deliveries", "count", purged)
+	}
+}
+
+// notify wakes the dispatcher so new deliveries are sent without waiting for
+// the next poll
+func (s *WebhookService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// validateURL checks that a webhook URL is an absolute http or https URL
+// without credentials. Hosts that are obviously internal are refused early,
+// the sender checks the resolved address of every connection
+func (s *WebhookService) validateURL(raw string) (string, error) {
+	return validateWebhookURL(raw, s.cfg.AllowPrivateNetworks)
+}
+
+// validateWebhookURL accepts absolute http and https URLs without
+// credentials, only pointing to public addresses unless allowPrivate is set
+func validateWebhookURL(raw string, allowPrivate bool) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if len(raw) > maxWebhookURLLength {
+		return "", invalidField("url", "url must be at most %d characters", maxWebhookURLLength)
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", invalidField("url", "url must be an absolute http or https URL")
+	}
+	if u.User != nil {
+		return "", invalidField("url", "url must not contain credentials")
+	}
+
+	if !allowPrivate {
+		host := strings.ToLower(u.Hostname())
+		ip := net.ParseIP(host)
+		if host == "localhost" || strings.HasSuffix(host, ".localhost") ||
+			(ip != nil && (ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsUnspecified())) {
+			return "", invalidField("url", "url must point to a public address")
+		}
+	}
+	return u.String(), nil
+}
+
+func (s *WebhookService) record(ctx context.Context, action string, before, after *models.Webhook) {
+	subject := after
+	if subject == nil {
+		subject = before
+	}
+
+	s.audit.Record(ctx, AuditEntry{
+		UserID:     &subject.UserID,
+		Action:     action,
+		EntityType: EntityWebhook,
+		EntityID:   subject.ID,
+		Before:     before,
+		After:      after,
+	})
+}
+
+// validateWebhookEvents checks the subscribed event types, dropping duplicates
+func validateWebhookEvents(subscribed []string) ([]string, error) {
+	unique := make([]string, 0, len(subscribed))
+	for _, eventType := range subscribed {
+		if !slices.Contains(WebhookEvents, eventType) {
+			return nil, invalidField("events", "unknown event type %q, expected one of %s", eventType, strings.Join(WebhookEvents, ", "))
+		}
+		if !slices.Contains(unique, eventType) {
+			unique = append(unique, eventType)
+		}
+	}
+	return unique, nil
+}
+
+func validateSecret(secret string) error {
+	if len(secret) < minSecretLength || len(secret) > maxSecretLength {
+		return invalidField("secret", "secret must be between %d and %d characters", minSecretLength, maxSecretLength)
+	}
+	return nil
+}
+
+func deliveryOutcome(delivery *models.WebhookDelivery) string {
+	if delivery.Status == models.DeliveryPending {
+		return "retry"
+	}
+	return delivery.Status
+}
+
+// truncate cuts s to at most n bytes without splitting a character
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
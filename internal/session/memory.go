@@ -0,0 +1,139 @@
+package session
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps sessions in process for single instance deployments,
+// they are lost on restart
+type MemoryStore struct {
+	mu       sync.Mutex
+	sessions map[string]memoryEntry
+	byUser   map[int64]map[string]struct{}
+
+	stop chan struct{}
+	once sync.Once
+}
+
+type memoryEntry struct {
+	session   Session
+	expiresAt time.Time
+}
+
+// NewMemoryStore creates a store and starts a janitor that evicts expired
+// sessions
+func NewMemoryStore() *MemoryStore {
+	s := &MemoryStore{
+		sessions: make(map[string]memoryEntry),
+		byUser:   make(map[int64]map[string]struct{}),
+		stop:     make(chan struct{}),
+	}
+
+	go s.cleanup(time.Minute)
+	return s
+}
+
+func (s *MemoryStore) Save(_ context.Context, session *Session, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[session.ID] = memoryEntry{session: *session, expiresAt: time.Now().Add(ttl)}
+	ids, ok := s.byUser[session.UserID]
+	if !ok {
+		ids = make(map[string]struct{})
+		s.byUser[session.UserID] = ids
+	}
+	ids[session.ID] = struct{}{}
+	return nil
+}
+
+func (s *MemoryStore) Touch(_ context.Context, session *Session, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.sessions[session.ID]
+	if !ok || entry.session.UserID != session.UserID || time.Now().After(entry.expiresAt) {
+		return ErrNotFound
+	}
+	s.sessions[session.ID] = memoryEntry{session: *session, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (s *MemoryStore) Get(_ context.Context, id string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.sessions[id]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, ErrNotFound
+	}
+	session := entry.session
+	return &session, nil
+}
+
+func (s *MemoryStore) List(_ context.Context, userID int64) ([]*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	sessions := make([]*Session, 0, len(s.byUser[userID]))
+	for id := range s.byUser[userID] {
+		entry, ok := s.sessions[id]
+		if !ok || now.After(entry.expiresAt) {
+			continue
+		}
+		session := entry.session
+		sessions = append(sessions, &session)
+	}
+	return sessions, nil
+}
+
+func (s *MemoryStore) Delete(_ context.Context, userID int64, ids ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range ids {
+		if entry, ok := s.sessions[id]; ok && entry.session.UserID == userID {
+			delete(s.sessions, id)
+		}
+		delete(s.byUser[userID], id)
+	}
+	if len(s.byUser[userID]) == 0 {
+		delete(s.byUser, userID)
+	}
+	return nil
+}
+
+// Close stops the janitor goroutine
+func (s *MemoryStore) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	return nil
+}
+
+// cleanup periodically removes expired sessions
+func (s *MemoryStore) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			now := time.Now()
+			s.mu.Lock()
+			for id, entry := range s.sessions {
+				if now.After(entry.expiresAt) {
+					delete(s.sessions, id)
+					delete(s.byUser[entry.session.UserID], id)
+					if len(s.byUser[entry.session.UserID]) == 0 {
+						delete(s.byUser, entry.session.UserID)
+					}
+				}
+			}
+			s.mu.Unlock()
+		case <-s.stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// saveScript stores a session and indexes it under its user. The index
+// lives as long as the longest lived session of the user
+//
+// KEYS: session, user index
+// ARGV: session JSON, ttl in ms, session id
+var saveScript = redis.NewScript(`
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+redis.call('SADD', KEYS[2], ARGV[3])
+if redis.call('PTTL', KEYS[2]) < tonumber(ARGV[2]) then
+	redis.call('PEXPIRE', KEYS[2], ARGV[2])
+end
+return 1
+`)
+
+// touchScript replaces a session only if it still exists, a revocation
+// deleting it first is not undone. Index entries are kept by Save, only the
+// lifetime of the index is extended
+//
+// KEYS: session, user index
+// ARGV: session JSON, ttl in ms
+var touchScript = redis.NewScript(`
+if not redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2], 'XX') then
+	return 0
+end
+if redis.call('PTTL', KEYS[2]) < tonumber(ARGV[2]) then
+	redis.call('PEXPIRE', KEYS[2], ARGV[2])
+end
+return 1
+`)
+
+// RedisStore keeps sessions in Redis so every replica sees them and they
+// survive restarts
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStore creates a store, keys are namespaced under prefix
+func NewRedisStore(client *redis.Client, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix + "session:"}
+}
+
+func (s *RedisStore) Save(ctx context.Context, session *Session, ttl time.Duration) error {
+	payload, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+
+	err = saveScript.Run(ctx, s.client,
+		[]string{s.sessionKey(session.ID), s.userKey(session.UserID)},
+		payload, ttl.Milliseconds(), session.ID,
+	).Err()
+	if err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) Touch(ctx context.Context, session *Session, ttl time.Duration) error {
+	payload, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+
+	touched, err := touchScript.Run(ctx, s.client,
+		[]string{s.sessionKey(session.ID), s.userKey(session.UserID)},
+		payload, ttl.Milliseconds(),
+	).Int()
+	if err != nil {
+		return fmt.Errorf("failed to touch session: %w", err)
+	}
+	if touched == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *RedisStore) Get(ctx context.Context, id string) (*Session, error) {
+	payload, err := s.client.Get(ctx, s.sessionKey(id)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	var session Session
+	if err := json.Unmarshal(payload, &session); err != nil {
+		return nil, fmt.Errorf("failed to decode session: %w", err)
+	}
+	return &session, nil
+}
+
+// List returns the sessions of the user, dropping the expired ones from
+// the user's index
+func (s *RedisStore) List(ctx context.Context, userID int64) ([]*Session, error) {
+	ids, err := s.client.SMembers(ctx, s.userKey(userID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	if len(ids) == 0 {
+		return []*Session{}, nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = s.sessionKey(id)
+	}
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	sessions := make([]*Session, 0, len(ids))
+	var expired []any
+	for i, value := range values {
+		payload, ok := value.(string)
+		if !ok {
+			expired = append(expired, ids[i])
+			continue
+		}
+		var session Session
+		if err := json.Unmarshal([]byte(payload), &session); err != nil {
+			return nil, fmt.Errorf("failed to decode session: %w", err)
+		}
+		sessions = append(sessions, &session)
+	}
+
+	if len(expired) > 0 {
+		if err := s.client.SRem(ctx, s.userKey(userID), expired...).Err(); err != nil {
+			return nil, fmt.Errorf("failed to prune sessions: %w", err)
+		}
+	}
+	return sessions, nil
+}
+
+func (s *RedisStore) Delete(ctx context.Context, userID int64, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	keys := make([]string, len(ids))
+	members := make([]any, len(ids))
+	for i, id := range ids {
+		keys[i] = s.sessionKey(id)
+		members[i] = id
+	}
+
+	pipe := s.client.TxPipeline()
+	pipe.Del(ctx, keys...)
+	pipe.SRem(ctx, s.userKey(userID), members...)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to delete sessions: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) sessionKey(id string) string {
+	return s.prefix + id
+}
+
+func (s *RedisStore) userKey(userID int64) string {
+	return s.prefix + "user:" + strconv.FormatInt(userID, 10)
+}
@@ -0,0 +1,66 @@
+// Package session keeps server-side login sessions for browser clients,
+// identified by an opaque cookie instead of a bearer token. Sessions can be
+// listed and revoked individually, unlike stateless access tokens
+package session
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned when a session does not exist or has expired
+var ErrNotFound = errors.New("session not found")
+
+// Session is a login session. ID is the hash of the cookie value, the
+// cookie cannot be recovered from the store and the ID can be shown to the
+// user. ExpiresAt bounds the lifetime however active the session is
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     int64     `json:"user_id"`
+	CSRFToken  string    `json:"csrf_token"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// Store keeps sessions until their time to live passes
+type Store interface {
+	// Save creates or replaces the session, it expires after ttl
+	Save(ctx context.Context, s *Session, ttl time.Duration) error
+
+	// Touch replaces a session that still exists, it expires after ttl.
+	// It returns ErrNotFound when the session was deleted or expired, so a
+	// touch racing a revocation cannot bring the session back
+	Touch(ctx context.Context, s *Session, ttl time.Duration) error
+
+	// Get returns the session with the given id or ErrNotFound
+	Get(ctx context.Context, id string) (*Session, error)
+
+	// List returns the sessions of the user in no particular order
+	List(ctx context.Context, userID int64) ([]*Session, error)
+
+	// Delete removes the given sessions of the user, missing ones are
+	// ignored
+	Delete(ctx context.Context, userID int64, ids ...string) error
+}
+
+// EndUserSessions deletes every session of the user, signing them out on
+// all devices. A nil store has no sessions
+func EndUserSessions(ctx context.Context, store Store, userID int64) error {
+	if store == nil {
+		return nil
+	}
+
+	sessions, err := store.List(ctx, userID)
+	if err != nil {
+		return err
+	}
+	ids := make([]string, len(sessions))
+	for i, sess := range sessions {
+		ids[i] = sess.ID
+	}
+	return store.Delete(ctx, userID, ids...)
+}
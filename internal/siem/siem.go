@@ -0,0 +1,195 @@
+// Package siem exports security events to a security information and event
+// management system, either through the application log or over HTTP
+package siem
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+	"github.com/MuthuM3/gin-microservice-template/internal/metrics"
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+)
+
+// flushTimeout bounds the export of the events still queued on shutdown
+const flushTimeout = 5 * time.Second
+
+// Exporter ships a batch of security events to a SIEM
+type Exporter interface {
+	Export(ctx context.Context, events []*models.SecurityEvent) error
+}
+
+// LogExporter writes every event as a structured log entry, for SIEMs that
+// collect the application logs
+type LogExporter struct {
+	log logger.Logger
+}
+
+func NewLogExporter(log logger.Logger) *LogExporter {
+	return &LogExporter{log: log.With("component", "siem")}
+}
+
+func (e *LogExporter) Export(_ context.Context, events []*models.SecurityEvent) error {
+	for _, event := range events {
+		args := []any{
+			"security_event_id", event.ID,
+			"type", event.Type,
+			"email", event.Email,
+			"ip_address", event.IPAddress,
+			"user_agent", event.UserAgent,
+			"request_id", event.RequestID,
+			"created_at", event.CreatedAt,
+		}
+		if event.UserID != nil {
+			args = append(args, "user_id", *event.UserID)
+		}
+		for key, value := range event.Details {
+			args = append(args, "detail_"+key, value)
+		}
+		e.log.Info("security event", args...)
+	}
+	return nil
+}
+
+// HTTPExporter posts batches of events as a JSON array to a collector,
+// authenticated with a bearer token when one is configured
+type HTTPExporter struct {
+	client *http.Client
+	url    string
+	token  string
+}
+
+func NewHTTPExporter(client *http.Client, url, token string) *HTTPExporter {
+	return &HTTPExporter{client: client, url: url, token: token}
+}
+
+func (e *HTTPExporter) Export(ctx context.Context, events []*models.SecurityEvent) error {
+	body, err := json.Marshal(events)
+	if err != nil {
+		return fmt.Errorf("failed to encode security events: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create siem request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.token != "" {
+		req.Header.Set("Authorization", "Bearer "+e.token)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export security events: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("siem responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// Forwarder exports events in the background so recording them never waits
+// on the SIEM. Events are sent once a batch fills up or the export interval
+// passes; when the queue is full new events are dropped and counted
+type Forwarder struct {
+	exporter  Exporter
+	queue     chan *models.SecurityEvent
+	batchSize int
+	interval  time.Duration
+	log       logger.Logger
+}
+
+func NewForwarder(exporter Exporter, cfg config.SecurityEventsConfig, log logger.Logger) *Forwarder {
+	return &Forwarder{
+		exporter:  exporter,
+		queue:     make(chan *models.SecurityEvent, cfg.ExportBufferSize),
+		batchSize: cfg.ExportBatchSize,
+		interval:  cfg.ExportInterval,
+		log:       log,
+	}
+}
+
+// Enqueue queues an event for export without blocking and reports whether
+// there was room for it
+func (f *Forwarder) Enqueue(event *models.SecurityEvent) bool {
+	select {
+	case f.queue <- event:
+		return true
+	default:
+		countExport("dropped", 1)
+		return false
+	}
+}
+
+// Run exports queued events until ctx is cancelled, then makes a last
+// attempt at the events still queued
+func (f *Forwarder) Run(ctx context.Context) {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	batch := make([]*models.SecurityEvent, 0, f.batchSize)
+	for {
+		select {
+		case event := <-f.queue:
+			batch = append(batch, event)
+			if len(batch) >= f.batchSize {
+				batch = f.export(ctx, batch)
+			}
+		case <-ticker.C:
+			batch = f.export(ctx, batch)
+		case <-ctx.Done():
+			f.drain(batch)
+			return
+		}
+	}
+}
+
+// drain exports the pending batch and whatever is left in the queue
+func (f *Forwarder) drain(batch []*models.SecurityEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+
+	for {
+		select {
+		case event := <-f.queue:
+			batch = append(batch, event)
+			if len(batch) >= f.batchSize {
+				batch = f.export(ctx, batch)
+			}
+		default:
+			f.export(ctx, batch)
+			return
+		}
+	}
+}
+
+// export sends the batch and returns it emptied for reuse. Failed batches
+// are dropped, the events remain in the database
+func (f *Forwarder) export(ctx context.Context, batch []*models.SecurityEvent) []*models.SecurityEvent {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	if err := f.exporter.Export(ctx, batch); err != nil {
+		f.log.Error("failed to export security events", "error", err, "count", len(batch))
+		countExport("failed", len(batch))
+	} else {
+		countExport("exported", len(batch))
+	}
+	return batch[:0]
+}
+
+// countExport counts events by the outcome of their export
+func countExport(result string, n int) {
+	metrics.Default.Counter("siem_events_total", "Total number of security events handed to the SIEM exporter by result",
+		metrics.Labels{"result": result}).Add(float64(n))
+}
@@ -0,0 +1,239 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+// CreateAttachment inserts the metadata of a file attached to one of the
+// user's todos
+func (s *TodoStore) CreateAttachment(_ context.Context, attachment *models.Attachment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.createAttachment(attachment)
+}
+
+// GetAttachment returns an attachment of one of the user's todos
+func (s *TodoStore) GetAttachment(_ context.Context, userID, todoID, id int64) (*models.Attachment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.getAttachment(userID, todoID, id)
+}
+
+// GetUserAttachment returns an attachment of any of the user's todos
+// outside the trash
+func (s *TodoStore) GetUserAttachment(_ context.Context, userID, id int64) (*models.Attachment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.getUserAttachment(userID, id)
+}
+
+// ListAttachments returns the ready attachments of a todo, oldest first
+func (s *TodoStore) ListAttachments(_ context.Context, userID, todoID int64) ([]*models.Attachment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.listAttachments(userID, todoID)
+}
+
+// ListUserAttachments returns the ready attachments of the user's todos,
+// trashed or not, oldest first
+func (s *TodoStore) ListUserAttachments(_ context.Context, userID int64) ([]*models.Attachment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.listUserAttachments(userID), nil
+}
+
+// ConfirmAttachment marks a pending attachment ready
+func (s *TodoStore) ConfirmAttachment(_ context.Context, userID, id, size int64, contentType string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.confirmAttachment(userID, id, size, contentType)
+}
+
+// DeleteAttachment removes the metadata of an attachment
+func (s *TodoStore) DeleteAttachment(_ context.Context, userID, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.deleteAttachment(userID, id)
+}
+
+// ListOrphanedAttachments returns attachments of purged todos and stale
+// pending uploads, oldest first
+func (s *TodoStore) ListOrphanedAttachments(_ context.Context, pendingBefore time.Time, limit int) ([]*models.Attachment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.listOrphanedAttachments(pendingBefore, limit)
+}
+
+// AttachmentBytes returns the total size of the user's attachments
+func (s *TodoStore) AttachmentBytes(_ context.Context, userID int64) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.attachmentBytes(userID), nil
+}
+
+func (t *todoTx) CreateAttachment(_ context.Context, attachment *models.Attachment) error {
+	return t.data.createAttachment(attachment)
+}
+
+func (t *todoTx) GetAttachment(_ context.Context, userID, todoID, id int64) (*models.Attachment, error) {
+	return t.data.getAttachment(userID, todoID, id)
+}
+
+func (t *todoTx) GetUserAttachment(_ context.Context, userID, id int64) (*models.Attachment, error) {
+	return t.data.getUserAttachment(userID, id)
+}
+
+func (t *todoTx) ListAttachments(_ context.Context, userID, todoID int64) ([]*models.Attachment, error) {
+	return t.data.listAttachments(userID, todoID)
+}
+
+func (t *todoTx) ListUserAttachments(_ context.Context, userID int64) ([]*models.Attachment, error) {
+	return t.data.listUserAttachments(userID), nil
+}
+
+func (t *todoTx) ConfirmAttachment(_ context.Context, userID, id, size int64, contentType string) error {
+	return t.data.confirmAttachment(userID, id, size, contentType)
+}
+
+func (t *todoTx) DeleteAttachment(_ context.Context, userID, id int64) error {
+	return t.data.deleteAttachment(userID, id)
+}
+
+func (t *todoTx) ListOrphanedAttachments(_ context.Context, pendingBefore time.Time, limit int) ([]*models.Attachment, error) {
+	return t.data.listOrphanedAttachments(pendingBefore, limit)
+}
+
+func (t *todoTx) AttachmentBytes(_ context.Context, userID int64) (int64, error) {
+	return t.data.attachmentBytes(userID), nil
+}
+
+func (d *todoData) createAttachment(attachment *models.Attachment) error {
+	if _, err := d.getByID(attachment.UserID, attachment.TodoID); err != nil {
+		return err
+	}
+
+	d.nextAttachmentID++
+	attachment.ID = d.nextAttachmentID
+	attachment.CreatedAt = time.Now()
+	if attachment.Status == "" {
+		attachment.Status = models.AttachmentReady
+	}
+	d.attachments[attachment.ID] = *attachment
+	return nil
+}
+
+func (d *todoData) getAttachment(userID, todoID, id int64) (*models.Attachment, error) {
+	if _, err := d.getByID(userID, todoID); err != nil {
+		return nil, err
+	}
+
+	attachment, ok := d.attachments[id]
+	if !ok || attachment.UserID != userID || attachment.TodoID != todoID {
+		return nil, storage.ErrNotFound
+	}
+	return &attachment, nil
+}
+
+func (d *todoData) getUserAttachment(userID, id int64) (*models.Attachment, error) {
+	attachment, ok := d.attachments[id]
+	if !ok || attachment.UserID != userID {
+		return nil, storage.ErrNotFound
+	}
+	return d.getAttachment(userID, attachment.TodoID, id)
+}
+
+func (d *todoData) listAttachments(userID, todoID int64) ([]*models.Attachment, error) {
+	if _, err := d.getByID(userID, todoID); err != nil {
+		return nil, err
+	}
+
+	attachments := make([]*models.Attachment, 0)
+	for _, attachment := range d.attachments {
+		if attachment.TodoID == todoID && attachment.Status == models.AttachmentReady {
+			attachment := attachment
+			attachments = append(attachments, &attachment)
+		}
+	}
+
+	sort.Slice(attachments, func(i, j int) bool { return attachments[i].ID < attachments[j].ID })
+	return attachments, nil
+}
+
+func (d *todoData) listUserAttachments(userID int64) []*models.Attachment {
+	attachments := make([]*models.Attachment, 0)
+	for _, attachment := range d.attachments {
+		if attachment.UserID == userID && attachment.TodoID != 0 && attachment.Status == models.AttachmentReady {
+			attachment := attachment
+			attachments = append(attachments, &attachment)
+		}
+	}
+
+	sort.Slice(attachments, func(i, j int) bool { return attachments[i].ID < attachments[j].ID })
+	return attachments
+}
+
+func (d *todoData) confirmAttachment(userID, id, size int64, contentType string) error {
+	attachment, ok := d.attachments[id]
+	if !ok || attachment.UserID != userID || attachment.Status != models.AttachmentPending {
+		return storage.ErrNotFound
+	}
+
+	attachment.Status = models.AttachmentReady
+	attachment.Size = size
+	attachment.ContentType = contentType
+	d.attachments[id] = attachment
+	return nil
+}
+
+func (d *todoData) deleteAttachment(userID, id int64) error {
+	attachment, ok := d.attachments[id]
+	if !ok || attachment.UserID != userID {
+		return storage.ErrNotFound
+	}
+
+	delete(d.attachments, id)
+	return nil
+}
+
+func (d *todoData) listOrphanedAttachments(pendingBefore time.Time, limit int) ([]*models.Attachment, error) {
+	attachments := make([]*models.Attachment, 0)
+	for _, attachment := range d.attachments {
+		stale := attachment.Status == models.AttachmentPending && attachment.CreatedAt.Before(pendingBefore)
+		if attachment.TodoID == 0 || stale {
+			attachment := attachment
+			attachments = append(attachments, &attachment)
+		}
+	}
+
+	sort.Slice(attachments, func(i, j int) bool { return attachments[i].ID < attachments[j].ID })
+	if len(attachments) > limit {
+		attachments = attachments[:limit]
+	}
+	return attachments, nil
+}
+
+func (d *todoData) attachmentBytes(userID int64) int64 {
+	var total int64
+	for _, attachment := range d.attachments {
+		if attachment.UserID == userID {
+			total += attachment.Size
+		}
+	}
+	return total
+}
+
+// orphanAttachments detaches the attachments of a removed todo, mirroring the
+// ON DELETE SET NULL of the todo_id foreign key
+func (d *todoData) orphanAttachments(todoID int64) {
+	for id, attachment := range d.attachments {
+		if attachment.TodoID == todoID {
+			attachment.TodoID = 0
+			d.attachments[id] = attachment
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+// AuditStore keeps the audit trail in append order
+type AuditStore struct {
+	mu     sync.RWMutex
+	nextID int64
+	events []models.AuditEvent
+}
+
+func newAuditStore() *AuditStore {
+	return &AuditStore{}
+}
+
+// CreateAuditEvent appends an event to the audit trail
+func (s *AuditStore) CreateAuditEvent(_ context.Context, event *models.AuditEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	event.ID = s.nextID
+	event.CreatedAt = time.Now()
+	s.events = append(s.events, *event)
+	return nil
+}
+
+// ListAuditEvents returns a page of events matching the filter ordered from
+// newest to oldest along with the total number of matches
+func (s *AuditStore) ListAuditEvents(_ context.Context, filter storage.AuditFilter, limit, offset int) ([]*models.AuditEvent, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Events are appended in order, so walking backwards yields newest first
+	matched := make([]*models.AuditEvent, 0)
+	for i := len(s.events) - 1; i >= 0; i-- {
+		event := s.events[i]
+		if auditMatches(&event, filter) {
+			matched = append(matched, &event)
+		}
+	}
+
+	total := len(matched)
+	if offset >= total {
+		return []*models.AuditEvent{}, total, nil
+	}
+	end := min(offset+limit, total)
+
+	return matched[offset:end], total, nil
+}
+
+func auditMatches(event *models.AuditEvent, filter storage.AuditFilter) bool {
+	switch {
+	case filter.UserID != nil && (event.UserID == nil || *event.UserID != *filter.UserID):
+		return false
+	case filter.Action != "" && event.Action != filter.Action:
+		return false
+	case filter.EntityType != "" && event.EntityType != filter.EntityType:
+		return false
+	case filter.EntityID != "" && event.EntityID != filter.EntityID:
+		return false
+	case filter.Since != nil && event.CreatedAt.Before(*filter.Since):
+		return false
+	case filter.Until != nil && !event.CreatedAt.Before(*filter.Until):
+		return false
+	}
+	return true
+}
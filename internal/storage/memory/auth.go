@@ -0,0 +1,739 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+// AuthStore keeps users, sessions and the various tokens in maps guarded by a
+// single lock. Transactions hold the lock for their duration and restore a
+// snapshot when they fail, so they are both isolated and atomic
+type AuthStore struct {
+	mu   sync.RWMutex
+	data *authData
+}
+
+// authData holds the store contents, its methods expect the caller to hold the lock
+type authData struct {
+	nextUserID    int64
+	users         map[int64]models.User
+	profiles      map[int64]profileData
+	channels      map[channelKey]models.NotificationChannel
+	sessions      map[string]models.Session
+	refreshTokens map[string]models.RefreshToken
+	identities    map[identityKey]models.Identity
+	resets        map[string]models.PasswordReset
+	verifications map[string]models.EmailVerification
+}
+
+type identityKey struct {
+	provider, subject string
+}
+
+func newAuthStore() *AuthStore {
+	return &AuthStore{
+		data: &authData{
+			users:         make(map[int64]models.User),
+			profiles:      make(map[int64]profileData),
+			channels:      make(map[channelKey]models.NotificationChannel),
+			sessions:      make(map[string]models.Session),
+			refreshTokens: make(map[string]models.RefreshToken),
+			identities:    make(map[identityKey]models.Identity),
+			resets:        make(map[string]models.PasswordReset),
+			verifications: make(map[string]models.EmailVerification),
+		},
+	}
+}
+
+func (d *authData) clone() *authData {
+	return &authData{
+		nextUserID:    d.nextUserID,
+		users:         maps.Clone(d.users),
+		profiles:      maps.Clone(d.profiles),
+		channels:      maps.Clone(d.channels),
+		sessions:      maps.Clone(d.sessions),
+		refreshTokens: maps.Clone(d.refreshTokens),
+		identities:    maps.Clone(d.identities),
+		resets:        maps.Clone(d.resets),
+		verifications: maps.Clone(d.verifications),
+	}
+}
+
+// InTx runs fn while holding the store lock, rolling back all of its writes
+// when it returns an error or panics
+func (s *AuthStore) InTx(_ context.Context, fn func(repo storage.AuthRepository) error) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := s.data.clone()
+	defer func() {
+		if p := recover(); p != nil {
+			s.data = snapshot
+			panic(p)
+		}
+		if err != nil {
+			s.data = snapshot
+		}
+	}()
+
+	return fn(&authTx{data: s.data})
+}
+
+// CreateUser inserts a new user, returning storage.ErrConflict when the email is taken
+func (s *AuthStore) CreateUser(_ context.Context, user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.createUser(user)
+}
+
+// GetUserByEmail looks up a user by email, case-insensitively
+func (s *AuthStore) GetUserByEmail(_ context.Context, email string) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.getUserByEmail(email)
+}
+
+// GetUserByID looks up a user by id
+func (s *AuthStore) GetUserByID(_ context.Context, id int64) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.getUserByID(id)
+}
+
+// UpdatePassword replaces the user's password hash
+func (s *AuthStore) UpdatePassword(_ context.Context, userID int64, passwordHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.updatePassword(userID, passwordHash)
+}
+
+// SetRole changes the user's role
+func (s *AuthStore) SetRole(_ context.Context, userID int64, role string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.setRole(userID, role)
+}
+
+// MarkEmailVerified records when the user verified their email
+func (s *AuthStore) MarkEmailVerified(_ context.Context, userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.markEmailVerified(userID)
+}
+
+// ListUsers returns a page of users matching the filter ordered by id, and
+// the number of matching users
+func (s *AuthStore) ListUsers(_ context.Context, filter storage.UserFilter, limit, offset int) ([]*models.User, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.listUsers(filter, limit, offset)
+}
+
+// UpdateUser saves the email, name and external id of the user
+func (s *AuthStore) UpdateUser(_ context.Context, user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.updateUser(user)
+}
+
+// SetDeactivated deactivates or reactivates the user
+func (s *AuthStore) SetDeactivated(_ context.Context, userID int64, deactivated bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.setDeactivated(userID, deactivated)
+}
+
+// CreateSession inserts a new login session
+func (s *AuthStore) CreateSession(_ context.Context, session *models.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.createSession(session)
+}
+
+// GetSession returns the session with the given id
+func (s *AuthStore) GetSession(_ context.Context, id string) (*models.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.getSession(id)
+}
+
+// RevokeSession marks the session as revoked, invalidating its refresh and access tokens
+func (s *AuthStore) RevokeSession(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.revokeSession(id)
+}
+
+// ListSessions returns the active sessions of the user, most recently seen
+// first
+func (s *AuthStore) ListSessions(_ context.Context, userID int64) ([]*models.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.listSessions(userID)
+}
+
+// TouchSession records that the session was used now from the device
+func (s *AuthStore) TouchSession(_ context.Context, id, ipAddress, userAgent string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.touchSession(id, ipAddress, userAgent)
+}
+
+// RevokeOtherSessions revokes every active session of the user but keepID
+// and returns how many were revoked
+func (s *AuthStore) RevokeOtherSessions(_ context.Context, userID int64, keepID string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.revokeOtherSessions(userID, keepID)
+}
+
+// RevokeUserSessions revokes every active session of the user
+func (s *AuthStore) RevokeUserSessions(_ context.Context, userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.revokeUserSessions(userID)
+}
+
+// IsRevoked reports whether the session has been revoked or has expired,
+// unknown sessions are treated as revoked
+func (s *AuthStore) IsRevoked(_ context.Context, sessionID string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.isRevoked(sessionID)
+}
+
+// CreateRefreshToken stores a new refresh token hash
+func (s *AuthStore) CreateRefreshToken(_ context.Context, token *models.RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.createRefreshToken(token)
+}
+
+// GetRefreshToken returns the refresh token with the given hash
+func (s *AuthStore) GetRefreshToken(_ context.Context, tokenHash string) (*models.RefreshToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.getRefreshToken(tokenHash)
+}
+
+// MarkRefreshTokenUsed consumes the refresh token, it reports false when the
+// token had already been used
+func (s *AuthStore) MarkRefreshTokenUsed(_ context.Context, tokenHash string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.markRefreshTokenUsed(tokenHash)
+}
+
+// GetIdentity returns the external identity linked to a user
+func (s *AuthStore) GetIdentity(_ context.Context, provider, subject string) (*models.Identity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.getIdentity(provider, subject)
+}
+
+// CreateIdentity links an external identity to a user
+func (s *AuthStore) CreateIdentity(_ context.Context, identity *models.Identity) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.createIdentity(identity)
+}
+
+// CreatePasswordReset stores a new password reset token hash
+func (s *AuthStore) CreatePasswordReset(_ context.Context, reset *models.PasswordReset) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.createPasswordReset(reset)
+}
+
+// ConsumePasswordReset marks the token as used if it is unused and has not expired
+func (s *AuthStore) ConsumePasswordReset(_ context.Context, tokenHash string) (*models.PasswordReset, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.consumePasswordReset(tokenHash)
+}
+
+// CreateEmailVerification stores a new email verification token hash
+func (s *AuthStore) CreateEmailVerification(_ context.Context, verification *models.EmailVerification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.createEmailVerification(verification)
+}
+
+// ConsumeEmailVerification marks the token as used if it is unused and has not expired
+func (s *AuthStore) ConsumeEmailVerification(_ context.Context, tokenHash string) (*models.EmailVerification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.consumeEmailVerification(tokenHash)
+}
+
+// PurgeExpired removes every token and session that expired before the cutoff
+func (s *AuthStore) PurgeExpired(_ context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.purgeExpired(before)
+}
+
+// authTx is the repository handed to transactions, the lock is already held
+type authTx struct {
+	data *authData
+}
+
+// InTx joins the surrounding transaction
+func (t *authTx) InTx(_ context.Context, fn func(repo storage.AuthRepository) error) error {
+	return fn(t)
+}
+
+func (t *authTx) CreateUser(_ context.Context, user *models.User) error {
+	return t.data.createUser(user)
+}
+
+func (t *authTx) GetUserByEmail(_ context.Context, email string) (*models.User, error) {
+	return t.data.getUserByEmail(email)
+}
+
+func (t *authTx) GetUserByID(_ context.Context, id int64) (*models.User, error) {
+	return t.data.getUserByID(id)
+}
+
+func (t *authTx) UpdatePassword(_ context.Context, userID int64, passwordHash string) error {
+	return t.data.updatePassword(userID, passwordHash)
+}
+
+func (t *authTx) SetRole(_ context.Context, userID int64, role string) error {
+	return t.data.setRole(userID, role)
+}
+
+func (t *authTx) MarkEmailVerified(_ context.Context, userID int64) error {
+	return t.data.markEmailVerified(userID)
+}
+
+func (t *authTx) ListUsers(_ context.Context, filter storage.UserFilter, limit, offset int) ([]*models.User, int, error) {
+	return t.data.listUsers(filter, limit, offset)
+}
+
+func (t *authTx) UpdateUser(_ context.Context, user *models.User) error {
+	return t.data.updateUser(user)
+}
+
+func (t *authTx) SetDeactivated(_ context.Context, userID int64, deactivated bool) error {
+	return t.data.setDeactivated(userID, deactivated)
+}
+
+func (t *authTx) CreateSession(_ context.Context, session *models.Session) error {
+	return t.data.createSession(session)
+}
+
+func (t *authTx) GetSession(_ context.Context, id string) (*models.Session, error) {
+	return t.data.getSession(id)
+}
+
+func (t *authTx) RevokeSession(_ context.Context, id string) error {
+	return t.data.revokeSession(id)
+}
+
+func (t *authTx) ListSessions(_ context.Context, userID int64) ([]*models.Session, error) {
+	return t.data.listSessions(userID)
+}
+
+func (t *authTx) TouchSession(_ context.Context, id, ipAddress, userAgent string) error {
+	return t.data.touchSession(id, ipAddress, userAgent)
+}
+
+func (t *authTx) RevokeOtherSessions(_ context.Context, userID int64, keepID string) (int64, error) {
+	return t.data.revokeOtherSessions(userID, keepID)
+}
+
+func (t *authTx) IsRevoked(_ context.Context, sessionID string) (bool, error) {
+	return t.data.isRevoked(sessionID)
+}
+
+func (t *authTx) CreateRefreshToken(_ context.Context, token *models.RefreshToken) error {
+	return t.data.createRefreshToken(token)
+}
+
+func (t *authTx) GetRefreshToken(_ context.Context, tokenHash string) (*models.RefreshToken, error) {
+	return t.data.getRefreshToken(tokenHash)
+}
+
+func (t *authTx) MarkRefreshTokenUsed(_ context.Context, tokenHash string) (bool, error) {
+	return t.data.markRefreshTokenUsed(tokenHash)
+}
+
+func (t *authTx) RevokeUserSessions(_ context.Context, userID int64) error {
+	return t.data.revokeUserSessions(userID)
+}
+
+func (t *authTx) GetIdentity(_ context.Context, provider, subject string) (*models.Identity, error) {
+	return t.data.getIdentity(provider, subject)
+}
+
+func (t *authTx) CreateIdentity(_ context.Context, identity *models.Identity) error {
+	return t.data.createIdentity(identity)
+}
+
+func (t *authTx) CreatePasswordReset(_ context.Context, reset *models.PasswordReset) error {
+	return t.data.createPasswordReset(reset)
+}
+
+func (t *authTx) ConsumePasswordReset(_ context.Context, tokenHash string) (*models.PasswordReset, error) {
+	return t.data.consumePasswordReset(tokenHash)
+}
+
+func (t *authTx) CreateEmailVerification(_ context.Context, verification *models.EmailVerification) error {
+	return t.data.createEmailVerification(verification)
+}
+
+func (t *authTx) ConsumeEmailVerification(_ context.Context, tokenHash string) (*models.EmailVerification, error) {
+	return t.data.consumeEmailVerification(tokenHash)
+}
+
+func (t *authTx) PurgeExpired(_ context.Context, before time.Time) (int64, error) {
+	return t.data.purgeExpired(before)
+}
+
+func (d *authData) createUser(user *models.User) error {
+	for _, existing := range d.users {
+		if strings.EqualFold(existing.Email, user.Email) || sameExternalID(existing, *user) {
+			return storage.ErrConflict
+		}
+	}
+
+	if user.Role == "" {
+		user.Role = models.RoleUser
+	}
+	d.nextUserID++
+	now := time.Now()
+	user.ID = d.nextUserID
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	d.users[user.ID] = *user
+
+	return nil
+}
+
+func (d *authData) getUserByEmail(email string) (*models.User, error) {
+	for _, user := range d.users {
+		if strings.EqualFold(user.Email, email) {
+			return &user, nil
+		}
+	}
+	return nil, storage.ErrNotFound
+}
+
+func (d *authData) getUserByID(id int64) (*models.User, error) {
+	user, ok := d.users[id]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return &user, nil
+}
+
+func (d *authData) updatePassword(userID int64, passwordHash string) error {
+	user, ok := d.users[userID]
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	user.PasswordHash = passwordHash
+	user.UpdatedAt = time.Now()
+	d.users[userID] = user
+	return nil
+}
+
+func (d *authData) setRole(userID int64, role string) error {
+	user, ok := d.users[userID]
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	user.Role = role
+	user.UpdatedAt = time.Now()
+	d.users[userID] = user
+	return nil
+}
+
+func (d *authData) listUsers(filter storage.UserFilter, limit, offset int) ([]*models.User, int, error) {
+	matched := make([]*models.User, 0)
+	for _, user := range d.users {
+		if filter.Email != "" && !strings.EqualFold(user.Email, filter.Email) {
+			continue
+		}
+		if filter.ExternalID != "" && user.ExternalID != filter.ExternalID {
+			continue
+		}
+		matched = append(matched, &user)
+	}
+	slices.SortFunc(matched, func(a, b *models.User) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	total := len(matched)
+	if offset >= total {
+		return []*models.User{}, total, nil
+	}
+	return matched[offset:min(offset+limit, total)], total, nil
+}
+
+func (d *authData) updateUser(update *models.User) error {
+	user, ok := d.users[update.ID]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	for id, existing := range d.users {
+		if id != update.ID && (strings.EqualFold(existing.Email, update.Email) || sameExternalID(existing, *update)) {
+			return storage.ErrConflict
+		}
+	}
+
+	user.Email = update.Email
+	user.Name = update.Name
+	user.ExternalID = update.ExternalID
+	user.UpdatedAt = time.Now()
+	update.UpdatedAt = user.UpdatedAt
+	d.users[update.ID] = user
+	return nil
+}
+
+// sameExternalID reports whether both users were provisioned with the same
+// external id
+func sameExternalID(a, b models.User) bool {
+	return a.ExternalID != "" && a.ExternalID == b.ExternalID
+}
+
+func (d *authData) setDeactivated(userID int64, deactivated bool) error {
+	user, ok := d.users[userID]
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	now := time.Now()
+	switch {
+	case !deactivated:
+		user.DeactivatedAt = nil
+	case user.DeactivatedAt == nil:
+		user.DeactivatedAt = &now
+	}
+	user.UpdatedAt = now
+	d.users[userID] = user
+	return nil
+}
+
+func (d *authData) markEmailVerified(userID int64) error {
+	user, ok := d.users[userID]
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	now := time.Now()
+	if user.EmailVerifiedAt == nil {
+		user.EmailVerifiedAt = &now
+	}
+	user.UpdatedAt = now
+	d.users[userID] = user
+	return nil
+}
+
+func (d *authData) createSession(session *models.Session) error {
+	session.CreatedAt = time.Now()
+	session.LastSeenAt = session.CreatedAt
+	d.sessions[session.ID] = *session
+	return nil
+}
+
+func (d *authData) listSessions(userID int64) ([]*models.Session, error) {
+	now := time.Now()
+	sessions := make([]*models.Session, 0)
+	for _, session := range d.sessions {
+		if session.UserID == userID && session.IsActive(now) {
+			sessions = append(sessions, &session)
+		}
+	}
+	slices.SortFunc(sessions, func(a, b *models.Session) int {
+		if c := b.LastSeenAt.Compare(a.LastSeenAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return sessions, nil
+}
+
+func (d *authData) touchSession(id, ipAddress, userAgent string) error {
+	session, ok := d.sessions[id]
+	if !ok {
+		return nil
+	}
+
+	session.IPAddress = ipAddress
+	session.UserAgent = userAgent
+	session.LastSeenAt = time.Now()
+	d.sessions[id] = session
+	return nil
+}
+
+func (d *authData) revokeOtherSessions(userID int64, keepID string) (int64, error) {
+	now := time.Now()
+	var revoked int64
+	for id, session := range d.sessions {
+		if session.UserID == userID && id != keepID && session.IsActive(now) {
+			session.RevokedAt = &now
+			d.sessions[id] = session
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+func (d *authData) getSession(id string) (*models.Session, error) {
+	session, ok := d.sessions[id]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return &session, nil
+}
+
+func (d *authData) revokeSession(id string) error {
+	session, ok := d.sessions[id]
+	if !ok || session.RevokedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	session.RevokedAt = &now
+	d.sessions[id] = session
+	return nil
+}
+
+func (d *authData) revokeUserSessions(userID int64) error {
+	now := time.Now()
+	for id, session := range d.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			session.RevokedAt = &now
+			d.sessions[id] = session
+		}
+	}
+	return nil
+}
+
+func (d *authData) isRevoked(sessionID string) (bool, error) {
+	session, ok := d.sessions[sessionID]
+	return !ok || !session.IsActive(time.Now()), nil
+}
+
+func (d *authData) createRefreshToken(token *models.RefreshToken) error {
+	token.CreatedAt = time.Now()
+	d.refreshTokens[token.TokenHash] = *token
+	return nil
+}
+
+func (d *authData) getRefreshToken(tokenHash string) (*models.RefreshToken, error) {
+	token, ok := d.refreshTokens[tokenHash]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return &token, nil
+}
+
+func (d *authData) markRefreshTokenUsed(tokenHash string) (bool, error) {
+	token, ok := d.refreshTokens[tokenHash]
+	if !ok || token.UsedAt != nil {
+		return false, nil
+	}
+
+	now := time.Now()
+	token.UsedAt = &now
+	d.refreshTokens[tokenHash] = token
+	return true, nil
+}
+
+func (d *authData) getIdentity(provider, subject string) (*models.Identity, error) {
+	identity, ok := d.identities[identityKey{provider, subject}]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return &identity, nil
+}
+
+func (d *authData) createIdentity(identity *models.Identity) error {
+	key := identityKey{identity.Provider, identity.Subject}
+	if _, ok := d.identities[key]; ok {
+		return storage.ErrConflict
+	}
+
+	identity.CreatedAt = time.Now()
+	d.identities[key] = *identity
+	return nil
+}
+
+func (d *authData) createPasswordReset(reset *models.PasswordReset) error {
+	reset.CreatedAt = time.Now()
+	d.resets[reset.TokenHash] = *reset
+	return nil
+}
+
+func (d *authData) consumePasswordReset(tokenHash string) (*models.PasswordReset, error) {
+	reset, ok := d.resets[tokenHash]
+	now := time.Now()
+	if !ok || reset.UsedAt != nil || !now.Before(reset.ExpiresAt) {
+		return nil, storage.ErrNotFound
+	}
+
+	reset.UsedAt = &now
+	d.resets[tokenHash] = reset
+	return &reset, nil
+}
+
+func (d *authData) createEmailVerification(verification *models.EmailVerification) error {
+	verification.CreatedAt = time.Now()
+	d.verifications[verification.TokenHash] = *verification
+	return nil
+}
+
+func (d *authData) consumeEmailVerification(tokenHash string) (*models.EmailVerification, error) {
+	verification, ok := d.verifications[tokenHash]
+	now := time.Now()
+	if !ok || verification.UsedAt != nil || !now.Before(verification.ExpiresAt) {
+		return nil, storage.ErrNotFound
+	}
+
+	verification.UsedAt = &now
+	d.verifications[tokenHash] = verification
+	return &verification, nil
+}
+
+func (d *authData) purgeExpired(before time.Time) (int64, error) {
+	var purged int64
+	for id, session := range d.sessions {
+		if session.ExpiresAt.Before(before) {
+			delete(d.sessions, id)
+			purged++
+		}
+	}
+	// Refresh tokens go with their session like the foreign key cascade
+	for hash, token := range d.refreshTokens {
+		if _, ok := d.sessions[token.SessionID]; !ok || token.ExpiresAt.Before(before) {
+			delete(d.refreshTokens, hash)
+			purged++
+		}
+	}
+	for hash, reset := range d.resets {
+		if reset.ExpiresAt.Before(before) {
+			delete(d.resets, hash)
+			purged++
+		}
+	}
+	for hash, verification := range d.verifications {
+		if verification.ExpiresAt.Before(before) {
+			delete(d.verifications, hash)
+			purged++
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,170 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+// ScheduleDeletion sets when the account is to be erased
+func (s *AuthStore) ScheduleDeletion(_ context.Context, userID int64, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.scheduleDeletion(userID, &at)
+}
+
+// CancelDeletion clears a scheduled deletion
+func (s *AuthStore) CancelDeletion(_ context.Context, userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.scheduleDeletion(userID, nil)
+}
+
+// DueDeletions returns the users whose erasure is due, earliest first
+func (s *AuthStore) DueDeletions(_ context.Context, now time.Time, limit int) ([]int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.dueDeletions(now, limit), nil
+}
+
+func (t *authTx) ScheduleDeletion(_ context.Context, userID int64, at time.Time) error {
+	return t.data.scheduleDeletion(userID, &at)
+}
+
+func (t *authTx) CancelDeletion(_ context.Context, userID int64) error {
+	return t.data.scheduleDeletion(userID, nil)
+}
+
+func (t *authTx) DueDeletions(_ context.Context, now time.Time, limit int) ([]int64, error) {
+	return t.data.dueDeletions(now, limit), nil
+}
+
+// scheduleDeletion sets or, with a nil at, cancels the deletion of a user
+func (d *authData) scheduleDeletion(userID int64, at *time.Time) error {
+	user, ok := d.users[userID]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	data := d.profiles[userID]
+	if at == nil && data.deletionScheduledAt == nil {
+		return storage.ErrNotFound
+	}
+
+	data.deletionScheduledAt = at
+	user.UpdatedAt = time.Now()
+	d.users[userID] = user
+	d.profiles[userID] = data
+	return nil
+}
+
+func (d *authData) dueDeletions(now time.Time, limit int) []int64 {
+	due := make([]int64, 0)
+	for userID, data := range d.profiles {
+		if data.deletionScheduledAt != nil && !data.deletionScheduledAt.After(now) {
+			due = append(due, userID)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		a, b := d.profiles[due[i]].deletionScheduledAt, d.profiles[due[j]].deletionScheduledAt
+		if !a.Equal(*b) {
+			return a.Before(*b)
+		}
+		return due[i] < due[j]
+	})
+	return due[:min(limit, len(due))]
+}
+
+// EraseUser removes the user from each store in turn. The user is removed
+// first, so a failure cannot leave an account behind whose data is gone
+func (s *Store) EraseUser(_ context.Context, userID int64, now time.Time) error {
+	if err := s.authStore.erase(userID, now); err != nil {
+		return err
+	}
+	s.todoStore.erase(userID)
+	s.webhookStore.erase(userID)
+	s.quotaStore.erase(userID)
+	s.auditStore.erase(userID)
+	s.securityStore.erase(userID)
+	return nil
+}
+
+// erase removes a user whose deletion is due together with their sessions
+// and tokens
+func (s *AuthStore) erase(userID int64, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d := s.data
+	scheduled := d.profiles[userID].deletionScheduledAt
+	if _, ok := d.users[userID]; !ok || scheduled == nil || scheduled.After(now) {
+		return storage.ErrNotFound
+	}
+
+	delete(d.users, userID)
+	delete(d.profiles, userID)
+	maps.DeleteFunc(d.channels, func(k channelKey, _ models.NotificationChannel) bool { return k.userID == userID })
+	maps.DeleteFunc(d.sessions, func(_ string, v models.Session) bool { return v.UserID == userID })
+	maps.DeleteFunc(d.refreshTokens, func(_ string, v models.RefreshToken) bool { return v.UserID == userID })
+	maps.DeleteFunc(d.identities, func(_ identityKey, v models.Identity) bool { return v.UserID == userID })
+	maps.DeleteFunc(d.resets, func(_ string, v models.PasswordReset) bool { return v.UserID == userID })
+	maps.DeleteFunc(d.verifications, func(_ string, v models.EmailVerification) bool { return v.UserID == userID })
+	return nil
+}
+
+// erase removes the user's todos with their sub-tasks, tags, outbox
+// messages and the shares with the user. Their attachments are orphaned like
+// those of purged todos
+func (s *TodoStore) erase(userID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d := s.data
+	for id, todo := range d.todos {
+		if todo.UserID == userID {
+			d.remove(id)
+		}
+	}
+	maps.DeleteFunc(d.tags, func(_ int64, v models.Tag) bool { return v.UserID == userID })
+	maps.DeleteFunc(d.shares, func(k shareKey, _ models.TodoShare) bool { return k.userID == userID })
+	d.outbox = slices.DeleteFunc(d.outbox, func(v models.OutboxMessage) bool { return v.UserID == userID })
+}
+
+// erase removes the user's webhooks and their deliveries
+func (s *WebhookStore) erase(userID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	maps.DeleteFunc(s.webhooks, func(_ int64, v models.Webhook) bool { return v.UserID == userID })
+	maps.DeleteFunc(s.deliveries, func(_ int64, v models.WebhookDelivery) bool { return v.UserID == userID })
+}
+
+func (s *QuotaStore) erase(userID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.overrides, userID)
+}
+
+// erase removes the events the user was the actor of
+func (s *AuditStore) erase(userID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.events = slices.DeleteFunc(s.events, func(v models.AuditEvent) bool {
+		return v.UserID != nil && *v.UserID == userID
+	})
+}
+
+func (s *SecurityStore) erase(userID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.events = slices.DeleteFunc(s.events, func(v models.SecurityEvent) bool {
+		return v.UserID != nil && *v.UserID == userID
+	})
+}
@@ -0,0 +1,70 @@
+// Package memory is an in-process storage backend for tests and local
+// development. Data is lost on restart and is not shared between replicas
+package memory
+
+import (
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+var _ storage.Store = (*Store)(nil)
+
+type Store struct {
+	todoStore     *TodoStore
+	authStore     *AuthStore
+	auditStore    *AuditStore
+	securityStore *SecurityStore
+	webhookStore  *WebhookStore
+	quotaStore    *QuotaStore
+}
+
+// New creates an empty in-memory store
+func New() *Store {
+	return &Store{
+		todoStore:     newTodoStore(),
+		authStore:     newAuthStore(),
+		auditStore:    newAuditStore(),
+		securityStore: newSecurityStore(),
+		webhookStore:  newWebhookStore(),
+		quotaStore:    newQuotaStore(),
+	}
+}
+
+// Todos returns the todo store
+func (s *Store) Todos() storage.TodoRepository {
+	return s.todoStore
+}
+
+// Auth returns the auth store
+func (s *Store) Auth() storage.AuthRepository {
+	return s.authStore
+}
+
+// Audit returns the audit event store
+func (s *Store) Audit() storage.AuditRepository {
+	return s.auditStore
+}
+
+// SecurityEvents returns the security event store
+func (s *Store) SecurityEvents() storage.SecurityEventRepository {
+	return s.securityStore
+}
+
+// Webhooks returns the webhook store
+func (s *Store) Webhooks() storage.WebhookRepository {
+	return s.webhookStore
+}
+
+// Quotas returns the quota override store
+func (s *Store) Quotas() storage.QuotaRepository {
+	return s.quotaStore
+}
+
+// IsHealthy always reports true since there is nothing to connect to
+func (s *Store) IsHealthy() bool {
+	return true
+}
+
+// Close is a no-op
+func (s *Store) Close() error {
+	return nil
+}
@@ -0,0 +1,252 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+type channelKey struct {
+	userID  int64
+	channel string
+}
+
+// ListNotificationChannels returns the user's channels ordered by name
+func (s *AuthStore) ListNotificationChannels(_ context.Context, userID int64) ([]*models.NotificationChannel, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.listNotificationChannels(userID), nil
+}
+
+// GetNotificationChannel returns one channel of the user
+func (s *AuthStore) GetNotificationChannel(_ context.Context, userID int64, channel string) (*models.NotificationChannel, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.getNotificationChannel(userID, channel)
+}
+
+// SetNotificationChannel creates or replaces a channel of the user
+func (s *AuthStore) SetNotificationChannel(_ context.Context, channel *models.NotificationChannel) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.setNotificationChannel(channel)
+}
+
+// DeleteNotificationChannel removes a channel of the user
+func (s *AuthStore) DeleteNotificationChannel(_ context.Context, userID int64, channel string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.deleteNotificationChannel(userID, channel)
+}
+
+func (t *authTx) ListNotificationChannels(_ context.Context, userID int64) ([]*models.NotificationChannel, error) {
+	return t.data.listNotificationChannels(userID), nil
+}
+
+func (t *authTx) GetNotificationChannel(_ context.Context, userID int64, channel string) (*models.NotificationChannel, error) {
+	return t.data.getNotificationChannel(userID, channel)
+}
+
+func (t *authTx) SetNotificationChannel(_ context.Context, channel *models.NotificationChannel) error {
+	return t.data.setNotificationChannel(channel)
+}
+
+func (t *authTx) DeleteNotificationChannel(_ context.Context, userID int64, channel string) error {
+	return t.data.deleteNotificationChannel(userID, channel)
+}
+
+func (d *authData) listNotificationChannels(userID int64) []*models.NotificationChannel {
+	channels := make([]*models.NotificationChannel, 0)
+	for key, channel := range d.channels {
+		if key.userID == userID {
+			channel := channel
+			channels = append(channels, &channel)
+		}
+	}
+
+	sort.Slice(channels, func(i, j int) bool { return channels[i].Channel < channels[j].Channel })
+	return channels
+}
+
+func (d *authData) getNotificationChannel(userID int64, channel string) (*models.NotificationChannel, error) {
+	found, ok := d.channels[channelKey{userID, channel}]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return &found, nil
+}
+
+func (d *authData) setNotificationChannel(channel *models.NotificationChannel) error {
+	if _, ok := d.users[channel.UserID]; !ok {
+		return storage.ErrNotFound
+	}
+
+	key := channelKey{channel.UserID, channel.Channel}
+	now := time.Now()
+	channel.CreatedAt = now
+	if existing, ok := d.channels[key]; ok {
+		channel.CreatedAt = existing.CreatedAt
+	}
+	channel.UpdatedAt = now
+	d.channels[key] = *channel
+	return nil
+}
+
+func (d *authData) deleteNotificationChannel(userID int64, channel string) error {
+	key := channelKey{userID, channel}
+	if _, ok := d.channels[key]; !ok {
+		return storage.ErrNotFound
+	}
+	delete(d.channels, key)
+	return nil
+}
+
+// DueReminders returns the todos due between from and to that have not been
+// reminded of, across all users
+func (s *TodoStore) DueReminders(_ context.Context, from, to time.Time, limit int) ([]*models.Todo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.dueReminders(from, to, limit), nil
+}
+
+// MarkReminded records that reminders of the todos were sent
+func (s *TodoStore) MarkReminded(_ context.Context, ids []int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.markReminded(ids)
+	return nil
+}
+
+func (t *todoTx) DueReminders(_ context.Context, from, to time.Time, limit int) ([]*models.Todo, error) {
+	return t.data.dueReminders(from, to, limit), nil
+}
+
+func (t *todoTx) MarkReminded(_ context.Context, ids []int64) error {
+	t.data.markReminded(ids)
+	return nil
+}
+
+func (d *todoData) dueReminders(from, to time.Time, limit int) []*models.Todo {
+	due := make([]*models.Todo, 0)
+	for id, todo := range d.todos {
+		if _, ok := d.reminded[id]; ok || todo.Completed || todo.DeletedAt != nil || todo.DueDate == nil {
+			continue
+		}
+		if todo.DueDate.Before(from) || todo.DueDate.After(to) {
+			continue
+		}
+		todo := todo
+		todo.Tags = d.tagNames(id)
+		due = append(due, &todo)
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].DueDate.Equal(*due[j].DueDate) {
+			return due[i].DueDate.Before(*due[j].DueDate)
+		}
+		return due[i].ID < due[j].ID
+	})
+	return due[:min(limit, len(due))]
+}
+
+func (d *todoData) markReminded(ids []int64) {
+	now := time.Now()
+	for _, id := range ids {
+		if _, ok := d.todos[id]; ok {
+			d.reminded[id] = now
+		}
+	}
+}
+
+// equalTimes reports whether two optional times are both unset or equal
+func equalTimes(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// SetReminder sets or clears when the user wants to be reminded of the todo
+func (s *TodoStore) SetReminder(_ context.Context, userID, id int64, remindAt *time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.setReminder(userID, id, remindAt)
+}
+
+// PendingReminders returns the todos whose reminder is due by before and
+// has not been dispatched, across all users
+func (s *TodoStore) PendingReminders(_ context.Context, before time.Time, limit int) ([]*models.Todo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.pendingReminders(before, limit), nil
+}
+
+// MarkRemindersDispatched records that the reminders of the todos were
+// queued or sent
+func (s *TodoStore) MarkRemindersDispatched(_ context.Context, ids []int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.markRemindersDispatched(ids)
+	return nil
+}
+
+func (t *todoTx) SetReminder(_ context.Context, userID, id int64, remindAt *time.Time) error {
+	return t.data.setReminder(userID, id, remindAt)
+}
+
+func (t *todoTx) PendingReminders(_ context.Context, before time.Time, limit int) ([]*models.Todo, error) {
+	return t.data.pendingReminders(before, limit), nil
+}
+
+func (t *todoTx) MarkRemindersDispatched(_ context.Context, ids []int64) error {
+	t.data.markRemindersDispatched(ids)
+	return nil
+}
+
+func (d *todoData) setReminder(userID, id int64, remindAt *time.Time) error {
+	todo, ok := d.todos[id]
+	if !ok || todo.UserID != userID || todo.DeletedAt != nil {
+		return storage.ErrNotFound
+	}
+
+	todo.RemindAt = remindAt
+	todo.UpdatedAt = time.Now()
+	d.todos[id] = todo
+	delete(d.queued, id)
+	return nil
+}
+
+func (d *todoData) pendingReminders(before time.Time, limit int) []*models.Todo {
+	pending := make([]*models.Todo, 0)
+	for id, todo := range d.todos {
+		if _, ok := d.queued[id]; ok || todo.Completed || todo.DeletedAt != nil || todo.RemindAt == nil {
+			continue
+		}
+		if todo.RemindAt.After(before) {
+			continue
+		}
+		todo := todo
+		todo.Tags = d.tagNames(id)
+		pending = append(pending, &todo)
+	}
+
+	sort.Slice(pending, func(i, j int) bool {
+		if !pending[i].RemindAt.Equal(*pending[j].RemindAt) {
+			return pending[i].RemindAt.Before(*pending[j].RemindAt)
+		}
+		return pending[i].ID < pending[j].ID
+	})
+	return pending[:min(limit, len(pending))]
+}
+
+func (d *todoData) markRemindersDispatched(ids []int64) {
+	now := time.Now()
+	for _, id := range ids {
+		if _, ok := d.todos[id]; ok {
+			d.queued[id] = now
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+// AddOutboxMessage appends a pending message to the outbox
+func (s *TodoStore) AddOutboxMessage(_ context.Context, msg *models.OutboxMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.addOutboxMessage(msg)
+}
+
+// PendingOutboxMessages returns the oldest unpublished messages
+func (s *TodoStore) PendingOutboxMessages(_ context.Context, limit int) ([]*models.OutboxMessage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.pendingOutboxMessages(limit), nil
+}
+
+// MarkOutboxPublished records the successful delivery of a message
+func (s *TodoStore) MarkOutboxPublished(_ context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.markOutbox(id, "", true)
+}
+
+// MarkOutboxFailed records a failed delivery attempt
+func (s *TodoStore) MarkOutboxFailed(_ context.Context, id int64, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.markOutbox(id, reason, false)
+}
+
+// PurgeOutbox removes messages published before the cutoff
+func (s *TodoStore) PurgeOutbox(_ context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.purgeOutbox(before), nil
+}
+
+func (t *todoTx) AddOutboxMessage(_ context.Context, msg *models.OutboxMessage) error {
+	return t.data.addOutboxMessage(msg)
+}
+
+func (t *todoTx) PendingOutboxMessages(_ context.Context, limit int) ([]*models.OutboxMessage, error) {
+	return t.data.pendingOutboxMessages(limit), nil
+}
+
+func (t *todoTx) MarkOutboxPublished(_ context.Context, id int64) error {
+	return t.data.markOutbox(id, "", true)
+}
+
+func (t *todoTx) MarkOutboxFailed(_ context.Context, id int64, reason string) error {
+	return t.data.markOutbox(id, reason, false)
+}
+
+func (t *todoTx) PurgeOutbox(_ context.Context, before time.Time) (int64, error) {
+	return t.data.purgeOutbox(before), nil
+}
+
+func (d *todoData) addOutboxMessage(msg *models.OutboxMessage) error {
+	for _, existing := range d.outbox {
+		if existing.IdempotencyKey == msg.IdempotencyKey {
+			return storage.ErrConflict
+		}
+	}
+
+	d.nextOutboxID++
+	msg.ID = d.nextOutboxID
+	msg.CreatedAt = time.Now()
+	d.outbox = append(d.outbox, *msg)
+	return nil
+}
+
+// pendingOutboxMessages relies on the outbox being kept in id order
+func (d *todoData) pendingOutboxMessages(limit int) []*models.OutboxMessage {
+	pending := make([]*models.OutboxMessage, 0, limit)
+	for _, msg := range d.outbox {
+		if len(pending) == limit {
+			break
+		}
+		if msg.PublishedAt == nil {
+			msg := msg
+			pending = append(pending, &msg)
+		}
+	}
+	return pending
+}
+
+func (d *todoData) markOutbox(id int64, reason string, published bool) error {
+	for i := range d.outbox {
+		if d.outbox[i].ID != id {
+			continue
+		}
+
+		msg := &d.outbox[i]
+		msg.Attempts++
+		if published {
+			now := time.Now()
+			msg.PublishedAt = &now
+		} else {
+			msg.LastError = reason
+		}
+		return nil
+	}
+	return storage.ErrNotFound
+}
+
+func (d *todoData) purgeOutbox(before time.Time) int64 {
+	n := len(d.outbox)
+	d.outbox = slices.DeleteFunc(d.outbox, func(msg models.OutboxMessage) bool {
+		return msg.PublishedAt != nil && msg.PublishedAt.Before(before)
+	})
+	return int64(n - len(d.outbox))
+}
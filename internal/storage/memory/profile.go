@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+// profileData holds the profile fields kept beside a user. notifications
+// only holds the changed preferences, like the JSONB column in Postgres, and
+// is replaced rather than modified so snapshots stay intact
+type profileData struct {
+	timezone      string
+	avatarID      *int64
+	notifications map[string]bool
+
+	deletionScheduledAt *time.Time
+}
+
+// GetProfile returns the profile of a user
+func (s *AuthStore) GetProfile(_ context.Context, userID int64) (*models.Profile, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.getProfile(userID)
+}
+
+// UpdateProfile applies a partial update and returns the updated profile
+func (s *AuthStore) UpdateProfile(_ context.Context, userID int64, update storage.ProfileUpdate) (*models.Profile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.updateProfile(userID, update)
+}
+
+func (t *authTx) GetProfile(_ context.Context, userID int64) (*models.Profile, error) {
+	return t.data.getProfile(userID)
+}
+
+func (t *authTx) UpdateProfile(_ context.Context, userID int64, update storage.ProfileUpdate) (*models.Profile, error) {
+	return t.data.updateProfile(userID, update)
+}
+
+func (d *authData) getProfile(userID int64) (*models.Profile, error) {
+	user, ok := d.users[userID]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+
+	data := d.profiles[userID]
+	profile := &models.Profile{
+		UserID:    user.ID,
+		Email:     user.Email,
+		Name:      user.Name,
+		Timezone:  data.timezone,
+		AvatarID:  data.avatarID,
+		UpdatedAt: user.UpdatedAt,
+
+		DeletionScheduledAt: data.deletionScheduledAt,
+	}
+	if profile.Timezone == "" {
+		profile.Timezone = "UTC"
+	}
+
+	changed, err := json.Marshal(data.notifications)
+	if err != nil {
+		return nil, err
+	}
+	if profile.Notifications, err = models.DecodeNotificationPreferences(changed); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+func (d *authData) updateProfile(userID int64, update storage.ProfileUpdate) (*models.Profile, error) {
+	user, ok := d.users[userID]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+
+	data := d.profiles[userID]
+	if update.Name != nil {
+		user.Name = *update.Name
+	}
+	if update.Timezone != nil {
+		data.timezone = *update.Timezone
+	}
+	if update.AvatarID != nil {
+		data.avatarID = update.AvatarID
+		if *update.AvatarID == 0 {
+			data.avatarID = nil
+		}
+	}
+	if len(update.Notifications) > 0 {
+		notifications := maps.Clone(data.notifications)
+		if notifications == nil {
+			notifications = make(map[string]bool, len(update.Notifications))
+		}
+		maps.Copy(notifications, update.Notifications)
+		data.notifications = notifications
+	}
+
+	user.UpdatedAt = time.Now()
+	d.users[userID] = user
+	d.profiles[userID] = data
+	return d.getProfile(userID)
+}
@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+// QuotaStore keeps the quota overrides of users
+type QuotaStore struct {
+	mu        sync.RWMutex
+	overrides map[int64]models.QuotaOverride
+}
+
+func newQuotaStore() *QuotaStore {
+	return &QuotaStore{overrides: make(map[int64]models.QuotaOverride)}
+}
+
+// GetQuotaOverride returns the override of a user
+func (s *QuotaStore) GetQuotaOverride(_ context.Context, userID int64) (*models.QuotaOverride, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	override, ok := s.overrides[userID]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	override = cloneQuotaOverride(override)
+	return &override, nil
+}
+
+// SetQuotaOverride creates or replaces the override of a user
+func (s *QuotaStore) SetQuotaOverride(_ context.Context, override *models.QuotaOverride) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	override.UpdatedAt = time.Now()
+	s.overrides[override.UserID] = cloneQuotaOverride(*override)
+	return nil
+}
+
+// DeleteQuotaOverride removes the override of a user
+func (s *QuotaStore) DeleteQuotaOverride(_ context.Context, userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.overrides[userID]; !ok {
+		return storage.ErrNotFound
+	}
+	delete(s.overrides, userID)
+	return nil
+}
+
+// cloneQuotaOverride copies the limits so the stored override does not share
+// them with the caller
+func cloneQuotaOverride(override models.QuotaOverride) models.QuotaOverride {
+	if override.MaxTodos != nil {
+		v := *override.MaxTodos
+		override.MaxTodos = &v
+	}
+	if override.MaxAttachmentBytes != nil {
+		v := *override.MaxAttachmentBytes
+		override.MaxAttachmentBytes = &v
+	}
+	if override.MaxAPICallsPerDay != nil {
+		v := *override.MaxAPICallsPerDay
+		override.MaxAPICallsPerDay = &v
+	}
+	return override
+}
@@ -0,0 +1,44 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+)
+
+// DueRecurrences returns the repeating todos that are completed or were due
+// before now, across all users
+func (s *TodoStore) DueRecurrences(_ context.Context, now time.Time, limit int) ([]*models.Todo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.dueRecurrences(now, limit), nil
+}
+
+func (t *todoTx) DueRecurrences(_ context.Context, now time.Time, limit int) ([]*models.Todo, error) {
+	return t.data.dueRecurrences(now, limit), nil
+}
+
+func (d *todoData) dueRecurrences(now time.Time, limit int) []*models.Todo {
+	due := make([]*models.Todo, 0)
+	for id, todo := range d.todos {
+		if todo.Recurrence == "" || todo.DeletedAt != nil || todo.DueDate == nil {
+			continue
+		}
+		if !todo.Completed && !todo.DueDate.Before(now) {
+			continue
+		}
+		todo := todo
+		todo.Tags = d.tagNames(id)
+		due = append(due, &todo)
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].DueDate.Equal(*due[j].DueDate) {
+			return due[i].DueDate.Before(*due[j].DueDate)
+		}
+		return due[i].ID < due[j].ID
+	})
+	return due[:min(limit, len(due))]
+}
@@ -0,0 +1,151 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+)
+
+// snippetLength is the longest snippet returned before it is cut around the
+// first match
+const snippetLength = 200
+
+// Search returns a page of the user's todos containing every word of the
+// query. There is no full-text index in memory, so words are matched as
+// case-insensitive substrings and ranked by where and how often they occur
+func (s *TodoStore) Search(_ context.Context, userID int64, query string, limit, offset int) ([]*models.TodoSearchResult, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.search(userID, query, limit, offset)
+}
+
+func (t *todoTx) Search(_ context.Context, userID int64, query string, limit, offset int) ([]*models.TodoSearchResult, int, error) {
+	return t.data.search(userID, query, limit, offset)
+}
+
+func (d *todoData) search(userID int64, query string, limit, offset int) ([]*models.TodoSearchResult, int, error) {
+	terms := strings.Fields(strings.ToLower(query))
+
+	results := make([]*models.TodoSearchResult, 0)
+	for _, todo := range d.todos {
+		if todo.UserID != userID || todo.DeletedAt != nil || len(terms) == 0 {
+			continue
+		}
+
+		rank, ok := searchRank(&todo, terms)
+		if !ok {
+			continue
+		}
+
+		todo := todo
+		todo.Tags = d.tagNames(todo.ID)
+		results = append(results, &models.TodoSearchResult{
+			Todo:    &todo,
+			Rank:    rank,
+			Snippet: highlight(todo.Title+" "+todo.Description, terms),
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Rank != b.Rank {
+			return a.Rank > b.Rank
+		}
+		if !a.Todo.CreatedAt.Equal(b.Todo.CreatedAt) {
+			return a.Todo.CreatedAt.After(b.Todo.CreatedAt)
+		}
+		return a.Todo.ID > b.Todo.ID
+	})
+
+	total := len(results)
+	if offset >= total {
+		return []*models.TodoSearchResult{}, total, nil
+	}
+	end := min(offset+limit, total)
+
+	return results[offset:end], total, nil
+}
+
+// searchRank reports whether the todo contains every term, weighting title
+// matches above description matches like the Postgres index does
+func searchRank(todo *models.Todo, terms []string) (float64, bool) {
+	title := strings.ToLower(todo.Title)
+	description := strings.ToLower(todo.Description)
+
+	var rank float64
+	for _, term := range terms {
+		inTitle := strings.Count(title, term)
+		inDescription := strings.Count(description, term)
+		if inTitle == 0 && inDescription == 0 {
+			return 0, false
+		}
+		rank += float64(inTitle) + 0.4*float64(inDescription)
+	}
+	return rank, true
+}
+
+// highlight wraps every occurrence of the terms in <mark> tags, long texts
+// are cut down to a window around the first match
+func highlight(text string, terms []string) string {
+	lower := strings.ToLower(text)
+	if len(lower) != len(text) {
+		// Lowercasing changed byte offsets, matches cannot be mapped back
+		terms = nil
+		lower = text
+	}
+
+	// Mark the byte ranges to highlight, merging overlapping matches
+	marked := make([]bool, len(text))
+	first := len(text)
+	for _, term := range terms {
+		for i := 0; ; {
+			j := strings.Index(lower[i:], term)
+			if j < 0 {
+				break
+			}
+			start := i + j
+			for k := start; k < start+len(term); k++ {
+				marked[k] = true
+			}
+			first = min(first, start)
+			i = start + len(term)
+		}
+	}
+
+	from, to := 0, len(text)
+	if len(text) > snippetLength {
+		from = max(0, min(first, len(text))-snippetLength/4)
+		to = min(len(text), from+snippetLength)
+		// Keep the cut on rune boundaries
+		for from > 0 && !isRuneStart(text[from]) {
+			from--
+		}
+		for to < len(text) && !isRuneStart(text[to]) {
+			to++
+		}
+	}
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("...")
+	}
+	for i := from; i < to; i++ {
+		if marked[i] && (i == from || !marked[i-1]) {
+			b.WriteString("<mark>")
+		}
+		b.WriteByte(text[i])
+		if marked[i] && (i == to-1 || !marked[i+1]) {
+			b.WriteString("</mark>")
+		}
+	}
+	if to < len(text) {
+		b.WriteString("...")
+	}
+	return b.String()
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
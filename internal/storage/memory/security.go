@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+// SecurityStore keeps the security event log in append order
+type SecurityStore struct {
+	mu     sync.RWMutex
+	nextID int64
+	events []models.SecurityEvent
+}
+
+func newSecurityStore() *SecurityStore {
+	return &SecurityStore{}
+}
+
+// CreateSecurityEvent appends an event to the log
+func (s *SecurityStore) CreateSecurityEvent(_ context.Context, event *models.SecurityEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	event.ID = s.nextID
+	event.CreatedAt = time.Now()
+	stored := *event
+	stored.Details = maps.Clone(event.Details)
+	s.events = append(s.events, stored)
+	return nil
+}
+
+// ListSecurityEvents returns a page of events matching the filter ordered
+// from newest to oldest along with the total number of matches
+func (s *SecurityStore) ListSecurityEvents(_ context.Context, filter storage.SecurityEventFilter, limit, offset int) ([]*models.SecurityEvent, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matched := make([]*models.SecurityEvent, 0)
+	for i := len(s.events) - 1; i >= 0; i-- {
+		event := s.events[i]
+		if securityEventMatches(&event, filter) {
+			event.Details = maps.Clone(event.Details)
+			matched = append(matched, &event)
+		}
+	}
+
+	total := len(matched)
+	if offset >= total {
+		return []*models.SecurityEvent{}, total, nil
+	}
+	end := min(offset+limit, total)
+
+	return matched[offset:end], total, nil
+}
+
+// PurgeSecurityEvents removes the events created before the cutoff, they
+// are ordered by creation so the oldest form a prefix of the log
+func (s *SecurityStore) PurgeSecurityEvents(_ context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for n < len(s.events) && s.events[n].CreatedAt.Before(before) {
+		n++
+	}
+	s.events = append([]models.SecurityEvent(nil), s.events[n:]...)
+	return int64(n), nil
+}
+
+func securityEventMatches(event *models.SecurityEvent, filter storage.SecurityEventFilter) bool {
+	switch {
+	case filter.UserID != nil && (event.UserID == nil || *event.UserID != *filter.UserID):
+		return false
+	case filter.Type != "" && event.Type != filter.Type:
+		return false
+	case filter.Since != nil && event.CreatedAt.Before(*filter.Since):
+		return false
+	}
+	return true
+}
@@ -0,0 +1,163 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+type shareKey struct {
+	todoID int64
+	userID int64
+}
+
+// CreateShare inserts a share, returning storage.ErrConflict when the todo
+// is already shared with the user
+func (s *TodoStore) CreateShare(_ context.Context, share *models.TodoShare) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.createShare(share)
+}
+
+// GetShare returns the share of a todo with the user
+func (s *TodoStore) GetShare(_ context.Context, todoID, userID int64) (*models.TodoShare, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.getShare(todoID, userID)
+}
+
+// ListShares returns the shares of a todo, oldest first
+func (s *TodoStore) ListShares(_ context.Context, todoID int64) ([]*models.TodoShare, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.listShares(todoID), nil
+}
+
+// ListUserShares returns the shares with the user of todos outside the
+// trash, newest first
+func (s *TodoStore) ListUserShares(_ context.Context, userID int64) ([]*models.TodoShare, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.listUserShares(userID), nil
+}
+
+// UpdateShare persists the role and acceptance of a share
+func (s *TodoStore) UpdateShare(_ context.Context, share *models.TodoShare) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.updateShare(share)
+}
+
+// DeleteShare removes the share of a todo with the user
+func (s *TodoStore) DeleteShare(_ context.Context, todoID, userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.deleteShare(todoID, userID)
+}
+
+func (t *todoTx) CreateShare(_ context.Context, share *models.TodoShare) error {
+	return t.data.createShare(share)
+}
+
+func (t *todoTx) GetShare(_ context.Context, todoID, userID int64) (*models.TodoShare, error) {
+	return t.data.getShare(todoID, userID)
+}
+
+func (t *todoTx) ListShares(_ context.Context, todoID int64) ([]*models.TodoShare, error) {
+	return t.data.listShares(todoID), nil
+}
+
+func (t *todoTx) ListUserShares(_ context.Context, userID int64) ([]*models.TodoShare, error) {
+	return t.data.listUserShares(userID), nil
+}
+
+func (t *todoTx) UpdateShare(_ context.Context, share *models.TodoShare) error {
+	return t.data.updateShare(share)
+}
+
+func (t *todoTx) DeleteShare(_ context.Context, todoID, userID int64) error {
+	return t.data.deleteShare(todoID, userID)
+}
+
+func (d *todoData) createShare(share *models.TodoShare) error {
+	if _, ok := d.todos[share.TodoID]; !ok {
+		return storage.ErrNotFound
+	}
+	key := shareKey{share.TodoID, share.UserID}
+	if _, ok := d.shares[key]; ok {
+		return storage.ErrConflict
+	}
+
+	share.CreatedAt = time.Now()
+	d.shares[key] = *share
+	return nil
+}
+
+func (d *todoData) getShare(todoID, userID int64) (*models.TodoShare, error) {
+	share, ok := d.shares[shareKey{todoID, userID}]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return &share, nil
+}
+
+func (d *todoData) listShares(todoID int64) []*models.TodoShare {
+	shares := make([]*models.TodoShare, 0)
+	for key, share := range d.shares {
+		if key.todoID == todoID {
+			share := share
+			shares = append(shares, &share)
+		}
+	}
+
+	sort.Slice(shares, func(i, j int) bool {
+		if !shares[i].CreatedAt.Equal(shares[j].CreatedAt) {
+			return shares[i].CreatedAt.Before(shares[j].CreatedAt)
+		}
+		return shares[i].UserID < shares[j].UserID
+	})
+	return shares
+}
+
+func (d *todoData) listUserShares(userID int64) []*models.TodoShare {
+	shares := make([]*models.TodoShare, 0)
+	for key, share := range d.shares {
+		if todo := d.todos[key.todoID]; key.userID == userID && todo.DeletedAt == nil {
+			share := share
+			shares = append(shares, &share)
+		}
+	}
+
+	sort.Slice(shares, func(i, j int) bool {
+		if !shares[i].CreatedAt.Equal(shares[j].CreatedAt) {
+			return shares[i].CreatedAt.After(shares[j].CreatedAt)
+		}
+		return shares[i].TodoID > shares[j].TodoID
+	})
+	return shares
+}
+
+func (d *todoData) updateShare(share *models.TodoShare) error {
+	key := shareKey{share.TodoID, share.UserID}
+	existing, ok := d.shares[key]
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	existing.Role = share.Role
+	existing.AcceptedAt = share.AcceptedAt
+	d.shares[key] = existing
+	return nil
+}
+
+func (d *todoData) deleteShare(todoID, userID int64) error {
+	key := shareKey{todoID, userID}
+	if _, ok := d.shares[key]; !ok {
+		return storage.ErrNotFound
+	}
+	delete(d.shares, key)
+	return nil
+}
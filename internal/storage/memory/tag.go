@@ -0,0 +1,196 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+// CreateTag inserts a new tag, returning storage.ErrConflict when the user
+// already has a tag with the name
+func (s *TodoStore) CreateTag(_ context.Context, tag *models.Tag) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.createTag(tag)
+}
+
+// GetTag returns the tag with the given id owned by the user
+func (s *TodoStore) GetTag(_ context.Context, userID, id int64) (*models.Tag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.getTag(userID, id)
+}
+
+// ListTags returns the user's tags ordered by name
+func (s *TodoStore) ListTags(_ context.Context, userID int64) ([]*models.Tag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.listTags(userID)
+}
+
+// UpdateTag renames a tag
+func (s *TodoStore) UpdateTag(_ context.Context, tag *models.Tag) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.updateTag(tag)
+}
+
+// DeleteTag removes a tag and detaches it from every todo
+func (s *TodoStore) DeleteTag(_ context.Context, userID, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.deleteTag(userID, id)
+}
+
+// SetTodoTags replaces the tags of a todo, creating missing tags
+func (s *TodoStore) SetTodoTags(_ context.Context, userID, todoID int64, names []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.setTodoTags(userID, todoID, names)
+}
+
+func (t *todoTx) CreateTag(_ context.Context, tag *models.Tag) error {
+	return t.data.createTag(tag)
+}
+
+func (t *todoTx) GetTag(_ context.Context, userID, id int64) (*models.Tag, error) {
+	return t.data.getTag(userID, id)
+}
+
+func (t *todoTx) ListTags(_ context.Context, userID int64) ([]*models.Tag, error) {
+	return t.data.listTags(userID)
+}
+
+func (t *todoTx) UpdateTag(_ context.Context, tag *models.Tag) error {
+	return t.data.updateTag(tag)
+}
+
+func (t *todoTx) DeleteTag(_ context.Context, userID, id int64) error {
+	return t.data.deleteTag(userID, id)
+}
+
+func (t *todoTx) SetTodoTags(_ context.Context, userID, todoID int64, names []string) error {
+	return t.data.setTodoTags(userID, todoID, names)
+}
+
+func (d *todoData) createTag(tag *models.Tag) error {
+	if _, ok := d.tagByName(tag.UserID, tag.Name); ok {
+		return storage.ErrConflict
+	}
+
+	d.nextTagID++
+	tag.ID = d.nextTagID
+	tag.CreatedAt = time.Now()
+	d.tags[tag.ID] = *tag
+	return nil
+}
+
+func (d *todoData) getTag(userID, id int64) (*models.Tag, error) {
+	tag, ok := d.tags[id]
+	if !ok || tag.UserID != userID {
+		return nil, storage.ErrNotFound
+	}
+	return &tag, nil
+}
+
+func (d *todoData) listTags(userID int64) ([]*models.Tag, error) {
+	tags := make([]*models.Tag, 0)
+	for _, tag := range d.tags {
+		if tag.UserID == userID {
+			tag := tag
+			tags = append(tags, &tag)
+		}
+	}
+
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags, nil
+}
+
+func (d *todoData) updateTag(tag *models.Tag) error {
+	existing, ok := d.tags[tag.ID]
+	if !ok || existing.UserID != tag.UserID {
+		return storage.ErrNotFound
+	}
+	if other, ok := d.tagByName(tag.UserID, tag.Name); ok && other.ID != tag.ID {
+		return storage.ErrConflict
+	}
+
+	existing.Name = tag.Name
+	d.tags[tag.ID] = existing
+	tag.CreatedAt = existing.CreatedAt
+	d.touchTagged(tag.ID)
+	return nil
+}
+
+func (d *todoData) deleteTag(userID, id int64) error {
+	tag, ok := d.tags[id]
+	if !ok || tag.UserID != userID {
+		return storage.ErrNotFound
+	}
+
+	d.touchTagged(id)
+	delete(d.tags, id)
+	// Replace rather than modify the slices, snapshots share them
+	for todoID, tagIDs := range d.todoTags {
+		if slices.Contains(tagIDs, id) {
+			d.todoTags[todoID] = slices.DeleteFunc(slices.Clone(tagIDs), func(tagID int64) bool { return tagID == id })
+		}
+	}
+	return nil
+}
+
+// touchTagged updates the updated_at of the todos carrying a tag, whose
+// representation changes with it
+func (d *todoData) touchTagged(tagID int64) {
+	now := time.Now()
+	for todoID, tagIDs := range d.todoTags {
+		if todo, ok := d.todos[todoID]; ok && slices.Contains(tagIDs, tagID) {
+			todo.UpdatedAt = now
+			d.todos[todoID] = todo
+		}
+	}
+}
+
+func (d *todoData) setTodoTags(userID, todoID int64, names []string) error {
+	if _, err := d.getByID(userID, todoID); err != nil {
+		return err
+	}
+
+	tagIDs := make([]int64, 0, len(names))
+	for _, name := range names {
+		tag, ok := d.tagByName(userID, name)
+		if !ok {
+			tag = models.Tag{UserID: userID, Name: name}
+			if err := d.createTag(&tag); err != nil {
+				return err
+			}
+		}
+		tagIDs = append(tagIDs, tag.ID)
+	}
+
+	d.todoTags[todoID] = tagIDs
+	return nil
+}
+
+// tagNames returns the sorted names of the tags attached to a todo
+func (d *todoData) tagNames(todoID int64) []string {
+	names := make([]string, 0, len(d.todoTags[todoID]))
+	for _, tagID := range d.todoTags[todoID] {
+		names = append(names, d.tags[tagID].Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (d *todoData) tagByName(userID int64, name string) (models.Tag, bool) {
+	for _, tag := range d.tags {
+		if tag.UserID == userID && tag.Name == name {
+			return tag, true
+		}
+	}
+	return models.Tag{}, false
+}
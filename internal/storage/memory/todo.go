@@ -0,0 +1,417 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+// TodoStore keeps todos in a map guarded by a single lock, transactions work
+// like those of the AuthStore
+type TodoStore struct {
+	mu   sync.RWMutex
+	data *todoData
+}
+
+// todoData holds the store contents, its methods expect the caller to hold the lock
+type todoData struct {
+	nextID       int64
+	nextTagID    int64
+	nextOutboxID int64
+	todos        map[int64]models.Todo
+	tags         map[int64]models.Tag
+	todoTags     map[int64][]int64
+	outbox       []models.OutboxMessage
+
+	// reminded holds when the due date reminders of todos were sent, queued
+	// when the reminders set by their remind_at were dispatched
+	reminded map[int64]time.Time
+	queued   map[int64]time.Time
+	shares   map[shareKey]models.TodoShare
+
+	nextAttachmentID int64
+	attachments      map[int64]models.Attachment
+}
+
+func newTodoStore() *TodoStore {
+	return &TodoStore{data: &todoData{
+		todos:    make(map[int64]models.Todo),
+		tags:     make(map[int64]models.Tag),
+		todoTags: make(map[int64][]int64),
+		reminded: make(map[int64]time.Time),
+		queued:   make(map[int64]time.Time),
+		shares:   make(map[shareKey]models.TodoShare),
+
+		attachments: make(map[int64]models.Attachment),
+	}}
+}
+
+func (d *todoData) clone() *todoData {
+	return &todoData{
+		nextID:       d.nextID,
+		nextTagID:    d.nextTagID,
+		nextOutboxID: d.nextOutboxID,
+		todos:        maps.Clone(d.todos),
+		tags:         maps.Clone(d.tags),
+		todoTags:     maps.Clone(d.todoTags),
+		outbox:       slices.Clone(d.outbox),
+		reminded:     maps.Clone(d.reminded),
+		queued:       maps.Clone(d.queued),
+		shares:       maps.Clone(d.shares),
+
+		nextAttachmentID: d.nextAttachmentID,
+		attachments:      maps.Clone(d.attachments),
+	}
+}
+
+// InTx runs fn while holding the store lock, rolling back all of its writes
+// when it returns an error or panics
+func (s *TodoStore) InTx(_ context.Context, fn func(repo storage.TodoRepository) error) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := s.data.clone()
+	defer func() {
+		if p := recover(); p != nil {
+			s.data = snapshot
+			panic(p)
+		}
+		if err != nil {
+			s.data = snapshot
+		}
+	}()
+
+	return fn(&todoTx{data: s.data})
+}
+
+// Create inserts a new todo and fills in the generated fields
+func (s *TodoStore) Create(_ context.Context, todo *models.Todo) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.create(todo)
+}
+
+// ImportTodos stores new todos in bulk
+func (s *TodoStore) ImportTodos(_ context.Context, todos []*models.Todo) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.importTodos(todos)
+}
+
+// GetByID returns the todo with the given id owned by the user
+func (s *TodoStore) GetByID(_ context.Context, userID, id int64) (*models.Todo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.getByID(userID, id)
+}
+
+// List returns a keyset page of the user's todos matching the filter ordered
+// from newest to oldest
+func (s *TodoStore) List(_ context.Context, userID int64, filter storage.TodoFilter, page storage.PageRequest) ([]*models.Todo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.list(userID, filter, page)
+}
+
+// ListChildren returns the direct sub-tasks of a todo, oldest first
+func (s *TodoStore) ListChildren(_ context.Context, userID, parentID int64) ([]*models.Todo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.listChildren(userID, parentID)
+}
+
+// Count returns the number of the user's todos outside the trash
+func (s *TodoStore) Count(_ context.Context, userID int64) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.count(userID), nil
+}
+
+// CountLocked counts the user's todos, the store lock serializes it with
+// every transaction
+func (s *TodoStore) CountLocked(ctx context.Context, userID int64) (int, error) {
+	return s.Count(ctx, userID)
+}
+
+// Ancestors returns the ids of the todo's parent, grandparent and so on
+func (s *TodoStore) Ancestors(_ context.Context, userID, id int64) ([]int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.ancestors(userID, id)
+}
+
+// Update persists all mutable fields of the todo
+func (s *TodoStore) Update(_ context.Context, todo *models.Todo) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.update(todo)
+}
+
+// Delete moves the todo with the given id owned by the user to the trash
+// along with its sub-tasks
+func (s *TodoStore) Delete(_ context.Context, userID, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.delete(userID, id)
+}
+
+// todoTx is the repository handed to transactions, the lock is already held
+type todoTx struct {
+	data *todoData
+}
+
+// InTx joins the surrounding transaction
+func (t *todoTx) InTx(_ context.Context, fn func(repo storage.TodoRepository) error) error {
+	return fn(t)
+}
+
+func (t *todoTx) Create(_ context.Context, todo *models.Todo) error {
+	return t.data.create(todo)
+}
+
+func (t *todoTx) ImportTodos(_ context.Context, todos []*models.Todo) (int64, error) {
+	return t.data.importTodos(todos)
+}
+
+func (t *todoTx) GetByID(_ context.Context, userID, id int64) (*models.Todo, error) {
+	return t.data.getByID(userID, id)
+}
+
+func (t *todoTx) List(_ context.Context, userID int64, filter storage.TodoFilter, page storage.PageRequest) ([]*models.Todo, error) {
+	return t.data.list(userID, filter, page)
+}
+
+func (t *todoTx) ListChildren(_ context.Context, userID, parentID int64) ([]*models.Todo, error) {
+	return t.data.listChildren(userID, parentID)
+}
+
+func (t *todoTx) Count(_ context.Context, userID int64) (int, error) {
+	return t.data.count(userID), nil
+}
+
+func (t *todoTx) CountLocked(_ context.Context, userID int64) (int, error) {
+	return t.data.count(userID), nil
+}
+
+func (t *todoTx) Ancestors(_ context.Context, userID, id int64) ([]int64, error) {
+	return t.data.ancestors(userID, id)
+}
+
+func (t *todoTx) Update(_ context.Context, todo *models.Todo) error {
+	return t.data.update(todo)
+}
+
+func (t *todoTx) Delete(_ context.Context, userID, id int64) error {
+	return t.data.delete(userID, id)
+}
+
+func (d *todoData) create(todo *models.Todo) error {
+	d.nextID++
+	now := time.Now()
+	todo.ID = d.nextID
+	todo.CreatedAt = now
+	todo.UpdatedAt = now
+
+	// Tags live in todoTags, see setTodoTags
+	stored := *todo
+	stored.Tags = nil
+	d.todos[todo.ID] = stored
+
+	return nil
+}
+
+func (d *todoData) importTodos(todos []*models.Todo) (int64, error) {
+	for _, todo := range todos {
+		if err := d.create(todo); err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(todos)), nil
+}
+
+func (d *todoData) getByID(userID, id int64) (*models.Todo, error) {
+	todo, ok := d.todos[id]
+	if !ok || todo.UserID != userID || todo.DeletedAt != nil {
+		return nil, storage.ErrNotFound
+	}
+	todo.Tags = d.tagNames(id)
+	return &todo, nil
+}
+
+func (d *todoData) list(userID int64, filter storage.TodoFilter, page storage.PageRequest) ([]*models.Todo, error) {
+	owned := make([]*models.Todo, 0)
+	for _, todo := range d.todos {
+		if todo.UserID == userID && d.matches(&todo, filter) {
+			todo := todo
+			todo.Tags = d.tagNames(todo.ID)
+			owned = append(owned, &todo)
+		}
+	}
+
+	sort.Slice(owned, func(i, j int) bool { return newerThan(owned[i], owned[j].CreatedAt, owned[j].ID) })
+
+	if page.Cursor == nil {
+		return owned[:min(page.Limit, len(owned))], nil
+	}
+
+	// Index of the first todo past the cursor in newest-first order
+	split := sort.Search(len(owned), func(i int) bool {
+		return !newerThan(owned[i], page.Cursor.CreatedAt, page.Cursor.ID)
+	})
+	if !page.Backward {
+		after := owned[split:]
+		if len(after) > 0 && after[0].ID == page.Cursor.ID && after[0].CreatedAt.Equal(page.Cursor.CreatedAt) {
+			after = after[1:]
+		}
+		return after[:min(page.Limit, len(after))], nil
+	}
+
+	before := owned[:split]
+	return before[max(0, len(before)-page.Limit):], nil
+}
+
+// newerThan reports whether the todo sorts before the (createdAt, id)
+// position in newest-first order
+func newerThan(todo *models.Todo, createdAt time.Time, id int64) bool {
+	if !todo.CreatedAt.Equal(createdAt) {
+		return todo.CreatedAt.After(createdAt)
+	}
+	return todo.ID > id
+}
+
+// matches mirrors the WHERE clause built by the Postgres store
+func (d *todoData) matches(todo *models.Todo, filter storage.TodoFilter) bool {
+	if todo.DeletedAt != nil {
+		return false
+	}
+	if filter.Completed != nil && todo.Completed != *filter.Completed {
+		return false
+	}
+	if filter.Priority != "" && todo.Priority != filter.Priority {
+		return false
+	}
+	if filter.DueBefore != nil && (todo.DueDate == nil || !todo.DueDate.Before(*filter.DueBefore)) {
+		return false
+	}
+	if filter.DueAfter != nil && (todo.DueDate == nil || !todo.DueDate.After(*filter.DueAfter)) {
+		return false
+	}
+	if len(filter.Tags) > 0 {
+		names := d.tagNames(todo.ID)
+		for _, name := range filter.Tags {
+			if !slices.Contains(names, name) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (d *todoData) count(userID int64) int {
+	count := 0
+	for _, todo := range d.todos {
+		if todo.UserID == userID && todo.DeletedAt == nil {
+			count++
+		}
+	}
+	return count
+}
+
+func (d *todoData) listChildren(userID, parentID int64) ([]*models.Todo, error) {
+	children := make([]*models.Todo, 0)
+	for _, todo := range d.todos {
+		if todo.UserID == userID && todo.ParentID != nil && *todo.ParentID == parentID && todo.DeletedAt == nil {
+			todo := todo
+			todo.Tags = d.tagNames(todo.ID)
+			children = append(children, &todo)
+		}
+	}
+
+	sort.Slice(children, func(i, j int) bool {
+		if !children[i].CreatedAt.Equal(children[j].CreatedAt) {
+			return children[i].CreatedAt.Before(children[j].CreatedAt)
+		}
+		return children[i].ID < children[j].ID
+	})
+	return children, nil
+}
+
+func (d *todoData) ancestors(userID, id int64) ([]int64, error) {
+	var ids []int64
+	todo, ok := d.todos[id]
+	// Bounded by the number of todos in case the data already holds a cycle
+	for ok && todo.UserID == userID && todo.ParentID != nil && len(ids) < len(d.todos) {
+		ids = append(ids, *todo.ParentID)
+		todo, ok = d.todos[*todo.ParentID]
+	}
+	return ids, nil
+}
+
+func (d *todoData) update(todo *models.Todo) error {
+	existing, ok := d.todos[todo.ID]
+	if !ok || existing.UserID != todo.UserID || existing.DeletedAt != nil {
+		return storage.ErrNotFound
+	}
+
+	if !equalTimes(existing.DueDate, todo.DueDate) {
+		delete(d.reminded, todo.ID)
+	}
+	existing.ParentID = todo.ParentID
+	existing.Title = todo.Title
+	existing.Description = todo.Description
+	existing.Completed = todo.Completed
+	existing.DueDate = todo.DueDate
+	existing.Recurrence = todo.Recurrence
+	existing.Priority = todo.Priority
+	existing.UpdatedAt = time.Now()
+	d.todos[todo.ID] = existing
+
+	todo.CreatedAt = existing.CreatedAt
+	todo.UpdatedAt = existing.UpdatedAt
+	return nil
+}
+
+func (d *todoData) delete(userID, id int64) error {
+	if _, err := d.getByID(userID, id); err != nil {
+		return err
+	}
+
+	d.trash(id, time.Now())
+	return nil
+}
+
+// trash moves a todo and its live sub-tasks to the trash, all sharing the
+// same deletion time so they are restored together
+func (d *todoData) trash(id int64, deletedAt time.Time) {
+	todo := d.todos[id]
+	todo.DeletedAt = &deletedAt
+	d.todos[id] = todo
+
+	for childID, child := range d.todos {
+		if child.ParentID != nil && *child.ParentID == id && child.DeletedAt == nil {
+			d.trash(childID, deletedAt)
+		}
+	}
+}
+
+// remove permanently deletes a todo, mirroring the ON DELETE CASCADE of the
+// parent_id foreign key
+func (d *todoData) remove(id int64) {
+	delete(d.todos, id)
+	delete(d.reminded, id)
+	delete(d.queued, id)
+	maps.DeleteFunc(d.shares, func(k shareKey, _ models.TodoShare) bool { return k.todoID == id })
+	delete(d.todoTags, id)
+	d.orphanAttachments(id)
+	for childID, child := range d.todos {
+		if child.ParentID != nil && *child.ParentID == id {
+			d.remove(childID)
+		}
+	}
+}
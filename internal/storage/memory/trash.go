@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+// ListDeleted returns a page of the user's trashed todos, most recently
+// deleted first, along with the number of todos in the trash
+func (s *TodoStore) ListDeleted(_ context.Context, userID int64, limit, offset int) ([]*models.Todo, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.listDeleted(userID, limit, offset)
+}
+
+// Restore takes a todo and the sub-tasks deleted along with it out of the trash
+func (s *TodoStore) Restore(_ context.Context, userID, id int64) (*models.Todo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.restore(userID, id)
+}
+
+// PurgeDeleted permanently removes every todo trashed before the cutoff
+func (s *TodoStore) PurgeDeleted(_ context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.purgeDeleted(before)
+}
+
+func (t *todoTx) ListDeleted(_ context.Context, userID int64, limit, offset int) ([]*models.Todo, int, error) {
+	return t.data.listDeleted(userID, limit, offset)
+}
+
+func (t *todoTx) Restore(_ context.Context, userID, id int64) (*models.Todo, error) {
+	return t.data.restore(userID, id)
+}
+
+func (t *todoTx) PurgeDeleted(_ context.Context, before time.Time) (int64, error) {
+	return t.data.purgeDeleted(before)
+}
+
+func (d *todoData) listDeleted(userID int64, limit, offset int) ([]*models.Todo, int, error) {
+	deleted := make([]*models.Todo, 0)
+	for _, todo := range d.todos {
+		if todo.UserID == userID && todo.DeletedAt != nil {
+			todo := todo
+			todo.Tags = d.tagNames(todo.ID)
+			deleted = append(deleted, &todo)
+		}
+	}
+
+	sort.Slice(deleted, func(i, j int) bool {
+		if !deleted[i].DeletedAt.Equal(*deleted[j].DeletedAt) {
+			return deleted[i].DeletedAt.After(*deleted[j].DeletedAt)
+		}
+		return deleted[i].ID > deleted[j].ID
+	})
+
+	total := len(deleted)
+	if offset >= total {
+		return []*models.Todo{}, total, nil
+	}
+	end := min(offset+limit, total)
+
+	return deleted[offset:end], total, nil
+}
+
+func (d *todoData) restore(userID, id int64) (*models.Todo, error) {
+	todo, ok := d.todos[id]
+	if !ok || todo.UserID != userID || todo.DeletedAt == nil {
+		return nil, storage.ErrNotFound
+	}
+
+	d.untrash(id, *todo.DeletedAt, time.Now())
+	return d.getByID(userID, id)
+}
+
+// untrash restores a todo and the descendants trashed at the same time,
+// sub-tasks deleted on their own earlier stay in the trash
+func (d *todoData) untrash(id int64, deletedAt, now time.Time) {
+	todo := d.todos[id]
+	todo.DeletedAt = nil
+	todo.UpdatedAt = now
+	d.todos[id] = todo
+
+	for childID, child := range d.todos {
+		if child.ParentID != nil && *child.ParentID == id && child.DeletedAt != nil && child.DeletedAt.Equal(deletedAt) {
+			d.untrash(childID, deletedAt, now)
+		}
+	}
+}
+
+func (d *todoData) purgeDeleted(before time.Time) (int64, error) {
+	var expired []int64
+	for id, todo := range d.todos {
+		if todo.DeletedAt != nil && todo.DeletedAt.Before(before) {
+			expired = append(expired, id)
+		}
+	}
+
+	for _, id := range expired {
+		d.remove(id)
+	}
+	return int64(len(expired)), nil
+}
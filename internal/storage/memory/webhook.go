@@ -0,0 +1,256 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+// WebhookStore keeps webhooks and their deliveries
+type WebhookStore struct {
+	mu             sync.RWMutex
+	webhooks       map[int64]models.Webhook
+	deliveries     map[int64]models.WebhookDelivery
+	nextWebhookID  int64
+	nextDeliveryID int64
+}
+
+func newWebhookStore() *WebhookStore {
+	return &WebhookStore{
+		webhooks:   make(map[int64]models.Webhook),
+		deliveries: make(map[int64]models.WebhookDelivery),
+	}
+}
+
+// CreateWebhook inserts a new webhook
+func (s *WebhookStore) CreateWebhook(_ context.Context, webhook *models.Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextWebhookID++
+	now := time.Now()
+	webhook.ID = s.nextWebhookID
+	webhook.CreatedAt = now
+	webhook.UpdatedAt = now
+	s.webhooks[webhook.ID] = cloneWebhook(*webhook)
+	return nil
+}
+
+// GetWebhook returns a webhook of the user
+func (s *WebhookStore) GetWebhook(_ context.Context, userID, id int64) (*models.Webhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	webhook, ok := s.webhooks[id]
+	if !ok || webhook.UserID != userID {
+		return nil, storage.ErrNotFound
+	}
+	webhook = cloneWebhook(webhook)
+	return &webhook, nil
+}
+
+// ListWebhooks returns the user's webhooks, oldest first
+func (s *WebhookStore) ListWebhooks(_ context.Context, userID int64) ([]*models.Webhook, error) {
+	return s.listWebhooks(func(webhook *models.Webhook) bool { return webhook.UserID == userID }), nil
+}
+
+// ListWebhooksForEvent returns the user's active webhooks subscribed to the
+// event type
+func (s *WebhookStore) ListWebhooksForEvent(_ context.Context, userID int64, eventType string) ([]*models.Webhook, error) {
+	return s.listWebhooks(func(webhook *models.Webhook) bool {
+		return webhook.UserID == userID && webhook.Active &&
+			(len(webhook.Events) == 0 || slices.Contains(webhook.Events, eventType))
+	}), nil
+}
+
+func (s *WebhookStore) listWebhooks(match func(*models.Webhook) bool) []*models.Webhook {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	webhooks := make([]*models.Webhook, 0)
+	for _, webhook := range s.webhooks {
+		if match(&webhook) {
+			webhook := cloneWebhook(webhook)
+			webhooks = append(webhooks, &webhook)
+		}
+	}
+
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].ID < webhooks[j].ID })
+	return webhooks
+}
+
+// UpdateWebhook saves the url, secret, events and active flag of a webhook
+func (s *WebhookStore) UpdateWebhook(_ context.Context, webhook *models.Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.webhooks[webhook.ID]
+	if !ok || existing.UserID != webhook.UserID {
+		return storage.ErrNotFound
+	}
+
+	existing.URL = webhook.URL
+	existing.Secret = webhook.Secret
+	existing.Events = slices.Clone(webhook.Events)
+	existing.Active = webhook.Active
+	existing.UpdatedAt = time.Now()
+	s.webhooks[webhook.ID] = existing
+
+	webhook.CreatedAt = existing.CreatedAt
+	webhook.UpdatedAt = existing.UpdatedAt
+	return nil
+}
+
+// DeleteWebhook removes a webhook together with its deliveries
+func (s *WebhookStore) DeleteWebhook(_ context.Context, userID, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	webhook, ok := s.webhooks[id]
+	if !ok || webhook.UserID != userID {
+		return storage.ErrNotFound
+	}
+
+	delete(s.webhooks, id)
+	for deliveryID, delivery := range s.deliveries {
+		if delivery.WebhookID == id {
+			delete(s.deliveries, deliveryID)
+		}
+	}
+	return nil
+}
+
+// CreateDelivery adds a delivery unless the event was already delivered to
+// the webhook
+func (s *WebhookStore) CreateDelivery(_ context.Context, delivery *models.WebhookDelivery) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.webhooks[delivery.WebhookID]; !ok {
+		return false, storage.ErrNotFound
+	}
+	if delivery.RedeliveryOf == nil {
+		for _, existing := range s.deliveries {
+			if existing.WebhookID == delivery.WebhookID && existing.EventID == delivery.EventID && existing.RedeliveryOf == nil {
+				return false, nil
+			}
+		}
+	}
+
+	s.nextDeliveryID++
+	delivery.ID = s.nextDeliveryID
+	delivery.CreatedAt = time.Now()
+	s.deliveries[delivery.ID] = *delivery
+	return true, nil
+}
+
+// GetDelivery returns a delivery of one of the user's webhooks
+func (s *WebhookStore) GetDelivery(_ context.Context, userID, webhookID, id int64) (*models.WebhookDelivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	delivery, ok := s.deliveries[id]
+	if !ok || delivery.UserID != userID || delivery.WebhookID != webhookID {
+		return nil, storage.ErrNotFound
+	}
+	return &delivery, nil
+}
+
+// ListDeliveries returns a page of the deliveries of a webhook, newest first
+func (s *WebhookStore) ListDeliveries(_ context.Context, userID, webhookID int64, limit, offset int) ([]*models.WebhookDelivery, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	webhook, ok := s.webhooks[webhookID]
+	if !ok || webhook.UserID != userID {
+		return nil, 0, storage.ErrNotFound
+	}
+
+	matched := make([]*models.WebhookDelivery, 0)
+	for _, delivery := range s.deliveries {
+		if delivery.WebhookID == webhookID {
+			delivery := delivery
+			matched = append(matched, &delivery)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].ID > matched[j].ID })
+
+	total := len(matched)
+	if offset >= total {
+		return []*models.WebhookDelivery{}, total, nil
+	}
+	return matched[offset:min(offset+limit, total)], total, nil
+}
+
+// DueDeliveries returns pending deliveries whose next attempt is due
+func (s *WebhookStore) DueDeliveries(_ context.Context, now time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	due := make([]*models.WebhookDelivery, 0)
+	for _, delivery := range s.deliveries {
+		if delivery.Status == models.DeliveryPending && delivery.NextAttemptAt != nil && !delivery.NextAttemptAt.After(now) {
+			delivery := delivery
+			due = append(due, &delivery)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].NextAttemptAt.Equal(*due[j].NextAttemptAt) {
+			return due[i].NextAttemptAt.Before(*due[j].NextAttemptAt)
+		}
+		return due[i].ID < due[j].ID
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+// UpdateDelivery saves the outcome of a delivery attempt
+func (s *WebhookStore) UpdateDelivery(_ context.Context, delivery *models.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.deliveries[delivery.ID]
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	existing.Status = delivery.Status
+	existing.Attempts = delivery.Attempts
+	existing.ResponseStatus = delivery.ResponseStatus
+	existing.LastError = delivery.LastError
+	existing.NextAttemptAt = delivery.NextAttemptAt
+	existing.DeliveredAt = delivery.DeliveredAt
+	s.deliveries[delivery.ID] = existing
+	return nil
+}
+
+// PurgeDeliveries removes final deliveries created before the cutoff
+func (s *WebhookStore) PurgeDeliveries(_ context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+	for id, delivery := range s.deliveries {
+		if delivery.Status != models.DeliveryPending && delivery.CreatedAt.Before(before) {
+			delete(s.deliveries, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func cloneWebhook(webhook models.Webhook) models.Webhook {
+	webhook.Events = slices.Clone(webhook.Events)
+	if webhook.Events == nil {
+		webhook.Events = []string{}
+	}
+	return webhook
+}
@@ -0,0 +1,25 @@
+package storage
+
+import "context"
+
+// Operations whose queries may run longer than the default statement timeout,
+// backends with a per-operation timeout apply it to the transactions run
+// under a context marked with WithOperation
+const (
+	OperationExport    = "export"
+	OperationMigration = "migration"
+)
+
+type operationKey struct{}
+
+// WithOperation marks ctx as running the named operation
+func WithOperation(ctx context.Context, operation string) context.Context {
+	return context.WithValue(ctx, operationKey{}, operation)
+}
+
+// OperationFromContext returns the operation ctx was marked with, empty when
+// it was not
+func OperationFromContext(ctx context.Context) string {
+	operation, _ := ctx.Value(operationKey{}).(string)
+	return operation
+}
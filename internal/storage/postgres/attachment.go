@@ -0,0 +1,213 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+const attachmentColumns = "a.id, a.todo_id, a.user_id, a.filename, a.content_type, a.size, a.status, a.storage_key, a.created_at"
+
+// CreateAttachment inserts the metadata of a file attached to one of the
+// user's todos, returning storage.ErrNotFound when the todo does not exist.
+// Attachments without a status are created ready
+func (s *TodoStore) CreateAttachment(ctx context.Context, attachment *models.Attachment) error {
+	if attachment.Status == "" {
+		attachment.Status = models.AttachmentReady
+	}
+
+	query := `
+		INSERT INTO attachments (todo_id, user_id, filename, content_type, size, status, storage_key)
+		SELECT id, user_id, $3, $4, $5, $6, $7
+		FROM todos
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+		RETURNING id, created_at`
+
+	err := s.db.QueryRowContext(ctx, query, attachment.TodoID, attachment.UserID, attachment.Filename,
+		attachment.ContentType, attachment.Size, attachment.Status, attachment.StorageKey).
+		Scan(&attachment.ID, &attachment.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.ErrNotFound
+		}
+		return fmt.Errorf("failed to create attachment: %w", err)
+	}
+
+	return nil
+}
+
+// GetAttachment returns an attachment of one of the user's todos in any status
+func (s *TodoStore) GetAttachment(ctx context.Context, userID, todoID, id int64) (*models.Attachment, error) {
+	query := `
+		SELECT ` + attachmentColumns + `
+		FROM attachments a
+		JOIN todos t ON t.id = a.todo_id
+		WHERE a.id = $1 AND a.todo_id = $2 AND a.user_id = $3 AND t.deleted_at IS NULL`
+
+	attachment, err := scanAttachment(s.db.QueryRowContext(ctx, query, id, todoID, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get attachment %d: %w", id, err)
+	}
+
+	return attachment, nil
+}
+
+// GetUserAttachment returns an attachment of any of the user's todos
+// outside the trash, in any status
+func (s *TodoStore) GetUserAttachment(ctx context.Context, userID, id int64) (*models.Attachment, error) {
+	query := `
+		SELECT ` + attachmentColumns + `
+		FROM attachments a
+		JOIN todos t ON t.id = a.todo_id
+		WHERE a.id = $1 AND a.user_id = $2 AND t.deleted_at IS NULL`
+
+	attachment, err := scanAttachment(s.db.QueryRowContext(ctx, query, id, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get attachment %d: %w", id, err)
+	}
+
+	return attachment, nil
+}
+
+// ListAttachments returns the ready attachments of a todo, oldest first
+func (s *TodoStore) ListAttachments(ctx context.Context, userID, todoID int64) ([]*models.Attachment, error) {
+	if _, err := s.GetByID(ctx, userID, todoID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + attachmentColumns + `
+		FROM attachments a
+		WHERE a.todo_id = $1 AND a.user_id = $2 AND a.status = $3
+		ORDER BY a.id`
+
+	return s.queryAttachments(ctx, query, todoID, userID, models.AttachmentReady)
+}
+
+// ListUserAttachments returns the ready attachments of the user's todos,
+// trashed or not. Attachments of purged todos have no todo and are left out
+func (s *TodoStore) ListUserAttachments(ctx context.Context, userID int64) ([]*models.Attachment, error) {
+	query := `
+		SELECT ` + attachmentColumns + `
+		FROM attachments a
+		WHERE a.user_id = $1 AND a.todo_id IS NOT NULL AND a.status = $2
+		ORDER BY a.id`
+
+	return s.queryAttachments(ctx, query, userID, models.AttachmentReady)
+}
+
+// ConfirmAttachment marks a pending attachment ready
+func (s *TodoStore) ConfirmAttachment(ctx context.Context, userID, id, size int64, contentType string) error {
+	query := `
+		UPDATE attachments
+		SET status = $5, size = $3, content_type = $4
+		WHERE id = $1 AND user_id = $2 AND status = $6`
+
+	result, err := s.db.ExecContext(ctx, query, id, userID, size, contentType, models.AttachmentReady, models.AttachmentPending)
+	if err != nil {
+		return fmt.Errorf("failed to confirm attachment %d: %w", id, err)
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to confirm attachment %d: %w", id, err)
+	}
+	if updated == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteAttachment removes the metadata of an attachment
+func (s *TodoStore) DeleteAttachment(ctx context.Context, userID, id int64) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM attachments WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete attachment %d: %w", id, err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete attachment %d: %w", id, err)
+	}
+	if deleted == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// ListOrphanedAttachments returns attachments of purged todos and stale
+// pending uploads, oldest first
+func (s *TodoStore) ListOrphanedAttachments(ctx context.Context, pendingBefore time.Time, limit int) ([]*models.Attachment, error) {
+	query := `
+		SELECT ` + attachmentColumns + `
+		FROM attachments a
+		WHERE a.todo_id IS NULL OR (a.status = $1 AND a.created_at < $2)
+		ORDER BY a.id
+		LIMIT $3`
+
+	return s.queryAttachments(ctx, query, models.AttachmentPending, pendingBefore, limit)
+}
+
+// AttachmentBytes returns the total size of the user's attachments in any
+// status, including those of purged todos
+func (s *TodoStore) AttachmentBytes(ctx context.Context, userID int64) (int64, error) {
+	query := `SELECT COALESCE(SUM(size), 0) FROM attachments WHERE user_id = $1`
+
+	var total int64
+	if err := s.db.QueryRowContext(ctx, query, userID).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to sum attachment sizes: %w", err)
+	}
+
+	return total, nil
+}
+
+func (s *TodoStore) queryAttachments(ctx context.Context, query string, args ...any) ([]*models.Attachment, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attachments: %w", err)
+	}
+	defer rows.Close()
+
+	attachments := make([]*models.Attachment, 0)
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate attachments: %w", err)
+	}
+
+	return attachments, nil
+}
+
+func scanAttachment(row rowScanner) (*models.Attachment, error) {
+	var (
+		attachment models.Attachment
+		todoID     sql.NullInt64
+	)
+	err := row.Scan(&attachment.ID, &todoID, &attachment.UserID, &attachment.Filename,
+		&attachment.ContentType, &attachment.Size, &attachment.Status, &attachment.StorageKey, &attachment.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	attachment.TodoID = todoID.Int64
+	return &attachment, nil
+}
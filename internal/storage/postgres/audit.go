@@ -0,0 +1,125 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+type AuditStore struct {
+	db Querier
+}
+
+func newAuditStore(db Querier) *AuditStore {
+	return &AuditStore{db: db}
+}
+
+const auditColumns = "id, user_id, action, entity_type, entity_id, changes, ip_address, request_id, created_at"
+
+// CreateAuditEvent appends an event to the audit trail
+func (s *AuditStore) CreateAuditEvent(ctx context.Context, event *models.AuditEvent) error {
+	var changes []byte
+	if len(event.Changes) > 0 {
+		var err error
+		if changes, err = json.Marshal(event.Changes); err != nil {
+			return fmt.Errorf("failed to encode audit changes: %w", err)
+		}
+	}
+
+	query := `
+		INSERT INTO audit_events (user_id, action, entity_type, entity_id, changes, ip_address, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at`
+
+	err := s.db.QueryRowContext(ctx, query, event.UserID, event.Action, event.EntityType, event.EntityID,
+		changes, event.IPAddress, event.RequestID).Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create audit event: %w", err)
+	}
+
+	return nil
+}
+
+// ListAuditEvents returns a page of events matching the filter ordered from
+// newest to oldest along with the total number of matches
+func (s *AuditStore) ListAuditEvents(ctx context.Context, filter storage.AuditFilter, limit, offset int) ([]*models.AuditEvent, int, error) {
+	where, args := auditFilterClause(filter)
+
+	var total int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM audit_events WHERE `+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit events: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT `+auditColumns+`
+		FROM audit_events
+		WHERE %s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d`, where, len(args)+1, len(args)+2)
+
+	rows, err := s.db.QueryContext(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list audit events: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]*models.AuditEvent, 0, limit)
+	for rows.Next() {
+		var event models.AuditEvent
+		var changes []byte
+		err := rows.Scan(&event.ID, &event.UserID, &event.Action, &event.EntityType, &event.EntityID,
+			&changes, &event.IPAddress, &event.RequestID, &event.CreatedAt)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan audit event: %w", err)
+		}
+		if len(changes) > 0 {
+			if err := json.Unmarshal(changes, &event.Changes); err != nil {
+				return nil, 0, fmt.Errorf("failed to decode audit changes of event %d: %w", event.ID, err)
+			}
+		}
+		events = append(events, &event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate audit events: %w", err)
+	}
+
+	return events, total, nil
+}
+
+// auditFilterClause builds the WHERE clause for a filter
+func auditFilterClause(filter storage.AuditFilter) (string, []any) {
+	conditions := []string{"TRUE"}
+	var args []any
+
+	add := func(condition string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.UserID != nil {
+		add("user_id = $%d", *filter.UserID)
+	}
+	if filter.Action != "" {
+		add("action = $%d", filter.Action)
+	}
+	if filter.EntityType != "" {
+		add("entity_type = $%d", filter.EntityType)
+	}
+	if filter.EntityID != "" {
+		add("entity_id = $%d", filter.EntityID)
+	}
+	if filter.Since != nil {
+		add("created_at >= $%d", *filter.Since)
+	}
+	if filter.Until != nil {
+		add("created_at < $%d", *filter.Until)
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
@@ -1,15 +1,634 @@
 package postgres
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+// Postgres error codes for unique and foreign key constraint violations
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
 
 type AuthStore struct {
-	db    *sql.DB
+	db    Querier
 	store *Store
 }
 
-func NewAuthStore(db *sql.DB, store *Store) *AuthStore {
+func NewAuthStore(db Querier, store *Store) *AuthStore {
 	return &AuthStore{
 		db:    db,
 		store: store,
 	}
 }
+
+const sessionColumns = "id, user_id, ip_address, user_agent, created_at, last_seen_at, expires_at, revoked_at"
+
+const userColumns = "id, email, name, role, external_id, password_hash, email_verified_at, deactivated_at, created_at, updated_at"
+
+// CreateUser inserts a new user, returning storage.ErrConflict when the
+// email is taken. Users without a role get models.RoleUser
+func (s *AuthStore) CreateUser(ctx context.Context, user *models.User) error {
+	query := `
+		INSERT INTO users (email, name, role, external_id, password_hash)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at`
+
+	if user.Role == "" {
+		user.Role = models.RoleUser
+	}
+	err := s.db.QueryRowContext(ctx, query, user.Email, user.Name, user.Role, user.ExternalID, user.PasswordHash).
+		Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return storage.ErrConflict
+		}
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return nil
+}
+
+// GetUserByEmail looks up a user by email, case-insensitively
+func (s *AuthStore) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE LOWER(email) = LOWER($1)`
+
+	user, err := scanUser(s.db.QueryRowContext(ctx, query, email))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get user by email: %w", err)
+	}
+
+	return user, nil
+}
+
+// GetUserByID looks up a user by id
+func (s *AuthStore) GetUserByID(ctx context.Context, id int64) (*models.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
+
+	user, err := scanUser(s.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get user %d: %w", id, err)
+	}
+
+	return user, nil
+}
+
+// UpdatePassword replaces the user's password hash
+func (s *AuthStore) UpdatePassword(ctx context.Context, userID int64, passwordHash string) error {
+	query := `UPDATE users SET password_hash = $2, updated_at = NOW() WHERE id = $1`
+
+	result, err := s.db.ExecContext(ctx, query, userID, passwordHash)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	if affected == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// SetRole changes the user's role
+func (s *AuthStore) SetRole(ctx context.Context, userID int64, role string) error {
+	query := `UPDATE users SET role = $2, updated_at = NOW() WHERE id = $1`
+
+	result, err := s.db.ExecContext(ctx, query, userID, role)
+	if err != nil {
+		return fmt.Errorf("failed to set role: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to set role: %w", err)
+	}
+	if affected == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// MarkEmailVerified records when the user verified their email, repeated
+// verifications keep the original time
+func (s *AuthStore) MarkEmailVerified(ctx context.Context, userID int64) error {
+	query := `
+		UPDATE users SET email_verified_at = COALESCE(email_verified_at, NOW()), updated_at = NOW()
+		WHERE id = $1`
+
+	result, err := s.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to mark email verified: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to mark email verified: %w", err)
+	}
+	if affected == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// ListUsers returns a page of users matching the filter ordered by id, and
+// the number of matching users
+func (s *AuthStore) ListUsers(ctx context.Context, filter storage.UserFilter, limit, offset int) ([]*models.User, int, error) {
+	conditions := []string{"TRUE"}
+	var args []any
+	if filter.Email != "" {
+		args = append(args, filter.Email)
+		conditions = append(conditions, fmt.Sprintf("LOWER(email) = LOWER($%d)", len(args)))
+	}
+	if filter.ExternalID != "" {
+		args = append(args, filter.ExternalID)
+		conditions = append(conditions, fmt.Sprintf("external_id = $%d", len(args)))
+	}
+	where := strings.Join(conditions, " AND ")
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	query := fmt.Sprintf(`SELECT `+userColumns+` FROM users WHERE %s ORDER BY id LIMIT $%d OFFSET $%d`,
+		where, len(args)+1, len(args)+2)
+	rows, err := s.db.QueryContext(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	users := make([]*models.User, 0, limit)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate users: %w", err)
+	}
+
+	return users, total, nil
+}
+
+// UpdateUser saves the email, name and external id of the user, returning
+// storage.ErrConflict when another user has the email
+func (s *AuthStore) UpdateUser(ctx context.Context, user *models.User) error {
+	query := `
+		UPDATE users SET email = $2, name = $3, external_id = $4, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at`
+
+	err := s.db.QueryRowContext(ctx, query, user.ID, user.Email, user.Name, user.ExternalID).Scan(&user.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.ErrNotFound
+		}
+		if isUniqueViolation(err) {
+			return storage.ErrConflict
+		}
+		return fmt.Errorf("failed to update user %d: %w", user.ID, err)
+	}
+
+	return nil
+}
+
+// SetDeactivated deactivates or reactivates the user, deactivating again
+// keeps the first time
+func (s *AuthStore) SetDeactivated(ctx context.Context, userID int64, deactivated bool) error {
+	query := `
+		UPDATE users SET deactivated_at = CASE WHEN $2 THEN COALESCE(deactivated_at, NOW()) END, updated_at = NOW()
+		WHERE id = $1`
+
+	result, err := s.db.ExecContext(ctx, query, userID, deactivated)
+	if err != nil {
+		return fmt.Errorf("failed to set user deactivation: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to set user deactivation: %w", err)
+	}
+	if affected == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+func scanUser(row rowScanner) (*models.User, error) {
+	var user models.User
+	err := row.Scan(
+		&user.ID,
+		&user.Email,
+		&user.Name,
+		&user.Role,
+		&user.ExternalID,
+		&user.PasswordHash,
+		&user.EmailVerifiedAt,
+		&user.DeactivatedAt,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func isUniqueViolation(err error) bool {
+	code, _ := errorCode(err)
+	return code == uniqueViolation
+}
+
+func isForeignKeyViolation(err error) bool {
+	code, _ := errorCode(err)
+	return code == foreignKeyViolation
+}
+
+// CreateSession inserts a new login session
+func (s *AuthStore) CreateSession(ctx context.Context, session *models.Session) error {
+	query := `
+		INSERT INTO sessions (id, user_id, ip_address, user_agent, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at, last_seen_at`
+
+	err := s.db.QueryRowContext(ctx, query, session.ID, session.UserID, session.IPAddress, session.UserAgent, session.ExpiresAt).
+		Scan(&session.CreatedAt, &session.LastSeenAt)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return nil
+}
+
+// GetSession returns the session with the given id
+func (s *AuthStore) GetSession(ctx context.Context, id string) (*models.Session, error) {
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE id = $1`
+
+	session, err := scanSession(s.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return session, nil
+}
+
+// ListSessions returns the active sessions of the user, most recently seen
+// first
+func (s *AuthStore) ListSessions(ctx context.Context, userID int64) ([]*models.Session, error) {
+	query := `
+		SELECT ` + sessionColumns + `
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY last_seen_at DESC, id`
+
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := make([]*models.Session, 0)
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// TouchSession records that the session was used now from the device
+func (s *AuthStore) TouchSession(ctx context.Context, id, ipAddress, userAgent string) error {
+	query := `UPDATE sessions SET ip_address = $2, user_agent = $3, last_seen_at = NOW() WHERE id = $1`
+
+	if _, err := s.db.ExecContext(ctx, query, id, ipAddress, userAgent); err != nil {
+		return fmt.Errorf("failed to touch session: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeSession marks the session as revoked, invalidating its refresh and access tokens
+func (s *AuthStore) RevokeSession(ctx context.Context, id string) error {
+	query := `UPDATE sessions SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`
+
+	if _, err := s.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeUserSessions revokes every active session of the user
+func (s *AuthStore) RevokeUserSessions(ctx context.Context, userID int64) error {
+	query := `UPDATE sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`
+
+	if _, err := s.db.ExecContext(ctx, query, userID); err != nil {
+		return fmt.Errorf("failed to revoke user sessions: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeOtherSessions revokes every active session of the user but keepID
+// and returns how many were revoked
+func (s *AuthStore) RevokeOtherSessions(ctx context.Context, userID int64, keepID string) (int64, error) {
+	query := `UPDATE sessions SET revoked_at = NOW() WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL AND expires_at > NOW()`
+
+	result, err := s.db.ExecContext(ctx, query, userID, keepID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke user sessions: %w", err)
+	}
+
+	revoked, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke user sessions: %w", err)
+	}
+
+	return revoked, nil
+}
+
+// IsRevoked reports whether the session has been revoked or has expired,
+// unknown sessions are treated as revoked
+func (s *AuthStore) IsRevoked(ctx context.Context, sessionID string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM sessions
+			WHERE id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		)`
+
+	var active bool
+	if err := s.db.QueryRowContext(ctx, query, sessionID).Scan(&active); err != nil {
+		return false, fmt.Errorf("failed to check session: %w", err)
+	}
+
+	return !active, nil
+}
+
+// CreateRefreshToken stores a new refresh token hash
+func (s *AuthStore) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (token_hash, session_id, user_id, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at`
+
+	err := s.db.QueryRowContext(ctx, query, token.TokenHash, token.SessionID, token.UserID, token.ExpiresAt).
+		Scan(&token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	return nil
+}
+
+// GetRefreshToken returns the refresh token with the given hash
+func (s *AuthStore) GetRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	query := `
+		SELECT token_hash, session_id, user_id, expires_at, used_at, created_at
+		FROM refresh_tokens
+		WHERE token_hash = $1`
+
+	var token models.RefreshToken
+	err := s.db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&token.TokenHash,
+		&token.SessionID,
+		&token.UserID,
+		&token.ExpiresAt,
+		&token.UsedAt,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	return &token, nil
+}
+
+// MarkRefreshTokenUsed consumes the refresh token, it reports false when the
+// token had already been used so concurrent rotations cannot both succeed
+func (s *AuthStore) MarkRefreshTokenUsed(ctx context.Context, tokenHash string) (bool, error) {
+	query := `UPDATE refresh_tokens SET used_at = NOW() WHERE token_hash = $1 AND used_at IS NULL`
+
+	result, err := s.db.ExecContext(ctx, query, tokenHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark refresh token used: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to mark refresh token used: %w", err)
+	}
+
+	return affected == 1, nil
+}
+
+// GetIdentity returns the external identity linked to a user
+func (s *AuthStore) GetIdentity(ctx context.Context, provider, subject string) (*models.Identity, error) {
+	query := `
+		SELECT provider, subject, user_id, email, created_at
+		FROM user_identities
+		WHERE provider = $1 AND subject = $2`
+
+	var identity models.Identity
+	err := s.db.QueryRowContext(ctx, query, provider, subject).Scan(
+		&identity.Provider,
+		&identity.Subject,
+		&identity.UserID,
+		&identity.Email,
+		&identity.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get identity: %w", err)
+	}
+
+	return &identity, nil
+}
+
+// CreateIdentity links an external identity to a user, returning
+// storage.ErrConflict when it is already linked
+func (s *AuthStore) CreateIdentity(ctx context.Context, identity *models.Identity) error {
+	query := `
+		INSERT INTO user_identities (provider, subject, user_id, email)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at`
+
+	err := s.db.QueryRowContext(ctx, query, identity.Provider, identity.Subject, identity.UserID, identity.Email).
+		Scan(&identity.CreatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return storage.ErrConflict
+		}
+		return fmt.Errorf("failed to create identity: %w", err)
+	}
+
+	return nil
+}
+
+// CreatePasswordReset stores a new password reset token hash
+func (s *AuthStore) CreatePasswordReset(ctx context.Context, reset *models.PasswordReset) error {
+	query := `
+		INSERT INTO password_resets (token_hash, user_id, expires_at)
+		VALUES ($1, $2, $3)
+		RETURNING created_at`
+
+	err := s.db.QueryRowContext(ctx, query, reset.TokenHash, reset.UserID, reset.ExpiresAt).
+		Scan(&reset.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create password reset: %w", err)
+	}
+
+	return nil
+}
+
+// ConsumePasswordReset marks the token as used if it is unused and has not
+// expired, the update guards against concurrent use of the same token
+func (s *AuthStore) ConsumePasswordReset(ctx context.Context, tokenHash string) (*models.PasswordReset, error) {
+	query := `
+		UPDATE password_resets SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING token_hash, user_id, expires_at, used_at, created_at`
+
+	var reset models.PasswordReset
+	err := s.db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&reset.TokenHash,
+		&reset.UserID,
+		&reset.ExpiresAt,
+		&reset.UsedAt,
+		&reset.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to consume password reset: %w", err)
+	}
+
+	return &reset, nil
+}
+
+// CreateEmailVerification stores a new email verification token hash
+func (s *AuthStore) CreateEmailVerification(ctx context.Context, verification *models.EmailVerification) error {
+	query := `
+		INSERT INTO email_verifications (token_hash, user_id, expires_at)
+		VALUES ($1, $2, $3)
+		RETURNING created_at`
+
+	err := s.db.QueryRowContext(ctx, query, verification.TokenHash, verification.UserID, verification.ExpiresAt).
+		Scan(&verification.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create email verification: %w", err)
+	}
+
+	return nil
+}
+
+// ConsumeEmailVerification marks the token as used if it is unused and has
+// not expired
+func (s *AuthStore) ConsumeEmailVerification(ctx context.Context, tokenHash string) (*models.EmailVerification, error) {
+	query := `
+		UPDATE email_verifications SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING token_hash, user_id, expires_at, used_at, created_at`
+
+	var verification models.EmailVerification
+	err := s.db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&verification.TokenHash,
+		&verification.UserID,
+		&verification.ExpiresAt,
+		&verification.UsedAt,
+		&verification.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to consume email verification: %w", err)
+	}
+
+	return &verification, nil
+}
+
+// PurgeExpired removes every token and session that expired before the
+// cutoff. Refresh tokens go first so the ones removed by the session cascade
+// are counted too
+func (s *AuthStore) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
+	queries := []string{
+		`DELETE FROM refresh_tokens
+		 WHERE expires_at < $1 OR session_id IN (SELECT id FROM sessions WHERE expires_at < $1)`,
+		`DELETE FROM sessions WHERE expires_at < $1`,
+		`DELETE FROM password_resets WHERE expires_at < $1`,
+		`DELETE FROM email_verifications WHERE expires_at < $1`,
+	}
+
+	var purged int64
+	for _, query := range queries {
+		result, err := s.db.ExecContext(ctx, query, before)
+		if err != nil {
+			return purged, fmt.Errorf("failed to purge expired tokens: %w", err)
+		}
+
+		n, err := result.RowsAffected()
+		if err != nil {
+			return purged, fmt.Errorf("failed to purge expired tokens: %w", err)
+		}
+		purged += n
+	}
+
+	return purged, nil
+}
+
+func scanSession(row rowScanner) (*models.Session, error) {
+	var session models.Session
+	err := row.Scan(
+		&session.ID,
+		&session.UserID,
+		&session.IPAddress,
+		&session.UserAgent,
+		&session.CreatedAt,
+		&session.LastSeenAt,
+		&session.ExpiresAt,
+		&session.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/breaker"
+	"github.com/MuthuM3/gin-microservice-template/internal/retry"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+)
+
+// IsOutage reports whether err means the database is unreachable or out of
+// resources, the failures that trip the circuit breaker. Errors returned by
+// the server for a query, such as constraint violations, show it is up
+func IsOutage(err error) bool {
+	switch {
+	case errors.Is(err, sql.ErrNoRows), errors.Is(err, context.Canceled), errors.Is(err, breaker.ErrOpen):
+		return false
+	}
+
+	if code, ok := errorCode(err); ok {
+		// Connection exceptions, insufficient resources and operator
+		// intervention such as a shutdown
+		switch code[:2] {
+		case "08", "53", "57":
+			return true
+		}
+		return false
+	}
+	return true
+}
+
+// IsRetryable reports whether an operation failing with err may succeed
+// when run again: serialization failures, deadlocks, lost connections and
+// transient network errors
+func IsRetryable(err error) bool {
+	if code, ok := errorCode(err); ok {
+		return isConflict(code) || code[:2] == "08"
+	}
+	return retry.IsTransient(err)
+}
+
+// isConflict reports whether code aborted a transaction because it
+// conflicted with a concurrent one, so running it again may succeed
+func isConflict(code string) bool {
+	// serialization_failure and deadlock_detected
+	return code == "40001" || code == "40P01"
+}
+
+// errorCode returns the SQLSTATE code of an error returned by the server,
+// with either driver
+func errorCode(err error) (string, bool) {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return string(pqErr.Code), true
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && len(pgErr.Code) == 5 {
+		return pgErr.Code, true
+	}
+	return "", false
+}
+
+var closed = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
+// rejectedContext is done from the start and fails with breaker.ErrOpen
+type rejectedContext struct {
+	context.Context
+}
+
+func (rejectedContext) Done() <-chan struct{} { return closed }
+
+func (rejectedContext) Err() error { return breaker.ErrOpen }
@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+// ScheduleDeletion sets when the account is to be erased, replacing an
+// earlier schedule
+func (s *AuthStore) ScheduleDeletion(ctx context.Context, userID int64, at time.Time) error {
+	query := `UPDATE users SET deletion_scheduled_at = $2, updated_at = NOW() WHERE id = $1`
+
+	result, err := s.db.ExecContext(ctx, query, userID, at)
+	if err != nil {
+		return fmt.Errorf("failed to schedule deletion of user %d: %w", userID, err)
+	}
+	return requireAffected(result, userID, "schedule deletion of")
+}
+
+// CancelDeletion clears a scheduled deletion
+func (s *AuthStore) CancelDeletion(ctx context.Context, userID int64) error {
+	query := `
+		UPDATE users SET deletion_scheduled_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND deletion_scheduled_at IS NOT NULL`
+
+	result, err := s.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to cancel deletion of user %d: %w", userID, err)
+	}
+	return requireAffected(result, userID, "cancel deletion of")
+}
+
+// DueDeletions returns the users whose erasure is due, earliest first
+func (s *AuthStore) DueDeletions(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	query := `
+		SELECT id FROM users
+		WHERE deletion_scheduled_at <= $1
+		ORDER BY deletion_scheduled_at, id
+		LIMIT $2`
+
+	rows, err := s.db.QueryContext(ctx, query, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due deletions: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan due deletion: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate due deletions: %w", err)
+	}
+
+	return ids, nil
+}
+
+// EraseUser deletes the user in one transaction. Deleting the row cascades
+// to everything with a foreign key to it, the tables whose user_id has none
+// so their rows outlive purges are cleared explicitly. Attachments lose
+// their todo with it and are purged like those of purged todos
+func (s *Store) EraseUser(ctx context.Context, userID int64, now time.Time) error {
+	return s.WithTx(ctx, func(_ *sql.Conn, tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `DELETE FROM users WHERE id = $1 AND deletion_scheduled_at <= $2`, userID, now)
+		if err != nil {
+			return fmt.Errorf("failed to erase user %d: %w", userID, err)
+		}
+		if err := requireAffected(result, userID, "erase"); err != nil {
+			return err
+		}
+
+		for _, table := range []string{"outbox", "audit_events", "security_events"} {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE user_id = $1`, userID); err != nil {
+				return fmt.Errorf("failed to erase %s of user %d: %w", table, userID, err)
+			}
+		}
+		return nil
+	})
+}
+
+// requireAffected returns storage.ErrNotFound when a statement on the user
+// changed no rows
+func requireAffected(result sql.Result, userID int64, action string) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to %s user %d: %w", action, userID, err)
+	}
+	if affected == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
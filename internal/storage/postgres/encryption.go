@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// encryptedColumns are the columns holding secrets encrypted with the
+// keyring, with the columns identifying their rows
+var encryptedColumns = []struct {
+	table  string
+	keys   string
+	column string
+}{
+	{table: "webhooks", keys: "id::text", column: "secret"},
+	{table: "notification_channels", keys: "user_id::text || ':' || channel", column: "secret"},
+}
+
+// RotateEncryption re-encrypts the secrets that are stored in plaintext or
+// with another key than the active one, and returns how many it changed.
+// Rows changed while it runs are left alone, running it again picks them up
+func (s *Store) RotateEncryption(ctx context.Context) (int, error) {
+	if s.keyring == nil {
+		return 0, errors.New("column encryption is not enabled")
+	}
+
+	rotated := 0
+	for _, c := range encryptedColumns {
+		stale, err := s.staleSecrets(ctx, c.table, c.keys, c.column)
+		if err != nil {
+			return rotated, err
+		}
+		for key, value := range stale {
+			plaintext, err := s.keyring.Decrypt(value)
+			if err != nil {
+				return rotated, fmt.Errorf("failed to decrypt %s.%s of %s: %w", c.table, c.column, key, err)
+			}
+			encrypted, err := s.keyring.Encrypt(plaintext)
+			if err != nil {
+				return rotated, fmt.Errorf("failed to encrypt %s.%s of %s: %w", c.table, c.column, key, err)
+			}
+
+			query := fmt.Sprintf(`UPDATE %s SET %s = $1 WHERE %s = $2 AND %s = $3`, c.table, c.column, c.keys, c.column)
+			result, err := s.db.ExecContext(ctx, query, encrypted, key, value)
+			if err != nil {
+				return rotated, fmt.Errorf("failed to update %s.%s of %s: %w", c.table, c.column, key, err)
+			}
+			if n, err := result.RowsAffected(); err == nil {
+				rotated += int(n)
+			}
+		}
+	}
+	return rotated, nil
+}
+
+// staleSecrets returns the values of a column that are not encrypted with
+// the active key, by row
+func (s *Store) staleSecrets(ctx context.Context, table, keys, column string) (map[string]string, error) {
+	query := fmt.Sprintf(`SELECT %s, %s FROM %s WHERE %s <> ''`, keys, column, table, column)
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s.%s: %w", table, column, err)
+	}
+	defer rows.Close()
+
+	stale := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan %s.%s: %w", table, column, err)
+		}
+		if s.keyring.Stale(value) {
+			stale[key] = value
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s.%s: %w", table, column, err)
+	}
+	return stale, nil
+}
@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/breaker"
+	"github.com/MuthuM3/gin-microservice-template/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentedDB wraps a *sql.DB or *sql.Tx so that every query issued by the
+// stores is recorded as a client span under the caller's context, observed
+// by the store's query observer and passes the store's circuit breaker
+type instrumentedDB struct {
+	Querier
+	tracer   trace.Tracer
+	dbName   string
+	breaker  *breaker.Breaker
+	observer *queryObserver
+}
+
+func newInstrumentedDB(q Querier, store *Store) *instrumentedDB {
+	return &instrumentedDB{
+		Querier:  q,
+		tracer:   tracing.Tracer(),
+		dbName:   store.config.Database,
+		breaker:  store.breaker,
+		observer: store.queries,
+	}
+}
+
+func (db *instrumentedDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if err := db.breaker.Allow(); err != nil {
+		return nil, err
+	}
+
+	ctx, span := db.startSpan(ctx, query)
+	defer span.End()
+
+	start := time.Now()
+	result, err := db.Querier.ExecContext(ctx, query, args...)
+	db.observer.observe(ctx, query, args, time.Since(start), err)
+	db.breaker.Done(err)
+	recordError(span, err)
+	return result, err
+}
+
+func (db *instrumentedDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if err := db.breaker.Allow(); err != nil {
+		return nil, err
+	}
+
+	ctx, span := db.startSpan(ctx, query)
+	defer span.End()
+
+	start := time.Now()
+	rows, err := db.Querier.QueryContext(ctx, query, args...)
+	db.observer.observe(ctx, query, args, time.Since(start), err)
+	db.breaker.Done(err)
+	recordError(span, err)
+	return rows, err
+}
+
+func (db *instrumentedDB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	if err := db.breaker.Allow(); err != nil {
+		// A *sql.Row cannot be built with an error, a context that is already
+		// done makes database/sql fail it before a connection is taken
+		return db.Querier.QueryRowContext(rejectedContext{ctx}, query, args...)
+	}
+
+	ctx, span := db.startSpan(ctx, query)
+	defer span.End()
+
+	start := time.Now()
+	row := db.Querier.QueryRowContext(ctx, query, args...)
+	db.observer.observe(ctx, query, args, time.Since(start), row.Err())
+	db.breaker.Done(row.Err())
+	recordError(span, row.Err())
+	return row
+}
+
+func (db *instrumentedDB) startSpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	operation := queryOperation(query)
+
+	return db.tracer.Start(ctx, "db."+strings.ToLower(operation),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.namespace", db.dbName),
+			attribute.String("db.operation.name", operation),
+			attribute.String("db.query.text", query),
+		),
+	)
+}
+
+func recordError(span trace.Span, err error) {
+	if err != nil && err != sql.ErrNoRows {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// queryOperation returns the SQL verb of the query, e.g. SELECT or INSERT
+func queryOperation(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "QUERY"
+	}
+	return strings.ToUpper(fields[0])
+}
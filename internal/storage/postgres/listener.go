@@ -0,0 +1,200 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/events"
+	"github.com/MuthuM3/gin-microservice-template/internal/lock"
+	"github.com/MuthuM3/gin-microservice-template/internal/metrics"
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/lib/pq"
+)
+
+// todoChangesChannel is the channel the trigger of the todos table notifies
+const todoChangesChannel = "todo_changes"
+
+const (
+	// changeListenerLockTTL bounds how long a crashed listener keeps the
+	// others waiting
+	changeListenerLockTTL = 15 * time.Second
+
+	// changeListenerRetryInterval is how often the replicas not listening
+	// try to take over
+	changeListenerRetryInterval = 5 * time.Second
+
+	// changeListenerPingInterval is how often an idle listener checks its
+	// connection, a dead one is only noticed on use
+	changeListenerPingInterval = 90 * time.Second
+)
+
+// todoChange is the payload of a notification on todoChangesChannel. Op is
+// created, updated, deleted or restored, HardDelete is set when the row is
+// gone. Origin is the application_name of the connection that wrote it
+type todoChange struct {
+	Op         string `json:"op"`
+	ID         int64  `json:"id"`
+	UserID     int64  `json:"user_id"`
+	HardDelete bool   `json:"hard_delete"`
+	Origin     string `json:"origin"`
+}
+
+// ChangeListener publishes the todo changes other services write to the
+// database as todo.* events, with LISTEN on the channel the todos trigger
+// notifies. Changes made under the application name of this service are
+// skipped, the outbox already publishes them. Only the replica holding the
+// change listener lock listens so each change is published once.
+// Notifications sent while no replica listens are lost, the feed is best
+// effort
+type ChangeListener struct {
+	store     *Store
+	todos     *TodoStore
+	publisher events.Publisher
+	locker    lock.Locker
+}
+
+// NewChangeListener creates a listener publishing the changes to publisher
+func (s *Store) NewChangeListener(publisher events.Publisher, locker lock.Locker) *ChangeListener {
+	return &ChangeListener{
+		store: s,
+		// The changed rows are read from the primary, replicas may not
+		// have them yet
+		todos:     newTodoStore(newInstrumentedDB(s.db, s), s),
+		publisher: publisher,
+		locker:    locker,
+	}
+}
+
+// Run listens for changes until ctx is done
+func (l *ChangeListener) Run(ctx context.Context) {
+	log := l.store.logger
+	for {
+		lease, err := l.locker.Lock(ctx, "database_changes", changeListenerLockTTL)
+		switch {
+		case err == nil:
+			log.Info("database change listener lock acquired", "token", lease.Token)
+			l.listen(lease.Context())
+			if err := lease.Unlock(); err != nil {
+				log.Warn("failed to release database change listener lock", "error", err)
+			}
+		case !errors.Is(err, lock.ErrNotAcquired) && ctx.Err() == nil:
+			log.Error("failed to acquire database change listener lock", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(changeListenerRetryInterval):
+		}
+	}
+}
+
+// listen publishes the notifications received until ctx is done. The
+// connection is re-established by pq after failures
+func (l *ChangeListener) listen(ctx context.Context) {
+	log := l.store.logger
+
+	dsn, err := withRuntimeParams(l.store.dsn, l.store.config)
+	if err != nil {
+		log.Error("failed to start database change listener", "error", err)
+		return
+	}
+	listener := pq.NewListener(dsn, time.Second, maxRetryInterval, func(event pq.ListenerEventType, err error) {
+		switch event {
+		case pq.ListenerEventDisconnected:
+			log.Warn("database change listener disconnected", "error", err)
+		case pq.ListenerEventConnectionAttemptFailed:
+			log.Debug("database change listener failed to reconnect", "error", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(todoChangesChannel); err != nil {
+		log.Error("failed to listen for database changes", "channel", todoChangesChannel, "error", err)
+		return
+	}
+	log.Info("listening for database changes", "channel", todoChangesChannel)
+
+	ping := time.NewTicker(changeListenerPingInterval)
+	defer ping.Stop()
+
+	notifications := listener.NotificationChannel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-notifications:
+			// pq sends nil after reconnecting
+			if n == nil {
+				log.Warn("database change listener reconnected, changes made while disconnected were not published")
+				continue
+			}
+			if err := l.handle(ctx, n.Extra); err != nil {
+				log.Error("failed to publish database change", "payload", n.Extra, "error", err)
+			}
+		case <-ping.C:
+			go listener.Ping()
+		}
+	}
+}
+
+// handle publishes the change announced by payload
+func (l *ChangeListener) handle(ctx context.Context, payload string) error {
+	var change todoChange
+	if err := json.Unmarshal([]byte(payload), &change); err != nil {
+		return fmt.Errorf("failed to decode change: %w", err)
+	}
+	if change.Origin == l.store.config.ApplicationName {
+		return nil
+	}
+
+	// The event types match the ones of the todo service
+	eventType := "todo." + change.Op
+	var data any
+	if change.HardDelete {
+		data = map[string]int64{"id": change.ID, "user_id": change.UserID}
+	} else {
+		todo, err := l.todo(ctx, change.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			// Removed since, its deletion has its own notification
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		data = todo
+	}
+
+	event, err := events.New(eventType, change.UserID, change.ID, data)
+	if err != nil {
+		return err
+	}
+	if err := l.publisher.Publish(ctx, events.TopicTodos, event); err != nil {
+		return fmt.Errorf("failed to publish %s event: %w", eventType, err)
+	}
+	metrics.Default.Counter("db_change_feed_events_total",
+		"Total number of todo events published from database notifications", metrics.Labels{"type": eventType}).Inc()
+	return nil
+}
+
+// todo returns the todo with the given id whether or not it is in the trash
+func (l *ChangeListener) todo(ctx context.Context, id int64) (*models.Todo, error) {
+	query := `SELECT ` + todoColumns + ` FROM todos WHERE id = $1`
+
+	todo, err := scanTodo(l.todos.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get todo %d: %w", id, err)
+	}
+
+	if err := l.todos.loadTags(ctx, []*models.Todo{todo}); err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
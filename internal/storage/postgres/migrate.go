@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Migrate applies the .sql files of dir that have not been applied yet in
+// name order, each in its own transaction, and returns the names of those
+// applied. Applied files are recorded in schema_migrations; the migrations
+// only create missing objects, so databases initialised from the same files
+// by the postgres image are brought under tracking without changes
+func (s *Store) Migrate(ctx context.Context, dir fs.FS) ([]string, error) {
+	_, err := s.db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			name       VARCHAR(255) PRIMARY KEY,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	pending, err := s.PendingMigrations(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []string
+	for _, name := range pending {
+		script, err := fs.ReadFile(dir, name)
+		if err != nil {
+			return applied, fmt.Errorf("failed to read migration %s: %w", name, err)
+		}
+
+		err = s.WithTx(ctx, func(_ *sql.Conn, tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, string(script)); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (name) VALUES ($1)`, name)
+			return err
+		})
+		if err != nil {
+			return applied, fmt.Errorf("failed to apply migration %s: %w", name, err)
+		}
+		applied = append(applied, name)
+	}
+
+	if len(applied) > 0 {
+		s.checkSchema(ctx)
+	}
+	return applied, nil
+}
+
+// PendingMigrations returns the names of the .sql files of dir not recorded
+// in schema_migrations, all of them when the table does not exist yet
+func (s *Store) PendingMigrations(ctx context.Context, dir fs.FS) ([]string, error) {
+	entries, err := fs.ReadDir(dir, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.EqualFold(path.Ext(entry.Name()), ".sql") {
+			names = append(names, entry.Name())
+		}
+	}
+	slices.Sort(names)
+
+	var exists bool
+	err = s.db.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check schema_migrations: %w", err)
+	}
+	if !exists {
+		return names, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT name FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan migration: %w", err)
+		}
+		applied[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list applied migrations: %w", err)
+	}
+
+	return slices.DeleteFunc(names, func(name string) bool { return applied[name] }), nil
+}
@@ -0,0 +1,117 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/encryption"
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+const notificationChannelColumns = "user_id, channel, url, secret, enabled, created_at, updated_at"
+
+// ListNotificationChannels returns the user's channels ordered by name
+func (s *AuthStore) ListNotificationChannels(ctx context.Context, userID int64) ([]*models.NotificationChannel, error) {
+	query := `
+		SELECT ` + notificationChannelColumns + `
+		FROM notification_channels
+		WHERE user_id = $1
+		ORDER BY channel`
+
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notification channels of user %d: %w", userID, err)
+	}
+	defer rows.Close()
+
+	channels := make([]*models.NotificationChannel, 0)
+	for rows.Next() {
+		channel, err := scanNotificationChannel(rows, s.store.keyring)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification channel: %w", err)
+		}
+		channels = append(channels, channel)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate notification channels: %w", err)
+	}
+
+	return channels, nil
+}
+
+// GetNotificationChannel returns one channel of the user
+func (s *AuthStore) GetNotificationChannel(ctx context.Context, userID int64, channel string) (*models.NotificationChannel, error) {
+	query := `SELECT ` + notificationChannelColumns + ` FROM notification_channels WHERE user_id = $1 AND channel = $2`
+
+	found, err := scanNotificationChannel(s.db.QueryRowContext(ctx, query, userID, channel), s.store.keyring)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get %s channel of user %d: %w", channel, userID, err)
+	}
+
+	return found, nil
+}
+
+// SetNotificationChannel creates or replaces a channel of the user,
+// returning storage.ErrNotFound when the user does not exist
+func (s *AuthStore) SetNotificationChannel(ctx context.Context, channel *models.NotificationChannel) error {
+	query := `
+		INSERT INTO notification_channels (user_id, channel, url, secret, enabled)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, channel) DO UPDATE SET
+			url = EXCLUDED.url,
+			secret = EXCLUDED.secret,
+			enabled = EXCLUDED.enabled,
+			updated_at = NOW()
+		RETURNING created_at, updated_at`
+
+	secret, err := s.store.keyring.Encrypt(channel.Secret)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt secret of %s channel: %w", channel.Channel, err)
+	}
+	err = s.db.QueryRowContext(ctx, query, channel.UserID, channel.Channel, channel.URL, secret, channel.Enabled).
+		Scan(&channel.CreatedAt, &channel.UpdatedAt)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return storage.ErrNotFound
+		}
+		return fmt.Errorf("failed to set %s channel of user %d: %w", channel.Channel, channel.UserID, err)
+	}
+
+	return nil
+}
+
+// DeleteNotificationChannel removes a channel of the user
+func (s *AuthStore) DeleteNotificationChannel(ctx context.Context, userID int64, channel string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM notification_channels WHERE user_id = $1 AND channel = $2`, userID, channel)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s channel of user %d: %w", channel, userID, err)
+	}
+	return requireAffected(result, userID, "delete "+channel+" channel of")
+}
+
+func scanNotificationChannel(row rowScanner, keyring *encryption.Keyring) (*models.NotificationChannel, error) {
+	var channel models.NotificationChannel
+	err := row.Scan(
+		&channel.UserID,
+		&channel.Channel,
+		&channel.URL,
+		&channel.Secret,
+		&channel.Enabled,
+		&channel.CreatedAt,
+		&channel.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if channel.Secret, err = keyring.Decrypt(channel.Secret); err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret of %s channel: %w", channel.Channel, err)
+	}
+	return &channel, nil
+}
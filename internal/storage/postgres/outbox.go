@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+// AddOutboxMessage inserts a pending message, it is meant to run in the
+// transaction of the change the message describes
+func (s *TodoStore) AddOutboxMessage(ctx context.Context, msg *models.OutboxMessage) error {
+	query := `
+		INSERT INTO outbox (idempotency_key, topic, event_type, user_id, entity_id, payload)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`
+
+	err := s.db.QueryRowContext(ctx, query,
+		msg.IdempotencyKey, msg.Topic, msg.EventType, msg.UserID, msg.EntityID, []byte(msg.Payload),
+	).Scan(&msg.ID, &msg.CreatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return storage.ErrConflict
+		}
+		return fmt.Errorf("failed to add outbox message: %w", err)
+	}
+
+	return nil
+}
+
+// PendingOutboxMessages returns the oldest unpublished messages, locking
+// them so relays on other replicas skip to the next ones
+func (s *TodoStore) PendingOutboxMessages(ctx context.Context, limit int) ([]*models.OutboxMessage, error) {
+	query := `
+		SELECT id, idempotency_key, topic, event_type, user_id, entity_id, payload, attempts, last_error, created_at
+		FROM outbox
+		WHERE published_at IS NULL
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED`
+
+	rows, err := s.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending outbox messages: %w", err)
+	}
+	defer rows.Close()
+
+	messages := make([]*models.OutboxMessage, 0, limit)
+	for rows.Next() {
+		msg := &models.OutboxMessage{}
+		err := rows.Scan(&msg.ID, &msg.IdempotencyKey, &msg.Topic, &msg.EventType, &msg.UserID,
+			&msg.EntityID, &msg.Payload, &msg.Attempts, &msg.LastError, &msg.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan outbox message: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate outbox messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+// MarkOutboxPublished records the successful delivery of a message
+func (s *TodoStore) MarkOutboxPublished(ctx context.Context, id int64) error {
+	query := `UPDATE outbox SET published_at = NOW(), attempts = attempts + 1 WHERE id = $1`
+	if _, err := s.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to mark outbox message %d published: %w", id, err)
+	}
+	return nil
+}
+
+// MarkOutboxFailed records a failed delivery attempt
+func (s *TodoStore) MarkOutboxFailed(ctx context.Context, id int64, reason string) error {
+	query := `UPDATE outbox SET attempts = attempts + 1, last_error = $2 WHERE id = $1`
+	if _, err := s.db.ExecContext(ctx, query, id, reason); err != nil {
+		return fmt.Errorf("failed to mark outbox message %d failed: %w", id, err)
+	}
+	return nil
+}
+
+// PurgeOutbox removes messages published before the cutoff
+func (s *TodoStore) PurgeOutbox(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM outbox WHERE published_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge outbox: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge outbox: %w", err)
+	}
+
+	return purged, nil
+}
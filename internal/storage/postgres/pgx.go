@@ -0,0 +1,150 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/metrics"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// DriverPgx selects pgx and its connection pool instead of lib/pq
+const DriverPgx = "pgx"
+
+// ErrPgxRequired is returned by the operations only the pgx driver offers
+var ErrPgxRequired = errors.New("operation requires the pgx database driver")
+
+// openDB opens a connection pool to dsn with the configured driver. With
+// pgx the pool is pgxpool, the returned *sql.DB borrows its connections so
+// the stores run unchanged on either driver. No connection is made yet
+func openDB(cfg *config.DatabaseConfig, dsn string) (*sql.DB, *pgxpool.Pool, error) {
+	dsn, err := withRuntimeParams(dsn, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if cfg.Driver != DriverPgx {
+		db, err := sql.Open("postgres", dsn)
+		if err != nil {
+			return nil, nil, err
+		}
+		db.SetMaxOpenConns(cfg.MaxOpenConns)
+		db.SetMaxIdleConns(cfg.MaxIdleConns)
+		db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+		return db, nil, nil
+	}
+
+	poolConfig, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+	poolConfig.MaxConns = int32(cfg.MaxOpenConns)
+	poolConfig.MaxConnLifetime = cfg.ConnMaxLifetime
+
+	// pgxpool connects lazily, the first ping establishes the connection
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	db := stdlib.OpenDBFromPool(pool)
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	return db, pool, nil
+}
+
+// withRuntimeParams adds the statement_timeout and application_name
+// run-time parameters to a key/value or URL connection string, both drivers
+// send unknown parameters to the server. The server then cancels statements
+// running longer than the timeout on every connection of the pool
+func withRuntimeParams(dsn string, cfg *config.DatabaseConfig) (string, error) {
+	params := make(map[string]string)
+	if cfg.StatementTimeout > 0 {
+		params["statement_timeout"] = strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10)
+	}
+	if cfg.ApplicationName != "" {
+		params["application_name"] = cfg.ApplicationName
+	}
+	if len(params) == 0 {
+		return dsn, nil
+	}
+
+	if !strings.Contains(dsn, "://") {
+		for _, name := range slices.Sorted(maps.Keys(params)) {
+			dsn += " " + name + "='" + params[name] + "'"
+		}
+		return dsn, nil
+	}
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", fmt.Errorf("invalid connection URL: %w", err)
+	}
+	query := u.Query()
+	for name, value := range params {
+		query.Set(name, value)
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// registerPoolMetrics exposes the statistics of a pgx pool, name labels the
+// series, "primary" or the name of a replica
+func registerPoolMetrics(pool *pgxpool.Pool, name string) {
+	labels := metrics.Labels{"database": name}
+	gauge := func(metric, help string, value func(*pgxpool.Stat) float64) {
+		metrics.Default.GaugeFunc(metric, help, labels, func() float64 {
+			return value(pool.Stat())
+		})
+	}
+
+	gauge("db_pool_connections", "Number of connections in the pool",
+		func(s *pgxpool.Stat) float64 { return float64(s.TotalConns()) })
+	gauge("db_pool_acquired_connections", "Number of connections in use",
+		func(s *pgxpool.Stat) float64 { return float64(s.AcquiredConns()) })
+	gauge("db_pool_idle_connections", "Number of idle connections",
+		func(s *pgxpool.Stat) float64 { return float64(s.IdleConns()) })
+	gauge("db_pool_max_connections", "Maximum size of the pool",
+		func(s *pgxpool.Stat) float64 { return float64(s.MaxConns()) })
+	gauge("db_pool_acquires", "Total number of connections acquired from the pool",
+		func(s *pgxpool.Stat) float64 { return float64(s.AcquireCount()) })
+	gauge("db_pool_empty_acquires", "Total number of acquires that waited for a connection",
+		func(s *pgxpool.Stat) float64 { return float64(s.EmptyAcquireCount()) })
+	gauge("db_pool_canceled_acquires", "Total number of acquires canceled by their context",
+		func(s *pgxpool.Stat) float64 { return float64(s.CanceledAcquireCount()) })
+	gauge("db_pool_acquire_seconds", "Total time spent acquiring connections",
+		func(s *pgxpool.Stat) float64 { return s.AcquireDuration().Seconds() })
+}
+
+// CopyFrom bulk inserts rows into the columns of table with the COPY
+// protocol, much faster than INSERT for large imports. It copies on conn,
+// within the transaction WithTx began on it, returns the number of rows
+// copied and requires the pgx driver
+func (s *Store) CopyFrom(ctx context.Context, conn *sql.Conn, table string, columns []string, rows [][]any) (int64, error) {
+	if s.pool == nil {
+		return 0, ErrPgxRequired
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return 0, err
+	}
+
+	var copied int64
+	err := conn.Raw(func(driverConn any) error {
+		var err error
+		copied, err = driverConn.(*stdlib.Conn).Conn().CopyFrom(ctx, pgx.Identifier{table}, columns, pgx.CopyFromRows(rows))
+		return err
+	})
+	s.breaker.Done(err)
+	if err != nil {
+		return 0, fmt.Errorf("failed to copy rows into %s: %w", table, err)
+	}
+	return copied, nil
+}
@@ -4,19 +4,40 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"log"
 	"sync"
 	"time"
 
+	"github.com/MuthuM3/gin-microservice-template/internal/breaker"
 	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/encryption"
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+	"github.com/MuthuM3/gin-microservice-template/internal/metrics"
+	"github.com/MuthuM3/gin-microservice-template/internal/retry"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+	"github.com/jackc/pgx/v5/pgxpool"
+	_ "github.com/lib/pq"
 )
 
+var _ storage.Store = (*Store)(nil)
+
 type Store struct {
-	db        *sql.DB
-	authStore *AuthStore
-	todoStore *TodoStore
-	config    *config.DatabaseConfig
-	logger    *log.Logger
+	db            *sql.DB
+	dsn           string
+	pool          *pgxpool.Pool // nil unless the driver is pgx
+	authStore     *AuthStore
+	todoStore     *TodoStore
+	auditStore    *AuditStore
+	securityStore *SecurityStore
+	webhookStore  *WebhookStore
+	quotaStore    *QuotaStore
+	statements    *stmtCache
+	replicas      *replicaSet
+	config        *config.DatabaseConfig
+	logger        logger.Logger
+	breaker       *breaker.Breaker
+	retrier       *retry.Retrier
+	queries       *queryObserver
+	keyring       *encryption.Keyring // nil unless column encryption is enabled
 
 	// Connection Monitoring
 	mu              sync.RWMutex
@@ -24,11 +45,14 @@ type Store struct {
 	isHealthy       bool
 	stats           ConnectionStats
 
-	// Lifecycle management
+	// Lifecycle management, wg tracks the reconnect and monitoring
+	// goroutines so Close returns only after they have stopped
 	ctx    context.Context
 	cancel context.CancelFunc
+	wg     sync.WaitGroup
 }
 
+// ConnectionStats describes the connection pool of the primary, see GetStats
 type ConnectionStats struct {
 	OpenConnections   int
 	InUseConnections  int
@@ -38,59 +62,211 @@ type ConnectionStats struct {
 	MaxIdleClosed     int64
 	MaxIdleTimeClosed int64
 	MaxLifeTimeClosed int64
+
+	// Statistics of the pgx pool, zero with lib/pq
+	AcquireCount         int64
+	AcquireDuration      time.Duration
+	EmptyAcquireCount    int64
+	CanceledAcquireCount int64
+	ConstructingConns    int
+	MaxConns             int
 }
 
-func newStore(connectionsString string, cfg *config.DatabaseConfig, logger *log.Logger) (*Store, error) {
-	db, err := sql.Open("postgres", connectionsString)
+// maxRetryInterval caps the backoff between connection attempts
+const maxRetryInterval = 30 * time.Second
+
+// New opens a connection pool to the configured Postgres database. Queries
+// fail fast with breaker.ErrOpen while b is open, b may be nil
+func New(ctx context.Context, cfg *config.DatabaseConfig, b *breaker.Breaker, log logger.Logger) (*Store, error) {
+	return newStore(ctx, cfg.GetConnectionString(), cfg, b, log)
+}
+
+func newStore(ctx context.Context, connectionsString string, cfg *config.DatabaseConfig, b *breaker.Breaker, log logger.Logger) (*Store, error) {
+	var keyring *encryption.Keyring
+	if cfg.Encryption.Enabled {
+		var err error
+		if keyring, err = encryption.NewKeyring(cfg.Encryption.KeySecrets, cfg.Encryption.ActiveKey); err != nil {
+			return nil, fmt.Errorf("failed to load column encryption keys: %w", err)
+		}
+	}
+
+	db, pool, err := openDB(cfg, connectionsString)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database connection: %w", err)
 	}
+	closeDB := func() {
+		db.Close()
+		if pool != nil {
+			pool.Close()
+		}
+	}
 
-	// Apply configuration settings
-	db.SetMaxOpenConns(cfg.MaxOpenConns)
-	db.SetMaxIdleConns(cfg.MaxIdleConns)
-	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
-
-	// Test the connection with timeout
-	pintCtx, pingCancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer pingCancel()
+	healthy := true
+	if err := connectWithRetry(ctx, db, cfg, log); err != nil {
+		if !cfg.StartDegraded || ctx.Err() != nil {
+			closeDB()
+			return nil, err
+		}
+		log.Warn("database unavailable, starting degraded", "error", err)
+		healthy = false
+	}
 
-	if err := db.PingContext(pintCtx); err != nil {
-		db.Close()
-		return nil, fmt.Errorf("failed to pink database: %w", err)
+	var replicas *replicaSet
+	if len(cfg.ReplicaDSNs) > 0 {
+		replicas, err = openReplicas(ctx, cfg, log)
+		if err != nil {
+			closeDB()
+			return nil, err
+		}
 	}
 
 	// Create context for lifecycle management
-	ctx, cancel := context.WithCancel(context.Background())
+	storeCtx, cancel := context.WithCancel(context.Background())
 
 	store := &Store{
 		db:              db,
+		dsn:             connectionsString,
+		pool:            pool,
+		replicas:        replicas,
 		config:          cfg,
-		logger:          logger,
-		isHealthy:       true,
+		logger:          log,
+		breaker:         b,
+		retrier:         retry.New("postgres", cfg.Retry, IsRetryable),
+		queries:         newQueryObserver(cfg, log),
+		keyring:         keyring,
+		isHealthy:       healthy,
 		lastHealthCheck: time.Now(),
-		ctx:             ctx,
+		ctx:             storeCtx,
 		cancel:          cancel,
 	}
 
-	store.authStore = NewAuthStore(db, store)
-	store.todoStore = newTodoStore(db, store)
+	registerConnectionMetrics(db, "primary")
+	if pool != nil {
+		registerPoolMetrics(pool, "primary")
+	}
+
+	// The todo and auth stores go through the statement caches, reads of
+	// every store through the replicas
+	var plain, cached Querier = db, db
+	if cfg.StatementCacheSize > 0 {
+		store.statements = newStmtCache(db, cfg.StatementCacheSize, "primary")
+		cached = store.statements
+		if healthy {
+			store.checkSchema(ctx)
+		}
+	}
+	if replicas != nil {
+		plain = &routedDB{primary: plain, replicas: replicas}
+		cached = &routedDB{primary: cached, replicas: replicas, cached: true}
+	}
+
+	instrumented := newInstrumentedDB(plain, store)
+	store.authStore = NewAuthStore(newInstrumentedDB(cached, store), store)
+	store.todoStore = newTodoStore(newInstrumentedDB(cached, store), store)
+	store.auditStore = newAuditStore(instrumented)
+	store.securityStore = newSecurityStore(instrumented)
+	store.webhookStore = newWebhookStore(instrumented, keyring)
+	store.quotaStore = newQuotaStore(instrumented)
 
-	// Start connection monitoring
-	go store.startConnectionMonitoring()
-	logger.Printf("Database connection established with %d max open connections", cfg.MaxOpenConns)
+	if !healthy {
+		store.background(store.reconnect)
+	}
+
+	store.background(store.startConnectionMonitoring)
+	if healthy {
+		log.Info("database connection established", "max_open_conns", cfg.MaxOpenConns)
+	}
 
 	return store, nil
 }
 
+// connectWithRetry pings the database until it responds, backing off
+// exponentially between the configured number of attempts
+func connectWithRetry(ctx context.Context, db *sql.DB, cfg *config.DatabaseConfig, log logger.Logger) error {
+	interval := cfg.ConnectRetryInterval
+	var err error
+
+	for attempt := 1; attempt <= cfg.ConnectAttempts; attempt++ {
+		if err = ping(ctx, db); err == nil {
+			return nil
+		}
+		if attempt == cfg.ConnectAttempts {
+			break
+		}
+
+		log.Warn("database not reachable, retrying",
+			"attempt", attempt,
+			"max_attempts", cfg.ConnectAttempts,
+			"retry_in", interval,
+			"error", err,
+		)
+
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return fmt.Errorf("database connection aborted: %w", ctx.Err())
+		}
+		interval = min(interval*2, maxRetryInterval)
+	}
+
+	return fmt.Errorf("failed to ping database after %d attempts: %w", cfg.ConnectAttempts, err)
+}
+
+// reconnect keeps pinging a database that was unavailable at startup until it
+// responds, then marks the store healthy
+func (s *Store) reconnect() {
+	interval := s.config.ConnectRetryInterval
+
+	for {
+		select {
+		case <-time.After(interval):
+		case <-s.ctx.Done():
+			return
+		}
+
+		if err := ping(s.ctx, s.db); err != nil {
+			s.logger.Debug("database still unavailable", "retry_in", interval, "error", err)
+			interval = min(interval*2, maxRetryInterval)
+			continue
+		}
+
+		s.mu.Lock()
+		s.isHealthy = true
+		s.lastHealthCheck = time.Now()
+		s.mu.Unlock()
+
+		s.logger.Info("database connection established", "max_open_conns", s.config.MaxOpenConns)
+		return
+	}
+}
+
+// background runs fn on a goroutine that Close waits for
+func (s *Store) background(fn func()) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		fn()
+	}()
+}
+
+func ping(ctx context.Context, db *sql.DB) error {
+	pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	return db.PingContext(pingCtx)
+}
+
+// startConnectionMonitoring logs the connection statistics and checks the
+// health of the database every monitor interval until the store is closed
 func (s *Store) startConnectionMonitoring() {
-	ticker := time.NewTicker(30 * time.Second)
+	ticker := time.NewTicker(s.config.MonitorInterval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ticker.C:
 			s.monitorConnections()
+		case <-s.ctx.Done():
+			return
 		}
 	}
 }
@@ -98,47 +274,67 @@ func (s *Store) startConnectionMonitoring() {
 func (s *Store) monitorConnections() {
 	stats := s.GetStats()
 
-	s.logger.Printf("DB stats: Open=%d, InUse=%d, Idle=%d, WaitCount=%d, WaitDuration=%v",
-		stats.OpenConnections,
-		stats.InUseConnections,
-		stats.IdleConnection,
-		stats.WaitCount,
-		stats.WaitDuration,
+	s.logger.Debug("database connection stats",
+		"open", stats.OpenConnections,
+		"in_use", stats.InUseConnections,
+		"idle", stats.IdleConnection,
+		"wait_count", stats.WaitCount,
+		"wait_duration", stats.WaitDuration,
 	)
 
 	// Warn if connection usage is high
 	maxConns := s.config.MaxOpenConns
 
 	if stats.OpenConnections > int(float64(maxConns)*0.8) {
-		s.logger.Printf(
-			"WARNING: High connection usage: %d/%d (%.1f%%)",
-			stats.OpenConnections,
-			maxConns,
-			float64(stats.OpenConnections)/float64(maxConns)*100,
+		s.logger.Warn("high database connection usage",
+			"open", stats.OpenConnections,
+			"max", maxConns,
+			"usage_percent", float64(stats.OpenConnections)/float64(maxConns)*100,
 		)
 	}
 
 	// Warn if wait times are high
 	if stats.WaitDuration > time.Second {
-		s.logger.Printf("WARNING: High connection wait time: %v", stats.WaitDuration)
+		s.logger.Warn("high database connection wait time", "wait_duration", stats.WaitDuration)
 	}
 
 	// Perform periodic health check
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
 	defer cancel()
 
+	if s.replicas != nil {
+		s.replicas.check(ctx, s.logger)
+	}
 	if err := s.HealthCheck(ctx); err != nil {
-		s.logger.Printf("Periodic health check failed: %v", err)
+		s.logger.Error("periodic database health check failed", "error", err)
+		return
 	}
+	s.checkSchema(ctx)
 }
 
-func (s *Store) GetStats() ConnectionStats {
-	s.mu.RLock()
-	defer s.mu.Unlock()
+// checkSchema empties the prepared statement cache after a migration
+func (s *Store) checkSchema(ctx context.Context) {
+	if s.statements == nil {
+		return
+	}
+	changed, err := s.statements.CheckSchema(ctx)
+	if err != nil {
+		s.logger.Warn("failed to check the database schema", "error", err)
+		return
+	}
+	if changed {
+		if s.replicas != nil {
+			s.replicas.resetStatements()
+		}
+		s.logger.Info("database schema changed, prepared statements reset")
+	}
+}
 
+// GetStats returns the statistics of the connection pool of the primary
+func (s *Store) GetStats() ConnectionStats {
 	dbStats := s.db.Stats()
 
-	return ConnectionStats{
+	stats := ConnectionStats{
 		OpenConnections:   dbStats.OpenConnections,
 		InUseConnections:  dbStats.InUse,
 		IdleConnection:    dbStats.Idle,
@@ -148,45 +344,134 @@ func (s *Store) GetStats() ConnectionStats {
 		MaxIdleTimeClosed: dbStats.MaxIdleTimeClosed,
 		MaxLifeTimeClosed: dbStats.MaxLifetimeClosed,
 	}
+	if s.pool != nil {
+		poolStats := s.pool.Stat()
+		stats.AcquireCount = poolStats.AcquireCount()
+		stats.AcquireDuration = poolStats.AcquireDuration()
+		stats.EmptyAcquireCount = poolStats.EmptyAcquireCount()
+		stats.CanceledAcquireCount = poolStats.CanceledAcquireCount()
+		stats.ConstructingConns = int(poolStats.ConstructingConns())
+		stats.MaxConns = int(poolStats.MaxConns())
+	}
+	return stats
 }
 
+// HealthCheck pings the primary and records whether it responded. The ping
+// runs without holding the lock so a slow database does not block IsHealthy
 func (s *Store) HealthCheck(ctx context.Context) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	start := time.Now()
 	err := s.db.PingContext(ctx)
 	duration := time.Since(start)
 
+	s.mu.Lock()
 	s.lastHealthCheck = time.Now()
 	s.isHealthy = err == nil
+	s.mu.Unlock()
 
 	if err != nil {
-		s.logger.Printf("Database health check failed (took %v): %v", duration, err)
+		s.logger.Error("database health check failed", "duration", duration, "error", err)
 		return fmt.Errorf("database health check failed: %w", err)
 	}
 
-	s.logger.Printf("Database health check passed (took %v)", duration)
+	s.logger.Debug("database health check passed", "duration", duration)
 	return nil
 }
 
 // IsHealthy returns the current health status
 func (s *Store) IsHealthy() bool {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	return s.isHealthy
 }
 
+// LastHealthCheck returns when the health of the database was last checked
+func (s *Store) LastHealthCheck() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastHealthCheck
+}
+
+// registerConnectionMetrics exposes the statistics of the database/sql pool
+// of db, name labels the series, "primary" or the name of a replica
+func registerConnectionMetrics(db *sql.DB, name string) {
+	labels := metrics.Labels{"database": name}
+	gauge := func(metric, help string, value func(sql.DBStats) float64) {
+		metrics.Default.GaugeFunc(metric, help, labels, func() float64 {
+			return value(db.Stats())
+		})
+	}
+
+	gauge("db_connections_open", "Number of open connections",
+		func(s sql.DBStats) float64 { return float64(s.OpenConnections) })
+	gauge("db_connections_in_use", "Number of connections in use",
+		func(s sql.DBStats) float64 { return float64(s.InUse) })
+	gauge("db_connections_idle", "Number of idle connections",
+		func(s sql.DBStats) float64 { return float64(s.Idle) })
+	gauge("db_connections_max_open", "Maximum number of open connections",
+		func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) })
+	gauge("db_connection_waits", "Total number of queries that waited for a connection",
+		func(s sql.DBStats) float64 { return float64(s.WaitCount) })
+	gauge("db_connection_wait_seconds", "Total time spent waiting for a connection",
+		func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() })
+	gauge("db_connections_max_idle_closed", "Total number of connections closed because too many were idle",
+		func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) })
+	gauge("db_connections_max_idle_time_closed", "Total number of connections closed after being idle too long",
+		func(s sql.DBStats) float64 { return float64(s.MaxIdleTimeClosed) })
+	gauge("db_connections_max_lifetime_closed", "Total number of connections closed at their maximum lifetime",
+		func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) })
+}
+
 // Close closes the database connection
 func (s *Store) Close() error {
-	s.logger.Println("Closing database connection...")
-	
-	// Cancel monitoring goroutine
+	s.logger.Info("closing database connection")
+
+	// Stop the monitoring goroutines before closing what they use
 	if s.cancel != nil {
 		s.cancel()
 	}
+	s.wg.Wait()
+	if s.statements != nil {
+		s.statements.Close()
+	}
+	if s.replicas != nil {
+		s.replicas.Close()
+	}
+
+	err := s.db.Close()
+	if s.pool != nil {
+		s.pool.Close()
+	}
+	return err
+}
+
+// Todos returns the todo store
+func (s *Store) Todos() storage.TodoRepository {
+	return s.todoStore
+}
+
+// Auth returns the auth store
+func (s *Store) Auth() storage.AuthRepository {
+	return s.authStore
+}
+
+// Audit returns the audit event store
+func (s *Store) Audit() storage.AuditRepository {
+	return s.auditStore
+}
+
+// SecurityEvents returns the security event store
+func (s *Store) SecurityEvents() storage.SecurityEventRepository {
+	return s.securityStore
+}
+
+// Webhooks returns the webhook store
+func (s *Store) Webhooks() storage.WebhookRepository {
+	return s.webhookStore
+}
 
-	return s.db.Close()
+// Quotas returns the quota override store
+func (s *Store) Quotas() storage.QuotaRepository {
+	return s.quotaStore
 }
 
 // DB returns the underlying database connection (for migrations, etc..)
@@ -194,32 +479,20 @@ func (s *Store) DB() *sql.DB {
 	return s.db
 }
 
-// ExecuteWithRetry execute a function with retry logic for database operations
-func (s *Store) ExecuteWithRetry(ctx context.Context, opertion func() error, maxRetries int) error {
-	var lastErr error
-
-	for attempt := 1; attempt < maxRetries; attempt++ {
-		if err := opertion(); err != nil {
-			lastErr = err
-			s.logger.Printf("Database operation attemps %d failed: %v", attempt, err)
-
-			if attempt < maxRetries {
-				// Exponential backoff
-				backOff := time.Duration(attempt) * 100 * time.Millisecond
-				select {
-				case <-time.After(backOff):
-					continue
-				case <-ctx.Done():
-					return ctx.Err()
-				}
-			}
-		} else {
-			if attempt > 1 {
-				s.logger.Printf("Database operation succeeded on attempt %d", attempt)
-			}
-			return nil
-		}
+// Truncate removes every user and todo together with everything recorded
+// about them and restarts the id sequences, for resetting development and
+// demo databases before seeding them
+func (s *Store) Truncate(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, `TRUNCATE users, todos, attachments, audit_events, security_events, outbox RESTART IDENTITY CASCADE`)
+	if err != nil {
+		return fmt.Errorf("failed to truncate tables: %w", err)
 	}
+	return nil
+}
 
-	return fmt.Errorf("database operation failed after %d attempts: %w", maxRetries, lastErr)
+// ExecuteWithRetry runs operation, retrying it with the configured backoff
+// while it fails with a serialization failure, a deadlock or a lost
+// connection. operation must be safe to run more than once
+func (s *Store) ExecuteWithRetry(ctx context.Context, operation func(ctx context.Context) error) error {
+	return s.retrier.Do(ctx, operation)
 }
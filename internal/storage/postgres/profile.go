@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+const profileColumns = "id, email, name, timezone, avatar_attachment_id, notification_preferences, updated_at, deletion_scheduled_at"
+
+// GetProfile returns the profile of a user
+func (s *AuthStore) GetProfile(ctx context.Context, userID int64) (*models.Profile, error) {
+	query := `SELECT ` + profileColumns + ` FROM users WHERE id = $1`
+
+	profile, err := scanProfile(s.db.QueryRowContext(ctx, query, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get profile of user %d: %w", userID, err)
+	}
+
+	return profile, nil
+}
+
+// UpdateProfile changes the given fields in a single statement. Changed
+// notification preferences are merged into the stored object with the jsonb
+// concatenation operator, so concurrent updates of different settings do
+// not overwrite each other
+func (s *AuthStore) UpdateProfile(ctx context.Context, userID int64, update storage.ProfileUpdate) (*models.Profile, error) {
+	notifications := []byte("{}")
+	if len(update.Notifications) > 0 {
+		var err error
+		if notifications, err = json.Marshal(update.Notifications); err != nil {
+			return nil, fmt.Errorf("failed to encode notification preferences: %w", err)
+		}
+	}
+
+	query := `
+		UPDATE users SET
+			name = COALESCE($2, name),
+			timezone = COALESCE($3, timezone),
+			avatar_attachment_id = CASE WHEN $4::BIGINT IS NULL THEN avatar_attachment_id ELSE NULLIF($4::BIGINT, 0) END,
+			notification_preferences = notification_preferences || $5::JSONB,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + profileColumns
+
+	row := s.db.QueryRowContext(ctx, query, userID, update.Name, update.Timezone, update.AvatarID, string(notifications))
+	profile, err := scanProfile(row)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, storage.ErrNotFound
+		case isForeignKeyViolation(err):
+			// The avatar was deleted since it was checked
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to update profile of user %d: %w", userID, err)
+	}
+
+	return profile, nil
+}
+
+func scanProfile(row rowScanner) (*models.Profile, error) {
+	var (
+		profile       models.Profile
+		notifications []byte
+	)
+	err := row.Scan(
+		&profile.UserID,
+		&profile.Email,
+		&profile.Name,
+		&profile.Timezone,
+		&profile.AvatarID,
+		&notifications,
+		&profile.UpdatedAt,
+		&profile.DeletionScheduledAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	profile.Notifications, err = models.DecodeNotificationPreferences(notifications)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode notification preferences: %w", err)
+	}
+	return &profile, nil
+}
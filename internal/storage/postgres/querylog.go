@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+	"github.com/MuthuM3/gin-microservice-template/internal/metrics"
+)
+
+// queryObserver records the latency of every query by operation and table,
+// logs queries at debug level when enabled and warns about the ones slower
+// than the configured threshold. Parameters are redacted to their type
+// unless LogQueryParams is set, they hold passwords and personal data
+type queryObserver struct {
+	logger        logger.Logger
+	logQueries    bool
+	logParams     bool
+	slowThreshold time.Duration
+}
+
+func newQueryObserver(cfg *config.DatabaseConfig, log logger.Logger) *queryObserver {
+	return &queryObserver{
+		logger:        log,
+		logQueries:    cfg.LogQueries,
+		logParams:     cfg.LogQueryParams,
+		slowThreshold: cfg.SlowQueryThreshold,
+	}
+}
+
+// observe records a query that took duration and failed with err, if it did
+func (o *queryObserver) observe(ctx context.Context, query string, args []any, duration time.Duration, err error) {
+	if o == nil {
+		return
+	}
+
+	labels := metrics.Labels{"operation": queryOperation(query), "table": queryTable(query)}
+	metrics.Default.Histogram("db_query_duration_seconds", "Latency of database queries",
+		metrics.DefaultBuckets, labels).Observe(duration.Seconds())
+
+	slow := o.slowThreshold > 0 && duration >= o.slowThreshold
+	if !slow && !o.logQueries {
+		return
+	}
+
+	attrs := []any{
+		"query", compactQuery(query),
+		"params", o.params(args),
+		"duration", duration,
+	}
+	if err != nil {
+		attrs = append(attrs, "error", err)
+	}
+	log := logger.FromContext(ctx, o.logger)
+
+	if slow {
+		metrics.Default.Counter("db_slow_queries_total",
+			"Total number of queries slower than the slow query threshold", labels).Inc()
+		log.Warn("slow database query", append(attrs, "threshold", o.slowThreshold)...)
+		return
+	}
+	log.Debug("database query", attrs...)
+}
+
+// params renders the query parameters for the log, as their type when they
+// are redacted
+func (o *queryObserver) params(args []any) []string {
+	params := make([]string, len(args))
+	for i, arg := range args {
+		if o.logParams {
+			params[i] = fmt.Sprint(arg)
+		} else {
+			params[i] = fmt.Sprintf("%T", arg)
+		}
+	}
+	return params
+}
+
+// queryTable returns the first table the query reads or writes, the name
+// following FROM, INTO or UPDATE, or "unknown"
+func queryTable(query string) string {
+	fields := strings.Fields(query)
+	for i, field := range fields[:max(len(fields)-1, 0)] {
+		switch strings.ToUpper(field) {
+		case "FROM", "INTO", "UPDATE":
+			table := strings.Trim(fields[i+1], `"();,`)
+			if table != "" && !strings.HasPrefix(table, "$") && strings.ToUpper(table) != "SELECT" {
+				return strings.ToLower(table)
+			}
+		}
+	}
+	return "unknown"
+}
+
+// compactQuery collapses the indentation of a query onto one line
+func compactQuery(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+type QuotaStore struct {
+	db Querier
+}
+
+func newQuotaStore(db Querier) *QuotaStore {
+	return &QuotaStore{db: db}
+}
+
+// GetQuotaOverride returns the override of a user
+func (s *QuotaStore) GetQuotaOverride(ctx context.Context, userID int64) (*models.QuotaOverride, error) {
+	query := `
+		SELECT user_id, max_todos, max_attachment_bytes, max_api_calls_per_day, updated_at
+		FROM quota_overrides
+		WHERE user_id = $1`
+
+	var (
+		override       models.QuotaOverride
+		maxTodos       sql.NullInt64
+		maxBytes       sql.NullInt64
+		maxCallsPerDay sql.NullInt64
+	)
+	err := s.db.QueryRowContext(ctx, query, userID).
+		Scan(&override.UserID, &maxTodos, &maxBytes, &maxCallsPerDay, &override.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get quota override of user %d: %w", userID, err)
+	}
+
+	if maxTodos.Valid {
+		v := int(maxTodos.Int64)
+		override.MaxTodos = &v
+	}
+	if maxBytes.Valid {
+		override.MaxAttachmentBytes = &maxBytes.Int64
+	}
+	if maxCallsPerDay.Valid {
+		v := int(maxCallsPerDay.Int64)
+		override.MaxAPICallsPerDay = &v
+	}
+	return &override, nil
+}
+
+// SetQuotaOverride creates or replaces the override of a user, returning
+// storage.ErrNotFound when the user does not exist
+func (s *QuotaStore) SetQuotaOverride(ctx context.Context, override *models.QuotaOverride) error {
+	query := `
+		INSERT INTO quota_overrides (user_id, max_todos, max_attachment_bytes, max_api_calls_per_day)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE SET
+			max_todos = EXCLUDED.max_todos,
+			max_attachment_bytes = EXCLUDED.max_attachment_bytes,
+			max_api_calls_per_day = EXCLUDED.max_api_calls_per_day,
+			updated_at = NOW()
+		RETURNING updated_at`
+
+	err := s.db.QueryRowContext(ctx, query, override.UserID, override.MaxTodos, override.MaxAttachmentBytes,
+		override.MaxAPICallsPerDay).Scan(&override.UpdatedAt)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return storage.ErrNotFound
+		}
+		return fmt.Errorf("failed to set quota override of user %d: %w", override.UserID, err)
+	}
+
+	return nil
+}
+
+// DeleteQuotaOverride removes the override of a user
+func (s *QuotaStore) DeleteQuotaOverride(ctx context.Context, userID int64) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM quota_overrides WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete quota override of user %d: %w", userID, err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete quota override of user %d: %w", userID, err)
+	}
+	if deleted == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
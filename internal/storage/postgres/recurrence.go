@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+)
+
+// DueRecurrences returns the repeating todos that are completed or were due
+// before now, across all users
+func (s *TodoStore) DueRecurrences(ctx context.Context, now time.Time, limit int) ([]*models.Todo, error) {
+	query := `
+		SELECT ` + todoColumns + `
+		FROM todos
+		WHERE recurrence <> '' AND deleted_at IS NULL AND (completed = TRUE OR due_date < $1)
+		ORDER BY due_date, id
+		LIMIT $2`
+
+	rows, err := s.db.QueryContext(ctx, query, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due recurrences: %w", err)
+	}
+	defer rows.Close()
+
+	todos := make([]*models.Todo, 0)
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan todo: %w", err)
+		}
+		todos = append(todos, todo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate due recurrences: %w", err)
+	}
+
+	if err := s.loadTags(ctx, todos); err != nil {
+		return nil, err
+	}
+
+	return todos, nil
+}
@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+	"github.com/lib/pq"
+)
+
+// DueReminders returns the todos due between from and to that have not been
+// reminded of, across all users
+func (s *TodoStore) DueReminders(ctx context.Context, from, to time.Time, limit int) ([]*models.Todo, error) {
+	query := `
+		SELECT ` + todoColumns + `
+		FROM todos
+		WHERE due_date >= $1 AND due_date <= $2
+			AND reminded_at IS NULL AND completed = FALSE AND deleted_at IS NULL
+		ORDER BY due_date, id
+		LIMIT $3`
+
+	rows, err := s.db.QueryContext(ctx, query, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due reminders: %w", err)
+	}
+	defer rows.Close()
+
+	todos := make([]*models.Todo, 0)
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan todo: %w", err)
+		}
+		todos = append(todos, todo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate due reminders: %w", err)
+	}
+
+	if err := s.loadTags(ctx, todos); err != nil {
+		return nil, err
+	}
+
+	return todos, nil
+}
+
+// MarkReminded records that reminders of the todos were sent
+func (s *TodoStore) MarkReminded(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	_, err := s.db.ExecContext(ctx, `UPDATE todos SET reminded_at = NOW() WHERE id = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to mark todos reminded: %w", err)
+	}
+	return nil
+}
+
+// SetReminder sets or clears when the user wants to be reminded of the todo
+func (s *TodoStore) SetReminder(ctx context.Context, userID, id int64, remindAt *time.Time) error {
+	query := `
+		UPDATE todos
+		SET remind_at = $1, reminder_queued_at = NULL, updated_at = NOW()
+		WHERE id = $2 AND user_id = $3 AND deleted_at IS NULL`
+
+	result, err := s.db.ExecContext(ctx, query, remindAt, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to set reminder of todo %d: %w", id, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to set reminder of todo %d: %w", id, err)
+	}
+	if affected == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+// PendingReminders returns the todos whose reminder is due by before and
+// has not been dispatched, across all users
+func (s *TodoStore) PendingReminders(ctx context.Context, before time.Time, limit int) ([]*models.Todo, error) {
+	query := `
+		SELECT ` + todoColumns + `
+		FROM todos
+		WHERE remind_at <= $1
+			AND reminder_queued_at IS NULL AND completed = FALSE AND deleted_at IS NULL
+		ORDER BY remind_at, id
+		LIMIT $2`
+
+	rows, err := s.db.QueryContext(ctx, query, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending reminders: %w", err)
+	}
+	defer rows.Close()
+
+	todos := make([]*models.Todo, 0)
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan todo: %w", err)
+		}
+		todos = append(todos, todo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate pending reminders: %w", err)
+	}
+
+	if err := s.loadTags(ctx, todos); err != nil {
+		return nil, err
+	}
+
+	return todos, nil
+}
+
+// MarkRemindersDispatched records that the reminders of the todos were
+// queued or sent
+func (s *TodoStore) MarkRemindersDispatched(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	_, err := s.db.ExecContext(ctx, `UPDATE todos SET reminder_queued_at = NOW() WHERE id = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to mark reminders dispatched: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/logger"
+	"github.com/MuthuM3/gin-microservice-template/internal/metrics"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// replica is a read-only copy of the database. Replicas are named by their
+// position in the configuration since their DSNs hold credentials
+type replica struct {
+	name       string
+	db         *sql.DB
+	pool       *pgxpool.Pool
+	statements *stmtCache
+	healthy    atomic.Bool
+
+	fallbacks *metrics.Counter
+}
+
+// querier returns the statement cache of the replica for the stores that
+// use one, the connection pool otherwise
+func (r *replica) querier(cached bool) Querier {
+	if cached && r.statements != nil {
+		return r.statements
+	}
+	return r.db
+}
+
+// replicaSet spreads read-only queries over the healthy replicas in turn
+type replicaSet struct {
+	replicas []*replica
+	next     atomic.Uint64
+}
+
+// openReplicas opens a pool per replica DSN with the settings of the
+// primary. Unreachable replicas are marked down and picked up by the health
+// checks once they respond
+func openReplicas(ctx context.Context, cfg *config.DatabaseConfig, log logger.Logger) (*replicaSet, error) {
+	set := &replicaSet{}
+	for i, dsn := range cfg.ReplicaDSNs {
+		db, pool, err := openDB(cfg, dsn)
+		if err != nil {
+			set.Close()
+			return nil, fmt.Errorf("failed to open replica %d: %w", i, err)
+		}
+
+		r := &replica{name: fmt.Sprintf("replica-%d", i), db: db, pool: pool}
+		registerConnectionMetrics(db, r.name)
+		if pool != nil {
+			registerPoolMetrics(pool, r.name)
+		}
+		if cfg.StatementCacheSize > 0 {
+			r.statements = newStmtCache(db, cfg.StatementCacheSize, r.name)
+		}
+		r.fallbacks = metrics.Default.Counter("db_replica_fallbacks_total",
+			"Total number of read queries sent to the primary after a replica failed", metrics.Labels{"replica": r.name})
+		metrics.Default.GaugeFunc("db_replica_healthy", "Whether the replica serves read queries",
+			metrics.Labels{"replica": r.name}, func() float64 {
+				if r.healthy.Load() {
+					return 1
+				}
+				return 0
+			})
+
+		if err := ping(ctx, db); err != nil {
+			log.Warn("database replica unavailable, reads go to the primary until it responds",
+				"replica", r.name, "error", err)
+		} else {
+			r.healthy.Store(true)
+		}
+		set.replicas = append(set.replicas, r)
+	}
+	return set, nil
+}
+
+// pick returns the next healthy replica, nil when none is
+func (s *replicaSet) pick() *replica {
+	n := uint64(len(s.replicas))
+	start := s.next.Add(1)
+	for i := range n {
+		if r := s.replicas[(start+i)%n]; r.healthy.Load() {
+			return r
+		}
+	}
+	return nil
+}
+
+// check pings every replica, taking failing ones out of the rotation and
+// putting recovered ones back
+func (s *replicaSet) check(ctx context.Context, log logger.Logger) {
+	for _, r := range s.replicas {
+		err := ping(ctx, r.db)
+		healthy := err == nil
+		if r.healthy.Swap(healthy) == healthy {
+			continue
+		}
+		if healthy {
+			log.Info("database replica recovered", "replica", r.name)
+		} else {
+			log.Warn("database replica unavailable, reads go to the primary", "replica", r.name, "error", err)
+		}
+	}
+}
+
+// resetStatements empties the statement caches of the replicas
+func (s *replicaSet) resetStatements() {
+	for _, r := range s.replicas {
+		if r.statements != nil {
+			r.statements.Reset()
+		}
+	}
+}
+
+// Close closes the pools of the replicas
+func (s *replicaSet) Close() {
+	for _, r := range s.replicas {
+		if r.statements != nil {
+			r.statements.Close()
+		}
+		r.db.Close()
+		if r.pool != nil {
+			r.pool.Close()
+		}
+	}
+}
+
+// routedDB sends read-only queries to a healthy replica and everything else
+// to the primary. A read failing because its replica is down marks the
+// replica down and runs again on the primary. Replicas lag behind the
+// primary, reads that must see a write of the same request belong in a
+// transaction, which always runs on the primary
+type routedDB struct {
+	primary  Querier
+	replicas *replicaSet
+	cached   bool
+}
+
+func (db *routedDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return db.primary.ExecContext(ctx, query, args...)
+}
+
+func (db *routedDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	r := db.replica(query)
+	if r == nil {
+		return db.primary.QueryContext(ctx, query, args...)
+	}
+
+	rows, err := r.querier(db.cached).QueryContext(ctx, query, args...)
+	if db.failed(ctx, r, err) {
+		return db.primary.QueryContext(ctx, query, args...)
+	}
+	return rows, err
+}
+
+func (db *routedDB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	r := db.replica(query)
+	if r == nil {
+		return db.primary.QueryRowContext(ctx, query, args...)
+	}
+
+	row := r.querier(db.cached).QueryRowContext(ctx, query, args...)
+	if db.failed(ctx, r, row.Err()) {
+		return db.primary.QueryRowContext(ctx, query, args...)
+	}
+	return row
+}
+
+// replica returns the replica to run query on, nil when it must run on the
+// primary
+func (db *routedDB) replica(query string) *replica {
+	if !readOnly(query) {
+		return nil
+	}
+	return db.replicas.pick()
+}
+
+// failed reports whether err means the replica is down, taking it out of
+// the rotation until a health check succeeds
+func (db *routedDB) failed(ctx context.Context, r *replica, err error) bool {
+	if err == nil || ctx.Err() != nil || !IsOutage(err) {
+		return false
+	}
+	r.healthy.Store(false)
+	r.fallbacks.Inc()
+	return true
+}
+
+// readOnly reports whether query only reads, locking reads and statements
+// with side effects such as data-modifying CTEs run on the primary
+func readOnly(query string) bool {
+	if queryOperation(query) != "SELECT" {
+		return false
+	}
+	upper := strings.ToUpper(query)
+	return !strings.Contains(upper, " FOR UPDATE") && !strings.Contains(upper, " FOR SHARE") &&
+		!strings.Contains(upper, "NEXTVAL(")
+}
@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+)
+
+// searchMatch selects the todos matching the query in $2. Queries made up of
+// stop words only ("the", "to") parse to an empty tsquery and fall back to a
+// case-insensitive substring match against the pattern in $3
+const searchMatch = `
+	user_id = $1 AND deleted_at IS NULL AND (
+		search_vector @@ websearch_to_tsquery('english', $2)
+		OR (numnode(websearch_to_tsquery('english', $2)) = 0
+			AND (title ILIKE $3 ESCAPE '\' OR description ILIKE $3 ESCAPE '\'))
+	)`
+
+// Search returns a page of the user's todos matching a web-style search query
+// ("buy milk", "report -draft", "\"exact phrase\"") ranked by relevance
+func (s *TodoStore) Search(ctx context.Context, userID int64, query string, limit, offset int) ([]*models.TodoSearchResult, int, error) {
+	pattern := "%" + escapeLike(query) + "%"
+
+	var total int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM todos WHERE `+searchMatch, userID, query, pattern).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count search results: %w", err)
+	}
+
+	sqlQuery := `
+		SELECT ` + todoColumns + `,
+			ts_rank(search_vector, websearch_to_tsquery('english', $2)) AS rank,
+			ts_headline('english', title || ' ' || description, websearch_to_tsquery('english', $2),
+				'StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2')
+		FROM todos
+		WHERE ` + searchMatch + `
+		ORDER BY rank DESC, created_at DESC, id DESC
+		LIMIT $4 OFFSET $5`
+
+	rows, err := s.db.QueryContext(ctx, sqlQuery, userID, query, pattern, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search todos: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]*models.TodoSearchResult, 0, limit)
+	todos := make([]*models.Todo, 0, limit)
+	for rows.Next() {
+		var result models.TodoSearchResult
+		todo, err := scanTodo(rows, &result.Rank, &result.Snippet)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		result.Todo = todo
+		results = append(results, &result)
+		todos = append(todos, todo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate search results: %w", err)
+	}
+
+	if err := s.loadTags(ctx, todos); err != nil {
+		return nil, 0, err
+	}
+
+	return results, total, nil
+}
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+type SecurityStore struct {
+	db Querier
+}
+
+func newSecurityStore(db Querier) *SecurityStore {
+	return &SecurityStore{db: db}
+}
+
+const securityEventColumns = "id, user_id, type, email, ip_address, user_agent, details, request_id, created_at"
+
+// CreateSecurityEvent appends an event to the log
+func (s *SecurityStore) CreateSecurityEvent(ctx context.Context, event *models.SecurityEvent) error {
+	var details []byte
+	if len(event.Details) > 0 {
+		var err error
+		if details, err = json.Marshal(event.Details); err != nil {
+			return fmt.Errorf("failed to encode security event details: %w", err)
+		}
+	}
+
+	query := `
+		INSERT INTO security_events (user_id, type, email, ip_address, user_agent, details, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at`
+
+	err := s.db.QueryRowContext(ctx, query, event.UserID, event.Type, event.Email, event.IPAddress,
+		event.UserAgent, details, event.RequestID).Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create security event: %w", err)
+	}
+
+	return nil
+}
+
+// ListSecurityEvents returns a page of events matching the filter ordered
+// from newest to oldest along with the total number of matches
+func (s *SecurityStore) ListSecurityEvents(ctx context.Context, filter storage.SecurityEventFilter, limit, offset int) ([]*models.SecurityEvent, int, error) {
+	where, args := securityFilterClause(filter)
+
+	var total int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM security_events WHERE `+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count security events: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT `+securityEventColumns+`
+		FROM security_events
+		WHERE %s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d`, where, len(args)+1, len(args)+2)
+
+	rows, err := s.db.QueryContext(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list security events: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]*models.SecurityEvent, 0, limit)
+	for rows.Next() {
+		var event models.SecurityEvent
+		var details []byte
+		err := rows.Scan(&event.ID, &event.UserID, &event.Type, &event.Email, &event.IPAddress,
+			&event.UserAgent, &details, &event.RequestID, &event.CreatedAt)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan security event: %w", err)
+		}
+		if len(details) > 0 {
+			if err := json.Unmarshal(details, &event.Details); err != nil {
+				return nil, 0, fmt.Errorf("failed to decode details of security event %d: %w", event.ID, err)
+			}
+		}
+		events = append(events, &event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate security events: %w", err)
+	}
+
+	return events, total, nil
+}
+
+// PurgeSecurityEvents removes the events created before the cutoff
+func (s *SecurityStore) PurgeSecurityEvents(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM security_events WHERE created_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge security events: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge security events: %w", err)
+	}
+	return purged, nil
+}
+
+// securityFilterClause builds the WHERE clause for a filter
+func securityFilterClause(filter storage.SecurityEventFilter) (string, []any) {
+	conditions := []string{"TRUE"}
+	var args []any
+
+	add := func(condition string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.UserID != nil {
+		add("user_id = $%d", *filter.UserID)
+	}
+	if filter.Type != "" {
+		add("type = $%d", filter.Type)
+	}
+	if filter.Since != nil {
+		add("created_at >= $%d", *filter.Since)
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
@@ -0,0 +1,135 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+const shareColumns = "s.todo_id, s.owner_id, s.user_id, s.role, s.created_at, s.accepted_at"
+
+// CreateShare inserts a share, returning storage.ErrConflict when the todo
+// is already shared with the user and storage.ErrNotFound when the todo or
+// a user does not exist
+func (s *TodoStore) CreateShare(ctx context.Context, share *models.TodoShare) error {
+	query := `
+		INSERT INTO todo_shares (todo_id, owner_id, user_id, role, accepted_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at`
+
+	err := s.db.QueryRowContext(ctx, query, share.TodoID, share.OwnerID, share.UserID, share.Role, share.AcceptedAt).
+		Scan(&share.CreatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return storage.ErrConflict
+		}
+		if isForeignKeyViolation(err) {
+			return storage.ErrNotFound
+		}
+		return fmt.Errorf("failed to share todo %d: %w", share.TodoID, err)
+	}
+
+	return nil
+}
+
+// GetShare returns the share of a todo with the user
+func (s *TodoStore) GetShare(ctx context.Context, todoID, userID int64) (*models.TodoShare, error) {
+	query := `SELECT ` + shareColumns + ` FROM todo_shares s WHERE s.todo_id = $1 AND s.user_id = $2`
+
+	share, err := scanShare(s.db.QueryRowContext(ctx, query, todoID, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get share of todo %d: %w", todoID, err)
+	}
+
+	return share, nil
+}
+
+// ListShares returns the shares of a todo, oldest first
+func (s *TodoStore) ListShares(ctx context.Context, todoID int64) ([]*models.TodoShare, error) {
+	query := `
+		SELECT ` + shareColumns + `
+		FROM todo_shares s
+		WHERE s.todo_id = $1
+		ORDER BY s.created_at, s.user_id`
+
+	return s.queryShares(ctx, query, todoID)
+}
+
+// ListUserShares returns the shares with the user of todos outside the
+// trash, newest first
+func (s *TodoStore) ListUserShares(ctx context.Context, userID int64) ([]*models.TodoShare, error) {
+	query := `
+		SELECT ` + shareColumns + `
+		FROM todo_shares s
+		JOIN todos t ON t.id = s.todo_id
+		WHERE s.user_id = $1 AND t.deleted_at IS NULL
+		ORDER BY s.created_at DESC, s.todo_id DESC`
+
+	return s.queryShares(ctx, query, userID)
+}
+
+// UpdateShare persists the role and acceptance of a share
+func (s *TodoStore) UpdateShare(ctx context.Context, share *models.TodoShare) error {
+	query := `UPDATE todo_shares SET role = $3, accepted_at = $4 WHERE todo_id = $1 AND user_id = $2`
+
+	result, err := s.db.ExecContext(ctx, query, share.TodoID, share.UserID, share.Role, share.AcceptedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update share of todo %d: %w", share.TodoID, err)
+	}
+	return requireAffected(result, share.UserID, "update the share of todo with")
+}
+
+// DeleteShare removes the share of a todo with the user
+func (s *TodoStore) DeleteShare(ctx context.Context, todoID, userID int64) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM todo_shares WHERE todo_id = $1 AND user_id = $2`, todoID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete share of todo %d: %w", todoID, err)
+	}
+	return requireAffected(result, userID, "delete the share of todo with")
+}
+
+func (s *TodoStore) queryShares(ctx context.Context, query string, arg int64) ([]*models.TodoShare, error) {
+	rows, err := s.db.QueryContext(ctx, query, arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shares: %w", err)
+	}
+	defer rows.Close()
+
+	shares := make([]*models.TodoShare, 0)
+	for rows.Next() {
+		share, err := scanShare(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan share: %w", err)
+		}
+		shares = append(shares, share)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate shares: %w", err)
+	}
+
+	return shares, nil
+}
+
+func scanShare(row rowScanner) (*models.TodoShare, error) {
+	var share models.TodoShare
+	err := row.Scan(
+		&share.TodoID,
+		&share.OwnerID,
+		&share.UserID,
+		&share.Role,
+		&share.CreatedAt,
+		&share.AcceptedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
@@ -0,0 +1,201 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/metrics"
+)
+
+// schemaFingerprintQuery hashes the columns of the tables of the current
+// schema, it changes whenever a migration adds, drops or retypes a column
+const schemaFingerprintQuery = `
+	SELECT COALESCE(md5(string_agg(table_name || '.' || column_name || ':' || data_type, ','
+		ORDER BY table_name, column_name)), '')
+	FROM information_schema.columns
+	WHERE table_schema = current_schema()`
+
+// stmtCache prepares the queries of the todo and auth stores the first time
+// they run and reuses the statements afterwards, saving Postgres a parse and
+// plan per request. database/sql prepares a statement again on each pooled
+// connection it is used on. Queries are keyed by their text, once the cache
+// holds maxSize statements new queries run unprepared. Transactions bypass
+// the cache
+type stmtCache struct {
+	db      *sql.DB
+	maxSize int
+
+	mu          sync.RWMutex
+	stmts       map[string]*sql.Stmt
+	fingerprint string
+
+	hits   *metrics.Counter
+	misses *metrics.Counter
+	resets *metrics.Counter
+}
+
+// newStmtCache creates the cache of the statements run on db, name labels
+// its metrics, "primary" or the name of a replica
+func newStmtCache(db *sql.DB, maxSize int, name string) *stmtCache {
+	labels := metrics.Labels{"database": name}
+	c := &stmtCache{
+		db:      db,
+		maxSize: maxSize,
+		stmts:   make(map[string]*sql.Stmt),
+		hits: metrics.Default.Counter("db_statement_cache_hits_total",
+			"Total number of queries run with a cached prepared statement", labels),
+		misses: metrics.Default.Counter("db_statement_cache_misses_total",
+			"Total number of queries that were not in the prepared statement cache", labels),
+		resets: metrics.Default.Counter("db_statement_cache_resets_total",
+			"Total number of times the prepared statement cache was emptied after a schema change", labels),
+	}
+
+	metrics.Default.GaugeFunc("db_statement_cache_size",
+		"Number of prepared statements in the cache", labels, func() float64 {
+			c.mu.RLock()
+			defer c.mu.RUnlock()
+			return float64(len(c.stmts))
+		})
+	metrics.Default.GaugeFunc("db_statement_cache_hit_ratio",
+		"Share of queries run with a cached prepared statement", labels, func() float64 {
+			hits, misses := c.hits.Value(), c.misses.Value()
+			if hits+misses == 0 {
+				return 0
+			}
+			return hits / (hits + misses)
+		})
+	return c
+}
+
+func (c *stmtCache) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	stmt := c.statement(ctx, query)
+	if stmt == nil {
+		return c.db.ExecContext(ctx, query, args...)
+	}
+
+	result, err := stmt.ExecContext(ctx, args...)
+	if c.unusable(err) {
+		return c.db.ExecContext(ctx, query, args...)
+	}
+	return result, err
+}
+
+func (c *stmtCache) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	stmt := c.statement(ctx, query)
+	if stmt == nil {
+		return c.db.QueryContext(ctx, query, args...)
+	}
+
+	rows, err := stmt.QueryContext(ctx, args...)
+	if c.unusable(err) {
+		return c.db.QueryContext(ctx, query, args...)
+	}
+	return rows, err
+}
+
+func (c *stmtCache) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	stmt := c.statement(ctx, query)
+	if stmt == nil {
+		return c.db.QueryRowContext(ctx, query, args...)
+	}
+
+	row := stmt.QueryRowContext(ctx, args...)
+	if c.unusable(row.Err()) {
+		return c.db.QueryRowContext(ctx, query, args...)
+	}
+	return row
+}
+
+// statement returns the prepared statement of query, preparing it on a
+// miss. It returns nil when the cache is full or the query could not be
+// prepared, the query then runs unprepared and reports its own error
+func (c *stmtCache) statement(ctx context.Context, query string) *sql.Stmt {
+	c.mu.RLock()
+	stmt, ok := c.stmts[query]
+	c.mu.RUnlock()
+	if ok {
+		c.hits.Inc()
+		return stmt
+	}
+	c.misses.Inc()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if stmt, ok := c.stmts[query]; ok {
+		return stmt
+	}
+	if len(c.stmts) >= c.maxSize {
+		return nil
+	}
+
+	stmt, err := c.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil
+	}
+	c.stmts[query] = stmt
+	return stmt
+}
+
+// Reset closes the cached statements, they are prepared again on next use
+func (c *stmtCache) Reset() {
+	c.mu.Lock()
+	stmts := c.stmts
+	c.stmts = make(map[string]*sql.Stmt)
+	c.mu.Unlock()
+
+	for _, stmt := range stmts {
+		stmt.Close()
+	}
+	c.resets.Inc()
+}
+
+// CheckSchema empties the cache when the schema changed since the last
+// check, so statements planned against the old tables are not reused
+func (c *stmtCache) CheckSchema(ctx context.Context) (bool, error) {
+	var fingerprint string
+	if err := c.db.QueryRowContext(ctx, schemaFingerprintQuery).Scan(&fingerprint); err != nil {
+		return false, fmt.Errorf("failed to read schema fingerprint: %w", err)
+	}
+
+	c.mu.Lock()
+	previous := c.fingerprint
+	c.fingerprint = fingerprint
+	c.mu.Unlock()
+
+	if previous == "" || previous == fingerprint {
+		return false, nil
+	}
+	c.Reset()
+	return true, nil
+}
+
+// Close closes the cached statements
+func (c *stmtCache) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, stmt := range c.stmts {
+		stmt.Close()
+	}
+	c.stmts = make(map[string]*sql.Stmt)
+}
+
+// unusable reports whether a query failed because its statement can no
+// longer be used and should run again unprepared: Postgres refuses
+// statements whose tables a migration changed, which empties the cache, and
+// a concurrent reset may have closed the statement
+func (c *stmtCache) unusable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if code, _ := errorCode(err); code == "0A000" &&
+		strings.Contains(err.Error(), "cached plan must not change result type") {
+		c.Reset()
+		return true
+	}
+	// database/sql does not export this error
+	return err.Error() == "sql: statement is closed"
+}
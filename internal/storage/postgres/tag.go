@@ -0,0 +1,215 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+	"github.com/lib/pq"
+)
+
+const tagColumns = "id, user_id, name, created_at"
+
+// CreateTag inserts a new tag, returning storage.ErrConflict when the user
+// already has a tag with the name
+func (s *TodoStore) CreateTag(ctx context.Context, tag *models.Tag) error {
+	query := `
+		INSERT INTO tags (user_id, name)
+		VALUES ($1, $2)
+		RETURNING id, created_at`
+
+	err := s.db.QueryRowContext(ctx, query, tag.UserID, tag.Name).Scan(&tag.ID, &tag.CreatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return storage.ErrConflict
+		}
+		return fmt.Errorf("failed to create tag: %w", err)
+	}
+
+	return nil
+}
+
+// GetTag returns the tag with the given id owned by the user
+func (s *TodoStore) GetTag(ctx context.Context, userID, id int64) (*models.Tag, error) {
+	query := `SELECT ` + tagColumns + ` FROM tags WHERE id = $1 AND user_id = $2`
+
+	var tag models.Tag
+	err := s.db.QueryRowContext(ctx, query, id, userID).Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get tag %d: %w", id, err)
+	}
+
+	return &tag, nil
+}
+
+// ListTags returns the user's tags ordered by name
+func (s *TodoStore) ListTags(ctx context.Context, userID int64) ([]*models.Tag, error) {
+	query := `SELECT ` + tagColumns + ` FROM tags WHERE user_id = $1 ORDER BY name`
+
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := make([]*models.Tag, 0)
+	for rows.Next() {
+		var tag models.Tag
+		if err := rows.Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags = append(tags, &tag)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+// UpdateTag renames a tag, returning storage.ErrConflict when the name is taken
+func (s *TodoStore) UpdateTag(ctx context.Context, tag *models.Tag) error {
+	// The todos carrying the tag are touched, their representation changes
+	query := `
+		WITH renamed AS (
+			UPDATE tags
+			SET name = $1
+			WHERE id = $2 AND user_id = $3
+			RETURNING id, created_at
+		), touched AS (
+			UPDATE todos SET updated_at = NOW()
+			WHERE user_id = $3 AND id IN (SELECT todo_id FROM todo_tags WHERE tag_id IN (SELECT id FROM renamed))
+		)
+		SELECT created_at FROM renamed`
+
+	err := s.db.QueryRowContext(ctx, query, tag.Name, tag.ID, tag.UserID).Scan(&tag.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.ErrNotFound
+		}
+		if isUniqueViolation(err) {
+			return storage.ErrConflict
+		}
+		return fmt.Errorf("failed to update tag %d: %w", tag.ID, err)
+	}
+
+	return nil
+}
+
+// DeleteTag removes a tag, the foreign key detaches it from every todo,
+// which is touched
+func (s *TodoStore) DeleteTag(ctx context.Context, userID, id int64) error {
+	query := `
+		WITH touched AS (
+			UPDATE todos SET updated_at = NOW()
+			WHERE user_id = $2 AND id IN (SELECT todo_id FROM todo_tags WHERE tag_id = $1)
+		)
+		DELETE FROM tags WHERE id = $1 AND user_id = $2`
+
+	result, err := s.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete tag %d: %w", id, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete tag %d: %w", id, err)
+	}
+
+	if affected == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// SetTodoTags replaces the tags of a todo, creating tags the user does not
+// have yet. It should run inside a transaction so the todo never ends up
+// with a partial set of tags
+func (s *TodoStore) SetTodoTags(ctx context.Context, userID, todoID int64, names []string) error {
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM todos WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL)`,
+		todoID, userID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check todo %d: %w", todoID, err)
+	}
+	if !exists {
+		return storage.ErrNotFound
+	}
+
+	if len(names) > 0 {
+		query := `
+			INSERT INTO tags (user_id, name)
+			SELECT $1, UNNEST($2::text[])
+			ON CONFLICT (user_id, name) DO NOTHING`
+		if _, err := s.db.ExecContext(ctx, query, userID, pq.Array(names)); err != nil {
+			return fmt.Errorf("failed to create tags: %w", err)
+		}
+	}
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM todo_tags WHERE todo_id = $1`, todoID); err != nil {
+		return fmt.Errorf("failed to clear tags of todo %d: %w", todoID, err)
+	}
+
+	if len(names) > 0 {
+		query := `
+			INSERT INTO todo_tags (todo_id, tag_id)
+			SELECT $1, id FROM tags WHERE user_id = $2 AND name = ANY($3)`
+		if _, err := s.db.ExecContext(ctx, query, todoID, userID, pq.Array(names)); err != nil {
+			return fmt.Errorf("failed to tag todo %d: %w", todoID, err)
+		}
+	}
+
+	return nil
+}
+
+// loadTags fills in the tag names of the todos with a single query instead of
+// one per todo
+func (s *TodoStore) loadTags(ctx context.Context, todos []*models.Todo) error {
+	if len(todos) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*models.Todo, len(todos))
+	ids := make([]int64, 0, len(todos))
+	for _, todo := range todos {
+		todo.Tags = []string{}
+		byID[todo.ID] = todo
+		ids = append(ids, todo.ID)
+	}
+
+	query := `
+		SELECT tt.todo_id, t.name
+		FROM todo_tags tt
+		JOIN tags t ON t.id = tt.tag_id
+		WHERE tt.todo_id = ANY($1)
+		ORDER BY t.name`
+
+	rows, err := s.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to load tags: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var todoID int64
+		var name string
+		if err := rows.Scan(&todoID, &name); err != nil {
+			return fmt.Errorf("failed to scan tag: %w", err)
+		}
+		byID[todoID].Tags = append(byID[todoID].Tags, name)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate tags: %w", err)
+	}
+
+	return nil
+}
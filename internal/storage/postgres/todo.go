@@ -1,15 +1,388 @@
 package postgres
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+	"github.com/lib/pq"
+)
 
 type TodoStore struct {
-	db    *sql.DB
+	db    Querier
+	conn  *sql.Conn // the connection of the transaction, nil outside one
 	store *Store
 }
 
-func newTodoStore(db *sql.DB, store *Store) *TodoStore {
+func newTodoStore(db Querier, store *Store) *TodoStore {
 	return &TodoStore{
 		db:    db,
 		store: store,
 	}
 }
+
+const todoColumns = "id, user_id, parent_id, title, description, completed, due_date, recurrence, remind_at, priority, created_at, updated_at, deleted_at"
+
+// Create inserts a new todo and fills in the generated fields
+func (s *TodoStore) Create(ctx context.Context, todo *models.Todo) error {
+	query := `
+		INSERT INTO todos (user_id, parent_id, title, description, completed, due_date, recurrence, remind_at, priority)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at, updated_at`
+
+	err := s.db.QueryRowContext(ctx, query, todo.UserID, todo.ParentID, todo.Title, todo.Description, todo.Completed,
+		todo.DueDate, todo.Recurrence, todo.RemindAt, todo.Priority).
+		Scan(&todo.ID, &todo.CreatedAt, &todo.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create todo: %w", err)
+	}
+
+	return nil
+}
+
+// importColumns are the columns ImportTodos fills in, the others take their
+// defaults
+var importColumns = []string{"user_id", "title", "description", "completed", "due_date", "priority"}
+
+// importBatchSize keeps the INSERT statements of ImportTodos below the 65535
+// parameters Postgres accepts
+const importBatchSize = 1000
+
+// ImportTodos stores todos in bulk, with COPY on the pgx driver and with
+// multi-row INSERTs otherwise. COPY joins the transaction of the store, a
+// store outside one always inserts
+func (s *TodoStore) ImportTodos(ctx context.Context, todos []*models.Todo) (int64, error) {
+	rows := make([][]any, len(todos))
+	for i, todo := range todos {
+		rows[i] = []any{todo.UserID, todo.Title, todo.Description, todo.Completed, todo.DueDate, todo.Priority}
+	}
+	if s.conn != nil && s.store.pool != nil {
+		return s.store.CopyFrom(ctx, s.conn, "todos", importColumns, rows)
+	}
+
+	var imported int64
+	for batch := range slices.Chunk(rows, importBatchSize) {
+		var query strings.Builder
+		query.WriteString(`INSERT INTO todos (` + strings.Join(importColumns, ", ") + `) VALUES `)
+		args := make([]any, 0, len(batch)*len(importColumns))
+		for i, row := range batch {
+			if i > 0 {
+				query.WriteString(", ")
+			}
+			query.WriteString("(")
+			for j, value := range row {
+				if j > 0 {
+					query.WriteString(", ")
+				}
+				args = append(args, value)
+				fmt.Fprintf(&query, "$%d", len(args))
+			}
+			query.WriteString(")")
+		}
+
+		result, err := s.db.ExecContext(ctx, query.String(), args...)
+		if err != nil {
+			return imported, fmt.Errorf("failed to import todos: %w", err)
+		}
+		inserted, err := result.RowsAffected()
+		if err != nil {
+			return imported, fmt.Errorf("failed to import todos: %w", err)
+		}
+		imported += inserted
+	}
+
+	return imported, nil
+}
+
+// GetByID returns the todo with the given id owned by the user
+func (s *TodoStore) GetByID(ctx context.Context, userID, id int64) (*models.Todo, error) {
+	query := `SELECT ` + todoColumns + ` FROM todos WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
+
+	todo, err := scanTodo(s.db.QueryRowContext(ctx, query, id, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get todo %d: %w", id, err)
+	}
+
+	if err := s.loadTags(ctx, []*models.Todo{todo}); err != nil {
+		return nil, err
+	}
+
+	return todo, nil
+}
+
+// List returns a keyset page of the user's todos matching the filter ordered
+// from newest to oldest. Seeking on (created_at, id) keeps deep pages as
+// cheap as the first one, unlike OFFSET
+func (s *TodoStore) List(ctx context.Context, userID int64, filter storage.TodoFilter, page storage.PageRequest) ([]*models.Todo, error) {
+	where, args := todoFilterClause(userID, filter)
+
+	// Backward pages are read in ascending order and reversed afterwards
+	order := "created_at DESC, id DESC"
+	if page.Cursor != nil {
+		operator := "<"
+		if page.Backward {
+			operator = ">"
+			order = "created_at ASC, id ASC"
+		}
+		args = append(args, page.Cursor.CreatedAt, page.Cursor.ID)
+		where += fmt.Sprintf(" AND (created_at, id) %s ($%d, $%d)", operator, len(args)-1, len(args))
+	}
+
+	query := fmt.Sprintf(`
+		SELECT `+todoColumns+`
+		FROM todos
+		WHERE %s
+		ORDER BY %s
+		LIMIT $%d`, where, order, len(args)+1)
+
+	rows, err := s.db.QueryContext(ctx, query, append(args, page.Limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list todos: %w", err)
+	}
+	defer rows.Close()
+
+	todos := make([]*models.Todo, 0, page.Limit)
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan todo: %w", err)
+		}
+		todos = append(todos, todo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate todos: %w", err)
+	}
+
+	if page.Cursor != nil && page.Backward {
+		slices.Reverse(todos)
+	}
+
+	if err := s.loadTags(ctx, todos); err != nil {
+		return nil, err
+	}
+
+	return todos, nil
+}
+
+// todoFilterClause builds the WHERE clause for a filter, values are always
+// passed as placeholders and never interpolated into the query
+func todoFilterClause(userID int64, filter storage.TodoFilter) (string, []any) {
+	conditions := []string{"user_id = $1", "deleted_at IS NULL"}
+	args := []any{userID}
+
+	add := func(condition string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Completed != nil {
+		add("completed = $%d", *filter.Completed)
+	}
+	if filter.Priority != "" {
+		add("priority = $%d", filter.Priority)
+	}
+	if filter.DueBefore != nil {
+		add("due_date < $%d", *filter.DueBefore)
+	}
+	if filter.DueAfter != nil {
+		add("due_date > $%d", *filter.DueAfter)
+	}
+	if len(filter.Tags) > 0 {
+		// The todo must carry every tag, so count the matching links
+		args = append(args, pq.Array(filter.Tags), len(filter.Tags))
+		conditions = append(conditions, fmt.Sprintf(`id IN (
+			SELECT tt.todo_id FROM todo_tags tt JOIN tags t ON t.id = tt.tag_id
+			WHERE t.user_id = $1 AND t.name = ANY($%d)
+			GROUP BY tt.todo_id HAVING COUNT(*) = $%d)`, len(args)-1, len(args)))
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// Count returns the number of the user's todos outside the trash
+func (s *TodoStore) Count(ctx context.Context, userID int64) (int, error) {
+	query := `SELECT COUNT(*) FROM todos WHERE user_id = $1 AND deleted_at IS NULL`
+
+	var count int
+	if err := s.db.QueryRowContext(ctx, query, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count todos: %w", err)
+	}
+
+	return count, nil
+}
+
+// CountLocked locks the user's row until the transaction ends, then counts
+// their todos outside the trash. The count is a statement of its own so it
+// sees the todos committed by whoever held the lock before
+func (s *TodoStore) CountLocked(ctx context.Context, userID int64) (int, error) {
+	query := `SELECT id FROM users WHERE id = $1 FOR UPDATE`
+
+	if _, err := s.db.ExecContext(ctx, query, userID); err != nil {
+		return 0, fmt.Errorf("failed to lock user: %w", err)
+	}
+
+	return s.Count(ctx, userID)
+}
+
+// ListChildren returns the direct sub-tasks of a todo, oldest first
+func (s *TodoStore) ListChildren(ctx context.Context, userID, parentID int64) ([]*models.Todo, error) {
+	query := `
+		SELECT ` + todoColumns + `
+		FROM todos
+		WHERE user_id = $1 AND parent_id = $2 AND deleted_at IS NULL
+		ORDER BY created_at ASC, id ASC`
+
+	rows, err := s.db.QueryContext(ctx, query, userID, parentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sub-tasks of todo %d: %w", parentID, err)
+	}
+	defer rows.Close()
+
+	todos := make([]*models.Todo, 0)
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan todo: %w", err)
+		}
+		todos = append(todos, todo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate sub-tasks: %w", err)
+	}
+
+	if err := s.loadTags(ctx, todos); err != nil {
+		return nil, err
+	}
+
+	return todos, nil
+}
+
+// Ancestors returns the ids of the todo's parent, grandparent and so on
+func (s *TodoStore) Ancestors(ctx context.Context, userID, id int64) ([]int64, error) {
+	// UNION rather than UNION ALL so the walk terminates even on a cycle
+	query := `
+		WITH RECURSIVE ancestors AS (
+			SELECT parent_id FROM todos WHERE id = $1 AND user_id = $2
+			UNION
+			SELECT t.parent_id FROM todos t JOIN ancestors a ON t.id = a.parent_id
+			WHERE t.user_id = $2
+		)
+		SELECT parent_id FROM ancestors WHERE parent_id IS NOT NULL`
+
+	rows, err := s.db.QueryContext(ctx, query, id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ancestors of todo %d: %w", id, err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var parentID int64
+		if err := rows.Scan(&parentID); err != nil {
+			return nil, fmt.Errorf("failed to scan ancestor: %w", err)
+		}
+		ids = append(ids, parentID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate ancestors: %w", err)
+	}
+
+	return ids, nil
+}
+
+// Update persists the mutable fields of the todo but its reminder, a changed
+// or cleared due date clears its due date reminder
+func (s *TodoStore) Update(ctx context.Context, todo *models.Todo) error {
+	query := `
+		UPDATE todos
+		SET parent_id = $1, title = $2, description = $3, completed = $4, due_date = $5, recurrence = $6,
+			priority = $7,
+			reminded_at = CASE WHEN due_date IS DISTINCT FROM $5 THEN NULL ELSE reminded_at END,
+			updated_at = NOW()
+		WHERE id = $8 AND user_id = $9 AND deleted_at IS NULL
+		RETURNING created_at, updated_at`
+
+	err := s.db.QueryRowContext(ctx, query, todo.ParentID, todo.Title, todo.Description, todo.Completed,
+		todo.DueDate, todo.Recurrence, todo.Priority, todo.ID, todo.UserID).
+		Scan(&todo.CreatedAt, &todo.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.ErrNotFound
+		}
+		return fmt.Errorf("failed to update todo %d: %w", todo.ID, err)
+	}
+
+	return nil
+}
+
+// Delete moves the todo with the given id owned by the user to the trash
+// along with its live sub-tasks, all sharing the same deletion time so they
+// are restored together
+func (s *TodoStore) Delete(ctx context.Context, userID, id int64) error {
+	query := `
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM todos WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+			UNION
+			SELECT t.id FROM todos t JOIN subtree st ON t.parent_id = st.id
+			WHERE t.deleted_at IS NULL
+		)
+		UPDATE todos SET deleted_at = NOW()
+		WHERE id IN (SELECT id FROM subtree)`
+
+	result, err := s.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete todo %d: %w", id, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete todo %d: %w", id, err)
+	}
+
+	if affected == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanTodo scans the todoColumns of a row, extra receives any columns
+// selected after them
+func scanTodo(row rowScanner, extra ...any) (*models.Todo, error) {
+	var todo models.Todo
+	dest := []any{
+		&todo.ID,
+		&todo.UserID,
+		&todo.ParentID,
+		&todo.Title,
+		&todo.Description,
+		&todo.Completed,
+		&todo.DueDate,
+		&todo.Recurrence,
+		&todo.RemindAt,
+		&todo.Priority,
+		&todo.CreatedAt,
+		&todo.UpdatedAt,
+		&todo.DeletedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
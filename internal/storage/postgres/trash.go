@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+// ListDeleted returns a page of the user's trashed todos, most recently
+// deleted first, along with the number of todos in the trash
+func (s *TodoStore) ListDeleted(ctx context.Context, userID int64, limit, offset int) ([]*models.Todo, int, error) {
+	var total int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM todos WHERE user_id = $1 AND deleted_at IS NOT NULL`, userID).
+		Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count trashed todos: %w", err)
+	}
+
+	query := `
+		SELECT ` + todoColumns + `
+		FROM todos
+		WHERE user_id = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id DESC
+		LIMIT $2 OFFSET $3`
+
+	rows, err := s.db.QueryContext(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list trashed todos: %w", err)
+	}
+	defer rows.Close()
+
+	todos := make([]*models.Todo, 0, limit)
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan todo: %w", err)
+		}
+		todos = append(todos, todo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate trashed todos: %w", err)
+	}
+
+	if err := s.loadTags(ctx, todos); err != nil {
+		return nil, 0, err
+	}
+
+	return todos, total, nil
+}
+
+// Restore takes a todo and the sub-tasks deleted along with it out of the
+// trash. Sub-tasks that were trashed on their own earlier stay in the trash
+func (s *TodoStore) Restore(ctx context.Context, userID, id int64) (*models.Todo, error) {
+	query := `
+		WITH RECURSIVE subtree AS (
+			SELECT id, deleted_at FROM todos WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL
+			UNION
+			SELECT t.id, t.deleted_at FROM todos t JOIN subtree st ON t.parent_id = st.id
+			WHERE t.deleted_at = st.deleted_at
+		)
+		UPDATE todos SET deleted_at = NULL, updated_at = NOW()
+		WHERE id IN (SELECT id FROM subtree)`
+
+	result, err := s.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore todo %d: %w", id, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore todo %d: %w", id, err)
+	}
+
+	if affected == 0 {
+		return nil, storage.ErrNotFound
+	}
+
+	return s.GetByID(ctx, userID, id)
+}
+
+// PurgeDeleted permanently removes every todo trashed before the cutoff
+func (s *TodoStore) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM todos WHERE deleted_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge trashed todos: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge trashed todos: %w", err)
+	}
+
+	return purged, nil
+}
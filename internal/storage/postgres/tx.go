@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/retry"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+)
+
+// Querier is the subset of *sql.DB and *sql.Tx used by the stores, so the
+// same store code runs inside or outside a transaction
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// WithTx runs fn inside a transaction. The transaction is committed when fn
+// returns nil and rolled back when it returns an error or panics; panics are
+// re-raised after the rollback. It runs on conn, which lets COPY join it.
+// The statements of a context marked with an operation that has its own
+// timeout run with that timeout.
+//
+// A transaction aborted by a serialization failure or deadlock is run again
+// from the start, as is one that could not begin because the connection
+// failed, so fn must only change the database
+func (s *Store) WithTx(ctx context.Context, fn func(conn *sql.Conn, tx *sql.Tx) error) error {
+	return s.retrier.Do(ctx, func(ctx context.Context) error {
+		return s.withTx(ctx, fn)
+	})
+}
+
+// withTx runs fn inside a single transaction. Once the transaction has begun
+// only conflicts with concurrent transactions are retried, a connection lost
+// during a commit may have committed it
+func (s *Store) withTx(ctx context.Context, fn func(conn *sql.Conn, tx *sql.Tx) error) error {
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	var tx *sql.Tx
+	conn, err := s.db.Conn(ctx)
+	if err == nil {
+		if tx, err = conn.BeginTx(ctx, nil); err != nil {
+			conn.Close()
+		}
+	}
+	s.breaker.Done(err)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer conn.Close()
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := s.setStatementTimeout(ctx, tx); err != nil {
+		tx.Rollback()
+		return retryOnConflict(err)
+	}
+	if err := fn(conn, tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			s.logger.Error("failed to roll back transaction", "error", rbErr)
+		}
+		return retryOnConflict(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return retryOnConflict(fmt.Errorf("failed to commit transaction: %w", err))
+	}
+	return nil
+}
+
+// retryOnConflict marks err permanent unless the transaction was aborted by
+// a conflict with a concurrent one
+func retryOnConflict(err error) error {
+	if code, ok := errorCode(err); ok && isConflict(code) {
+		return err
+	}
+	return retry.Permanent(err)
+}
+
+// WithTx returns a todo store that runs its queries in tx, begun on conn
+func (s *TodoStore) WithTx(conn *sql.Conn, tx *sql.Tx) *TodoStore {
+	store := newTodoStore(newInstrumentedDB(tx, s.store), s.store)
+	store.conn = conn
+	return store
+}
+
+// InTx runs fn with a todo store bound to a new transaction
+func (s *TodoStore) InTx(ctx context.Context, fn func(repo storage.TodoRepository) error) error {
+	return s.store.WithTx(ctx, func(conn *sql.Conn, tx *sql.Tx) error {
+		return fn(s.WithTx(conn, tx))
+	})
+}
+
+// WithTx returns an auth store that runs its queries in tx
+func (s *AuthStore) WithTx(tx *sql.Tx) *AuthStore {
+	return NewAuthStore(newInstrumentedDB(tx, s.store), s.store)
+}
+
+// InTx runs fn with an auth store bound to a new transaction
+func (s *AuthStore) InTx(ctx context.Context, fn func(repo storage.AuthRepository) error) error {
+	return s.store.WithTx(ctx, func(_ *sql.Conn, tx *sql.Tx) error {
+		return fn(s.WithTx(tx))
+	})
+}
+
+// setStatementTimeout overrides the statement timeout of the connection for
+// the rest of tx when ctx runs an operation configured with its own
+func (s *Store) setStatementTimeout(ctx context.Context, tx *sql.Tx) error {
+	timeout, ok := s.config.OperationTimeouts[storage.OperationFromContext(ctx)]
+	if !ok {
+		return nil
+	}
+
+	// SET LOCAL takes no parameters, set_config is its equivalent
+	_, err := tx.ExecContext(ctx, "SELECT set_config('statement_timeout', $1, true)",
+		strconv.FormatInt(timeout.Milliseconds(), 10))
+	if err != nil {
+		return fmt.Errorf("failed to set statement timeout: %w", err)
+	}
+	return nil
+}
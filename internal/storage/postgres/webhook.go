@@ -0,0 +1,346 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/encryption"
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/MuthuM3/gin-microservice-template/internal/storage"
+	"github.com/lib/pq"
+)
+
+type WebhookStore struct {
+	db      Querier
+	keyring *encryption.Keyring
+}
+
+func newWebhookStore(db Querier, keyring *encryption.Keyring) *WebhookStore {
+	return &WebhookStore{db: db, keyring: keyring}
+}
+
+const (
+	webhookColumns  = "id, user_id, url, secret, events, active, created_at, updated_at"
+	deliveryColumns = "id, webhook_id, user_id, event_id, event_type, payload, status, attempts, response_status, " +
+		"last_error, redelivery_of, next_attempt_at, created_at, delivered_at"
+)
+
+// CreateWebhook inserts a new webhook
+func (s *WebhookStore) CreateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	query := `
+		INSERT INTO webhooks (user_id, url, secret, events, active)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at`
+
+	secret, err := s.keyring.Encrypt(webhook.Secret)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt webhook secret: %w", err)
+	}
+	err = s.db.QueryRowContext(ctx, query, webhook.UserID, webhook.URL, secret,
+		pq.Array(nonNilEvents(webhook.Events)), webhook.Active).
+		Scan(&webhook.ID, &webhook.CreatedAt, &webhook.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	return nil
+}
+
+// GetWebhook returns a webhook of the user
+func (s *WebhookStore) GetWebhook(ctx context.Context, userID, id int64) (*models.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = $1 AND user_id = $2`
+
+	webhook, err := scanWebhook(s.db.QueryRowContext(ctx, query, id, userID), s.keyring)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook %d: %w", id, err)
+	}
+
+	return webhook, nil
+}
+
+// ListWebhooks returns the user's webhooks, oldest first
+func (s *WebhookStore) ListWebhooks(ctx context.Context, userID int64) ([]*models.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE user_id = $1 ORDER BY id`
+	return s.queryWebhooks(ctx, query, userID)
+}
+
+// ListWebhooksForEvent returns the user's active webhooks subscribed to the
+// event type
+func (s *WebhookStore) ListWebhooksForEvent(ctx context.Context, userID int64, eventType string) ([]*models.Webhook, error) {
+	query := `
+		SELECT ` + webhookColumns + `
+		FROM webhooks
+		WHERE user_id = $1 AND active AND (cardinality(events) = 0 OR $2 = ANY(events))
+		ORDER BY id`
+
+	return s.queryWebhooks(ctx, query, userID, eventType)
+}
+
+// UpdateWebhook saves the url, secret, events and active flag of a webhook
+func (s *WebhookStore) UpdateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	query := `
+		UPDATE webhooks
+		SET url = $3, secret = $4, events = $5, active = $6, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2
+		RETURNING created_at, updated_at`
+
+	secret, err := s.keyring.Encrypt(webhook.Secret)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt webhook secret: %w", err)
+	}
+	err = s.db.QueryRowContext(ctx, query, webhook.ID, webhook.UserID, webhook.URL, secret,
+		pq.Array(nonNilEvents(webhook.Events)), webhook.Active).
+		Scan(&webhook.CreatedAt, &webhook.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.ErrNotFound
+		}
+		return fmt.Errorf("failed to update webhook %d: %w", webhook.ID, err)
+	}
+
+	return nil
+}
+
+// DeleteWebhook removes a webhook, its deliveries are removed by the
+// foreign key
+func (s *WebhookStore) DeleteWebhook(ctx context.Context, userID, id int64) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook %d: %w", id, err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook %d: %w", id, err)
+	}
+	if deleted == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// CreateDelivery adds a delivery unless the event was already delivered to
+// the webhook
+func (s *WebhookStore) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) (bool, error) {
+	query := `
+		INSERT INTO webhook_deliveries (webhook_id, user_id, event_id, event_type, payload, status, redelivery_of, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (webhook_id, event_id) WHERE redelivery_of IS NULL DO NOTHING
+		RETURNING id, created_at`
+
+	err := s.db.QueryRowContext(ctx, query, delivery.WebhookID, delivery.UserID, delivery.EventID, delivery.EventType,
+		[]byte(delivery.Payload), delivery.Status, delivery.RedeliveryOf, delivery.NextAttemptAt).
+		Scan(&delivery.ID, &delivery.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		if isForeignKeyViolation(err) {
+			return false, storage.ErrNotFound
+		}
+		return false, fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+
+	return true, nil
+}
+
+// GetDelivery returns a delivery of one of the user's webhooks
+func (s *WebhookStore) GetDelivery(ctx context.Context, userID, webhookID, id int64) (*models.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE id = $1 AND webhook_id = $2 AND user_id = $3`
+
+	delivery, err := scanDelivery(s.db.QueryRowContext(ctx, query, id, webhookID, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook delivery %d: %w", id, err)
+	}
+
+	return delivery, nil
+}
+
+// ListDeliveries returns a page of the deliveries of a webhook, newest first
+func (s *WebhookStore) ListDeliveries(ctx context.Context, userID, webhookID int64, limit, offset int) ([]*models.WebhookDelivery, int, error) {
+	if _, err := s.GetWebhook(ctx, userID, webhookID); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM webhook_deliveries WHERE webhook_id = $1`, webhookID).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count webhook deliveries: %w", err)
+	}
+
+	query := `
+		SELECT ` + deliveryColumns + `
+		FROM webhook_deliveries
+		WHERE webhook_id = $1
+		ORDER BY id DESC
+		LIMIT $2 OFFSET $3`
+
+	deliveries, err := s.queryDeliveries(ctx, query, webhookID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
+
+// DueDeliveries returns pending deliveries whose next attempt is due
+func (s *WebhookStore) DueDeliveries(ctx context.Context, now time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	query := `
+		SELECT ` + deliveryColumns + `
+		FROM webhook_deliveries
+		WHERE status = $1 AND next_attempt_at <= $2
+		ORDER BY next_attempt_at, id
+		LIMIT $3`
+
+	return s.queryDeliveries(ctx, query, models.DeliveryPending, now, limit)
+}
+
+// UpdateDelivery saves the outcome of a delivery attempt
+func (s *WebhookStore) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = $3, response_status = $4, last_error = $5, next_attempt_at = $6, delivered_at = $7
+		WHERE id = $1`
+
+	result, err := s.db.ExecContext(ctx, query, delivery.ID, delivery.Status, delivery.Attempts,
+		delivery.ResponseStatus, delivery.LastError, delivery.NextAttemptAt, delivery.DeliveredAt)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery %d: %w", delivery.ID, err)
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery %d: %w", delivery.ID, err)
+	}
+	if updated == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// PurgeDeliveries removes final deliveries created before the cutoff
+func (s *WebhookStore) PurgeDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx,
+		`DELETE FROM webhook_deliveries WHERE status <> $1 AND created_at < $2`, models.DeliveryPending, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge webhook deliveries: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge webhook deliveries: %w", err)
+	}
+	return purged, nil
+}
+
+func (s *WebhookStore) queryWebhooks(ctx context.Context, query string, args ...any) ([]*models.Webhook, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := make([]*models.Webhook, 0)
+	for rows.Next() {
+		webhook, err := scanWebhook(rows, s.keyring)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+func (s *WebhookStore) queryDeliveries(ctx context.Context, query string, args ...any) ([]*models.WebhookDelivery, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]*models.WebhookDelivery, 0)
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+func scanWebhook(row rowScanner, keyring *encryption.Keyring) (*models.Webhook, error) {
+	var webhook models.Webhook
+	err := row.Scan(&webhook.ID, &webhook.UserID, &webhook.URL, &webhook.Secret, pq.Array(&webhook.Events),
+		&webhook.Active, &webhook.CreatedAt, &webhook.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if webhook.Secret, err = keyring.Decrypt(webhook.Secret); err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret of webhook %d: %w", webhook.ID, err)
+	}
+
+	webhook.Events = nonNilEvents(webhook.Events)
+	return &webhook, nil
+}
+
+func scanDelivery(row rowScanner) (*models.WebhookDelivery, error) {
+	var (
+		delivery       models.WebhookDelivery
+		payload        []byte
+		responseStatus sql.NullInt32
+		redeliveryOf   sql.NullInt64
+		nextAttemptAt  sql.NullTime
+		deliveredAt    sql.NullTime
+	)
+	err := row.Scan(&delivery.ID, &delivery.WebhookID, &delivery.UserID, &delivery.EventID, &delivery.EventType,
+		&payload, &delivery.Status, &delivery.Attempts, &responseStatus, &delivery.LastError, &redeliveryOf,
+		&nextAttemptAt, &delivery.CreatedAt, &deliveredAt)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery.Payload = payload
+	if responseStatus.Valid {
+		status := int(responseStatus.Int32)
+		delivery.ResponseStatus = &status
+	}
+	if redeliveryOf.Valid {
+		delivery.RedeliveryOf = &redeliveryOf.Int64
+	}
+	if nextAttemptAt.Valid {
+		delivery.NextAttemptAt = &nextAttemptAt.Time
+	}
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.Time
+	}
+	return &delivery, nil
+}
+
+// nonNilEvents stores and returns no events as an empty array rather than NULL
+func nonNilEvents(events []string) []string {
+	if events == nil {
+		return []string{}
+	}
+	return events
+}
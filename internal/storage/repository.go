@@ -0,0 +1,473 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+)
+
+// TodoFilter narrows the todos returned by List, zero fields match everything.
+// Due date bounds are exclusive and never match todos without a due date
+type TodoFilter struct {
+	Completed *bool
+	Priority  string
+	DueBefore *time.Time
+	DueAfter  *time.Time
+
+	// Tags only matches todos carrying every one of the named tags
+	Tags []string
+}
+
+// Cursor is a position in the newest-first todo ordering
+type Cursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+// PageRequest selects a keyset page. Without a cursor the page starts at the
+// newest todo, otherwise it holds the todos after the cursor, or the todos
+// before it when Backward is set
+type PageRequest struct {
+	Cursor   *Cursor
+	Backward bool
+	Limit    int
+}
+
+// TagRepository persists the user's tags and their links to todos. Todos
+// returned by the todo repository carry their tag names
+type TagRepository interface {
+	// CreateTag returns ErrConflict when the user already has a tag with the name
+	CreateTag(ctx context.Context, tag *models.Tag) error
+	GetTag(ctx context.Context, userID, id int64) (*models.Tag, error)
+
+	// ListTags returns the user's tags ordered by name
+	ListTags(ctx context.Context, userID int64) ([]*models.Tag, error)
+
+	// UpdateTag renames a tag, returning ErrConflict when the name is taken
+	UpdateTag(ctx context.Context, tag *models.Tag) error
+	DeleteTag(ctx context.Context, userID, id int64) error
+
+	// SetTodoTags replaces the tags of a todo, creating tags the user does not
+	// have yet
+	SetTodoTags(ctx context.Context, userID, todoID int64, names []string) error
+}
+
+// AttachmentRepository persists the metadata of todo attachments, their
+// contents are kept in blob storage
+type AttachmentRepository interface {
+	CreateAttachment(ctx context.Context, attachment *models.Attachment) error
+
+	// GetAttachment returns an attachment in any status
+	GetAttachment(ctx context.Context, userID, todoID, id int64) (*models.Attachment, error)
+
+	// GetUserAttachment returns an attachment in any status of any of the
+	// user's todos outside the trash
+	GetUserAttachment(ctx context.Context, userID, id int64) (*models.Attachment, error)
+
+	// ListAttachments returns the ready attachments of a todo, oldest first
+	ListAttachments(ctx context.Context, userID, todoID int64) ([]*models.Attachment, error)
+
+	// ListUserAttachments returns the ready attachments of all of the user's
+	// todos, including those in the trash, oldest first
+	ListUserAttachments(ctx context.Context, userID int64) ([]*models.Attachment, error)
+
+	// ConfirmAttachment marks a pending attachment ready with the size and
+	// type of its uploaded content, returning ErrNotFound when it is not pending
+	ConfirmAttachment(ctx context.Context, userID, id, size int64, contentType string) error
+	DeleteAttachment(ctx context.Context, userID, id int64) error
+
+	// ListOrphanedAttachments returns up to limit attachments whose blobs are
+	// to be removed: those of purged todos and uploads left pending since
+	// before pendingBefore
+	ListOrphanedAttachments(ctx context.Context, pendingBefore time.Time, limit int) ([]*models.Attachment, error)
+
+	// AttachmentBytes returns the total size of the user's attachments in any
+	// status, including those whose blobs await removal
+	AttachmentBytes(ctx context.Context, userID int64) (int64, error)
+}
+
+// OutboxRepository persists the transactional outbox. Messages are added in
+// the transaction of the change they describe and relayed after it commits
+type OutboxRepository interface {
+	AddOutboxMessage(ctx context.Context, msg *models.OutboxMessage) error
+
+	// PendingOutboxMessages returns up to limit unpublished messages, oldest
+	// first. Inside a transaction the messages stay locked until it ends and
+	// are skipped by concurrent relays
+	PendingOutboxMessages(ctx context.Context, limit int) ([]*models.OutboxMessage, error)
+	MarkOutboxPublished(ctx context.Context, id int64) error
+
+	// MarkOutboxFailed counts a failed delivery attempt, the message stays pending
+	MarkOutboxFailed(ctx context.Context, id int64, reason string) error
+
+	// PurgeOutbox removes messages published before the cutoff and returns
+	// how many were removed
+	PurgeOutbox(ctx context.Context, before time.Time) (int64, error)
+}
+
+// ShareRepository persists who todos are shared with. Shares are not
+// scoped to a user, the service checks who may see and change them
+type ShareRepository interface {
+	// CreateShare returns ErrConflict when the todo is already shared with
+	// the user
+	CreateShare(ctx context.Context, share *models.TodoShare) error
+	GetShare(ctx context.Context, todoID, userID int64) (*models.TodoShare, error)
+
+	// ListShares returns the shares of a todo, oldest first
+	ListShares(ctx context.Context, todoID int64) ([]*models.TodoShare, error)
+
+	// ListUserShares returns the shares with the user of todos outside the
+	// trash, newest first
+	ListUserShares(ctx context.Context, userID int64) ([]*models.TodoShare, error)
+
+	// UpdateShare persists the role and acceptance of a share
+	UpdateShare(ctx context.Context, share *models.TodoShare) error
+	DeleteShare(ctx context.Context, todoID, userID int64) error
+}
+
+// TodoRepository persists todos. Every method is scoped to the owning user
+// and returns ErrNotFound for todos that do not exist or belong to someone else
+type TodoRepository interface {
+	TagRepository
+	AttachmentRepository
+	OutboxRepository
+	ShareRepository
+
+	Create(ctx context.Context, todo *models.Todo) error
+	GetByID(ctx context.Context, userID, id int64) (*models.Todo, error)
+
+	// ImportTodos stores new top-level todos in bulk and returns how many
+	// were stored. Unlike Create, the generated fields may be left unset
+	ImportTodos(ctx context.Context, todos []*models.Todo) (int64, error)
+
+	// List returns a page of todos matching the filter, newest first
+	List(ctx context.Context, userID int64, filter TodoFilter, page PageRequest) ([]*models.Todo, error)
+
+	// Search returns a page of the todos matching a full-text query, best
+	// matches first, and the total number of matches
+	Search(ctx context.Context, userID int64, query string, limit, offset int) ([]*models.TodoSearchResult, int, error)
+
+	// Count returns the number of the user's todos outside the trash
+	Count(ctx context.Context, userID int64) (int, error)
+
+	// CountLocked is Count after locking the user until the transaction
+	// ends, so concurrent transactions of one user count one after another
+	CountLocked(ctx context.Context, userID int64) (int, error)
+
+	// ListChildren returns the direct sub-tasks of a todo, oldest first
+	ListChildren(ctx context.Context, userID, parentID int64) ([]*models.Todo, error)
+
+	// Ancestors returns the ids of every todo above the given one in its hierarchy
+	Ancestors(ctx context.Context, userID, id int64) ([]int64, error)
+	Update(ctx context.Context, todo *models.Todo) error
+
+	// Delete moves the todo together with its sub-tasks to the trash. Trashed
+	// todos are invisible to every other method until they are restored
+	Delete(ctx context.Context, userID, id int64) error
+
+	// ListDeleted returns a page of trashed todos, most recently deleted first,
+	// and the number of todos in the trash
+	ListDeleted(ctx context.Context, userID int64, limit, offset int) ([]*models.Todo, int, error)
+
+	// Restore takes a trashed todo and the sub-tasks deleted with it out of the trash
+	Restore(ctx context.Context, userID, id int64) (*models.Todo, error)
+
+	// PurgeDeleted permanently removes the todos of every user trashed before
+	// the cutoff and returns how many were removed
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
+
+	// DueReminders returns up to limit incomplete todos of every user outside
+	// the trash that are due between from and to and have not been reminded
+	// of, earliest due first
+	DueReminders(ctx context.Context, from, to time.Time, limit int) ([]*models.Todo, error)
+
+	// MarkReminded records that reminders of the todos were sent, changing
+	// or clearing the due date of a todo clears it
+	MarkReminded(ctx context.Context, ids []int64) error
+
+	// DueRecurrences returns up to limit repeating todos of every user
+	// outside the trash that are completed or were due before now, earliest
+	// due first
+	DueRecurrences(ctx context.Context, now time.Time, limit int) ([]*models.Todo, error)
+
+	// SetReminder sets or, with a nil remindAt, clears when the user wants to
+	// be reminded of the todo. The reminder is dispatched again afterwards
+	SetReminder(ctx context.Context, userID, id int64, remindAt *time.Time) error
+
+	// PendingReminders returns up to limit incomplete todos of every user
+	// outside the trash whose reminder is due by before and has not been
+	// dispatched, earliest first
+	PendingReminders(ctx context.Context, before time.Time, limit int) ([]*models.Todo, error)
+
+	// MarkRemindersDispatched records that the reminders of the todos were
+	// queued or sent
+	MarkRemindersDispatched(ctx context.Context, ids []int64) error
+
+	// InTx runs fn with a repository whose operations commit or roll back together
+	InTx(ctx context.Context, fn func(repo TodoRepository) error) error
+}
+
+// UserRepository persists user accounts. CreateUser returns ErrConflict when
+// the email is already registered, emails are compared case-insensitively
+type UserRepository interface {
+	CreateUser(ctx context.Context, user *models.User) error
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	GetUserByID(ctx context.Context, id int64) (*models.User, error)
+	UpdatePassword(ctx context.Context, userID int64, passwordHash string) error
+	SetRole(ctx context.Context, userID int64, role string) error
+
+	// MarkEmailVerified records the verification time, keeping the first one
+	MarkEmailVerified(ctx context.Context, userID int64) error
+
+	// ListUsers returns a page of users matching the filter ordered by id,
+	// and the number of matching users
+	ListUsers(ctx context.Context, filter UserFilter, limit, offset int) ([]*models.User, int, error)
+
+	// UpdateUser saves the email, name and external id of the user,
+	// returning ErrConflict when another user has the email
+	UpdateUser(ctx context.Context, user *models.User) error
+
+	// SetDeactivated deactivates or reactivates the user, deactivating again
+	// keeps the first time
+	SetDeactivated(ctx context.Context, userID int64, deactivated bool) error
+}
+
+// UserFilter narrows the users returned by ListUsers, empty fields match
+// everything. Emails are compared case-insensitively
+type UserFilter struct {
+	Email      string
+	ExternalID string
+}
+
+// ProfileUpdate holds the profile fields to change, nil fields are kept. An
+// AvatarID of 0 removes the avatar and Notifications, keyed by the JSON name
+// of a setting, are merged into the preferences stored before
+type ProfileUpdate struct {
+	Name          *string
+	Timezone      *string
+	AvatarID      *int64
+	Notifications map[string]bool
+}
+
+// ProfileRepository persists the details users manage about themselves
+type ProfileRepository interface {
+	GetProfile(ctx context.Context, userID int64) (*models.Profile, error)
+
+	// UpdateProfile applies a partial update and returns the updated profile
+	UpdateProfile(ctx context.Context, userID int64, update ProfileUpdate) (*models.Profile, error)
+}
+
+// DeletionRepository persists the accounts scheduled for erasure
+type DeletionRepository interface {
+	// ScheduleDeletion sets when the account is to be erased, returning
+	// ErrNotFound when the user does not exist
+	ScheduleDeletion(ctx context.Context, userID int64, at time.Time) error
+
+	// CancelDeletion returns ErrNotFound when no deletion is scheduled
+	CancelDeletion(ctx context.Context, userID int64) error
+
+	// DueDeletions returns the ids of up to limit users whose erasure is due
+	// at now, earliest first
+	DueDeletions(ctx context.Context, now time.Time, limit int) ([]int64, error)
+}
+
+// NotificationChannelRepository persists where users receive notifications
+type NotificationChannelRepository interface {
+	// ListNotificationChannels returns the user's channels ordered by name
+	ListNotificationChannels(ctx context.Context, userID int64) ([]*models.NotificationChannel, error)
+	GetNotificationChannel(ctx context.Context, userID int64, channel string) (*models.NotificationChannel, error)
+
+	// SetNotificationChannel creates or replaces a channel of the user,
+	// returning ErrNotFound when the user does not exist
+	SetNotificationChannel(ctx context.Context, channel *models.NotificationChannel) error
+	DeleteNotificationChannel(ctx context.Context, userID int64, channel string) error
+}
+
+// SessionRepository persists login sessions and their refresh tokens
+type SessionRepository interface {
+	CreateSession(ctx context.Context, session *models.Session) error
+	GetSession(ctx context.Context, id string) (*models.Session, error)
+	RevokeSession(ctx context.Context, id string) error
+
+	// ListSessions returns the active sessions of the user, most recently
+	// seen first
+	ListSessions(ctx context.Context, userID int64) ([]*models.Session, error)
+
+	// TouchSession records that the session was used now from the device
+	TouchSession(ctx context.Context, id, ipAddress, userAgent string) error
+
+	// RevokeOtherSessions revokes every active session of the user but keepID
+	// and returns how many were revoked
+	RevokeOtherSessions(ctx context.Context, userID int64, keepID string) (int64, error)
+
+	// IsRevoked treats unknown and expired sessions as revoked
+	IsRevoked(ctx context.Context, sessionID string) (bool, error)
+
+	CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error
+	GetRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+
+	// MarkRefreshTokenUsed reports false when the token was already consumed
+	MarkRefreshTokenUsed(ctx context.Context, tokenHash string) (bool, error)
+
+	// RevokeUserSessions revokes every active session of the user
+	RevokeUserSessions(ctx context.Context, userID int64) error
+}
+
+// PasswordResetRepository persists password reset tokens
+type PasswordResetRepository interface {
+	CreatePasswordReset(ctx context.Context, reset *models.PasswordReset) error
+
+	// ConsumePasswordReset marks an unused, unexpired token as used and
+	// returns it, other tokens yield ErrNotFound
+	ConsumePasswordReset(ctx context.Context, tokenHash string) (*models.PasswordReset, error)
+}
+
+// EmailVerificationRepository persists email verification tokens
+type EmailVerificationRepository interface {
+	CreateEmailVerification(ctx context.Context, verification *models.EmailVerification) error
+
+	// ConsumeEmailVerification marks an unused, unexpired token as used and
+	// returns it, other tokens yield ErrNotFound
+	ConsumeEmailVerification(ctx context.Context, tokenHash string) (*models.EmailVerification, error)
+}
+
+// IdentityRepository persists links to external identity providers.
+// CreateIdentity returns ErrConflict when the identity is already linked
+type IdentityRepository interface {
+	GetIdentity(ctx context.Context, provider, subject string) (*models.Identity, error)
+	CreateIdentity(ctx context.Context, identity *models.Identity) error
+}
+
+// AuthRepository combines users, profiles, scheduled deletions,
+// notification channels, sessions, external identities, password resets and
+// email verifications
+type AuthRepository interface {
+	UserRepository
+	ProfileRepository
+	DeletionRepository
+	NotificationChannelRepository
+	SessionRepository
+	IdentityRepository
+	PasswordResetRepository
+	EmailVerificationRepository
+
+	// PurgeExpired removes the sessions, refresh tokens, password resets and
+	// email verifications that expired before the cutoff and returns how
+	// many were removed
+	PurgeExpired(ctx context.Context, before time.Time) (int64, error)
+
+	// InTx runs fn with a repository whose operations commit or roll back together
+	InTx(ctx context.Context, fn func(repo AuthRepository) error) error
+}
+
+// AuditFilter narrows the events returned by ListAuditEvents, zero fields
+// match everything
+type AuditFilter struct {
+	UserID     *int64
+	Action     string
+	EntityType string
+	EntityID   string
+	Since      *time.Time
+	Until      *time.Time
+}
+
+// AuditRepository persists the audit trail, events are never updated
+type AuditRepository interface {
+	CreateAuditEvent(ctx context.Context, event *models.AuditEvent) error
+
+	// ListAuditEvents returns a page of events matching the filter, newest
+	// first, and the total number of matches
+	ListAuditEvents(ctx context.Context, filter AuditFilter, limit, offset int) ([]*models.AuditEvent, int, error)
+}
+
+// SecurityEventFilter narrows the events returned by ListSecurityEvents,
+// zero fields match everything
+type SecurityEventFilter struct {
+	UserID *int64
+	Type   string
+	Since  *time.Time
+}
+
+// SecurityEventRepository persists the log of authentication events
+type SecurityEventRepository interface {
+	CreateSecurityEvent(ctx context.Context, event *models.SecurityEvent) error
+
+	// ListSecurityEvents returns a page of events matching the filter,
+	// newest first, and the total number of matches
+	ListSecurityEvents(ctx context.Context, filter SecurityEventFilter, limit, offset int) ([]*models.SecurityEvent, int, error)
+
+	// PurgeSecurityEvents removes the events created before the cutoff and
+	// returns how many were removed
+	PurgeSecurityEvents(ctx context.Context, before time.Time) (int64, error)
+}
+
+// WebhookRepository persists webhooks and their deliveries. Webhook methods
+// are scoped to the owning user like the todo methods
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, webhook *models.Webhook) error
+	GetWebhook(ctx context.Context, userID, id int64) (*models.Webhook, error)
+
+	// ListWebhooks returns the user's webhooks, oldest first
+	ListWebhooks(ctx context.Context, userID int64) ([]*models.Webhook, error)
+
+	// ListWebhooksForEvent returns the user's active webhooks subscribed to
+	// the event type
+	ListWebhooksForEvent(ctx context.Context, userID int64, eventType string) ([]*models.Webhook, error)
+	UpdateWebhook(ctx context.Context, webhook *models.Webhook) error
+
+	// DeleteWebhook removes a webhook together with its deliveries
+	DeleteWebhook(ctx context.Context, userID, id int64) error
+
+	// CreateDelivery adds a delivery and reports whether it was added. An
+	// event already delivered to the webhook is skipped unless the delivery
+	// is a redelivery
+	CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) (bool, error)
+	GetDelivery(ctx context.Context, userID, webhookID, id int64) (*models.WebhookDelivery, error)
+
+	// ListDeliveries returns a page of the deliveries of a webhook, newest
+	// first, and their total number
+	ListDeliveries(ctx context.Context, userID, webhookID int64, limit, offset int) ([]*models.WebhookDelivery, int, error)
+
+	// DueDeliveries returns up to limit pending deliveries whose next
+	// attempt is due at now, earliest first
+	DueDeliveries(ctx context.Context, now time.Time, limit int) ([]*models.WebhookDelivery, error)
+
+	// UpdateDelivery saves the outcome of a delivery attempt
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+
+	// PurgeDeliveries removes final deliveries created before the cutoff and
+	// returns how many were removed
+	PurgeDeliveries(ctx context.Context, before time.Time) (int64, error)
+}
+
+// QuotaRepository persists the per-user overrides of the quota limits
+type QuotaRepository interface {
+	// GetQuotaOverride returns ErrNotFound when the user has no override
+	GetQuotaOverride(ctx context.Context, userID int64) (*models.QuotaOverride, error)
+
+	// SetQuotaOverride creates or replaces the override of a user, returning
+	// ErrNotFound when the user does not exist
+	SetQuotaOverride(ctx context.Context, override *models.QuotaOverride) error
+	DeleteQuotaOverride(ctx context.Context, userID int64) error
+}
+
+// Store is a storage backend providing the repositories
+type Store interface {
+	Todos() TodoRepository
+	Auth() AuthRepository
+	Audit() AuditRepository
+	SecurityEvents() SecurityEventRepository
+	Webhooks() WebhookRepository
+	Quotas() QuotaRepository
+
+	// EraseUser permanently removes a user whose scheduled deletion is due at
+	// now together with everything stored about them, returning ErrNotFound
+	// when the user does not exist or the deletion was cancelled. Attachments
+	// are left for the orphaned attachment purge to remove their contents
+	EraseUser(ctx context.Context, userID int64, now time.Time) error
+
+	// IsHealthy reports whether the backend is reachable
+	IsHealthy() bool
+	Close() error
+}
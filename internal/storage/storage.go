@@ -0,0 +1,11 @@
+package storage
+
+import "errors"
+
+var (
+	// ErrNotFound is returned when the requested record does not exist
+	ErrNotFound = errors.New("record not found")
+
+	// ErrConflict is returned when a record violates a uniqueness constraint
+	ErrConflict = errors.New("record already exists")
+)
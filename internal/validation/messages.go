@@ -0,0 +1,55 @@
+package validation
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/i18n"
+)
+
+// Localize renders the violations in the language of loc, grouped by field.
+// Message keys are validation.<rule>, see the locales of the i18n package
+func Localize(errs Errors, loc *i18n.Localizer) map[string][]string {
+	fields := make(map[string][]string, len(errs))
+	for _, v := range errs {
+		k := "validation." + key(v)
+		message := loc.T(k, "field", v.Field, "param", v.Param)
+		if message == k {
+			message = loc.T("validation.default", "field", v.Field, "param", v.Param)
+		}
+
+		if len(v.Details) > 0 {
+			message += ": " + strings.Join(v.Details, "; ")
+		}
+		fields[v.Field] = append(fields[v.Field], message)
+	}
+	return fields
+}
+
+// key returns the catalog key of a violation
+func key(v Violation) string {
+	if enums[v.Rule] != nil {
+		return "oneof"
+	}
+
+	rule := v.Rule
+	switch rule {
+	case "lte":
+		rule = "max"
+	case "gte":
+		rule = "min"
+	}
+
+	switch rule {
+	case "max", "min", "len":
+		switch v.Kind {
+		case reflect.String:
+			return rule + ".string"
+		case reflect.Slice, reflect.Array, reflect.Map:
+			return rule + ".items"
+		default:
+			return rule + ".number"
+		}
+	}
+	return rule
+}
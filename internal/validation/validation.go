@@ -0,0 +1,228 @@
+// Package validation validates bound request structs with declarative
+// `binding` tags. It wraps go-playground/validator with the rules of this
+// API and reports failures as per-field violations that are rendered in the
+// client's language
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/MuthuM3/gin-microservice-template/internal/auth"
+	"github.com/MuthuM3/gin-microservice-template/internal/config"
+	"github.com/MuthuM3/gin-microservice-template/internal/models"
+	"github.com/go-playground/validator/v10"
+)
+
+// enums are the enum rules, the tag is the rule name and the field must
+// hold one of the values
+var enums = map[string][]string{
+	"priority": {models.PriorityLow, models.PriorityMedium, models.PriorityHigh},
+}
+
+// Violation is a failed rule of a single field. Field is the JSON or form
+// name of the field, Param the rule's parameter such as the limit of max and
+// Details any further explanation, e.g. the unmet password requirements
+type Violation struct {
+	Field   string
+	Rule    string
+	Param   string
+	Kind    reflect.Kind
+	Details []string
+}
+
+// Errors is returned when a bound struct fails validation
+type Errors []Violation
+
+func (e Errors) Error() string {
+	fields := make([]string, len(e))
+	for i, v := range e {
+		fields[i] = v.Field + " (" + v.Rule + ")"
+	}
+	return "validation failed: " + strings.Join(fields, ", ")
+}
+
+// FromBindError returns the violations behind an error from binding a
+// request: failed rules and JSON values of the wrong type
+func FromBindError(err error) (Errors, bool) {
+	var errs Errors
+	if errors.As(err, &errs) {
+		return errs, true
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return Errors{{Field: typeErr.Field, Rule: "type", Param: jsonType(typeErr.Type)}}, true
+	}
+	return nil, false
+}
+
+// Validator is a gin binding.StructValidator, install it as
+// binding.Validator before the router is built
+type Validator struct {
+	validate *validator.Validate
+	security *config.SecurityConfig
+}
+
+// New creates the validator with the custom rules:
+//   - password checks the password policy of security
+//   - rfc3339 requires a string holding an RFC 3339 timestamp
+//   - priority and the other enums require one of the enum's values
+func New(security *config.SecurityConfig) (*Validator, error) {
+	v := &Validator{
+		validate: validator.New(validator.WithRequiredStructEnabled()),
+		security: security,
+	}
+	v.validate.SetTagName("binding")
+	v.validate.RegisterTagNameFunc(fieldName)
+
+	rules := map[string]validator.Func{
+		"password": v.password,
+		"rfc3339":  rfc3339,
+	}
+	for name, values := range enums {
+		rules[name] = oneOf(values)
+	}
+	for tag, rule := range rules {
+		if err := v.validate.RegisterValidation(tag, rule); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// ValidateStruct validates a struct, a pointer to one or a slice of them
+func (v *Validator) ValidateStruct(obj any) error {
+	if obj == nil {
+		return nil
+	}
+
+	value := reflect.ValueOf(obj)
+	switch value.Kind() {
+	case reflect.Pointer:
+		if value.IsNil() {
+			return nil
+		}
+		return v.ValidateStruct(value.Elem().Interface())
+	case reflect.Struct:
+		return v.convert(v.validate.Struct(obj))
+	case reflect.Slice, reflect.Array:
+		var all Errors
+		for i := range value.Len() {
+			err := v.ValidateStruct(value.Index(i).Interface())
+			var errs Errors
+			if errors.As(err, &errs) {
+				all = append(all, errs...)
+			} else if err != nil {
+				return err
+			}
+		}
+		if len(all) > 0 {
+			return all
+		}
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Engine returns the underlying validator so more rules can be registered
+func (v *Validator) Engine() any {
+	return v.validate
+}
+
+// convert turns validator errors into Errors, anything else is returned as is
+func (v *Validator) convert(err error) error {
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return err
+	}
+
+	violations := make(Errors, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		violation := Violation{
+			Field: fieldPath(fe.Namespace()),
+			Rule:  fe.Tag(),
+			Param: fe.Param(),
+			Kind:  fe.Kind(),
+		}
+		switch {
+		case fe.Tag() == "password":
+			password, _ := fe.Value().(string)
+			violation.Details = auth.ValidatePassword(v.security, password)
+		case enums[fe.Tag()] != nil:
+			violation.Param = strings.Join(enums[fe.Tag()], " ")
+		}
+		violations = append(violations, violation)
+	}
+	return violations
+}
+
+func (v *Validator) password(fl validator.FieldLevel) bool {
+	return len(auth.ValidatePassword(v.security, fl.Field().String())) == 0
+}
+
+func rfc3339(fl validator.FieldLevel) bool {
+	_, err := time.Parse(time.RFC3339, fl.Field().String())
+	return err == nil
+}
+
+func oneOf(values []string) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		value := fl.Field().String()
+		for _, allowed := range values {
+			if value == allowed {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// fieldName reports fields by the name clients use, the JSON name for
+// bodies and the form name for query strings
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// fieldPath strips the struct name from a namespace such as
+// "todoRequest.tags[0]"
+func fieldPath(namespace string) string {
+	if _, path, ok := strings.Cut(namespace, "."); ok {
+		return path
+	}
+	return namespace
+}
+
+// jsonType names the JSON type a Go type is decoded from
+func jsonType(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "object"
+	}
+}
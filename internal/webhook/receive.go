@@ -0,0 +1,194 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidSignature is returned for inbound requests whose signature is
+	// missing, malformed, stale or does not match the body
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+
+	// ErrInvalidPayload is returned for signed bodies that are not JSON
+	ErrInvalidPayload = errors.New("invalid webhook payload")
+)
+
+// Event is an inbound webhook whose signature was verified. ID and Type are
+// empty when the provider does not send them
+type Event struct {
+	Provider   string          `json:"provider"`
+	ID         string          `json:"id,omitempty"`
+	Type       string          `json:"type,omitempty"`
+	Payload    json.RawMessage `json:"payload"`
+	ReceivedAt time.Time       `json:"received_at"`
+}
+
+// Handler processes the events of a provider. Returning an error retries the
+// event, wrap queue.ErrSkipRetry to give up on it
+type Handler func(ctx context.Context, event *Event) error
+
+// Verifier authenticates the requests of a provider and extracts their event
+type Verifier interface {
+	Verify(header http.Header, body []byte) (*Event, error)
+}
+
+// GitHubVerifier checks the X-Hub-Signature-256 header of GitHub deliveries
+type GitHubVerifier struct {
+	secret []byte
+}
+
+func NewGitHubVerifier(secret string) *GitHubVerifier {
+	return &GitHubVerifier{secret: []byte(secret)}
+}
+
+// Verify accepts JSON deliveries as well as form encoded ones, whose JSON is
+// in the payload field
+func (v *GitHubVerifier) Verify(header http.Header, body []byte) (*Event, error) {
+	signature, ok := strings.CutPrefix(header.Get("X-Hub-Signature-256"), "sha256=")
+	if !ok || !validMAC(v.secret, signature, body) {
+		return nil, ErrInvalidSignature
+	}
+
+	payload := body
+	if mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type")); mediaType == "application/x-www-form-urlencoded" {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+		}
+		payload = []byte(form.Get("payload"))
+	}
+	if !json.Valid(payload) {
+		return nil, ErrInvalidPayload
+	}
+
+	return &Event{
+		ID:      header.Get("X-GitHub-Delivery"),
+		Type:    header.Get("X-GitHub-Event"),
+		Payload: payload,
+	}, nil
+}
+
+// StripeVerifier checks the Stripe-Signature header of Stripe events,
+// refusing signatures made more than tolerance ago
+type StripeVerifier struct {
+	secret    []byte
+	tolerance time.Duration
+}
+
+func NewStripeVerifier(secret string, tolerance time.Duration) *StripeVerifier {
+	return &StripeVerifier{secret: []byte(secret), tolerance: tolerance}
+}
+
+// Verify accepts the request when any of its v1 signatures matches, Stripe
+// sends several while a secret is being rolled
+func (v *StripeVerifier) Verify(header http.Header, body []byte) (*Event, error) {
+	var (
+		timestamp  string
+		signatures []string
+	)
+	for part := range strings.SplitSeq(header.Get("Stripe-Signature"), ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+	if age := time.Since(time.Unix(seconds, 0)); age > v.tolerance || age < -v.tolerance {
+		return nil, fmt.Errorf("%w: timestamp outside the tolerance", ErrInvalidSignature)
+	}
+
+	signed := append([]byte(timestamp+"."), body...)
+	valid := false
+	for _, signature := range signatures {
+		if validMAC(v.secret, signature, signed) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return nil, ErrInvalidSignature
+	}
+
+	var envelope struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+
+	return &Event{ID: envelope.ID, Type: envelope.Type, Payload: body}, nil
+}
+
+// HMACVerifier checks a hex encoded HMAC-SHA256 of the body sent in a
+// configurable header, the scheme used by most other providers
+type HMACVerifier struct {
+	secret          []byte
+	signatureHeader string
+	prefix          string
+	eventHeader     string
+	idHeader        string
+}
+
+// NewHMACVerifier creates a verifier reading the signature from
+// signatureHeader after prefix, and the event type and id from eventHeader
+// and idHeader when they are not empty
+func NewHMACVerifier(secret, signatureHeader, prefix, eventHeader, idHeader string) *HMACVerifier {
+	return &HMACVerifier{
+		secret:          []byte(secret),
+		signatureHeader: signatureHeader,
+		prefix:          prefix,
+		eventHeader:     eventHeader,
+		idHeader:        idHeader,
+	}
+}
+
+func (v *HMACVerifier) Verify(header http.Header, body []byte) (*Event, error) {
+	signature, ok := strings.CutPrefix(header.Get(v.signatureHeader), v.prefix)
+	if !ok || !validMAC(v.secret, signature, body) {
+		return nil, ErrInvalidSignature
+	}
+	if !json.Valid(body) {
+		return nil, ErrInvalidPayload
+	}
+
+	event := &Event{Payload: body}
+	if v.eventHeader != "" {
+		event.Type = header.Get(v.eventHeader)
+	}
+	if v.idHeader != "" {
+		event.ID = header.Get(v.idHeader)
+	}
+	return event, nil
+}
+
+// validMAC compares the hex signature with the HMAC-SHA256 of message in
+// constant time
+func validMAC(secret []byte, signature string, message []byte) bool {
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(message)
+	return hmac.Equal(got, mac.Sum(nil))
+}
@@ -0,0 +1,141 @@
+// Package webhook signs and sends the HTTP requests delivering events to the
+// URLs registered by users, and verifies the signatures of webhooks received
+// from third parties. Receivers of outbound deliveries verify them by
+// recomputing the signature over the timestamp and body with their secret:
+//
+//	X-Webhook-Signature: t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">
+//
+// and should reject timestamps too far in the past to prevent replays
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Headers sent with every delivery
+const (
+	HeaderSignature = "X-Webhook-Signature"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+)
+
+// ErrForbiddenAddress is returned for URLs resolving to an address webhooks
+// may not reach
+var ErrForbiddenAddress = errors.New("webhook address is not allowed")
+
+// Sign returns the signature header value of body sent at t
+func Sign(secret string, t time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Request is one delivery attempt. The delivery header is left out when
+// DeliveryID is zero
+type Request struct {
+	URL        string
+	Secret     string
+	EventType  string
+	DeliveryID int64
+	Body       []byte
+}
+
+// Result is the outcome of a delivery attempt. StatusCode is zero when no
+// response was received
+type Result struct {
+	StatusCode int
+	Err        error
+}
+
+// Sender posts deliveries
+type Sender struct {
+	client    *http.Client
+	userAgent string
+}
+
+// NewSender creates a sender whose requests time out after timeout. Unless
+// allowPrivate is set, connections to loopback, private, link-local and
+// unspecified addresses are refused after DNS resolution, so webhooks cannot
+// reach internal services
+func NewSender(timeout time.Duration, allowPrivate bool, userAgent string) *Sender {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	// A proxy would hide the address actually connected to
+	transport.Proxy = nil
+
+	return &Sender{
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   timeout,
+			// Redirects are not followed, receivers answer the URL they registered
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		userAgent: userAgent,
+	}
+}
+
+// Send posts the delivery. Only 2xx responses count as delivered, the
+// response body is discarded
+func (s *Sender) Send(ctx context.Context, delivery Request) Result {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Body))
+	if err != nil {
+		return Result{Err: fmt.Errorf("invalid webhook url: %w", err)}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", s.userAgent)
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	if delivery.DeliveryID != 0 {
+		req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.DeliveryID, 10))
+	}
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, time.Now(), delivery.Body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return Result{Err: err}
+	}
+	defer resp.Body.Close()
+	// Drain a little so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return Result{StatusCode: resp.StatusCode, Err: fmt.Errorf("webhook responded with %s", resp.Status)}
+	}
+	return Result{StatusCode: resp.StatusCode}
+}
+
+func publicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified()
+}
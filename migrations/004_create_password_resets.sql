@@ -0,0 +1,10 @@
+-- Single-use password reset tokens, only the SHA-256 hash of a token is stored
+CREATE TABLE IF NOT EXISTS password_resets (
+    token_hash  VARCHAR(64) PRIMARY KEY,
+    user_id     BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    expires_at  TIMESTAMPTZ NOT NULL,
+    used_at     TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_resets_user_id ON password_resets (user_id);
@@ -0,0 +1,13 @@
+-- External identities (OAuth / OIDC) linked to local users. Users created
+-- through social login have an empty password hash and cannot log in with
+-- a password until they reset it
+CREATE TABLE IF NOT EXISTS user_identities (
+    provider    VARCHAR(64) NOT NULL,
+    subject     VARCHAR(255) NOT NULL,
+    user_id     BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    email       VARCHAR(255) NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (provider, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities (user_id);
@@ -0,0 +1,4 @@
+-- Sub-tasks, deleting a todo removes its whole subtree
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS parent_id BIGINT REFERENCES todos (id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_todos_user_id_parent_id ON todos (user_id, parent_id);
@@ -0,0 +1,7 @@
+-- Due dates and priorities, the composite indexes back the list filters
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS due_date TIMESTAMPTZ;
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS priority VARCHAR(10) NOT NULL DEFAULT 'medium'
+    CHECK (priority IN ('low', 'medium', 'high'));
+
+CREATE INDEX IF NOT EXISTS idx_todos_user_id_completed_due_date ON todos (user_id, completed, due_date);
+CREATE INDEX IF NOT EXISTS idx_todos_user_id_priority_created_at ON todos (user_id, priority, created_at DESC);
@@ -0,0 +1,5 @@
+-- Soft delete, trashed todos keep their row until the purge job removes them
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_todos_user_id_deleted_at ON todos (user_id, deleted_at DESC)
+    WHERE deleted_at IS NOT NULL;
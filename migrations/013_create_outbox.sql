@@ -0,0 +1,19 @@
+-- Transactional outbox, events are written in the transaction of the change
+-- they describe and published by the relay once it has committed.
+-- idempotency_key travels with the event so consumers can drop redeliveries
+CREATE TABLE IF NOT EXISTS outbox (
+    id              BIGSERIAL PRIMARY KEY,
+    idempotency_key VARCHAR(64) NOT NULL UNIQUE,
+    topic           VARCHAR(64) NOT NULL,
+    event_type      VARCHAR(64) NOT NULL,
+    user_id         BIGINT NOT NULL,
+    entity_id       BIGINT NOT NULL,
+    payload         JSONB NOT NULL,
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    last_error      TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    published_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox (id) WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_published_at ON outbox (published_at) WHERE published_at IS NOT NULL;
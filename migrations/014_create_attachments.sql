@@ -0,0 +1,17 @@
+-- Metadata of files attached to todos, their contents are kept in blob
+-- storage under storage_key. Purging a todo leaves its attachments with a
+-- NULL todo_id until the purge job has removed their blobs, user_id has no
+-- foreign key for the same reason
+CREATE TABLE IF NOT EXISTS attachments (
+    id           BIGSERIAL PRIMARY KEY,
+    todo_id      BIGINT REFERENCES todos (id) ON DELETE SET NULL,
+    user_id      BIGINT NOT NULL,
+    filename     VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    size         BIGINT NOT NULL,
+    storage_key  VARCHAR(512) NOT NULL UNIQUE,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_attachments_todo_id ON attachments (todo_id);
+CREATE INDEX IF NOT EXISTS idx_attachments_orphaned ON attachments (id) WHERE todo_id IS NULL;
@@ -0,0 +1,6 @@
+-- Attachments uploaded directly to blob storage start out pending and become
+-- ready once the client has confirmed the upload. Pending uploads that are
+-- never confirmed are removed by the purge job
+ALTER TABLE attachments ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'ready';
+
+CREATE INDEX IF NOT EXISTS idx_attachments_pending ON attachments (created_at) WHERE status = 'pending';
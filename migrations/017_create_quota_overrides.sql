@@ -0,0 +1,12 @@
+-- Per-user replacements of the quota limits from the configuration, a NULL
+-- limit keeps the configured one and 0 lifts the limit
+CREATE TABLE IF NOT EXISTS quota_overrides (
+    user_id               BIGINT PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    max_todos             INTEGER,
+    max_attachment_bytes  BIGINT,
+    max_api_calls_per_day INTEGER,
+    updated_at            TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Attachment usage is summed per user
+CREATE INDEX IF NOT EXISTS idx_attachments_user_id ON attachments (user_id);
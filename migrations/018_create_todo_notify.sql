@@ -0,0 +1,48 @@
+-- Announces every change of a todo on the todo_changes channel so the
+-- change feed sees writes made by other services sharing the database.
+-- origin is the application_name of the writer, the service ignores its own
+-- changes since the outbox already publishes them. Purging todos from the
+-- trash is not announced, their deletion was
+CREATE OR REPLACE FUNCTION notify_todo_change() RETURNS TRIGGER AS $$
+DECLARE
+    op   TEXT;
+    todo todos%ROWTYPE;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        op := 'created';
+        todo := NEW;
+    ELSIF TG_OP = 'DELETE' THEN
+        IF OLD.deleted_at IS NOT NULL THEN
+            RETURN NULL;
+        END IF;
+        op := 'deleted';
+        todo := OLD;
+    ELSIF OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN
+        op := 'deleted';
+        todo := NEW;
+    ELSIF OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN
+        op := 'restored';
+        todo := NEW;
+    ELSIF NEW.deleted_at IS NULL AND NEW IS DISTINCT FROM OLD THEN
+        op := 'updated';
+        todo := NEW;
+    ELSE
+        RETURN NULL;
+    END IF;
+
+    PERFORM pg_notify('todo_changes', json_build_object(
+        'op', op,
+        'id', todo.id,
+        'user_id', todo.user_id,
+        'hard_delete', TG_OP = 'DELETE',
+        'origin', current_setting('application_name', true)
+    )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS todos_notify_change ON todos;
+CREATE TRIGGER todos_notify_change
+    AFTER INSERT OR DELETE OR UPDATE ON todos
+    FOR EACH ROW
+    EXECUTE FUNCTION notify_todo_change();
@@ -0,0 +1,17 @@
+-- Log of authentication events such as logins, failed logins and lockouts.
+-- user_id has no foreign key so events outlive the accounts they describe
+-- and is NULL for failed logins with an unknown email
+CREATE TABLE IF NOT EXISTS security_events (
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     BIGINT,
+    type        VARCHAR(64) NOT NULL,
+    email       VARCHAR(255) NOT NULL DEFAULT '',
+    ip_address  VARCHAR(64) NOT NULL DEFAULT '',
+    user_agent  VARCHAR(255) NOT NULL DEFAULT '',
+    details     JSONB,
+    request_id  VARCHAR(128) NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_security_events_created_at ON security_events (created_at);
+CREATE INDEX IF NOT EXISTS idx_security_events_user_id ON security_events (user_id, created_at DESC);
@@ -0,0 +1,3 @@
+-- Role of each user, admins may use the /api/v1/admin endpoints. The first
+-- admin is created with "server user create --admin" or "server user promote"
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'user';
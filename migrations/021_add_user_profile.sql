@@ -0,0 +1,7 @@
+-- Profile details users manage themselves. The avatar is one of their image
+-- attachments and is removed with it. notification_preferences only holds
+-- the settings a user changed from the defaults, updates merge into it
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    ADD COLUMN IF NOT EXISTS avatar_attachment_id BIGINT REFERENCES attachments (id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS notification_preferences JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,7 @@
+-- Accounts their users deleted are erased together with all of their data
+-- once deletion_scheduled_at has passed, until then the deletion can be
+-- cancelled
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deletion_scheduled_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_users_deletion_scheduled_at ON users (deletion_scheduled_at)
+    WHERE deletion_scheduled_at IS NOT NULL;
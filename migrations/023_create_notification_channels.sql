@@ -0,0 +1,21 @@
+-- Where users receive the notifications they enabled in
+-- notification_preferences. Email goes to the account address and is on
+-- unless the user disabled it, webhook and Slack channels need a url.
+-- secret signs webhook requests like those of the todo webhooks
+CREATE TABLE IF NOT EXISTS notification_channels (
+    user_id    BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    channel    VARCHAR(16) NOT NULL,
+    url        VARCHAR(2048) NOT NULL DEFAULT '',
+    secret     VARCHAR(255) NOT NULL DEFAULT '',
+    enabled    BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, channel)
+);
+
+-- Time the due date reminder of a todo was sent, cleared when the due date
+-- changes so the new one is reminded of again
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS reminded_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_todos_due_reminders ON todos (due_date)
+    WHERE reminded_at IS NULL AND completed = FALSE AND deleted_at IS NULL;
@@ -0,0 +1,14 @@
+-- Users a todo is shared with and their role, viewer or editor. Sharing a
+-- todo shares its sub-tasks too. A share is an invitation until the user
+-- accepts it, accepted_at is then set
+CREATE TABLE IF NOT EXISTS todo_shares (
+    todo_id     BIGINT NOT NULL REFERENCES todos (id) ON DELETE CASCADE,
+    owner_id    BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    user_id     BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role        VARCHAR(16) NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    accepted_at TIMESTAMPTZ,
+    PRIMARY KEY (todo_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_todo_shares_user_id ON todo_shares (user_id);
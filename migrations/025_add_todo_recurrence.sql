@@ -0,0 +1,7 @@
+-- RFC 5545 recurrence rule of a repeating todo, such as FREQ=WEEKLY;BYDAY=MO,
+-- empty when it does not repeat. The rule starts at the due date and moves
+-- to the todo of the next occurrence once this one is completed or overdue
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS recurrence VARCHAR(255) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_todos_recurrences ON todos (due_date)
+    WHERE recurrence <> '' AND deleted_at IS NULL;
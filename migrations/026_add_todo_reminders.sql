@@ -0,0 +1,9 @@
+-- Time the user wants to be reminded of a todo. The reminders job queues
+-- the reminder as a delayed task shortly before and records it in
+-- reminder_queued_at, which is cleared when remind_at changes so the new
+-- time is queued again
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS remind_at TIMESTAMPTZ;
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS reminder_queued_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_todos_pending_reminders ON todos (remind_at)
+    WHERE reminder_queued_at IS NULL AND completed = FALSE AND deleted_at IS NULL;
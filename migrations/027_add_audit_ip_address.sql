@@ -0,0 +1,3 @@
+-- Address of the client whose request made the change, resolved from the
+-- forwarding headers of trusted proxies. Empty for changes made by jobs
+ALTER TABLE audit_events ADD COLUMN IF NOT EXISTS ip_address VARCHAR(64) NOT NULL DEFAULT '';
@@ -0,0 +1,7 @@
+-- Webhook and notification channel secrets are encrypted by the application
+-- when database.encryption is enabled, stored as "enc:<key version>:" and the
+-- base64 encoded nonce and AES-256-GCM ciphertext, which outgrows 255
+-- characters. Secrets stored before encryption was enabled are read as they
+-- are until "server encryption rotate" encrypts them with the active key
+ALTER TABLE webhooks ALTER COLUMN secret TYPE TEXT;
+ALTER TABLE notification_channels ALTER COLUMN secret TYPE TEXT;
@@ -0,0 +1,6 @@
+-- Device that last used a login session, so users can tell their sessions
+-- apart and sign out of the ones they do not recognize. Sessions created
+-- before this migration show no device until they are refreshed
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS ip_address VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS user_agent VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
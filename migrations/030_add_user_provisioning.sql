@@ -0,0 +1,7 @@
+-- Accounts provisioned over SCIM keep the id they have at the identity
+-- provider, which is unique among the provisioned accounts. Deactivated
+-- accounts cannot sign in and have their sessions revoked
+ALTER TABLE users ADD COLUMN IF NOT EXISTS external_id VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deactivated_at TIMESTAMPTZ;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_external_id ON users (external_id) WHERE external_id <> '';